@@ -0,0 +1,140 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"devbox/internal/config"
+	"devbox/internal/docker"
+)
+
+var adoptAllFlag bool
+
+var adoptCmd = &cobra.Command{
+	Use:   "adopt [box...]",
+	Short: "Track existing devbox boxes again from their labels",
+	Long: `Register boxes that exist in Docker but are missing from devbox's config, for example
+after the config file was lost or reset. devbox records the project name, workspace path,
+config hash, devbox version, and creating command as labels on every box it creates;
+adopt reads them back and adds the project entry. Boxes created before devbox recorded
+these labels can't be adopted.
+
+Examples:
+  devbox adopt devbox_web
+  devbox adopt --all`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if adoptAllFlag == (len(args) > 0) {
+			return fmt.Errorf("pass box names or --all")
+		}
+		cfg, err := configManager.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		boxes, err := dockerClient.ListBoxes()
+		if err != nil {
+			return fmt.Errorf("failed to list boxes: %w", err)
+		}
+		boxes = ownedBoxes(boxes)
+
+		byName := make(map[string]docker.BoxInfo)
+		for _, box := range boxes {
+			for _, name := range box.Names {
+				byName[strings.TrimPrefix(name, "/")] = box
+			}
+		}
+		var candidates []docker.BoxInfo
+		if adoptAllFlag {
+			tracked := make(map[string]bool)
+			for _, p := range cfg.GetProjects() {
+				tracked[p.BoxName] = true
+			}
+			for _, box := range boxes {
+				if box.Metadata.Known() && !tracked[strings.TrimPrefix(box.Names[0], "/")] {
+					candidates = append(candidates, box)
+				}
+			}
+			if len(candidates) == 0 {
+				fmt.Println("No untracked boxes with devbox labels found.")
+				return nil
+			}
+		}
+		for _, name := range args {
+			box, ok := byName[name]
+			if !ok {
+				return fmt.Errorf("box '%s' not found", name)
+			}
+			candidates = append(candidates, box)
+		}
+
+		var adopted, failed int
+		for _, box := range candidates {
+			project, err := adoptionFor(cfg, box)
+			if err != nil {
+				fmt.Printf("error: %v\n", err)
+				failed++
+				continue
+			}
+			cfg.AddProject(project)
+			adopted++
+			fmt.Printf("Adopted box '%s' as project '%s' (%s)\n", project.BoxName, project.Name, project.WorkspacePath)
+			if configChangedSinceCreate(box.Metadata, project.WorkspacePath) {
+				fmt.Printf("hint: devbox.json changed since the box was created; run 'devbox update %s' to recreate it\n", project.Name)
+			}
+		}
+		if adopted > 0 {
+			if err := configManager.Save(cfg); err != nil {
+				return fmt.Errorf("failed to save configuration: %w", err)
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("failed to adopt %d box(es)", failed)
+		}
+		return nil
+	},
+}
+
+func init() {
+	adoptCmd.Flags().BoolVar(&adoptAllFlag, "all", false, "Adopt every untracked box that has devbox labels")
+	adoptCmd.Flags().BoolVar(&allUsersFlag, "all-users", false, "Include boxes owned by other users")
+	rootCmd.AddCommand(adoptCmd)
+}
+
+func adoptionFor(cfg *config.Config, box docker.BoxInfo) (*config.Project, error) {
+	boxName := strings.TrimPrefix(box.Names[0], "/")
+	md := box.Metadata
+	if !md.Known() {
+		return nil, fmt.Errorf("box '%s' has no devbox project label (it was created by an older devbox)\nhint: remove it with 'devbox destroy --cleanup-orphaned', or run 'devbox init' in its workspace", boxName)
+	}
+	for _, p := range cfg.GetProjects() {
+		if p.BoxName == boxName {
+			return nil, fmt.Errorf("box '%s' is already tracked by project '%s'", boxName, p.Name)
+		}
+	}
+	if existing, ok := cfg.GetProject(md.Project); ok {
+		return nil, fmt.Errorf("box '%s' was created for project '%s', which already exists with box '%s'", boxName, md.Project, existing.BoxName)
+	}
+	if err := validateProjectName(md.Project); err != nil {
+		return nil, fmt.Errorf("box '%s' has an invalid project label: %w", boxName, err)
+	}
+	if md.Workspace == "" {
+		return nil, fmt.Errorf("box '%s' has no workspace label", boxName)
+	}
+	if info, err := os.Stat(md.Workspace); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("workspace %s for box '%s' no longer exists", md.Workspace, boxName)
+	}
+
+	project := &config.Project{
+		Name:          md.Project,
+		BoxName:       boxName,
+		BaseImage:     box.Image,
+		WorkspacePath: md.Workspace,
+		Status:        "running",
+	}
+	if pc, err := configManager.LoadProjectConfig(md.Workspace); err == nil {
+		cfg.MergeProjectConfig(project, pc)
+	}
+	return project, nil
+}
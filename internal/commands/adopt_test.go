@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"devbox/internal/config"
+	"devbox/internal/docker"
+)
+
+func TestAdoptionFor(t *testing.T) {
+	withTempConfigManager(t)
+
+	workspace := t.TempDir()
+	if err := os.WriteFile(filepath.Join(workspace, "devbox.json"), []byte(`{"name": "web", "base_image": "node:20"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{Projects: map[string]*config.Project{
+		"api": {Name: "api", BoxName: "devbox_api", WorkspacePath: "/src/api"},
+	}}
+	box := func(name string, md docker.BoxMetadata) docker.BoxInfo {
+		return docker.BoxInfo{Names: []string{name}, Image: "node:20", Metadata: md}
+	}
+
+	project, err := adoptionFor(cfg, box("devbox_web", docker.BoxMetadata{Project: "web", Workspace: workspace}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := config.Project{Name: "web", BoxName: "devbox_web", BaseImage: "node:20", WorkspacePath: workspace, Status: "running", ConfigFile: filepath.Join(workspace, "devbox.json")}
+	if *project != want {
+		t.Errorf("adoptionFor() = %+v, want %+v", *project, want)
+	}
+
+	tests := []struct {
+		name string
+		box  docker.BoxInfo
+		want string
+	}{
+		{"no labels", box("devbox_old", docker.BoxMetadata{}), "no devbox project label"},
+		{"tracked box", box("devbox_api", docker.BoxMetadata{Project: "api2", Workspace: workspace}), "already tracked by project 'api'"},
+		{"project exists", box("devbox_api2", docker.BoxMetadata{Project: "api", Workspace: workspace}), "which already exists with box 'devbox_api'"},
+		{"missing workspace", box("devbox_gone", docker.BoxMetadata{Project: "gone", Workspace: filepath.Join(workspace, "missing")}), "no longer exists"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := adoptionFor(cfg, tt.box); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("adoptionFor() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigChangedSinceCreate(t *testing.T) {
+	withTempConfigManager(t)
+
+	workspace := t.TempDir()
+	path := filepath.Join(workspace, "devbox.json")
+	if err := os.WriteFile(path, []byte(`{"name": "web", "ports": ["3000:3000"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	md := boxMetadataFunc("up")("devbox_web", workspace, nil)
+	if md.Project != "web" || md.Workspace != workspace || md.Source != "up" || md.Version != Version || md.ConfigHash == "" {
+		t.Fatalf("boxMetadataFunc() = %+v", md)
+	}
+	if configChangedSinceCreate(md, workspace) {
+		t.Error("configChangedSinceCreate() = true right after creation")
+	}
+	if err := os.WriteFile(path, []byte(`{"name": "web", "ports": ["8080:8080"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if !configChangedSinceCreate(md, workspace) {
+		t.Error("configChangedSinceCreate() = false after devbox.json changed")
+	}
+	if configChangedSinceCreate(docker.BoxMetadata{Project: "web"}, workspace) {
+		t.Error("configChangedSinceCreate() = true for a box without a config hash")
+	}
+}
@@ -1,27 +1,17 @@
 package commands
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/spf13/cobra"
 )
 
-type applyLockFile struct {
-	Version    int            `json:"version"`
-	Project    string         `json:"project"`
-	BoxName    string         `json:"box_name"`
-	Packages   lockPackages   `json:"packages"`
-	Registries lockRegistries `json:"registries"`
-	AptSources lockAptSources `json:"apt_sources"`
-}
-
 var applyCmd = &cobra.Command{
 	Use:   "apply <project>",
-	Short: "Apply devbox.lock.json: set registries and apt sources, then reconcile packages",
+	Short: "Apply devbox.lock.json: update box settings, set registries and apt sources, then reconcile packages",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		projectName := args[0]
@@ -36,99 +26,199 @@ var applyCmd = &cobra.Command{
 		}
 
 		lockPath := filepath.Join(proj.WorkspacePath, "devbox.lock.json")
-		data, err := os.ReadFile(lockPath)
+		lf, err := loadLockFile(lockPath)
 		if err != nil {
 			return fmt.Errorf("failed to read %s: %w", lockPath, err)
 		}
 
-		var lf applyLockFile
-		if err := json.Unmarshal(data, &lf); err != nil {
-			return fmt.Errorf("invalid lockfile: %w", err)
+		if err := ensureBoxRunning(proj.BoxName); err != nil {
+			return err
 		}
-
-		exists, err := dockerClient.BoxExists(proj.BoxName)
+		current, imageDrifted := lockImageDrift(proj.BoxName, lf)
+		if imageDrifted {
+			fmt.Printf("Warning: box '%s' runs %s, but devbox.lock.json pins %s; run 'devbox maintenance --rebuild' to recreate it from the pinned image\n", proj.BoxName, current, lockedImageRef(lf))
+		}
+		updated, err := reconcileContainer(proj.BoxName, projectName, lf.Container)
 		if err != nil {
 			return err
 		}
-		if !exists {
-			return fmt.Errorf("box '%s' not found; run 'devbox up %s' first", proj.BoxName, projectName)
-		}
-		status, err := dockerClient.GetBoxStatus(proj.BoxName)
+		summary, err := applyLockToBox(proj.BoxName, proj.WorkspacePath, lf)
 		if err != nil {
 			return err
 		}
-		if status != "running" {
-			if err := dockerClient.StartBox(proj.BoxName); err != nil {
-				return fmt.Errorf("failed to start box: %w", err)
-			}
+		summary.Container = updated
+
+		if !imageDrifted {
+			_ = WriteLockFileForBox(proj.BoxName, projectName, proj.WorkspacePath, proj.BaseImage, "")
 		}
 
-		var applyCmds []string
+		fmt.Printf("Applied lockfile: %s\n", summary)
+		return nil
+	},
+}
 
-		if len(lf.AptSources.SourcesLists) > 0 {
+func ensureBoxRunning(boxName string) error {
+	exists, err := dockerClient.BoxExists(boxName)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("box '%s' not found; run 'devbox up' first", boxName)
+	}
+	status, err := dockerClient.GetBoxStatus(boxName)
+	if err != nil {
+		return err
+	}
+	if status != "running" {
+		return startStoppedBox(boxName)
+	}
+	return nil
+}
 
-			heredoc := "cat > /etc/apt/sources.list <<'EOF'\n" + strings.Join(lf.AptSources.SourcesLists, "\n") + "\nEOF"
-			applyCmds = append(applyCmds,
-				"cp /etc/apt/sources.list /etc/apt/sources.list.bak 2>/dev/null || true",
-				"rm -f /etc/apt/sources.list.d/*.list 2>/dev/null || true",
-				heredoc,
-			)
-		}
-		if lf.AptSources.PinnedRelease != "" {
-			applyCmds = append(applyCmds, fmt.Sprintf("bash -lc 'echo APT::Default-Release \"%s\"; > /etc/apt/apt.conf.d/99defaultrelease'", escapeBash(lf.AptSources.PinnedRelease)))
-		}
-		if len(lf.AptSources.SourcesLists) > 0 {
-			applyCmds = append(applyCmds, "apt update -y")
-		}
+func lockSourceCommands(lf *lockFile) []string {
+	var cmds []string
 
-		if lf.Registries.PipIndexURL != "" || len(lf.Registries.PipExtraIndex) > 0 {
-			var b strings.Builder
-			b.WriteString("cat > /etc/pip.conf <<'EOF'\n[global]\n")
-			if lf.Registries.PipIndexURL != "" {
-				b.WriteString("index-url = ")
-				b.WriteString(lf.Registries.PipIndexURL)
-				b.WriteString("\n")
-			}
-			for _, u := range lf.Registries.PipExtraIndex {
-				if strings.TrimSpace(u) == "" {
-					continue
-				}
-				b.WriteString("extra-index-url = ")
-				b.WriteString(u)
-				b.WriteString("\n")
-			}
-			b.WriteString("EOF")
-			applyCmds = append(applyCmds, b.String())
-		}
+	if len(lf.AptSources.SourcesLists) > 0 {
 
-		if lf.Registries.NpmRegistry != "" {
-			applyCmds = append(applyCmds, fmt.Sprintf("npm config set registry %s -g", lf.Registries.NpmRegistry))
-		}
-		if lf.Registries.YarnRegistry != "" {
-			applyCmds = append(applyCmds, fmt.Sprintf("yarn config set npmRegistryServer %s -g", lf.Registries.YarnRegistry))
+		heredoc := "cat > /etc/apt/sources.list <<'EOF'\n" + strings.Join(lf.AptSources.SourcesLists, "\n") + "\nEOF"
+		cmds = append(cmds,
+			"cp /etc/apt/sources.list /etc/apt/sources.list.bak 2>/dev/null || true",
+			"rm -f /etc/apt/sources.list.d/*.list 2>/dev/null || true",
+			heredoc,
+		)
+	}
+	if lf.AptSources.PinnedRelease != "" {
+		cmds = append(cmds, fmt.Sprintf("bash -lc 'echo APT::Default-Release \"%s\"; > /etc/apt/apt.conf.d/99defaultrelease'", escapeBash(lf.AptSources.PinnedRelease)))
+	}
+	if len(lf.AptSources.SourcesLists) > 0 {
+		cmds = append(cmds, "apt update -y")
+	}
+
+	if lf.Registries.PipIndexURL != "" || len(lf.Registries.PipExtraIndex) > 0 {
+		var b strings.Builder
+		b.WriteString("cat > /etc/pip.conf <<'EOF'\n[global]\n")
+		if lf.Registries.PipIndexURL != "" {
+			b.WriteString("index-url = ")
+			b.WriteString(lf.Registries.PipIndexURL)
+			b.WriteString("\n")
 		}
-		if lf.Registries.PnpmRegistry != "" {
-			applyCmds = append(applyCmds, fmt.Sprintf("pnpm config set registry %s -g", lf.Registries.PnpmRegistry))
+		for _, u := range lf.Registries.PipExtraIndex {
+			if strings.TrimSpace(u) == "" {
+				continue
+			}
+			b.WriteString("extra-index-url = ")
+			b.WriteString(u)
+			b.WriteString("\n")
 		}
+		b.WriteString("EOF")
+		cmds = append(cmds, b.String())
+	}
 
-		if err := dockerClient.ExecuteSetupCommandsWithOutput(proj.BoxName, applyCmds, false); err != nil {
-			return fmt.Errorf("failed applying registries/sources: %w", err)
-		}
+	if lf.Registries.NpmRegistry != "" {
+		cmds = append(cmds, fmt.Sprintf("npm config set registry %s -g", lf.Registries.NpmRegistry))
+	}
+	if lf.Registries.YarnRegistry != "" {
+		cmds = append(cmds, fmt.Sprintf("yarn config set npmRegistryServer %s -g", lf.Registries.YarnRegistry))
+	}
+	if lf.Registries.PnpmRegistry != "" {
+		cmds = append(cmds, fmt.Sprintf("pnpm config set registry %s -g", lf.Registries.PnpmRegistry))
+	}
+	return cmds
+}
+
+type applySummary struct {
+	Installs   int
+	Removals   int
+	Registries []string
+	Container  int
+}
 
-		curApt, curPip, curNpm, curYarn, curPnpm := dockerClient.QueryPackagesParallel(proj.BoxName)
+func (s applySummary) String() string {
+	out := fmt.Sprintf("%d install(s), %d removal(s)", s.Installs, s.Removals)
+	if len(s.Registries) > 0 {
+		out += "; sources/registries: " + strings.Join(s.Registries, ", ")
+	} else {
+		out += "; no sources/registries changed"
+	}
+	if s.Container > 0 {
+		out += fmt.Sprintf("; %d container setting(s) updated", s.Container)
+	}
+	return out
+}
 
-		actions := buildReconcileActions(lf.Packages, curApt, curPip, curNpm, curYarn, curPnpm)
-		if len(actions) > 0 {
-			if err := dockerClient.ExecuteSetupCommandsWithOutput(proj.BoxName, actions, true); err != nil {
-				return fmt.Errorf("failed to reconcile packages: %w", err)
+func lockRegistriesTouched(lf *lockFile) []string {
+	var touched []string
+	if len(lf.AptSources.SourcesLists) > 0 || lf.AptSources.PinnedRelease != "" {
+		touched = append(touched, "apt")
+	}
+	if lf.Registries.PipIndexURL != "" || len(lf.Registries.PipExtraIndex) > 0 {
+		touched = append(touched, "pip")
+	}
+	if lf.Registries.NpmRegistry != "" {
+		touched = append(touched, "npm")
+	}
+	if lf.Registries.YarnRegistry != "" {
+		touched = append(touched, "yarn")
+	}
+	if lf.Registries.PnpmRegistry != "" {
+		touched = append(touched, "pnpm")
+	}
+	return touched
+}
+
+func summarizeReconcile(lockPkgs lockPackages, curApt, curPip, curNpm, curYarn, curPnpm []string) (installs, removals int) {
+	pairs := []struct {
+		lock, cur []string
+		sep       string
+	}{
+		{lockPkgs.Apt, curApt, "="},
+		{lockPkgs.Pip, curPip, "=="},
+		{lockPkgs.Npm, curNpm, "@"},
+		{lockPkgs.Yarn, curYarn, "@"},
+		{lockPkgs.Pnpm, curPnpm, "@"},
+	}
+	for _, p := range pairs {
+		lockM := parseMap(p.lock, p.sep)
+		curM := parseMap(p.cur, p.sep)
+		for name, ver := range lockM {
+			if curVer, ok := curM[name]; !ok || curVer != ver {
+				installs++
 			}
 		}
+		removals += len(keysNotIn(curM, lockM))
+	}
+	return installs, removals
+}
 
-		_ = WriteLockFileForBox(proj.BoxName, projectName, proj.WorkspacePath, proj.BaseImage, "")
+func applyLockToBox(boxName, workspacePath string, lf *lockFile) (*applySummary, error) {
+	defer dropPackageSnapshot(boxName)
+	summary := &applySummary{Registries: lockRegistriesTouched(lf)}
+	if err := dockerClient.ExecuteSetupCommandsWithOutput(boxName, lockSourceCommands(lf), false); err != nil {
+		return summary, fmt.Errorf("failed applying registries/sources: %w", err)
+	}
 
-		fmt.Println("Applied lockfile: registries/sources configured and packages reconciled")
-		return nil
-	},
+	curApt, curPip, curNpm, curYarn, curPnpm := dockerClient.QueryPackagesParallel(boxName)
+	summary.Installs, summary.Removals = summarizeReconcile(lf.Packages, curApt, curPip, curNpm, curYarn, curPnpm)
+	actions := buildReconcileActions(lf.Packages, curApt, curPip, curNpm, curYarn, curPnpm, len(lf.AptSources.SourcesLists) == 0)
+	managers := loadExtraPackageManagers()
+	for _, name := range untrackedPluginManagers(managers, lf.Packages.Extra) {
+		fmt.Printf("Warning: lockfile has %s packages but no plugin registers the '%s' package manager; skipping\n", name, name)
+	}
+	pluginActions, pluginInstalls, pluginRemovals := pluginReconcile(managers, lf.Packages.Extra, queryPluginPackages(boxName, managers))
+	actions = append(actions, pluginActions...)
+	summary.Installs += pluginInstalls
+	summary.Removals += pluginRemovals
+	if len(actions) > 0 {
+		fmt.Printf("Reconciling packages: %d install(s), %d removal(s)...\n", summary.Installs, summary.Removals)
+		if err := dockerClient.ExecuteSetupCommandsWithOutput(boxName, actions, true); err != nil {
+			return summary, fmt.Errorf("failed to reconcile packages: %w", err)
+		}
+	}
+
+	if err := replayLegacyLock(dockerClient, boxName, workspacePath); err != nil {
+		return summary, fmt.Errorf("failed to replay recorded commands: %w", err)
+	}
+	return summary, nil
 }
 
 func escapeBash(s string) string {
@@ -181,76 +271,89 @@ func keysNotIn(a, b map[string]string) []string {
 	return out
 }
 
-func buildReconcileActions(lockPkgs lockPackages, curApt, curPip, curNpm, curYarn, curPnpm []string) []string {
+func buildReconcileActions(lockPkgs lockPackages, curApt, curPip, curNpm, curYarn, curPnpm []string, refreshApt bool) []string {
 	var cmds []string
-
-	lockA := parseMap(lockPkgs.Apt, "=")
-	curA := parseMap(curApt, "=")
-	lockP := parseMap(lockPkgs.Pip, "==")
-	curP := parseMap(curPip, "==")
-	lockN := parseMap(lockPkgs.Npm, "@")
-	curN := parseMap(curNpm, "@")
-	lockY := parseMap(lockPkgs.Yarn, "@")
-	curY := parseMap(curYarn, "@")
-	lockQ := parseMap(lockPkgs.Pnpm, "@")
-	curQ := parseMap(curPnpm, "@")
-
-	var aptInstall []string
-	for name, ver := range lockA {
-		if curVer, ok := curA[name]; !ok || curVer != ver {
-			aptInstall = append(aptInstall, fmt.Sprintf("%s=%s", name, ver))
-		}
+	cmds = append(cmds, aptReconcileActions(parseMap(lockPkgs.Apt, "="), parseMap(curApt, "="), refreshApt)...)
+
+	managers := []struct {
+		lock, cur          []string
+		sep                string
+		install, uninstall string
+	}{
+		{lockPkgs.Pip, curPip, "==", "python3 -m pip install", "python3 -m pip uninstall -y"},
+		{lockPkgs.Npm, curNpm, "@", "npm i -g", "npm rm -g"},
+		{lockPkgs.Yarn, curYarn, "@", "yarn global add", "yarn global remove"},
+		{lockPkgs.Pnpm, curPnpm, "@", "pnpm add -g", "pnpm remove -g"},
 	}
-	if len(aptInstall) > 0 {
-		cmds = append(cmds, "apt update -y", "DEBIAN_FRONTEND=noninteractive apt-get install -y "+strings.Join(aptInstall, " "))
+	for _, m := range managers {
+		lockM := parseMap(m.lock, m.sep)
+		curM := parseMap(m.cur, m.sep)
+		var install []string
+		for _, name := range sortedKeys(lockM) {
+			if curVer, ok := curM[name]; !ok || curVer != lockM[name] {
+				install = append(install, name+m.sep+lockM[name])
+			}
+		}
+		if len(install) > 0 {
+			cmds = append(cmds, m.install+" "+strings.Join(install, " "))
+		}
+		extras := keysNotIn(curM, lockM)
+		sort.Strings(extras)
+		if len(extras) > 0 {
+			cmds = append(cmds, m.uninstall+" "+strings.Join(extras, " "))
+		}
 	}
+	return cmds
+}
 
-	for _, extra := range keysNotIn(curA, lockA) {
-		cmds = append(cmds, fmt.Sprintf("apt-get remove -y %s", extra))
-	}
-	if len(keysNotIn(curA, lockA)) > 0 {
-		cmds = append(cmds, "apt-get autoremove -y")
-	}
+const aptLockPinFile = "/etc/apt/preferences.d/devbox-lock"
 
-	for name, ver := range lockP {
-		if curVer, ok := curP[name]; !ok || curVer != ver {
-			cmds = append(cmds, fmt.Sprintf("python3 -m pip install %s==%s", name, ver))
-		}
-	}
-	for _, extra := range keysNotIn(curP, lockP) {
-		cmds = append(cmds, fmt.Sprintf("python3 -m pip uninstall -y %s", extra))
-	}
+const aptGetNonInteractive = "DEBIAN_FRONTEND=noninteractive apt-get -y -o Dpkg::Options::=--force-confdef -o Dpkg::Options::=--force-confold"
 
-	for name, ver := range lockN {
-		if curVer, ok := curN[name]; !ok || curVer != ver {
-			cmds = append(cmds, fmt.Sprintf("npm i -g %s@%s", name, ver))
-		}
-	}
-	for _, extra := range keysNotIn(curN, lockN) {
-		cmds = append(cmds, fmt.Sprintf("npm rm -g %s", extra))
+func aptReconcileActions(lockA, curA map[string]string, refresh bool) []string {
+	var cmds []string
+
+	extras := keysNotIn(curA, lockA)
+	sort.Strings(extras)
+	if len(extras) > 0 {
+		cmds = append(cmds, aptGetNonInteractive+" remove --autoremove "+strings.Join(extras, " "))
 	}
 
-	for name, ver := range lockY {
-		if curVer, ok := curY[name]; !ok || curVer != ver {
-			cmds = append(cmds, fmt.Sprintf("yarn global add %s@%s", name, ver))
+	var install []string
+	downgrade := false
+	var pins strings.Builder
+	for _, name := range sortedKeys(lockA) {
+		ver := lockA[name]
+		fmt.Fprintf(&pins, "Package: %s\nPin: version %s\nPin-Priority: 1001\n\n", name, ver)
+		curVer, ok := curA[name]
+		if ok && curVer == ver {
+			continue
 		}
+		if ok && compareDebianVersions(ver, curVer) < 0 {
+			downgrade = true
+		}
+		install = append(install, fmt.Sprintf("%s=%s", name, ver))
 	}
-	for _, extra := range keysNotIn(curY, lockY) {
-		cmds = append(cmds, fmt.Sprintf("yarn global remove %s", extra))
+	if len(install) == 0 {
+		return cmds
 	}
 
-	for name, ver := range lockQ {
-		if curVer, ok := curQ[name]; !ok || curVer != ver {
-			cmds = append(cmds, fmt.Sprintf("pnpm add -g %s@%s", name, ver))
-		}
+	steps := []string{
+		"mkdir -p /etc/apt/preferences.d",
+		"printf '%s' '" + escapeBash(strings.TrimRight(pins.String(), "\n")+"\n") + "' > " + aptLockPinFile,
 	}
-	for _, extra := range keysNotIn(curQ, lockQ) {
-		cmds = append(cmds, fmt.Sprintf("pnpm remove -g %s", extra))
+	if refresh {
+		steps = append(steps, "apt-get update -y")
 	}
-
-	return cmds
+	installCmd := aptGetNonInteractive + " install"
+	if downgrade {
+		installCmd += " --allow-downgrades"
+	}
+	steps = append(steps, installCmd+" "+strings.Join(install, " "))
+	return append(cmds, strings.Join(steps, " && "))
 }
 
 func init() {
 	rootCmd.AddCommand(applyCmd)
+	applyCmd.RunE = withResultMetric("apply", applyCmd.RunE)
 }
@@ -0,0 +1,116 @@
+package commands
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompareDebianVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.0", "1.0", 0},
+		{"1.0", "1.1", -1},
+		{"1.10", "1.9", 1},
+		{"1:1.0", "2.0", 1},
+		{"1.0~rc1", "1.0", -1},
+		{"1.0-1", "1.0-2", -1},
+		{"7.81.0-1ubuntu1.15", "7.81.0-1ubuntu1.4", 1},
+		{"2.34.1-1ubuntu1.10", "2.34.1-1ubuntu1.9", 1},
+		{"1.0a", "1.0", 1},
+		{"1.0+dfsg", "1.0", 1},
+	}
+	for _, tt := range tests {
+		if got := compareDebianVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareDebianVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestAptReconcileActionsOrderAndDowngrade(t *testing.T) {
+	lock := map[string]string{"curl": "7.81.0-1ubuntu1.4", "git": "1:2.34.1-1"}
+	cur := map[string]string{"curl": "7.81.0-1ubuntu1.15", "git": "1:2.34.1-1", "nano": "6.2-1"}
+
+	cmds := aptReconcileActions(lock, cur, true)
+	if len(cmds) != 2 {
+		t.Fatalf("expected 2 commands, got %d: %q", len(cmds), cmds)
+	}
+	if !strings.Contains(cmds[0], "apt-get -y -o Dpkg::Options::=--force-confdef -o Dpkg::Options::=--force-confold remove --autoremove nano") {
+		t.Errorf("removals should come first in one non-interactive call, got %q", cmds[0])
+	}
+	install := cmds[1]
+	if !strings.Contains(install, "Pin: version 7.81.0-1ubuntu1.4") || !strings.Contains(install, "Package: git") {
+		t.Errorf("expected pin file for locked packages, got %q", install)
+	}
+	if strings.Count(install, "apt-get update") != 1 {
+		t.Errorf("expected exactly one apt-get update, got %q", install)
+	}
+	if !strings.Contains(install, "--allow-downgrades") || !strings.HasSuffix(install, "curl=7.81.0-1ubuntu1.4") {
+		t.Errorf("expected downgrade install of curl only, got %q", install)
+	}
+
+	cmds = aptReconcileActions(lock, cur, false)
+	if strings.Contains(strings.Join(cmds, "\n"), "apt-get update") {
+		t.Errorf("apt-get update should be skipped when sources were just refreshed, got %q", cmds)
+	}
+}
+
+func TestAptReconcileActionsNoChanges(t *testing.T) {
+	lock := map[string]string{"git": "1:2.34.1-1"}
+	if cmds := aptReconcileActions(lock, lock, true); len(cmds) != 0 {
+		t.Errorf("expected no actions, got %q", cmds)
+	}
+}
+
+func TestBuildReconcileActionsBatches(t *testing.T) {
+	lock := lockPackages{
+		Pip: []string{"requests==2.31.0", "flask==3.0.3", "rich==13.7.1"},
+		Npm: []string{"typescript@5.4.5", "@angular/cli@17.3.0"},
+	}
+	curPip := []string{"requests==2.31.0", "flask==3.0.0", "ipdb==0.13.13", "black==24.4.2"}
+	curNpm := []string{"typescript@5.4.5", "eslint@9.0.0"}
+
+	got := buildReconcileActions(lock, nil, curPip, curNpm, nil, nil, true)
+	want := []string{
+		"python3 -m pip install flask==3.0.3 rich==13.7.1",
+		"python3 -m pip uninstall -y black ipdb",
+		"npm i -g @angular/cli@17.3.0",
+		"npm rm -g eslint",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("buildReconcileActions() = %q, want %q", got, want)
+	}
+}
+
+func TestSummarizeReconcile(t *testing.T) {
+	lock := lockPackages{
+		Apt: []string{"curl=7.81.0-1ubuntu1.4", "git=1:2.34.1-1"},
+		Pip: []string{"requests==2.31.0"},
+		Npm: []string{"typescript@5.4.5"},
+	}
+	curApt := []string{"curl=7.81.0-1ubuntu1.15", "git=1:2.34.1-1", "nano=6.2-1"}
+	curPip := []string{"requests==2.31.0", "flask==3.0.0"}
+
+	installs, removals := summarizeReconcile(lock, curApt, curPip, nil, nil, nil)
+	if installs != 2 {
+		t.Errorf("installs = %d, want 2", installs)
+	}
+	if removals != 2 {
+		t.Errorf("removals = %d, want 2", removals)
+	}
+}
+
+func TestLockRegistriesTouched(t *testing.T) {
+	lf := &lockFile{}
+	lf.Registries.PipIndexURL = "https://pypi.example.com/simple"
+	lf.Registries.NpmRegistry = "https://npm.example.com"
+	got := strings.Join(lockRegistriesTouched(lf), ",")
+	if got != "pip,npm" {
+		t.Errorf("lockRegistriesTouched = %q, want %q", got, "pip,npm")
+	}
+	if !strings.Contains(applySummary{Installs: 1}.String(), "no sources/registries changed") {
+		t.Errorf("summary without registries should say so")
+	}
+}
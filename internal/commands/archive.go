@@ -0,0 +1,151 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const projectStatusArchived = "archived"
+
+var (
+	archiveEncrypt        bool
+	archiveRecipients     []string
+	archivePassphraseFile string
+	archiveSquash         bool
+	unarchiveIdentity     string
+	unarchivePassphrase   string
+)
+
+var archiveCmd = &cobra.Command{
+	Use:   "archive <project>",
+	Short: "Back up a project's box and remove its container to free resources",
+	Long: `Back up a project's box into its workspace, remove the container and its image,
+and mark the project as archived. The workspace and project entry are kept;
+bring the box back with 'devbox unarchive <project>'.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projectName := args[0]
+		if err := validateProjectName(projectName); err != nil {
+			return err
+		}
+
+		cfg, err := configManager.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		proj, ok := cfg.GetProject(projectName)
+		if !ok {
+			return fmt.Errorf("project '%s' not found", projectName)
+		}
+		if proj.Status == projectStatusArchived {
+			return fmt.Errorf("project '%s' is already archived at %s", projectName, proj.ArchivePath)
+		}
+
+		exists, err := dockerClient.BoxExists(proj.BoxName)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("box '%s' does not exist", proj.BoxName)
+		}
+
+		enc, err := resolveBackupEncryption(cfg, archiveEncrypt, archiveRecipients, archivePassphraseFile)
+		if err != nil {
+			return err
+		}
+
+		ts := time.Now().UTC().Format("20060102-150405")
+		if err := ensureWorkspaceDataDir(proj.WorkspacePath); err != nil {
+			return err
+		}
+		outDir := filepath.Join(backupsDir(proj.WorkspacePath), "archive-"+ts)
+		imageTag := fmt.Sprintf("devbox/%s:archive-%s", projectName, ts)
+		if _, err := writeLocalBackup(proj.BoxName, outDir, imageTag, newBackupManifest(proj), enc, archiveSquash); err != nil {
+			_ = os.RemoveAll(outDir)
+			return err
+		}
+
+		fmt.Printf("Removing box '%s'...\n", proj.BoxName)
+		_ = dockerClient.StopBox(proj.BoxName)
+		if err := dockerClient.RemoveBox(proj.BoxName); err != nil {
+			return fmt.Errorf("failed to remove box: %w", err)
+		}
+		if err := dockerClient.RemoveImage(imageTag); err != nil {
+			fmt.Printf("Warning: failed to remove archive image %s: %v\n", imageTag, err)
+		}
+
+		proj.Status = projectStatusArchived
+		proj.ArchivePath = outDir
+		if err := configManager.Save(cfg); err != nil {
+			return fmt.Errorf("failed to save configuration: %w", err)
+		}
+
+		fmt.Printf("Project '%s' archived.\n", projectName)
+		fmt.Printf("Archive: %s\n", outDir)
+		fmt.Printf("Restore with: devbox unarchive %s\n", projectName)
+		return nil
+	},
+}
+
+var unarchiveCmd = &cobra.Command{
+	Use:   "unarchive <project>",
+	Short: "Recreate an archived project's box from its archive",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projectName := args[0]
+		if err := validateProjectName(projectName); err != nil {
+			return err
+		}
+
+		cfg, err := configManager.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		proj, ok := cfg.GetProject(projectName)
+		if !ok {
+			return fmt.Errorf("project '%s' not found", projectName)
+		}
+		if proj.Status != projectStatusArchived || strings.TrimSpace(proj.ArchivePath) == "" {
+			return fmt.Errorf("project '%s' is not archived", projectName)
+		}
+
+		requireEncrypted := cfg.Settings != nil && cfg.Settings.RequireEncryptedBackups
+		manifest, imageRef, cleanup, err := loadBackupDir(proj.ArchivePath, requireEncrypted, unarchiveIdentity, unarchivePassphrase)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+
+		if _, err := recreateBoxFromBackup(cfg, proj, manifest, imageRef); err != nil {
+			return err
+		}
+
+		archivePath := proj.ArchivePath
+		proj.Status = "running"
+		proj.ArchivePath = ""
+		if err := configManager.Save(cfg); err != nil {
+			return fmt.Errorf("failed to save configuration: %w", err)
+		}
+
+		fmt.Printf("Project '%s' unarchived. Box '%s' is running.\n", projectName, proj.BoxName)
+		fmt.Printf("The archive is kept at %s; delete it once you no longer need it.\n", archivePath)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(archiveCmd)
+	rootCmd.AddCommand(unarchiveCmd)
+	archiveCmd.Flags().BoolVar(&archiveEncrypt, "encrypt", false, "Encrypt the archive with a passphrase (DEVBOX_BACKUP_PASSPHRASE or --passphrase-file)")
+	archiveCmd.Flags().StringArrayVar(&archiveRecipients, "recipient", nil, "Encrypt the archive to an age recipient (repeatable; requires 'age')")
+	archiveCmd.Flags().StringVar(&archivePassphraseFile, "passphrase-file", "", "Read the encryption passphrase from this file")
+	archiveCmd.Flags().BoolVar(&archiveSquash, "squash", false, "Flatten the box image into a single layer to shrink the archive")
+	unarchiveCmd.Flags().BoolVarP(&forceFlag, "force", "f", false, "Recreate the box even when host resources look insufficient")
+	unarchiveCmd.Flags().StringVar(&unarchiveIdentity, "identity", "", "age identity file for archives encrypted with --recipient (default: DEVBOX_AGE_IDENTITY)")
+	unarchiveCmd.Flags().StringVar(&unarchivePassphrase, "passphrase-file", "", "Read the decryption passphrase from this file (default: DEVBOX_BACKUP_PASSPHRASE)")
+}
@@ -0,0 +1,73 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	autoStartAlways = "always"
+	autoStartPrompt = "prompt"
+	autoStartNever  = "never"
+)
+
+var noStartFlag bool
+
+func autoStartPolicy() string {
+	cfg, err := configManager.Load()
+	if err != nil || cfg.Settings == nil {
+		return autoStartAlways
+	}
+	switch policy := strings.ToLower(strings.TrimSpace(cfg.Settings.AutoStart)); policy {
+	case autoStartPrompt, autoStartNever:
+		return policy
+	case "", autoStartAlways:
+		return autoStartAlways
+	default:
+		fmt.Printf("Warning: unknown settings.auto_start value %q; using %q\n", cfg.Settings.AutoStart, autoStartAlways)
+		return autoStartAlways
+	}
+}
+
+func stdinIsTerminal() bool {
+	fi, err := os.Stdin.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+func confirmAutoStart(boxName string) error {
+	if noStartFlag {
+		return fmt.Errorf("box '%s' is stopped and --no-start was given", boxName)
+	}
+	switch autoStartPolicy() {
+	case autoStartNever:
+		return fmt.Errorf("box '%s' is stopped and settings.auto_start is %q; start it with 'devbox up' from the project folder", boxName, autoStartNever)
+	case autoStartPrompt:
+		if !stdinIsTerminal() {
+			return fmt.Errorf("box '%s' is stopped and settings.auto_start is %q, but stdin is not a terminal", boxName, autoStartPrompt)
+		}
+		fmt.Printf("Box '%s' is stopped. Start it? (y/N): ", boxName)
+		reader := bufio.NewReader(os.Stdin)
+		response, err := reader.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("failed to read confirmation: %w", err)
+		}
+		response = strings.ToLower(strings.TrimSpace(response))
+		if response != "y" && response != "yes" {
+			return fmt.Errorf("box '%s' was not started", boxName)
+		}
+	}
+	return nil
+}
+
+func startStoppedBox(boxName string) error {
+	if err := confirmAutoStart(boxName); err != nil {
+		return err
+	}
+	fmt.Printf("Starting box '%s'...\n", boxName)
+	if err := dockerClient.StartBox(boxName); err != nil {
+		return fmt.Errorf("failed to start box '%s': %w", boxName, err)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConfirmAutoStart(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  string
+		noStart bool
+		wantErr string
+	}{
+		{name: "default starts", policy: ""},
+		{name: "always starts", policy: "always"},
+		{name: "unknown falls back to always", policy: "sometimes"},
+		{name: "never refuses", policy: "never", wantErr: "devbox up"},
+		{name: "no-start flag wins", policy: "always", noStart: true, wantErr: "--no-start"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cm := withTempConfigManager(t)
+			prevFlag := noStartFlag
+			noStartFlag = tt.noStart
+			defer func() { noStartFlag = prevFlag }()
+
+			cfg, _ := cm.Load()
+			cfg.Settings.AutoStart = tt.policy
+			if err := cm.Save(cfg); err != nil {
+				t.Fatal(err)
+			}
+
+			err := confirmAutoStart("devbox_demo")
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("confirmAutoStart() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("confirmAutoStart() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
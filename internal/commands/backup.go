@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -23,8 +24,15 @@ type backupManifest struct {
 	LockFileJSON json.RawMessage       `json:"lock_file_json,omitempty"`
 }
 
+const backupManifestLabel = "devbox.backup.manifest"
+
 var (
-	backupOutput string
+	backupOutput         string
+	backupToRegistry     string
+	backupEncrypt        bool
+	backupRecipients     []string
+	backupPassphraseFile string
+	backupSquash         bool
 )
 
 var backupCmd = &cobra.Command{
@@ -51,63 +59,171 @@ var backupCmd = &cobra.Command{
 			return fmt.Errorf("box '%s' does not exist", proj.BoxName)
 		}
 
-		ts := time.Now().UTC().Format("20060102-150405")
-		defaultDir := filepath.Join(proj.WorkspacePath, ".devbox_backups", ts)
-		outDir := backupOutput
-		if strings.TrimSpace(outDir) == "" {
-			outDir = defaultDir
-		}
-		if err := os.MkdirAll(outDir, 0755); err != nil {
-			return fmt.Errorf("failed to create backup directory: %w", err)
+		if cfg.Settings != nil && cfg.Settings.RequireEncryptedBackups && strings.TrimSpace(backupToRegistry) != "" {
+			return fmt.Errorf("settings.require_encrypted_backups is enabled; registry backups are not encrypted")
 		}
-
-		imageTag := fmt.Sprintf("devbox/%s:backup-%s", projectName, ts)
-		fmt.Printf("Creating image from box '%s'...\n", proj.BoxName)
-		imgID, err := dockerClient.CommitContainer(proj.BoxName, imageTag)
+		enc, err := resolveBackupEncryption(cfg, backupEncrypt, backupRecipients, backupPassphraseFile)
 		if err != nil {
-			return fmt.Errorf("failed to commit container: %w", err)
+			return err
 		}
-		_ = imgID
-
-		imageTar := filepath.Join(outDir, "image.tar")
-		fmt.Printf("Saving image '%s' to %s...\n", imageTag, imageTar)
-		if err := dockerClient.SaveImage(imageTag, imageTar); err != nil {
-			return fmt.Errorf("failed to save image: %w", err)
+		if enc != nil && strings.TrimSpace(backupToRegistry) != "" {
+			return fmt.Errorf("--encrypt and --recipient cannot be combined with --to-registry")
 		}
 
-		var pcfg *config.ProjectConfig
-		if c, err := configManager.LoadProjectConfig(proj.WorkspacePath); err == nil {
-			pcfg = c
-		}
-		var lockRaw json.RawMessage
-		if b, err := os.ReadFile(filepath.Join(proj.WorkspacePath, "devbox.lock.json")); err == nil {
-			lockRaw = json.RawMessage(b)
+		manifest := newBackupManifest(proj)
+		if ref := strings.TrimSpace(backupToRegistry); ref != "" {
+			return backupToRegistryRef(proj.BoxName, ref, manifest, backupSquash)
 		}
 
-		manifest := backupManifest{
-			Version:      1,
-			Project:      proj.Name,
-			BoxName:      proj.BoxName,
-			CreatedAt:    time.Now().UTC().Format(time.RFC3339),
-			ImageTag:     imageTag,
-			DevboxConfig: pcfg,
-			LockFileJSON: lockRaw,
+		ts := time.Now().UTC().Format("20060102-150405")
+		outDir := backupOutput
+		if strings.TrimSpace(outDir) == "" {
+			if err := ensureWorkspaceDataDir(proj.WorkspacePath); err != nil {
+				return err
+			}
+			outDir = filepath.Join(backupsDir(proj.WorkspacePath), ts)
 		}
-		manPath := filepath.Join(outDir, "metadata.json")
-		b, _ := json.MarshalIndent(manifest, "", "  ")
-		if err := os.WriteFile(manPath, b, 0644); err != nil {
-			return fmt.Errorf("failed to write metadata: %w", err)
+		imageTag := fmt.Sprintf("devbox/%s:backup-%s", projectName, ts)
+		files, err := writeLocalBackup(proj.BoxName, outDir, imageTag, manifest, enc, backupSquash)
+		if err != nil {
+			return err
 		}
 
 		fmt.Printf("Backup complete\n")
 		fmt.Printf("Directory: %s\n", outDir)
 		fmt.Printf("Image tag: %s\n", imageTag)
-		fmt.Printf("Files: image.tar, metadata.json\n")
+		fmt.Printf("Files: %s, %s\n", filepath.Base(files[0]), filepath.Base(files[1]))
 		return nil
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(backupCmd)
-	backupCmd.Flags().StringVarP(&backupOutput, "output", "o", "", "Output directory for backup (default: <workspace>/.devbox_backups/<timestamp>)")
+	backupCmd.Flags().StringVarP(&backupOutput, "output", "o", "", "Output directory for backup (default: <workspace>/.devbox/backups/<timestamp>)")
+	backupCmd.Flags().StringVar(&backupToRegistry, "to-registry", "", "Push the backup to an OCI registry image reference instead of a local directory")
+	backupCmd.Flags().BoolVar(&backupEncrypt, "encrypt", false, "Encrypt image.tar and metadata.json with a passphrase (DEVBOX_BACKUP_PASSPHRASE or --passphrase-file)")
+	backupCmd.Flags().StringArrayVar(&backupRecipients, "recipient", nil, "Encrypt to an age recipient public key or recipients file (repeatable; requires 'age')")
+	backupCmd.Flags().StringVar(&backupPassphraseFile, "passphrase-file", "", "Read the encryption passphrase from this file")
+	backupCmd.Flags().BoolVar(&backupSquash, "squash", false, "Flatten the box image into a single layer before saving or pushing it")
+	backupCmd.MarkFlagsMutuallyExclusive("output", "to-registry")
+}
+
+type backupEncryption struct {
+	Recipients []string
+	Passphrase string
+}
+
+func resolveBackupEncryption(cfg *config.Config, encrypt bool, recipients []string, passphraseFile string) (*backupEncryption, error) {
+	encrypt = encrypt || len(recipients) > 0
+	if !encrypt {
+		if cfg.Settings != nil && cfg.Settings.RequireEncryptedBackups {
+			return nil, fmt.Errorf("settings.require_encrypted_backups is enabled; pass --encrypt or --recipient")
+		}
+		return nil, nil
+	}
+	if len(recipients) > 0 {
+		return &backupEncryption{Recipients: recipients}, nil
+	}
+	passphrase, err := backupPassphrase(passphraseFile)
+	if err != nil {
+		return nil, err
+	}
+	return &backupEncryption{Passphrase: passphrase}, nil
+}
+
+func newBackupManifest(proj *config.Project) backupManifest {
+	var pcfg *config.ProjectConfig
+	if c, err := configManager.LoadProjectConfig(proj.WorkspacePath); err == nil {
+		pcfg = c
+	}
+	var lockRaw json.RawMessage
+	if b, err := os.ReadFile(filepath.Join(proj.WorkspacePath, "devbox.lock.json")); err == nil {
+		lockRaw = json.RawMessage(b)
+	}
+	return backupManifest{
+		Version:      1,
+		Project:      proj.Name,
+		BoxName:      proj.BoxName,
+		CreatedAt:    time.Now().UTC().Format(time.RFC3339),
+		DevboxConfig: pcfg,
+		LockFileJSON: lockRaw,
+	}
+}
+
+func writeLocalBackup(boxName, outDir, imageTag string, manifest backupManifest, enc *backupEncryption, squash bool) ([]string, error) {
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	fmt.Printf("Creating image from box '%s'...\n", boxName)
+	if err := commitBoxImage(boxName, imageTag, nil, squash); err != nil {
+		return nil, err
+	}
+
+	imageTar := filepath.Join(outDir, "image.tar")
+	fmt.Printf("Saving image '%s' to %s...\n", imageTag, imageTar)
+	if err := dockerClient.SaveImage(imageTag, imageTar); err != nil {
+		return nil, fmt.Errorf("failed to save image: %w", err)
+	}
+
+	manifest.ImageTag = imageTag
+	manPath := filepath.Join(outDir, "metadata.json")
+	b, _ := json.MarshalIndent(manifest, "", "  ")
+	if err := os.WriteFile(manPath, b, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write metadata: %w", err)
+	}
+
+	files := []string{imageTar, manPath}
+	if enc != nil {
+		fmt.Printf("Encrypting backup files...\n")
+		for i, f := range files {
+			out, err := encryptBackupFile(f, enc.Recipients, enc.Passphrase)
+			if err != nil {
+				return nil, err
+			}
+			files[i] = out
+		}
+	}
+	return files, nil
+}
+
+func backupToRegistryRef(boxName, ref string, manifest backupManifest, squash bool) error {
+	manifest.ImageTag = ref
+	label, err := encodeBackupManifestLabel(manifest)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Creating image '%s' from box '%s'...\n", ref, boxName)
+	if err := commitBoxImage(boxName, ref, map[string]string{backupManifestLabel: label}, squash); err != nil {
+		return err
+	}
+	if err := dockerClient.PushImage(ref); err != nil {
+		return err
+	}
+
+	fmt.Printf("Backup complete\n")
+	fmt.Printf("Registry image: %s\n", ref)
+	fmt.Printf("Restore with: devbox restore %s --from-registry %s\n", manifest.Project, ref)
+	return nil
+}
+
+func encodeBackupManifestLabel(manifest backupManifest) (string, error) {
+	b, err := json.Marshal(manifest)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode backup metadata: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+func decodeBackupManifestLabel(label string) (*backupManifest, error) {
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(label))
+	if err != nil {
+		return nil, fmt.Errorf("invalid backup metadata label: %w", err)
+	}
+	var manifest backupManifest
+	if err := json.Unmarshal(b, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse backup metadata label: %w", err)
+	}
+	return &manifest, nil
 }
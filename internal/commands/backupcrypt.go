@@ -0,0 +1,294 @@
+package commands
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	encryptedSuffix      = ".enc"
+	ageSuffix            = ".age"
+	backupCryptMagic     = "DEVBOXENC1"
+	backupCryptChunkSize = 64 * 1024
+	backupCryptSaltSize  = 16
+	backupCryptKDFRounds = 200000
+)
+
+var errBackupDecrypt = errors.New("failed to decrypt backup (wrong passphrase or corrupted file)")
+
+func backupPassphrase(passphraseFile string) (string, error) {
+	if strings.TrimSpace(passphraseFile) != "" {
+		data, err := os.ReadFile(passphraseFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read passphrase file: %w", err)
+		}
+		if p := strings.TrimRight(string(data), "\r\n"); p != "" {
+			return p, nil
+		}
+		return "", fmt.Errorf("passphrase file %s is empty", passphraseFile)
+	}
+	if p := os.Getenv("DEVBOX_BACKUP_PASSPHRASE"); p != "" {
+		return p, nil
+	}
+	return "", fmt.Errorf("no backup passphrase: set DEVBOX_BACKUP_PASSPHRASE, pass --passphrase-file, or use --recipient")
+}
+
+func pbkdf2SHA256(password, salt []byte, rounds, keyLen int) []byte {
+	prf := hmac.New(sha256.New, password)
+	var out []byte
+	for block := uint32(1); len(out) < keyLen; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		var idx [4]byte
+		binary.BigEndian.PutUint32(idx[:], block)
+		prf.Write(idx[:])
+		u := prf.Sum(nil)
+		t := append([]byte{}, u...)
+		for i := 1; i < rounds; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		out = append(out, t...)
+	}
+	return out[:keyLen]
+}
+
+func newBackupAEAD(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key := pbkdf2SHA256([]byte(passphrase), salt, backupCryptKDFRounds, 32)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func chunkNonce(prefix []byte, counter uint32, size int) []byte {
+	nonce := make([]byte, size)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[size-4:], counter)
+	return nonce
+}
+
+func encryptStream(dst io.Writer, src io.Reader, passphrase string) error {
+	salt := make([]byte, backupCryptSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	aead, err := newBackupAEAD(passphrase, salt)
+	if err != nil {
+		return err
+	}
+	prefix := make([]byte, aead.NonceSize()-4)
+	if _, err := rand.Read(prefix); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(dst, backupCryptMagic); err != nil {
+		return err
+	}
+	if _, err := dst.Write(append(salt, prefix...)); err != nil {
+		return err
+	}
+
+	buf := make([]byte, backupCryptChunkSize)
+	next := make([]byte, backupCryptChunkSize)
+	n, err := io.ReadFull(src, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return err
+	}
+	for counter := uint32(0); ; counter++ {
+		m, rerr := io.ReadFull(src, next)
+		if rerr != nil && rerr != io.ErrUnexpectedEOF && rerr != io.EOF {
+			return rerr
+		}
+		final := m == 0
+		ad := []byte{0}
+		if final {
+			ad[0] = 1
+		}
+		sealed := aead.Seal(nil, chunkNonce(prefix, counter, aead.NonceSize()), buf[:n], ad)
+		var length [4]byte
+		binary.BigEndian.PutUint32(length[:], uint32(len(sealed)))
+		if _, err := dst.Write(length[:]); err != nil {
+			return err
+		}
+		if _, err := dst.Write(sealed); err != nil {
+			return err
+		}
+		if final {
+			return nil
+		}
+		buf, next = next, buf
+		n = m
+	}
+}
+
+func decryptStream(dst io.Writer, src io.Reader, passphrase string) error {
+	magic := make([]byte, len(backupCryptMagic))
+	if _, err := io.ReadFull(src, magic); err != nil || string(magic) != backupCryptMagic {
+		return fmt.Errorf("not a devbox encrypted backup file")
+	}
+	salt := make([]byte, backupCryptSaltSize)
+	if _, err := io.ReadFull(src, salt); err != nil {
+		return errBackupDecrypt
+	}
+	aead, err := newBackupAEAD(passphrase, salt)
+	if err != nil {
+		return err
+	}
+	prefix := make([]byte, aead.NonceSize()-4)
+	if _, err := io.ReadFull(src, prefix); err != nil {
+		return errBackupDecrypt
+	}
+
+	for counter := uint32(0); ; counter++ {
+		var length [4]byte
+		if _, err := io.ReadFull(src, length[:]); err != nil {
+			return errBackupDecrypt
+		}
+		n := binary.BigEndian.Uint32(length[:])
+		if n > backupCryptChunkSize+uint32(aead.Overhead()) {
+			return errBackupDecrypt
+		}
+		sealed := make([]byte, n)
+		if _, err := io.ReadFull(src, sealed); err != nil {
+			return errBackupDecrypt
+		}
+		nonce := chunkNonce(prefix, counter, aead.NonceSize())
+		if plain, err := aead.Open(nil, nonce, sealed, []byte{0}); err == nil {
+			if _, err := dst.Write(plain); err != nil {
+				return err
+			}
+			continue
+		}
+		plain, err := aead.Open(nil, nonce, sealed, []byte{1})
+		if err != nil {
+			return errBackupDecrypt
+		}
+		if _, err := dst.Write(plain); err != nil {
+			return err
+		}
+		var extra [1]byte
+		if m, _ := src.Read(extra[:]); m != 0 {
+			return errBackupDecrypt
+		}
+		return nil
+	}
+}
+
+func encryptBackupFile(path string, recipients []string, passphrase string) (string, error) {
+	if len(recipients) > 0 {
+		out := path + ageSuffix
+		args := []string{"-o", out}
+		for _, r := range recipients {
+			if _, err := os.Stat(r); err == nil {
+				args = append(args, "-R", r)
+			} else {
+				args = append(args, "-r", r)
+			}
+		}
+		args = append(args, path)
+		var errb bytes.Buffer
+		cmd := exec.Command("age", args...)
+		cmd.Stderr = &errb
+		if err := cmd.Run(); err != nil {
+			if errors.Is(err, exec.ErrNotFound) {
+				return "", fmt.Errorf("--recipient requires the 'age' binary on PATH")
+			}
+			return "", fmt.Errorf("age encryption failed: %s", strings.TrimSpace(errb.String()))
+		}
+		return out, os.Remove(path)
+	}
+
+	out := path + encryptedSuffix
+	in, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+	f, err := os.OpenFile(out, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", err
+	}
+	if err := encryptStream(f, in, passphrase); err != nil {
+		f.Close()
+		os.Remove(out)
+		return "", fmt.Errorf("failed to encrypt %s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	in.Close()
+	return out, os.Remove(path)
+}
+
+func decryptBackupFile(encPath, outPath, identity, passphraseFile string) error {
+	if strings.HasSuffix(encPath, ageSuffix) {
+		if strings.TrimSpace(identity) == "" {
+			identity = os.Getenv("DEVBOX_AGE_IDENTITY")
+		}
+		if strings.TrimSpace(identity) == "" {
+			return fmt.Errorf("backup is age-encrypted: pass --identity or set DEVBOX_AGE_IDENTITY")
+		}
+		var errb bytes.Buffer
+		cmd := exec.Command("age", "-d", "-i", identity, "-o", outPath, encPath)
+		cmd.Stderr = &errb
+		if err := cmd.Run(); err != nil {
+			if errors.Is(err, exec.ErrNotFound) {
+				return fmt.Errorf("restoring an age-encrypted backup requires the 'age' binary on PATH")
+			}
+			return fmt.Errorf("age decryption failed: %s", strings.TrimSpace(errb.String()))
+		}
+		return nil
+	}
+
+	passphrase, err := backupPassphrase(passphraseFile)
+	if err != nil {
+		return err
+	}
+	in, err := os.Open(encPath)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	f, err := os.OpenFile(outPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if err := decryptStream(f, in, passphrase); err != nil {
+		f.Close()
+		os.Remove(outPath)
+		return err
+	}
+	return f.Close()
+}
+
+func requireEncryptedBackups() bool {
+	cfg, err := configManager.Load()
+	return err == nil && cfg.Settings != nil && cfg.Settings.RequireEncryptedBackups
+}
+
+func findBackupFile(backupDir, name string) (string, bool) {
+	for _, suffix := range []string{"", encryptedSuffix, ageSuffix} {
+		p := filepath.Join(backupDir, name+suffix)
+		if _, err := os.Stat(p); err == nil {
+			return p, suffix != ""
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,135 @@
+package commands
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"devbox/internal/config"
+)
+
+func TestPBKDF2SHA256(t *testing.T) {
+	got := hex.EncodeToString(pbkdf2SHA256([]byte("password"), []byte("salt"), 1, 32))
+	want := "120fb6cffcf8b32c43e7225256c4f837a86548c92ccc35480805987cb70be17b"
+	if got != want {
+		t.Errorf("pbkdf2SHA256 = %s, want %s", got, want)
+	}
+	got = hex.EncodeToString(pbkdf2SHA256([]byte("password"), []byte("salt"), 2, 32))
+	want = "ae4d0c95af6b46d32d0adff928f06dd02a303f8ef3c251dfd6e2d85a95474c43"
+	if got != want {
+		t.Errorf("pbkdf2SHA256 (2 rounds) = %s, want %s", got, want)
+	}
+}
+
+func TestEncryptDecryptStreamRoundTrip(t *testing.T) {
+	sizes := []int{0, 10, backupCryptChunkSize, backupCryptChunkSize*2 + 7}
+	for _, size := range sizes {
+		plain := make([]byte, size)
+		_, _ = rand.Read(plain)
+
+		var enc bytes.Buffer
+		if err := encryptStream(&enc, bytes.NewReader(plain), "s3cret"); err != nil {
+			t.Fatalf("size %d: encrypt: %v", size, err)
+		}
+		if size > 0 && bytes.Contains(enc.Bytes(), plain) {
+			t.Fatalf("size %d: ciphertext contains plaintext", size)
+		}
+
+		var dec bytes.Buffer
+		if err := decryptStream(&dec, bytes.NewReader(enc.Bytes()), "s3cret"); err != nil {
+			t.Fatalf("size %d: decrypt: %v", size, err)
+		}
+		if !bytes.Equal(dec.Bytes(), plain) {
+			t.Fatalf("size %d: round trip mismatch", size)
+		}
+	}
+}
+
+func TestDecryptStreamRejectsBadInput(t *testing.T) {
+	plain := bytes.Repeat([]byte("devbox"), backupCryptChunkSize/3)
+	var enc bytes.Buffer
+	if err := encryptStream(&enc, bytes.NewReader(plain), "right"); err != nil {
+		t.Fatal(err)
+	}
+	data := enc.Bytes()
+
+	if err := decryptStream(&bytes.Buffer{}, bytes.NewReader(data), "wrong"); err == nil {
+		t.Error("expected error for wrong passphrase")
+	}
+	headerLen := len(backupCryptMagic) + backupCryptSaltSize + 8
+	firstChunk := headerLen + 4 + backupCryptChunkSize + 16
+	if err := decryptStream(&bytes.Buffer{}, bytes.NewReader(data[:firstChunk]), "right"); err == nil {
+		t.Error("expected error for truncated ciphertext")
+	}
+	if err := decryptStream(&bytes.Buffer{}, bytes.NewReader([]byte("plain tar data")), "right"); err == nil {
+		t.Error("expected error for unencrypted input")
+	}
+}
+
+func TestOpenBackupDirPolicy(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"image.tar", "metadata.json"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("{}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, _, err := openBackupDir(dir, true, "", ""); err == nil {
+		t.Error("expected unencrypted backup to be refused under policy")
+	}
+	got, cleanup, err := openBackupDir(dir, false, "", "")
+	defer cleanup()
+	if err != nil || got != dir {
+		t.Errorf("openBackupDir = %q, %v; want %q", got, err, dir)
+	}
+}
+
+func TestEncryptedBackupFileRoundTrip(t *testing.T) {
+	t.Setenv("DEVBOX_BACKUP_PASSPHRASE", "hunter2")
+	dir := t.TempDir()
+	for name, content := range map[string]string{"image.tar": "layers", "metadata.json": `{"image_tag":"devbox/demo:backup"}`} {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := encryptBackupFile(p, nil, "hunter2"); err != nil {
+			t.Fatalf("encryptBackupFile(%s): %v", name, err)
+		}
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Errorf("plaintext %s should be removed after encryption", name)
+		}
+	}
+
+	restoreDir, cleanup, err := openBackupDir(dir, true, "", "")
+	if err != nil {
+		t.Fatalf("openBackupDir: %v", err)
+	}
+	defer cleanup()
+	manifest, err := readBackupManifest(restoreDir)
+	if err != nil {
+		t.Fatalf("readBackupManifest: %v", err)
+	}
+	if manifest.ImageTag != "devbox/demo:backup" {
+		t.Errorf("ImageTag = %q", manifest.ImageTag)
+	}
+}
+
+func TestResolveBackupEncryption(t *testing.T) {
+	t.Setenv("DEVBOX_BACKUP_PASSPHRASE", "")
+	strict := &config.Config{Settings: &config.GlobalSettings{RequireEncryptedBackups: true}}
+	if _, err := resolveBackupEncryption(strict, false, nil, ""); err == nil {
+		t.Error("expected policy to refuse unencrypted backup")
+	}
+	enc, err := resolveBackupEncryption(strict, false, []string{"age1example"}, "")
+	if err != nil || enc == nil || len(enc.Recipients) != 1 {
+		t.Errorf("recipient should imply encryption: %+v, %v", enc, err)
+	}
+	if _, err := resolveBackupEncryption(&config.Config{}, true, nil, ""); err == nil {
+		t.Error("expected error when no passphrase is available")
+	}
+	if enc, err := resolveBackupEncryption(&config.Config{}, false, nil, ""); err != nil || enc != nil {
+		t.Errorf("plain backup should need no encryption: %+v, %v", enc, err)
+	}
+}
@@ -0,0 +1,86 @@
+package commands
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"devbox/internal/config"
+	"devbox/internal/docker"
+)
+
+func projectConfigHash(pc *config.ProjectConfig) string {
+	if pc == nil {
+		return ""
+	}
+	data, _ := json.Marshal(pc)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+func boxCreationSource(cmd *cobra.Command) string {
+	return strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+}
+
+func boxMetadataFunc(source string) docker.BoxMetadataFunc {
+	return func(boxName, workspaceHost string, _ map[string]interface{}) docker.BoxMetadata {
+		cfg, _ := configManager.Load()
+		md := docker.BoxMetadata{
+			Project:   projectNameForBox(cfg, boxName),
+			Workspace: workspaceHost,
+			Version:   Version,
+			Source:    source,
+		}
+		if pc, err := configManager.LoadProjectConfig(workspaceHost); err == nil {
+			md.ConfigHash = projectConfigHash(pc)
+		}
+		return md
+	}
+}
+
+func configChangedSinceCreate(md docker.BoxMetadata, workspacePath string) bool {
+	if md.ConfigHash == "" {
+		return false
+	}
+	pc, err := configManager.LoadProjectConfig(workspacePath)
+	if err != nil {
+		return false
+	}
+	return projectConfigHash(pc) != md.ConfigHash
+}
+
+func boxMetadataMismatch(md docker.BoxMetadata, projectName, workspacePath string) string {
+	if !md.Known() || (md.Project == projectName && (md.Workspace == "" || md.Workspace == workspacePath)) {
+		return ""
+	}
+	return boxOrigin(md)
+}
+
+func printOrphanedBoxes(names []string, metadata map[string]docker.BoxMetadata) {
+	adoptable := false
+	for _, name := range names {
+		if origin := boxOrigin(metadata[name]); origin != "" {
+			fmt.Printf("  - %s (%s)\n", name, origin)
+			adoptable = true
+			continue
+		}
+		fmt.Printf("  - %s\n", name)
+	}
+	if adoptable {
+		fmt.Printf("hint: run 'devbox adopt <box>' to track a box again instead of removing it\n")
+	}
+}
+
+func boxOrigin(md docker.BoxMetadata) string {
+	if !md.Known() {
+		return ""
+	}
+	if md.Workspace == "" {
+		return fmt.Sprintf("project %s", md.Project)
+	}
+	return fmt.Sprintf("project %s, %s", md.Project, md.Workspace)
+}
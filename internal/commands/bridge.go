@@ -0,0 +1,171 @@
+package commands
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"devbox/internal/config"
+)
+
+const bridgeExitMarker = "__DEVBOX_BRIDGE_EXIT__"
+
+var bridgeCommands = map[string]map[string]bool{
+	"lock":   {"--show": true, "--fs-manifest": true, "--no-cache": true},
+	"verify": {"--no-cache": true},
+	"apply":  {},
+}
+
+type hostBridge struct {
+	listener net.Listener
+	token    string
+	project  *config.Project
+	mu       sync.Mutex
+}
+
+func startHostBridge(project *config.Project) (*hostBridge, error) {
+	host, err := dockerClient.GetBoxGateway(project.BoxName)
+	if err != nil {
+		return nil, err
+	}
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("tcp", net.JoinHostPort(host, "0"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", host, err)
+	}
+	b := &hostBridge{listener: listener, token: hex.EncodeToString(tokenBytes), project: project}
+	go b.serve()
+	return b, nil
+}
+
+func (b *hostBridge) Env() []string {
+	return []string{
+		"DEVBOX_BRIDGE=" + b.listener.Addr().String(),
+		"DEVBOX_BRIDGE_TOKEN=" + b.token,
+	}
+}
+
+func (b *hostBridge) Close() error {
+	return b.listener.Close()
+}
+
+func (b *hostBridge) serve() {
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			return
+		}
+		go b.handle(conn)
+	}
+}
+
+func (b *hostBridge) handle(conn net.Conn) {
+	defer conn.Close()
+	_ = conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return
+	}
+	_ = conn.SetReadDeadline(time.Time{})
+
+	out := &lineEndWriter{w: conn}
+	args, err := parseBridgeRequest(strings.TrimRight(line, "\r\n"), b.token, b.project.Name)
+	if err != nil {
+		fmt.Fprintf(out, "error: %v\n", err)
+		fmt.Fprintf(conn, "%s 1\n", bridgeExitMarker)
+		return
+	}
+
+	b.mu.Lock()
+	code := runBridgeCommand(args, b.project.WorkspacePath, out)
+	b.mu.Unlock()
+
+	out.finishLine()
+	fmt.Fprintf(conn, "%s %d\n", bridgeExitMarker, code)
+}
+
+func parseBridgeRequest(line, token, projectName string) ([]string, error) {
+	fields := strings.Split(line, "\t")
+	if len(fields) < 2 || subtle.ConstantTimeCompare([]byte(fields[0]), []byte(token)) != 1 {
+		return nil, errors.New("host bridge request not authorized")
+	}
+	command := fields[1]
+	allowed, ok := bridgeCommands[command]
+	if !ok {
+		return nil, fmt.Errorf("'devbox %s' is not available through the host bridge", command)
+	}
+	args := []string{command, projectName}
+	for _, arg := range fields[2:] {
+		switch {
+		case arg == "" || arg == projectName:
+		case allowed[arg]:
+			args = append(args, arg)
+		case strings.HasPrefix(arg, "-"):
+			return nil, fmt.Errorf("flag %s is not allowed for 'devbox %s' inside the box", arg, command)
+		default:
+			return nil, fmt.Errorf("'devbox %s' inside the box only operates on project '%s'", command, projectName)
+		}
+	}
+	return args, nil
+}
+
+func runBridgeCommand(args []string, workspacePath string, out io.Writer) int {
+	exe, err := os.Executable()
+	if err != nil {
+		fmt.Fprintf(out, "error: failed to locate devbox binary: %v\n", err)
+		return 1
+	}
+	cmd := exec.Command(exe, args...)
+	cmd.Dir = workspacePath
+	cmd.Stdout = out
+	cmd.Stderr = out
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode()
+		}
+		fmt.Fprintf(out, "error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+type lineEndWriter struct {
+	w    io.Writer
+	mu   sync.Mutex
+	last byte
+}
+
+func (l *lineEndWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(p) > 0 {
+		l.last = p[len(p)-1]
+	}
+	return l.w.Write(p)
+}
+
+func (l *lineEndWriter) finishLine() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.last != 0 && l.last != '\n' {
+		_, _ = l.w.Write([]byte{'\n'})
+		l.last = '\n'
+	}
+}
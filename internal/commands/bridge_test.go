@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseBridgeRequest(t *testing.T) {
+	const token = "s3cret"
+	tests := []struct {
+		name    string
+		line    string
+		want    []string
+		wantErr string
+	}{
+		{name: "lock", line: "s3cret\tlock", want: []string{"lock", "demo"}},
+		{name: "lock with allowed flags", line: "s3cret\tlock\t--show", want: []string{"lock", "demo", "--show"}},
+		{name: "own project name ignored", line: "s3cret\tverify\tdemo", want: []string{"verify", "demo"}},
+		{name: "apply", line: "s3cret\tapply", want: []string{"apply", "demo"}},
+		{name: "bad token", line: "nope\tlock", wantErr: "not authorized"},
+		{name: "missing command", line: "s3cret", wantErr: "not authorized"},
+		{name: "command not allowed", line: "s3cret\tdestroy", wantErr: "not available"},
+		{name: "flag not allowed", line: "s3cret\tlock\t--output=/etc/passwd", wantErr: "not allowed"},
+		{name: "other project", line: "s3cret\tapply\tother", wantErr: "only operates on project 'demo'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseBridgeRequest(tt.line, token, "demo")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseBridgeRequest() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseBridgeRequest() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseBridgeRequest() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLineEndWriterFinishLine(t *testing.T) {
+	var buf bytes.Buffer
+	w := &lineEndWriter{w: &buf}
+	w.finishLine()
+	w.Write([]byte("partial"))
+	w.finishLine()
+	w.Write([]byte("done\n"))
+	w.finishLine()
+	if got := buf.String(); got != "partial\ndone\n" {
+		t.Errorf("output = %q", got)
+	}
+}
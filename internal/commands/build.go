@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"fmt"
+
+	"devbox/internal/config"
+	"devbox/internal/docker"
+)
+
+var noCacheInitFlag bool
+
+func checkProjectImagePolicy(cfg *config.Config, image, workspace string, pc *config.ProjectConfig) error {
+	if pc == nil || pc.Build == nil {
+		return checkImagePolicy(cfg, image)
+	}
+	images, err := pc.Build.BaseImages(workspace)
+	if err != nil {
+		return err
+	}
+	for _, img := range images {
+		if err := checkImagePolicy(cfg, img); err != nil {
+			return fmt.Errorf("build.dockerfile: %w", err)
+		}
+	}
+	return nil
+}
+
+func ensureBaseImage(image, workspace string, pc *config.ProjectConfig, noCache, refresh bool) error {
+	if pc == nil || pc.Build == nil {
+		if refresh {
+			return dockerClient.RefreshImage(image, true)
+		}
+		return dockerClient.PullImage(image)
+	}
+	contextDir, dockerfile := pc.Build.Paths(workspace)
+	return dockerClient.BuildImage(docker.BuildOptions{
+		Tag:        image,
+		ContextDir: contextDir,
+		Dockerfile: dockerfile,
+		Args:       pc.Build.Args,
+		Target:     pc.Build.Target,
+		NoCache:    noCache,
+		Pull:       refresh,
+	})
+}
+
+func warnStaleBuild(boxName, image, projectName string) {
+	_, built, err := dockerClient.GetImageDigestInfo(image)
+	if err != nil || built == "" {
+		return
+	}
+	if _, current, err := dockerClient.GetImageDigestInfo(boxName); err == nil && current != "" && current != built {
+		fmt.Printf("Warning: box '%s' was created from an older build of %s\n", boxName, image)
+		fmt.Printf("hint: run 'devbox update %s' to recreate the box from the new image\n", projectName)
+	}
+}
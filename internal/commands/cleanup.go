@@ -7,6 +7,8 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+
+	"devbox/internal/docker"
 )
 
 var (
@@ -164,6 +166,7 @@ func cleanupOrphanedFromCleanup() error {
 	if err != nil {
 		return fmt.Errorf("failed to list boxes: %w", err)
 	}
+	boxes = ownedBoxes(boxes)
 
 	trackedboxes := make(map[string]bool)
 	for _, project := range cfg.GetProjects() {
@@ -171,11 +174,13 @@ func cleanupOrphanedFromCleanup() error {
 	}
 
 	var orphanedboxes []string
+	metadata := make(map[string]docker.BoxMetadata)
 	for _, box := range boxes {
 		for _, name := range box.Names {
 			cleanName := strings.TrimPrefix(name, "/")
-			if strings.HasPrefix(cleanName, "devbox_") && !trackedboxes[cleanName] {
+			if (strings.HasPrefix(cleanName, "devbox_") || box.Metadata.Known()) && !trackedboxes[cleanName] {
 				orphanedboxes = append(orphanedboxes, cleanName)
+				metadata[cleanName] = box.Metadata
 			}
 		}
 	}
@@ -186,9 +191,7 @@ func cleanupOrphanedFromCleanup() error {
 	}
 
 	fmt.Printf("Found %d orphaned devbox box(s):\n", len(orphanedboxes))
-	for _, boxName := range orphanedboxes {
-		fmt.Printf("  - %s\n", boxName)
-	}
+	printOrphanedBoxes(orphanedboxes, metadata)
 
 	if dryRunFlag {
 		fmt.Printf("\nDRY RUN: Would remove %d orphaned boxes\n", len(orphanedboxes))
@@ -379,10 +382,15 @@ func showSystemStatus() error {
 	if err != nil {
 		fmt.Printf("error: failed to list boxes: %v\n", err)
 	} else {
+		boxes, _ = filterOwnedBoxes(boxes, docker.CurrentOwner(), allUsersFlag)
 		fmt.Printf("Active devbox boxes: %d\n", len(boxes))
 		for _, box := range boxes {
 			for _, name := range box.Names {
-				fmt.Printf("  - %s (%s)\n", strings.TrimPrefix(name, "/"), box.Status)
+				if allUsersFlag {
+					fmt.Printf("  - %s (%s, owner: %s)\n", strings.TrimPrefix(name, "/"), box.Status, ownerName(box.Owner))
+				} else {
+					fmt.Printf("  - %s (%s)\n", strings.TrimPrefix(name, "/"), box.Status)
+				}
 			}
 		}
 	}
@@ -416,4 +424,5 @@ func init() {
 	cleanupCmd.Flags().BoolVar(&networksFlag, "networks", false, "Clean up unused Docker networks only")
 	cleanupCmd.Flags().BoolVar(&systemPruneFlag, "system-prune", false, "Run Docker system prune for comprehensive cleanup")
 	cleanupCmd.Flags().BoolVarP(&forceFlag, "force", "f", false, "Force cleanup without confirmation prompts")
+	cleanupCmd.Flags().BoolVar(&allUsersFlag, "all-users", false, "Include boxes owned by other users or without an owner label")
 }
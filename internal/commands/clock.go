@@ -0,0 +1,143 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const clockSkewThreshold = 5 * time.Second
+
+func parseEpochSeconds(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	secPart, fracPart, _ := strings.Cut(s, ".")
+	sec, err := strconv.ParseInt(secPart, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unexpected date output %q", s)
+	}
+	var nsec int64
+	if fracPart != "" {
+		if len(fracPart) > 9 {
+			fracPart = fracPart[:9]
+		}
+		if frac, err := strconv.ParseInt(fracPart, 10, 64); err == nil {
+			for i := len(fracPart); i < 9; i++ {
+				frac *= 10
+			}
+			nsec = frac
+		}
+	}
+	return time.Unix(sec, nsec), nil
+}
+
+func skewBetween(boxTime, before, after time.Time) time.Duration {
+	mid := before.Add(after.Sub(before) / 2)
+	return boxTime.Sub(mid)
+}
+
+func measureClockSkew(boxName string) (time.Duration, error) {
+	before := time.Now()
+	out, _, err := dockerClient.ExecCapture(boxName, "date -u +%s.%N")
+	after := time.Now()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read box clock: %w", err)
+	}
+	boxTime, err := parseEpochSeconds(out)
+	if err != nil {
+		return 0, err
+	}
+	return skewBetween(boxTime, before, after), nil
+}
+
+func clockSkewExceeded(skew time.Duration) bool {
+	return skew > clockSkewThreshold || skew < -clockSkewThreshold
+}
+
+func describeClockSkew(skew time.Duration) string {
+	d := skew.Round(100 * time.Millisecond)
+	switch {
+	case !clockSkewExceeded(skew):
+		return fmt.Sprintf("in sync (skew %s)", d)
+	case skew > 0:
+		return fmt.Sprintf("box is %s ahead of the host", d)
+	default:
+		return fmt.Sprintf("box is %s behind the host", -d)
+	}
+}
+
+func syncBoxClock(boxName string) (string, error) {
+	attempts := []struct {
+		method  string
+		command string
+	}{
+		{"hwclock", "hwclock --hctosys"},
+		{"chrony", "chronyc -a makestep"},
+	}
+	for _, a := range attempts {
+		if _, _, err := dockerClient.ExecCapture(boxName, a.command+" >/dev/null 2>&1"); err != nil {
+			continue
+		}
+		if skew, err := measureClockSkew(boxName); err == nil && !clockSkewExceeded(skew) {
+			return a.method, nil
+		}
+	}
+
+	if err := dockerClient.StopBox(boxName); err != nil {
+		return "", fmt.Errorf("failed to stop box: %w", err)
+	}
+	if err := dockerClient.StartBox(boxName); err != nil {
+		return "", fmt.Errorf("failed to start box: %w", err)
+	}
+	skew, err := measureClockSkew(boxName)
+	if err != nil {
+		return "restart", err
+	}
+	if clockSkewExceeded(skew) {
+		return "restart", fmt.Errorf("clock still skewed after restart: %s; the Docker host or VM clock itself is likely wrong", describeClockSkew(skew))
+	}
+	return "restart", nil
+}
+
+func syncAllBoxClocks() error {
+	fmt.Printf("Checking box clocks against the host...\n")
+
+	cfg, err := configManager.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	var checked, synced, failed int
+	for projectName, project := range cfg.GetProjects() {
+		status, err := dockerClient.GetBoxStatus(project.BoxName)
+		if err != nil || status != "running" {
+			continue
+		}
+		checked++
+		skew, err := measureClockSkew(project.BoxName)
+		if err != nil {
+			fmt.Printf("error: %s: %v\n", projectName, err)
+			failed++
+			continue
+		}
+		if !clockSkewExceeded(skew) {
+			fmt.Printf("%s: %s\n", projectName, describeClockSkew(skew))
+			continue
+		}
+		fmt.Printf("%s: %s, syncing...\n", projectName, describeClockSkew(skew))
+		method, err := syncBoxClock(project.BoxName)
+		if err != nil {
+			fmt.Printf("error: %s: %v\n", projectName, err)
+			failed++
+			continue
+		}
+		fmt.Printf("%s: clock synced (%s)\n", projectName, method)
+		synced++
+	}
+
+	fmt.Printf("\nTime sync summary: %d running box(es) checked, %d synced, %d failed\n", checked, synced, failed)
+	if failed > 0 {
+		return fmt.Errorf("failed to sync the clock of %d box(es)", failed)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseEpochSeconds(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{in: "1700000000.250000000\n", want: time.Unix(1700000000, 250000000)},
+		{in: "1700000000.5", want: time.Unix(1700000000, 500000000)},
+		{in: "1700000000", want: time.Unix(1700000000, 0)},
+		{in: "1700000000.N", want: time.Unix(1700000000, 0)},
+		{in: "Tue Oct 17", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseEpochSeconds(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseEpochSeconds(%q) expected error", tt.in)
+			}
+			continue
+		}
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("parseEpochSeconds(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestClockSkew(t *testing.T) {
+	before := time.Unix(1000, 0)
+	after := before.Add(200 * time.Millisecond)
+
+	skew := skewBetween(before.Add(100*time.Millisecond), before, after)
+	if skew != 0 || clockSkewExceeded(skew) {
+		t.Errorf("skew = %v, want 0 and in sync", skew)
+	}
+
+	skew = skewBetween(before.Add(42*time.Second), before, after)
+	if !clockSkewExceeded(skew) {
+		t.Errorf("skew %v should exceed threshold", skew)
+	}
+	if got := describeClockSkew(skew); got != "box is 41.9s ahead of the host" {
+		t.Errorf("describeClockSkew() = %q", got)
+	}
+	if got := describeClockSkew(-2 * time.Minute); got != "box is 2m0s behind the host" {
+		t.Errorf("describeClockSkew() = %q", got)
+	}
+}
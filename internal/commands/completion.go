@@ -82,12 +82,33 @@ func getTemplateNames(cmd *cobra.Command, args []string, toComplete string) ([]s
 	return templates, cobra.ShellCompDirectiveNoFileComp
 }
 
+func getSnapshotNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return getProjectNames(cmd, args, toComplete)
+	}
+	if configManager == nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	entries, _ := loadSnapshots(args[0])
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name)
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
 func init() {
 
 	shellCmd.ValidArgsFunction = getProjectNames
 	runCmd.ValidArgsFunction = getProjectNames
 	stopCmd.ValidArgsFunction = getProjectNames
 	destroyCmd.ValidArgsFunction = getProjectNames
+	snapshotCreateCmd.ValidArgsFunction = getProjectNames
+	snapshotListCmd.ValidArgsFunction = getProjectNames
+	snapshotDiffCmd.ValidArgsFunction = getSnapshotNames
+	snapshotRestoreCmd.ValidArgsFunction = getSnapshotNames
+	snapshotDeleteCmd.ValidArgsFunction = getSnapshotNames
 
 	templatesShowCmd.ValidArgsFunction = getTemplateNames
 	templatesDeleteCmd.ValidArgsFunction = getTemplateNames
@@ -0,0 +1,537 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"devbox/internal/config"
+	"devbox/internal/docker"
+)
+
+var composeServiceFlag string
+
+var composeFileNames = []string{"compose.yaml", "compose.yml", "docker-compose.yaml", "docker-compose.yml"}
+
+var composeVariable = regexp.MustCompile(`^\$\{?([A-Za-z_][A-Za-z0-9_]*)\}?$`)
+
+var composeHandledKeys = map[string]bool{
+	"image": true, "build": true, "environment": true, "ports": true, "volumes": true, "healthcheck": true,
+	"deploy": true, "mem_limit": true, "cpus": true, "working_dir": true, "user": true, "cap_add": true,
+	"labels": true, "restart": true, "depends_on": true, "networks": true, "container_name": true,
+}
+
+type composeValue string
+
+func (v *composeValue) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*v = composeValue(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	*v = composeValue(n.String())
+	return nil
+}
+
+type composeBuild struct {
+	Context    string             `json:"context"`
+	Dockerfile string             `json:"dockerfile"`
+	Args       map[string]*string `json:"args"`
+	Target     string             `json:"target"`
+}
+
+type composeService struct {
+	Image       string             `json:"image"`
+	Build       *composeBuild      `json:"build"`
+	Environment map[string]*string `json:"environment"`
+	Ports       []struct {
+		Target    int          `json:"target"`
+		Published composeValue `json:"published"`
+		HostIP    string       `json:"host_ip"`
+		Protocol  string       `json:"protocol"`
+	} `json:"ports"`
+	Volumes []struct {
+		Type     string `json:"type"`
+		Source   string `json:"source"`
+		Target   string `json:"target"`
+		ReadOnly bool   `json:"read_only"`
+	} `json:"volumes"`
+	HealthCheck *struct {
+		Test        []string `json:"test"`
+		Interval    string   `json:"interval"`
+		Timeout     string   `json:"timeout"`
+		StartPeriod string   `json:"start_period"`
+		Retries     int      `json:"retries"`
+		Disable     bool     `json:"disable"`
+	} `json:"healthcheck"`
+	Deploy *struct {
+		Resources struct {
+			Limits struct {
+				CPUs   composeValue `json:"cpus"`
+				Memory composeValue `json:"memory"`
+			} `json:"limits"`
+		} `json:"resources"`
+	} `json:"deploy"`
+	MemLimit   composeValue               `json:"mem_limit"`
+	CPUs       composeValue               `json:"cpus"`
+	WorkingDir string                     `json:"working_dir"`
+	User       string                     `json:"user"`
+	CapAdd     []string                   `json:"cap_add"`
+	Labels     map[string]string          `json:"labels"`
+	Restart    string                     `json:"restart"`
+	DependsOn  map[string]json.RawMessage `json:"depends_on"`
+	Networks   map[string]json.RawMessage `json:"networks"`
+}
+
+var importComposeCmd = &cobra.Command{
+	Use:   "compose [file]",
+	Short: "Create devbox.json from a docker-compose file",
+	Long: `Read a compose file (default compose.yaml, compose.yml, docker-compose.yaml, or
+docker-compose.yml in the current folder) and write an equivalent devbox.json. The
+primary service becomes the box: its image or build, environment, ports, volumes,
+healthcheck, and resource limits are translated. Other services that use an image become
+devbox services. Anything devbox can't express is listed as not imported.
+
+The file is normalized with 'docker compose config', so the Compose plugin (or
+docker-compose v2) must be installed. Variables such as ${TOKEN} are not expanded; a
+value that is just a variable becomes a secret read from the host environment.
+
+Examples:
+  devbox import compose
+  devbox import compose docker-compose.dev.yml --service app
+  devbox import compose --stdout`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get cwd: %w", err)
+		}
+		path, err := findComposeFile(cwd, args)
+		if err != nil {
+			return err
+		}
+		data, err := docker.ComposeConfig(path)
+		if err != nil {
+			return err
+		}
+		pc, report, err := importCompose(data, composeServiceFlag, cwd)
+		if err != nil {
+			return fmt.Errorf("failed to import %s: %w", path, err)
+		}
+		return writeImportedConfig(cwd, path, pc, report, importStdoutFlag)
+	},
+}
+
+func init() {
+	importComposeCmd.Flags().StringVar(&composeServiceFlag, "service", "", "Service to turn into the box (default: the only service, or the only one with a build)")
+	importComposeCmd.Flags().BoolVar(&importStdoutFlag, "stdout", false, "Print devbox.json instead of writing it")
+	importComposeCmd.Flags().BoolVarP(&forceFlag, "force", "f", false, "Replace an existing devbox.json")
+	importCmd.AddCommand(importComposeCmd)
+}
+
+func findComposeFile(cwd string, args []string) (string, error) {
+	if len(args) == 1 {
+		path := args[0]
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(cwd, path)
+		}
+		if _, err := os.Stat(path); err != nil {
+			return "", fmt.Errorf("compose file %s not found", path)
+		}
+		return path, nil
+	}
+	for _, name := range composeFileNames {
+		p := filepath.Join(cwd, name)
+		if _, err := os.Stat(p); err == nil {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("no compose file found in %s (checked %s)", cwd, strings.Join(composeFileNames, ", "))
+}
+
+func importCompose(data []byte, primary, projectDir string) (*config.ProjectConfig, *importReport, error) {
+	var file struct {
+		Name     string                     `json:"name"`
+		Services map[string]json.RawMessage `json:"services"`
+		Secrets  json.RawMessage            `json:"secrets"`
+		Configs  json.RawMessage            `json:"configs"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, nil, fmt.Errorf("unexpected compose config output: %w", err)
+	}
+	if len(file.Services) == 0 {
+		return nil, nil, fmt.Errorf("the compose file has no services")
+	}
+	services := make(map[string]*composeService, len(file.Services))
+	for name, raw := range file.Services {
+		var svc composeService
+		if err := json.Unmarshal(raw, &svc); err != nil {
+			return nil, nil, fmt.Errorf("services.%s: %w", name, err)
+		}
+		services[name] = &svc
+	}
+	primary, err := composePrimaryService(services, primary)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pc := &config.ProjectConfig{
+		Schema:        config.ProjectConfigSchemaURL,
+		SchemaVersion: config.SchemaVersion,
+		Name:          filepath.Base(projectDir),
+	}
+	r := &importReport{}
+	if file.Name != "" && validateProjectName(file.Name) == nil {
+		pc.Name = file.Name
+	}
+	r.ok("services.%s -> the box", primary)
+	if err := importComposePrimary(services[primary], file.Services[primary], primary, projectDir, pc, r); err != nil {
+		return nil, nil, err
+	}
+
+	for _, name := range sortedComposeServices(services) {
+		if name == primary {
+			continue
+		}
+		svc := services[name]
+		if svc.Image == "" {
+			r.skip("services.%s: built from a Dockerfile; devbox services need an image", name)
+			continue
+		}
+		if pc.Services == nil {
+			pc.Services = map[string]*config.Service{}
+		}
+		pc.Services[name] = importComposeService(name, svc, file.Services[name], projectDir, r)
+		r.ok("services.%s -> services.%s", name, name)
+	}
+	for _, deps := range pc.Services {
+		var kept []string
+		for _, dep := range deps.DependsOn {
+			if _, ok := pc.Services[dep]; ok {
+				kept = append(kept, dep)
+			}
+		}
+		deps.DependsOn = kept
+	}
+	if len(file.Secrets) > 0 && string(file.Secrets) != "null" {
+		r.skip("secrets: compose secrets are not imported; declare them in devbox.json's secrets")
+	}
+	if len(file.Configs) > 0 && string(file.Configs) != "null" {
+		r.skip("configs: not supported by devbox")
+	}
+	return pc, r, nil
+}
+
+func sortedComposeServices(services map[string]*composeService) []string {
+	names := make([]string, 0, len(services))
+	for name := range services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func composePrimaryService(services map[string]*composeService, requested string) (string, error) {
+	names := sortedComposeServices(services)
+	if requested != "" {
+		if _, ok := services[requested]; !ok {
+			return "", fmt.Errorf("service '%s' not found (services: %s)", requested, strings.Join(names, ", "))
+		}
+		return requested, nil
+	}
+	if len(names) == 1 {
+		return names[0], nil
+	}
+	var built []string
+	for _, name := range names {
+		if services[name].Build != nil {
+			built = append(built, name)
+		}
+	}
+	if len(built) == 1 {
+		return built[0], nil
+	}
+	return "", fmt.Errorf("can't tell which service should become the box (services: %s)\nhint: pass --service <name>", strings.Join(names, ", "))
+}
+
+func composeUnhandled(prefix string, raw json.RawMessage, handled map[string]bool, r *importReport) {
+	var keys map[string]json.RawMessage
+	if json.Unmarshal(raw, &keys) != nil {
+		return
+	}
+	var names []string
+	for k := range keys {
+		if !handled[k] {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		switch k {
+		case "command", "entrypoint":
+			r.skip("%s.%s: devbox keeps the box running with its own command", prefix, k)
+		case "expose":
+			r.skip("%s.%s: not needed; services share the project network", prefix, k)
+		default:
+			r.skip("%s.%s: not supported by devbox", prefix, k)
+		}
+	}
+}
+
+func importComposePrimary(svc *composeService, raw json.RawMessage, name, projectDir string, pc *config.ProjectConfig, r *importReport) error {
+	prefix := "services." + name
+	composeUnhandled(prefix, raw, composeHandledKeys, r)
+
+	if b := svc.Build; b != nil {
+		context, err := filepath.Rel(projectDir, b.Context)
+		if err != nil || strings.HasPrefix(context, "..") {
+			return fmt.Errorf("%s.build: context %s is outside the project folder", prefix, b.Context)
+		}
+		pc.Build = &config.Build{Dockerfile: b.Dockerfile, Target: b.Target}
+		if context != "." {
+			pc.Build.Context = filepath.ToSlash(context)
+		}
+		for k, v := range b.Args {
+			if v == nil || strings.Contains(*v, "$") {
+				r.skip("%s.build.args.%s: comes from the host environment; set it in devbox.json", prefix, k)
+				continue
+			}
+			if pc.Build.Args == nil {
+				pc.Build.Args = map[string]string{}
+			}
+			pc.Build.Args[k] = *v
+		}
+		r.ok("%s.build -> build", prefix)
+		if svc.Image != "" {
+			r.skip("%s.image: '%s' names the built image; devbox tags its own", prefix, svc.Image)
+		}
+	} else {
+		pc.BaseImage = svc.Image
+		r.ok("%s.image -> base_image", prefix)
+	}
+
+	for _, k := range sortedPointerKeys(svc.Environment) {
+		v := svc.Environment[k]
+		if v == nil {
+			addComposeSecret(pc, k, k, r, prefix)
+			continue
+		}
+		if m := composeVariable.FindStringSubmatch(*v); m != nil && config.IsSecretName(k) {
+			addComposeSecret(pc, k, m[1], r, prefix)
+			continue
+		}
+		if strings.Contains(strings.ReplaceAll(*v, "$$", ""), "$") {
+			r.skip("%s.environment.%s: '%s' uses variable substitution devbox doesn't do", prefix, k, *v)
+			continue
+		}
+		if pc.Environment == nil {
+			pc.Environment = map[string]string{}
+		}
+		pc.Environment[k] = strings.ReplaceAll(*v, "$$", "$")
+	}
+	if len(pc.Environment) > 0 {
+		r.ok("%s.environment -> environment", prefix)
+	}
+
+	pc.Ports = composePorts(svc)
+	if len(pc.Ports) > 0 {
+		r.ok("%s.ports -> ports", prefix)
+	}
+	pc.Volumes = composeVolumes(svc, prefix, projectDir, r)
+	if len(pc.Volumes) > 0 {
+		r.ok("%s.volumes -> volumes", prefix)
+	}
+
+	if h := svc.HealthCheck; h != nil {
+		if h.Disable {
+			pc.HealthCheck = &config.HealthCheck{Test: []string{"NONE"}}
+		} else {
+			pc.HealthCheck = &config.HealthCheck{Test: h.Test, Interval: h.Interval, Timeout: h.Timeout, StartPeriod: h.StartPeriod, Retries: h.Retries}
+		}
+		r.ok("%s.healthcheck -> health_check", prefix)
+	}
+
+	cpus, memory := svc.CPUs, svc.MemLimit
+	if d := svc.Deploy; d != nil {
+		if d.Resources.Limits.CPUs != "" {
+			cpus = d.Resources.Limits.CPUs
+		}
+		if d.Resources.Limits.Memory != "" {
+			memory = d.Resources.Limits.Memory
+		}
+	}
+	hasCPUs, hasMemory := cpus != "" && cpus != "0", memory != "" && memory != "0"
+	if hasCPUs || hasMemory {
+		pc.Resources = &config.Resources{}
+		if hasCPUs {
+			pc.Resources.CPUs = config.NormalizeCPUs(string(cpus))
+		}
+		if hasMemory {
+			pc.Resources.Memory = composeMemory(string(memory))
+		}
+		r.ok("%s resource limits -> resources", prefix)
+	}
+	if svc.Deploy != nil {
+		r.skip("%s.deploy: only resources.limits is imported", prefix)
+	}
+
+	if svc.WorkingDir != "" {
+		pc.WorkingDir = svc.WorkingDir
+		r.ok("%s.working_dir -> working_dir", prefix)
+	}
+	if svc.User != "" {
+		pc.User = svc.User
+		r.ok("%s.user -> user", prefix)
+	}
+	if len(svc.CapAdd) > 0 {
+		pc.Capabilities = svc.CapAdd
+		r.ok("%s.cap_add -> capabilities", prefix)
+	}
+	if len(svc.Labels) > 0 {
+		pc.Labels = svc.Labels
+		r.ok("%s.labels -> labels", prefix)
+	}
+	if svc.Restart != "" {
+		pc.Restart = svc.Restart
+		r.ok("%s.restart -> restart", prefix)
+	}
+	composeNetworks(svc, prefix, r)
+	return nil
+}
+
+func importComposeService(name string, svc *composeService, raw json.RawMessage, projectDir string, r *importReport) *config.Service {
+	prefix := "services." + name
+	composeUnhandled(prefix, raw, map[string]bool{"image": true, "environment": true, "ports": true, "volumes": true, "depends_on": true, "networks": true, "container_name": true}, r)
+	out := &config.Service{Image: svc.Image}
+	for _, k := range sortedPointerKeys(svc.Environment) {
+		v := svc.Environment[k]
+		if v == nil || strings.Contains(strings.ReplaceAll(*v, "$$", ""), "$") {
+			r.skip("%s.environment.%s: comes from the host environment; devbox services take literal values", prefix, k)
+			continue
+		}
+		if out.Environment == nil {
+			out.Environment = map[string]string{}
+		}
+		out.Environment[k] = strings.ReplaceAll(*v, "$$", "$")
+	}
+	out.Ports = composePorts(svc)
+	out.Volumes = composeVolumes(svc, prefix, projectDir, r)
+	for dep := range svc.DependsOn {
+		out.DependsOn = append(out.DependsOn, dep)
+	}
+	sort.Strings(out.DependsOn)
+	composeNetworks(svc, prefix, r)
+	return out
+}
+
+func addComposeSecret(pc *config.ProjectConfig, name, variable string, r *importReport, prefix string) {
+	if pc.Secrets == nil {
+		pc.Secrets = map[string]config.Secret{}
+	}
+	pc.Secrets[name] = config.Secret{From: "env://" + variable}
+	r.ok("%s.environment.%s -> secrets (env://%s)", prefix, name, variable)
+}
+
+func sortedPointerKeys(m map[string]*string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func composePorts(svc *composeService) []string {
+	var ports []string
+	for _, p := range svc.Ports {
+		target := strconv.Itoa(p.Target)
+		proto := ""
+		if p.Protocol != "" && p.Protocol != "tcp" {
+			proto = "/" + p.Protocol
+		}
+		switch {
+		case p.Published == "":
+			ports = append(ports, target+"/"+firstNonEmpty(p.Protocol, "tcp"))
+		case p.HostIP != "":
+			ports = append(ports, p.HostIP+":"+string(p.Published)+":"+target+proto)
+		default:
+			ports = append(ports, string(p.Published)+":"+target+proto)
+		}
+	}
+	return ports
+}
+
+func composeVolumes(svc *composeService, prefix, projectDir string, r *importReport) []string {
+	var volumes []string
+	for _, v := range svc.Volumes {
+		source := v.Source
+		switch v.Type {
+		case "bind":
+			source = composeHostPath(source, projectDir)
+		case "volume":
+			if source == "" {
+				r.skip("%s.volumes: anonymous volume at %s; name it to keep its data", prefix, v.Target)
+				continue
+			}
+		default:
+			r.skip("%s.volumes: %s mounts at %s are not supported", prefix, v.Type, v.Target)
+			continue
+		}
+		volume := source + ":" + v.Target
+		if v.ReadOnly {
+			volume += ":ro"
+		}
+		volumes = append(volumes, volume)
+	}
+	return volumes
+}
+
+func composeHostPath(path, projectDir string) string {
+	if rel, err := filepath.Rel(projectDir, path); err == nil && !strings.HasPrefix(rel, "..") {
+		if rel == "." {
+			return "."
+		}
+		return "./" + filepath.ToSlash(rel)
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		if rel, err := filepath.Rel(home, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return "~/" + filepath.ToSlash(rel)
+		}
+	}
+	return path
+}
+
+func composeMemory(value string) string {
+	if bytes, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return config.FormatMemory(bytes)
+	}
+	return config.NormalizeMemory(value)
+}
+
+func composeNetworks(svc *composeService, prefix string, r *importReport) {
+	for _, name := range sortedRawKeys(svc.Networks) {
+		if name != "default" {
+			r.skip("%s.networks.%s: devbox puts the box and its services on one project network", prefix, name)
+		}
+	}
+}
+
+func sortedRawKeys(m map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
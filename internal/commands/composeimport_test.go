@@ -0,0 +1,135 @@
+package commands
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"devbox/internal/config"
+)
+
+func TestImportCompose(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	project := filepath.Join(home, "src", "shop")
+	data := `{
+  "name": "shop",
+  "services": {
+    "app": {
+      "build": {"context": "` + project + `", "dockerfile": "docker/Dockerfile.dev", "args": {"NODE_VERSION": "20", "NPM_TOKEN": null}},
+      "image": "shop-app:dev",
+      "command": ["npm", "run", "dev"],
+      "environment": {"NODE_ENV": "development", "PRICE": "$$5", "API_KEY": "${API_KEY}", "GH_TOKEN": null, "DB_URL": "postgres://${DB_USER:-dev}@db/shop"},
+      "ports": [
+        {"mode": "ingress", "target": 3000, "published": "3000", "protocol": "tcp"},
+        {"mode": "ingress", "target": 9229, "published": "9229", "host_ip": "127.0.0.1", "protocol": "tcp"},
+        {"mode": "ingress", "target": 5353, "published": "5353", "protocol": "udp"},
+        {"mode": "ingress", "target": 8080, "protocol": "tcp"}
+      ],
+      "volumes": [
+        {"type": "bind", "source": "` + project + `", "target": "/app", "bind": {"create_host_path": true}},
+        {"type": "bind", "source": "` + home + `/.npmrc", "target": "/root/.npmrc", "read_only": true},
+        {"type": "volume", "source": "node_modules", "target": "/app/node_modules", "volume": {}},
+        {"type": "volume", "target": "/tmp/cache"},
+        {"type": "tmpfs", "target": "/run"}
+      ],
+      "healthcheck": {"test": ["CMD-SHELL", "curl -f http://localhost:3000 || exit 1"], "interval": "30s", "timeout": "5s", "retries": 3, "start_period": "10s"},
+      "deploy": {"resources": {"limits": {"cpus": 1.5, "memory": "2147483648"}}},
+      "working_dir": "/app",
+      "cap_add": ["SYS_PTRACE"],
+      "restart": "unless-stopped",
+      "depends_on": {"db": {"condition": "service_healthy", "required": true}},
+      "networks": {"default": null, "backend": null}
+    },
+    "db": {
+      "image": "postgres:16",
+      "environment": {"POSTGRES_PASSWORD": "dev", "POSTGRES_USER": "${DB_USER}"},
+      "ports": [{"mode": "ingress", "target": 5432, "published": "5432", "protocol": "tcp"}],
+      "volumes": [{"type": "volume", "source": "pgdata", "target": "/var/lib/postgresql/data", "volume": {}}],
+      "healthcheck": {"test": ["CMD", "pg_isready"]},
+      "networks": {"default": null}
+    },
+    "worker": {
+      "build": {"context": "` + project + `/worker", "dockerfile": "Dockerfile"},
+      "depends_on": {"db": {"condition": "service_started", "required": true}}
+    }
+  },
+  "secrets": {"stripe": {"file": "` + project + `/stripe.key"}}
+}`
+	pc, report, err := importCompose([]byte(data), "app", project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &config.ProjectConfig{
+		Schema:        config.ProjectConfigSchemaURL,
+		SchemaVersion: config.SchemaVersion,
+		Name:          "shop",
+		Build:         &config.Build{Dockerfile: "docker/Dockerfile.dev", Args: map[string]string{"NODE_VERSION": "20"}},
+		Environment:   map[string]string{"NODE_ENV": "development", "PRICE": "$5"},
+		Secrets:       map[string]config.Secret{"API_KEY": {From: "env://API_KEY"}, "GH_TOKEN": {From: "env://GH_TOKEN"}},
+		Ports:         []string{"3000:3000", "127.0.0.1:9229:9229", "5353:5353/udp", "8080/tcp"},
+		Volumes:       []string{".:/app", "~/.npmrc:/root/.npmrc:ro", "node_modules:/app/node_modules"},
+		HealthCheck:   &config.HealthCheck{Test: []string{"CMD-SHELL", "curl -f http://localhost:3000 || exit 1"}, Interval: "30s", Timeout: "5s", StartPeriod: "10s", Retries: 3},
+		Resources:     &config.Resources{CPUs: "1.5", Memory: "2g"},
+		WorkingDir:    "/app",
+		Capabilities:  []string{"SYS_PTRACE"},
+		Restart:       "unless-stopped",
+		Services: map[string]*config.Service{
+			"db": {
+				Image:       "postgres:16",
+				Environment: map[string]string{"POSTGRES_PASSWORD": "dev"},
+				Ports:       []string{"5432:5432"},
+				Volumes:     []string{"pgdata:/var/lib/postgresql/data"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(pc, want) {
+		got, _ := json.MarshalIndent(pc, "", "  ")
+		exp, _ := json.MarshalIndent(want, "", "  ")
+		t.Errorf("importCompose() =\n%s\nwant\n%s", got, exp)
+	}
+
+	skipped := strings.Join(report.skipped, "\n")
+	for _, s := range []string{
+		"services.app.command", "build.args.NPM_TOKEN", "services.app.image", "environment.DB_URL",
+		"anonymous volume at /tmp/cache", "tmpfs mounts at /run", "services.app.deploy", "networks.backend",
+		"services.db.healthcheck", "environment.POSTGRES_USER", "services.worker: built from a Dockerfile", "secrets:",
+	} {
+		if !strings.Contains(skipped, s) {
+			t.Errorf("report.skipped missing %q:\n%s", s, skipped)
+		}
+	}
+}
+
+func TestComposePrimaryService(t *testing.T) {
+	build := &composeService{Build: &composeBuild{}}
+	tests := []struct {
+		name      string
+		services  map[string]*composeService
+		requested string
+		want      string
+		wantErr   string
+	}{
+		{"only service", map[string]*composeService{"web": {Image: "nginx"}}, "", "web", ""},
+		{"only build", map[string]*composeService{"app": build, "db": {Image: "postgres"}}, "", "app", ""},
+		{"requested", map[string]*composeService{"app": build, "db": {Image: "postgres"}}, "db", "db", ""},
+		{"unknown", map[string]*composeService{"app": build}, "api", "", "service 'api' not found"},
+		{"ambiguous", map[string]*composeService{"web": {Image: "nginx"}, "db": {Image: "postgres"}}, "", "", "pass --service"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := composePrimaryService(tt.services, tt.requested)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("composePrimaryService() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("composePrimaryService() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
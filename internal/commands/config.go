@@ -9,8 +9,11 @@ import (
 	"github.com/spf13/cobra"
 
 	"devbox/internal/config"
+	"devbox/internal/docker"
 )
 
+var configLintFix bool
+
 var configCmd = &cobra.Command{
 	Use:   "config <command>",
 	Short: "Manage devbox configurations",
@@ -19,10 +22,19 @@ var configCmd = &cobra.Command{
 Available commands:
   generate <project>    Generate devbox.json for project
   validate <project>    Validate project configuration
-	schema                Print JSON Schema for devbox.json
+  lint <project>        Flag best-practice issues (--fix to repair safe ones)
+  schema [file]         Print (or write) the JSON Schema for devbox.json
   show <project>        Show project configuration
   templates             List available templates
-  global               Show global configuration`,
+  global               Show global configuration
+  workspace-root [path] Show or change where new project folders are created
+                        (--move moves existing projects, --dry-run previews)
+  profile list          List saved global profiles
+  profile show <name>   Show a saved profile
+  profile save <name>   Save the current global settings as a profile
+  profile use <name>    Switch global settings to a saved profile
+  webhooks list         List configured webhooks
+  webhooks test [event] Send a sample event to matching webhooks`,
 	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		subCommand := args[0]
@@ -38,9 +50,17 @@ Available commands:
 				return fmt.Errorf("project name required for validate command")
 			}
 			return validateProjectConfig(args[1])
+		case "lint":
+			if len(args) < 2 {
+				return fmt.Errorf("project name required for lint command")
+			}
+			return lintProjectConfig(args[1], configLintFix)
 		case "schema":
-			fmt.Println(config.ProjectConfigJSONSchema)
-			return nil
+			if len(args) < 2 {
+				fmt.Println(config.ProjectConfigJSONSchema)
+				return nil
+			}
+			return writeProjectSchema(args[1])
 		case "show":
 			if len(args) < 2 {
 				return fmt.Errorf("project name required for show command")
@@ -50,6 +70,12 @@ Available commands:
 			return showTemplates()
 		case "global":
 			return showGlobalConfig()
+		case "profile":
+			return runProfileCommand(args[1:])
+		case "webhooks":
+			return runWebhooksCommand(args[1:])
+		case "workspace-root":
+			return runWorkspaceRootCommand(args[1:])
 		default:
 			return fmt.Errorf("unknown config command: %s", subCommand)
 		}
@@ -122,12 +148,37 @@ func validateProjectConfig(projectName string) error {
 		fmt.Printf("   %s\n", err.Error())
 		return fmt.Errorf("project config validation failed: %w", err)
 	}
+	if err := checkConfigCompatibility(projectConfig); err != nil {
+		return err
+	}
+	if err := checkRestartPolicy(projectConfig); err != nil {
+		return err
+	}
+	if cfg.Settings != nil {
+		if err := config.ValidateResources("settings.default_resources", cfg.Settings.DefaultResources); err != nil {
+			fmt.Printf("error: %v\n", err)
+			return fmt.Errorf("global settings validation failed: %w", err)
+		}
+	}
 
 	fmt.Printf("Configuration for project '%s' is valid\n", projectName)
+	if changes := projectConfig.Normalized(); len(changes) > 0 {
+		fmt.Printf("\nNormalized values (what devbox passes to the engine):\n")
+		for _, c := range changes {
+			fmt.Printf("  %s\n", c)
+		}
+	}
 
 	fmt.Printf("\nConfiguration summary:\n")
 	fmt.Printf("  Name: %s\n", projectConfig.Name)
 	fmt.Printf("  Base image: %s\n", projectConfig.BaseImage)
+	if v := projectConfig.SchemaVersion; v > 0 {
+		if since, ok := config.SchemaVersions[v]; ok {
+			fmt.Printf("  Schema version: %d (devbox %s+)\n", v, since)
+		} else {
+			fmt.Printf("  Schema version: %d (newer than this devbox, which supports up to %d)\n", v, config.SchemaVersion)
+		}
+	}
 
 	if len(projectConfig.SetupCommands) > 0 {
 		fmt.Printf("  Setup commands: %d\n", len(projectConfig.SetupCommands))
@@ -142,12 +193,72 @@ func validateProjectConfig(projectName string) error {
 	}
 
 	if len(projectConfig.Volumes) > 0 {
-		fmt.Printf("  Volume mappings: %v\n", projectConfig.Volumes)
+		fmt.Printf("  Volume mappings:\n")
+		for _, v := range projectConfig.Volumes {
+			fmt.Printf("    %s\n", docker.ResolveVolume(v, project.WorkspacePath))
+		}
 	}
 
 	return nil
 }
 
+func lintProjectConfig(projectName string, fix bool) error {
+	if err := validateProjectName(projectName); err != nil {
+		return err
+	}
+
+	cfg, err := configManager.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	project, exists := cfg.GetProject(projectName)
+	if !exists {
+		return fmt.Errorf("project '%s' not found", projectName)
+	}
+
+	projectConfig, err := configManager.LoadProjectConfig(project.WorkspacePath)
+	if err != nil {
+		return fmt.Errorf("failed to load project configuration: %w", err)
+	}
+	if projectConfig == nil {
+		return fmt.Errorf("no devbox.json found for project '%s'", projectName)
+	}
+
+	findings := config.LintProjectConfig(projectConfig, cfg.Settings)
+	if fix {
+		if warnings := projectConfig.CompatibilityWarnings(); len(warnings) > 0 {
+			fmt.Printf("Warning: not fixing: devbox.json has fields this devbox does not know, and rewriting it would drop them\n")
+		} else if fixed := config.FixProjectConfig(projectConfig, findings); fixed > 0 {
+			if err := configManager.SaveProjectConfig(project.WorkspacePath, projectConfig); err != nil {
+				return err
+			}
+			fmt.Printf("Fixed %d finding(s) in devbox.json\n", fixed)
+			findings = config.LintProjectConfig(projectConfig, cfg.Settings)
+		}
+	}
+
+	if len(findings) == 0 {
+		fmt.Printf("No lint findings for project '%s'\n", projectName)
+		return nil
+	}
+
+	fixable := 0
+	fmt.Printf("Lint findings for project '%s':\n", projectName)
+	for _, f := range findings {
+		marker := ""
+		if f.Fixable {
+			marker = " (fixable)"
+			fixable++
+		}
+		fmt.Printf(" - %s%s\n", f, marker)
+	}
+	if fixable > 0 && !fix {
+		fmt.Printf("hint: run 'devbox config lint %s --fix' to fix %d of them\n", projectName, fixable)
+	}
+	return fmt.Errorf("%d lint finding(s)", len(findings))
+}
+
 func showProjectConfig(projectName string) error {
 	if err := validateProjectName(projectName); err != nil {
 		return err
@@ -229,6 +340,10 @@ func showProjectConfig(projectName string) error {
 		fmt.Printf("  User: %s\n", projectConfig.User)
 	}
 
+	if projectConfig.CreateUser {
+		fmt.Printf("  Create user: yes (matches your host UID/GID)\n")
+	}
+
 	if len(projectConfig.Capabilities) > 0 {
 		fmt.Printf("  Capabilities: %v\n", projectConfig.Capabilities)
 	}
@@ -254,7 +369,9 @@ func showProjectConfig(projectName string) error {
 		}
 	}
 
-	if projectConfig.HealthCheck != nil {
+	if projectConfig.HealthCheck.Disabled() {
+		fmt.Printf("  Health check: disabled\n")
+	} else if projectConfig.HealthCheck != nil {
 		fmt.Printf("  Health check:\n")
 		if len(projectConfig.HealthCheck.Test) > 0 {
 			fmt.Printf("    Test: %v\n", projectConfig.HealthCheck.Test)
@@ -265,6 +382,9 @@ func showProjectConfig(projectName string) error {
 		if projectConfig.HealthCheck.Timeout != "" {
 			fmt.Printf("    Timeout: %s\n", projectConfig.HealthCheck.Timeout)
 		}
+		if projectConfig.HealthCheck.StartPeriod != "" {
+			fmt.Printf("    Start period: %s\n", projectConfig.HealthCheck.StartPeriod)
+		}
 		if projectConfig.HealthCheck.Retries > 0 {
 			fmt.Printf("    Retries: %d\n", projectConfig.HealthCheck.Retries)
 		}
@@ -310,6 +430,73 @@ func showTemplates() error {
 	return nil
 }
 
+func configStrictness() string {
+	cfg, err := configManager.Load()
+	if err != nil || cfg.Settings == nil {
+		return config.StrictnessWarn
+	}
+	switch strictness := strings.ToLower(strings.TrimSpace(cfg.Settings.ConfigStrictness)); strictness {
+	case config.StrictnessStrict, config.StrictnessIgnore:
+		return strictness
+	case "", config.StrictnessWarn:
+		return config.StrictnessWarn
+	default:
+		fmt.Printf("Warning: unknown settings.config_strictness value %q; using %q\n", cfg.Settings.ConfigStrictness, config.StrictnessWarn)
+		return config.StrictnessWarn
+	}
+}
+
+func checkConfigCompatibility(projectConfig *config.ProjectConfig) error {
+	warnings := projectConfig.CompatibilityWarnings()
+	if len(warnings) == 0 {
+		return nil
+	}
+	switch configStrictness() {
+	case config.StrictnessIgnore:
+		return nil
+	case config.StrictnessStrict:
+		return fmt.Errorf("devbox.json is not fully supported by this devbox (settings.config_strictness is strict):\n - %s", strings.Join(warnings, "\n - "))
+	}
+	for _, w := range warnings {
+		fmt.Printf("Warning: devbox.json: %s\n", w)
+	}
+	return nil
+}
+
+func checkRestartPolicy(projectConfig *config.ProjectConfig) error {
+	cfg, err := configManager.Load()
+	if err != nil {
+		return nil
+	}
+	if err := config.ValidateRestartPolicy(cfg.Settings.DefaultRestart); err != nil {
+		return fmt.Errorf("settings.default_restart: %w", err)
+	}
+	policy, source := cfg.Settings.RestartPolicy(), "settings.default_restart"
+	if projectConfig != nil && projectConfig.Restart != "" {
+		if err := config.ValidateRestartPolicy(projectConfig.Restart); err != nil {
+			return fmt.Errorf("devbox.json: %w", err)
+		}
+		policy, source = projectConfig.Restart, "devbox.json"
+	}
+	if cfg.Settings.AutoStopOnExit && config.RestartSurvivesReboot(policy) {
+		fmt.Printf("Warning: restart policy '%s' (from %s) brings the box back after a reboot or daemon restart, even though auto_stop_on_exit is enabled\n", policy, source)
+		fmt.Printf("hint: set \"restart\": \"no\" or disable auto_stop_on_exit to make them agree\n")
+	}
+	return nil
+}
+
+func writeProjectSchema(path string) error {
+	if _, err := os.Stat(path); err == nil && !forceFlag {
+		return fmt.Errorf("%s already exists. Use --force to overwrite", path)
+	}
+	if err := os.WriteFile(path, []byte(config.ProjectConfigJSONSchema+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write schema: %w", err)
+	}
+	fmt.Printf("Wrote devbox.json schema to %s\n", path)
+	fmt.Printf("Reference it from devbox.json with: \"$schema\": \"%s\"\n", path)
+	return nil
+}
+
 func showGlobalConfig() error {
 	cfg, err := configManager.Load()
 	if err != nil {
@@ -320,9 +507,17 @@ func showGlobalConfig() error {
 
 	if cfg.Settings != nil {
 		fmt.Printf("Settings:\n")
+		if cfg.Settings.Profile != "" {
+			fmt.Printf("  Profile: %s\n", cfg.Settings.Profile)
+		}
 		fmt.Printf("  Default base image: %s\n", cfg.Settings.DefaultBaseImage)
+		fmt.Printf("  Workspace root: %s\n", firstNonEmpty(cfg.Settings.WorkspaceRoot, "~/devbox"))
 		fmt.Printf("  Auto update: %t\n", cfg.Settings.AutoUpdate)
 		fmt.Printf("  Auto stop on exit: %t\n", cfg.Settings.AutoStopOnExit)
+		fmt.Printf("  Default restart policy: %s\n", cfg.Settings.RestartPolicy())
+		if len(cfg.Settings.Webhooks) > 0 {
+			fmt.Printf("  Webhooks: %d (see 'devbox config webhooks list')\n", len(cfg.Settings.Webhooks))
+		}
 
 		if cfg.Settings.ConfigTemplatesPath != "" {
 			fmt.Printf("  Templates path: %s\n", cfg.Settings.ConfigTemplatesPath)
@@ -347,4 +542,7 @@ func showGlobalConfig() error {
 
 func init() {
 	configCmd.Flags().BoolVarP(&forceFlag, "force", "f", false, "Force operation, overwriting existing files")
+	configCmd.Flags().BoolVar(&workspaceRootMove, "move", false, "With workspace-root, move projects in the old root (and remount their boxes)")
+	configCmd.Flags().BoolVar(&workspaceRootDryRun, "dry-run", false, "With workspace-root, show what would change without changing anything")
+	configCmd.Flags().BoolVar(&configLintFix, "fix", false, "With lint, rewrite devbox.json to fix findings that are safe to change automatically")
 }
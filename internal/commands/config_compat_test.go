@@ -0,0 +1,52 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckConfigCompatibility(t *testing.T) {
+	tests := []struct {
+		strictness string
+		wantErr    bool
+	}{
+		{strictness: ""},
+		{strictness: "warn"},
+		{strictness: "ignore"},
+		{strictness: "bogus"},
+		{strictness: "strict", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.strictness, func(t *testing.T) {
+			cm := withTempConfigManager(t)
+
+			cfg, _ := cm.Load()
+			cfg.Settings.ConfigStrictness = tt.strictness
+			if err := cm.Save(cfg); err != nil {
+				t.Fatal(err)
+			}
+
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "devbox.json"), []byte(`{"name": "demo", "future_field": 1}`), 0644); err != nil {
+				t.Fatal(err)
+			}
+			pc, err := cm.LoadProjectConfig(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = checkConfigCompatibility(pc)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "future_field") {
+					t.Fatalf("checkConfigCompatibility() = %v, want error naming future_field", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("checkConfigCompatibility() = %v, want nil", err)
+			}
+		})
+	}
+}
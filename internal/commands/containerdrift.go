@@ -0,0 +1,97 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+)
+
+type containerDrift struct {
+	Field   string
+	Locked  string
+	Current string
+}
+
+func (d containerDrift) String() string {
+	current := d.Current
+	if current == "" {
+		current = "(none)"
+	}
+	return fmt.Sprintf("container %s mismatch: lock=%s current=%s", d.Field, d.Locked, current)
+}
+
+func (d containerDrift) updatable() bool {
+	switch d.Field {
+	case "restart", "cpus", "memory":
+		return true
+	}
+	return false
+}
+
+func currentContainer(boxName string) (lockContainer, map[string]string) {
+	env, workdir, user, restart, labels, _, resources, network := dockerClient.GetContainerMeta(boxName)
+	env, _ = withoutSecrets(env, labels)
+	imageEnv, _ := dockerClient.GetBoxImageEnv(boxName)
+	return lockContainer{WorkingDir: workdir, User: user, Restart: restart, Network: network, Resources: resources, Environment: env}, imageEnv
+}
+
+func diffContainer(locked, current lockContainer, imageEnv map[string]string) []containerDrift {
+	var drifts []containerDrift
+	check := func(field, lockedValue, currentValue string) {
+		if lockedValue != "" && strings.TrimSpace(lockedValue) != strings.TrimSpace(currentValue) {
+			drifts = append(drifts, containerDrift{Field: field, Locked: lockedValue, Current: currentValue})
+		}
+	}
+	check("restart", locked.Restart, current.Restart)
+	check("network", locked.Network, current.Network)
+	check("user", locked.User, current.User)
+	check("cpus", locked.Resources["cpus"], current.Resources["cpus"])
+	check("memory", locked.Resources["memory"], current.Resources["memory"])
+	return append(drifts, diffEnvironment(locked.Environment, current.Environment, imageEnv)...)
+}
+
+func containerDriftStrings(drifts []containerDrift) []string {
+	out := make([]string, 0, len(drifts))
+	for _, d := range drifts {
+		out = append(out, d.String())
+	}
+	return out
+}
+
+func reconcileContainer(boxName, projectName string, locked lockContainer) (int, error) {
+	current, imageEnv := currentContainer(boxName)
+	drifts := diffContainer(locked, current, imageEnv)
+	if len(drifts) == 0 {
+		return 0, nil
+	}
+
+	var restart, cpus, memory string
+	var recreate []containerDrift
+	for _, d := range drifts {
+		switch {
+		case !d.updatable():
+			recreate = append(recreate, d)
+		case d.Field == "restart":
+			restart = d.Locked
+		case d.Field == "cpus":
+			cpus = d.Locked
+		case d.Field == "memory":
+			memory = d.Locked
+		}
+	}
+
+	updated := len(drifts) - len(recreate)
+	if updated > 0 {
+		fmt.Printf("Updating box settings to match lockfile (%d change(s))...\n", updated)
+		if err := dockerClient.UpdateContainer(boxName, restart, cpus, memory); err != nil {
+			return 0, err
+		}
+	}
+	if len(recreate) > 0 {
+		fmt.Printf("Warning: these settings cannot be changed on an existing box:\n")
+		for _, d := range recreate {
+			fmt.Printf(" - %s\n", d)
+		}
+		fmt.Printf("hint: set them in devbox.json, then run 'devbox update %s' to recreate the box and 'devbox apply %s' to restore packages\n", projectName, projectName)
+	}
+	return updated, nil
+}
@@ -8,13 +8,16 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+
+	"devbox/internal/config"
+	"devbox/internal/docker"
 )
 
 var destroyCmd = &cobra.Command{
 	Use:   "destroy <project>",
 	Short: "Stop and remove a project box",
-	Long: `Stop and remove the Docker box for the specified project.
-Removes empty project directories automatically.
+	Long: `Stop and remove the Docker box for the specified project, along with its
+service containers and project network. Removes empty project directories automatically.
 
 Special usage:
   devbox destroy --cleanup-orphaned  Remove boxes not tracked in config`,
@@ -39,10 +42,16 @@ Special usage:
 		if !exists {
 			return fmt.Errorf("project '%s' not found", projectName)
 		}
+		kubeConfig := kubernetesProjectConfig(project)
 
 		if !forceFlag {
 			fmt.Printf("This will destroy the box '%s' for project '%s'.\n", project.BoxName, projectName)
 			fmt.Printf("Empty project directories will be automatically removed.\n")
+			if kubeConfig != nil {
+				fmt.Printf("The pod and its workspace claim are removed too; run 'devbox sync %s --pull' first to keep changes made in the box.\n", projectName)
+			} else if workspaceInVolume(project) {
+				fmt.Printf("The workspace volume '%s' is removed too; run 'devbox sync %s --pull' first to keep changes made in the box.\n", docker.WorkspaceVolumeName(project.BoxName), projectName)
+			}
 			fmt.Print("Are you sure? (y/N): ")
 
 			reader := bufio.NewReader(os.Stdin)
@@ -58,20 +67,44 @@ Special usage:
 			}
 		}
 
-		exists, err = dockerClient.BoxExists(project.BoxName)
-		if err != nil {
-			return fmt.Errorf("failed to check box status: %w", err)
-		}
+		var hooks hookTarget
+		if kubeConfig != nil {
+			kubeDestroy(project, kubeConfig)
+		} else {
+			exists, err = dockerClient.BoxExists(project.BoxName)
+			if err != nil {
+				return fmt.Errorf("failed to check box status: %w", err)
+			}
 
-		if exists {
+			hooks = projectHookTarget(project)
+			if exists {
+				status, _ := dockerClient.GetBoxStatus(project.BoxName)
+				hooks.boxStopped = status != "running"
+			} else {
+				hooks.boxStopped = true
+			}
+			if err := runHooks(config.HookPreDestroy, hooks); err != nil {
+				return err
+			}
+			if exists {
 
-			fmt.Printf("Stopping and removing box '%s'...\n", project.BoxName)
-			if err := dockerClient.RemoveBox(project.BoxName); err != nil {
-				fmt.Printf("Warning: failed to remove box: %v\n", err)
+				fmt.Printf("Stopping and removing box '%s'...\n", project.BoxName)
+				if err := dockerClient.RemoveBox(project.BoxName); err != nil {
+					fmt.Printf("Warning: failed to remove box: %v\n", err)
 
+				}
+			} else {
+				fmt.Printf("Box '%s' not found (already removed)\n", project.BoxName)
+			}
+			stopSyncAgent(projectName, true)
+			if workspaceInVolume(project) {
+				if err := dockerClient.RemoveVolume(docker.WorkspaceVolumeName(project.BoxName)); err != nil {
+					fmt.Printf("Warning: %v\n", err)
+				}
+			}
+			if err := removeServices(project.BoxName); err != nil {
+				fmt.Printf("Warning: %v\n", err)
 			}
-		} else {
-			fmt.Printf("Box '%s' not found (already removed)\n", project.BoxName)
 		}
 
 		cfg.RemoveProject(projectName)
@@ -80,8 +113,14 @@ Special usage:
 		}
 
 		fmt.Printf("Project '%s' destroyed successfully!\n", projectName)
+		hookErr := runHooks(config.HookPostDestroy, hooks)
 
-		if _, err := os.Stat(project.WorkspacePath); err == nil {
+		if isReviewWorkspace(project.WorkspacePath) {
+			fmt.Printf("Removing review workspace: %s\n", project.WorkspacePath)
+			if err := os.RemoveAll(project.WorkspacePath); err != nil {
+				fmt.Printf("Warning: failed to remove review workspace: %v\n", err)
+			}
+		} else if _, err := os.Stat(project.WorkspacePath); err == nil {
 
 			isEmpty, err := isDirEmpty(project.WorkspacePath)
 			if err != nil {
@@ -101,7 +140,7 @@ Special usage:
 			}
 		}
 
-		return nil
+		return hookErr
 	},
 }
 
@@ -131,6 +170,7 @@ func cleanupOrphanedboxes() error {
 	if err != nil {
 		return fmt.Errorf("failed to list boxes: %w", err)
 	}
+	boxes = ownedBoxes(boxes)
 
 	trackedBoxes := make(map[string]bool)
 	for _, project := range cfg.GetProjects() {
@@ -138,11 +178,13 @@ func cleanupOrphanedboxes() error {
 	}
 
 	var orphanedBoxes []string
+	metadata := make(map[string]docker.BoxMetadata)
 	for _, box := range boxes {
 		for _, name := range box.Names {
 			cleanName := strings.TrimPrefix(name, "/")
 			if !trackedBoxes[cleanName] {
 				orphanedBoxes = append(orphanedBoxes, cleanName)
+				metadata[cleanName] = box.Metadata
 			}
 		}
 	}
@@ -153,9 +195,7 @@ func cleanupOrphanedboxes() error {
 	}
 
 	fmt.Printf("Found %d orphaned devbox box(s):\n", len(orphanedBoxes))
-	for _, boxName := range orphanedBoxes {
-		fmt.Printf("  - %s\n", boxName)
-	}
+	printOrphanedBoxes(orphanedBoxes, metadata)
 
 	if !forceFlag {
 		fmt.Print("\nRemove these orphaned boxes? (y/N): ")
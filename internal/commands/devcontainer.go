@@ -22,7 +22,7 @@ type devContainer struct {
 
 var devcontainerCmd = &cobra.Command{
 	Use:   "devcontainer",
-	Short: "Generate VS Code devcontainer.json from devbox.json",
+	Short: "Convert between devbox.json and VS Code devcontainer.json",
 	Args:  cobra.NoArgs,
 }
 
@@ -0,0 +1,657 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"devbox/internal/config"
+)
+
+var devcontainerVariable = regexp.MustCompile(`\$\{[^}]*\}`)
+
+var devcontainerFeaturePackages = map[string][]string{
+	"common-utils": {"curl", "wget", "ca-certificates", "git", "sudo", "less"},
+	"git":          {"git"},
+	"github-cli":   {"gh"},
+	"go":           {"golang-go"},
+	"node":         {"nodejs", "npm"},
+	"python":       {"python3", "python3-pip", "python3-venv"},
+}
+
+var devcontainerImportCmd = &cobra.Command{
+	Use:   "import [path]",
+	Short: "Create devbox.json from an existing devcontainer.json",
+	Long: `Read a devcontainer.json (default .devcontainer/devcontainer.json, then .devcontainer.json)
+and write an equivalent devbox.json in the current folder. The image or Dockerfile build,
+a few common features, forwarded ports, lifecycle commands, mounts, environment, user,
+and supported runArgs are translated; everything else is listed as not imported so you
+can port it by hand.
+
+Examples:
+  devbox devcontainer import
+  devbox devcontainer import --stdout
+  devbox devcontainer import .devcontainer/python/devcontainer.json --force`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get cwd: %w", err)
+		}
+		path, err := findDevcontainerFile(cwd, args)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		pc, report, err := importDevcontainer(data, filepath.Dir(path), cwd)
+		if err != nil {
+			return fmt.Errorf("failed to import %s: %w", path, err)
+		}
+		return writeImportedConfig(cwd, path, pc, report, importStdoutFlag)
+	},
+}
+
+func init() {
+	devcontainerImportCmd.Flags().BoolVar(&importStdoutFlag, "stdout", false, "Print devbox.json instead of writing it")
+	devcontainerImportCmd.Flags().BoolVarP(&forceFlag, "force", "f", false, "Replace an existing devbox.json")
+	devcontainerCmd.AddCommand(devcontainerImportCmd)
+}
+
+func findDevcontainerFile(cwd string, args []string) (string, error) {
+	if len(args) == 1 {
+		path := args[0]
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(cwd, path)
+		}
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			path = filepath.Join(path, "devcontainer.json")
+		}
+		return path, nil
+	}
+	for _, p := range []string{filepath.Join(cwd, ".devcontainer", "devcontainer.json"), filepath.Join(cwd, ".devcontainer.json")} {
+		if _, err := os.Stat(p); err == nil {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("no devcontainer.json found in %s (checked .devcontainer/devcontainer.json, .devcontainer.json)", cwd)
+}
+
+func stripJSONC(data []byte) []byte {
+	var out []byte
+	inString, escaped := false, false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if inString {
+			out = append(out, c)
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch {
+		case c == '"':
+			inString = true
+			out = append(out, c)
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			if i < len(data) {
+				out = append(out, '\n')
+			}
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			end := strings.Index(string(data[i+2:]), "*/")
+			if end == -1 {
+				return out
+			}
+			i += end + 3
+		case c == ',':
+			j := i + 1
+			for j < len(data) && strings.ContainsRune(" \t\r\n", rune(data[j])) {
+				j++
+			}
+			if j < len(data) && (data[j] == '}' || data[j] == ']') {
+				continue
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func importDevcontainer(data []byte, dcDir, projectDir string) (*config.ProjectConfig, *importReport, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(stripJSONC(data), &raw); err != nil {
+		return nil, nil, fmt.Errorf("invalid devcontainer.json: %w", err)
+	}
+	if _, ok := raw["dockerComposeFile"]; ok {
+		return nil, nil, fmt.Errorf("devcontainers based on dockerComposeFile are not supported\nhint: set \"image\" or \"build\" in devcontainer.json, or write devbox.json by hand with services")
+	}
+
+	pc := &config.ProjectConfig{
+		Schema:        config.ProjectConfigSchemaURL,
+		SchemaVersion: config.SchemaVersion,
+		Name:          filepath.Base(projectDir),
+		Environment:   map[string]string{},
+	}
+	r := &importReport{}
+	str := func(key string) string {
+		var s string
+		_ = json.Unmarshal(raw[key], &s)
+		return s
+	}
+
+	if name := str("name"); name != "" {
+		if validateProjectName(name) == nil {
+			pc.Name = name
+			r.ok("name: %s", name)
+		} else {
+			r.skip("name: '%s' is not a valid project name; using '%s'", name, pc.Name)
+		}
+	}
+	if wf := str("workspaceFolder"); wf != "" {
+		if strings.Contains(wf, "${") {
+			pc.WorkingDir = "/workspace"
+			r.skip("workspaceFolder: '%s' uses variables; using /workspace", wf)
+		} else {
+			pc.WorkingDir = wf
+			r.ok("workspaceFolder -> working_dir")
+		}
+	}
+
+	var setup []string
+	var features []string
+	keys := make([]string, 0, len(raw))
+	for k := range raw {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := raw[key]
+		switch key {
+		case "name", "workspaceFolder", "$schema", "onCreateCommand", "updateContentCommand", "postCreateCommand":
+		case "image":
+			pc.BaseImage = str("image")
+			r.ok("image -> base_image")
+		case "build", "dockerFile", "context":
+			if pc.Build != nil {
+				continue
+			}
+			if err := importDevcontainerBuild(raw, dcDir, projectDir, pc, r); err != nil {
+				return nil, nil, err
+			}
+		case "features":
+			features = importDevcontainerFeatures(value, r)
+		case "forwardPorts", "appPort":
+			importDevcontainerPorts(key, value, pc, r)
+		case "mounts":
+			var mounts []json.RawMessage
+			if err := json.Unmarshal(value, &mounts); err != nil {
+				r.skip("mounts: expected a list")
+				continue
+			}
+			for _, m := range mounts {
+				if volume, reason := devcontainerMount(m); reason != "" {
+					r.skip("mounts: %s", reason)
+				} else {
+					pc.Volumes = append(pc.Volumes, volume)
+					r.ok("mount %s -> volumes", volume)
+				}
+			}
+		case "containerEnv", "remoteEnv":
+			var env map[string]string
+			if err := json.Unmarshal(value, &env); err != nil {
+				r.skip("%s: expected an object of strings", key)
+				continue
+			}
+			importDevcontainerEnv(key, env, pc, r)
+		case "containerUser", "remoteUser":
+			user := str(key)
+			if pc.User != "" && pc.User != user {
+				r.skip("%s: '%s' differs from '%s'; devbox uses one user", key, user, pc.User)
+				continue
+			}
+			pc.User = user
+			r.ok("%s -> user", key)
+		case "updateRemoteUserUID":
+			var update bool
+			if err := json.Unmarshal(value, &update); err != nil {
+				r.skip("updateRemoteUserUID: expected true or false")
+				continue
+			}
+			pc.CreateUser = update
+			r.ok("updateRemoteUserUID -> create_user")
+		case "capAdd":
+			var caps []string
+			if err := json.Unmarshal(value, &caps); err == nil {
+				pc.Capabilities = append(pc.Capabilities, caps...)
+				r.ok("capAdd -> capabilities")
+			}
+		case "runArgs":
+			var args []string
+			if err := json.Unmarshal(value, &args); err != nil {
+				r.skip("runArgs: expected a list of strings")
+				continue
+			}
+			importDevcontainerRunArgs(args, pc, r)
+		case "hostRequirements":
+			importDevcontainerHostRequirements(value, pc, r)
+		case "initializeCommand", "postStartCommand":
+			cmds, err := devcontainerCommands(value)
+			if err != nil {
+				r.skip("%s: %v", key, err)
+				continue
+			}
+			if pc.Hooks == nil {
+				pc.Hooks = map[string][]config.Hook{}
+			}
+			for _, c := range cmds {
+				if key == "initializeCommand" {
+					pc.Hooks[config.HookPreUp] = append(pc.Hooks[config.HookPreUp], config.Hook{Run: c})
+				} else {
+					pc.Hooks[config.HookPostUp] = append(pc.Hooks[config.HookPostUp], config.Hook{Run: c, In: config.HookInBox})
+				}
+			}
+			if key == "initializeCommand" {
+				r.ok("initializeCommand -> hooks.pre_up (host)")
+			} else {
+				r.ok("postStartCommand -> hooks.post_up (box); it runs after each 'devbox up' rather than each start")
+			}
+		case "customizations":
+			r.skip("customizations: editor settings and extensions stay in devcontainer.json")
+		case "workspaceMount":
+			r.skip("workspaceMount: devbox always mounts the project folder at working_dir")
+		default:
+			r.skip("%s: not supported by devbox", key)
+		}
+	}
+
+	if len(features) > 0 {
+		setup = append(setup, "apt-get install -y "+strings.Join(features, " "))
+	}
+	for _, key := range []string{"onCreateCommand", "updateContentCommand", "postCreateCommand"} {
+		value, ok := raw[key]
+		if !ok {
+			continue
+		}
+		cmds, err := devcontainerCommands(value)
+		if err != nil {
+			r.skip("%s: %v", key, err)
+			continue
+		}
+		setup = append(setup, cmds...)
+		r.ok("%s -> setup_commands", key)
+	}
+	pc.SetupCommands = setup
+
+	if pc.BaseImage == "" && pc.Build == nil {
+		pc.BaseImage = "ubuntu:22.04"
+		r.skip("image: none set; using ubuntu:22.04")
+	}
+	if len(pc.Environment) == 0 {
+		pc.Environment = nil
+	}
+	return pc, r, nil
+}
+
+func importDevcontainerBuild(raw map[string]json.RawMessage, dcDir, projectDir string, pc *config.ProjectConfig, r *importReport) error {
+	var b struct {
+		Dockerfile string            `json:"dockerfile"`
+		Context    string            `json:"context"`
+		Args       map[string]string `json:"args"`
+		Target     string            `json:"target"`
+		CacheFrom  interface{}       `json:"cacheFrom"`
+	}
+	if v, ok := raw["build"]; ok {
+		if err := json.Unmarshal(v, &b); err != nil {
+			return fmt.Errorf("invalid build: %w", err)
+		}
+	}
+	if b.Dockerfile == "" {
+		_ = json.Unmarshal(raw["dockerFile"], &b.Dockerfile)
+	}
+	if b.Context == "" {
+		_ = json.Unmarshal(raw["context"], &b.Context)
+	}
+	if b.Dockerfile == "" {
+		r.skip("build: no dockerfile set")
+		return nil
+	}
+	contextDir := filepath.Join(dcDir, firstNonEmpty(b.Context, "."))
+	dockerfile := filepath.Join(dcDir, b.Dockerfile)
+	relContext, err := filepath.Rel(projectDir, contextDir)
+	if err != nil || strings.HasPrefix(relContext, "..") {
+		return fmt.Errorf("build context %s is outside the project folder", contextDir)
+	}
+	relDockerfile, err := filepath.Rel(contextDir, dockerfile)
+	if err != nil {
+		return fmt.Errorf("failed to resolve dockerfile %s: %w", dockerfile, err)
+	}
+	pc.Build = &config.Build{Dockerfile: filepath.ToSlash(relDockerfile), Args: b.Args, Target: b.Target}
+	if relContext != "." {
+		pc.Build.Context = filepath.ToSlash(relContext)
+	}
+	r.ok("build -> build (dockerfile %s, context %s)", pc.Build.Dockerfile, firstNonEmpty(pc.Build.Context, "."))
+	if b.CacheFrom != nil {
+		r.skip("build.cacheFrom: not supported by devbox")
+	}
+	return nil
+}
+
+func importDevcontainerFeatures(value json.RawMessage, r *importReport) []string {
+	var features map[string]json.RawMessage
+	if err := json.Unmarshal(value, &features); err != nil {
+		r.skip("features: expected an object")
+		return nil
+	}
+	ids := make([]string, 0, len(features))
+	for id := range features {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	var packages []string
+	seen := map[string]bool{}
+	for _, id := range ids {
+		name, official := strings.CutPrefix(id, "ghcr.io/devcontainers/features/")
+		name, _, _ = strings.Cut(name, ":")
+		pkgs, ok := devcontainerFeaturePackages[name]
+		if !ok || !official {
+			r.skip("features.%s: not supported; add its install steps to setup_commands", id)
+			continue
+		}
+		for _, p := range pkgs {
+			if !seen[p] {
+				seen[p] = true
+				packages = append(packages, p)
+			}
+		}
+		var opts map[string]interface{}
+		if json.Unmarshal(features[id], &opts) == nil && len(opts) > 0 {
+			r.ok("features.%s -> apt-get install %s (options ignored; the distribution's version is installed)", id, strings.Join(pkgs, " "))
+		} else {
+			r.ok("features.%s -> apt-get install %s", id, strings.Join(pkgs, " "))
+		}
+	}
+	return packages
+}
+
+func importDevcontainerPorts(key string, value json.RawMessage, pc *config.ProjectConfig, r *importReport) {
+	var ports []interface{}
+	if err := json.Unmarshal(value, &ports); err != nil {
+		var single interface{}
+		if json.Unmarshal(value, &single) != nil {
+			r.skip("%s: expected a port or a list of ports", key)
+			return
+		}
+		ports = []interface{}{single}
+	}
+	for _, p := range ports {
+		var port string
+		switch v := p.(type) {
+		case float64:
+			port = strconv.Itoa(int(v))
+		case string:
+			port = v
+		}
+		if _, err := strconv.Atoi(port); err == nil {
+			pc.Ports = append(pc.Ports, port+":"+port)
+			r.ok("%s %s -> ports", key, port)
+			continue
+		}
+		if key == "appPort" && strings.Contains(port, ":") {
+			pc.Ports = append(pc.Ports, port)
+			r.ok("%s %s -> ports", key, port)
+			continue
+		}
+		r.skip("%s: '%v' forwards another container's port; use services", key, p)
+	}
+}
+
+func devcontainerCommands(value json.RawMessage) ([]string, error) {
+	var s string
+	if json.Unmarshal(value, &s) == nil {
+		if strings.TrimSpace(s) == "" {
+			return nil, nil
+		}
+		return []string{s}, nil
+	}
+	var args []string
+	if json.Unmarshal(value, &args) == nil {
+		if len(args) == 0 {
+			return nil, nil
+		}
+		return []string{devcontainerArgsCommand(args)}, nil
+	}
+	var parallel map[string]json.RawMessage
+	if err := json.Unmarshal(value, &parallel); err != nil {
+		return nil, fmt.Errorf("expected a string, a list, or an object of commands")
+	}
+	names := make([]string, 0, len(parallel))
+	for name := range parallel {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var cmds []string
+	for _, name := range names {
+		sub, err := devcontainerCommands(parallel[name])
+		if err != nil {
+			return nil, err
+		}
+		cmds = append(cmds, sub...)
+	}
+	return cmds, nil
+}
+
+func devcontainerArgsCommand(args []string) string {
+	quoted := make([]string, 0, len(args))
+	for _, a := range args {
+		if a != "" && !strings.ContainsAny(a, " \t\n'\"$`\\|&;<>(){}*?[]#~!") {
+			quoted = append(quoted, a)
+			continue
+		}
+		quoted = append(quoted, "'"+escapeBash(a)+"'")
+	}
+	return strings.Join(quoted, " ")
+}
+
+func devcontainerLocalPath(path string) (string, bool) {
+	switch {
+	case strings.HasPrefix(path, "${localWorkspaceFolder}"):
+		path = "." + strings.TrimPrefix(path, "${localWorkspaceFolder}")
+	case strings.HasPrefix(path, "${localEnv:HOME}"):
+		path = "~" + strings.TrimPrefix(path, "${localEnv:HOME}")
+	case strings.HasPrefix(path, "${env:HOME}"):
+		path = "~" + strings.TrimPrefix(path, "${env:HOME}")
+	}
+	if strings.Contains(path, "${") {
+		return path, false
+	}
+	return path, true
+}
+
+func devcontainerMount(raw json.RawMessage) (string, string) {
+	fields := map[string]string{}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		for _, part := range strings.Split(s, ",") {
+			k, v, _ := strings.Cut(strings.TrimSpace(part), "=")
+			switch k {
+			case "src":
+				k = "source"
+			case "dst", "destination":
+				k = "target"
+			}
+			if k == "readonly" || k == "ro" {
+				v = "true"
+			}
+			fields[k] = v
+		}
+	} else {
+		var obj map[string]interface{}
+		if err := json.Unmarshal(raw, &obj); err != nil {
+			return "", "expected a string or an object"
+		}
+		for k, v := range obj {
+			fields[k] = fmt.Sprint(v)
+		}
+		s = fmt.Sprintf("source=%s,target=%s,type=%s", fields["source"], fields["target"], fields["type"])
+	}
+	switch fields["type"] {
+	case "", "bind", "volume":
+	default:
+		return "", fmt.Sprintf("'%s': %s mounts are not supported", s, fields["type"])
+	}
+	source, target := fields["source"], fields["target"]
+	if source == "" || target == "" {
+		return "", fmt.Sprintf("'%s': needs a source and a target", s)
+	}
+	if strings.Contains(target, "${") {
+		return "", fmt.Sprintf("'%s': the target uses variables", s)
+	}
+	if fields["type"] != "volume" {
+		local, ok := devcontainerLocalPath(source)
+		if !ok {
+			return "", fmt.Sprintf("'%s': the source uses variables devbox can't resolve", s)
+		}
+		source = local
+	} else if strings.Contains(source, "${") {
+		return "", fmt.Sprintf("'%s': the volume name uses variables", s)
+	}
+	volume := source + ":" + target
+	if fields["readonly"] == "true" {
+		volume += ":ro"
+	}
+	return volume, ""
+}
+
+func importDevcontainerEnv(key string, env map[string]string, pc *config.ProjectConfig, r *importReport) {
+	for _, k := range sortedKeys(env) {
+		v := env[k]
+		if m := devcontainerVariable.FindString(v); m == v && strings.HasPrefix(v, "${localEnv:") && config.IsSecretName(k) {
+			name := strings.TrimSuffix(strings.TrimPrefix(v, "${localEnv:"), "}")
+			if config.IsSecretName(name) {
+				if pc.Secrets == nil {
+					pc.Secrets = map[string]config.Secret{}
+				}
+				pc.Secrets[k] = config.Secret{From: "env://" + name}
+				r.ok("%s.%s -> secrets (env://%s)", key, k, name)
+				continue
+			}
+		}
+		if devcontainerVariable.MatchString(v) {
+			r.skip("%s.%s: '%s' uses variables devbox can't resolve", key, k, v)
+			continue
+		}
+		pc.Environment[k] = v
+		r.ok("%s.%s -> environment", key, k)
+	}
+}
+
+func importDevcontainerRunArgs(args []string, pc *config.ProjectConfig, r *importReport) {
+	for i := 0; i < len(args); i++ {
+		flag, value, hasValue := strings.Cut(args[i], "=")
+		takesValue := map[string]bool{
+			"--cap-add": true, "--gpus": true, "--memory": true, "-m": true, "--cpus": true,
+			"--network": true, "--net": true, "-e": true, "--env": true, "--label": true, "-l": true,
+			"-p": true, "--publish": true, "-v": true, "--volume": true,
+		}[flag]
+		if takesValue && !hasValue && i+1 < len(args) {
+			i++
+			value = args[i]
+		}
+		switch flag {
+		case "--cap-add":
+			pc.Capabilities = append(pc.Capabilities, value)
+		case "--gpus":
+			pc.Gpus = value
+		case "--memory", "-m", "--cpus":
+			if pc.Resources == nil {
+				pc.Resources = &config.Resources{}
+			}
+			if flag == "--cpus" {
+				pc.Resources.CPUs = value
+			} else {
+				pc.Resources.Memory = config.NormalizeMemory(value)
+			}
+		case "--network", "--net":
+			pc.Network = value
+		case "-e", "--env":
+			k, v, ok := strings.Cut(value, "=")
+			if !ok {
+				r.skip("runArgs: '%s %s' passes a host variable; use secrets with env://%s", flag, value, value)
+				continue
+			}
+			pc.Environment[k] = v
+		case "--label", "-l":
+			k, v, _ := strings.Cut(value, "=")
+			if pc.Labels == nil {
+				pc.Labels = map[string]string{}
+			}
+			pc.Labels[k] = v
+		case "-p", "--publish":
+			pc.Ports = append(pc.Ports, value)
+		case "-v", "--volume":
+			pc.Volumes = append(pc.Volumes, value)
+		default:
+			r.skip("runArgs: '%s' is not supported by devbox", args[i])
+			continue
+		}
+		r.ok("runArgs %s %s", flag, value)
+	}
+}
+
+func importDevcontainerHostRequirements(value json.RawMessage, pc *config.ProjectConfig, r *importReport) {
+	var req struct {
+		CPUs    interface{} `json:"cpus"`
+		Memory  string      `json:"memory"`
+		Storage string      `json:"storage"`
+		GPU     interface{} `json:"gpu"`
+	}
+	if err := json.Unmarshal(value, &req); err != nil {
+		r.skip("hostRequirements: expected an object")
+		return
+	}
+	if req.CPUs != nil || req.Memory != "" {
+		if pc.Resources == nil {
+			pc.Resources = &config.Resources{}
+		}
+		if req.CPUs != nil {
+			pc.Resources.CPUs = fmt.Sprint(req.CPUs)
+			r.ok("hostRequirements.cpus -> resources.cpus (a limit in devbox, a minimum in devcontainers)")
+		}
+		if req.Memory != "" {
+			pc.Resources.Memory = config.NormalizeMemory(req.Memory)
+			r.ok("hostRequirements.memory -> resources.memory (a limit in devbox, a minimum in devcontainers)")
+		}
+	}
+	switch gpu := req.GPU.(type) {
+	case bool:
+		if gpu {
+			pc.Gpus = "all"
+			r.ok("hostRequirements.gpu -> gpus")
+		}
+	case nil:
+	default:
+		r.skip("hostRequirements.gpu: '%v' is not supported; set gpus in devbox.json", gpu)
+	}
+	if req.Storage != "" {
+		r.skip("hostRequirements.storage: not supported by devbox")
+	}
+}
@@ -0,0 +1,119 @@
+package commands
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"devbox/internal/config"
+)
+
+func TestStripJSONC(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{`{"a": 1} // trailing`, `{"a": 1} `},
+		{"{\n  // comment\n  \"a\": \"x // not a comment\"\n}", "{\n  \n  \"a\": \"x // not a comment\"\n}"},
+		{`{"a": /* inline */ 1}`, `{"a":  1}`},
+		{`{"a": [1, 2,], "b": "\"/*\"",}`, `{"a": [1, 2], "b": "\"/*\""}`},
+	}
+	for _, tt := range tests {
+		if got := string(stripJSONC([]byte(tt.in))); got != tt.want {
+			t.Errorf("stripJSONC(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestImportDevcontainer(t *testing.T) {
+	project := filepath.Join(t.TempDir(), "webapp")
+	dcDir := filepath.Join(project, ".devcontainer")
+	data := `{
+  // a typical generated devcontainer
+  "name": "My App",
+  "build": {"dockerfile": "Dockerfile", "context": "..", "args": {"VARIANT": "3.12"}},
+  "features": {
+    "ghcr.io/devcontainers/features/node:1": {"version": "20"},
+    "ghcr.io/devcontainers/features/git:1": {},
+    "ghcr.io/example/features/custom:1": {}
+  },
+  "forwardPorts": [3000, "8080", "db:5432"],
+  "onCreateCommand": ["pip", "install", "-r", "requirements dev.txt"],
+  "postCreateCommand": {"npm": "npm ci", "db": "make migrate"},
+  "postStartCommand": "make serve",
+  "initializeCommand": "./scripts/prepare.sh",
+  "mounts": [
+    "source=${localWorkspaceFolder}/data,target=/data,type=bind,readonly",
+    "source=${localEnv:HOME}/.ssh,target=/root/.ssh,type=bind",
+    "source=cache,target=/cache,type=volume",
+    {"type": "tmpfs", "target": "/tmp"}
+  ],
+  "workspaceFolder": "/workspaces/app",
+  "containerEnv": {"APP_ENV": "dev", "TOKEN": "${localEnv:GH_TOKEN}", "PATH_X": "${containerEnv:PATH}"},
+  "remoteUser": "vscode",
+  "updateRemoteUserUID": true,
+  "runArgs": ["--cap-add=SYS_PTRACE", "--memory", "4gb", "--init"],
+  "hostRequirements": {"cpus": 2},
+  "customizations": {"vscode": {"extensions": ["golang.go"]}},
+  "shutdownAction": "stopContainer",
+}`
+	pc, report, err := importDevcontainer([]byte(data), dcDir, project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &config.ProjectConfig{
+		Schema:        config.ProjectConfigSchemaURL,
+		SchemaVersion: config.SchemaVersion,
+		Name:          "webapp",
+		Build:         &config.Build{Dockerfile: ".devcontainer/Dockerfile", Args: map[string]string{"VARIANT": "3.12"}},
+		Ports:         []string{"3000:3000", "8080:8080"},
+		SetupCommands: []string{"apt-get install -y git nodejs npm", "pip install -r 'requirements dev.txt'", "make migrate", "npm ci"},
+		Hooks: map[string][]config.Hook{
+			config.HookPreUp:  {{Run: "./scripts/prepare.sh"}},
+			config.HookPostUp: {{Run: "make serve", In: config.HookInBox}},
+		},
+		Volumes:      []string{"./data:/data:ro", "~/.ssh:/root/.ssh", "cache:/cache"},
+		WorkingDir:   "/workspaces/app",
+		Environment:  map[string]string{"APP_ENV": "dev"},
+		Secrets:      map[string]config.Secret{"TOKEN": {From: "env://GH_TOKEN"}},
+		User:         "vscode",
+		CreateUser:   true,
+		Capabilities: []string{"SYS_PTRACE"},
+		Resources:    &config.Resources{Memory: "4g", CPUs: "2"},
+	}
+	if !reflect.DeepEqual(pc, want) {
+		got, _ := json.MarshalIndent(pc, "", "  ")
+		exp, _ := json.MarshalIndent(want, "", "  ")
+		t.Errorf("importDevcontainer() =\n%s\nwant\n%s", got, exp)
+	}
+
+	skipped := strings.Join(report.skipped, "\n")
+	for _, s := range []string{"name: 'My App'", "features.ghcr.io/example/features/custom:1", "db:5432", "tmpfs", "containerEnv.PATH_X", "'--init'", "customizations", "shutdownAction"} {
+		if !strings.Contains(skipped, s) {
+			t.Errorf("report.skipped missing %q:\n%s", s, skipped)
+		}
+	}
+	if imported := strings.Join(report.imported, "\n"); !strings.Contains(imported, "options ignored") {
+		t.Errorf("report.imported should note ignored feature options:\n%s", imported)
+	}
+}
+
+func TestImportDevcontainerRejects(t *testing.T) {
+	tests := []struct {
+		name, data, want string
+	}{
+		{"compose", `{"dockerComposeFile": "docker-compose.yml", "service": "app"}`, "dockerComposeFile"},
+		{"invalid", `{"image": }`, "invalid devcontainer.json"},
+		{"context outside", `{"build": {"dockerfile": "Dockerfile", "context": "../.."}}`, "outside the project folder"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project := t.TempDir()
+			_, _, err := importDevcontainer([]byte(tt.data), filepath.Join(project, ".devcontainer"), project)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("importDevcontainer() error = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
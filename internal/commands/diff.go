@@ -0,0 +1,370 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffManagersFlag []string
+	diffOutputFlag   string
+)
+
+type packageChange struct {
+	Name    string `json:"name"`
+	Locked  string `json:"locked,omitempty"`
+	Current string `json:"current,omitempty"`
+}
+
+type ecosystemDiff struct {
+	Manager string          `json:"manager"`
+	Added   []packageChange `json:"added,omitempty"`
+	Removed []packageChange `json:"removed,omitempty"`
+	Changed []packageChange `json:"changed,omitempty"`
+}
+
+func (d ecosystemDiff) empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+type settingChange struct {
+	Field   string `json:"field"`
+	Change  string `json:"change"`
+	Locked  string `json:"locked,omitempty"`
+	Current string `json:"current,omitempty"`
+}
+
+type lockDiff struct {
+	Project    string          `json:"project"`
+	Box        string          `json:"box"`
+	OK         bool            `json:"ok"`
+	Packages   []ecosystemDiff `json:"packages"`
+	Registries []settingChange `json:"registries"`
+	AptSources []settingChange `json:"apt_sources"`
+	Ignored    []string        `json:"ignored,omitempty"`
+}
+
+func (d *lockDiff) empty() bool {
+	return len(d.Packages) == 0 && len(d.Registries) == 0 && len(d.AptSources) == 0
+}
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <project>",
+	Short: "Show how the box differs from devbox.lock.json",
+	Long: `Show package, registry, and apt source differences between devbox.lock.json and the box.
+
+Packages are compared per package manager and listed as added (+), removed (-), or
+changed to another version (~). The command exits non-zero when the box differs.
+
+Examples:
+  devbox diff myproject
+  devbox diff myproject --managers apt,pip
+  devbox diff myproject --output json     # Structured diff on stdout for CI annotations`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFormat(diffOutputFlag); err != nil {
+			return err
+		}
+		if diffOutputFlag == outputTable {
+			return runDiff(args[0], nil)
+		}
+		stdout := os.Stdout
+		return withStdoutToStderr(func() error {
+			return runDiff(args[0], stdout)
+		})
+	},
+}
+
+func runDiff(projectName string, structuredOut io.Writer) error {
+	cfg, err := configManager.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	proj, ok := cfg.GetProject(projectName)
+	if !ok {
+		return fmt.Errorf("project '%s' not found", projectName)
+	}
+
+	lockPath := filepath.Join(proj.WorkspacePath, "devbox.lock.json")
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", lockPath, err)
+	}
+	var lf verifyLockFile
+	if err := json.Unmarshal(data, &lf); err != nil {
+		return fmt.Errorf("invalid lockfile: %w", err)
+	}
+	scope := verifyScope{Packages: true, Registries: true, Sources: true}
+	if len(diffManagersFlag) > 0 {
+		scope, err = newVerifyScope(true, true, true, false, false, diffManagersFlag, verifyManagerNames(&lf))
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := ensureBoxRunning(proj.BoxName); err != nil {
+		return err
+	}
+	if release, err := dockerClient.UseExecSession(proj.BoxName); err == nil {
+		defer release()
+	}
+
+	rules, err := loadIgnoreRules(proj.WorkspacePath, lf.Ignore)
+	if err != nil {
+		return err
+	}
+	snapshot := loadScopedSnapshot(proj.BoxName, scope)
+	separators := pluginSeparators()
+	ignored := stripIgnoredPackages(rules, &lf.Packages, &snapshot.Packages, separators)
+
+	result := lockDiff{
+		Project:    projectName,
+		Box:        proj.BoxName,
+		Packages:   diffPackages(&lf.Packages, &snapshot.Packages, separators, scope),
+		Registries: diffRegistries(&lf.Registries, &snapshot.Registries, scope),
+		AptSources: diffAptSources(&lf.AptSources, &snapshot.AptSources),
+		Ignored:    ignored,
+	}
+	result.OK = result.empty()
+
+	if len(ignored) > 0 {
+		fmt.Printf("Ignoring %d difference(s) matched by ignore rules:\n", len(ignored))
+		for _, d := range ignored {
+			fmt.Printf(" ~ %s\n", d)
+		}
+	}
+
+	if structuredOut != nil {
+		if result.Packages == nil {
+			result.Packages = []ecosystemDiff{}
+		}
+		if result.Registries == nil {
+			result.Registries = []settingChange{}
+		}
+		if result.AptSources == nil {
+			result.AptSources = []settingChange{}
+		}
+		if err := writeStructured(structuredOut, diffOutputFlag, result); err != nil {
+			return err
+		}
+	} else {
+		printLockDiff(os.Stdout, &result)
+	}
+
+	if !result.OK {
+		return fmt.Errorf("box '%s' differs from devbox.lock.json", proj.BoxName)
+	}
+	return nil
+}
+
+func diffPackages(locked, current *lockPackages, separators map[string]string, scope verifyScope) []ecosystemDiff {
+	builtin := []struct {
+		name, sep       string
+		locked, current []string
+	}{
+		{"apt", "=", locked.Apt, current.Apt},
+		{"pip", "==", locked.Pip, current.Pip},
+		{"npm", "@", locked.Npm, current.Npm},
+		{"yarn", "@", locked.Yarn, current.Yarn},
+		{"pnpm", "@", locked.Pnpm, current.Pnpm},
+	}
+	var diffs []ecosystemDiff
+	for _, m := range builtin {
+		if !scope.wantsManager(m.name) {
+			continue
+		}
+		if d := diffPackageList(m.name, m.sep, m.locked, m.current); !d.empty() {
+			diffs = append(diffs, d)
+		}
+	}
+
+	extras := map[string]bool{}
+	for name := range locked.Extra {
+		extras[name] = true
+	}
+	for name := range current.Extra {
+		extras[name] = true
+	}
+	names := make([]string, 0, len(extras))
+	for name := range extras {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !scope.wantsManager(name) {
+			continue
+		}
+		sep := firstNonEmpty(separators[name], "@")
+		if d := diffPackageList(name, sep, locked.Extra[name], current.Extra[name]); !d.empty() {
+			diffs = append(diffs, d)
+		}
+	}
+	return diffs
+}
+
+func diffPackageList(manager, sep string, locked, current []string) ecosystemDiff {
+	versions := func(list []string) map[string]string {
+		out := map[string]string{}
+		for _, entry := range normalizePackageList(manager, list) {
+			if name := packageEntryName(entry, sep); name != "" {
+				out[name] = packageEntryVersion(entry, sep)
+			}
+		}
+		return out
+	}
+	lockedVersions, currentVersions := versions(locked), versions(current)
+
+	d := ecosystemDiff{Manager: manager}
+	for name, lv := range lockedVersions {
+		cv, ok := currentVersions[name]
+		switch {
+		case !ok:
+			d.Removed = append(d.Removed, packageChange{Name: name, Locked: lv})
+		case cv != lv:
+			d.Changed = append(d.Changed, packageChange{Name: name, Locked: lv, Current: cv})
+		}
+	}
+	for name, cv := range currentVersions {
+		if _, ok := lockedVersions[name]; !ok {
+			d.Added = append(d.Added, packageChange{Name: name, Current: cv})
+		}
+	}
+	for _, list := range [][]packageChange{d.Added, d.Removed, d.Changed} {
+		sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	}
+	return d
+}
+
+func packageEntryVersion(entry, sep string) string {
+	s := strings.TrimSpace(entry)
+	i := strings.Index(s, sep)
+	if sep == "@" {
+		i = strings.LastIndex(s, sep)
+	}
+	if i <= 0 {
+		return ""
+	}
+	return strings.TrimSpace(s[i+len(sep):])
+}
+
+func diffSetting(field, locked, current string) []settingChange {
+	if locked == "" || normalizeURL(locked) == normalizeURL(current) {
+		return nil
+	}
+	return []settingChange{{Field: field, Change: "changed", Locked: locked, Current: current}}
+}
+
+func diffSettingList(field string, locked, current []string) []settingChange {
+	if len(locked) == 0 {
+		return nil
+	}
+	index := func(list []string) map[string]bool {
+		set := map[string]bool{}
+		for _, s := range list {
+			if s = strings.TrimSpace(s); s != "" {
+				set[s] = true
+			}
+		}
+		return set
+	}
+	lockedSet, currentSet := index(locked), index(current)
+	var changes []settingChange
+	for _, s := range sortedUnique(append([]string{}, locked...)) {
+		if !currentSet[s] {
+			changes = append(changes, settingChange{Field: field, Change: "removed", Locked: s})
+		}
+	}
+	for _, s := range sortedUnique(append([]string{}, current...)) {
+		if !lockedSet[s] {
+			changes = append(changes, settingChange{Field: field, Change: "added", Current: s})
+		}
+	}
+	return changes
+}
+
+func diffRegistries(locked, current *lockRegistries, scope verifyScope) []settingChange {
+	var changes []settingChange
+	if scope.wantsManager("pip") {
+		changes = append(changes, diffSetting("pip_index_url", locked.PipIndexURL, current.PipIndexURL)...)
+		changes = append(changes, diffSettingList("pip_extra_index_urls", locked.PipExtraIndex, current.PipExtraIndex)...)
+	}
+	if scope.wantsManager("npm") {
+		changes = append(changes, diffSetting("npm_registry", locked.NpmRegistry, current.NpmRegistry)...)
+	}
+	if scope.wantsManager("yarn") {
+		changes = append(changes, diffSetting("yarn_registry", locked.YarnRegistry, current.YarnRegistry)...)
+	}
+	if scope.wantsManager("pnpm") {
+		changes = append(changes, diffSetting("pnpm_registry", locked.PnpmRegistry, current.PnpmRegistry)...)
+	}
+	return changes
+}
+
+func diffAptSources(locked, current *lockAptSources) []settingChange {
+	var changes []settingChange
+	changes = append(changes, diffSetting("snapshot_url", locked.SnapshotURL, current.SnapshotURL)...)
+	if strings.TrimSpace(locked.PinnedRelease) != "" && strings.TrimSpace(locked.PinnedRelease) != strings.TrimSpace(current.PinnedRelease) {
+		changes = append(changes, settingChange{Field: "pinned_release", Change: "changed", Locked: locked.PinnedRelease, Current: current.PinnedRelease})
+	}
+	return append(changes, diffSettingList("sources_lists", locked.SourcesLists, current.SourcesLists)...)
+}
+
+func printLockDiff(w io.Writer, d *lockDiff) {
+	if d.OK {
+		fmt.Fprintf(w, "No differences between devbox.lock.json and box '%s'\n", d.Box)
+		return
+	}
+	fmt.Fprintf(w, "Differences between devbox.lock.json and box '%s':\n", d.Box)
+	for _, e := range d.Packages {
+		fmt.Fprintf(w, "\n%s packages (%d added, %d removed, %d changed)\n", e.Manager, len(e.Added), len(e.Removed), len(e.Changed))
+		for _, p := range e.Added {
+			fmt.Fprintln(w, colorText(colorGreen, "  + "+strings.TrimSpace(p.Name+" "+p.Current)))
+		}
+		for _, p := range e.Removed {
+			fmt.Fprintln(w, colorText(colorRed, "  - "+strings.TrimSpace(p.Name+" "+p.Locked)))
+		}
+		for _, p := range e.Changed {
+			fmt.Fprintln(w, colorText(colorYellow, fmt.Sprintf("  ~ %s %s -> %s", p.Name, valueOrNone(p.Locked), valueOrNone(p.Current))))
+		}
+	}
+	printSettingChanges(w, "registries", d.Registries)
+	printSettingChanges(w, "apt sources", d.AptSources)
+}
+
+func printSettingChanges(w io.Writer, title string, changes []settingChange) {
+	if len(changes) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n%s\n", title)
+	for _, c := range changes {
+		switch c.Change {
+		case "added":
+			fmt.Fprintln(w, colorText(colorGreen, fmt.Sprintf("  + %s %s", c.Field, c.Current)))
+		case "removed":
+			fmt.Fprintln(w, colorText(colorRed, fmt.Sprintf("  - %s %s", c.Field, c.Locked)))
+		default:
+			fmt.Fprintln(w, colorText(colorYellow, fmt.Sprintf("  ~ %s %s -> %s", c.Field, c.Locked, valueOrNone(c.Current))))
+		}
+	}
+}
+
+func valueOrNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+	diffCmd.Flags().BoolVar(&noCacheFlag, "no-cache", false, "Re-query packages and registries instead of reusing a recent snapshot")
+	diffCmd.Flags().StringSliceVar(&diffManagersFlag, "managers", nil, "Limit the package and registry diff to these package managers (apt, pip, npm, yarn, pnpm, cargo, go, gem, composer, conda, or a plugin manager)")
+	addOutputFlag(diffCmd, &diffOutputFlag)
+}
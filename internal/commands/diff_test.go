@@ -0,0 +1,110 @@
+package commands
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffPackageList(t *testing.T) {
+	got := diffPackageList("apt", "=",
+		[]string{"curl=7.81.0-1", "git=1:2.34.1", "nano=6.2-1"},
+		[]string{"curl=7.81.0-1ubuntu1.15", "git=1:2.34.1", "vim=2:8.2"})
+	want := ecosystemDiff{
+		Manager: "apt",
+		Added:   []packageChange{{Name: "vim", Current: "2:8.2"}},
+		Removed: []packageChange{{Name: "nano", Locked: "6.2-1"}},
+		Changed: []packageChange{{Name: "curl", Locked: "7.81.0-1", Current: "7.81.0-1ubuntu1.15"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("diffPackageList() = %+v, want %+v", got, want)
+	}
+
+	npm := diffPackageList("npm", "@", []string{"@types/node@20.1.0", "eslint@9.0.0"}, []string{"@types/node@20.2.0", "eslint@9.0.0"})
+	if len(npm.Changed) != 1 || npm.Changed[0] != (packageChange{Name: "@types/node", Locked: "20.1.0", Current: "20.2.0"}) {
+		t.Errorf("scoped npm package diff = %+v", npm)
+	}
+
+	pip := diffPackageList("pip", "==", []string{"Requests==2.31.0"}, []string{"requests==2.31.0"})
+	if !pip.empty() {
+		t.Errorf("pip names differing only in case should match, got %+v", pip)
+	}
+}
+
+func TestDiffPackagesScope(t *testing.T) {
+	locked := &lockPackages{
+		Apt:   []string{"curl=1"},
+		Pip:   []string{"flask==3.0.0"},
+		Extra: map[string][]string{"cargo": {"ripgrep@14.1.0"}},
+	}
+	current := &lockPackages{
+		Apt:   []string{"curl=2"},
+		Pip:   []string{"flask==3.0.0"},
+		Extra: map[string][]string{"cargo": {"ripgrep@14.1.1"}},
+	}
+	seps := map[string]string{"cargo": "@"}
+
+	var managers []string
+	for _, d := range diffPackages(locked, current, seps, fullVerifyScope()) {
+		managers = append(managers, d.Manager)
+	}
+	if !reflect.DeepEqual(managers, []string{"apt", "cargo"}) {
+		t.Errorf("managers with differences = %v, want [apt cargo]", managers)
+	}
+
+	scoped := diffPackages(locked, current, seps, verifyScope{Packages: true, Managers: map[string]bool{"cargo": true}})
+	if len(scoped) != 1 || scoped[0].Changed[0] != (packageChange{Name: "ripgrep", Locked: "14.1.0", Current: "14.1.1"}) {
+		t.Errorf("diffPackages() limited to cargo = %+v", scoped)
+	}
+}
+
+func TestDiffSettings(t *testing.T) {
+	registries := diffRegistries(
+		&lockRegistries{PipIndexURL: "https://pypi.org/simple/", PipExtraIndex: []string{"https://a.example/simple"}, NpmRegistry: "https://registry.npmjs.org/"},
+		&lockRegistries{PipIndexURL: "https://pypi.org/simple", PipExtraIndex: []string{"https://b.example/simple"}, NpmRegistry: "https://npm.example.com/"},
+		fullVerifyScope())
+	want := []settingChange{
+		{Field: "pip_extra_index_urls", Change: "removed", Locked: "https://a.example/simple"},
+		{Field: "pip_extra_index_urls", Change: "added", Current: "https://b.example/simple"},
+		{Field: "npm_registry", Change: "changed", Locked: "https://registry.npmjs.org/", Current: "https://npm.example.com/"},
+	}
+	if !reflect.DeepEqual(registries, want) {
+		t.Errorf("diffRegistries() = %+v, want %+v", registries, want)
+	}
+
+	sources := diffAptSources(
+		&lockAptSources{SnapshotURL: "https://snapshot.ubuntu.com/ubuntu/20240301T000000Z", PinnedRelease: "jammy", SourcesLists: []string{"deb http://archive.ubuntu.com/ubuntu jammy main"}},
+		&lockAptSources{SnapshotURL: "https://snapshot.ubuntu.com/ubuntu/20240301T000000Z", PinnedRelease: "noble", SourcesLists: []string{"deb http://archive.ubuntu.com/ubuntu jammy main"}})
+	if len(sources) != 1 || sources[0].Field != "pinned_release" || sources[0].Current != "noble" {
+		t.Errorf("diffAptSources() = %+v", sources)
+	}
+	if got := diffAptSources(&lockAptSources{}, &lockAptSources{SourcesLists: []string{"deb x"}}); got != nil {
+		t.Errorf("diffAptSources() without locked sources = %+v, want none", got)
+	}
+}
+
+func TestPrintLockDiff(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	var b bytes.Buffer
+	printLockDiff(&b, &lockDiff{
+		Box: "devbox_web",
+		Packages: []ecosystemDiff{{
+			Manager: "apt",
+			Added:   []packageChange{{Name: "vim", Current: "2:8.2"}},
+			Changed: []packageChange{{Name: "curl", Locked: "1", Current: "2"}},
+		}},
+		AptSources: []settingChange{{Field: "sources_lists", Change: "removed", Locked: "deb x"}},
+	})
+	for _, line := range []string{"apt packages (1 added, 0 removed, 1 changed)", "  + vim 2:8.2", "  ~ curl 1 -> 2", "apt sources", "  - sources_lists deb x"} {
+		if !strings.Contains(b.String(), line+"\n") {
+			t.Errorf("output is missing %q:\n%s", line, b.String())
+		}
+	}
+
+	b.Reset()
+	printLockDiff(&b, &lockDiff{Box: "devbox_web", OK: true})
+	if !strings.HasPrefix(b.String(), "No differences") {
+		t.Errorf("output without differences = %q", b.String())
+	}
+}
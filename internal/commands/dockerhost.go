@@ -0,0 +1,133 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"devbox/internal/config"
+	"devbox/internal/docker"
+)
+
+var dockerHostSource = ""
+
+func resolveDockerHost(projectHost string, getenv func(string) string, settings *config.GlobalSettings) (value, source string) {
+	if projectHost = strings.TrimSpace(projectHost); projectHost != "" {
+		return projectHost, "devbox.json"
+	}
+	if v := strings.TrimSpace(getenv("DOCKER_HOST")); v != "" {
+		return "", "DOCKER_HOST"
+	}
+	if v := strings.TrimSpace(getenv("DOCKER_CONTEXT")); v != "" {
+		return "", "DOCKER_CONTEXT"
+	}
+	if settings != nil && strings.TrimSpace(settings.DockerHost) != "" {
+		return strings.TrimSpace(settings.DockerHost), "settings.docker_host"
+	}
+	return "", ""
+}
+
+func applyDockerHost(value string) error {
+	if value == "" {
+		return nil
+	}
+	engine := filepath.Base(docker.Engine())
+	if docker.IsDockerHostURL(value) {
+		switch engine {
+		case "docker":
+			os.Unsetenv("DOCKER_CONTEXT")
+			return os.Setenv("DOCKER_HOST", value)
+		case "podman":
+			return os.Setenv("CONTAINER_HOST", value)
+		}
+		return fmt.Errorf("docker_host '%s' is not supported with the %s engine", value, engine)
+	}
+	if engine != "docker" {
+		return fmt.Errorf("docker_host '%s' names a docker context, which the %s engine does not support; use a URL such as ssh://user@host", value, engine)
+	}
+	if _, err := docker.ContextEndpoint(value); err != nil {
+		return err
+	}
+	os.Unsetenv("DOCKER_HOST")
+	return os.Setenv("DOCKER_CONTEXT", value)
+}
+
+func commandProjectConfig(cmd *cobra.Command, args []string) *config.ProjectConfig {
+	var workspace string
+	if len(args) > 0 {
+		if cfg, err := configManager.Load(); err == nil {
+			if project, ok := cfg.GetProject(args[0]); ok {
+				workspace = project.WorkspacePath
+			}
+		}
+	} else if cmd == upCmd {
+		workspace, _ = os.Getwd()
+	}
+	if workspace == "" {
+		return nil
+	}
+	projectConfig, err := configManager.LoadProjectConfig(workspace)
+	if err != nil {
+		return nil
+	}
+	return projectConfig
+}
+
+func configureDockerHost(cmd *cobra.Command, args []string) error {
+	var settings *config.GlobalSettings
+	if cfg, err := configManager.Load(); err == nil {
+		settings = cfg.Settings
+	}
+	var projectHost string
+	if projectConfig := commandProjectConfig(cmd, args); projectConfig != nil {
+		projectHost = projectConfig.DockerHost
+	}
+	value, source := resolveDockerHost(projectHost, os.Getenv, settings)
+	dockerHostSource = source
+	if err := applyDockerHost(value); err != nil {
+		return fmt.Errorf("%s: %w", source, err)
+	}
+	return nil
+}
+
+func autodetectDockerSocket() {
+	if dockerHostSource != "" {
+		return
+	}
+	if socket, ok := docker.DetectSocket(); ok {
+		os.Setenv("DOCKER_HOST", socket.Endpoint())
+		dockerHostSource = "autodetected " + socket.Name
+	}
+}
+
+func dockerEndpoint() (endpoint, source string) {
+	endpoint = docker.DaemonEndpoint()
+	switch {
+	case endpoint == "" && filepath.Base(docker.Engine()) != "docker":
+		return "", ""
+	case endpoint == "":
+		return "unix://" + docker.DefaultSocketPath, "default"
+	case dockerHostSource == "":
+		return endpoint, "docker context"
+	}
+	return endpoint, dockerHostSource
+}
+
+func dockerUnavailableError(err error) error {
+	if _, source := dockerEndpoint(); source == "default" {
+		return fmt.Errorf("docker availability check failed: %w\nhint: no Docker socket answered at %s or the usual rootless Docker, Colima, Lima, Docker Desktop, and Rancher Desktop locations; set DOCKER_HOST or run 'devbox doctor'", err, docker.DefaultSocketPath)
+	}
+	return fmt.Errorf("docker availability check failed: %w", err)
+}
+
+func printRemoteWorkspace(projectName, boxName string) {
+	if !dockerClient.WorkspaceSync() {
+		return
+	}
+	endpoint, source := dockerEndpoint()
+	fmt.Printf("Docker host: %s (remote, from %s)\n", endpoint, source)
+	fmt.Printf("Workspace volume: %s (run 'devbox sync %s --pull' to copy changes back)\n", docker.WorkspaceVolumeName(boxName), projectName)
+}
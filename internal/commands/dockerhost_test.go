@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"devbox/internal/config"
+)
+
+func TestResolveDockerHost(t *testing.T) {
+	settings := &config.GlobalSettings{DockerHost: "ssh://me@shared"}
+	env := func(vars map[string]string) func(string) string {
+		return func(k string) string { return vars[k] }
+	}
+	tests := []struct {
+		name        string
+		projectHost string
+		env         map[string]string
+		settings    *config.GlobalSettings
+		wantValue   string
+		wantSource  string
+	}{
+		{"nothing set", "", nil, nil, "", ""},
+		{"settings", "", nil, settings, "ssh://me@shared", "settings.docker_host"},
+		{"DOCKER_HOST beats settings", "", map[string]string{"DOCKER_HOST": "tcp://10.0.0.5:2376"}, settings, "", "DOCKER_HOST"},
+		{"DOCKER_CONTEXT beats settings", "", map[string]string{"DOCKER_CONTEXT": "remote"}, settings, "", "DOCKER_CONTEXT"},
+		{"project beats env", "build-server", map[string]string{"DOCKER_HOST": "tcp://10.0.0.5:2376"}, settings, "build-server", "devbox.json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, source := resolveDockerHost(tt.projectHost, env(tt.env), tt.settings)
+			if value != tt.wantValue || source != tt.wantSource {
+				t.Errorf("resolveDockerHost() = %q, %q, want %q, %q", value, source, tt.wantValue, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestDockerHostCheck(t *testing.T) {
+	local := dockerHostCheck("unix:///run/user/1000/docker.sock", "DOCKER_HOST")
+	if local.Status != doctorPass || !strings.Contains(local.Detail, "(local)") || !strings.HasSuffix(local.Detail, "from DOCKER_HOST") {
+		t.Errorf("local dockerHostCheck() = %+v", local)
+	}
+	remote := dockerHostCheck("ssh://me@build-server", "devbox.json")
+	if remote.Status != doctorPass || !strings.Contains(remote.Detail, "remote") {
+		t.Errorf("remote dockerHostCheck() = %+v", remote)
+	}
+}
@@ -0,0 +1,406 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"devbox/internal/config"
+	"devbox/internal/docker"
+)
+
+const (
+	doctorPass = "pass"
+	doctorWarn = "warn"
+	doctorFail = "fail"
+)
+
+const (
+	doctorDiskWarnBytes = 5 << 30
+	doctorDiskFailBytes = 1 << 30
+)
+
+var doctorFixFlag bool
+
+type doctorCheck struct {
+	Name   string
+	Status string
+	Detail string
+	Hint   string
+	Fix    string
+	fix    func() error
+}
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose the devbox environment and suggest fixes",
+	Long: `Check the container engine, socket permissions, free disk space, ~/.devbox/config.json,
+every registered project (workspace, devbox.json, box, leftover legacy files, lockfile age),
+and untracked devbox boxes, then print a pass/warn/fail report with a hint for each problem.
+
+With --fix, devbox applies the fixes it can make safely on its own: creating missing
+workspace folders, moving legacy workspace files into .devbox/, and removing your
+orphaned devbox boxes. Exits non-zero while any check fails.
+
+Examples:
+  devbox doctor
+  devbox doctor --fix`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return runDoctor()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+	doctorCmd.Flags().BoolVar(&doctorFixFlag, "fix", false, "Apply the available fixes")
+}
+
+func runDoctor() error {
+	var checks []doctorCheck
+	engine := checkDockerDaemon()
+	if engineErr != nil {
+		engine = doctorCheck{Name: "Container engine", Status: doctorFail, Detail: engineErr.Error(),
+			Hint: fmt.Sprintf("install it, or pick another engine with --engine, DEVBOX_ENGINE or settings.engine (%s)", strings.Join(config.ContainerEngines, ", "))}
+	}
+	checks = append(checks, engine)
+	if endpoint, source := dockerEndpoint(); endpoint != "" {
+		checks = append(checks, dockerHostCheck(endpoint, source))
+	}
+	if socket, ok := checkDockerSocket(os.Getenv("DOCKER_HOST")); ok {
+		checks = append(checks, socket)
+	}
+
+	homeDir, _ := os.UserHomeDir()
+	checks = append(checks, diskSpaceCheck("Disk space (home)", firstNonEmpty(homeDir, "/")))
+	if engine.Status == doctorPass && dockerClient != nil {
+		if root := dockerClient.GetDockerRootDir(); root != "" {
+			checks = append(checks, diskSpaceCheck("Disk space (engine)", root))
+		}
+	}
+
+	cfg, configCheck := checkGlobalConfig(filepath.Join(configManager.ConfigDir(), "config.json"))
+	checks = append(checks, configCheck)
+	if cfg != nil {
+		checks = append(checks, checkRegistry(cfg)...)
+		var boxes []docker.BoxInfo
+		if engine.Status == doctorPass && dockerClient != nil {
+			boxes, _ = dockerClient.ListBoxes()
+		}
+		for _, name := range sortedProjectNames(cfg) {
+			checks = append(checks, checkDoctorProject(cfg, name, cfg.Projects[name], boxes, dockerClient != nil && engine.Status == doctorPass)...)
+		}
+		checks = append(checks, checkOrphanedBoxes(cfg, boxes)...)
+		if check, ok := gpuSupportCheck(cfg, engine.Status == doctorPass); ok {
+			checks = append(checks, check)
+		}
+	}
+
+	fmt.Printf("Devbox doctor\n\n")
+	passed, warned, failed, fixed := 0, 0, 0, 0
+	for i := range checks {
+		c := &checks[i]
+		if c.Status != doctorPass && doctorFixFlag && c.fix != nil {
+			if err := c.fix(); err != nil {
+				c.Detail += fmt.Sprintf(" (fix failed: %v)", err)
+			} else {
+				c.Status = doctorPass
+				c.Detail += " (fixed)"
+				fixed++
+			}
+		}
+		fmt.Printf("  [%s] %s: %s\n", c.Status, c.Name, c.Detail)
+		switch c.Status {
+		case doctorPass:
+			passed++
+			continue
+		case doctorWarn:
+			warned++
+		case doctorFail:
+			failed++
+		}
+		if c.fix != nil {
+			fmt.Printf("         fix: %s (run with --fix)\n", c.Fix)
+		}
+		if c.Hint != "" {
+			fmt.Printf("         hint: %s\n", c.Hint)
+		}
+	}
+
+	fmt.Printf("\nSummary: %d passed, %d warning(s), %d failed", passed, warned, failed)
+	if fixed > 0 {
+		fmt.Printf(", %d fixed", fixed)
+	}
+	fmt.Println()
+	if failed > 0 {
+		return fmt.Errorf("doctor found %d failing check(s)", failed)
+	}
+	return nil
+}
+
+func checkDockerDaemon() doctorCheck {
+	c := doctorCheck{Name: "Container engine"}
+	out, err := exec.Command(engineCmd(), "version", "--format", "{{.Server.Version}}").CombinedOutput()
+	if err != nil && docker.IsDockerAvailable() == nil {
+		out, err = []byte("available"), nil
+	}
+	if err != nil {
+		c.Status = doctorFail
+		c.Detail = fmt.Sprintf("%s daemon not reachable: %s", engineCmd(), firstNonEmpty(strings.TrimSpace(string(out)), err.Error()))
+		c.Hint = fmt.Sprintf("install %s and start its daemon (e.g. 'sudo systemctl start docker')", engineCmd())
+		return c
+	}
+	c.Status = doctorPass
+	c.Detail = describeEngine()
+	return c
+}
+
+func dockerHostCheck(endpoint, source string) doctorCheck {
+	c := doctorCheck{Name: "Docker host", Status: doctorPass, Detail: endpoint + " (local)"}
+	if docker.IsRemoteEndpoint(endpoint) {
+		c.Detail = endpoint + " (remote; workspaces are synced volumes, see 'devbox sync')"
+	}
+	if source != "" {
+		c.Detail += " from " + source
+	}
+	return c
+}
+
+func gpuSupportCheck(cfg *config.Config, engineOK bool) (doctorCheck, bool) {
+	var projects []string
+	for _, name := range sortedProjectNames(cfg) {
+		if pc, err := configManager.LoadProjectConfig(cfg.Projects[name].WorkspacePath); err == nil && pc != nil && strings.TrimSpace(pc.Gpus) != "" {
+			projects = append(projects, name)
+		}
+	}
+	if len(projects) == 0 || !engineOK {
+		return doctorCheck{}, false
+	}
+	c := doctorCheck{Name: "GPU support", Status: doctorPass, Detail: "NVIDIA container runtime available for " + strings.Join(projects, ", ")}
+	if err := docker.CheckGPUSupport(); err != nil {
+		c.Status = doctorFail
+		c.Detail = fmt.Sprintf("%s request gpus, but %v", strings.Join(projects, ", "), err)
+	}
+	return c, true
+}
+
+func dockerSocketPath(dockerHost string) string {
+	if dockerHost == "" {
+		return "/var/run/docker.sock"
+	}
+	if strings.HasPrefix(dockerHost, "unix://") {
+		return strings.TrimPrefix(dockerHost, "unix://")
+	}
+	return ""
+}
+
+func checkDockerSocket(dockerHost string) (doctorCheck, bool) {
+	path := dockerSocketPath(dockerHost)
+	if path == "" || engineCmd() != "docker" {
+		return doctorCheck{}, false
+	}
+	c := doctorCheck{Name: "Docker socket", Status: doctorPass, Detail: path + " is writable"}
+	if _, err := os.Stat(path); err != nil {
+		c.Status = doctorFail
+		c.Detail = path + " does not exist"
+		c.Hint = "start the Docker daemon (or 'colima start'), or set DOCKER_HOST to the socket it listens on"
+	} else if err := syscall.Access(path, 2); err != nil {
+		c.Status = doctorFail
+		c.Detail = path + " is not writable by the current user"
+		c.Hint = "run 'sudo usermod -aG docker $USER' and log in again"
+	}
+	return c, true
+}
+
+func diskSpaceCheck(label, path string) doctorCheck {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return doctorCheck{Name: label, Status: doctorWarn, Detail: fmt.Sprintf("cannot check %s: %v", path, err)}
+	}
+	return diskSpaceStatus(label, path, st.Bavail*uint64(st.Bsize))
+}
+
+func diskSpaceStatus(label, path string, free uint64) doctorCheck {
+	c := doctorCheck{Name: label, Status: doctorPass, Detail: fmt.Sprintf("%s free on %s", formatBytes(int64(free)), path)}
+	switch {
+	case free < doctorDiskFailBytes:
+		c.Status = doctorFail
+	case free < doctorDiskWarnBytes:
+		c.Status = doctorWarn
+	}
+	if c.Status != doctorPass {
+		c.Hint = "run 'devbox gc' to remove stale boxes, old backups, and unused images"
+	}
+	return c
+}
+
+func checkGlobalConfig(path string) (*config.Config, doctorCheck) {
+	c := doctorCheck{Name: "Global config", Status: doctorPass, Detail: path + " is valid"}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		c.Detail = path + " does not exist yet (defaults in use)"
+	} else if err != nil {
+		c.Status = doctorFail
+		c.Detail = fmt.Sprintf("cannot read %s: %v", path, err)
+		return nil, c
+	} else if len(strings.TrimSpace(string(data))) > 0 {
+		var raw config.Config
+		if err := json.Unmarshal(data, &raw); err != nil {
+			c.Status = doctorFail
+			c.Detail = fmt.Sprintf("%s is not valid JSON: %v", path, err)
+			c.Hint = "fix the file by hand, or move it aside and re-register projects with 'devbox projects import'"
+			return nil, c
+		}
+	}
+	cfg, err := configManager.Load()
+	if err != nil {
+		c.Status = doctorFail
+		c.Detail = err.Error()
+		return nil, c
+	}
+	return cfg, c
+}
+
+func checkRegistry(cfg *config.Config) []doctorCheck {
+	var checks []doctorCheck
+	owners := map[string]string{}
+	for _, name := range sortedProjectNames(cfg) {
+		p := cfg.Projects[name]
+		if p == nil || p.BoxName == "" {
+			checks = append(checks, doctorCheck{Name: "Project " + name, Status: doctorFail, Detail: "registry entry has no box name",
+				Hint: fmt.Sprintf("remove it with 'devbox destroy %s' and run 'devbox up' in its workspace", name)})
+			continue
+		}
+		if other, ok := owners[p.BoxName]; ok {
+			checks = append(checks, doctorCheck{Name: "Project " + name, Status: doctorFail,
+				Detail: fmt.Sprintf("box name '%s' is also used by project '%s'", p.BoxName, other),
+				Hint:   "destroy one of the projects and re-create it under a different name"})
+		}
+		owners[p.BoxName] = name
+	}
+	return checks
+}
+
+func lockfileOlderThanConfig(workspacePath string) bool {
+	lock, err := os.Stat(filepath.Join(workspacePath, "devbox.lock.json"))
+	if err != nil {
+		return false
+	}
+	cfgInfo, err := os.Stat(filepath.Join(workspacePath, "devbox.json"))
+	return err == nil && cfgInfo.ModTime().After(lock.ModTime())
+}
+
+func checkDoctorProject(cfg *config.Config, name string, p *config.Project, boxes []docker.BoxInfo, engineUp bool) []doctorCheck {
+	if p == nil || p.BoxName == "" {
+		return nil
+	}
+	label := "Project " + name
+	var checks []doctorCheck
+	archived := p.Status == projectStatusArchived
+
+	if archived {
+		if _, err := os.Stat(p.ArchivePath); err != nil {
+			checks = append(checks, doctorCheck{Name: label, Status: doctorFail, Detail: fmt.Sprintf("archive %s is missing", p.ArchivePath),
+				Hint: fmt.Sprintf("restore the archive to that path, or remove the project with 'devbox destroy %s'", name)})
+		}
+	} else if _, err := os.Stat(p.WorkspacePath); err != nil {
+		workspace := p.WorkspacePath
+		checks = append(checks, doctorCheck{Name: label, Status: doctorFail, Detail: fmt.Sprintf("workspace %s is missing", workspace),
+			Hint: "if the folder moved, update the entry with 'devbox projects export' and 'devbox projects import --force --map OLD=NEW'",
+			Fix:  "create an empty workspace folder",
+			fix: func() error {
+				return os.MkdirAll(workspace, 0755)
+			}})
+		return checks
+	}
+	if archived {
+		return checks
+	}
+
+	if _, err := configManager.LoadProjectConfig(p.WorkspacePath); err != nil {
+		checks = append(checks, doctorCheck{Name: label, Status: doctorFail, Detail: fmt.Sprintf("devbox.json is invalid: %v", err),
+			Hint: fmt.Sprintf("fix %s and check it with 'devbox config validate %s'", filepath.Join(p.WorkspacePath, "devbox.json"), name)})
+	}
+
+	var legacy []string
+	for _, n := range []string{legacyJournalName, legacyBackupsDirName} {
+		if _, err := os.Stat(filepath.Join(p.WorkspacePath, n)); err == nil {
+			legacy = append(legacy, n)
+		}
+	}
+	if len(legacy) > 0 {
+		workspace := p.WorkspacePath
+		checks = append(checks, doctorCheck{Name: label, Status: doctorWarn, Detail: fmt.Sprintf("stale %s left from an older devbox", strings.Join(legacy, " and ")),
+			Fix: "move them into .devbox/",
+			fix: func() error {
+				return migrateWorkspaceLayout(cfg, workspace)
+			}})
+	}
+	if lockfileOlderThanConfig(p.WorkspacePath) {
+		checks = append(checks, doctorCheck{Name: label, Status: doctorWarn, Detail: "devbox.lock.json is older than devbox.json",
+			Hint: fmt.Sprintf("run 'devbox up' in the workspace, then 'devbox lock %s'", name)})
+	}
+
+	if engineUp {
+		found := false
+		for _, box := range boxes {
+			for _, n := range box.Names {
+				if strings.TrimPrefix(n, "/") != p.BoxName {
+					continue
+				}
+				found = true
+				if box.Owner != "" && box.Owner != docker.CurrentOwner() {
+					checks = append(checks, doctorCheck{Name: label, Status: doctorWarn, Detail: fmt.Sprintf("box '%s' is owned by %s", p.BoxName, ownerName(box.Owner)),
+						Hint: "enable settings.user_box_prefix to namespace box names per user"})
+				}
+			}
+		}
+		if !found {
+			checks = append(checks, doctorCheck{Name: label, Status: doctorWarn, Detail: fmt.Sprintf("box '%s' does not exist", p.BoxName),
+				Hint: fmt.Sprintf("run 'devbox up' in %s, or 'devbox maintenance --auto-repair'", p.WorkspacePath)})
+		} else if mounted := dockerClient.GetWorkspacePath(p.BoxName); mounted != "" && filepath.Clean(mounted) != filepath.Clean(p.WorkspacePath) {
+			checks = append(checks, doctorCheck{Name: label, Status: doctorWarn, Detail: fmt.Sprintf("box mounts %s but the workspace is %s", mounted, p.WorkspacePath),
+				Hint: fmt.Sprintf("recreate the box with 'devbox update %s'", name)})
+		}
+	}
+
+	if len(checks) == 0 {
+		checks = append(checks, doctorCheck{Name: label, Status: doctorPass, Detail: "ok"})
+	}
+	return checks
+}
+
+func checkOrphanedBoxes(cfg *config.Config, boxes []docker.BoxInfo) []doctorCheck {
+	tracked := map[string]bool{}
+	for _, p := range cfg.GetProjects() {
+		tracked[p.BoxName] = true
+	}
+	owned, _ := filterOwnedBoxes(boxes, docker.CurrentOwner(), false)
+	var checks []doctorCheck
+	for _, box := range owned {
+		for _, n := range box.Names {
+			name := strings.TrimPrefix(n, "/")
+			if tracked[name] {
+				continue
+			}
+			check := doctorCheck{Name: "Orphaned box", Status: doctorWarn, Detail: fmt.Sprintf("%s (%s) is not tracked by any project", name, box.Status),
+				Fix: "remove the box",
+				fix: func() error {
+					return dockerClient.RemoveBox(name)
+				}}
+			if origin := boxOrigin(box.Metadata); origin != "" {
+				check.Detail = fmt.Sprintf("%s (%s) was created for %s but is not tracked", name, box.Status, origin)
+				check.Hint = fmt.Sprintf("run 'devbox adopt %s' to track it again", name)
+			}
+			checks = append(checks, check)
+		}
+	}
+	return checks
+}
@@ -0,0 +1,104 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"devbox/internal/config"
+)
+
+func TestDockerSocketPath(t *testing.T) {
+	tests := []struct {
+		host, want string
+	}{
+		{"", "/var/run/docker.sock"},
+		{"unix:///run/user/1000/docker.sock", "/run/user/1000/docker.sock"},
+		{"tcp://10.0.0.5:2376", ""},
+		{"ssh://me@builder", ""},
+	}
+	for _, tt := range tests {
+		if got := dockerSocketPath(tt.host); got != tt.want {
+			t.Errorf("dockerSocketPath(%q) = %q, want %q", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestDiskSpaceStatus(t *testing.T) {
+	tests := []struct {
+		free uint64
+		want string
+	}{
+		{20 << 30, doctorPass},
+		{3 << 30, doctorWarn},
+		{512 << 20, doctorFail},
+	}
+	for _, tt := range tests {
+		c := diskSpaceStatus("Disk space", "/", tt.free)
+		if c.Status != tt.want {
+			t.Errorf("diskSpaceStatus(%d) = %s, want %s", tt.free, c.Status, tt.want)
+		}
+		if (c.Hint != "") != (tt.want != doctorPass) {
+			t.Errorf("diskSpaceStatus(%d) hint = %q", tt.free, c.Hint)
+		}
+	}
+}
+
+func TestCheckRegistry(t *testing.T) {
+	cfg := &config.Config{Projects: map[string]*config.Project{
+		"a": {Name: "a", BoxName: "devbox_shared"},
+		"b": {Name: "b", BoxName: "devbox_shared"},
+		"c": {Name: "c"},
+		"d": {Name: "d", BoxName: "devbox_d"},
+	}}
+	checks := checkRegistry(cfg)
+	if len(checks) != 2 || checks[0].Name != "Project b" || checks[1].Name != "Project c" {
+		t.Errorf("checkRegistry() = %+v, want failures for b and c", checks)
+	}
+}
+
+func TestCheckDoctorProject(t *testing.T) {
+	withTempConfigManager(t)
+
+	ws := t.TempDir()
+	if err := os.WriteFile(filepath.Join(ws, legacyJournalName), []byte("apt install git\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(ws, "devbox.lock.json"), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(ws, "devbox.json"), []byte(`{"name": "web"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join(ws, "devbox.lock.json"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{Projects: map[string]*config.Project{
+		"web":  {Name: "web", BoxName: "devbox_web", WorkspacePath: ws},
+		"gone": {Name: "gone", BoxName: "devbox_gone", WorkspacePath: filepath.Join(ws, "missing")},
+	}}
+
+	checks := checkDoctorProject(cfg, "web", cfg.Projects["web"], nil, false)
+	if len(checks) != 2 || checks[0].Status != doctorWarn || checks[0].fix == nil || checks[1].Status != doctorWarn {
+		t.Fatalf("checkDoctorProject(web) = %+v, want legacy-file and stale-lockfile warnings", checks)
+	}
+	if err := checks[0].fix(); err != nil {
+		t.Fatalf("legacy fix error = %v", err)
+	}
+	if _, err := os.Stat(journalPath(ws)); err != nil {
+		t.Errorf("legacy journal was not migrated: %v", err)
+	}
+
+	checks = checkDoctorProject(cfg, "gone", cfg.Projects["gone"], nil, false)
+	if len(checks) != 1 || checks[0].Status != doctorFail || checks[0].fix == nil {
+		t.Fatalf("checkDoctorProject(gone) = %+v, want a fixable failure", checks)
+	}
+	if err := checks[0].fix(); err != nil {
+		t.Fatal(err)
+	}
+	if checks = checkDoctorProject(cfg, "gone", cfg.Projects["gone"], nil, false); len(checks) != 1 || checks[0].Status != doctorPass {
+		t.Errorf("after fix checkDoctorProject(gone) = %+v, want pass", checks)
+	}
+}
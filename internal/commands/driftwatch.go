@@ -0,0 +1,153 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"devbox/internal/config"
+)
+
+const minDriftInterval = time.Minute
+
+type driftRecord struct {
+	Status    string    `json:"status"`
+	Details   []string  `json:"details,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+	Since     time.Time `json:"since"`
+}
+
+func driftStorePath() string {
+	return filepath.Join(configManager.ConfigDir(), "drift.json")
+}
+
+func loadDriftRecords() map[string]driftRecord {
+	records := map[string]driftRecord{}
+	if data, err := os.ReadFile(driftStorePath()); err == nil {
+		_ = json.Unmarshal(data, &records)
+	}
+	return records
+}
+
+func saveDriftRecords(records map[string]driftRecord) error {
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(driftStorePath(), data, 0644)
+}
+
+func nextDriftRecord(prev driftRecord, hadPrev bool, status string, details []string, now time.Time) (driftRecord, bool) {
+	rec := driftRecord{Status: status, Details: details, CheckedAt: now, Since: now}
+	if hadPrev && prev.Status == status {
+		rec.Since = prev.Since
+	}
+	if status != driftDrifted {
+		return rec, false
+	}
+	return rec, !hadPrev || prev.Status != driftDrifted || strings.Join(prev.Details, "\n") != strings.Join(details, "\n")
+}
+
+func driftLabel(rec driftRecord, ok bool) string {
+	if !ok {
+		return "-"
+	}
+	switch rec.Status {
+	case driftNone:
+		return "ok"
+	case driftNoLock:
+		return "no lock"
+	}
+	return rec.Status
+}
+
+func checkDriftOnce(cfg *config.Config, desktop bool) {
+	records := loadDriftRecords()
+	projects := cfg.GetProjects()
+	for name := range records {
+		if _, ok := projects[name]; !ok {
+			delete(records, name)
+		}
+	}
+
+	names := make([]string, 0, len(projects))
+	for name := range projects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p := projects[name]
+		if p.Status == projectStatusArchived {
+			continue
+		}
+		if exists, err := dockerClient.BoxExists(p.BoxName); err != nil || !exists {
+			continue
+		}
+		if status, err := dockerClient.GetBoxStatus(p.BoxName); err != nil || status != "running" {
+			continue
+		}
+
+		status, details := measureDrift(p, p.BoxName)
+		prev, hadPrev := records[name]
+		rec, notify := nextDriftRecord(prev, hadPrev, status, details, time.Now().UTC())
+		records[name] = rec
+		fmt.Printf("%s drift check %s: %s\n", time.Now().Format("15:04:05"), name, driftLabel(rec, true))
+		if !notify {
+			continue
+		}
+		message := fmt.Sprintf("Environment '%s' drifted from devbox.lock.json", name)
+		notifyWebhooks(newWebhookEvent(config.WebhookDriftDetected, name, p.BoxName, message, details...))
+		if desktop {
+			if err := desktopNotify("devbox: drift detected", fmt.Sprintf("%s (%d difference(s)); run 'devbox verify %s'", message, len(details), name)); err != nil {
+				fmt.Printf("Warning: desktop notification failed: %v\n", err)
+			}
+		}
+	}
+	if err := saveDriftRecords(records); err != nil {
+		fmt.Printf("Warning: failed to record drift results: %v\n", err)
+	}
+}
+
+func runDriftWatch(ctx context.Context, interval time.Duration, desktop bool) {
+	check := func() {
+		cfg, err := configManager.Load()
+		if err != nil {
+			fmt.Printf("Warning: drift check skipped: failed to load configuration: %v\n", err)
+			return
+		}
+		checkDriftOnce(cfg, desktop)
+	}
+	check()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			check()
+		}
+	}
+}
+
+func desktopNotify(title, message string) error {
+	switch runtime.GOOS {
+	case "linux":
+		path, err := exec.LookPath("notify-send")
+		if err != nil {
+			return fmt.Errorf("notify-send not found")
+		}
+		return exec.Command(path, title, message).Run()
+	case "darwin":
+		script := fmt.Sprintf("display notification %q with title %q", message, title)
+		return exec.Command("osascript", "-e", script).Run()
+	}
+	return fmt.Errorf("desktop notifications are not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,64 @@
+package commands
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextDriftRecord(t *testing.T) {
+	t0 := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	t1 := t0.Add(30 * time.Minute)
+	drifted := driftRecord{Status: driftDrifted, Details: []string{"pip packages drifted"}, CheckedAt: t0, Since: t0}
+	clean := driftRecord{Status: driftNone, CheckedAt: t0, Since: t0}
+
+	tests := []struct {
+		name       string
+		prev       driftRecord
+		hadPrev    bool
+		status     string
+		details    []string
+		wantNotify bool
+		wantSince  time.Time
+	}{
+		{"first check clean", driftRecord{}, false, driftNone, nil, false, t1},
+		{"first check drifted", driftRecord{}, false, driftDrifted, []string{"pip packages drifted"}, true, t1},
+		{"starts drifting", clean, true, driftDrifted, []string{"APT packages drifted"}, true, t1},
+		{"same drift again", drifted, true, driftDrifted, []string{"pip packages drifted"}, false, t0},
+		{"drift grows", drifted, true, driftDrifted, []string{"pip packages drifted", "npm packages drifted"}, true, t0},
+		{"back in sync", drifted, true, driftNone, nil, false, t1},
+		{"still clean", clean, true, driftNone, nil, false, t0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, notify := nextDriftRecord(tt.prev, tt.hadPrev, tt.status, tt.details, t1)
+			if notify != tt.wantNotify {
+				t.Errorf("notify = %v, want %v", notify, tt.wantNotify)
+			}
+			if !rec.Since.Equal(tt.wantSince) {
+				t.Errorf("Since = %v, want %v", rec.Since, tt.wantSince)
+			}
+			if !rec.CheckedAt.Equal(t1) || rec.Status != tt.status {
+				t.Errorf("record = %+v", rec)
+			}
+		})
+	}
+}
+
+func TestDriftLabel(t *testing.T) {
+	tests := []struct {
+		rec  driftRecord
+		ok   bool
+		want string
+	}{
+		{driftRecord{}, false, "-"},
+		{driftRecord{Status: driftNone}, true, "ok"},
+		{driftRecord{Status: driftDrifted}, true, "drifted"},
+		{driftRecord{Status: driftNoLock}, true, "no lock"},
+		{driftRecord{Status: driftUnknown}, true, "unknown"},
+	}
+	for _, tt := range tests {
+		if got := driftLabel(tt.rec, tt.ok); got != tt.want {
+			t.Errorf("driftLabel(%+v, %v) = %q, want %q", tt.rec, tt.ok, got, tt.want)
+		}
+	}
+}
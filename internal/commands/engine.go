@@ -0,0 +1,62 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"devbox/internal/config"
+	"devbox/internal/docker"
+)
+
+var (
+	engineFlag   string
+	engineSource = "default"
+	engineErr    error
+)
+
+func resolveEngine(flag, env string, settings *config.GlobalSettings) (engine, source string) {
+	if flag = strings.TrimSpace(flag); flag != "" {
+		return flag, "--engine"
+	}
+	if env = strings.TrimSpace(env); env != "" {
+		return env, "DEVBOX_ENGINE"
+	}
+	if settings != nil && strings.TrimSpace(settings.Engine) != "" {
+		return strings.TrimSpace(settings.Engine), "settings.engine"
+	}
+	return config.DefaultEngine, "default"
+}
+
+func configureEngine() error {
+	engineErr = checkEngine()
+	return engineErr
+}
+
+func checkEngine() error {
+	var settings *config.GlobalSettings
+	if cfg, err := configManager.Load(); err == nil {
+		settings = cfg.Settings
+	}
+	engine, source := resolveEngine(engineFlag, os.Getenv("DEVBOX_ENGINE"), settings)
+	engineSource = source
+	if err := config.ValidateEngine(engine); err != nil {
+		return fmt.Errorf("%s: %w", source, err)
+	}
+	if err := os.Setenv("DEVBOX_ENGINE", engine); err != nil {
+		return err
+	}
+	if _, err := exec.LookPath(engine); err != nil {
+		return fmt.Errorf("container engine '%s' (from %s) is not installed or not in PATH", engine, source)
+	}
+	return nil
+}
+
+func describeEngine() string {
+	info, err := docker.DetectEngine()
+	if err != nil {
+		return fmt.Sprintf("%s (not found; from %s)", docker.Engine(), engineSource)
+	}
+	return fmt.Sprintf("%s %s (%s; from %s)", info.Name, firstNonEmpty(info.Version, "unknown version"), info.Capabilities(), engineSource)
+}
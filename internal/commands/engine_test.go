@@ -0,0 +1,32 @@
+package commands
+
+import (
+	"testing"
+
+	"devbox/internal/config"
+)
+
+func TestResolveEngine(t *testing.T) {
+	settings := &config.GlobalSettings{Engine: "nerdctl"}
+	tests := []struct {
+		name       string
+		flag, env  string
+		settings   *config.GlobalSettings
+		wantEngine string
+		wantSource string
+	}{
+		{"default", "", "", nil, "docker", "default"},
+		{"settings", "", "", settings, "nerdctl", "settings.engine"},
+		{"env beats settings", "", "podman", settings, "podman", "DEVBOX_ENGINE"},
+		{"flag beats env", "nerdctl", "podman", nil, "nerdctl", "--engine"},
+		{"blank values ignored", " ", " ", &config.GlobalSettings{Engine: " "}, "docker", "default"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine, source := resolveEngine(tt.flag, tt.env, tt.settings)
+			if engine != tt.wantEngine || source != tt.wantSource {
+				t.Errorf("resolveEngine() = %q, %q, want %q, %q", engine, source, tt.wantEngine, tt.wantSource)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"devbox/internal/config"
+)
+
+const (
+	envFormatShell  = "shell"
+	envFormatDotenv = "dotenv"
+	envFormatJSON   = "json"
+)
+
+var envFormatFlag string
+
+var envCmd = &cobra.Command{
+	Use:   "env <project>",
+	Short: "Print a project's box environment for host tools",
+	Long: `Print the environment variables a project's box gets, so host-side IDEs and scripts
+can use the same settings. The values are resolved from the global default environment
+(settings, proxy, and mirrors), then the project's devbox.lock.json, then its
+devbox.json, with later sources winning. Secrets are never printed.
+
+Formats:
+  shell   export KEY='value' lines, for eval
+  dotenv  KEY=value lines, for .env files and IDE run configurations
+  json    a JSON object
+
+Examples:
+  eval "$(devbox env myproject)"
+  devbox env myproject --format dotenv > .env
+  devbox env myproject --format json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		switch envFormatFlag {
+		case envFormatShell, envFormatDotenv, envFormatJSON:
+		default:
+			return fmt.Errorf("invalid format '%s' (use shell, dotenv, or json)", envFormatFlag)
+		}
+		cfg, err := configManager.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		project, ok := cfg.GetProject(args[0])
+		if !ok {
+			return fmt.Errorf("project '%s' not found", args[0])
+		}
+		pc, err := configManager.LoadProjectConfig(project.WorkspacePath)
+		if err != nil {
+			return fmt.Errorf("failed to load project config: %w", err)
+		}
+		var lf *lockFile
+		lockPath := filepath.Join(project.WorkspacePath, "devbox.lock.json")
+		if loaded, err := loadLockFile(lockPath); err == nil {
+			lf = loaded
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+		env := effectiveEnvironment(globalBoxDefaults(cfg.Settings).Environment, lf, pc)
+		return writeEnv(os.Stdout, env, envFormatFlag)
+	},
+}
+
+func init() {
+	envCmd.Flags().StringVar(&envFormatFlag, "format", envFormatShell, "Output format: shell, dotenv, or json")
+	rootCmd.AddCommand(envCmd)
+}
+
+func effectiveEnvironment(defaults map[string]string, lf *lockFile, pc *config.ProjectConfig) map[string]string {
+	env := map[string]string{}
+	for k, v := range defaults {
+		env[k] = v
+	}
+	if lf != nil {
+		for k, v := range lf.Container.Environment {
+			env[k] = v
+		}
+	}
+	if pc != nil {
+		for k, v := range pc.Environment {
+			env[k] = v
+		}
+		for name := range pc.Secrets {
+			delete(env, name)
+		}
+	}
+	return env
+}
+
+func writeEnv(w io.Writer, env map[string]string, format string) error {
+	if format == envFormatJSON {
+		return writeStructured(w, outputJSON, env)
+	}
+	for _, k := range sortedKeys(env) {
+		if format == envFormatDotenv {
+			fmt.Fprintf(w, "%s=%s\n", k, dotenvValue(env[k]))
+			continue
+		}
+		fmt.Fprintf(w, "export %s='%s'\n", k, escapeBash(env[k]))
+	}
+	return nil
+}
+
+func dotenvValue(v string) string {
+	if v != "" && !strings.ContainsAny(v, " \t\n\r\"'#$\\`=") {
+		return v
+	}
+	if !strings.ContainsAny(v, "'\n\r") {
+		return "'" + v + "'"
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`)
+	return `"` + r.Replace(v) + `"`
+}
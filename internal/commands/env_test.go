@@ -0,0 +1,55 @@
+package commands
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"devbox/internal/config"
+)
+
+func TestEffectiveEnvironment(t *testing.T) {
+	defaults := map[string]string{"HTTP_PROXY": "http://proxy:3128", "TZ": "UTC"}
+	lf := &lockFile{Container: lockContainer{Environment: map[string]string{"TZ": "Europe/Berlin", "APP_ENV": "staging", "LANG": "C.UTF-8"}}}
+	pc := &config.ProjectConfig{
+		Environment: map[string]string{"APP_ENV": "dev"},
+		Secrets:     map[string]config.Secret{"LANG": {}},
+	}
+	got := effectiveEnvironment(defaults, lf, pc)
+	want := map[string]string{"HTTP_PROXY": "http://proxy:3128", "TZ": "Europe/Berlin", "APP_ENV": "dev"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("effectiveEnvironment() = %v, want %v", got, want)
+	}
+	if got := effectiveEnvironment(defaults, nil, nil); !reflect.DeepEqual(got, defaults) {
+		t.Errorf("effectiveEnvironment() without lockfile or config = %v, want the defaults", got)
+	}
+}
+
+func TestWriteEnv(t *testing.T) {
+	env := map[string]string{
+		"APP_ENV":  "dev",
+		"GREETING": "it's a \"test\"",
+		"NOTE":     "a b",
+		"MULTI":    "line1\nline2",
+		"EMPTY":    "",
+	}
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{envFormatShell, "export APP_ENV='dev'\nexport EMPTY=''\nexport GREETING='it'\\''s a \"test\"'\nexport MULTI='line1\nline2'\nexport NOTE='a b'\n"},
+		{envFormatDotenv, "APP_ENV=dev\nEMPTY=''\nGREETING=\"it's a \\\"test\\\"\"\nMULTI=\"line1\\nline2\"\nNOTE='a b'\n"},
+		{envFormatJSON, "{\n  \"APP_ENV\": \"dev\",\n  \"EMPTY\": \"\",\n  \"GREETING\": \"it's a \\\"test\\\"\",\n  \"MULTI\": \"line1\\nline2\",\n  \"NOTE\": \"a b\"\n}\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeEnv(&buf, env, tt.format); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("writeEnv(%s) =\n%s\nwant\n%s", tt.format, buf.String(), tt.want)
+			}
+		})
+	}
+}
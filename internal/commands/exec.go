@@ -0,0 +1,133 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"devbox/internal/config"
+	"devbox/internal/docker"
+)
+
+var (
+	execNoTTYFlag   bool
+	execUserFlag    string
+	execWorkdirFlag string
+	execEnvFlag     []string
+)
+
+type ExitCodeError struct {
+	Code int
+}
+
+func (e *ExitCodeError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+var execCmd = &cobra.Command{
+	Use:   "exec <project> -- <command> [args...]",
+	Short: "Run a command in a box non-interactively and return its exit code",
+	Long: `Run a command directly in the project's box, without a login shell, for scripts and CI.
+devbox exits with the command's exit code. stdout and stderr are kept separate unless a TTY
+is allocated, and devbox's own messages go to stderr.
+
+A TTY is allocated only when stdin and stdout are both terminals, or never with --no-tty.
+
+Examples:
+  devbox exec myproject -- go test ./...
+  devbox exec myproject --no-tty --workdir /workspace/api -- make lint
+  devbox exec myproject --user 1000 --env CI=1 -- npm test
+  devbox exec myproject -- bash -c 'echo $HOME' > out.txt`,
+	Args: cobra.MinimumNArgs(2),
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		_ = withStdoutToStderr(func() error {
+			rootCmd.PersistentPostRun(cmd, args)
+			return nil
+		})
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projectName := args[0]
+		if err := validateProjectName(projectName); err != nil {
+			return err
+		}
+		for _, e := range execEnvFlag {
+			if name, _, _ := strings.Cut(e, "="); name == "" || strings.ContainsAny(name, " \t") {
+				return fmt.Errorf("invalid --env '%s' (expected KEY=VALUE or KEY)", e)
+			}
+		}
+
+		var boxName string
+		var project *config.Project
+		var kubeConfig *config.ProjectConfig
+		err := withStdoutToStderr(func() error {
+			cfg, err := configManager.Load()
+			if err != nil {
+				return fmt.Errorf("failed to load configuration: %w", err)
+			}
+			var ok bool
+			project, ok = cfg.GetProject(projectName)
+			if !ok {
+				return fmt.Errorf("project '%s' not found. Run 'devbox init %s' first", projectName, projectName)
+			}
+			boxName = project.BoxName
+			if kubeConfig = kubernetesProjectConfig(project); kubeConfig != nil {
+				return nil
+			}
+			status, err := dockerClient.GetBoxStatus(boxName)
+			if err != nil {
+				return fmt.Errorf("failed to get box status: %w", err)
+			}
+			if status != "running" {
+				return startStoppedBox(boxName)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		opts := docker.ExecOptions{
+			TTY:     !execNoTTYFlag && stdinIsTerminal() && stdoutIsTerminal(),
+			User:    execUserFlag,
+			Workdir: execWorkdirFlag,
+			Env:     execEnvFlag,
+		}
+		var code int
+		if kubeConfig != nil {
+			code, err = kubeExec(project, kubeConfig, args[1:], opts)
+		} else {
+			code, err = dockerClient.Exec(boxName, args[1:], opts)
+		}
+		if err != nil {
+			return err
+		}
+		if code != 0 {
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+			return &ExitCodeError{Code: code}
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(execCmd)
+	execCmd.Flags().BoolVarP(&execNoTTYFlag, "no-tty", "T", false, "Never allocate a TTY")
+	execCmd.Flags().StringVarP(&execUserFlag, "user", "u", "", "User (name or uid[:gid]) to run the command as (default: the create_user account, if any)")
+	execCmd.Flags().StringVarP(&execWorkdirFlag, "workdir", "w", "", "Working directory inside the box")
+	execCmd.Flags().StringArrayVarP(&execEnvFlag, "env", "e", nil, "Set an environment variable (KEY=VALUE, or KEY to pass the host value); repeatable")
+}
+
+func stdoutIsTerminal() bool {
+	fi, err := os.Stdout.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+func withStdoutToStderr(fn func() error) error {
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+	defer func() { os.Stdout = stdout }()
+	return fn()
+}
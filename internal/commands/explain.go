@@ -0,0 +1,393 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"devbox/internal/config"
+	"devbox/internal/docker"
+)
+
+var explainOutputFlag string
+
+type explainStep struct {
+	Note    string   `json:"note"`
+	Command []string `json:"command,omitempty"`
+}
+
+type explainPlan struct {
+	Command   string        `json:"command"`
+	Project   string        `json:"project"`
+	Box       string        `json:"box"`
+	Image     string        `json:"image"`
+	Workspace string        `json:"workspace"`
+	Steps     []explainStep `json:"steps"`
+}
+
+type explainInput struct {
+	project      string
+	box          string
+	image        string
+	workspace    string
+	workspaceBox string
+	config       *config.ProjectConfig
+	configMap    map[string]interface{}
+	exists       bool
+	running      bool
+	applyLock    bool
+}
+
+var explainCmd = &cobra.Command{
+	Use:   "explain up|init [project]",
+	Short: "Print the container commands 'up' or 'init' would run, without running them",
+	Long: `Print the pull, create, and exec commands 'devbox up' or 'devbox init' would run for the
+current configuration. Nothing is pulled, created, or changed.
+
+'explain up' reads devbox.json from the registered project's workspace, or from the
+current directory when no project is given. 'explain init' needs the project name and
+reads devbox.json from the workspace 'devbox init' would use.
+
+Examples:
+  devbox explain up                   # The project in the current directory
+  devbox explain up myproject
+  devbox explain init myproject
+  devbox explain up --output json     # The plan as JSON`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 || len(args) > 2 {
+			return fmt.Errorf("expected 'up [project]' or 'init <project>'")
+		}
+		switch args[0] {
+		case "up":
+			return nil
+		case "init":
+			if len(args) != 2 {
+				return fmt.Errorf("'explain init' requires a project name")
+			}
+			return nil
+		}
+		return fmt.Errorf("unknown command '%s' (expected up or init)", args[0])
+	},
+	ValidArgs: []string{"up", "init"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFormat(explainOutputFlag); err != nil {
+			return err
+		}
+		project := ""
+		if len(args) == 2 {
+			project = args[1]
+		}
+		if explainOutputFlag == outputTable {
+			return runExplain(args[0], project, os.Stdout)
+		}
+		stdout := os.Stdout
+		return withStdoutToStderr(func() error {
+			return runExplain(args[0], project, stdout)
+		})
+	},
+}
+
+func runExplain(command, projectName string, w io.Writer) error {
+	cfg, err := configManager.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	var in explainInput
+	switch command {
+	case "up":
+		in, err = explainUpInput(cfg, projectName)
+	case "init":
+		in, err = explainInitInput(cfg, projectName)
+	}
+	if err != nil {
+		return err
+	}
+
+	exists, err := dockerClient.BoxExists(in.box)
+	if err != nil {
+		return fmt.Errorf("failed to check box existence: %w", err)
+	}
+	in.exists = exists
+	if exists {
+		if status, err := dockerClient.GetBoxStatus(in.box); err == nil {
+			in.running = status == "running"
+		}
+	}
+
+	var plan *explainPlan
+	if command == "up" {
+		plan, err = explainUp(dockerClient, in)
+	} else {
+		plan, err = explainInit(dockerClient, in)
+	}
+	if err != nil {
+		return err
+	}
+	plan.Command = command
+	if explainOutputFlag != outputTable {
+		return writeStructured(w, explainOutputFlag, plan)
+	}
+	printExplainPlan(w, plan)
+	return nil
+}
+
+func explainUpInput(cfg *config.Config, projectName string) (explainInput, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return explainInput{}, fmt.Errorf("failed to get current directory: %w", err)
+	}
+	if projectName != "" {
+		proj, ok := cfg.GetProject(projectName)
+		if !ok {
+			return explainInput{}, fmt.Errorf("project '%s' not found", projectName)
+		}
+		cwd = proj.WorkspacePath
+	}
+
+	projectConfig, err := configManager.LoadProjectConfig(cwd)
+	if err != nil {
+		return explainInput{}, fmt.Errorf("failed to load project config: %w", err)
+	}
+	if projectConfig == nil {
+		return explainInput{}, fmt.Errorf("no project config found in %s (checked devbox.json, devbox.project.json, .devbox.json)", cwd)
+	}
+	if err := configManager.ValidateProjectConfig(projectConfig); err != nil {
+		return explainInput{}, fmt.Errorf("invalid devbox.json: %w", err)
+	}
+	if projectConfig.KubernetesBackend() {
+		return explainInput{}, fmt.Errorf("'devbox explain' only covers the container engine backend; this project uses the kubernetes backend")
+	}
+
+	projectName = firstNonEmpty(projectName, projectConfig.Name, filepath.Base(cwd))
+	boxName := boxNameFor(cfg, projectName)
+	baseImage := cfg.GetEffectiveBaseImage(&config.Project{Name: projectName, BaseImage: projectConfig.BaseImage}, projectConfig)
+
+	image := baseImage
+	if !ignoreDigestFlag && projectConfig.Build == nil {
+		if lf, err := loadLockFile(filepath.Join(cwd, "devbox.lock.json")); err == nil {
+			if pinned := lockPinnedRef(lf, baseImage); pinned != "" {
+				image = pinned
+			}
+		}
+	}
+
+	_, lockErr := os.Stat(filepath.Join(cwd, "devbox.lock.json"))
+	return explainInput{
+		project:      projectName,
+		box:          boxName,
+		image:        image,
+		workspace:    cwd,
+		workspaceBox: firstNonEmpty(projectConfig.WorkingDir, "/workspace"),
+		config:       projectConfig,
+		configMap:    upConfigMap(projectConfig, boxName, upDotfilesPath),
+		applyLock:    lockErr == nil && shouldApplyLockOnUp(cfg),
+	}, nil
+}
+
+func explainInitInput(cfg *config.Config, projectName string) (explainInput, error) {
+	if err := validateProjectName(projectName); err != nil {
+		return explainInput{}, err
+	}
+	workspacePath, err := resolveWorkspacePath(projectName, workspacePathFlag)
+	if err != nil {
+		return explainInput{}, err
+	}
+
+	projectConfig, _ := configManager.LoadProjectConfig(workspacePath)
+	if projectConfig != nil {
+		if err := configManager.ValidateProjectConfig(projectConfig); err != nil {
+			return explainInput{}, fmt.Errorf("invalid project configuration: %w", err)
+		}
+	}
+
+	var configMap map[string]interface{}
+	workspaceBox := "/workspace"
+	if projectConfig != nil {
+		data, _ := json.Marshal(projectConfig)
+		_ = json.Unmarshal(data, &configMap)
+		workspaceBox = firstNonEmpty(projectConfig.WorkingDir, workspaceBox)
+	}
+
+	return explainInput{
+		project:      projectName,
+		box:          boxNameFor(cfg, projectName),
+		image:        cfg.GetEffectiveBaseImage(&config.Project{Name: projectName, BaseImage: "ubuntu:22.04"}, projectConfig),
+		workspace:    workspacePath,
+		workspaceBox: workspaceBox,
+		config:       projectConfig,
+		configMap:    configMap,
+	}, nil
+}
+
+func explainUp(client *docker.Client, in explainInput) (*explainPlan, error) {
+	plan := newExplainPlan(in)
+	pc := in.config
+	plan.addHooks(config.HookPreUp, in)
+
+	if in.exists {
+		plan.add("box already exists; 'devbox up' reuses it instead of creating a new one")
+		if pc.Build != nil {
+			plan.addImage(in)
+		}
+		if !in.running {
+			plan.add("start the stopped box", "start", in.box)
+		}
+		if err := plan.addServices(client, in); err != nil {
+			return nil, err
+		}
+		if len(pc.SetupCommands) > 0 {
+			plan.add("setup commands that already ran in this box are skipped (--no-cache to re-run them)")
+			plan.addExecs(in.box, pc.SetupCommands)
+		}
+		plan.addHooks(config.HookPostUp, in)
+		return plan, nil
+	}
+
+	plan.addImage(in)
+	if err := plan.addServices(client, in); err != nil {
+		return nil, err
+	}
+	plan.add("create the box", client.CreateArgs(in.box, in.image, in.workspace, in.workspaceBox, in.configMap)...)
+	plan.add("start the box", "start", in.box)
+	plan.add("install the devbox helper commands in the box and mark it initialized", "exec", in.box, "touch", "/etc/devbox-initialized")
+	plan.addExecs(in.box, []string{"apt update -y", "apt full-upgrade -y", "apt autoremove -y", "apt autoclean"})
+	if len(pc.Services) > 0 {
+		plan.add("join the service network", "network", "connect", serviceNetworkName(in.box), in.box)
+	}
+	plan.addExecs(in.box, pc.SetupCommands)
+	if in.applyLock {
+		plan.add("apply devbox.lock.json (see 'devbox apply')")
+	}
+	plan.addHooks(config.HookPostUp, in)
+	return plan, nil
+}
+
+func explainInit(client *docker.Client, in explainInput) (*explainPlan, error) {
+	plan := newExplainPlan(in)
+
+	plan.addImage(in)
+	if in.exists {
+		plan.add("remove the existing box ('devbox init' needs --force for this)", "rm", "-f", in.box)
+	}
+	plan.add("create the box", client.CreateArgs(in.box, in.image, in.workspace, in.workspaceBox, in.configMap)...)
+	plan.add("start the box", "start", in.box)
+	plan.addExecs(in.box, []string{"apt update -y", "apt full-upgrade -y"})
+	if in.config != nil {
+		plan.addExecs(in.box, in.config.SetupCommands)
+	}
+	plan.add("install the devbox helper commands in the box and mark it initialized", "exec", in.box, "touch", "/etc/devbox-initialized")
+	return plan, nil
+}
+
+func newExplainPlan(in explainInput) *explainPlan {
+	return &explainPlan{
+		Project:   in.project,
+		Box:       in.box,
+		Image:     in.image,
+		Workspace: in.workspace,
+		Steps:     []explainStep{},
+	}
+}
+
+func (p *explainPlan) add(note string, args ...string) {
+	step := explainStep{Note: note}
+	if len(args) > 0 {
+		step.Command = append([]string{engineCmd()}, args...)
+	}
+	p.Steps = append(p.Steps, step)
+}
+
+func (p *explainPlan) addHooks(event string, in explainInput) {
+	if noHooksFlag || in.config == nil {
+		return
+	}
+	for _, h := range in.config.Hooks[event] {
+		if h.InBox() {
+			p.add(event+" hook in the box", "exec", "--workdir", firstNonEmpty(in.config.WorkingDir, "/workspace"), in.box, "bash", "-c", h.Run)
+			continue
+		}
+		p.add(fmt.Sprintf("%s hook on the host, in %s: %s", event, in.workspace, h.Run))
+	}
+}
+
+func (p *explainPlan) addImage(in explainInput) {
+	if in.config == nil || in.config.Build == nil {
+		p.add("pull the base image (skipped when it is already present)", "pull", in.image)
+		return
+	}
+	contextDir, dockerfile := in.config.Build.Paths(in.workspace)
+	p.add("build the base image from devbox.json", docker.BuildArgs(docker.BuildOptions{
+		Tag:        in.image,
+		ContextDir: contextDir,
+		Dockerfile: dockerfile,
+		Args:       in.config.Build.Args,
+		Target:     in.config.Build.Target,
+	})...)
+}
+
+func (p *explainPlan) addServices(client *docker.Client, in explainInput) error {
+	if len(in.config.Services) == 0 {
+		return nil
+	}
+	specs, err := serviceSpecs(in.box, in.workspace, in.config.Services)
+	if err != nil {
+		return err
+	}
+	p.add("create the service network (skipped when it exists)", "network", "create", "--label", fmt.Sprintf("%s=%s", docker.OwnerLabel, docker.CurrentOwner()), serviceNetworkName(in.box))
+	for _, spec := range specs {
+		p.add(fmt.Sprintf("start service '%s' (skipped when it is running and unchanged)", spec.Service), "pull", spec.Image)
+		p.add("", client.ServiceCreateArgs(spec)...)
+		p.add("", "start", spec.Name)
+	}
+	return nil
+}
+
+func (p *explainPlan) addExecs(box string, commands []string) {
+	for i, command := range commands {
+		note := ""
+		if i == 0 {
+			note = fmt.Sprintf("run %d command(s) in the box", len(commands))
+		}
+		p.add(note, "exec", box, "bash", "-c", command)
+	}
+}
+
+func explainQuote(arg string) string {
+	if arg != "" && strings.IndexFunc(arg, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:=@,+%", r))
+	}) < 0 {
+		return arg
+	}
+	return "'" + escapeBash(arg) + "'"
+}
+
+func printExplainPlan(w io.Writer, plan *explainPlan) {
+	fmt.Fprintf(w, "%s\n", dimText(fmt.Sprintf("# devbox %s: project %s, box %s, image %s", plan.Command, plan.Project, plan.Box, plan.Image)))
+	for _, step := range plan.Steps {
+		if step.Note != "" {
+			fmt.Fprintf(w, "\n%s\n", dimText("# "+step.Note))
+		}
+		if len(step.Command) == 0 {
+			continue
+		}
+		quoted := make([]string, len(step.Command))
+		for i, arg := range step.Command {
+			quoted[i] = explainQuote(arg)
+		}
+		fmt.Fprintln(w, strings.Join(quoted, " "))
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(explainCmd)
+	explainCmd.Flags().StringVar(&upDotfilesPath, "dotfiles", "", "Include a dotfiles directory mount, as with 'devbox up --dotfiles'")
+	explainCmd.Flags().StringVar(&workspacePathFlag, "path", "", "With init, the workspace directory 'devbox init --path' would use")
+	explainCmd.Flags().BoolVar(&ignoreDigestFlag, "ignore-digest", false, "Use base_image's tag even when devbox.lock.json pins an image digest, as with 'devbox up --ignore-digest'")
+	addOutputFlag(explainCmd, &explainOutputFlag)
+}
@@ -0,0 +1,126 @@
+package commands
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"devbox/internal/config"
+	"devbox/internal/docker"
+)
+
+func explainCommands(plan *explainPlan) []string {
+	var out []string
+	for _, step := range plan.Steps {
+		if len(step.Command) > 0 {
+			out = append(out, strings.Join(step.Command, " "))
+		}
+	}
+	return out
+}
+
+func TestExplainUp(t *testing.T) {
+	t.Setenv("DEVBOX_ENGINE", "docker")
+	pc := &config.ProjectConfig{
+		Name:          "web",
+		SetupCommands: []string{"apt install -y jq"},
+		Services:      map[string]*config.Service{"db": {Image: "postgres:16"}},
+	}
+	in := explainInput{
+		project:      "web",
+		box:          "devbox_web",
+		image:        "ubuntu@sha256:abcd",
+		workspace:    "/src/web",
+		workspaceBox: "/workspace",
+		config:       pc,
+		configMap:    upConfigMap(pc, "devbox_web", ""),
+		applyLock:    true,
+	}
+
+	plan, err := explainUp(&docker.Client{}, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmds := explainCommands(plan)
+	want := []string{
+		"docker pull ubuntu@sha256:abcd",
+		"docker pull postgres:16",
+		"docker start devbox_web",
+		"docker exec devbox_web bash -c apt update -y",
+		"docker network connect devbox_web_net devbox_web",
+		"docker exec devbox_web bash -c apt install -y jq",
+	}
+	joined := strings.Join(cmds, "\n")
+	for _, w := range want {
+		if !strings.Contains(joined, w) {
+			t.Errorf("explainUp() is missing %q in:\n%s", w, joined)
+		}
+	}
+	create := ""
+	for _, c := range cmds {
+		if strings.HasPrefix(c, "docker create --name devbox_web ") {
+			create = c
+		}
+	}
+	if !strings.Contains(create, "--network devbox_web_net") || !strings.HasSuffix(create, "ubuntu@sha256:abcd sleep infinity") {
+		t.Errorf("explainUp() create = %q", create)
+	}
+	if last := plan.Steps[len(plan.Steps)-1]; len(last.Command) != 0 || !strings.Contains(last.Note, "devbox.lock.json") {
+		t.Errorf("explainUp() last step = %+v, want the lockfile note", last)
+	}
+
+	in.exists, in.running = true, false
+	plan, err = explainUp(&docker.Client{}, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	joined = strings.Join(explainCommands(plan), "\n")
+	if strings.Contains(joined, "docker create --name devbox_web ") || !strings.Contains(joined, "docker start devbox_web") {
+		t.Errorf("explainUp() for an existing box =\n%s", joined)
+	}
+}
+
+func TestExplainInit(t *testing.T) {
+	t.Setenv("DEVBOX_ENGINE", "podman")
+	in := explainInput{
+		project:      "api",
+		box:          "devbox_api",
+		image:        "ubuntu:22.04",
+		workspace:    "/src/api",
+		workspaceBox: "/workspace",
+		exists:       true,
+	}
+	plan, err := explainInit(&docker.Client{}, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmds := explainCommands(plan)
+	want := []string{
+		"podman pull ubuntu:22.04",
+		"podman rm -f devbox_api",
+	}
+	for i, w := range want {
+		if cmds[i] != w {
+			t.Errorf("explainInit() command %d = %q, want %q", i, cmds[i], w)
+		}
+	}
+	if !strings.HasPrefix(cmds[2], "podman create --name devbox_api --mount type=bind,source=/src/api,target=/workspace") {
+		t.Errorf("explainInit() create = %q", cmds[2])
+	}
+}
+
+func TestPrintExplainPlan(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	plan := &explainPlan{Command: "up", Project: "web", Box: "devbox_web", Image: "ubuntu:22.04", Steps: []explainStep{
+		{Note: "run 1 command(s) in the box", Command: []string{"docker", "exec", "devbox_web", "bash", "-c", "echo 'hi' > /tmp/x"}},
+		{Command: []string{"docker", "create", "-e", "A=", "-p", "8080:80"}},
+	}}
+	var buf bytes.Buffer
+	printExplainPlan(&buf, plan)
+	want := "# devbox up: project web, box devbox_web, image ubuntu:22.04\n\n# run 1 command(s) in the box\n" +
+		"docker exec devbox_web bash -c 'echo '\\''hi'\\'' > /tmp/x'\n" +
+		"docker create -e A= -p 8080:80\n"
+	if buf.String() != want {
+		t.Errorf("printExplainPlan() =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
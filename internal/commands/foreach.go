@@ -0,0 +1,237 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"devbox/internal/config"
+	"devbox/internal/parallel"
+)
+
+var (
+	foreachRunningFlag bool
+	foreachAllFlag     bool
+	foreachTimeoutFlag time.Duration
+)
+
+type foreachTarget struct {
+	Project string
+	Box     string
+	Start   bool
+	Skip    string
+}
+
+type foreachResult struct {
+	foreachTarget
+	ExitCode int
+	Output   string
+	Duration time.Duration
+	Err      error
+}
+
+func (r foreachResult) status() string {
+	switch {
+	case r.Skip != "":
+		return "skipped (" + r.Skip + ")"
+	case r.Err != nil:
+		return "error: " + r.Err.Error()
+	case r.ExitCode != 0:
+		return fmt.Sprintf("exit %d", r.ExitCode)
+	}
+	return "ok"
+}
+
+var foreachCmd = &cobra.Command{
+	Use:   "foreach [--running|--all] -- <command> [args...]",
+	Short: "Run a command in every devbox box",
+	Long: `Run a command in every running box (the default) or, with --all, in every box,
+starting stopped boxes for the command and stopping them again afterwards.
+
+Boxes are handled in parallel (see --workers). Each box's output is printed as a block
+when it finishes, followed by a per-project summary.
+
+Examples:
+  devbox foreach -- ldd --version
+  devbox foreach --all -- 'python3 --version 2>&1'
+  devbox foreach --workers 8 --timeout 2m -- apt list --upgradable`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if foreachRunningFlag && foreachAllFlag {
+			return fmt.Errorf("--running and --all cannot be combined")
+		}
+		if foreachTimeoutFlag <= 0 {
+			return fmt.Errorf("--timeout must be positive")
+		}
+
+		cfg, err := configManager.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+
+		targets := foreachTargets(cfg.GetProjects(), foreachAllFlag, noStartFlag, func(box string) string {
+			exists, err := dockerClient.BoxExists(box)
+			if err != nil || !exists {
+				return ""
+			}
+			status, err := dockerClient.GetBoxStatus(box)
+			if err != nil {
+				return ""
+			}
+			return status
+		})
+		if len(targets) == 0 {
+			fmt.Printf("No projects found.\n")
+			return nil
+		}
+
+		results := runForeach(targets, foreachScript(args), foreachTimeoutFlag)
+		fmt.Printf("\nSummary:\n")
+		ok, failed, skipped := 0, 0, 0
+		for _, r := range results {
+			switch {
+			case r.Skip != "":
+				skipped++
+			case r.Err != nil || r.ExitCode != 0:
+				failed++
+			default:
+				ok++
+			}
+			fmt.Printf("  %-20s %-8s %s\n", r.Project, formatForeachDuration(r), r.status())
+		}
+		fmt.Printf("\n%d ok, %d failed, %d skipped\n", ok, failed, skipped)
+		if failed > 0 {
+			return fmt.Errorf("command failed in %d box(es)", failed)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(foreachCmd)
+	foreachCmd.Flags().SetInterspersed(false)
+	foreachCmd.Flags().BoolVar(&foreachRunningFlag, "running", false, "Only run in boxes that are already running (default)")
+	foreachCmd.Flags().BoolVar(&foreachAllFlag, "all", false, "Run in every box, starting stopped boxes and stopping them again afterwards")
+	foreachCmd.Flags().DurationVar(&foreachTimeoutFlag, "timeout", 10*time.Minute, "Maximum time the command may run in each box")
+}
+
+func foreachScript(args []string) string {
+	if len(args) == 1 {
+		return args[0]
+	}
+	return quoteAll(args)
+}
+
+func foreachTargets(projects map[string]*config.Project, all, noStart bool, statusOf func(box string) string) []foreachTarget {
+	names := make([]string, 0, len(projects))
+	for name := range projects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var targets []foreachTarget
+	for _, name := range names {
+		p := projects[name]
+		t := foreachTarget{Project: name, Box: p.BoxName}
+		switch status := statusOf(p.BoxName); {
+		case p.Status == projectStatusArchived:
+			t.Skip = "archived"
+		case status == "":
+			t.Skip = "no box"
+		case status == "running":
+		case all && noStart:
+			t.Skip = "stopped, --no-start"
+		case all:
+			t.Start = true
+		default:
+			t.Skip = status
+		}
+		targets = append(targets, t)
+	}
+	return targets
+}
+
+func runForeach(targets []foreachTarget, script string, timeout time.Duration) []foreachResult {
+	results := make([]foreachResult, len(targets))
+	tasks := make([]parallel.ContextTask, 0, len(targets))
+	var printMu sync.Mutex
+	for i, t := range targets {
+		results[i].foreachTarget = t
+		if t.Skip != "" {
+			continue
+		}
+		i := i
+		tasks = append(tasks, func(ctx context.Context) error {
+			r := &results[i]
+			taskCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			start := time.Now()
+			r.ExitCode, r.Output, r.Err = runInForeachBox(taskCtx, r.foreachTarget, script)
+			r.Duration = time.Since(start)
+			printMu.Lock()
+			printForeachResult(*r)
+			printMu.Unlock()
+			return nil
+		})
+	}
+
+	pcfg := parallel.LoadConfig()
+	workers := pcfg.MaxWorkers
+	if !pcfg.EnableParallel {
+		workers = 1
+	}
+	pool := parallel.NewWorkerPool(workers, timeout*time.Duration(len(tasks)+1))
+	pool.ExecuteContext(context.Background(), tasks)
+	return results
+}
+
+func runInForeachBox(ctx context.Context, t foreachTarget, script string) (int, string, error) {
+	if t.Start {
+		if err := dockerClient.StartBox(t.Box); err != nil {
+			return -1, "", fmt.Errorf("failed to start box: %w", err)
+		}
+		defer func() {
+			if err := dockerClient.StopBox(t.Box); err != nil {
+				fmt.Printf("Warning: failed to stop box '%s': %v\n", t.Box, err)
+			}
+		}()
+	}
+	cmd := exec.CommandContext(ctx, engineCmd(), "exec", t.Box, "bash", "-c", parallel.WithShellInit(script, true))
+	out, err := cmd.CombinedOutput()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return -1, string(out), fmt.Errorf("timed out")
+	}
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode(), string(out), nil
+		}
+		return -1, string(out), err
+	}
+	return 0, string(out), nil
+}
+
+func printForeachResult(r foreachResult) {
+	fmt.Printf("==> %s (%s) [%s, %s]\n", r.Project, r.Box, r.status(), formatForeachDuration(r))
+	output := strings.TrimRight(r.Output, "\n")
+	if output == "" {
+		return
+	}
+	for _, line := range strings.Split(output, "\n") {
+		fmt.Printf("    %s\n", line)
+	}
+}
+
+func formatForeachDuration(r foreachResult) string {
+	if r.Skip != "" {
+		return "-"
+	}
+	return r.Duration.Round(100 * time.Millisecond).String()
+}
@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"devbox/internal/config"
+)
+
+func TestForeachTargets(t *testing.T) {
+	projects := map[string]*config.Project{
+		"web":    {Name: "web", BoxName: "devbox_web"},
+		"api":    {Name: "api", BoxName: "devbox_api"},
+		"old":    {Name: "old", BoxName: "devbox_old", Status: projectStatusArchived},
+		"ghost":  {Name: "ghost", BoxName: "devbox_ghost"},
+		"worker": {Name: "worker", BoxName: "devbox_worker"},
+	}
+	statuses := map[string]string{"devbox_web": "running", "devbox_api": "exited", "devbox_worker": "running"}
+	statusOf := func(box string) string { return statuses[box] }
+
+	tests := []struct {
+		name         string
+		all, noStart bool
+		want         []foreachTarget
+	}{
+		{"running", false, false, []foreachTarget{
+			{Project: "api", Box: "devbox_api", Skip: "exited"},
+			{Project: "ghost", Box: "devbox_ghost", Skip: "no box"},
+			{Project: "old", Box: "devbox_old", Skip: "archived"},
+			{Project: "web", Box: "devbox_web"},
+			{Project: "worker", Box: "devbox_worker"},
+		}},
+		{"all", true, false, []foreachTarget{
+			{Project: "api", Box: "devbox_api", Start: true},
+			{Project: "ghost", Box: "devbox_ghost", Skip: "no box"},
+			{Project: "old", Box: "devbox_old", Skip: "archived"},
+			{Project: "web", Box: "devbox_web"},
+			{Project: "worker", Box: "devbox_worker"},
+		}},
+		{"all without starting", true, true, []foreachTarget{
+			{Project: "api", Box: "devbox_api", Skip: "stopped, --no-start"},
+			{Project: "ghost", Box: "devbox_ghost", Skip: "no box"},
+			{Project: "old", Box: "devbox_old", Skip: "archived"},
+			{Project: "web", Box: "devbox_web"},
+			{Project: "worker", Box: "devbox_worker"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := foreachTargets(projects, tt.all, tt.noStart, statusOf); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("foreachTargets() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestForeachScript(t *testing.T) {
+	if got := foreachScript([]string{"ldd --version | head -1"}); got != "ldd --version | head -1" {
+		t.Errorf("single argument should be used as a shell script, got %q", got)
+	}
+	if got := foreachScript([]string{"echo", "it's"}); got != `'echo' 'it'\''s'` {
+		t.Errorf("multiple arguments should be quoted, got %q", got)
+	}
+}
+
+func TestForeachResultStatus(t *testing.T) {
+	tests := []struct {
+		result foreachResult
+		want   string
+	}{
+		{foreachResult{}, "ok"},
+		{foreachResult{ExitCode: 3}, "exit 3"},
+		{foreachResult{Err: errors.New("timed out")}, "error: timed out"},
+		{foreachResult{foreachTarget: foreachTarget{Skip: "no box"}}, "skipped (no box)"},
+	}
+	for _, tt := range tests {
+		if got := tt.result.status(); got != tt.want {
+			t.Errorf("status() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package commands
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var forwardAddressFlag string
+
+type forwardSpec struct {
+	HostPort int
+	BoxPort  int
+}
+
+var forwardCmd = &cobra.Command{
+	Use:   "forward <project> <hostPort:boxPort>...",
+	Short: "Forward host ports into a running box without recreating it",
+	Long: `Open TCP tunnels from host ports to ports inside a running box until interrupted.
+Each connection is relayed through 'docker exec' (socat when the box has it, otherwise
+bash's /dev/tcp), so services listening on localhost inside the box are reachable too.
+Use this for ad-hoc access; add the mapping to "ports" in devbox.json to make it permanent.
+
+Examples:
+  devbox forward myproject 8080:80
+  devbox forward myproject 3000 5432:5432
+  devbox forward myproject 9229 --address 0.0.0.0`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projectName := args[0]
+		if err := validateProjectName(projectName); err != nil {
+			return err
+		}
+		var specs []forwardSpec
+		for _, arg := range args[1:] {
+			spec, err := parseForwardSpec(arg)
+			if err != nil {
+				return err
+			}
+			specs = append(specs, spec)
+		}
+
+		cfg, err := configManager.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		project, ok := cfg.GetProject(projectName)
+		if !ok {
+			return fmt.Errorf("project '%s' not found", projectName)
+		}
+		status, err := dockerClient.GetBoxStatus(project.BoxName)
+		if err != nil {
+			return fmt.Errorf("failed to get box status: %w", err)
+		}
+		if status != "running" {
+			return fmt.Errorf("box '%s' is not running (state: %s)", project.BoxName, status)
+		}
+
+		var listeners []net.Listener
+		defer func() {
+			for _, l := range listeners {
+				_ = l.Close()
+			}
+		}()
+		for _, spec := range specs {
+			addr := net.JoinHostPort(forwardAddressFlag, strconv.Itoa(spec.HostPort))
+			l, err := net.Listen("tcp", addr)
+			if err != nil {
+				return fmt.Errorf("failed to listen on %s: %w", addr, err)
+			}
+			listeners = append(listeners, l)
+			go serveForward(l, project.BoxName, spec.BoxPort)
+			fmt.Printf("Forwarding %s -> %s:%d\n", addr, project.BoxName, spec.BoxPort)
+		}
+
+		interrupt := make(chan os.Signal, 1)
+		signal.Notify(interrupt, os.Interrupt)
+		defer signal.Stop(interrupt)
+		fmt.Printf("Press Ctrl+C to stop forwarding\n")
+		<-interrupt
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(forwardCmd)
+	forwardCmd.Flags().StringVar(&forwardAddressFlag, "address", "127.0.0.1", "Host address to listen on")
+}
+
+func parseForwardSpec(s string) (forwardSpec, error) {
+	host, box, found := strings.Cut(strings.TrimSpace(s), ":")
+	if !found {
+		box = host
+	}
+	hostPort, err1 := strconv.Atoi(host)
+	boxPort, err2 := strconv.Atoi(box)
+	if err1 != nil || err2 != nil || hostPort < 1 || hostPort > 65535 || boxPort < 1 || boxPort > 65535 {
+		return forwardSpec{}, fmt.Errorf("invalid port mapping '%s' (expected hostPort:boxPort or port)", s)
+	}
+	return forwardSpec{HostPort: hostPort, BoxPort: boxPort}, nil
+}
+
+func serveForward(l net.Listener, boxName string, boxPort int) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			if err := dockerClient.ForwardConn(boxName, boxPort, conn); err != nil {
+				fmt.Printf("Warning: %v\n", err)
+			}
+		}()
+	}
+}
@@ -0,0 +1,26 @@
+package commands
+
+import "testing"
+
+func TestParseForwardSpec(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    forwardSpec
+		wantErr bool
+	}{
+		{"8080:80", forwardSpec{8080, 80}, false},
+		{"3000", forwardSpec{3000, 3000}, false},
+		{" 5432:5432 ", forwardSpec{5432, 5432}, false},
+		{"0:80", forwardSpec{}, true},
+		{"8080:70000", forwardSpec{}, true},
+		{"web:80", forwardSpec{}, true},
+		{"127.0.0.1:8080:80", forwardSpec{}, true},
+		{"", forwardSpec{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parseForwardSpec(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseForwardSpec(%q) = %+v, %v; want %+v, wantErr %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
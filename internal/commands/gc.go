@@ -0,0 +1,486 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"devbox/internal/config"
+	"devbox/internal/docker"
+)
+
+const gcCheckInterval = 5 * time.Minute
+
+type gcCategory struct {
+	Name      string
+	Items     []string
+	Reclaimed int64
+	Errors    int
+}
+
+type gcState struct {
+	LastRun   string           `json:"last_run"`
+	Reclaimed map[string]int64 `json:"reclaimed,omitempty"`
+}
+
+var gcStaleDays int
+
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Garbage-collect stale boxes, orphans, old backups, and unused images/volumes",
+	Long: `Apply the garbage collection policy from ~/.devbox/config.json (settings.gc) in one pass:
+
+- Stale projects: boxes not used for stale_after_days (removed when remove_stale_boxes is set)
+- Orphaned boxes: devbox_* containers not tracked in config
+- Backup images: devbox/<project>:backup-* and pre-update-* snapshots beyond the newest keep_backups per project
+- Cache volumes: devbox workspace volumes left behind by removed boxes (removed when trim_cache_volumes is set)
+- Package cache: cached package snapshots of boxes that no longer exist
+- Dangling images and unused volumes labelled devbox.owner (prune_images / prune_volumes)
+
+When settings.gc.interval is set (e.g. "24h"), 'devbox serve' also runs gc
+automatically once the interval has elapsed. Scheduled runs skip image and
+volume pruning unless scheduled_prune is set.
+
+Boxes created by 'devbox try' and 'devbox review' are never removed as orphans.
+
+Examples:
+  devbox gc --dry-run        # Show what would be collected
+  devbox gc                  # Collect with confirmation
+  devbox gc --force          # Collect without prompting`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := configManager.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		policy := cfg.GetGCPolicy()
+		if gcStaleDays > 0 {
+			policy.StaleAfterDays = gcStaleDays
+		}
+
+		if !dryRunFlag && !forceFlag {
+			fmt.Print("Run garbage collection with the configured policy? (y/N): ")
+			reader := bufio.NewReader(os.Stdin)
+			response, err := reader.ReadString('\n')
+			if err != nil {
+				return fmt.Errorf("failed to read confirmation: %w", err)
+			}
+			response = strings.ToLower(strings.TrimSpace(response))
+			if response != "y" && response != "yes" {
+				fmt.Println("Garbage collection cancelled.")
+				return nil
+			}
+		}
+
+		return runGC(cfg, policy, dryRunFlag)
+	},
+}
+
+func runGC(cfg *config.Config, policy *config.GCPolicy, dryRun bool) error {
+	if dryRun {
+		fmt.Printf("DRY RUN - nothing will be removed\n")
+	}
+
+	categories := []*gcCategory{
+		gcStaleBoxes(cfg, policy, dryRun),
+	}
+	if policy.RemoveOrphans {
+		categories = append(categories, gcOrphanedBoxes(cfg, dryRun))
+	}
+	categories = append(categories, gcBackupImages(cfg, policy, dryRun), gcCacheVolumes(cfg, policy, dryRun), gcPackageCache(dryRun))
+	if policy.PruneImages {
+		categories = append(categories, gcPrune("Dangling images", "image", dryRun))
+	}
+	if policy.PruneVolumes {
+		categories = append(categories, gcPrune("Unused volumes", "volume", dryRun))
+	}
+
+	fmt.Printf("\n%-20s %-8s %s\n", "CATEGORY", "ITEMS", "RECLAIMED")
+	fmt.Printf("%-20s %-8s %s\n", strings.Repeat("-", 20), strings.Repeat("-", 8), strings.Repeat("-", 10))
+	var total int64
+	var failed int
+	state := gcState{LastRun: time.Now().UTC().Format(time.RFC3339), Reclaimed: map[string]int64{}}
+	for _, c := range categories {
+		fmt.Printf("%-20s %-8d %s\n", c.Name, len(c.Items), formatBytes(c.Reclaimed))
+		total += c.Reclaimed
+		failed += c.Errors
+		state.Reclaimed[c.Name] = c.Reclaimed
+	}
+	fmt.Printf("%-20s %-8s %s\n", "Total", "", formatBytes(total))
+
+	if !dryRun {
+		if err := saveGCState(&state); err != nil {
+			fmt.Printf("Warning: failed to record gc state: %v\n", err)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("garbage collection finished with %d error(s)", failed)
+	}
+	return nil
+}
+
+func gcStaleBoxes(cfg *config.Config, policy *config.GCPolicy, dryRun bool) *gcCategory {
+	cat := &gcCategory{Name: "Stale boxes"}
+	cutoff := time.Now().Add(-time.Duration(policy.StaleAfterDays) * 24 * time.Hour)
+
+	var names []string
+	for name := range cfg.GetProjects() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		project := cfg.Projects[name]
+		exists, err := dockerClient.BoxExists(project.BoxName)
+		if err != nil || !exists {
+			continue
+		}
+		lastUsed, err := dockerClient.GetLastUsed(project.BoxName)
+		if err != nil || lastUsed.IsZero() || lastUsed.After(cutoff) {
+			continue
+		}
+		idleDays := int(time.Since(lastUsed).Hours() / 24)
+		if !policy.RemoveStaleBoxes {
+			fmt.Printf("Stale: %s (unused for %d days; set gc.remove_stale_boxes to reclaim)\n", name, idleDays)
+			continue
+		}
+		size, _ := dockerClient.GetContainerSize(project.BoxName)
+		fmt.Printf("Stale: %s (unused for %d days, %s)\n", name, idleDays, formatBytes(size))
+		cat.Items = append(cat.Items, project.BoxName)
+		if dryRun {
+			cat.Reclaimed += size
+			continue
+		}
+		if err := dockerClient.RemoveBox(project.BoxName); err != nil {
+			fmt.Printf("error: failed to remove %s: %v\n", project.BoxName, err)
+			cat.Errors++
+			continue
+		}
+		cat.Reclaimed += size
+	}
+	return cat
+}
+
+func gcOrphanedBoxes(cfg *config.Config, dryRun bool) *gcCategory {
+	cat := &gcCategory{Name: "Orphaned boxes"}
+	boxes, err := dockerClient.ListBoxes()
+	if err != nil {
+		fmt.Printf("error: failed to list boxes: %v\n", err)
+		cat.Errors++
+		return cat
+	}
+	boxes = ownedBoxes(boxes)
+	boxes, skipped := filterDisposableBoxes(boxes)
+	if skipped > 0 {
+		fmt.Printf("Skipping %d box(es) created by 'devbox try' or 'devbox review'\n", skipped)
+	}
+	tracked := make(map[string]bool)
+	for _, project := range cfg.GetProjects() {
+		tracked[project.BoxName] = true
+	}
+	for _, box := range boxes {
+		for _, name := range box.Names {
+			cleanName := strings.TrimPrefix(name, "/")
+			if tracked[cleanName] {
+				continue
+			}
+			size, _ := dockerClient.GetContainerSize(cleanName)
+			if origin := boxOrigin(box.Metadata); origin != "" {
+				fmt.Printf("Orphaned: %s (%s; %s)\n", cleanName, formatBytes(size), origin)
+			} else {
+				fmt.Printf("Orphaned: %s (%s)\n", cleanName, formatBytes(size))
+			}
+			cat.Items = append(cat.Items, cleanName)
+			if dryRun {
+				cat.Reclaimed += size
+				continue
+			}
+			if err := dockerClient.RemoveBox(cleanName); err != nil {
+				fmt.Printf("error: failed to remove %s: %v\n", cleanName, err)
+				cat.Errors++
+				continue
+			}
+			cat.Reclaimed += size
+		}
+	}
+	return cat
+}
+
+func gcBackupImages(cfg *config.Config, policy *config.GCPolicy, dryRun bool) *gcCategory {
+	cat := &gcCategory{Name: "Backup images"}
+	images, err := dockerClient.ListImages("devbox/*")
+	if err != nil {
+		fmt.Printf("error: failed to list images: %v\n", err)
+		cat.Errors++
+		return cat
+	}
+
+	byRepo := map[string][]int{}
+	for i, img := range images {
+		switch {
+		case strings.HasPrefix(img.Tag, "backup-"):
+			byRepo[img.Repository] = append(byRepo[img.Repository], i)
+		case strings.HasPrefix(img.Tag, preUpdateTagPrefix):
+			byRepo[img.Repository+":"+preUpdateTagPrefix] = append(byRepo[img.Repository+":"+preUpdateTagPrefix], i)
+		}
+	}
+	for _, idxs := range byRepo {
+		sort.Slice(idxs, func(a, b int) bool {
+			return images[idxs[a]].CreatedAt.After(images[idxs[b]].CreatedAt)
+		})
+		if len(idxs) <= policy.KeepBackups {
+			continue
+		}
+		for _, i := range idxs[policy.KeepBackups:] {
+			ref := images[i].Repository + ":" + images[i].Tag
+			size, _ := dockerClient.GetImageSize(ref)
+			fmt.Printf("Old backup: %s (%s)\n", ref, formatBytes(size))
+			cat.Items = append(cat.Items, ref)
+			if dryRun {
+				cat.Reclaimed += size
+				continue
+			}
+			if err := dockerClient.RemoveImage(ref); err != nil {
+				fmt.Printf("error: failed to remove %s: %v\n", ref, err)
+				cat.Errors++
+				continue
+			}
+			cat.Reclaimed += size
+		}
+	}
+	return cat
+}
+
+func gcCacheVolumes(cfg *config.Config, policy *config.GCPolicy, dryRun bool) *gcCategory {
+	cat := &gcCategory{Name: "Cache volumes"}
+	vols, err := dockerClient.ListDanglingVolumes()
+	if err != nil {
+		fmt.Printf("error: %v\n", err)
+		cat.Errors++
+		return cat
+	}
+	tracked := make(map[string]bool)
+	for _, project := range cfg.GetProjects() {
+		tracked[project.BoxName] = true
+	}
+	names := unusedCacheVolumes(vols, tracked, docker.CurrentOwner(), allUsersFlag)
+	if len(names) == 0 {
+		return cat
+	}
+	sizes, _ := dockerClient.GetVolumeSizes()
+	for _, name := range names {
+		size := sizes[name]
+		if !policy.TrimCacheVolumes {
+			fmt.Printf("Unused volume: %s (%s; set gc.trim_cache_volumes to reclaim)\n", name, formatBytes(size))
+			continue
+		}
+		fmt.Printf("Unused volume: %s (%s)\n", name, formatBytes(size))
+		cat.Items = append(cat.Items, name)
+		if dryRun {
+			cat.Reclaimed += size
+			continue
+		}
+		if err := dockerClient.RemoveVolume(name); err != nil {
+			fmt.Printf("error: failed to remove %s: %v\n", name, err)
+			cat.Errors++
+			continue
+		}
+		cat.Reclaimed += size
+	}
+	return cat
+}
+
+func unusedCacheVolumes(vols []docker.VolumeInfo, tracked map[string]bool, owner string, allUsers bool) []string {
+	var names []string
+	for _, v := range vols {
+		box := strings.TrimSuffix(v.Name, "_workspace")
+		if box == v.Name || !strings.HasPrefix(box, "devbox_") || tracked[box] {
+			continue
+		}
+		if !allUsers && v.Owner != owner {
+			continue
+		}
+		names = append(names, v.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func gcPackageCache(dryRun bool) *gcCategory {
+	cat := &gcCategory{Name: "Package cache"}
+	files, _ := filepath.Glob(packageSnapshotPath("*"))
+	for _, file := range files {
+		boxName := strings.TrimSuffix(filepath.Base(file), ".json")
+		exists, err := dockerClient.BoxExists(boxName)
+		if err != nil || exists {
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		cat.Items = append(cat.Items, boxName)
+		if dryRun {
+			cat.Reclaimed += info.Size()
+			continue
+		}
+		if err := os.Remove(file); err != nil {
+			fmt.Printf("error: failed to remove %s: %v\n", file, err)
+			cat.Errors++
+			continue
+		}
+		cat.Reclaimed += info.Size()
+	}
+	return cat
+}
+
+func gcOwnerFilter() string {
+	if allUsersFlag {
+		return "label=" + docker.OwnerLabel
+	}
+	return fmt.Sprintf("label=%s=%s", docker.OwnerLabel, docker.CurrentOwner())
+}
+
+func gcPrune(name, resource string, dryRun bool) *gcCategory {
+	cat := &gcCategory{Name: name}
+	filter := gcOwnerFilter()
+	if dryRun {
+		fmt.Printf("Would run: %s %s prune -f --filter %s\n", engineCmd(), resource, filter)
+		return cat
+	}
+	reclaimed, err := dockerClient.Prune(resource, filter)
+	if err != nil {
+		fmt.Printf("error: %v\n", err)
+		cat.Errors++
+		return cat
+	}
+	cat.Reclaimed = reclaimed
+	if reclaimed > 0 {
+		cat.Items = append(cat.Items, resource)
+	}
+	return cat
+}
+
+func gcStatePath() string {
+	return filepath.Join(configManager.ConfigDir(), "gc-state.json")
+}
+
+func loadGCState() *gcState {
+	data, err := os.ReadFile(gcStatePath())
+	if err != nil {
+		return nil
+	}
+	var st gcState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil
+	}
+	return &st
+}
+
+func saveGCState(st *gcState) error {
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(gcStatePath(), data, 0644)
+}
+
+func gcDue(cfg *config.Config, st *gcState, now time.Time) (time.Duration, bool) {
+	if cfg == nil || cfg.Settings == nil || cfg.Settings.GC == nil || cfg.Settings.GC.Interval == "" {
+		return 0, false
+	}
+	interval, err := time.ParseDuration(cfg.Settings.GC.Interval)
+	if err != nil || interval <= 0 {
+		return 0, false
+	}
+	if st != nil {
+		if last, err := time.Parse(time.RFC3339, st.LastRun); err == nil && now.Sub(last) < interval {
+			return interval, false
+		}
+	}
+	return interval, true
+}
+
+func maybeRunScheduledGC(now time.Time) {
+	cfg, err := configManager.Load()
+	if err != nil {
+		return
+	}
+	interval, due := gcDue(cfg, loadGCState(), now)
+	if !due {
+		return
+	}
+	fmt.Printf("\nRunning scheduled garbage collection (interval %s)...\n", interval)
+	if err := runGC(cfg, scheduledGCPolicy(cfg), false); err != nil {
+		fmt.Printf("Warning: scheduled gc: %v\n", err)
+	}
+}
+
+func scheduledGCPolicy(cfg *config.Config) *config.GCPolicy {
+	policy := cfg.GetGCPolicy()
+	if !policy.ScheduledPrune {
+		policy.PruneImages = false
+		policy.PruneVolumes = false
+	}
+	return policy
+}
+
+func runGCWatch(ctx context.Context) {
+	maybeRunScheduledGC(time.Now())
+	ticker := time.NewTicker(gcCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			maybeRunScheduledGC(now)
+		}
+	}
+}
+
+func disposableBox(md docker.BoxMetadata) bool {
+	return md.Source == "try" || md.Source == "review"
+}
+
+func filterDisposableBoxes(boxes []docker.BoxInfo) ([]docker.BoxInfo, int) {
+	var kept []docker.BoxInfo
+	skipped := 0
+	for _, box := range boxes {
+		if disposableBox(box.Metadata) {
+			skipped++
+			continue
+		}
+		kept = append(kept, box)
+	}
+	return kept, skipped
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func init() {
+	rootCmd.AddCommand(gcCmd)
+	gcCmd.Flags().BoolVarP(&dryRunFlag, "dry-run", "n", false, "Show what would be collected without removing anything")
+	gcCmd.Flags().BoolVarP(&forceFlag, "force", "f", false, "Run without confirmation prompt")
+	gcCmd.Flags().BoolVar(&allUsersFlag, "all-users", false, "Include boxes, volumes, and images owned by other users or without an owner label")
+	gcCmd.Flags().IntVar(&gcStaleDays, "stale-days", 0, "Override gc.stale_after_days for this run")
+}
@@ -0,0 +1,137 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"devbox/internal/config"
+	"devbox/internal/docker"
+)
+
+func TestGCDue(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	withInterval := func(interval string) *config.Config {
+		return &config.Config{Settings: &config.GlobalSettings{GC: &config.GCPolicy{Interval: interval}}}
+	}
+	ranAt := func(d time.Duration) *gcState {
+		return &gcState{LastRun: now.Add(-d).Format(time.RFC3339)}
+	}
+	tests := []struct {
+		name  string
+		cfg   *config.Config
+		state *gcState
+		want  bool
+	}{
+		{"no settings", &config.Config{}, nil, false},
+		{"no interval", withInterval(""), nil, false},
+		{"invalid interval", withInterval("daily"), nil, false},
+		{"never ran", withInterval("24h"), nil, true},
+		{"ran recently", withInterval("24h"), ranAt(time.Hour), false},
+		{"interval elapsed", withInterval("24h"), ranAt(25 * time.Hour), true},
+		{"unreadable state", withInterval("24h"), &gcState{LastRun: "yesterday"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, got := gcDue(tt.cfg, tt.state, now); got != tt.want {
+				t.Errorf("gcDue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterDisposableBoxes(t *testing.T) {
+	boxes := []docker.BoxInfo{
+		{Names: []string{"devbox_web"}, Metadata: docker.BoxMetadata{Source: "up"}},
+		{Names: []string{"devbox_try_0a1b2c"}, Metadata: docker.BoxMetadata{Source: "try"}},
+		{Names: []string{"devbox_webapp-review-42"}, Metadata: docker.BoxMetadata{Source: "review"}},
+		{Names: []string{"devbox_old"}},
+	}
+	kept, skipped := filterDisposableBoxes(boxes)
+	if skipped != 2 {
+		t.Errorf("skipped = %d, want 2", skipped)
+	}
+	if len(kept) != 2 || kept[0].Names[0] != "devbox_web" || kept[1].Names[0] != "devbox_old" {
+		t.Errorf("kept = %+v, want devbox_web and devbox_old", kept)
+	}
+}
+
+func TestUnusedCacheVolumes(t *testing.T) {
+	vols := []docker.VolumeInfo{
+		{Name: "devbox_old_workspace", Owner: "1000"},
+		{Name: "devbox_web_workspace", Owner: "1000"},
+		{Name: "devbox_shared_workspace", Owner: "1001"},
+		{Name: "devbox_legacy_workspace"},
+		{Name: "postgres_data", Owner: "1000"},
+		{Name: "devbox_cache", Owner: "1000"},
+	}
+	tracked := map[string]bool{"devbox_web": true}
+	tests := []struct {
+		name     string
+		allUsers bool
+		want     []string
+	}{
+		{"own volumes", false, []string{"devbox_old_workspace"}},
+		{"all users", true, []string{"devbox_legacy_workspace", "devbox_old_workspace", "devbox_shared_workspace"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unusedCacheVolumes(vols, tracked, "1000", tt.allUsers); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("unusedCacheVolumes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduledGCPolicy(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    *config.GCPolicy
+		wantPrune bool
+	}{
+		{"defaults", nil, false},
+		{"prune without opt-in", &config.GCPolicy{PruneImages: true, PruneVolumes: true}, false},
+		{"prune with opt-in", &config.GCPolicy{PruneImages: true, PruneVolumes: true, ScheduledPrune: true}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{Settings: &config.GlobalSettings{GC: tt.policy}}
+			got := scheduledGCPolicy(cfg)
+			if got.PruneImages != tt.wantPrune || got.PruneVolumes != tt.wantPrune {
+				t.Errorf("scheduledGCPolicy() prune images/volumes = %v/%v, want %v", got.PruneImages, got.PruneVolumes, tt.wantPrune)
+			}
+			if cfg.GetGCPolicy().PruneImages != (tt.policy == nil || tt.policy.PruneImages) {
+				t.Error("scheduledGCPolicy() modified the configured policy")
+			}
+		})
+	}
+}
+
+func TestGCPruneFiltersByOwner(t *testing.T) {
+	dir := t.TempDir()
+	log := filepath.Join(dir, "args")
+	script := filepath.Join(dir, "engine")
+	body := "#!/bin/sh\necho \"$@\" > " + log + "\necho 'Total reclaimed space: 2kB'\n"
+	if err := os.WriteFile(script, []byte(body), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DEVBOX_ENGINE", script)
+	defer func(orig *docker.Client) { dockerClient = orig }(dockerClient)
+	dockerClient = &docker.Client{}
+
+	cat := gcPrune("Dangling images", "image", false)
+	data, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "image prune -f --filter label=devbox.owner=" + docker.CurrentOwner()
+	if got := strings.TrimSpace(string(data)); got != want {
+		t.Errorf("gcPrune() ran %q, want %q", got, want)
+	}
+	if cat.Reclaimed != 2000 || cat.Errors != 0 {
+		t.Errorf("gcPrune() = %+v, want 2000 bytes reclaimed", cat)
+	}
+}
@@ -0,0 +1,183 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"devbox/internal/config"
+)
+
+const minHostDiskFree = 2 << 30
+
+type hostResources struct {
+	MemTotal     int64
+	MemAvailable int64
+	DiskFree     int64
+	DiskTotal    int64
+	CPUs         int
+}
+
+type boxRequirements struct {
+	CPUs   float64
+	Memory int64
+}
+
+func parseMeminfo(r io.Reader) (total, available int64) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		v, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		if len(fields) > 2 && strings.EqualFold(fields[2], "kB") {
+			v *= 1024
+		}
+		switch fields[0] {
+		case "MemTotal:":
+			total = v
+		case "MemAvailable:":
+			available = v
+		}
+	}
+	return total, available
+}
+
+func parseMemoryLimit(s string) (int64, error) {
+	if strings.TrimSpace(s) == "" {
+		return 0, nil
+	}
+	return config.ParseMemory(s)
+}
+
+func requirementsFor(projectConfig *config.ProjectConfig) boxRequirements {
+	var req boxRequirements
+	if projectConfig == nil || projectConfig.Resources == nil {
+		return req
+	}
+	if v, err := config.ParseCPUs(projectConfig.Resources.CPUs); err == nil {
+		req.CPUs = v
+	}
+	if v, err := parseMemoryLimit(projectConfig.Resources.Memory); err == nil {
+		req.Memory = v
+	}
+	return req
+}
+
+func readHostResources() hostResources {
+	res := hostResources{CPUs: runtime.NumCPU()}
+	if f, err := os.Open("/proc/meminfo"); err == nil {
+		res.MemTotal, res.MemAvailable = parseMeminfo(f)
+		f.Close()
+	}
+	path := "/"
+	if root := dockerClient.GetDockerRootDir(); root != "" {
+		if _, err := os.Stat(root); err == nil {
+			path = root
+		}
+	}
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err == nil {
+		res.DiskFree = int64(st.Bavail) * int64(st.Bsize)
+		res.DiskTotal = int64(st.Blocks) * int64(st.Bsize)
+	}
+	return res
+}
+
+func resourceShortages(host hostResources, req boxRequirements) (problems, warnings []string) {
+	if req.CPUs > 0 && host.CPUs > 0 && req.CPUs > float64(host.CPUs) {
+		problems = append(problems, fmt.Sprintf("box requests %.1f CPUs but the host has %d", req.CPUs, host.CPUs))
+	}
+	if req.Memory > 0 && host.MemAvailable > 0 {
+		switch {
+		case req.Memory > host.MemAvailable:
+			problems = append(problems, fmt.Sprintf("box requests %s memory but only %s is available", formatBytes(req.Memory), formatBytes(host.MemAvailable)))
+		case host.MemTotal > 0 && host.MemAvailable-req.Memory < host.MemTotal/10:
+			warnings = append(warnings, fmt.Sprintf("starting this box leaves less than 10%% of host memory free (%s available, %s requested)", formatBytes(host.MemAvailable), formatBytes(req.Memory)))
+		}
+	}
+	if host.DiskFree > 0 && host.DiskFree < minHostDiskFree {
+		problems = append(problems, fmt.Sprintf("only %s of disk space free for Docker", formatBytes(host.DiskFree)))
+	}
+	return problems, warnings
+}
+
+func idleBoxSuggestions(cfg *config.Config, exclude string, limit int) []string {
+	boxes, err := dockerClient.ListBoxes()
+	if err != nil {
+		return nil
+	}
+	projectByBox := map[string]string{}
+	for name, p := range cfg.GetProjects() {
+		projectByBox[p.BoxName] = name
+	}
+	type candidate struct {
+		project  string
+		lastUsed time.Time
+	}
+	var running []candidate
+	for _, box := range boxes {
+		if len(box.Names) == 0 || box.Names[0] == exclude || !box.Running() {
+			continue
+		}
+		project, ok := projectByBox[box.Names[0]]
+		if !ok {
+			continue
+		}
+		lastUsed, _ := dockerClient.GetLastUsed(box.Names[0])
+		running = append(running, candidate{project: project, lastUsed: lastUsed})
+	}
+	sort.Slice(running, func(i, j int) bool { return running[i].lastUsed.Before(running[j].lastUsed) })
+
+	var out []string
+	for i, c := range running {
+		if i >= limit {
+			break
+		}
+		since := "unknown"
+		if !c.lastUsed.IsZero() {
+			since = time.Since(c.lastUsed).Round(time.Minute).String() + " ago"
+		}
+		out = append(out, fmt.Sprintf("devbox stop %s   # last used %s", c.project, since))
+	}
+	return out
+}
+
+func reportHostCapacity(cfg *config.Config, boxName string, projectConfig *config.ProjectConfig) bool {
+	problems, warnings := resourceShortages(readHostResources(), requirementsFor(projectConfig))
+	for _, w := range append(problems, warnings...) {
+		fmt.Printf("Warning: %s\n", w)
+	}
+	if len(problems) == 0 {
+		return false
+	}
+	if suggestions := idleBoxSuggestions(cfg, boxName, 3); len(suggestions) > 0 {
+		fmt.Printf("hint: free resources by stopping idle boxes:\n")
+		for _, s := range suggestions {
+			fmt.Printf("  %s\n", s)
+		}
+	}
+	return true
+}
+
+func checkHostCapacity(cfg *config.Config, boxName string, projectConfig *config.ProjectConfig, force bool) error {
+	if !reportHostCapacity(cfg, boxName, projectConfig) {
+		return nil
+	}
+	if force {
+		fmt.Printf("Continuing despite low host resources (--force)\n")
+		return nil
+	}
+	return fmt.Errorf("insufficient host resources for box '%s'. Use --force to start anyway", boxName)
+}
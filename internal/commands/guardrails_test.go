@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"devbox/internal/config"
+)
+
+func TestParseMeminfo(t *testing.T) {
+	in := "MemTotal:       16303452 kB\nMemFree:          812344 kB\nMemAvailable:    8151726 kB\n"
+	total, avail := parseMeminfo(strings.NewReader(in))
+	if total != 16303452*1024 || avail != 8151726*1024 {
+		t.Errorf("parseMeminfo = %d, %d", total, avail)
+	}
+}
+
+func TestParseMemoryLimit(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+		err  bool
+	}{
+		{"", 0, false},
+		{"512m", 512 << 20, false},
+		{"2g", 2 << 30, false},
+		{"1.5GB", 3 << 29, false},
+		{"1024k", 1 << 20, false},
+		{"4096", 4096, false},
+		{"lots", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseMemoryLimit(tt.in)
+		if (err != nil) != tt.err || got != tt.want {
+			t.Errorf("parseMemoryLimit(%q) = %d, %v; want %d (err=%t)", tt.in, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestResourceShortages(t *testing.T) {
+	host := hostResources{MemTotal: 16 << 30, MemAvailable: 4 << 30, DiskFree: 50 << 30, CPUs: 4}
+
+	problems, warnings := resourceShortages(host, requirementsFor(&config.ProjectConfig{Resources: &config.Resources{CPUs: "2", Memory: "2g"}}))
+	if len(problems) != 0 || len(warnings) != 0 {
+		t.Errorf("expected no issues, got %v / %v", problems, warnings)
+	}
+
+	problems, _ = resourceShortages(host, requirementsFor(&config.ProjectConfig{Resources: &config.Resources{CPUs: "8", Memory: "8g"}}))
+	if len(problems) != 2 {
+		t.Errorf("expected CPU and memory problems, got %v", problems)
+	}
+
+	_, warnings = resourceShortages(host, boxRequirements{Memory: 3 << 30})
+	if len(warnings) != 1 {
+		t.Errorf("expected low-headroom warning, got %v", warnings)
+	}
+
+	host.DiskFree = 1 << 30
+	problems, _ = resourceShortages(host, boxRequirements{})
+	if len(problems) != 1 || !strings.Contains(problems[0], "disk") {
+		t.Errorf("expected disk problem, got %v", problems)
+	}
+}
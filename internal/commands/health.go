@@ -0,0 +1,55 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"devbox/internal/docker"
+)
+
+func healthLabel(h *docker.HealthStatus) string {
+	if !h.Configured() {
+		return "-"
+	}
+	if h.Status == "unhealthy" && h.FailingStreak > 0 {
+		return fmt.Sprintf("unhealthy (%d)", h.FailingStreak)
+	}
+	return h.Status
+}
+
+func lastProbeSummary(h *docker.HealthStatus) string {
+	if h == nil || h.LastCheck.IsZero() {
+		return ""
+	}
+	output := strings.Join(strings.Fields(h.LastOutput), " ")
+	if len(output) > 120 {
+		output = output[:117] + "..."
+	}
+	summary := fmt.Sprintf("%s ago, exit %d", humanizeDuration(time.Since(h.LastCheck)), h.LastExitCode)
+	if output != "" {
+		summary += ": " + output
+	}
+	return summary
+}
+
+func waitForBoxHealth(boxName string, timeout time.Duration) error {
+	health, err := dockerClient.GetHealth(boxName)
+	if err != nil {
+		return err
+	}
+	if !health.Configured() {
+		fmt.Printf("Box '%s' has no health check; not waiting.\n", boxName)
+		return nil
+	}
+	fmt.Printf("Waiting for box '%s' to become healthy (timeout %s)...\n", boxName, timeout)
+	health, err = dockerClient.WaitForHealthy(boxName, timeout)
+	if err != nil {
+		if probe := lastProbeSummary(health); probe != "" {
+			fmt.Printf("Last health check: %s\n", probe)
+		}
+		return err
+	}
+	fmt.Printf("Box '%s' is healthy.\n", boxName)
+	return nil
+}
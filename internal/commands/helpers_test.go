@@ -0,0 +1,17 @@
+package commands
+
+import (
+	"testing"
+
+	"devbox/internal/config"
+	"devbox/internal/testutil"
+)
+
+func withTempConfigManager(t *testing.T) *config.ConfigManager {
+	t.Helper()
+	cm, _ := testutil.CreateConfigManager(t)
+	prev := configManager
+	configManager = cm
+	t.Cleanup(func() { configManager = prev })
+	return cm
+}
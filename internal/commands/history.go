@@ -0,0 +1,142 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"devbox/internal/parallel"
+)
+
+type setupStepLog struct {
+	Name    string
+	Path    string
+	Command string
+	Exit    string
+}
+
+type setupRun struct {
+	Name  string
+	Dir   string
+	Steps []setupStepLog
+}
+
+var historyLimit int
+
+var historyCmd = &cobra.Command{
+	Use:   "history <project>",
+	Short: "Show recent setup runs and their per-step logs",
+	Long: `List setup runs recorded under <workspace>/.devbox/logs, newest first.
+Each step's output is kept in its own log file; failed steps are highlighted.
+
+Examples:
+  devbox history myproject
+  devbox history myproject --limit 3`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projectName := args[0]
+		if err := validateProjectName(projectName); err != nil {
+			return fmt.Errorf("invalid project name: %w", err)
+		}
+
+		cfg, err := configManager.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		project, ok := cfg.GetProject(projectName)
+		if !ok {
+			return fmt.Errorf("project '%s' not found", projectName)
+		}
+
+		runs, err := loadSetupRuns(filepath.Join(project.WorkspacePath, ".devbox", "logs"))
+		if err != nil {
+			return err
+		}
+		if len(runs) == 0 {
+			fmt.Printf("No setup runs recorded for '%s'.\n", projectName)
+			return nil
+		}
+
+		if historyLimit > 0 && len(runs) > historyLimit {
+			runs = runs[:historyLimit]
+		}
+		for _, run := range runs {
+			failed := 0
+			for _, st := range run.Steps {
+				if st.Exit != "0" {
+					failed++
+				}
+			}
+			fmt.Printf("%s  %d step(s), %d failed  %s\n", run.Name, len(run.Steps), failed, run.Dir)
+			for _, st := range run.Steps {
+				state := "ok    "
+				if st.Exit != "0" {
+					state = "FAILED"
+				}
+				fmt.Printf("  %s %-22s %s\n", state, st.Name, st.Command)
+			}
+		}
+		return nil
+	},
+}
+
+func loadSetupRuns(logsDir string) ([]setupRun, error) {
+	entries, err := os.ReadDir(logsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", logsDir, err)
+	}
+
+	var runs []setupRun
+	for _, e := range entries {
+		if !e.IsDir() || !strings.HasPrefix(e.Name(), "setup-") {
+			continue
+		}
+		dir := filepath.Join(logsDir, e.Name())
+		files, _ := filepath.Glob(filepath.Join(dir, "*.log"))
+		sort.Strings(files)
+		run := setupRun{Name: e.Name(), Dir: dir}
+		for _, f := range files {
+			run.Steps = append(run.Steps, readSetupStepLog(f))
+		}
+		runs = append(runs, run)
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].Name > runs[j].Name })
+	return runs, nil
+}
+
+func readSetupStepLog(path string) setupStepLog {
+	st := setupStepLog{Name: filepath.Base(path), Path: path, Exit: "unknown"}
+	f, err := os.Open(path)
+	if err != nil {
+		return st
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	first := true
+	for scanner.Scan() {
+		line := scanner.Text()
+		if first {
+			st.Command = strings.TrimPrefix(line, "$ ")
+			first = false
+		}
+		if strings.HasPrefix(line, parallel.StepLogExitPrefix) {
+			st.Exit = strings.TrimPrefix(line, parallel.StepLogExitPrefix)
+		}
+	}
+	return st
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+	historyCmd.Flags().IntVar(&historyLimit, "limit", 10, "Maximum number of setup runs to show")
+}
@@ -0,0 +1,50 @@
+package commands
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"devbox/internal/parallel"
+)
+
+func TestLoadSetupRunsReadsStepLogs(t *testing.T) {
+	workspace := t.TempDir()
+	logger := parallel.NewStepLogger(workspace)
+
+	okPath := logger.Write("APT Packages", 1, "apt install -y git", "done\n", nil)
+	failPath := logger.Write("Python Packages", 1, "pip install nope", "ERROR: not found", errors.New("exit status 1"))
+	if okPath == "" || failPath == "" {
+		t.Fatal("expected step logs to be written")
+	}
+	if !strings.HasSuffix(failPath, "python-packages-01.log") {
+		t.Errorf("unexpected log name %s", failPath)
+	}
+
+	runs, err := loadSetupRuns(filepath.Join(workspace, ".devbox", "logs"))
+	if err != nil {
+		t.Fatalf("loadSetupRuns: %v", err)
+	}
+	if len(runs) != 1 || len(runs[0].Steps) != 2 {
+		t.Fatalf("expected 1 run with 2 steps, got %+v", runs)
+	}
+
+	steps := map[string]setupStepLog{}
+	for _, st := range runs[0].Steps {
+		steps[st.Command] = st
+	}
+	if steps["apt install -y git"].Exit != "0" {
+		t.Errorf("expected successful apt step, got %+v", steps["apt install -y git"])
+	}
+	if steps["pip install nope"].Exit != "exit status 1" {
+		t.Errorf("expected failed pip step, got %+v", steps["pip install nope"])
+	}
+}
+
+func TestLoadSetupRunsMissingDir(t *testing.T) {
+	runs, err := loadSetupRuns(filepath.Join(t.TempDir(), "missing"))
+	if err != nil || len(runs) != 0 {
+		t.Errorf("expected no runs and no error, got %v, %v", runs, err)
+	}
+}
@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"devbox/internal/config"
+	"devbox/internal/docker"
+)
+
+var (
+	noHooksFlag        bool
+	allowHostHooksFlag bool
+)
+
+type hookTarget struct {
+	project    string
+	box        string
+	workspace  string
+	config     *config.ProjectConfig
+	boxStopped bool
+}
+
+func projectHookTarget(project *config.Project) hookTarget {
+	pc, _ := configManager.LoadProjectConfig(project.WorkspacePath)
+	return hookTarget{project: project.Name, box: project.BoxName, workspace: project.WorkspacePath, config: pc}
+}
+
+func (t hookTarget) env(event, workspace string) []string {
+	return []string{
+		"DEVBOX_HOOK=" + event,
+		"DEVBOX_PROJECT=" + t.project,
+		"DEVBOX_BOX=" + t.box,
+		"DEVBOX_WORKSPACE=" + workspace,
+	}
+}
+
+func runHooks(event string, t hookTarget) error {
+	if noHooksFlag || t.config == nil {
+		return nil
+	}
+	hooks := t.config.Hooks[event]
+	for i, h := range hooks {
+		where := config.HookInHost
+		if h.InBox() {
+			where = config.HookInBox
+		}
+		if !h.InBox() && isReviewWorkspace(t.workspace) && !allowHostHooksFlag {
+			fmt.Printf("Warning: skipping %s host hook '%s': hooks from a review checkout only run in the box (pass --allow-host-hooks to 'devbox review' to trust them)\n", event, h.Run)
+			continue
+		}
+		if h.InBox() && t.boxStopped {
+			fmt.Printf("Warning: skipping %s hook '%s': box '%s' is not running\n", event, h.Run, t.box)
+			continue
+		}
+		fmt.Printf("Running %s hook %d/%d (%s): %s\n", event, i+1, len(hooks), where, h.Run)
+		err := runHook(event, h, t)
+		if err == nil {
+			continue
+		}
+		if h.ContinueOnError {
+			fmt.Printf("Warning: %s hook '%s' failed: %v\n", event, h.Run, err)
+			continue
+		}
+		return fmt.Errorf("%s hook '%s' failed: %w\nhint: set \"continue_on_error\": true on the hook to ignore failures, or pass --no-hooks to skip hooks", event, h.Run, err)
+	}
+	return nil
+}
+
+func runHook(event string, h config.Hook, t hookTarget) error {
+	if !h.InBox() {
+		cmd := exec.Command("sh", "-c", h.Run)
+		cmd.Dir = t.workspace
+		cmd.Env = append(os.Environ(), t.env(event, t.workspace)...)
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		return cmd.Run()
+	}
+	workdir := firstNonEmpty(t.config.WorkingDir, "/workspace")
+	opts := docker.ExecOptions{Workdir: workdir, Env: t.env(event, workdir)}
+	code, err := dockerClient.Exec(t.box, []string{"bash", "-c", h.Run}, opts)
+	if err != nil {
+		return err
+	}
+	if code != 0 {
+		return &ExitCodeError{Code: code}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"devbox/internal/config"
+)
+
+func TestRunHooks(t *testing.T) {
+	dir := t.TempDir()
+	target := hookTarget{project: "web", box: "devbox_web", workspace: dir, config: &config.ProjectConfig{Hooks: map[string][]config.Hook{
+		config.HookPreUp: {
+			{Run: `echo "$DEVBOX_HOOK $DEVBOX_PROJECT $DEVBOX_BOX $PWD" >> hooks.log`},
+			{Run: "exit 3", ContinueOnError: true},
+			{Run: "echo second >> hooks.log"},
+		},
+		config.HookPostUp: {
+			{Run: "exit 1"},
+			{Run: "echo unreachable >> hooks.log"},
+		},
+		config.HookPreDestroy: {
+			{Run: "echo box >> hooks.log", In: config.HookInBox},
+			{Run: "echo host >> hooks.log"},
+		},
+	}}}
+
+	if err := runHooks(config.HookPreUp, target); err != nil {
+		t.Fatalf("runHooks(pre_up) error = %v", err)
+	}
+	err := runHooks(config.HookPostUp, target)
+	if err == nil || !strings.Contains(err.Error(), "post_up hook 'exit 1' failed") {
+		t.Errorf("runHooks(post_up) error = %v, want the failing hook", err)
+	}
+	target.boxStopped = true
+	if err := runHooks(config.HookPreDestroy, target); err != nil {
+		t.Errorf("runHooks(pre_destroy) with a stopped box error = %v", err)
+	}
+	if err := runHooks(config.HookPreShell, target); err != nil {
+		t.Errorf("runHooks(pre_shell) without hooks error = %v", err)
+	}
+
+	noHooksFlag = true
+	err = runHooks(config.HookPostUp, target)
+	noHooksFlag = false
+	if err != nil {
+		t.Errorf("runHooks() with --no-hooks error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "hooks.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "pre_up web devbox_web " + dir + "\nsecond\nhost\n"
+	if string(data) != want {
+		t.Errorf("hooks.log = %q, want %q", data, want)
+	}
+}
+
+func TestRunHooksSkipsHostHooksForReviews(t *testing.T) {
+	withTempConfigManager(t)
+	dir := filepath.Join(reviewsDir(), "webapp-pr-7")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	target := hookTarget{project: "webapp-pr-7", box: "devbox_webapp-pr-7", workspace: dir, config: &config.ProjectConfig{Hooks: map[string][]config.Hook{
+		config.HookPreUp: {{Run: "touch pwned"}},
+	}}}
+
+	if err := runHooks(config.HookPreUp, target); err != nil {
+		t.Fatalf("runHooks() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "pwned")); err == nil {
+		t.Fatal("host hook from a review checkout ran without --allow-host-hooks")
+	}
+
+	allowHostHooksFlag = true
+	defer func() { allowHostHooksFlag = false }()
+	if err := runHooks(config.HookPreUp, target); err != nil {
+		t.Fatalf("runHooks() with --allow-host-hooks error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "pwned")); err != nil {
+		t.Error("host hook did not run with --allow-host-hooks")
+	}
+}
+
+func TestExplainUpHooks(t *testing.T) {
+	t.Setenv("DEVBOX_ENGINE", "docker")
+	pc := &config.ProjectConfig{Name: "web", Hooks: map[string][]config.Hook{
+		config.HookPreUp:  {{Run: "make tunnel"}},
+		config.HookPostUp: {{Run: "make seed", In: config.HookInBox}},
+	}}
+	in := explainInput{project: "web", box: "devbox_web", image: "ubuntu:22.04", workspace: "/src/web", workspaceBox: "/workspace", config: pc, exists: true, running: true}
+	plan, err := explainUp(nil, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first := plan.Steps[0]; !strings.Contains(first.Note, "pre_up hook on the host") || !strings.HasSuffix(first.Note, "make tunnel") {
+		t.Errorf("explainUp() first step = %+v, want the pre_up hook", first)
+	}
+	last := plan.Steps[len(plan.Steps)-1]
+	if got := strings.Join(last.Command, " "); got != "docker exec --workdir /workspace devbox_web bash -c make seed" {
+		t.Errorf("explainUp() last command = %q, want the post_up hook", got)
+	}
+}
@@ -0,0 +1,172 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"devbox/internal/config"
+)
+
+func loadIgnoreRules(workspacePath string, lockRules *config.IgnoreRules) (*config.IgnoreRules, error) {
+	if err := config.ValidateIgnoreRules(lockRules); err != nil {
+		return nil, fmt.Errorf("invalid lockfile: %w", err)
+	}
+	pc, err := configManager.LoadProjectConfig(workspacePath)
+	if err != nil || pc == nil {
+		return config.MergeIgnoreRules(lockRules), nil
+	}
+	if err := config.ValidateIgnoreRules(pc.Ignore); err != nil {
+		return nil, fmt.Errorf("invalid devbox.json: %w", err)
+	}
+	return config.MergeIgnoreRules(lockRules, pc.Ignore), nil
+}
+
+func pluginSeparators() map[string]string {
+	seps := map[string]string{}
+	for _, pm := range loadExtraPackageManagers() {
+		seps[pm.Name] = pm.Separator
+	}
+	return seps
+}
+
+func packageEntryName(entry, sep string) string {
+	s := strings.TrimSpace(entry)
+	i := strings.Index(s, sep)
+	if sep == "@" {
+		i = strings.LastIndex(s, sep)
+	}
+	if i > 0 {
+		s = s[:i]
+	}
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
+func splitIgnoredPackages(rules *config.IgnoreRules, manager, sep string, entries []string) (kept []string, ignored map[string]string) {
+	ignored = map[string]string{}
+	for _, e := range entries {
+		name := packageEntryName(e, sep)
+		if name != "" && rules.IgnoresPackage(manager, name) {
+			ignored[name] = strings.TrimSpace(e)
+			continue
+		}
+		kept = append(kept, e)
+	}
+	return kept, ignored
+}
+
+func ignoredPackageNotes(manager string, locked, current map[string]string) []string {
+	names := map[string]bool{}
+	for n := range locked {
+		names[n] = true
+	}
+	for n := range current {
+		names[n] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for n := range names {
+		sorted = append(sorted, n)
+	}
+	sort.Strings(sorted)
+
+	var notes []string
+	for _, n := range sorted {
+		l, c := locked[n], current[n]
+		switch {
+		case l == c:
+		case l == "":
+			notes = append(notes, fmt.Sprintf("%s package %s added: %s", manager, n, c))
+		case c == "":
+			notes = append(notes, fmt.Sprintf("%s package %s removed: %s", manager, n, l))
+		default:
+			notes = append(notes, fmt.Sprintf("%s package %s changed: lock=%s current=%s", manager, n, l, c))
+		}
+	}
+	return notes
+}
+
+func stripIgnoredPackages(rules *config.IgnoreRules, locked, current *lockPackages, separators map[string]string) []string {
+	if rules.Empty() {
+		return nil
+	}
+	var notes []string
+	builtin := []struct {
+		name, sep       string
+		locked, current *[]string
+	}{
+		{"apt", "=", &locked.Apt, &current.Apt},
+		{"pip", "==", &locked.Pip, &current.Pip},
+		{"npm", "@", &locked.Npm, &current.Npm},
+		{"yarn", "@", &locked.Yarn, &current.Yarn},
+		{"pnpm", "@", &locked.Pnpm, &current.Pnpm},
+	}
+	for _, m := range builtin {
+		var lockIgnored, curIgnored map[string]string
+		*m.locked, lockIgnored = splitIgnoredPackages(rules, m.name, m.sep, *m.locked)
+		*m.current, curIgnored = splitIgnoredPackages(rules, m.name, m.sep, *m.current)
+		notes = append(notes, ignoredPackageNotes(m.name, lockIgnored, curIgnored)...)
+	}
+
+	extras := map[string]bool{}
+	for name := range locked.Extra {
+		extras[name] = true
+	}
+	for name := range current.Extra {
+		extras[name] = true
+	}
+	names := make([]string, 0, len(extras))
+	for name := range extras {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		sep := firstNonEmpty(separators[name], "@")
+		lockIgnored, curIgnored := map[string]string{}, map[string]string{}
+		if list, ok := locked.Extra[name]; ok {
+			locked.Extra[name], lockIgnored = splitIgnoredPackages(rules, name, sep, list)
+		}
+		if list, ok := current.Extra[name]; ok {
+			current.Extra[name], curIgnored = splitIgnoredPackages(rules, name, sep, list)
+		}
+		notes = append(notes, ignoredPackageNotes(name, lockIgnored, curIgnored)...)
+	}
+	return notes
+}
+
+func stripIgnoredEnv(rules *config.IgnoreRules, locked, current *lockContainer, imageEnv map[string]string) []string {
+	if rules.Empty() {
+		return nil
+	}
+	managed := filterLockEnv(current.Environment, imageEnv, nil)
+	keys := map[string]bool{}
+	for k := range locked.Environment {
+		keys[k] = true
+	}
+	for k := range current.Environment {
+		keys[k] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		if rules.IgnoresEnv(k) {
+			sorted = append(sorted, k)
+		}
+	}
+	sort.Strings(sorted)
+
+	var notes []string
+	for _, k := range sorted {
+		l, inLock := locked.Environment[k]
+		c, inBox := current.Environment[k]
+		_, isManaged := managed[k]
+		switch {
+		case lockEnvNoise[k]:
+		case inLock && (!inBox || l != c):
+			notes = append(notes, fmt.Sprintf("env %s: lock=%s current=%s", k, l, firstNonEmpty(c, "(none)")))
+		case !inLock && isManaged:
+			notes = append(notes, fmt.Sprintf("env %s: lock=(none) current=%s", k, c))
+		}
+		delete(locked.Environment, k)
+		delete(current.Environment, k)
+	}
+	return notes
+}
@@ -0,0 +1,61 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+
+	"devbox/internal/config"
+)
+
+func TestStripIgnoredPackages(t *testing.T) {
+	rules := &config.IgnoreRules{Packages: map[string][]string{
+		"apt":   {"gdb", "strace"},
+		"pip":   {"ipdb"},
+		"cargo": {"bacon"},
+	}}
+	locked := lockPackages{
+		Apt:   []string{"curl=7.81", "strace=5.16"},
+		Pip:   []string{"requests==2.31.0", "ipdb==0.13.11"},
+		Extra: map[string][]string{"cargo": {"ripgrep@14.1.0"}},
+	}
+	current := lockPackages{
+		Apt:   []string{"curl=7.81", "gdb=12.1"},
+		Pip:   []string{"requests==2.31.0", "ipdb==0.13.13"},
+		Extra: map[string][]string{"cargo": {"ripgrep@14.1.0", "bacon@2.14.0"}},
+	}
+
+	notes := stripIgnoredPackages(rules, &locked, &current, map[string]string{"cargo": "@"})
+	want := []string{
+		"apt package gdb added: gdb=12.1",
+		"apt package strace removed: strace=5.16",
+		"pip package ipdb changed: lock=ipdb==0.13.11 current=ipdb==0.13.13",
+		"cargo package bacon added: bacon@2.14.0",
+	}
+	if !reflect.DeepEqual(notes, want) {
+		t.Errorf("notes = %v, want %v", notes, want)
+	}
+	lf := &verifyLockFile{Packages: locked}
+	if drifts := snapshotDrift(lf, &packageSnapshot{Packages: current}); len(drifts) != 0 {
+		t.Errorf("snapshotDrift() after stripping = %v, want none", drifts)
+	}
+
+	if notes := stripIgnoredPackages(nil, &locked, &current, nil); notes != nil {
+		t.Errorf("stripIgnoredPackages(nil) = %v, want nil", notes)
+	}
+}
+
+func TestStripIgnoredEnv(t *testing.T) {
+	rules := &config.IgnoreRules{Env: []string{"DEBUG*", "HISTFILE"}}
+	locked := lockContainer{Environment: map[string]string{"APP_ENV": "dev", "DEBUG": "0"}}
+	current := lockContainer{Environment: map[string]string{"APP_ENV": "dev", "DEBUG": "1", "DEBUG_SQL": "1", "HISTFILE": "/tmp/h"}}
+	imageEnv := map[string]string{"HISTFILE": "/tmp/h"}
+
+	notes := stripIgnoredEnv(rules, &locked, &current, imageEnv)
+	want := []string{"env DEBUG: lock=0 current=1", "env DEBUG_SQL: lock=(none) current=1"}
+	if !reflect.DeepEqual(notes, want) {
+		t.Errorf("notes = %v, want %v", notes, want)
+	}
+	if drifts := diffContainer(locked, current, imageEnv); len(drifts) != 0 {
+		t.Errorf("diffContainer() after stripping = %v, want none", drifts)
+	}
+}
@@ -0,0 +1,354 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"devbox/internal/docker"
+)
+
+var (
+	imagesProjectFlag string
+	imagesOutputFlag  string
+)
+
+type imageEntry struct {
+	Ref       string    `json:"ref"`
+	Kind      string    `json:"kind"`
+	ID        string    `json:"id"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+	Projects  []string  `json:"projects,omitempty"`
+	InUseBy   []string  `json:"in_use_by,omitempty"`
+}
+
+var imagesCmd = &cobra.Command{
+	Use:   "images",
+	Short: "List and remove devbox-owned images",
+	Long: `List the images devbox uses and creates: each project's base image, images built from
+devbox.json's build section, and the backup, pre-update, snapshot, archive, and remount
+images tagged devbox/<project>:*. For each image the list shows its size, when it was
+created, which projects reference it, and which containers use it.
+
+Examples:
+  devbox images
+  devbox images --project myproject
+  devbox images -o json
+  devbox images rm devbox/myproject:backup-20240101-120000`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFormat(imagesOutputFlag); err != nil {
+			return err
+		}
+		if imagesProjectFlag != "" {
+			if err := validateProjectName(imagesProjectFlag); err != nil {
+				return err
+			}
+		}
+		if imagesOutputFlag == outputTable {
+			return runImages(os.Stdout, imagesProjectFlag, imagesOutputFlag)
+		}
+		stdout := os.Stdout
+		return withStdoutToStderr(func() error {
+			return runImages(stdout, imagesProjectFlag, imagesOutputFlag)
+		})
+	},
+}
+
+var imagesRmCmd = &cobra.Command{
+	Use:   "rm <image>...",
+	Short: "Remove devbox images that no container uses",
+	Long: `Remove images listed by 'devbox images', by reference or image ID. Images used by a
+container are never removed; destroy or update the box first. A project's base image is
+only removed with --force, and the next 'devbox up' pulls or builds it again. Removing a
+snapshot image also deletes the snapshot's metadata.
+
+Examples:
+  devbox images rm devbox/myproject:pre-update-20240101-120000
+  devbox images rm ubuntu:20.04 --force`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		entries, err := collectImages()
+		if err != nil {
+			return err
+		}
+		return removeImages(entries, args, forceFlag)
+	},
+}
+
+func init() {
+	imagesCmd.Flags().StringVarP(&imagesProjectFlag, "project", "p", "", "Only show images referenced by this project")
+	addOutputFlag(imagesCmd, &imagesOutputFlag)
+	imagesRmCmd.Flags().BoolVarP(&forceFlag, "force", "f", false, "Also remove images that are a project's base image")
+	imagesCmd.AddCommand(imagesRmCmd)
+	rootCmd.AddCommand(imagesCmd)
+}
+
+func runImages(w io.Writer, project, format string) error {
+	entries, err := collectImages()
+	if err != nil {
+		return err
+	}
+	return printImages(w, filterImages(entries, project), format)
+}
+
+func imageKind(tag string) string {
+	switch {
+	case tag == "build":
+		return "build"
+	case strings.HasPrefix(tag, "backup-"):
+		return "backup"
+	case strings.HasPrefix(tag, preUpdateTagPrefix):
+		return "pre-update"
+	case strings.HasPrefix(tag, snapshotTagPrefix):
+		return "snapshot"
+	case strings.HasPrefix(tag, "archive-"):
+		return "archive"
+	case strings.HasPrefix(tag, "remount-"):
+		return "remount"
+	}
+	return "other"
+}
+
+func collectImages() ([]imageEntry, error) {
+	cfg, err := configManager.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+	owned, err := dockerClient.ListImages("devbox/*")
+	if err != nil {
+		return nil, err
+	}
+
+	baseRefs := map[string][]string{}
+	projectByBox := map[string]string{}
+	for name, p := range cfg.GetProjects() {
+		projectByBox[p.BoxName] = name
+		pc, _ := configManager.LoadProjectConfig(p.WorkspacePath)
+		ref := cfg.GetEffectiveBaseImage(p, pc)
+		baseRefs[ref] = append(baseRefs[ref], name)
+	}
+	bases := map[string]docker.ImageInfo{}
+	for ref := range baseRefs {
+		if strings.HasPrefix(ref, "devbox/") {
+			continue
+		}
+		if found, err := dockerClient.ListImages(ref); err == nil && len(found) > 0 {
+			bases[ref] = found[0]
+		}
+	}
+
+	containers, err := dockerClient.ContainerImages()
+	if err != nil {
+		return nil, err
+	}
+	entries := buildImageEntries(owned, bases, baseRefs, containers, projectByBox)
+	for i := range entries {
+		if size, err := dockerClient.GetImageSize(entries[i].ID); err == nil {
+			entries[i].Size = size
+		}
+	}
+	return entries, nil
+}
+
+func buildImageEntries(owned []docker.ImageInfo, bases map[string]docker.ImageInfo, baseRefs map[string][]string, containers, projectByBox map[string]string) []imageEntry {
+	byRef := map[string]*imageEntry{}
+	var refs []string
+	add := func(ref, kind string, img docker.ImageInfo, projects ...string) {
+		e, ok := byRef[ref]
+		if !ok {
+			e = &imageEntry{Ref: ref, Kind: kind, ID: img.ID, CreatedAt: img.CreatedAt}
+			byRef[ref] = e
+			refs = append(refs, ref)
+		}
+		e.Projects = append(e.Projects, projects...)
+	}
+	for _, img := range owned {
+		if img.Tag == "" || img.Tag == "<none>" {
+			continue
+		}
+		add(img.Repository+":"+img.Tag, imageKind(img.Tag), img, strings.TrimPrefix(img.Repository, "devbox/"))
+	}
+	for ref, img := range bases {
+		add(ref, "base", img)
+	}
+	for ref, projects := range baseRefs {
+		if e, ok := byRef[ref]; ok {
+			e.Projects = append(e.Projects, projects...)
+		}
+	}
+
+	entries := make([]imageEntry, 0, len(refs))
+	for _, ref := range refs {
+		e := byRef[ref]
+		for name, id := range containers {
+			if id == "" || id != e.ID {
+				continue
+			}
+			e.InUseBy = append(e.InUseBy, name)
+			if project, ok := projectByBox[name]; ok {
+				e.Projects = append(e.Projects, project)
+			}
+		}
+		e.Projects = uniqueSorted(e.Projects)
+		sort.Strings(e.InUseBy)
+		entries = append(entries, *e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Kind != entries[j].Kind {
+			return imageKindOrder(entries[i].Kind) < imageKindOrder(entries[j].Kind)
+		}
+		if !entries[i].CreatedAt.Equal(entries[j].CreatedAt) {
+			return entries[i].CreatedAt.After(entries[j].CreatedAt)
+		}
+		return entries[i].Ref < entries[j].Ref
+	})
+	return entries
+}
+
+func imageKindOrder(kind string) int {
+	for i, k := range []string{"base", "build", "snapshot", "backup", "pre-update", "archive", "remount"} {
+		if k == kind {
+			return i
+		}
+	}
+	return 99
+}
+
+func uniqueSorted(values []string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, v := range values {
+		if v != "" && !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func filterImages(entries []imageEntry, project string) []imageEntry {
+	if project == "" {
+		return entries
+	}
+	var out []imageEntry
+	for _, e := range entries {
+		for _, p := range e.Projects {
+			if p == project {
+				out = append(out, e)
+				break
+			}
+		}
+	}
+	return out
+}
+
+func printImages(out io.Writer, entries []imageEntry, format string) error {
+	if entries == nil {
+		entries = []imageEntry{}
+	}
+	if format == outputJSON || format == outputYAML {
+		return writeStructured(out, format, entries)
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(out, "No devbox images found.")
+		return nil
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "IMAGE\tKIND\tSIZE\tCREATED\tPROJECTS\tIN USE BY")
+	var total int64
+	for _, e := range entries {
+		created := "-"
+		if !e.CreatedAt.IsZero() {
+			created = e.CreatedAt.Local().Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", e.Ref, e.Kind, formatBytes(e.Size), created, listOrDash(e.Projects), listOrDash(e.InUseBy))
+		total += e.Size
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "\n%d image(s), %s (shared layers are counted once per image)\n", len(entries), formatBytes(total))
+	return nil
+}
+
+func listOrDash(values []string) string {
+	if len(values) == 0 {
+		return "-"
+	}
+	return strings.Join(values, ",")
+}
+
+func findImage(entries []imageEntry, arg string) (imageEntry, bool) {
+	id := strings.TrimPrefix(arg, "sha256:")
+	for _, e := range entries {
+		if e.Ref == arg || (len(id) >= 12 && strings.HasPrefix(strings.TrimPrefix(e.ID, "sha256:"), id)) {
+			return e, true
+		}
+	}
+	return imageEntry{}, false
+}
+
+func checkImageRemovable(e imageEntry, force bool) error {
+	if len(e.InUseBy) > 0 {
+		return fmt.Errorf("%s is in use by %s\nhint: destroy or update the box first", e.Ref, strings.Join(e.InUseBy, ", "))
+	}
+	if (e.Kind == "base" || e.Kind == "build") && len(e.Projects) > 0 && !force {
+		return fmt.Errorf("%s is the base image of %s\nhint: pass --force to remove it anyway; the next 'devbox up' pulls or builds it again", e.Ref, strings.Join(e.Projects, ", "))
+	}
+	return nil
+}
+
+func removeImages(entries []imageEntry, args []string, force bool) error {
+	var failed []string
+	for _, arg := range args {
+		e, ok := findImage(entries, arg)
+		if !ok {
+			fmt.Printf("error: '%s' is not a devbox image (see 'devbox images')\n", arg)
+			failed = append(failed, arg)
+			continue
+		}
+		if err := checkImageRemovable(e, force); err != nil {
+			fmt.Printf("error: %v\n", err)
+			failed = append(failed, arg)
+			continue
+		}
+		if project, name, ok := snapshotFromRef(e.Ref); ok && e.Kind == "snapshot" {
+			if snapshots, err := loadSnapshots(project); err == nil {
+				if _, found := findSnapshot(snapshots, name); found {
+					if err := deleteSnapshots(project, []string{name}); err != nil {
+						fmt.Printf("error: %v\n", err)
+						failed = append(failed, arg)
+					}
+					continue
+				}
+			}
+		}
+		if err := dockerClient.RemoveImage(e.Ref); err != nil {
+			fmt.Printf("error: %v\n", err)
+			failed = append(failed, arg)
+			continue
+		}
+		fmt.Printf("Removed %s (%s)\n", e.Ref, formatBytes(e.Size))
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to remove %d image(s): %s", len(failed), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+func snapshotFromRef(ref string) (project, name string, ok bool) {
+	repo, tag, found := strings.Cut(ref, ":")
+	if !found || !strings.HasPrefix(repo, "devbox/") || !strings.HasPrefix(tag, snapshotTagPrefix) {
+		return "", "", false
+	}
+	return strings.TrimPrefix(repo, "devbox/"), strings.TrimPrefix(tag, snapshotTagPrefix), true
+}
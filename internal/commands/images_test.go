@@ -0,0 +1,90 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"devbox/internal/docker"
+)
+
+func TestBuildImageEntries(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	owned := []docker.ImageInfo{
+		{Repository: "devbox/web", Tag: "backup-20240102", ID: "sha256:b1", CreatedAt: day(2)},
+		{Repository: "devbox/web", Tag: "snap-before-cuda", ID: "sha256:s1", CreatedAt: day(3)},
+		{Repository: "devbox/api", Tag: "build", ID: "sha256:a1", CreatedAt: day(1)},
+		{Repository: "devbox/web", Tag: "<none>", ID: "sha256:dangling"},
+	}
+	bases := map[string]docker.ImageInfo{"ubuntu:22.04": {Repository: "ubuntu", Tag: "22.04", ID: "sha256:u1", CreatedAt: day(1)}}
+	baseRefs := map[string][]string{"ubuntu:22.04": {"web", "cli"}, "devbox/api:build": {"api"}, "node:20": {"site"}}
+	containers := map[string]string{"devbox_web": "sha256:u1", "devbox_api": "sha256:a1", "devbox_api.db": "sha256:pg"}
+	projectByBox := map[string]string{"devbox_web": "web", "devbox_api": "api"}
+
+	entries := buildImageEntries(owned, bases, baseRefs, containers, projectByBox)
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Ref+" "+e.Kind+" "+strings.Join(e.Projects, ",")+" "+strings.Join(e.InUseBy, ","))
+	}
+	want := []string{
+		"ubuntu:22.04 base cli,web devbox_web",
+		"devbox/api:build build api devbox_api",
+		"devbox/web:snap-before-cuda snapshot web ",
+		"devbox/web:backup-20240102 backup web ",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("buildImageEntries() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	if web := filterImages(entries, "web"); len(web) != 3 {
+		t.Errorf("filterImages(web) = %d entries, want 3", len(web))
+	}
+	if none := filterImages(entries, "site"); len(none) != 0 {
+		t.Errorf("filterImages(site) = %+v, want none", none)
+	}
+}
+
+func TestCheckImageRemovable(t *testing.T) {
+	tests := []struct {
+		name    string
+		entry   imageEntry
+		force   bool
+		wantErr string
+	}{
+		{"unused backup", imageEntry{Ref: "devbox/web:backup-1", Kind: "backup", Projects: []string{"web"}}, false, ""},
+		{"in use", imageEntry{Ref: "ubuntu:22.04", Kind: "base", InUseBy: []string{"devbox_web"}}, true, "in use by devbox_web"},
+		{"base", imageEntry{Ref: "ubuntu:22.04", Kind: "base", Projects: []string{"web"}}, false, "base image of web"},
+		{"base forced", imageEntry{Ref: "ubuntu:22.04", Kind: "base", Projects: []string{"web"}}, true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkImageRemovable(tt.entry, tt.force)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("checkImageRemovable() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("checkImageRemovable() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFindImage(t *testing.T) {
+	entries := []imageEntry{{Ref: "devbox/web:snap-a", ID: "sha256:0123456789abcdef"}}
+	for _, arg := range []string{"devbox/web:snap-a", "0123456789ab", "sha256:0123456789abcdef"} {
+		if _, ok := findImage(entries, arg); !ok {
+			t.Errorf("findImage(%q) found nothing", arg)
+		}
+	}
+	if _, ok := findImage(entries, "0123"); ok {
+		t.Error("findImage() matched a short ID prefix")
+	}
+
+	project, name, ok := snapshotFromRef("devbox/web:snap-before-cuda")
+	if !ok || project != "web" || name != "before-cuda" {
+		t.Errorf("snapshotFromRef() = %q, %q, %v", project, name, ok)
+	}
+	if _, _, ok := snapshotFromRef("ubuntu:snap-1"); ok {
+		t.Error("snapshotFromRef() accepted a non-devbox image")
+	}
+}
@@ -0,0 +1,81 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"devbox/internal/config"
+)
+
+var importStdoutFlag bool
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Create devbox.json from other tools' configuration",
+	Long: `Translate another tool's environment definition into devbox.json in the current folder.
+Each importer lists what it translated and what it couldn't. To import a VS Code
+devcontainer.json, use 'devbox devcontainer import'.`,
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+}
+
+type importReport struct {
+	imported []string
+	skipped  []string
+}
+
+func (r *importReport) ok(format string, a ...interface{}) {
+	r.imported = append(r.imported, fmt.Sprintf(format, a...))
+}
+
+func (r *importReport) skip(format string, a ...interface{}) {
+	r.skipped = append(r.skipped, fmt.Sprintf(format, a...))
+}
+
+func printImportReport(r *importReport) {
+	if len(r.imported) > 0 {
+		fmt.Println("\nImported:")
+		for _, line := range r.imported {
+			fmt.Printf("  %s\n", line)
+		}
+	}
+	if len(r.skipped) > 0 {
+		fmt.Println("\nNot imported:")
+		for _, line := range r.skipped {
+			fmt.Printf("  %s\n", line)
+		}
+	}
+}
+
+func writeImportedConfig(cwd, source string, pc *config.ProjectConfig, report *importReport, stdout bool) error {
+	if stdout {
+		out, err := json.MarshalIndent(pc, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal devbox.json: %w", err)
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+	if _, err := os.Stat(filepath.Join(cwd, "devbox.json")); err == nil && !forceFlag {
+		return fmt.Errorf("devbox.json already exists. Use --force to overwrite, or --stdout to compare first")
+	}
+	if err := configManager.SaveProjectConfig(cwd, pc); err != nil {
+		return fmt.Errorf("failed to save project configuration: %w", err)
+	}
+	rel, err := filepath.Rel(cwd, source)
+	if err != nil {
+		rel = source
+	}
+	fmt.Printf("Wrote devbox.json from %s\n", rel)
+	printImportReport(report)
+	if err := configManager.ValidateProjectConfig(pc); err != nil {
+		fmt.Printf("Warning: the imported devbox.json does not validate yet: %v\n", err)
+	}
+	return nil
+}
@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"time"
 
 	"github.com/spf13/cobra"
 
@@ -27,7 +26,9 @@ Examples:
   devbox init myproject                    # Basic project
   devbox init myproject --template python # Python development project
   devbox init myproject --config-only     # Generate devbox.json only
-  devbox init myproject --generate-config # Create box and generate devbox.json`,
+  devbox init myproject --generate-config # Create box and generate devbox.json
+  devbox init myproject --from-lock       # Build the box from devbox.lock.json
+  devbox init myproject --from-lock=path/to/devbox.lock.json`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		projectName := args[0]
@@ -45,7 +46,7 @@ Examples:
 			return fmt.Errorf("project '%s' already exists. Use --force to overwrite", projectName)
 		}
 
-		workspacePath, err := getWorkspacePath(projectName)
+		workspacePath, err := resolveWorkspacePath(projectName, workspacePathFlag)
 		if err != nil {
 			return err
 		}
@@ -56,6 +57,13 @@ Examples:
 
 		fmt.Printf("Created workspace directory: %s\n", workspacePath)
 
+		if fromLockFlag != "" {
+			if templateFlag != "" || generateConfig || configOnlyFlag {
+				return fmt.Errorf("--from-lock cannot be combined with --template, --generate-config, or --config-only")
+			}
+			return initFromLock(cfg, projectName, workspacePath)
+		}
+
 		var projectConfig *config.ProjectConfig
 
 		if existingConfig, err := configManager.LoadProjectConfig(workspacePath); err == nil && existingConfig != nil {
@@ -91,9 +99,17 @@ Examples:
 			if err := configManager.ValidateProjectConfig(projectConfig); err != nil {
 				return fmt.Errorf("invalid project configuration: %w", err)
 			}
+			if err := checkConfigCompatibility(projectConfig); err != nil {
+				return err
+			}
+		}
+		if err := checkRestartPolicy(projectConfig); err != nil {
+			return err
 		}
+		timeouts := useProjectTimeouts(projectConfig)
+		warnWorkspaceFS(workspacePath, projectConfig)
 
-		boxName := fmt.Sprintf("devbox_%s", projectName)
+		boxName := boxNameFor(cfg, projectName)
 
 		baseImage := cfg.GetEffectiveBaseImage(&config.Project{
 			Name:      projectName,
@@ -105,9 +121,20 @@ Examples:
 			workspaceBox = projectConfig.WorkingDir
 		}
 
+		if err := checkProjectImagePolicy(cfg, baseImage, workspacePath, projectConfig); err != nil {
+			return err
+		}
+		if err := checkHostCapacity(cfg, boxName, projectConfig, forceFlag); err != nil {
+			return err
+		}
+
 		fmt.Printf("Setting up box '%s' with image '%s'...\n", boxName, baseImage)
-		if err := dockerClient.PullImage(baseImage); err != nil {
-			return fmt.Errorf("failed to pull base image: %w", err)
+		if err := ensureBaseImage(baseImage, workspacePath, projectConfig, noCacheInitFlag, false); err != nil {
+			return fmt.Errorf("failed to prepare base image: %w", err)
+		}
+
+		if err := checkBoxOwnership(boxName); err != nil {
+			return err
 		}
 
 		if forceFlag {
@@ -130,15 +157,6 @@ Examples:
 			json.Unmarshal(configData, &configMap)
 		}
 
-		if cfg.Settings != nil && cfg.Settings.AutoStopOnExit {
-			if configMap == nil {
-				configMap = map[string]interface{}{}
-			}
-			if _, ok := configMap["restart"]; !ok {
-				configMap["restart"] = "no"
-			}
-		}
-
 		boxID, err := dockerClient.CreateBoxWithConfig(boxName, baseImage, workspacePath, workspaceBox, configMap)
 		if err != nil {
 			return fmt.Errorf("failed to create box: %w", err)
@@ -149,7 +167,7 @@ Examples:
 		}
 
 		fmt.Printf("Starting box...\n")
-		if err := dockerClient.WaitForBox(boxName, 30*time.Second); err != nil {
+		if err := dockerClient.WaitForBox(boxName, timeouts.Startup); err != nil {
 			return fmt.Errorf("box failed to start: %w", err)
 		}
 
@@ -164,11 +182,17 @@ Examples:
 
 		if projectConfig != nil && len(projectConfig.SetupCommands) > 0 {
 			fmt.Printf("Installing template packages (%d commands)...\n", len(projectConfig.SetupCommands))
-			if err := dockerClient.ExecuteSetupCommandsWithOutput(boxName, projectConfig.SetupCommands, false); err != nil {
+			if err := runTimedSetup(projectName, func() error {
+				return runSetupSteps(dockerClient, boxName, projectConfig.SetupCommands, false)
+			}); err != nil {
 				return fmt.Errorf("failed to execute setup commands: %w", err)
 			}
 		}
 
+		if err := applyGitGuards(boxName, workspaceBox, projectConfig); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+
 		fmt.Printf("Setting up devbox commands in box...\n")
 		if err := dockerClient.SetupDevboxInBoxWithUpdate(boxName, projectName); err != nil {
 			return fmt.Errorf("failed to setup devbox in box: %w", err)
@@ -189,6 +213,12 @@ Examples:
 			return fmt.Errorf("failed to save configuration: %w", err)
 		}
 
+		if projectConfig != nil && projectConfig.TTL != "" {
+			if err := recordBoxTTL(boxName, projectName, projectConfig.TTL, false); err != nil {
+				fmt.Printf("Warning: %v\n", err)
+			}
+		}
+
 		if projectConfig != nil && (templateFlag != "" || generateConfig) {
 			fmt.Printf("Generating lock file (devbox.lock.json)...\n")
 			if err := WriteLockFileForProject(projectName, ""); err != nil {
@@ -236,4 +266,8 @@ func init() {
 	initCmd.Flags().StringVarP(&templateFlag, "template", "t", "", "Initialize from template (python, nodejs, go, web)")
 	initCmd.Flags().BoolVarP(&generateConfig, "generate-config", "g", false, "Generate devbox.json configuration file")
 	initCmd.Flags().BoolVarP(&configOnlyFlag, "config-only", "c", false, "Generate configuration file only (don't create box)")
+	initCmd.Flags().BoolVar(&noCacheInitFlag, "no-cache", false, "Build the image from devbox.json's build section without the layer cache")
+	initCmd.Flags().StringVar(&workspacePathFlag, "path", "", "Workspace directory for this project (default: <workspace_root>/<project>)")
+	initCmd.Flags().StringVar(&fromLockFlag, "from-lock", "", "Create the box from a lockfile's image, registries, and packages instead of running setup commands")
+	initCmd.Flags().Lookup("from-lock").NoOptDefVal = defaultFromLock
 }
@@ -0,0 +1,217 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"devbox/internal/config"
+	"devbox/internal/docker"
+)
+
+const defaultFromLock = "devbox.lock.json"
+
+var fromLockFlag string
+
+func resolveFromLockPath(flag, workspacePath string) (string, error) {
+	candidates := []string{flag}
+	if flag == defaultFromLock {
+		candidates = []string{filepath.Join(workspacePath, defaultFromLock), defaultFromLock}
+	}
+	for _, c := range candidates {
+		if abs, err := filepath.Abs(c); err == nil {
+			if _, err := os.Stat(abs); err == nil {
+				return abs, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("lockfile not found (looked for %s)", strings.Join(candidates, ", "))
+}
+
+func lockedImageRef(lf *lockFile) string {
+	switch digest := lf.Info.ImageDigest; {
+	case strings.Contains(digest, "@"):
+		return digest
+	case digest != "":
+		return lf.BaseImage.Name + "@" + digest
+	}
+	return lf.BaseImage.Name
+}
+
+func lockPortMapping(line string) (string, bool) {
+	containerPort, host, ok := strings.Cut(line, " -> ")
+	if !ok {
+		return "", false
+	}
+	i := strings.LastIndex(host, ":")
+	if i == -1 || i == len(host)-1 {
+		return "", false
+	}
+	return host[i+1:] + ":" + strings.TrimSpace(containerPort), true
+}
+
+func lockContainerConfig(c lockContainer) map[string]interface{} {
+	m := map[string]interface{}{}
+	setString := func(key, value string) {
+		if value != "" {
+			m[key] = value
+		}
+	}
+	setString("working_dir", c.WorkingDir)
+	setString("user", c.User)
+	setString("restart", c.Restart)
+	setString("network", c.Network)
+
+	if len(c.Environment) > 0 {
+		env := map[string]interface{}{}
+		for k, v := range c.Environment {
+			env[k] = v
+		}
+		m["environment"] = env
+	}
+	labels := map[string]interface{}{}
+	for k, v := range c.Labels {
+		if k != docker.OwnerLabel && !docker.IsMetadataLabel(k) {
+			labels[k] = v
+		}
+	}
+	if len(labels) > 0 {
+		m["labels"] = labels
+	}
+	seen := map[string]bool{}
+	var ports []interface{}
+	for _, line := range c.Ports {
+		if p, ok := lockPortMapping(line); ok && !seen[p] {
+			seen[p] = true
+			ports = append(ports, p)
+		}
+	}
+	if len(ports) > 0 {
+		m["ports"] = ports
+	}
+	if len(c.Capabilities) > 0 {
+		caps := make([]interface{}, 0, len(c.Capabilities))
+		for _, capability := range c.Capabilities {
+			caps = append(caps, capability)
+		}
+		m["capabilities"] = caps
+	}
+	if len(c.Resources) > 0 {
+		res := map[string]interface{}{}
+		for k, v := range c.Resources {
+			res[k] = v
+		}
+		m["resources"] = res
+	}
+	return m
+}
+
+func initFromLock(cfg *config.Config, projectName, workspacePath string) error {
+	lockPath, err := resolveFromLockPath(fromLockFlag, workspacePath)
+	if err != nil {
+		return err
+	}
+	lf, err := loadLockFile(lockPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", lockPath, err)
+	}
+	if lf.BaseImage.Name == "" && lf.Info.ImageDigest == "" {
+		return fmt.Errorf("%s does not record a base image", lockPath)
+	}
+
+	workspaceLock := filepath.Join(workspacePath, "devbox.lock.json")
+	if lockPath != workspaceLock {
+		data, err := os.ReadFile(lockPath)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", lockPath, err)
+		}
+		if err := os.WriteFile(workspaceLock, data, 0644); err != nil {
+			return fmt.Errorf("failed to copy lockfile into workspace: %w", err)
+		}
+		fmt.Printf("Copied %s to %s\n", lockPath, workspaceLock)
+	}
+
+	boxName := boxNameFor(cfg, projectName)
+	image := lockedImageRef(lf)
+	if err := checkImagePolicy(cfg, image); err != nil {
+		return err
+	}
+	fmt.Printf("Creating box '%s' from lockfile with image '%s'...\n", boxName, image)
+	if err := dockerClient.PullImage(image); err != nil {
+		return fmt.Errorf("failed to pull locked image: %w", err)
+	}
+
+	if err := checkBoxOwnership(boxName); err != nil {
+		return err
+	}
+	exists, err := dockerClient.BoxExists(boxName)
+	if err != nil {
+		return fmt.Errorf("failed to check box existence: %w", err)
+	}
+	if exists {
+		if !forceFlag {
+			return fmt.Errorf("box '%s' already exists. Use --force to recreate it", boxName)
+		}
+		fmt.Printf("Removing existing box '%s'...\n", boxName)
+		_ = dockerClient.StopBox(boxName)
+		if err := dockerClient.RemoveBox(boxName); err != nil {
+			return fmt.Errorf("failed to remove existing box: %w", err)
+		}
+	}
+
+	workspaceBox := "/workspace"
+	if lf.Container.WorkingDir != "" {
+		workspaceBox = lf.Container.WorkingDir
+	}
+	boxID, err := dockerClient.CreateBoxWithConfig(boxName, image, workspacePath, workspaceBox, lockContainerConfig(lf.Container))
+	if err != nil {
+		return fmt.Errorf("failed to create box: %w", err)
+	}
+	if err := dockerClient.StartBox(boxID); err != nil {
+		return fmt.Errorf("failed to start box: %w", err)
+	}
+	fmt.Printf("Starting box...\n")
+	if err := dockerClient.WaitForBox(boxName, workspaceTimeouts(workspacePath).Startup); err != nil {
+		return fmt.Errorf("box failed to start: %w", err)
+	}
+
+	fmt.Printf("Setting up devbox commands in box...\n")
+	if err := dockerClient.SetupDevboxInBoxWithUpdate(boxName, projectName); err != nil {
+		return fmt.Errorf("failed to setup devbox in box: %w", err)
+	}
+
+	project := &config.Project{
+		Name:          projectName,
+		BoxName:       boxName,
+		BaseImage:     lf.BaseImage.Name,
+		WorkspacePath: workspacePath,
+		Status:        "running",
+	}
+	cfg.AddProject(project)
+	if err := configManager.Save(cfg); err != nil {
+		return fmt.Errorf("failed to save configuration: %w", err)
+	}
+
+	fmt.Printf("Installing locked packages...\n")
+	var summary *applySummary
+	if err := runTimedSetup(projectName, func() error {
+		var applyErr error
+		summary, applyErr = applyLockToBox(boxName, workspacePath, lf)
+		return applyErr
+	}); err != nil {
+		fmt.Printf("hint: project '%s' was created; fix the error, then run 'devbox apply %s'\n", projectName, projectName)
+		return fmt.Errorf("failed to apply lockfile: %w", err)
+	}
+
+	fmt.Printf("Project '%s' initialized from %s.\n", projectName, lockPath)
+	fmt.Printf("Workspace: %s\n", workspacePath)
+	fmt.Printf("Box: %s\n", boxName)
+	fmt.Printf("Image: %s\n", image)
+	fmt.Printf("Applied lockfile: %s\n", summary)
+
+	fmt.Printf("\nNext steps:\n")
+	fmt.Printf("  devbox verify %s      # Confirm the box matches the lockfile\n", projectName)
+	fmt.Printf("  devbox shell %s       # Open interactive shell\n", projectName)
+	return nil
+}
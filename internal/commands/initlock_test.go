@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"devbox/internal/docker"
+)
+
+func TestLockedImageRef(t *testing.T) {
+	tests := []struct {
+		name   string
+		digest string
+		want   string
+	}{
+		{"repo digest", "ubuntu@sha256:abc", "ubuntu@sha256:abc"},
+		{"bare digest", "sha256:abc", "ubuntu:22.04@sha256:abc"},
+		{"no digest", "", "ubuntu:22.04"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lf := &lockFile{BaseImage: lockImage{Name: "ubuntu:22.04"}, Info: lockInfo{ImageDigest: tt.digest}}
+			if got := lockedImageRef(lf); got != tt.want {
+				t.Errorf("lockedImageRef() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLockContainerConfig(t *testing.T) {
+	m := lockContainerConfig(lockContainer{
+		Restart: "no",
+		Ports:   []string{"80/tcp -> 0.0.0.0:8080", "80/tcp -> [::]:8080", "garbage"},
+		Labels:  map[string]string{docker.OwnerLabel: "someone", "team": "web"},
+	})
+	if m["restart"] != "no" {
+		t.Errorf("restart = %v", m["restart"])
+	}
+	ports, _ := m["ports"].([]interface{})
+	if len(ports) != 1 || ports[0] != "8080:80/tcp" {
+		t.Errorf("ports = %v, want [8080:80/tcp]", ports)
+	}
+	labels, _ := m["labels"].(map[string]interface{})
+	if _, ok := labels[docker.OwnerLabel]; ok || labels["team"] != "web" {
+		t.Errorf("labels = %v, want team only", labels)
+	}
+	if _, ok := m["user"]; ok {
+		t.Error("empty user should not be set")
+	}
+}
+
+func TestResolveFromLockPath(t *testing.T) {
+	workspace := t.TempDir()
+	if _, err := resolveFromLockPath(defaultFromLock, workspace); err == nil {
+		t.Fatal("expected error when no lockfile exists")
+	}
+	want := filepath.Join(workspace, defaultFromLock)
+	if err := os.WriteFile(want, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := resolveFromLockPath(defaultFromLock, workspace); err != nil || got != want {
+		t.Errorf("resolveFromLockPath() = %q, %v; want %q", got, err, want)
+	}
+	if got, err := resolveFromLockPath(want, t.TempDir()); err != nil || got != want {
+		t.Errorf("explicit path = %q, %v; want %q", got, err, want)
+	}
+}
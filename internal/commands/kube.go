@@ -0,0 +1,324 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"devbox/internal/config"
+	"devbox/internal/docker"
+	"devbox/internal/kube"
+	"devbox/internal/parallel"
+)
+
+const kubeReadyTimeout = 5 * time.Minute
+
+var kubernetesCommands = []string{"up", "shell", "run", "exec", "status", "stop", "destroy", "sync"}
+
+func checkKubernetesCommand(cmd *cobra.Command) error {
+	path := strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+	for _, name := range kubernetesCommands {
+		if path == name {
+			return kube.Available()
+		}
+	}
+	return fmt.Errorf("'devbox %s' is not available for projects on the kubernetes backend (supported: %s)", path, strings.Join(kubernetesCommands, ", "))
+}
+
+func kubernetesProjectConfig(project *config.Project) *config.ProjectConfig {
+	projectConfig, err := configManager.LoadProjectConfig(project.WorkspacePath)
+	if err != nil || !projectConfig.KubernetesBackend() {
+		return nil
+	}
+	return projectConfig
+}
+
+func kubeClientFor(projectConfig *config.ProjectConfig) *kube.Client {
+	client := &kube.Client{}
+	if projectConfig.Kubernetes != nil {
+		client.Context = projectConfig.Kubernetes.Context
+		client.Namespace = projectConfig.Kubernetes.Namespace
+	}
+	return client
+}
+
+func kubeBoxSpec(projectName, boxName, baseImage, workspaceBox string, projectConfig *config.ProjectConfig) kube.BoxSpec {
+	spec := kube.BoxSpec{
+		Box:         boxName,
+		Image:       baseImage,
+		WorkingDir:  workspaceBox,
+		Owner:       docker.CurrentOwner(),
+		Env:         projectConfig.Environment,
+		GPUs:        projectConfig.Gpus,
+		Annotations: map[string]string{"devbox.project": projectName},
+	}
+	if projectConfig.Resources != nil {
+		spec.CPUs = projectConfig.Resources.CPUs
+		spec.Memory = projectConfig.Resources.Memory
+	}
+	if projectConfig.Kubernetes != nil {
+		spec.StorageClass = projectConfig.Kubernetes.StorageClass
+		spec.Storage = projectConfig.Kubernetes.Storage
+	}
+	return spec
+}
+
+func upKubernetes(cfg *config.Config, projectName, boxName, baseImage, cwd, workspaceBox string, projectConfig *config.ProjectConfig) error {
+	client := kubeClientFor(projectConfig)
+	if err := client.CheckAccess(); err != nil {
+		return err
+	}
+	if len(projectConfig.Ports)+len(projectConfig.Volumes)+len(projectConfig.Dotfiles) > 0 {
+		fmt.Printf("Warning: ports, volumes and dotfiles are ignored by the kubernetes backend\n")
+	}
+	pod := kube.PodName(boxName)
+	claim := kube.ClaimName(boxName)
+	info, err := client.GetPod(pod)
+	if err != nil {
+		return fmt.Errorf("failed to get pod: %w", err)
+	}
+
+	created, seed := info == nil, false
+	if created {
+		seed = !client.ClaimExists(claim)
+		manifest, err := kube.Manifest(kubeBoxSpec(projectName, boxName, baseImage, workspaceBox, projectConfig))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Creating pod '%s' with image '%s' (%s)...\n", pod, baseImage, client.Describe())
+		if err := client.Apply(manifest); err != nil {
+			return fmt.Errorf("failed to create pod: %w", err)
+		}
+	}
+	fmt.Printf("Waiting for pod '%s' to be ready...\n", pod)
+	if err := client.WaitReady(pod, kubeReadyTimeout); err != nil {
+		return fmt.Errorf("pod '%s' did not become ready: %w\nhint: run 'kubectl describe pod %s' to see why", pod, err, pod)
+	}
+	if seed {
+		fmt.Printf("Copying %s to the workspace claim '%s'...\n", cwd, claim)
+		if err := client.CopyTo(pod, cwd, workspaceBox); err != nil {
+			return err
+		}
+	}
+	if created && len(projectConfig.SetupCommands) > 0 {
+		fmt.Printf("Executing setup commands in pod '%s'...\n", pod)
+		for i, command := range projectConfig.SetupCommands {
+			fmt.Printf("[%d/%d] %s\n", i+1, len(projectConfig.SetupCommands), command)
+			if err := client.Exec(pod, false, "bash", "-c", parallel.WithShellInit(command, false)).Run(); err != nil {
+				return fmt.Errorf("setup command '%s' failed: %w", command, err)
+			}
+		}
+	}
+
+	project := &config.Project{
+		Name:          projectName,
+		BoxName:       boxName,
+		BaseImage:     baseImage,
+		WorkspacePath: cwd,
+		Status:        "running",
+	}
+	cfg.MergeProjectConfig(project, projectConfig)
+	cfg.AddProject(project)
+	if err := configManager.Save(cfg); err != nil {
+		return fmt.Errorf("failed to save configuration: %w", err)
+	}
+
+	fmt.Printf("Environment is up.\n")
+	fmt.Printf("Project: %s\n", projectName)
+	fmt.Printf("Pod: %s (%s)\n", pod, client.Describe())
+	fmt.Printf("Image: %s\n", baseImage)
+	fmt.Printf("Workspace: claim '%s' mounted at %s; run 'devbox sync %s' to push local changes or --pull to fetch them.\n", claim, workspaceBox, projectName)
+	fmt.Printf("Tip: run 'devbox shell %s' to enter the environment.\n", projectName)
+	notifyWebhooks(newWebhookEvent(config.WebhookUpFinished, projectName, boxName, fmt.Sprintf("Environment '%s' is up", projectName)))
+	return nil
+}
+
+func readyKubePod(project *config.Project, projectConfig *config.ProjectConfig) (*kube.Client, string, error) {
+	client := kubeClientFor(projectConfig)
+	pod := kube.PodName(project.BoxName)
+	info, err := client.GetPod(pod)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get pod: %w", err)
+	}
+	if info == nil {
+		return nil, "", fmt.Errorf("pod '%s' not found. Run 'devbox up' in %s to recreate it", pod, project.WorkspacePath)
+	}
+	if !info.Ready {
+		return nil, "", fmt.Errorf("pod '%s' is %s and not ready; run 'kubectl describe pod %s' to see why", pod, strings.ToLower(info.Phase), pod)
+	}
+	return client, pod, nil
+}
+
+func kubeShell(project *config.Project, projectConfig *config.ProjectConfig) error {
+	client, pod, err := readyKubePod(project, projectConfig)
+	if err != nil {
+		return err
+	}
+	if err := client.Exec(pod, true, "bash", "-l").Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil
+		}
+		return fmt.Errorf("failed to attach shell: %w", err)
+	}
+	return nil
+}
+
+func kubeRun(project *config.Project, projectConfig *config.ProjectConfig, command []string) error {
+	client, pod, err := readyKubePod(project, projectConfig)
+	if err != nil {
+		return err
+	}
+	if err := client.Exec(pod, stdinIsTerminal(), "bash", "-c", parallel.WithShellInit(strings.Join(command, " "), false)).Run(); err != nil {
+		return fmt.Errorf("failed to run command: %w", err)
+	}
+	return nil
+}
+
+func kubeExec(project *config.Project, projectConfig *config.ProjectConfig, command []string, opts docker.ExecOptions) (int, error) {
+	if opts.User != "" {
+		return 0, fmt.Errorf("--user is not supported with the kubernetes backend")
+	}
+	client, pod, err := readyKubePod(project, projectConfig)
+	if err != nil {
+		return 0, err
+	}
+	if len(opts.Env) > 0 {
+		env := []string{"env"}
+		for _, e := range opts.Env {
+			if !strings.Contains(e, "=") {
+				e += "=" + os.Getenv(e)
+			}
+			env = append(env, e)
+		}
+		command = append(env, command...)
+	}
+	if opts.Workdir != "" {
+		command = append([]string{"sh", "-c", `cd "$1" && shift && exec "$@"`, "sh", opts.Workdir}, command...)
+	}
+	if err := client.Exec(pod, opts.TTY, command...).Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode(), nil
+		}
+		return 0, fmt.Errorf("failed to exec in pod: %w", err)
+	}
+	return 0, nil
+}
+
+func collectKubernetesStatus(doc *projectStatusDoc, projectConfig *config.ProjectConfig) {
+	client := kubeClientFor(projectConfig)
+	doc.Backend = config.BackendKubernetes
+	doc.Cluster = client.Describe()
+	info, err := client.GetPod(kube.PodName(doc.Box))
+	if err != nil || info == nil {
+		return
+	}
+	doc.State = strings.ToLower(info.Phase)
+	doc.Running = info.Phase == "Running" && info.Ready
+	if info.Image != "" {
+		doc.Image = info.Image
+	}
+	if doc.Running && !info.StartedAt.IsZero() {
+		doc.UptimeSeconds = int64(time.Since(info.StartedAt).Seconds())
+	}
+	doc.GPUs = projectConfig.Gpus
+}
+
+func kubeStatus(projectName string, project *config.Project, projectConfig *config.ProjectConfig) error {
+	client := kubeClientFor(projectConfig)
+	pod := kube.PodName(project.BoxName)
+	info, err := client.GetPod(pod)
+	if err != nil {
+		return fmt.Errorf("failed to get pod: %w", err)
+	}
+	fmt.Printf("Devbox status\n")
+	fmt.Printf("Project: %s\n", projectName)
+	fmt.Printf("Backend: kubernetes (%s)\n", client.Describe())
+	if info == nil {
+		fmt.Printf("Pod: %s (not found)\n", pod)
+		fmt.Printf("Workspace claim: %s\n", kube.ClaimName(project.BoxName))
+		return nil
+	}
+	fmt.Printf("Pod: %s\n", pod)
+	fmt.Printf("Image: %s\n", firstNonEmpty(info.Image, project.BaseImage))
+	state := strings.ToLower(info.Phase)
+	if info.Phase == "Running" && !info.Ready {
+		state += " (not ready)"
+	}
+	fmt.Printf("State: %s\n", state)
+	if info.Phase == "Running" && !info.StartedAt.IsZero() {
+		fmt.Printf("Uptime: %s\n", humanizeDuration(time.Since(info.StartedAt)))
+	} else {
+		fmt.Printf("Uptime: -\n")
+	}
+	if info.Node != "" {
+		fmt.Printf("Node: %s\n", info.Node)
+	}
+	if info.IP != "" {
+		fmt.Printf("Pod IP: %s\n", info.IP)
+	}
+	fmt.Printf("Restarts: %d\n", info.Restarts)
+	if projectConfig.Gpus != "" {
+		fmt.Printf("GPUs: %d requested\n", kube.GPUCount(projectConfig.Gpus))
+	}
+	fmt.Printf("Workspace claim: %s\n", kube.ClaimName(project.BoxName))
+	return nil
+}
+
+func kubeStop(project *config.Project, projectConfig *config.ProjectConfig) error {
+	client := kubeClientFor(projectConfig)
+	pod := kube.PodName(project.BoxName)
+	info, err := client.GetPod(pod)
+	if err != nil {
+		return fmt.Errorf("failed to get pod: %w", err)
+	}
+	if info == nil {
+		fmt.Printf("Pod '%s' not found. Nothing to stop.\n", pod)
+		return nil
+	}
+	fmt.Printf("Deleting pod '%s' (the workspace claim '%s' is kept)...\n", pod, kube.ClaimName(project.BoxName))
+	if err := client.DeletePod(pod); err != nil {
+		return fmt.Errorf("failed to stop pod: %w", err)
+	}
+	fmt.Printf("Stopped '%s'\n", pod)
+	return nil
+}
+
+func kubeDestroy(project *config.Project, projectConfig *config.ProjectConfig) {
+	client := kubeClientFor(projectConfig)
+	pod := kube.PodName(project.BoxName)
+	fmt.Printf("Deleting pod '%s' and workspace claim '%s'...\n", pod, kube.ClaimName(project.BoxName))
+	if err := client.DeletePod(pod); err != nil {
+		fmt.Printf("Warning: failed to delete pod: %v\n", err)
+	}
+	if err := client.DeleteClaim(kube.ClaimName(project.BoxName)); err != nil {
+		fmt.Printf("Warning: failed to delete workspace claim: %v\n", err)
+	}
+}
+
+func kubeSync(project *config.Project, projectConfig *config.ProjectConfig, pull bool) error {
+	client, pod, err := readyKubePod(project, projectConfig)
+	if err != nil {
+		return err
+	}
+	workspaceBox := projectWorkspaceBox(projectConfig)
+	if pull {
+		fmt.Printf("Copying %s:%s to %s...\n", pod, workspaceBox, project.WorkspacePath)
+		if err := client.CopyFrom(pod, workspaceBox, project.WorkspacePath); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("Copying %s to %s:%s (claim %s)...\n", project.WorkspacePath, pod, workspaceBox, kube.ClaimName(project.BoxName))
+		if err := client.CopyTo(pod, project.WorkspacePath, workspaceBox); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Workspace synced\n")
+	return nil
+}
@@ -0,0 +1,29 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckKubernetesCommand(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"stop"}, "kubectl not found"},
+		{[]string{"exec"}, "kubectl not found"},
+		{[]string{"sync"}, "kubectl not found"},
+		{[]string{"sync", "status"}, "'devbox sync status' is not available"},
+		{[]string{"snapshot", "create"}, "'devbox snapshot create' is not available"},
+	}
+	for _, tt := range tests {
+		cmd, _, err := rootCmd.Find(tt.args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := checkKubernetesCommand(cmd); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("checkKubernetesCommand(%q) = %v, want %q", tt.args, err, tt.want)
+		}
+	}
+}
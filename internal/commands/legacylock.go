@@ -0,0 +1,372 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type recordedCommand struct {
+	Manager  string
+	Binary   string
+	Remove   bool
+	Packages []string
+	Raw      string
+	Opaque   bool
+}
+
+var legacyManagerOrder = []string{"apt", "pip", "npm", "yarn", "pnpm"}
+
+var valueFlags = map[string]map[string]bool{
+	"apt": {"-t": true, "--target-release": true, "-o": true, "--option": true, "-c": true},
+	"pip": {"-r": true, "--requirement": true, "-e": true, "--editable": true, "-c": true, "--constraint": true,
+		"-i": true, "--index-url": true, "--extra-index-url": true, "-f": true, "--find-links": true, "-t": true, "--target": true},
+	"npm":  {"--registry": true, "--prefix": true},
+	"yarn": {"--registry": true, "--prefix": true},
+	"pnpm": {"--registry": true, "--dir": true, "-C": true},
+}
+
+func readLegacyLock(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		cmd := strings.TrimSpace(line)
+		if cmd == "" || strings.HasPrefix(cmd, "#") {
+			continue
+		}
+		lines = append(lines, cmd)
+	}
+	return lines, nil
+}
+
+func parseRecordedCommand(line string) recordedCommand {
+	rc := recordedCommand{Raw: strings.Join(strings.Fields(line), " "), Opaque: true}
+	fields := strings.Fields(line)
+	for len(fields) > 0 && (fields[0] == "sudo" || fields[0] == "corepack" || (strings.Contains(fields[0], "=") && !strings.HasPrefix(fields[0], "-"))) {
+		fields = fields[1:]
+	}
+	if len(fields) < 2 {
+		return rc
+	}
+
+	bin, args := fields[0], fields[1:]
+	global := false
+	switch bin {
+	case "apt", "apt-get":
+		rc.Manager = "apt"
+		switch args[0] {
+		case "install":
+		case "remove", "purge":
+			rc.Remove = true
+		default:
+			return rc
+		}
+		args = args[1:]
+		global = true
+	case "pip", "pip3":
+		rc.Manager = "pip"
+		switch args[0] {
+		case "install":
+		case "uninstall":
+			rc.Remove = true
+		default:
+			return rc
+		}
+		args = args[1:]
+		global = true
+	case "npm", "pnpm":
+		rc.Manager = bin
+		switch args[0] {
+		case "install", "i", "add":
+		case "uninstall", "remove", "rm", "r", "un":
+			rc.Remove = true
+		default:
+			return rc
+		}
+		args = args[1:]
+	case "yarn":
+		rc.Manager = "yarn"
+		if args[0] != "global" || len(args) < 2 {
+			return rc
+		}
+		switch args[1] {
+		case "add":
+		case "remove":
+			rc.Remove = true
+		default:
+			return rc
+		}
+		args = args[2:]
+		global = true
+	default:
+		return rc
+	}
+	rc.Binary = bin
+
+	var pkgs []string
+	for _, a := range args {
+		switch {
+		case a == "-g" || a == "--global":
+			global = true
+		case valueFlags[rc.Manager][a] || strings.HasPrefix(a, "--index-url=") || strings.HasPrefix(a, "--requirement="):
+			return rc
+		case strings.HasPrefix(a, "-"):
+		case strings.HasSuffix(a, ".deb") || strings.HasSuffix(a, ".whl") || strings.HasSuffix(a, ".tgz") ||
+			strings.Contains(a, "/") || strings.Contains(a, "://"):
+			return rc
+		default:
+			pkgs = append(pkgs, a)
+		}
+	}
+	if !global || len(pkgs) == 0 {
+		return rc
+	}
+	rc.Packages = pkgs
+	rc.Opaque = false
+	return rc
+}
+
+func legacyPackageName(manager, spec string) string {
+	s := strings.TrimSpace(spec)
+	switch manager {
+	case "apt":
+		if i := strings.Index(s, "="); i > 0 {
+			s = s[:i]
+		}
+	case "pip":
+		if i := strings.IndexAny(s, "=<>!~[;"); i > 0 {
+			s = s[:i]
+		}
+	default:
+		if i := strings.LastIndex(s, "@"); i > 0 {
+			s = s[:i]
+		}
+	}
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
+func installedPackageSets(installed lockPackages) map[string]map[string]string {
+	return map[string]map[string]string{
+		"apt":  parseMap(installed.Apt, "="),
+		"pip":  parseMap(installed.Pip, "=="),
+		"npm":  parseMap(installed.Npm, "@"),
+		"yarn": parseMap(installed.Yarn, "@"),
+		"pnpm": parseMap(installed.Pnpm, "@"),
+	}
+}
+
+func legacySpecSatisfied(manager, spec string, present map[string]string) bool {
+	name := legacyPackageName(manager, spec)
+	ver, ok := present[name]
+	if !ok {
+		return false
+	}
+	pinned := strings.TrimSpace(spec)[len(name):]
+	switch {
+	case pinned == "":
+		return true
+	case manager == "apt" && strings.HasPrefix(pinned, "="):
+		return strings.TrimPrefix(pinned, "=") == ver
+	case manager == "pip" && strings.HasPrefix(pinned, "=="):
+		return strings.TrimPrefix(pinned, "==") == ver
+	case strings.HasPrefix(pinned, "@"):
+		return strings.TrimPrefix(pinned, "@") == ver
+	}
+	return false
+}
+
+func normalizeLegacyCommands(lines []string, installed *lockPackages) []string {
+	type segment struct {
+		opaque string
+		run    []recordedCommand
+	}
+	var segments []segment
+	seenOpaque := map[string]bool{}
+	for _, line := range lines {
+		rc := parseRecordedCommand(line)
+		if !rc.Opaque {
+			if n := len(segments); n > 0 && segments[n-1].opaque == "" {
+				segments[n-1].run = append(segments[n-1].run, rc)
+			} else {
+				segments = append(segments, segment{run: []recordedCommand{rc}})
+			}
+			continue
+		}
+		if !seenOpaque[rc.Raw] {
+			seenOpaque[rc.Raw] = true
+			segments = append(segments, segment{opaque: rc.Raw})
+		}
+	}
+
+	var present map[string]map[string]string
+	if installed != nil {
+		present = installedPackageSets(*installed)
+	}
+	touched := map[string]int{}
+	for _, seg := range segments {
+		seen := map[string]bool{}
+		for _, rc := range seg.run {
+			for _, spec := range rc.Packages {
+				key := rc.Manager + "\x00" + legacyPackageName(rc.Manager, spec)
+				if !seen[key] {
+					seen[key] = true
+					touched[key]++
+				}
+			}
+		}
+	}
+
+	var out []string
+	for _, seg := range segments {
+		if seg.opaque != "" {
+			out = append(out, seg.opaque)
+			continue
+		}
+		out = append(out, normalizeLegacyRun(seg.run, present, touched)...)
+	}
+	return out
+}
+
+func normalizeLegacyRun(run []recordedCommand, present map[string]map[string]string, touched map[string]int) []string {
+	type pkgState struct {
+		spec    string
+		install bool
+	}
+	order := map[string][]string{}
+	state := map[string]map[string]*pkgState{}
+	binaries := map[string]string{}
+
+	for _, rc := range run {
+		if _, ok := binaries[rc.Manager]; !ok {
+			binaries[rc.Manager] = rc.Binary
+		}
+		if state[rc.Manager] == nil {
+			state[rc.Manager] = map[string]*pkgState{}
+		}
+		for _, spec := range rc.Packages {
+			name := legacyPackageName(rc.Manager, spec)
+			st, ok := state[rc.Manager][name]
+			if !ok {
+				st = &pkgState{}
+				state[rc.Manager][name] = st
+				order[rc.Manager] = append(order[rc.Manager], name)
+			}
+			st.install = !rc.Remove
+			if !rc.Remove {
+				st.spec = spec
+			} else {
+				st.spec = name
+			}
+		}
+	}
+
+	var out []string
+	for _, mgr := range legacyManagerOrder {
+		var installs, removes []string
+		for _, name := range order[mgr] {
+			st := state[mgr][name]
+			if present != nil && touched[mgr+"\x00"+name] == 1 {
+				_, isPresent := present[mgr][name]
+				if st.install && legacySpecSatisfied(mgr, st.spec, present[mgr]) {
+					continue
+				}
+				if !st.install && !isPresent {
+					continue
+				}
+			}
+			if st.install {
+				installs = append(installs, st.spec)
+			} else {
+				removes = append(removes, st.spec)
+			}
+		}
+		out = append(out, legacyManagerCommands(mgr, binaries[mgr], installs, removes)...)
+	}
+	return out
+}
+
+func legacyManagerCommands(manager, binary string, installs, removes []string) []string {
+	var cmds []string
+	join := func(prefix string, pkgs []string) {
+		if len(pkgs) > 0 {
+			cmds = append(cmds, prefix+" "+strings.Join(pkgs, " "))
+		}
+	}
+	switch manager {
+	case "apt":
+		join("apt-get remove -y", removes)
+		join("apt-get install -y", installs)
+	case "pip":
+		if binary == "" {
+			binary = "pip"
+		}
+		join(binary+" uninstall -y", removes)
+		join(binary+" install", installs)
+	case "npm":
+		join("npm uninstall -g", removes)
+		join("npm install -g", installs)
+	case "yarn":
+		join("yarn global remove", removes)
+		join("yarn global add", installs)
+	case "pnpm":
+		join("pnpm remove -g", removes)
+		join("pnpm add -g", installs)
+	}
+	return cmds
+}
+
+func legacyReplayCommands(workspacePath string) []string {
+	var lines []string
+	if lf, err := loadLockFile(filepath.Join(workspacePath, "devbox.lock.json")); err == nil {
+		lines = append(lines, lf.RecordedCommands...)
+	}
+	return append(lines, readJournal(workspacePath)...)
+}
+
+func readJournal(workspacePath string) []string {
+	var lines []string
+	for _, path := range journalPaths(workspacePath) {
+		if journal, err := readLegacyLock(path); err == nil {
+			lines = append(lines, journal...)
+		}
+	}
+	return lines
+}
+
+func replayLegacyLock(client DockerClientInterface, boxName, workspacePath string) error {
+	lines := legacyReplayCommands(workspacePath)
+	if len(lines) == 0 {
+		return nil
+	}
+
+	aptList, pipList, npmList, yarnList, pnpmList := client.QueryPackagesParallel(boxName)
+	installed := lockPackages{Apt: aptList, Pip: pipList, Npm: npmList, Yarn: yarnList, Pnpm: pnpmList}
+	cmds := normalizeLegacyCommands(lines, &installed)
+	if len(cmds) == 0 {
+		fmt.Printf("All %d recorded install(s) already present; nothing to replay.\n", len(lines))
+		return nil
+	}
+
+	fmt.Printf("Replaying %d command(s) normalized from %d recorded install(s)...\n", len(cmds), len(lines))
+	return client.ExecuteSetupCommandsWithOutput(boxName, cmds, false)
+}
+
+func foldRecorderJournal(workspacePath string, lf *lockFile) ([]string, int) {
+	var folded []string
+	var lines []string
+	for _, path := range journalPaths(workspacePath) {
+		if journal, err := readLegacyLock(path); err == nil && len(journal) > 0 {
+			folded = append(folded, path)
+			lines = append(lines, journal...)
+		}
+	}
+	if len(lines) == 0 {
+		return nil, 0
+	}
+	lf.RecordedCommands = normalizeLegacyCommands(append(append([]string{}, lf.RecordedCommands...), lines...), nil)
+	return folded, len(lines)
+}
@@ -0,0 +1,110 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeLegacyCommands(t *testing.T) {
+	lines := []string{
+		"apt install git",
+		"apt-get install -y git curl",
+		"pip3 install requests flask==2.3.0",
+		"npm install -g typescript",
+		"npm install lodash",
+		"apt remove curl",
+		"pip3 install -r requirements.txt",
+		"pip3 install -r requirements.txt",
+		"yarn global add serve",
+	}
+
+	got := normalizeLegacyCommands(lines, nil)
+	want := []string{
+		"apt-get install -y git curl",
+		"pip3 install requests flask==2.3.0",
+		"npm install -g typescript",
+		"npm install lodash",
+		"apt-get remove -y curl",
+		"pip3 install -r requirements.txt",
+		"yarn global add serve",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("normalizeLegacyCommands() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestNormalizeLegacyCommandsKeepsOpaqueOrder(t *testing.T) {
+	lines := []string{
+		"apt install -y curl gnupg",
+		"curl -fsSL https://deb.nodesource.com/setup_20.x | bash -",
+		"apt install -y nodejs",
+		"apt install -y nodejs jq",
+		"curl -fsSL https://deb.nodesource.com/setup_20.x | bash -",
+		"apt install -y git",
+	}
+	installed := &lockPackages{Apt: []string{"curl=8.5.0", "gnupg=2.4.4"}}
+
+	got := normalizeLegacyCommands(lines, installed)
+	want := []string{
+		"curl -fsSL https://deb.nodesource.com/setup_20.x | bash -",
+		"apt-get install -y nodejs jq git",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("normalizeLegacyCommands() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestNormalizeLegacyCommandsSkipsInstalled(t *testing.T) {
+	lines := []string{
+		"apt install -y git jq",
+		"pip install flask==2.3.0 requests",
+		"apt remove -y nano",
+	}
+	installed := &lockPackages{
+		Apt: []string{"git=1:2.34.1"},
+		Pip: []string{"flask==2.2.0", "requests==2.31.0"},
+	}
+
+	got := normalizeLegacyCommands(lines, installed)
+	want := []string{
+		"apt-get install -y jq",
+		"pip install flask==2.3.0",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("normalizeLegacyCommands() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestFoldRecorderJournal(t *testing.T) {
+	dir := t.TempDir()
+	journal := "apt install git\npip install requests\napt install git\n"
+	if err := os.WriteFile(filepath.Join(dir, "devbox.lock"), []byte(journal), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	lf := &lockFile{RecordedCommands: []string{"apt-get install -y curl"}}
+	paths, n := foldRecorderJournal(dir, lf)
+	if len(paths) != 1 || n != 3 {
+		t.Fatalf("expected 3 folded lines, got %d (%q)", n, paths)
+	}
+	want := []string{"apt-get install -y curl git", "pip install requests"}
+	if !reflect.DeepEqual(lf.RecordedCommands, want) {
+		t.Errorf("RecordedCommands = %q, want %q", lf.RecordedCommands, want)
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, ".devbox"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(journalPath(dir), []byte("npm i -g typescript\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if paths, n := foldRecorderJournal(dir, lf); len(paths) != 2 || n != 4 {
+		t.Errorf("expected the new and legacy journals to be folded together, got %d (%q)", n, paths)
+	}
+
+	if paths, n := foldRecorderJournal(t.TempDir(), lf); len(paths) != 0 || n != 0 {
+		t.Errorf("expected nothing to fold without a journal, got %d (%q)", n, paths)
+	}
+}
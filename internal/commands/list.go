@@ -2,21 +2,76 @@ package commands
 
 import (
 	"fmt"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+
+	"devbox/internal/config"
+	"devbox/internal/docker"
 )
 
 var (
-	verboseFlag bool
+	verboseFlag    bool
+	listOutputFlag string
 )
 
+type listEntry struct {
+	Project       string     `json:"project"`
+	Box           string     `json:"box"`
+	Status        string     `json:"status"`
+	Health        string     `json:"health"`
+	Drift         string     `json:"drift"`
+	DriftDetails  []string   `json:"drift_details,omitempty"`
+	Config        string     `json:"config"`
+	Workspace     string     `json:"workspace"`
+	Owner         string     `json:"owner,omitempty"`
+	CreatedBy     string     `json:"created_by,omitempty"`
+	ConfigChanged bool       `json:"config_changed,omitempty"`
+	ArchivePath   string     `json:"archive_path,omitempty"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	ExpiryAction  string     `json:"expiry_action,omitempty"`
+	BaseImage     string     `json:"base_image,omitempty"`
+	Ports         []string   `json:"ports,omitempty"`
+	SetupCommands int        `json:"setup_commands,omitempty"`
+
+	archived      bool
+	healthText    string
+	driftText     string
+	driftChecked  time.Time
+	ownerWarning  bool
+	ttl           *ttlEntry
+	imageOverride bool
+	mismatch      string
+}
+
+type listOtherBox struct {
+	Box       string `json:"box"`
+	Owner     string `json:"owner"`
+	Status    string `json:"status"`
+	Project   string `json:"project,omitempty"`
+	Workspace string `json:"workspace,omitempty"`
+}
+
+type listDoc struct {
+	Projects   []listEntry    `json:"projects"`
+	OtherBoxes []listOtherBox `json:"other_boxes,omitempty"`
+}
+
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all devbox projects and their status",
-	Long:  `Display all managed devbox projects along with their box status.`,
-	Args:  cobra.NoArgs,
+	Long: `Display all managed devbox projects along with their box status.
+
+With --output json or --output yaml, prints every project with its full details
+(including what --verbose shows) as a structured document for scripts and CI.`,
+	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFormat(listOutputFlag); err != nil {
+			return err
+		}
+		structured := listOutputFlag != outputTable
 
 		cfg, err := configManager.Load()
 		if err != nil {
@@ -24,7 +79,7 @@ var listCmd = &cobra.Command{
 		}
 
 		projects := cfg.GetProjects()
-		if len(projects) == 0 {
+		if len(projects) == 0 && !allUsersFlag && !structured {
 			fmt.Println("No devbox projects found.")
 			fmt.Println("Create a new project with: devbox init <project-name>")
 			return nil
@@ -36,97 +91,255 @@ var listCmd = &cobra.Command{
 		}
 
 		boxStatus := make(map[string]string)
+		boxOwner := make(map[string]string)
+		boxRunning := make(map[string]bool)
+		boxMetadata := make(map[string]docker.BoxMetadata)
 		for _, box := range boxes {
 			for _, name := range box.Names {
 
 				cleanName := strings.TrimPrefix(name, "/")
 				boxStatus[cleanName] = box.Status
+				boxOwner[cleanName] = box.Owner
+				boxRunning[cleanName] = box.Running()
+				boxMetadata[cleanName] = box.Metadata
 			}
 		}
+		currentOwner := docker.CurrentOwner()
+		driftRecords := loadDriftRecords()
+		ttlEntries := loadTTLEntries()
 
-		fmt.Printf("DEVBOX PROJECTS\n")
-		if verboseFlag {
-			fmt.Printf("%-20s %-20s %-15s %-12s %s\n", "PROJECT", "BOX", "STATUS", "CONFIG", "WORKSPACE")
-			fmt.Printf("%-20s %-20s %-15s %-12s %s\n",
-				strings.Repeat("-", 20),
-				strings.Repeat("-", 20),
-				strings.Repeat("-", 15),
-				strings.Repeat("-", 12),
-				strings.Repeat("-", 30))
-		} else {
-			fmt.Printf("%-20s %-20s %-15s %s\n", "PROJECT", "BOX", "STATUS", "WORKSPACE")
-			fmt.Printf("%-20s %-20s %-15s %s\n",
-				strings.Repeat("-", 20),
-				strings.Repeat("-", 20),
-				strings.Repeat("-", 15),
-				strings.Repeat("-", 30))
-		}
-
-		for _, project := range projects {
-			status := "not found"
+		doc := listDoc{Projects: []listEntry{}}
+		tracked := make(map[string]bool)
+		for _, name := range sortedProjectNames(cfg) {
+			project := projects[name]
+			tracked[project.BoxName] = true
+			entry := listEntry{
+				Project:    project.Name,
+				Box:        project.BoxName,
+				Status:     "not found",
+				Health:     "none",
+				Drift:      driftUnknown,
+				Config:     "none",
+				Workspace:  project.WorkspacePath,
+				Owner:      boxOwner[project.BoxName],
+				healthText: "-",
+			}
 			if boxStatus[project.BoxName] != "" {
-				status = boxStatus[project.BoxName]
+				entry.Status = boxStatus[project.BoxName]
+			}
+			entry.archived = project.Status == projectStatusArchived && boxStatus[project.BoxName] == ""
+			if entry.archived {
+				entry.Status = projectStatusArchived
+				entry.ArchivePath = project.ArchivePath
+			}
+			entry.ownerWarning = entry.Owner != "" && entry.Owner != currentOwner
+			if md := boxMetadata[project.BoxName]; md.Known() {
+				entry.CreatedBy = md.CreatedBy()
+				entry.mismatch = boxMetadataMismatch(md, project.Name, project.WorkspacePath)
+				if verboseFlag || structured {
+					entry.ConfigChanged = configChangedSinceCreate(md, project.WorkspacePath)
+				}
 			}
 
-			configStatus := "none"
-			if project.ConfigFile != "" {
-				configStatus = "devbox.json"
-			} else {
-
-				projectConfig, err := configManager.LoadProjectConfig(project.WorkspacePath)
-				if err == nil && projectConfig != nil {
-					configStatus = "devbox.json"
+			if boxRunning[project.BoxName] {
+				if h, err := dockerClient.GetHealth(project.BoxName); err == nil {
+					entry.healthText = healthLabel(h)
+					if h.Configured() {
+						entry.Health = h.Status
+					}
 				}
 			}
 
-			if verboseFlag {
-				fmt.Printf("%-20s %-20s %-15s %-12s %s\n",
-					project.Name,
-					project.BoxName,
-					status,
-					configStatus,
-					project.WorkspacePath)
-			} else {
-				fmt.Printf("%-20s %-20s %-15s %s\n",
-					project.Name,
-					project.BoxName,
-					status,
-					project.WorkspacePath)
+			if project.ConfigFile != "" {
+				entry.Config = "devbox.json"
 			}
+			if entry.Config == "none" || verboseFlag || structured {
 
-			if verboseFlag {
 				projectConfig, err := configManager.LoadProjectConfig(project.WorkspacePath)
 				if err == nil && projectConfig != nil {
+					entry.Config = "devbox.json"
 					if projectConfig.BaseImage != "" && projectConfig.BaseImage != project.BaseImage {
-						fmt.Printf("  - Base image: %s (override)\n", projectConfig.BaseImage)
-					}
-					if len(projectConfig.Ports) > 0 {
-						fmt.Printf("  - Ports: %s\n", strings.Join(projectConfig.Ports, ", "))
-					}
-					if len(projectConfig.SetupCommands) > 0 {
-						fmt.Printf("  - Setup commands: %d\n", len(projectConfig.SetupCommands))
+						entry.BaseImage = projectConfig.BaseImage
+						entry.imageOverride = true
 					}
+					entry.Ports = projectConfig.Ports
+					entry.SetupCommands = len(projectConfig.SetupCommands)
 				}
 			}
-		}
+			if entry.BaseImage == "" {
+				entry.BaseImage = project.BaseImage
+			}
 
-		fmt.Printf("\nTotal projects: %d\n", len(projects))
+			driftRecord, hasDrift := driftRecords[project.Name]
+			entry.driftText = driftLabel(driftRecord, hasDrift)
+			if hasDrift {
+				entry.Drift = driftRecord.Status
+				entry.DriftDetails = driftRecord.Details
+				entry.driftChecked = driftRecord.CheckedAt
+			}
 
-		if verboseFlag {
+			if e, ok := ttlEntries[project.BoxName]; ok {
+				e := e
+				entry.ttl = &e
+				entry.ExpiresAt = &e.ExpiresAt
+				entry.ExpiryAction = ttlActionVerb(e)
+			}
+			doc.Projects = append(doc.Projects, entry)
+		}
 
-			if cfg.Settings != nil {
-				fmt.Printf("\nGlobal settings:\n")
-				fmt.Printf("  Default base image: %s\n", cfg.Settings.DefaultBaseImage)
-				fmt.Printf("  Auto update: %t\n", cfg.Settings.AutoUpdate)
+		if allUsersFlag {
+			for _, box := range boxes {
+				for _, name := range box.Names {
+					cleanName := strings.TrimPrefix(name, "/")
+					if tracked[cleanName] {
+						continue
+					}
+					doc.OtherBoxes = append(doc.OtherBoxes, listOtherBox{Box: cleanName, Owner: ownerName(box.Owner), Status: box.Status, Project: box.Metadata.Project, Workspace: box.Metadata.Workspace})
+				}
 			}
-		} else {
-			fmt.Printf("\nUse --verbose for detailed information including configurations.\n")
 		}
 
+		if structured {
+			return writeStructured(os.Stdout, listOutputFlag, doc)
+		}
+		printListTable(doc, cfg.Settings)
 		return nil
 	},
 }
 
 func init() {
 	listCmd.Flags().BoolVarP(&verboseFlag, "verbose", "v", false, "Show detailed information including configuration details")
+	listCmd.Flags().BoolVar(&allUsersFlag, "all-users", false, "Also show devbox boxes not tracked by your config, with their owners")
+	addOutputFlag(listCmd, &listOutputFlag)
+}
+
+func printListTable(doc listDoc, settings *config.GlobalSettings) {
+	fmt.Printf("DEVBOX PROJECTS\n")
+	if verboseFlag {
+		fmt.Printf("%-20s %-20s %-15s %-15s %-10s %-12s %s\n", "PROJECT", "BOX", "STATUS", "HEALTH", "DRIFT", "CONFIG", "WORKSPACE")
+		fmt.Printf("%-20s %-20s %-15s %-15s %-10s %-12s %s\n",
+			strings.Repeat("-", 20),
+			strings.Repeat("-", 20),
+			strings.Repeat("-", 15),
+			strings.Repeat("-", 15),
+			strings.Repeat("-", 10),
+			strings.Repeat("-", 12),
+			strings.Repeat("-", 30))
+	} else {
+		fmt.Printf("%-20s %-20s %-15s %-15s %-10s %s\n", "PROJECT", "BOX", "STATUS", "HEALTH", "DRIFT", "WORKSPACE")
+		fmt.Printf("%-20s %-20s %-15s %-15s %-10s %s\n",
+			strings.Repeat("-", 20),
+			strings.Repeat("-", 20),
+			strings.Repeat("-", 15),
+			strings.Repeat("-", 15),
+			strings.Repeat("-", 10),
+			strings.Repeat("-", 30))
+	}
+
+	for _, entry := range doc.Projects {
+		var line string
+		if verboseFlag {
+			line = fmt.Sprintf("%-20s %-20s %-15s %-15s %-10s %-12s %s",
+				entry.Project,
+				entry.Box,
+				entry.Status,
+				entry.healthText,
+				entry.driftText,
+				entry.Config,
+				entry.Workspace)
+		} else {
+			line = fmt.Sprintf("%-20s %-20s %-15s %-15s %-10s %s",
+				entry.Project,
+				entry.Box,
+				entry.Status,
+				entry.healthText,
+				entry.driftText,
+				entry.Workspace)
+		}
+		if entry.archived {
+			line = dimText(line)
+		}
+		fmt.Println(line)
+		if entry.ownerWarning {
+			fmt.Printf("  - Warning: box is owned by %s; enable settings.user_box_prefix to avoid name collisions\n", ownerName(entry.Owner))
+		}
+		if entry.mismatch != "" {
+			fmt.Printf("  - Warning: box was created for %s\n", entry.mismatch)
+		}
+		if !verboseFlag {
+			continue
+		}
+		if entry.archived {
+			fmt.Printf("  - Archive: %s\n", entry.ArchivePath)
+		}
+		if entry.ttl != nil {
+			fmt.Printf("  - TTL: %s left, then %s\n", ttlRemaining(*entry.ttl, time.Now()), entry.ExpiryAction)
+		}
+		if entry.Drift == driftDrifted {
+			fmt.Printf("  - Drift (checked %s ago):\n", time.Since(entry.driftChecked).Round(time.Minute))
+			for _, d := range entry.DriftDetails {
+				fmt.Printf("      %s\n", d)
+			}
+		}
+		if entry.CreatedBy != "" {
+			fmt.Printf("  - Created by: %s\n", entry.CreatedBy)
+		}
+		if entry.ConfigChanged {
+			fmt.Printf("  - Config: devbox.json changed since the box was created; run 'devbox update %s' to recreate it\n", entry.Project)
+		}
+		if entry.imageOverride {
+			fmt.Printf("  - Base image: %s (override)\n", entry.BaseImage)
+		}
+		if len(entry.Ports) > 0 {
+			fmt.Printf("  - Ports: %s\n", strings.Join(entry.Ports, ", "))
+		}
+		if entry.SetupCommands > 0 {
+			fmt.Printf("  - Setup commands: %d\n", entry.SetupCommands)
+		}
+	}
+
+	fmt.Printf("\nTotal projects: %d\n", len(doc.Projects))
+
+	if allUsersFlag {
+		fmt.Printf("\nOTHER DEVBOX BOXES\n")
+		fmt.Printf("%-30s %-15s %-25s %s\n", "BOX", "OWNER", "STATUS", "PROJECT")
+		for _, box := range doc.OtherBoxes {
+			fmt.Printf("%-30s %-15s %-25s %s\n", box.Box, box.Owner, box.Status, firstNonEmpty(boxOrigin(docker.BoxMetadata{Project: box.Project, Workspace: box.Workspace}), "-"))
+		}
+		if len(doc.OtherBoxes) == 0 {
+			fmt.Printf("(none)\n")
+		}
+	}
+
+	if verboseFlag {
+
+		if settings != nil {
+			fmt.Printf("\nGlobal settings:\n")
+			fmt.Printf("  Default base image: %s\n", settings.DefaultBaseImage)
+			fmt.Printf("  Auto update: %t\n", settings.AutoUpdate)
+		}
+	} else {
+		fmt.Printf("\nUse --verbose for detailed information including configurations.\n")
+	}
+}
+
+const (
+	colorDim    = "2"
+	colorRed    = "31"
+	colorGreen  = "32"
+	colorYellow = "33"
+)
+
+func dimText(s string) string {
+	return colorText(colorDim, s)
+}
+
+func colorText(code, s string) string {
+	if os.Getenv("NO_COLOR") != "" {
+		return s
+	}
+	if fi, err := os.Stdout.Stat(); err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		return s
+	}
+	return "\033[" + code + "m" + s + "\033[0m"
 }
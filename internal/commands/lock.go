@@ -5,30 +5,45 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
+
+	"devbox/internal/config"
+	"devbox/internal/docker"
 )
 
+const lockFileVersion = 2
+
 type lockFile struct {
-	Version     int               `json:"version"`
-	Project     string            `json:"project"`
-	BoxName     string            `json:"box_name"`
-	CreatedAt   string            `json:"created_at"`
-	BaseImage   lockImage         `json:"base_image"`
-	Container   lockContainer     `json:"container"`
-	Packages    lockPackages      `json:"packages"`
-	Registries  lockRegistries    `json:"registries,omitempty"`
-	AptSources  lockAptSources    `json:"apt_sources,omitempty"`
-	SetupScript []string          `json:"setup_commands,omitempty"`
-	Notes       map[string]string `json:"notes,omitempty"`
+	Version     int                 `json:"version"`
+	Project     string              `json:"project"`
+	BoxName     string              `json:"box_name"`
+	BaseImage   lockImage           `json:"base_image"`
+	Container   lockContainer       `json:"container"`
+	Packages    lockPackages        `json:"packages"`
+	Registries  lockRegistries      `json:"registries,omitempty"`
+	AptSources  lockAptSources      `json:"apt_sources,omitempty"`
+	SetupScript []string            `json:"setup_commands,omitempty"`
+	Filesystem  *lockFilesystem     `json:"filesystem,omitempty"`
+	Notes       map[string]string   `json:"notes,omitempty"`
+	Ignore      *config.IgnoreRules `json:"ignore,omitempty"`
+
+	RecordedCommands []string `json:"recorded_commands,omitempty"`
+
+	Info lockInfo `json:"info"`
 }
 
 type lockImage struct {
-	Name   string `json:"name"`
-	Digest string `json:"digest,omitempty"`
-	ID     string `json:"id,omitempty"`
+	Name string `json:"name"`
+}
+
+type lockInfo struct {
+	CreatedAt   string `json:"created_at,omitempty"`
+	ImageDigest string `json:"image_digest,omitempty"`
+	ImageID     string `json:"image_id,omitempty"`
 }
 
 type lockContainer struct {
@@ -50,6 +65,8 @@ type lockPackages struct {
 	Npm  []string `json:"npm,omitempty"`
 	Yarn []string `json:"yarn,omitempty"`
 	Pnpm []string `json:"pnpm,omitempty"`
+
+	Extra map[string][]string `json:"extra,omitempty"`
 }
 
 type lockRegistries struct {
@@ -67,8 +84,27 @@ type lockAptSources struct {
 	PinnedRelease string   `json:"pinned_release,omitempty"`
 }
 
+type lockFilesystem struct {
+	Paths   []string          `json:"paths"`
+	Exclude []string          `json:"exclude,omitempty"`
+	Files   map[string]string `json:"files"`
+}
+
+var defaultFSManifestPaths = []string{"/etc", "/usr/local/bin", "/usr/local/sbin"}
+
+var defaultFSManifestExclude = []string{
+	"/etc/hostname",
+	"/etc/hosts",
+	"/etc/resolv.conf",
+	"/etc/mtab",
+	"/etc/ld.so.cache",
+	"/etc/devbox-initialized",
+}
+
 var (
-	lockOutput string
+	lockOutput     string
+	lockFSManifest bool
+	lockShow       bool
 )
 
 var lockCmd = &cobra.Command{
@@ -77,6 +113,9 @@ var lockCmd = &cobra.Command{
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		projectName := args[0]
+		if lockShow {
+			return showLock(projectName)
+		}
 		return WriteLockFileForProject(projectName, lockOutput)
 	},
 }
@@ -84,6 +123,9 @@ var lockCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(lockCmd)
 	lockCmd.Flags().StringVarP(&lockOutput, "output", "o", "", "Output path for lock file (default: <workspace>/devbox.lock.json)")
+	lockCmd.Flags().BoolVar(&lockShow, "show", false, "Print a summary of devbox.lock.json and pending recorded commands without regenerating it")
+	lockCmd.Flags().BoolVar(&noCacheFlag, "no-cache", false, "Re-query packages and registries instead of reusing a recent snapshot")
+	lockCmd.Flags().BoolVar(&lockFSManifest, "fs-manifest", false, "Record SHA-256 hashes of files under fs_manifest paths (default: /etc, /usr/local/bin, /usr/local/sbin)")
 }
 
 func WriteLockFileForProject(projectName string, outPath string) error {
@@ -112,21 +154,22 @@ func WriteLockFileForBox(boxName, projectName, workspacePath, baseImage, outPath
 		return err
 	}
 	if status != "running" {
-		if err := dockerClient.StartBox(boxName); err != nil {
-			return fmt.Errorf("failed to start box '%s': %w", boxName, err)
+		if err := startStoppedBox(boxName); err != nil {
+			return err
 		}
 	}
+	if release, err := dockerClient.UseExecSession(boxName); err == nil {
+		defer release()
+	}
 
 	imgName := baseImage
-	digest, imgID, imgErr := dockerClient.GetImageDigestInfo(imgName)
-	if imgErr != nil || strings.TrimSpace(digest) == "" {
-
-		cid, err := dockerClient.GetContainerID(boxName)
-		if err == nil && cid != "" {
-			d2, id2, _ := dockerClient.GetImageDigestInfo(cid)
-			if d2 != "" || id2 != "" {
-				digest, imgID = d2, id2
-			}
+	var digest, imgID string
+	if cid, err := dockerClient.GetContainerID(boxName); err == nil && cid != "" {
+		digest, imgID, _ = dockerClient.GetImageDigestInfo(cid)
+	}
+	if strings.TrimSpace(digest) == "" {
+		if d2, id2, err := dockerClient.GetImageDigestInfo(imgName); err == nil && (d2 != "" || imgID == "") {
+			digest, imgID = d2, id2
 		}
 	}
 
@@ -134,20 +177,29 @@ func WriteLockFileForBox(boxName, projectName, workspacePath, baseImage, outPath
 	ports, _ := dockerClient.GetPortMappings(boxName)
 
 	envMap, workdir, user, restart, labels, capabilities, resources, network := dockerClient.GetContainerMeta(boxName)
+	envMap, labels = withoutSecrets(envMap, labels)
+	for k := range labels {
+		if docker.IsMetadataLabel(k) {
+			delete(labels, k)
+		}
+	}
+	imageEnv, _ := dockerClient.GetBoxImageEnv(boxName)
+	pcfg, _ := configManager.LoadProjectConfig(workspacePath)
+	var declaredEnv map[string]string
+	if pcfg != nil {
+		declaredEnv = pcfg.Environment
+	}
 
-	fmt.Printf("Gathering package information in parallel...\n")
-	aptList, pipList, npmList, yarnList, pnpmList := dockerClient.QueryPackagesParallel(boxName)
-
-	aptSnapshot, aptSources, aptRelease := dockerClient.GetAptSources(boxName)
-	pipIndex, pipExtras := dockerClient.GetPipRegistries(boxName)
-	npmReg, yarnReg, pnpmReg := dockerClient.GetNodeRegistries(boxName)
+	dropPackageSnapshot(boxName)
+	snapshot := loadPackageSnapshot(boxName)
+	registries := snapshot.Registries
+	registries.Env = registryEnv(envMap)
 
 	lf := lockFile{
-		Version:   1,
+		Version:   lockFileVersion,
 		Project:   projectName,
 		BoxName:   boxName,
-		CreatedAt: time.Now().UTC().Format(time.RFC3339),
-		BaseImage: lockImage{Name: imgName, Digest: digest, ID: imgID},
+		BaseImage: lockImage{Name: imgName},
 		Container: lockContainer{
 			WorkingDir:   workdir,
 			User:         user,
@@ -156,36 +208,26 @@ func WriteLockFileForBox(boxName, projectName, workspacePath, baseImage, outPath
 			Ports:        ports,
 			Volumes:      mounts,
 			Labels:       labels,
-			Environment:  envMap,
+			Environment:  filterLockEnv(envMap, imageEnv, declaredEnv),
 			Capabilities: capabilities,
 			Resources:    resources,
 		},
-		Packages: lockPackages{
-			Apt:  aptList,
-			Pip:  pipList,
-			Npm:  npmList,
-			Yarn: yarnList,
-			Pnpm: pnpmList,
-		},
-		Registries: lockRegistries{
-			PipIndexURL:   pipIndex,
-			PipExtraIndex: pipExtras,
-			NpmRegistry:   npmReg,
-			YarnRegistry:  yarnReg,
-			PnpmRegistry:  pnpmReg,
-			Env:           envMap,
-		},
-		AptSources: lockAptSources{
-			SnapshotURL:   aptSnapshot,
-			SourcesLists:  aptSources,
-			PinnedRelease: aptRelease,
+		Packages:   snapshot.Packages,
+		Registries: registries,
+		AptSources: snapshot.AptSources,
+		Info: lockInfo{
+			CreatedAt:   time.Now().UTC().Format(time.RFC3339),
+			ImageDigest: digest,
+			ImageID:     imgID,
 		},
 	}
 
-	if pcfg, err := configManager.LoadProjectConfig(workspacePath); err == nil && pcfg != nil {
+	var fsPaths []string
+	if pcfg != nil {
 		if len(pcfg.SetupCommands) > 0 {
 			lf.SetupScript = pcfg.SetupCommands
 		}
+		fsPaths = pcfg.FSManifest
 	}
 
 	finalOut := strings.TrimSpace(outPath)
@@ -193,14 +235,231 @@ func WriteLockFileForBox(boxName, projectName, workspacePath, baseImage, outPath
 		finalOut = filepath.Join(workspacePath, "devbox.lock.json")
 	}
 
+	if lockFSManifest || lockHasFilesystem(finalOut) {
+		if len(fsPaths) == 0 {
+			fsPaths = defaultFSManifestPaths
+		}
+		fmt.Printf("Hashing files under %s...\n", strings.Join(fsPaths, ", "))
+		files, err := dockerClient.GetFileHashes(boxName, fsPaths, defaultFSManifestExclude)
+		if err != nil {
+			return fmt.Errorf("failed to build filesystem manifest: %w", err)
+		}
+		lf.Filesystem = &lockFilesystem{Paths: fsPaths, Exclude: defaultFSManifestExclude, Files: files}
+	}
+
+	existing, existingErr := loadLockFile(finalOut)
+	if existingErr == nil {
+		lf.RecordedCommands = existing.RecordedCommands
+		lf.Ignore = existing.Ignore
+		if strings.Contains(imgName, "@") && lockedImageRef(existing) == imgName {
+			lf.BaseImage.Name = existing.BaseImage.Name
+		}
+	}
+
+	var journals []string
+	folded := 0
+	if strings.TrimSpace(outPath) == "" {
+		journals, folded = foldRecorderJournal(workspacePath, &lf)
+	}
+
+	if existingErr == nil && sameLockSpec(existing, &lf) &&
+		existing.Info.ImageDigest == lf.Info.ImageDigest && existing.Info.ImageID == lf.Info.ImageID {
+		lf.Info.CreatedAt = existing.Info.CreatedAt
+	}
+
+	if err := writeLockFileJSON(finalOut, &lf); err != nil {
+		return err
+	}
+
+	if len(journals) > 0 {
+		cleared := true
+		for _, path := range journals {
+			if err := os.Remove(path); err != nil {
+				fmt.Printf("Warning: failed to clear %s: %v\n", path, err)
+				cleared = false
+			}
+		}
+		if pcfg != nil && pcfg.Workspace.Synced() {
+			boxJournal := projectWorkspaceBox(pcfg) + "/" + syncPulledFiles[0]
+			if _, _, err := dockerClient.ExecCapture(boxName, "rm -f '"+escapeBash(boxJournal)+"'"); err != nil {
+				fmt.Printf("Warning: failed to clear %s in the box: %v\n", boxJournal, err)
+				cleared = false
+			}
+		}
+		if cleared {
+			fmt.Printf("Folded %d recorded command(s) from the journal into recorded_commands\n", folded)
+		}
+	}
+
+	fmt.Printf("Wrote lock file: %s\n", finalOut)
+	return nil
+}
+
+func writeLockFileJSON(path string, lf *lockFile) error {
+	lf.normalize()
 	b, err := json.MarshalIndent(lf, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal lock file: %w", err)
 	}
-	if err := os.WriteFile(finalOut, b, 0644); err != nil {
+	if err := os.WriteFile(path, append(b, '\n'), 0644); err != nil {
 		return fmt.Errorf("failed to write lock file: %w", err)
 	}
+	return nil
+}
 
-	fmt.Printf("Wrote lock file: %s\n", finalOut)
+func (lf *lockFile) normalize() {
+	p := &lf.Packages
+	p.Apt = normalizePackageList("apt", p.Apt)
+	p.Pip = normalizePackageList("pip", p.Pip)
+	p.Npm = normalizePackageList("npm", p.Npm)
+	p.Yarn = normalizePackageList("yarn", p.Yarn)
+	p.Pnpm = normalizePackageList("pnpm", p.Pnpm)
+	for name, list := range p.Extra {
+		if len(list) == 0 {
+			delete(p.Extra, name)
+			continue
+		}
+		p.Extra[name] = normalizePackageList(name, list)
+	}
+
+	c := &lf.Container
+	c.Ports = sortedUnique(c.Ports)
+	c.Volumes = sortedUnique(c.Volumes)
+	for i, capability := range c.Capabilities {
+		c.Capabilities[i] = strings.ToUpper(strings.TrimSpace(capability))
+	}
+	c.Capabilities = sortedUnique(c.Capabilities)
+
+	lf.Registries.PipExtraIndex = sortedUnique(lf.Registries.PipExtraIndex)
+	lf.AptSources.SourcesLists = sortedUnique(lf.AptSources.SourcesLists)
+	if lf.Filesystem != nil {
+		lf.Filesystem.Paths = sortedUnique(lf.Filesystem.Paths)
+		lf.Filesystem.Exclude = sortedUnique(lf.Filesystem.Exclude)
+	}
+}
+
+func sameLockSpec(a, b *lockFile) bool {
+	spec := func(lf *lockFile) []byte {
+		lf.normalize()
+		c := *lf
+		c.Info = lockInfo{}
+		data, _ := json.Marshal(c)
+		return data
+	}
+	return string(spec(a)) == string(spec(b))
+}
+
+func normalizePackageList(manager string, list []string) []string {
+	out := make([]string, 0, len(list))
+	for _, s := range list {
+		s = strings.Join(strings.Fields(s), " ")
+		if manager == "pip" {
+			if name, version, ok := strings.Cut(s, "=="); ok {
+				s = strings.ToLower(strings.TrimSpace(name)) + "==" + strings.TrimSpace(version)
+			}
+		}
+		out = append(out, s)
+	}
+	return sortedUnique(out)
+}
+
+func sortedUnique(list []string) []string {
+	if len(list) == 0 {
+		return list
+	}
+	seen := map[string]bool{}
+	out := make([]string, 0, len(list))
+	for _, s := range list {
+		s = strings.TrimSpace(s)
+		if s == "" || seen[s] {
+			continue
+		}
+		seen[s] = true
+		out = append(out, s)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func showLock(projectName string) error {
+	cfg, err := configManager.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	proj, ok := cfg.GetProject(projectName)
+	if !ok {
+		return fmt.Errorf("project '%s' not found", projectName)
+	}
+
+	lockPath := filepath.Join(proj.WorkspacePath, "devbox.lock.json")
+	lf, err := loadLockFile(lockPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("no lockfile at %s; run 'devbox lock %s' first", lockPath, projectName)
+		}
+		return err
+	}
+
+	fmt.Printf("Lockfile: %s (created %s)\n", lockPath, lf.Info.CreatedAt)
+	fmt.Printf("Image:    %s\n", lockedImageRef(lf))
+	fmt.Printf("Packages: apt %d, pip %d, npm %d, yarn %d, pnpm %d",
+		len(lf.Packages.Apt), len(lf.Packages.Pip), len(lf.Packages.Npm), len(lf.Packages.Yarn), len(lf.Packages.Pnpm))
+	for _, name := range sortedExtraKeys(lf.Packages.Extra) {
+		fmt.Printf(", %s %d", name, len(lf.Packages.Extra[name]))
+	}
+	fmt.Println()
+	if lf.Filesystem != nil {
+		fmt.Printf("Files:    %d hashed under %s\n", len(lf.Filesystem.Files), strings.Join(lf.Filesystem.Paths, ", "))
+	}
+
+	if len(lf.RecordedCommands) > 0 {
+		fmt.Printf("\nRecorded commands:\n")
+		for _, c := range lf.RecordedCommands {
+			fmt.Printf("  %s\n", c)
+		}
+	}
+	if pending := readJournal(proj.WorkspacePath); len(pending) > 0 {
+		fmt.Printf("\nPending (recorded since last lock, folded in on next 'devbox lock'):\n")
+		for _, c := range pending {
+			fmt.Printf("  %s\n", c)
+		}
+	}
 	return nil
 }
+
+func loadLockFile(lockPath string) (*lockFile, error) {
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		return nil, err
+	}
+	var lf lockFile
+	if err := json.Unmarshal(data, &lf); err != nil {
+		return nil, fmt.Errorf("invalid lockfile %s: %w", lockPath, err)
+	}
+	if lf.Version < lockFileVersion && lf.Info == (lockInfo{}) {
+		var legacy struct {
+			CreatedAt string `json:"created_at"`
+			BaseImage struct {
+				Digest string `json:"digest"`
+				ID     string `json:"id"`
+			} `json:"base_image"`
+		}
+		_ = json.Unmarshal(data, &legacy)
+		lf.Info = lockInfo{CreatedAt: legacy.CreatedAt, ImageDigest: legacy.BaseImage.Digest, ImageID: legacy.BaseImage.ID}
+	}
+	return &lf, nil
+}
+
+func lockHasFilesystem(lockPath string) bool {
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		return false
+	}
+	var existing struct {
+		Filesystem *lockFilesystem `json:"filesystem"`
+	}
+	if err := json.Unmarshal(data, &existing); err != nil {
+		return false
+	}
+	return existing.Filesystem != nil
+}
@@ -0,0 +1,172 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteLockFileJSONIsDeterministic(t *testing.T) {
+	newLock := func(reverse bool) *lockFile {
+		order := func(list ...string) []string {
+			if !reverse {
+				return list
+			}
+			out := make([]string, 0, len(list))
+			for i := len(list) - 1; i >= 0; i-- {
+				out = append(out, list[i])
+			}
+			return out
+		}
+		return &lockFile{
+			Version: 1,
+			Project: "web",
+			Container: lockContainer{
+				Ports:        order("8080:80", "3000:3000"),
+				Volumes:      order("/data:/data", "/cache:/cache"),
+				Labels:       map[string]string{"b": "2", "a": "1"},
+				Environment:  map[string]string{"PATH": "/usr/bin", "LANG": "C.UTF-8"},
+				Capabilities: order("sys_ptrace", "NET_ADMIN"),
+			},
+			Packages: lockPackages{
+				Apt:   order("git=1:2.34.1", "curl=7.81.0-1", "curl=7.81.0-1"),
+				Pip:   order("Requests==2.31.0", " flask==3.0.0"),
+				Npm:   order("typescript@5.4.5", "eslint@9.0.0"),
+				Extra: map[string][]string{"cargo": order("ripgrep 14.1.0", "bat  0.24.0")},
+			},
+			Registries:  lockRegistries{PipExtraIndex: order("https://b.example/simple", "https://a.example/simple")},
+			AptSources:  lockAptSources{SourcesLists: order("deb http://b.example stable main", "deb http://a.example stable main")},
+			SetupScript: []string{"apt-get update", "apt-get install -y git"},
+		}
+	}
+
+	dir := t.TempDir()
+	var outputs [][]byte
+	for i, reverse := range []bool{false, true} {
+		path := filepath.Join(dir, "lock"+string(rune('a'+i))+".json")
+		if err := writeLockFileJSON(path, newLock(reverse)); err != nil {
+			t.Fatalf("writeLockFileJSON() error = %v", err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		outputs = append(outputs, data)
+	}
+	if !bytes.Equal(outputs[0], outputs[1]) {
+		t.Fatalf("lockfiles differ for identical state:\n%s\n---\n%s", outputs[0], outputs[1])
+	}
+
+	lf, err := loadLockFile(filepath.Join(dir, "locka.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := lf.Packages.Pip; len(got) != 2 || got[0] != "flask==3.0.0" || got[1] != "requests==2.31.0" {
+		t.Errorf("pip = %q, want sorted lowercase names", got)
+	}
+	if got := lf.Packages.Apt; len(got) != 2 {
+		t.Errorf("apt = %q, want duplicates removed", got)
+	}
+	if got := lf.Packages.Extra["cargo"]; got[0] != "bat 0.24.0" {
+		t.Errorf("cargo = %q, want whitespace collapsed", got)
+	}
+	if got := lf.Container.Capabilities; got[0] != "NET_ADMIN" || got[1] != "SYS_PTRACE" {
+		t.Errorf("capabilities = %q, want sorted upper case", got)
+	}
+	if got := lf.SetupScript; got[0] != "apt-get update" {
+		t.Errorf("setup_commands = %q, want original order kept", got)
+	}
+}
+
+func TestLoadLockFileMovesLegacyInfo(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "devbox.lock.json")
+	legacy := `{"version": 1, "project": "web", "created_at": "2025-09-18T20:41:51Z",
+		"base_image": {"name": "ubuntu:22.04", "digest": "ubuntu@sha256:abc", "id": "sha256:def"}}`
+	if err := os.WriteFile(path, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+	lf, err := loadLockFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := lockInfo{CreatedAt: "2025-09-18T20:41:51Z", ImageDigest: "ubuntu@sha256:abc", ImageID: "sha256:def"}
+	if lf.Info != want || lf.BaseImage.Name != "ubuntu:22.04" {
+		t.Errorf("loadLockFile() = %+v / %+v, want info %+v", lf.BaseImage, lf.Info, want)
+	}
+}
+
+func TestSameLockSpecIgnoresInfo(t *testing.T) {
+	a := &lockFile{Version: lockFileVersion, Packages: lockPackages{Apt: []string{"git=1", "curl=2"}}, Info: lockInfo{CreatedAt: "2025-01-01T00:00:00Z"}}
+	b := &lockFile{Version: lockFileVersion, Packages: lockPackages{Apt: []string{"curl=2", "git=1"}}, Info: lockInfo{CreatedAt: "2026-01-01T00:00:00Z"}}
+	if !sameLockSpec(a, b) {
+		t.Error("lockfiles that only differ in info should have the same spec")
+	}
+	b.Packages.Apt = append(b.Packages.Apt, "vim=3")
+	if sameLockSpec(a, b) {
+		t.Error("a package change should change the spec")
+	}
+}
+
+func benchLockFile(n int) *lockFile {
+	lf := &lockFile{
+		Version:   lockFileVersion,
+		Project:   "bench",
+		BoxName:   "devbox_bench",
+		BaseImage: lockImage{Name: "ubuntu:22.04"},
+		Packages:  lockPackages{Extra: map[string][]string{}},
+		Filesystem: &lockFilesystem{
+			Paths: defaultFSManifestPaths,
+			Files: map[string]string{},
+		},
+		Info: lockInfo{CreatedAt: "2025-01-01T00:00:00Z"},
+	}
+	for i := n - 1; i >= 0; i-- {
+		lf.Packages.Apt = append(lf.Packages.Apt, fmt.Sprintf("libpkg%d=1.%d.0-1ubuntu1", i, i))
+		lf.Packages.Pip = append(lf.Packages.Pip, fmt.Sprintf("Package-%d == 2.%d.0", i, i))
+		lf.Packages.Npm = append(lf.Packages.Npm, fmt.Sprintf("pkg-%d@3.%d.0", i, i))
+		lf.Packages.Extra["cargo"] = append(lf.Packages.Extra["cargo"], fmt.Sprintf("crate-%d  0.%d.0", i, i))
+		lf.Filesystem.Files[fmt.Sprintf("/etc/file-%d.conf", i)] = fmt.Sprintf("%064x", i)
+	}
+	return lf
+}
+
+func BenchmarkWriteLockFileJSON(b *testing.B) {
+	path := filepath.Join(b.TempDir(), "devbox.lock.json")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		lf := benchLockFile(2000)
+		b.StartTimer()
+		if err := writeLockFileJSON(path, lf); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLoadLockFile(b *testing.B) {
+	path := filepath.Join(b.TempDir(), "devbox.lock.json")
+	if err := writeLockFileJSON(path, benchLockFile(2000)); err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := loadLockFile(path); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSameLockSpec(b *testing.B) {
+	a, c := benchLockFile(2000), benchLockFile(2000)
+	c.Info.CreatedAt = "2026-01-01T00:00:00Z"
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !sameLockSpec(a, c) {
+			b.Fatal("identical lockfiles compared as different")
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package commands
+
+import (
+	"strings"
+
+	"devbox/internal/docker"
+)
+
+var lockEnvNoise = map[string]bool{
+	"PATH":     true,
+	"HOSTNAME": true,
+	"HOME":     true,
+	"TERM":     true,
+	"SHLVL":    true,
+	"PWD":      true,
+	"OLDPWD":   true,
+	"_":        true,
+}
+
+var registryEnvKeys = map[string]bool{
+	"GOPROXY":      true,
+	"GOPRIVATE":    true,
+	"GONOSUMDB":    true,
+	"GONOPROXY":    true,
+	"UV_INDEX_URL": true,
+}
+
+var registryEnvPrefixes = []string{"PIP_", "NPM_CONFIG_", "YARN_", "PNPM_", "UV_EXTRA_INDEX"}
+
+func filterLockEnv(env, imageEnv, declared map[string]string) map[string]string {
+	out := map[string]string{}
+	for k, v := range env {
+		if _, ok := declared[k]; ok {
+			out[k] = v
+			continue
+		}
+		if lockEnvNoise[k] {
+			continue
+		}
+		if imageValue, ok := imageEnv[k]; ok && imageValue == v {
+			continue
+		}
+		out[k] = v
+	}
+	return out
+}
+
+func isRegistryEnv(key string) bool {
+	if registryEnvKeys[key] || strings.HasSuffix(strings.ToUpper(key), "_PROXY") {
+		return true
+	}
+	for _, prefix := range registryEnvPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func registryEnv(env map[string]string) map[string]string {
+	out := map[string]string{}
+	for k, v := range env {
+		if isRegistryEnv(k) {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+func diffEnvironment(locked, current, imageEnv map[string]string) []containerDrift {
+	var drifts []containerDrift
+	for _, k := range sortedKeys(locked) {
+		if lockEnvNoise[k] {
+			continue
+		}
+		if cur, ok := current[k]; !ok || cur != locked[k] {
+			drifts = append(drifts, containerDrift{Field: "env " + k, Locked: locked[k], Current: current[k]})
+		}
+	}
+	managed := filterLockEnv(current, imageEnv, nil)
+	for _, k := range sortedKeys(managed) {
+		if _, ok := locked[k]; !ok {
+			drifts = append(drifts, containerDrift{Field: "env " + k, Locked: "(none)", Current: managed[k]})
+		}
+	}
+	return drifts
+}
+
+func withoutSecrets(env, labels map[string]string) (map[string]string, map[string]string) {
+	value, ok := labels[docker.SecretsLabel]
+	if !ok {
+		return env, labels
+	}
+	secrets := docker.ParseSecretsLabel(value)
+	outEnv := map[string]string{}
+	for k, v := range env {
+		if !secrets[k] {
+			outEnv[k] = v
+		}
+	}
+	outLabels := map[string]string{}
+	for k, v := range labels {
+		if k != docker.SecretsLabel {
+			outLabels[k] = v
+		}
+	}
+	return outEnv, outLabels
+}
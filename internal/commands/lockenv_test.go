@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+
+	"devbox/internal/docker"
+)
+
+func TestFilterLockEnv(t *testing.T) {
+	env := map[string]string{
+		"PATH":           "/usr/local/bin:/usr/bin",
+		"HOSTNAME":       "3f2a1b",
+		"LANG":           "C.UTF-8",
+		"PYTHON_VERSION": "3.12.1",
+		"HTTP_PROXY":     "http://proxy:3128",
+		"APP_ENV":        "dev",
+	}
+	imageEnv := map[string]string{
+		"PATH":           "/usr/local/bin:/usr/bin",
+		"LANG":           "C.UTF-8",
+		"PYTHON_VERSION": "3.12.1",
+	}
+	got := filterLockEnv(env, imageEnv, map[string]string{"LANG": "C.UTF-8"})
+	want := map[string]string{"LANG": "C.UTF-8", "HTTP_PROXY": "http://proxy:3128", "APP_ENV": "dev"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("filterLockEnv() = %v, want %v", got, want)
+	}
+
+	reg := registryEnv(env)
+	if !reflect.DeepEqual(reg, map[string]string{"HTTP_PROXY": "http://proxy:3128"}) {
+		t.Errorf("registryEnv() = %v", reg)
+	}
+}
+
+func TestDiffEnvironment(t *testing.T) {
+	imageEnv := map[string]string{"PATH": "/usr/bin", "LANG": "C.UTF-8"}
+	locked := map[string]string{"APP_ENV": "dev", "HOSTNAME": "old", "LANG": "C.UTF-8"}
+	tests := []struct {
+		name    string
+		current map[string]string
+		want    []string
+	}{
+		{"in sync", map[string]string{"PATH": "/usr/bin", "HOSTNAME": "new", "APP_ENV": "dev", "LANG": "C.UTF-8"}, nil},
+		{"changed", map[string]string{"APP_ENV": "prod", "LANG": "C.UTF-8"}, []string{"env APP_ENV"}},
+		{"missing and extra", map[string]string{"LANG": "C.UTF-8", "DEBUG": "1"}, []string{"env APP_ENV", "env DEBUG"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fields []string
+			for _, d := range diffEnvironment(locked, tt.current, imageEnv) {
+				fields = append(fields, d.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.want) {
+				t.Errorf("drift fields = %v, want %v", fields, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithoutSecrets(t *testing.T) {
+	env := map[string]string{"APP_ENV": "dev", "NPM_TOKEN": "npm_abc", "GITHUB_TOKEN": "ghp_abc"}
+	labels := map[string]string{"team": "web", docker.SecretsLabel: "GITHUB_TOKEN,NPM_TOKEN"}
+	gotEnv, gotLabels := withoutSecrets(env, labels)
+	if !reflect.DeepEqual(gotEnv, map[string]string{"APP_ENV": "dev"}) {
+		t.Errorf("withoutSecrets() env = %v", gotEnv)
+	}
+	if !reflect.DeepEqual(gotLabels, map[string]string{"team": "web"}) {
+		t.Errorf("withoutSecrets() labels = %v", gotLabels)
+	}
+	if gotEnv, _ := withoutSecrets(env, map[string]string{"team": "web"}); !reflect.DeepEqual(gotEnv, env) {
+		t.Errorf("withoutSecrets() without label = %v, want env unchanged", gotEnv)
+	}
+}
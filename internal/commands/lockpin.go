@@ -0,0 +1,72 @@
+package commands
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"devbox/internal/config"
+)
+
+var ignoreDigestFlag bool
+
+func imageRepository(ref string) string {
+	ref, _, _ = strings.Cut(ref, "@")
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		ref = ref[:i]
+	}
+	return ref
+}
+
+func imageDigest(ref string) string {
+	if i := strings.LastIndex(ref, "@"); i >= 0 {
+		return ref[i+1:]
+	}
+	return ref
+}
+
+func lockPinnedRef(lf *lockFile, image string) string {
+	digest := strings.TrimSpace(lf.Info.ImageDigest)
+	if digest == "" || strings.Contains(image, "@") || lf.BaseImage.Name != image {
+		return ""
+	}
+	if strings.Contains(digest, "@") && imageRepository(digest) != imageRepository(image) {
+		return ""
+	}
+	return lockedImageRef(lf)
+}
+
+func pinLockedImage(image, workspacePath string, projectConfig *config.ProjectConfig) string {
+	if ignoreDigestFlag || (projectConfig != nil && projectConfig.Build != nil) {
+		return image
+	}
+	lf, err := loadLockFile(filepath.Join(workspacePath, "devbox.lock.json"))
+	if err != nil {
+		return image
+	}
+	pinned := lockPinnedRef(lf, image)
+	if pinned == "" {
+		if lf.Info.ImageDigest != "" && lf.BaseImage.Name != "" && lf.BaseImage.Name != image {
+			fmt.Printf("Warning: devbox.lock.json pins %s, not %s; using the tag. Run 'devbox lock <project>' to refresh the lockfile\n", lf.BaseImage.Name, image)
+		}
+		return image
+	}
+	fmt.Printf("Using %s pinned by devbox.lock.json (--ignore-digest to use the tag)\n", pinned)
+	return pinned
+}
+
+func lockImageDrift(boxName string, lf *lockFile) (string, bool) {
+	want := imageDigest(strings.TrimSpace(lf.Info.ImageDigest))
+	if want == "" {
+		return "", false
+	}
+	cid, err := dockerClient.GetContainerID(boxName)
+	if err != nil || cid == "" {
+		return "", false
+	}
+	current, _, err := dockerClient.GetImageDigestInfo(cid)
+	if err != nil || current == "" {
+		return "", false
+	}
+	return current, imageDigest(current) != want
+}
@@ -0,0 +1,44 @@
+package commands
+
+import "testing"
+
+func TestImageRepository(t *testing.T) {
+	tests := map[string]string{
+		"ubuntu":                        "ubuntu",
+		"ubuntu:22.04":                  "ubuntu",
+		"ubuntu@sha256:abc":             "ubuntu",
+		"localhost:5000/team/web:1.2":   "localhost:5000/team/web",
+		"localhost:5000/team/web":       "localhost:5000/team/web",
+		"ghcr.io/org/app:v1@sha256:abc": "ghcr.io/org/app",
+	}
+	for ref, want := range tests {
+		if got := imageRepository(ref); got != want {
+			t.Errorf("imageRepository(%q) = %q, want %q", ref, got, want)
+		}
+	}
+}
+
+func TestLockPinnedRef(t *testing.T) {
+	tests := []struct {
+		name   string
+		locked string
+		digest string
+		image  string
+		want   string
+	}{
+		{"repo digest", "ubuntu:22.04", "ubuntu@sha256:abc", "ubuntu:22.04", "ubuntu@sha256:abc"},
+		{"bare digest", "ubuntu:22.04", "sha256:abc", "ubuntu:22.04", "ubuntu:22.04@sha256:abc"},
+		{"no digest", "ubuntu:22.04", "", "ubuntu:22.04", ""},
+		{"image changed", "ubuntu:22.04", "ubuntu@sha256:abc", "ubuntu:24.04", ""},
+		{"digest from another repository", "ubuntu:22.04", "mirror.example.com/ubuntu@sha256:abc", "ubuntu:22.04", ""},
+		{"already pinned", "ubuntu@sha256:abc", "ubuntu@sha256:abc", "ubuntu@sha256:abc", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lf := &lockFile{BaseImage: lockImage{Name: tt.locked}, Info: lockInfo{ImageDigest: tt.digest}}
+			if got := lockPinnedRef(lf, tt.image); got != tt.want {
+				t.Errorf("lockPinnedRef() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,12 +2,17 @@ package commands
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
+
+	"devbox/internal/config"
 )
 
 var (
@@ -17,8 +22,22 @@ var (
 	restartFlag     bool
 	statusCheckFlag bool
 	autoRepairFlag  bool
+	syncTimeFlag    bool
+
+	updateManagersFlag []string
+	refreshLockFlag    bool
+	rollbackFlag       string
+	noSnapshotFlag     bool
+
+	rebuildFromSnapshotFlag bool
+	rebuildPristineFlag     bool
+	rebuildNoCacheFlag      bool
 )
 
+const preUpdateTagPrefix = "pre-update-"
+
+var supportedUpdateManagers = []string{"apt", "pip", "npm"}
+
 var maintenanceCmd = &cobra.Command{
 	Use:   "maintenance [flags]",
 	Short: "Perform maintenance tasks on devbox projects and boxes",
@@ -34,15 +53,27 @@ var maintenanceCmd = &cobra.Command{
 Examples:
   devbox maintenance                     # Interactive maintenance menu
   devbox maintenance --update            # Update all boxes
+  devbox maintenance --update --managers apt,pip --refresh-lock
   devbox maintenance --health-check      # Check health of all projects
   devbox maintenance --restart           # Restart all stopped boxes
   devbox maintenance --rebuild           # Rebuild all boxes
+  devbox maintenance --rebuild --from-snapshot  # Fast rebuild from snapshots + lockfile delta
+  devbox maintenance --rebuild --pristine       # Rebuild from freshly pulled base images
+  devbox maintenance --rollback myproj   # Restore the pre-update snapshot
   devbox maintenance --status            # Show detailed status
-  devbox maintenance --auto-repair       # Auto-fix common issues`,
+  devbox maintenance --auto-repair       # Auto-fix common issues
+  devbox maintenance --sync-time         # Fix box clocks that drifted from the host`,
 	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
 
-		if !updateFlag && !healthCheckFlag && !rebuildFlag && !restartFlag && !statusCheckFlag && !autoRepairFlag {
+		if strings.TrimSpace(rollbackFlag) != "" {
+			return rollbackProject(strings.TrimSpace(rollbackFlag))
+		}
+		if err := validateRebuildFlags(); err != nil {
+			return err
+		}
+
+		if !updateFlag && !healthCheckFlag && !rebuildFlag && !restartFlag && !statusCheckFlag && !autoRepairFlag && !syncTimeFlag {
 			return runInteractiveMaintenance()
 		}
 
@@ -72,6 +103,10 @@ Examples:
 			maintenanceTasks = append(maintenanceTasks, autoRepairIssues)
 		}
 
+		if syncTimeFlag {
+			maintenanceTasks = append(maintenanceTasks, syncAllBoxClocks)
+		}
+
 		for _, task := range maintenanceTasks {
 			if err := task(); err != nil {
 				return err
@@ -174,7 +209,7 @@ func performStatusCheck() error {
 	for _, box := range boxes {
 		for _, name := range box.Names {
 			cleanName := strings.TrimPrefix(name, "/")
-			boxStatus[cleanName] = box.Status
+			boxStatus[cleanName] = box.State
 		}
 	}
 
@@ -185,7 +220,7 @@ func performStatusCheck() error {
 		if status == "" {
 			fmt.Printf("  missing %s -> %s\n", projectName, project.BoxName)
 			missing++
-		} else if strings.Contains(status, "Up") {
+		} else if status == "running" {
 			fmt.Printf("  running %s -> %s\n", projectName, project.BoxName)
 			running++
 		} else {
@@ -227,11 +262,11 @@ func performHealthCheck() error {
 	for _, box := range boxes {
 		for _, name := range box.Names {
 			cleanName := strings.TrimPrefix(name, "/")
-			boxStatus[cleanName] = box.Status
+			boxStatus[cleanName] = box.State
 		}
 	}
 
-	var healthy, unhealthy, missing int
+	var healthy, unhealthy, missing, clockSkewed, workspaceIssues int
 
 	fmt.Printf("\nProject Health Report:\n")
 	fmt.Printf("----------------------\n")
@@ -246,7 +281,7 @@ func performHealthCheck() error {
 			continue
 		}
 
-		if !strings.Contains(status, "Up") {
+		if status != "running" {
 			fmt.Printf("warning: box stopped (%s)\n", status)
 			unhealthy++
 			continue
@@ -264,6 +299,20 @@ func performHealthCheck() error {
 			continue
 		}
 
+		if skew, err := measureClockSkew(project.BoxName); err == nil && clockSkewExceeded(skew) {
+			fmt.Printf("warning: clock skew (%s)\n", describeClockSkew(skew))
+			clockSkewed++
+			unhealthy++
+			continue
+		}
+
+		if issues := workspaceFileIssues(project.WorkspacePath); len(issues) > 0 {
+			fmt.Printf("warning: %s\n", strings.Join(issues, "; "))
+			workspaceIssues++
+			unhealthy++
+			continue
+		}
+
 		fmt.Printf("Healthy\n")
 		healthy++
 	}
@@ -276,12 +325,22 @@ func performHealthCheck() error {
 	if unhealthy > 0 || missing > 0 {
 		fmt.Printf("\nhint: Use 'devbox maintenance --auto-repair' to fix common issues\n")
 	}
+	if clockSkewed > 0 {
+		fmt.Printf("hint: Use 'devbox maintenance --sync-time' to fix box clocks\n")
+	}
+	if workspaceIssues > 0 {
+		fmt.Printf("hint: Convert scripts to LF (e.g. 'sed -i \"s/\\r$//\" script.sh') and set \"git_guards\" in devbox.json; see the configuration docs\n")
+	}
 
 	return nil
 }
 
 func updateAllboxes() error {
-	fmt.Printf("Updating system packages in all devbox boxes...\n")
+	managers, err := normalizeUpdateManagers(updateManagersFlag)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Updating %s packages in all devbox boxes...\n", strings.Join(managers, ", "))
 
 	cfg, err := configManager.Load()
 	if err != nil {
@@ -322,22 +381,42 @@ func updateAllboxes() error {
 			time.Sleep(2 * time.Second)
 		}
 
-		updateCommands := []string{
-			"apt update -y",
-			"apt full-upgrade -y",
-			"apt autoremove -y",
-			"apt autoclean",
+		if !noSnapshotFlag {
+			if _, err := snapshotBeforeUpdate(projectName, project.BoxName, project.WorkspacePath); err != nil {
+				fmt.Printf("error: %v; skipping %s (use --no-snapshot to update anyway)\n", err, projectName)
+				failed++
+				continue
+			}
 		}
 
-		if err := dockerClient.ExecuteSetupCommandsWithOutput(project.BoxName, updateCommands, false); err != nil {
+		lockPath := filepath.Join(project.WorkspacePath, "devbox.lock.json")
+		var locked lockPackages
+		hasLock := false
+		if lf, err := loadLockFile(lockPath); err == nil {
+			locked = lf.Packages
+			hasLock = true
+		}
+
+		updateCommands := buildUpdateCommands(managers, locked)
+		if hasLock {
+			fmt.Printf("Holding %d package(s) pinned in devbox.lock.json\n", countLockedPackages(managers, locked))
+		}
+
+		err = dockerClient.ExecuteSetupCommandsSequential(project.BoxName, updateCommands, false)
+		dropPackageSnapshot(project.BoxName)
+		if err != nil {
 			fmt.Printf("error: failed to update %s: %v\n", projectName, err)
 			failed++
-		} else {
-			fmt.Printf("Updated %s successfully\n", projectName)
+			continue
+		}
 
+		fmt.Printf("Updated %s successfully\n", projectName)
+		if refreshLockFlag || !hasLock {
 			_ = WriteLockFileForBox(project.BoxName, projectName, project.WorkspacePath, project.BaseImage, "")
-			updated++
+		} else {
+			fmt.Printf("hint: devbox.lock.json left unchanged; use --refresh-lock or 'devbox lock %s' to record the new state\n", projectName)
 		}
+		updated++
 	}
 
 	fmt.Printf("\nUpdate Summary: %d updated, %d failed\n", updated, failed)
@@ -348,6 +427,110 @@ func updateAllboxes() error {
 	return nil
 }
 
+func normalizeUpdateManagers(in []string) ([]string, error) {
+	if len(in) == 0 {
+		return []string{"apt"}, nil
+	}
+	seen := map[string]bool{}
+	var out []string
+	for _, m := range in {
+		m = strings.ToLower(strings.TrimSpace(m))
+		if m == "" || seen[m] {
+			continue
+		}
+		supported := false
+		for _, s := range supportedUpdateManagers {
+			if s == m {
+				supported = true
+				break
+			}
+		}
+		if !supported {
+			return nil, fmt.Errorf("unsupported package manager '%s' (supported: %s)", m, strings.Join(supportedUpdateManagers, ", "))
+		}
+		seen[m] = true
+		out = append(out, m)
+	}
+	return out, nil
+}
+
+func countLockedPackages(managers []string, locked lockPackages) int {
+	n := 0
+	for _, m := range managers {
+		switch m {
+		case "apt":
+			n += len(parseMap(locked.Apt, "="))
+		case "pip":
+			n += len(parseMap(locked.Pip, "=="))
+		case "npm":
+			n += len(parseMap(locked.Npm, "@"))
+		}
+	}
+	return n
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func excludeFilter(names []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(" | grep -vxiF")
+	for _, n := range names {
+		b.WriteString(" -e '")
+		b.WriteString(escapeBash(n))
+		b.WriteString("'")
+	}
+	return b.String()
+}
+
+func buildUpdateCommands(managers []string, locked lockPackages) []string {
+	var cmds []string
+	for _, m := range managers {
+		switch m {
+		case "apt":
+			pinned := sortedKeys(parseMap(locked.Apt, "="))
+			cmds = append(cmds, "apt update -y")
+			if len(pinned) > 0 {
+				hold := "prev=$(apt-mark showhold); printf '%s\\n' " + quoteAll(pinned) + " > /tmp/devbox-held; apt-mark hold " + quoteAll(pinned) + " >/dev/null; " +
+					"DEBIAN_FRONTEND=noninteractive apt full-upgrade -y; rc=$?; " +
+					"while read -r p; do echo \"$prev\" | grep -qx \"$p\" || apt-mark unhold \"$p\" >/dev/null; done < /tmp/devbox-held; rm -f /tmp/devbox-held; exit $rc"
+				cmds = append(cmds, hold)
+			} else {
+				cmds = append(cmds, "DEBIAN_FRONTEND=noninteractive apt full-upgrade -y")
+			}
+			cmds = append(cmds, "apt autoremove -y", "apt autoclean")
+		case "pip":
+			pinned := sortedKeys(parseMap(locked.Pip, "=="))
+			cmds = append(cmds, "command -v python3 >/dev/null 2>&1 || exit 0; "+
+				"python3 -m pip list --outdated --format=freeze 2>/dev/null | cut -d= -f1"+excludeFilter(pinned)+
+				" | xargs -r python3 -m pip install -U")
+		case "npm":
+			pinned := sortedKeys(parseMap(locked.Npm, "@"))
+			cmds = append(cmds, "command -v npm >/dev/null 2>&1 || exit 0; "+
+				"npm outdated -g --json 2>/dev/null | node -e \"let s='';process.stdin.on('data',d=>s+=d).on('end',()=>{try{console.log(Object.keys(JSON.parse(s||'{}')).join('\\n'))}catch(e){}})\""+
+				excludeFilter(pinned)+" | sed 's/$/@latest/' | xargs -r npm install -g")
+		}
+	}
+	return cmds
+}
+
+func quoteAll(items []string) string {
+	quoted := make([]string, len(items))
+	for i, it := range items {
+		quoted[i] = "'" + escapeBash(it) + "'"
+	}
+	return strings.Join(quoted, " ")
+}
+
 func restartStoppedboxes() error {
 	fmt.Printf("Restarting stopped devbox boxes...\n")
 
@@ -399,8 +582,22 @@ func restartStoppedboxes() error {
 	return nil
 }
 
+func validateRebuildFlags() error {
+	if (rebuildFromSnapshotFlag || rebuildPristineFlag || rebuildNoCacheFlag) && !rebuildFlag {
+		return fmt.Errorf("--from-snapshot, --pristine, and --no-cache only apply to --rebuild")
+	}
+	return nil
+}
+
 func rebuildAllboxes() error {
-	fmt.Printf("Rebuilding all devbox boxes from latest base images...\n")
+	switch {
+	case rebuildFromSnapshotFlag:
+		fmt.Printf("Rebuilding all devbox boxes from their latest snapshots...\n")
+	case rebuildPristineFlag:
+		fmt.Printf("Rebuilding all devbox boxes from freshly pulled base images...\n")
+	default:
+		fmt.Printf("Rebuilding all devbox boxes from latest base images...\n")
+	}
 
 	if !forceFlag {
 		fmt.Print("This will destroy and recreate all boxes. Continue? (y/N): ")
@@ -433,11 +630,19 @@ func rebuildAllboxes() error {
 	for projectName, project := range projects {
 		fmt.Printf("\nRebuilding %s...\n", projectName)
 
+		snapshotRef := ""
 		if exists, err := dockerClient.BoxExists(project.BoxName); err != nil {
 			fmt.Printf("error: failed to check if %s exists: %v\n", project.BoxName, err)
 			failed++
 			continue
 		} else if exists {
+			if !noSnapshotFlag {
+				if snapshotRef, err = snapshotBeforeUpdate(projectName, project.BoxName, project.WorkspacePath); err != nil {
+					fmt.Printf("error: %v; skipping %s (use --no-snapshot to rebuild anyway)\n", err, projectName)
+					failed++
+					continue
+				}
+			}
 			fmt.Printf("Stopping and removing existing box...\n")
 			dockerClient.StopBox(project.BoxName)
 			if err := dockerClient.RemoveBox(project.BoxName); err != nil {
@@ -447,6 +652,23 @@ func rebuildAllboxes() error {
 			}
 		}
 
+		if rebuildFromSnapshotFlag {
+			if snapshotRef == "" {
+				snapshotRef, _, _ = latestPreUpdateSnapshot(projectName)
+			}
+			if snapshotRef != "" {
+				if err := rebuildFromSnapshot(projectName, project, snapshotRef); err != nil {
+					fmt.Printf("error: %v\n", err)
+					failed++
+					continue
+				}
+				fmt.Printf("Rebuilt %s successfully\n", projectName)
+				rebuilt++
+				continue
+			}
+			fmt.Printf("No snapshot found for %s; rebuilding from the base image\n", projectName)
+		}
+
 		fmt.Printf("Recreating box...\n")
 
 		projectConfig, err := configManager.LoadProjectConfig(project.WorkspacePath)
@@ -455,8 +677,17 @@ func rebuildAllboxes() error {
 		}
 
 		baseImage := cfg.GetEffectiveBaseImage(project, projectConfig)
-		if err := dockerClient.PullImage(baseImage); err != nil {
-			fmt.Printf("error: failed to pull %s: %v\n", baseImage, err)
+		if err := checkProjectImagePolicy(cfg, baseImage, project.WorkspacePath, projectConfig); err != nil {
+			fmt.Printf("error: %v\n", err)
+			failed++
+			continue
+		}
+		image := baseImage
+		if !rebuildPristineFlag {
+			image = pinLockedImage(baseImage, project.WorkspacePath, projectConfig)
+		}
+		if err := ensureBaseImage(image, project.WorkspacePath, projectConfig, rebuildPristineFlag, rebuildPristineFlag); err != nil {
+			fmt.Printf("error: failed to prepare %s: %v\n", image, err)
 			failed++
 			continue
 		}
@@ -466,7 +697,7 @@ func rebuildAllboxes() error {
 			workspaceBox = projectConfig.WorkingDir
 		}
 
-		boxID, err := dockerClient.CreateBox(project.BoxName, baseImage, project.WorkspacePath, workspaceBox)
+		boxID, err := dockerClient.CreateBox(project.BoxName, image, project.WorkspacePath, workspaceBox)
 		if err != nil {
 			fmt.Printf("error: failed to create %s: %v\n", project.BoxName, err)
 			failed++
@@ -479,7 +710,7 @@ func rebuildAllboxes() error {
 			continue
 		}
 
-		if err := dockerClient.WaitForBox(project.BoxName, 30*time.Second); err != nil {
+		if err := dockerClient.WaitForBox(project.BoxName, workspaceTimeouts(project.WorkspacePath).Startup); err != nil {
 			fmt.Printf("error: box %s failed to start: %v\n", project.BoxName, err)
 			failed++
 			continue
@@ -494,7 +725,7 @@ func rebuildAllboxes() error {
 		}
 
 		if projectConfig != nil && len(projectConfig.SetupCommands) > 0 {
-			if err := dockerClient.ExecuteSetupCommandsWithOutput(project.BoxName, projectConfig.SetupCommands, false); err != nil {
+			if err := runSetupSteps(dockerClient, project.BoxName, projectConfig.SetupCommands, rebuildNoCacheFlag); err != nil {
 				fmt.Printf("warning: failed to execute setup commands: %v\n", err)
 			}
 		}
@@ -517,6 +748,182 @@ func rebuildAllboxes() error {
 	return nil
 }
 
+func rebuildFromSnapshot(projectName string, project *config.Project, imageRef string) error {
+	projectConfig, err := configManager.LoadProjectConfig(project.WorkspacePath)
+	if err != nil {
+		fmt.Printf("warning: could not load project config: %v\n", err)
+	}
+	workspaceBox := "/workspace"
+	if projectConfig != nil && projectConfig.WorkingDir != "" {
+		workspaceBox = projectConfig.WorkingDir
+	}
+	var configMap map[string]interface{}
+	if projectConfig != nil {
+		if data, err := json.Marshal(projectConfig); err == nil {
+			_ = json.Unmarshal(data, &configMap)
+		}
+	}
+
+	fmt.Printf("Recreating box from %s...\n", imageRef)
+	boxID, err := dockerClient.CreateBoxWithConfig(project.BoxName, imageRef, project.WorkspacePath, workspaceBox, configMap)
+	if err != nil {
+		return fmt.Errorf("failed to create %s from %s: %w", project.BoxName, imageRef, err)
+	}
+	if err := dockerClient.StartBox(boxID); err != nil {
+		return fmt.Errorf("failed to start %s: %w", project.BoxName, err)
+	}
+	if err := dockerClient.WaitForBox(project.BoxName, workspaceTimeouts(project.WorkspacePath).Startup); err != nil {
+		return fmt.Errorf("box %s failed to start: %w", project.BoxName, err)
+	}
+
+	if projectConfig != nil && len(projectConfig.SetupCommands) > 0 {
+		if err := runTimedSetup(projectName, func() error {
+			return runSetupSteps(dockerClient, project.BoxName, projectConfig.SetupCommands, rebuildNoCacheFlag)
+		}); err != nil {
+			fmt.Printf("warning: failed to execute setup commands: %v\n", err)
+		}
+	}
+
+	lockPath := filepath.Join(project.WorkspacePath, "devbox.lock.json")
+	if _, err := os.Stat(lockPath); err != nil {
+		return nil
+	}
+	lf, err := loadLockFile(lockPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", lockPath, err)
+	}
+	summary, err := applyLockToBox(project.BoxName, project.WorkspacePath, lf)
+	if err != nil {
+		return fmt.Errorf("failed to apply lockfile delta: %w", err)
+	}
+	fmt.Printf("Applied devbox.lock.json: %s\n", summary)
+	return nil
+}
+
+func snapshotBeforeUpdate(projectName, boxName, workspacePath string) (string, error) {
+	ts := time.Now().UTC().Format("20060102-150405")
+	imageTag := fmt.Sprintf("devbox/%s:%s%s", projectName, preUpdateTagPrefix, ts)
+	fmt.Printf("Snapshotting %s as %s...\n", boxName, imageTag)
+	if _, err := dockerClient.CommitContainer(boxName, imageTag); err != nil {
+		return "", fmt.Errorf("failed to snapshot %s: %w", boxName, err)
+	}
+
+	lockPath := filepath.Join(workspacePath, "devbox.lock.json")
+	if data, err := os.ReadFile(lockPath); err == nil {
+		dir := backupsDir(workspacePath)
+		if err := ensureWorkspaceDataDir(workspacePath); err == nil && os.MkdirAll(dir, 0755) == nil {
+			_ = os.WriteFile(filepath.Join(dir, preUpdateTagPrefix+ts+".lock.json"), data, 0644)
+		}
+	}
+	return imageTag, nil
+}
+
+func latestPreUpdateSnapshot(projectName string) (string, string, error) {
+	images, err := dockerClient.ListImages("devbox/" + projectName)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to list snapshots: %w", err)
+	}
+	var latest string
+	for _, img := range images {
+		if !strings.HasPrefix(img.Tag, preUpdateTagPrefix) {
+			continue
+		}
+		ts := strings.TrimPrefix(img.Tag, preUpdateTagPrefix)
+		if ts > latest {
+			latest = ts
+		}
+	}
+	if latest == "" {
+		return "", "", fmt.Errorf("no pre-update snapshot found for project '%s'", projectName)
+	}
+	return fmt.Sprintf("devbox/%s:%s%s", projectName, preUpdateTagPrefix, latest), latest, nil
+}
+
+func rollbackProject(projectName string) error {
+	cfg, err := configManager.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	project, ok := cfg.GetProject(projectName)
+	if !ok {
+		return fmt.Errorf("project '%s' not found", projectName)
+	}
+
+	imageRef, ts, err := latestPreUpdateSnapshot(projectName)
+	if err != nil {
+		return err
+	}
+
+	if !forceFlag {
+		fmt.Printf("Roll back '%s' to snapshot %s? The current box will be replaced. (y/N): ", projectName, imageRef)
+		reader := bufio.NewReader(os.Stdin)
+		response, err := reader.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("failed to read confirmation: %w", err)
+		}
+		response = strings.ToLower(strings.TrimSpace(response))
+		if response != "y" && response != "yes" {
+			fmt.Printf("Rollback cancelled.\n")
+			return nil
+		}
+	}
+
+	if err := recreateBoxFromImage(project, imageRef); err != nil {
+		return err
+	}
+
+	savedLock := filepath.Join(backupsDir(project.WorkspacePath), preUpdateTagPrefix+ts+".lock.json")
+	data, err := os.ReadFile(savedLock)
+	if err != nil {
+		data, err = os.ReadFile(filepath.Join(project.WorkspacePath, legacyBackupsDirName, preUpdateTagPrefix+ts+".lock.json"))
+	}
+	if err == nil {
+		if err := os.WriteFile(filepath.Join(project.WorkspacePath, "devbox.lock.json"), data, 0644); err != nil {
+			fmt.Printf("Warning: failed to restore devbox.lock.json: %v\n", err)
+		} else {
+			fmt.Printf("Restored devbox.lock.json from snapshot\n")
+		}
+	}
+
+	fmt.Printf("Rolled back %s to %s\n", projectName, imageRef)
+	return nil
+}
+
+func recreateBoxFromImage(project *config.Project, imageRef string) error {
+	if exists, err := dockerClient.BoxExists(project.BoxName); err == nil && exists {
+		fmt.Printf("Stopping and removing current box '%s'...\n", project.BoxName)
+		_ = dockerClient.StopBox(project.BoxName)
+		if err := dockerClient.RemoveBox(project.BoxName); err != nil {
+			return fmt.Errorf("failed to remove existing box: %w", err)
+		}
+	}
+
+	projectConfig, _ := configManager.LoadProjectConfig(project.WorkspacePath)
+	workspaceBox := "/workspace"
+	if projectConfig != nil && projectConfig.WorkingDir != "" {
+		workspaceBox = projectConfig.WorkingDir
+	}
+	var configMap map[string]interface{}
+	if projectConfig != nil {
+		if data, err := json.Marshal(projectConfig); err == nil {
+			_ = json.Unmarshal(data, &configMap)
+		}
+	}
+
+	fmt.Printf("Recreating box '%s' from %s...\n", project.BoxName, imageRef)
+	boxID, err := dockerClient.CreateBoxWithConfig(project.BoxName, imageRef, project.WorkspacePath, workspaceBox, configMap)
+	if err != nil {
+		return fmt.Errorf("failed to create box from snapshot: %w", err)
+	}
+	if err := dockerClient.StartBox(boxID); err != nil {
+		return fmt.Errorf("failed to start box: %w", err)
+	}
+	if err := dockerClient.WaitForBox(project.BoxName, workspaceTimeouts(project.WorkspacePath).Startup); err != nil {
+		return fmt.Errorf("box failed to become ready: %w", err)
+	}
+	return nil
+}
+
 func autoRepairIssues() error {
 	fmt.Printf("Auto-repairing common devbox issues...\n")
 
@@ -560,13 +967,26 @@ func autoRepairIssues() error {
 
 			projectConfig, _ := configManager.LoadProjectConfig(project.WorkspacePath)
 			baseImage := cfg.GetEffectiveBaseImage(project, projectConfig)
+			if err := checkProjectImagePolicy(cfg, baseImage, project.WorkspacePath, projectConfig); err != nil {
+				fmt.Printf("error: %v\n", err)
+				failed++
+				continue
+			}
+			if projectConfig != nil && projectConfig.Build != nil {
+				if err := ensureBaseImage(baseImage, project.WorkspacePath, projectConfig, false, false); err != nil {
+					fmt.Printf("error: failed to build %s: %v\n", baseImage, err)
+					failed++
+					continue
+				}
+			}
 
 			workspaceBox := "/workspace"
 			if projectConfig != nil && projectConfig.WorkingDir != "" {
 				workspaceBox = projectConfig.WorkingDir
 			}
 
-			boxID, err := dockerClient.CreateBox(project.BoxName, baseImage, project.WorkspacePath, workspaceBox)
+			image := pinLockedImage(baseImage, project.WorkspacePath, projectConfig)
+			boxID, err := dockerClient.CreateBox(project.BoxName, image, project.WorkspacePath, workspaceBox)
 			if err != nil {
 				fmt.Printf("error: failed to recreate box: %v\n", err)
 				failed++
@@ -604,6 +1024,18 @@ func autoRepairIssues() error {
 					continue
 				}
 				issuesFound = true
+			} else if health, err := dockerClient.GetHealth(project.BoxName); err == nil && health.Status == "unhealthy" {
+				fmt.Printf("Box unhealthy (%d failing check(s)), restarting...\n", health.FailingStreak)
+				if probe := lastProbeSummary(health); probe != "" {
+					fmt.Printf("Last health check: %s\n", probe)
+				}
+				dockerClient.StopBox(project.BoxName)
+				if err := dockerClient.StartBox(project.BoxName); err != nil {
+					fmt.Printf("error: failed to restart box: %v\n", err)
+					failed++
+					continue
+				}
+				issuesFound = true
 			}
 		}
 
@@ -625,10 +1057,20 @@ func autoRepairIssues() error {
 
 func init() {
 	maintenanceCmd.Flags().BoolVar(&updateFlag, "update", false, "Update system packages in all boxes")
+	maintenanceCmd.Flags().StringSliceVar(&updateManagersFlag, "managers", nil, "Package managers to update with --update (apt, pip, npm; default: apt)")
+	maintenanceCmd.Flags().StringVar(&rollbackFlag, "rollback", "", "Restore a project's box from its latest pre-update snapshot")
+	maintenanceCmd.Flags().BoolVar(&noSnapshotFlag, "no-snapshot", false, "Skip the pre-update snapshot for --update/--rebuild")
+	maintenanceCmd.Flags().BoolVar(&refreshLockFlag, "refresh-lock", false, "Rewrite devbox.lock.json after --update (pinned packages are held otherwise)")
 	maintenanceCmd.Flags().BoolVar(&healthCheckFlag, "health-check", false, "Perform health check on all projects")
 	maintenanceCmd.Flags().BoolVar(&rebuildFlag, "rebuild", false, "Rebuild all boxes from latest base images")
+	maintenanceCmd.Flags().BoolVar(&rebuildFromSnapshotFlag, "from-snapshot", false, "With --rebuild, recreate boxes from their latest snapshot and apply only the lockfile delta")
+	maintenanceCmd.Flags().BoolVar(&ignoreDigestFlag, "ignore-digest", false, "With --rebuild or --auto-repair, recreate boxes from base_image's tag even when devbox.lock.json pins an image digest")
+	maintenanceCmd.Flags().BoolVar(&rebuildNoCacheFlag, "no-cache", false, "With --rebuild, re-run every setup command even if it already ran in the box")
+	maintenanceCmd.Flags().BoolVar(&rebuildPristineFlag, "pristine", false, "With --rebuild, pull base images (or build without cache) before recreating boxes from scratch")
+	maintenanceCmd.MarkFlagsMutuallyExclusive("from-snapshot", "pristine")
 	maintenanceCmd.Flags().BoolVar(&restartFlag, "restart", false, "Restart stopped boxes")
 	maintenanceCmd.Flags().BoolVar(&statusCheckFlag, "status", false, "Show detailed system status")
 	maintenanceCmd.Flags().BoolVar(&autoRepairFlag, "auto-repair", false, "Automatically repair common issues")
+	maintenanceCmd.Flags().BoolVar(&syncTimeFlag, "sync-time", false, "Check every running box's clock against the host and resync drifted ones")
 	maintenanceCmd.Flags().BoolVarP(&forceFlag, "force", "f", false, "Force operations without confirmation prompts")
 }
@@ -0,0 +1,76 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeUpdateManagers(t *testing.T) {
+	got, err := normalizeUpdateManagers(nil)
+	if err != nil || len(got) != 1 || got[0] != "apt" {
+		t.Fatalf("default managers = %v, %v; want [apt]", got, err)
+	}
+
+	got, err = normalizeUpdateManagers([]string{"PIP", "apt", "pip", " npm "})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(got, ",") != "pip,apt,npm" {
+		t.Errorf("managers = %v, want [pip apt npm]", got)
+	}
+
+	if _, err := normalizeUpdateManagers([]string{"brew"}); err == nil {
+		t.Error("expected error for unsupported manager")
+	}
+}
+
+func TestBuildUpdateCommandsHoldsLockedPackages(t *testing.T) {
+	locked := lockPackages{
+		Apt: []string{"curl=7.81.0-1", "git=1:2.34.1"},
+		Pip: []string{"requests==2.31.0"},
+	}
+	cmds := buildUpdateCommands([]string{"apt", "pip"}, locked)
+
+	joined := strings.Join(cmds, "\n")
+	if !strings.Contains(joined, "apt-mark hold 'curl' 'git'") {
+		t.Errorf("expected locked apt packages to be held, got:\n%s", joined)
+	}
+	if !strings.Contains(joined, "-e 'requests'") {
+		t.Errorf("expected locked pip package to be excluded, got:\n%s", joined)
+	}
+	if cmds[0] != "apt update -y" {
+		t.Errorf("first command = %q, want apt update", cmds[0])
+	}
+
+	plain := buildUpdateCommands([]string{"apt"}, lockPackages{})
+	for _, c := range plain {
+		if strings.Contains(c, "apt-mark") {
+			t.Errorf("unexpected hold without lockfile: %q", c)
+		}
+	}
+}
+
+func TestValidateRebuildFlags(t *testing.T) {
+	defer func() {
+		rebuildFlag, rebuildFromSnapshotFlag, rebuildPristineFlag, rebuildNoCacheFlag = false, false, false, false
+	}()
+	tests := []struct {
+		rebuild, fromSnapshot, pristine, noCache bool
+		wantErr                                  bool
+	}{
+		{false, false, false, false, false},
+		{true, false, false, false, false},
+		{true, true, false, false, false},
+		{true, false, true, false, false},
+		{true, false, false, true, false},
+		{false, true, false, false, true},
+		{false, false, true, false, true},
+		{false, false, false, true, true},
+	}
+	for _, tt := range tests {
+		rebuildFlag, rebuildFromSnapshotFlag, rebuildPristineFlag, rebuildNoCacheFlag = tt.rebuild, tt.fromSnapshot, tt.pristine, tt.noCache
+		if err := validateRebuildFlags(); (err != nil) != tt.wantErr {
+			t.Errorf("validateRebuildFlags() rebuild=%v from-snapshot=%v pristine=%v no-cache=%v error = %v, wantErr %v", tt.rebuild, tt.fromSnapshot, tt.pristine, tt.noCache, err, tt.wantErr)
+		}
+	}
+}
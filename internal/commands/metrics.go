@@ -0,0 +1,175 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+type metricsStore struct {
+	Results      map[string]map[string]map[string]int `json:"results"`
+	SetupSeconds map[string]float64                   `json:"setup_seconds"`
+}
+
+func metricsStorePath() string {
+	return filepath.Join(configManager.ConfigDir(), "metrics.json")
+}
+
+func loadMetricsStore() *metricsStore {
+	store := &metricsStore{}
+	if data, err := os.ReadFile(metricsStorePath()); err == nil {
+		_ = json.Unmarshal(data, store)
+	}
+	if store.Results == nil {
+		store.Results = map[string]map[string]map[string]int{}
+	}
+	if store.SetupSeconds == nil {
+		store.SetupSeconds = map[string]float64{}
+	}
+	return store
+}
+
+func updateMetricsStore(update func(*metricsStore)) {
+	if configManager == nil {
+		return
+	}
+	store := loadMetricsStore()
+	update(store)
+	if data, err := json.Marshal(store); err == nil {
+		_ = os.WriteFile(metricsStorePath(), data, 0644)
+	}
+}
+
+func resultLabel(err error) string {
+	if err != nil {
+		return "failure"
+	}
+	return "success"
+}
+
+func (s *metricsStore) count(kind, project string, err error) {
+	if s.Results[kind] == nil {
+		s.Results[kind] = map[string]map[string]int{}
+	}
+	if s.Results[kind][project] == nil {
+		s.Results[kind][project] = map[string]int{}
+	}
+	s.Results[kind][project][resultLabel(err)]++
+}
+
+func recordResult(kind, project string, err error) {
+	updateMetricsStore(func(s *metricsStore) { s.count(kind, project, err) })
+}
+
+func runTimedSetup(project string, run func() error) error {
+	start := time.Now()
+	err := run()
+	updateMetricsStore(func(s *metricsStore) {
+		s.count("setup", project, err)
+		if err == nil {
+			s.SetupSeconds[project] = time.Since(start).Seconds()
+		}
+	})
+	return err
+}
+
+func withResultMetric(kind string, run func(*cobra.Command, []string) error) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		err := run(cmd, args)
+		if len(args) > 0 {
+			if cfg, loadErr := configManager.Load(); loadErr == nil {
+				if _, ok := cfg.GetProject(args[0]); ok {
+					recordResult(kind, args[0], err)
+				}
+			}
+		}
+		return err
+	}
+}
+
+type metricSample struct {
+	labels map[string]string
+	value  float64
+}
+
+type metricFamily struct {
+	name    string
+	help    string
+	kind    string
+	samples []metricSample
+}
+
+func (f *metricFamily) add(value float64, labels ...string) {
+	m := map[string]string{}
+	for i := 0; i+1 < len(labels); i += 2 {
+		m[labels[i]] = labels[i+1]
+	}
+	f.samples = append(f.samples, metricSample{labels: m, value: value})
+}
+
+func escapeLabelValue(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
+func writeMetricFamilies(w io.Writer, families []*metricFamily) {
+	for _, f := range families {
+		fmt.Fprintf(w, "# HELP %s %s\n", f.name, f.help)
+		fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.kind)
+		for _, s := range f.samples {
+			keys := make([]string, 0, len(s.labels))
+			for k := range s.labels {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			parts := make([]string, 0, len(keys))
+			for _, k := range keys {
+				parts = append(parts, fmt.Sprintf(`%s="%s"`, k, escapeLabelValue(s.labels[k])))
+			}
+			labels := ""
+			if len(parts) > 0 {
+				labels = "{" + strings.Join(parts, ",") + "}"
+			}
+			fmt.Fprintf(w, "%s%s %g\n", f.name, labels, s.value)
+		}
+	}
+}
+
+func storeMetricFamilies(store *metricsStore) []*metricFamily {
+	setup := &metricFamily{name: "devbox_setup_duration_seconds", help: "Duration of the last successful setup command run for the project.", kind: "gauge"}
+	for _, project := range sortedMapKeys(store.SetupSeconds) {
+		setup.add(store.SetupSeconds[project], "project", project)
+	}
+	families := []*metricFamily{setup}
+	for _, kind := range []string{"setup", "apply", "verify"} {
+		f := &metricFamily{name: "devbox_" + kind + "_total", help: fmt.Sprintf("Number of devbox %s runs by result.", kind), kind: "counter"}
+		byProject := store.Results[kind]
+		projects := make([]string, 0, len(byProject))
+		for p := range byProject {
+			projects = append(projects, p)
+		}
+		sort.Strings(projects)
+		for _, p := range projects {
+			for _, result := range []string{"success", "failure"} {
+				f.add(float64(byProject[p][result]), "project", p, "result", result)
+			}
+		}
+		families = append(families, f)
+	}
+	return families
+}
+
+func sortedMapKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
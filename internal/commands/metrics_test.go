@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWriteMetricFamilies(t *testing.T) {
+	f := &metricFamily{name: "devbox_box_running", help: "Whether the box is running.", kind: "gauge"}
+	f.add(1, "project", "demo", "box", "devbox_demo")
+	f.add(0, "project", `we"ird\name`)
+	empty := &metricFamily{name: "devbox_projects", help: "Number of projects.", kind: "gauge"}
+	empty.add(2)
+
+	var buf bytes.Buffer
+	writeMetricFamilies(&buf, []*metricFamily{f, empty})
+	want := `# HELP devbox_box_running Whether the box is running.
+# TYPE devbox_box_running gauge
+devbox_box_running{box="devbox_demo",project="demo"} 1
+devbox_box_running{project="we\"ird\\name"} 0
+# HELP devbox_projects Number of projects.
+# TYPE devbox_projects gauge
+devbox_projects 2
+`
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestMetricsStoreRecording(t *testing.T) {
+	withTempConfigManager(t)
+
+	recordResult("verify", "demo", nil)
+	recordResult("verify", "demo", errors.New("drift"))
+	recordResult("verify", "demo", nil)
+	if err := runTimedSetup("demo", func() error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if err := runTimedSetup("demo", func() error { return errors.New("boom") }); err == nil {
+		t.Fatal("expected setup error to be returned")
+	}
+
+	store := loadMetricsStore()
+	if got := store.Results["verify"]["demo"]; got["success"] != 2 || got["failure"] != 1 {
+		t.Errorf("verify results = %v", got)
+	}
+	if got := store.Results["setup"]["demo"]; got["success"] != 1 || got["failure"] != 1 {
+		t.Errorf("setup results = %v", got)
+	}
+	if _, ok := store.SetupSeconds["demo"]; !ok {
+		t.Error("expected setup duration for demo")
+	}
+
+	var buf bytes.Buffer
+	writeMetricFamilies(&buf, storeMetricFamilies(store))
+	for _, line := range []string{
+		`devbox_verify_total{project="demo",result="success"} 2`,
+		`devbox_verify_total{project="demo",result="failure"} 1`,
+		`# TYPE devbox_apply_total counter`,
+		`devbox_setup_duration_seconds{project="demo"}`,
+	} {
+		if !strings.Contains(buf.String(), line) {
+			t.Errorf("metrics output missing %q:\n%s", line, buf.String())
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"devbox/internal/config"
+)
+
+const onboardMarker = "<!-- Generated by 'devbox onboard generate' from devbox.json. Edit devbox.json and re-run it instead of editing this file. -->"
+
+var (
+	onboardFileFlag   string
+	onboardStdoutFlag bool
+	onboardCheckFlag  bool
+)
+
+var onboardCmd = &cobra.Command{
+	Use:   "onboard",
+	Short: "Generate onboarding docs from devbox.json",
+	Args:  cobra.NoArgs,
+}
+
+var onboardGenerateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Write ONBOARDING.md for the current project",
+	Long: `Write a short ONBOARDING.md derived from the current folder's devbox.json: host
+prerequisites, how to start and enter the environment, published ports, services, hooks,
+and environment variables. Re-run it after changing devbox.json so the docs never drift.
+
+devbox only overwrites files it generated; pass --force to replace a hand-written file.
+With --check, nothing is written and devbox exits non-zero when the file is out of date,
+for use in CI.
+
+Examples:
+  devbox onboard generate
+  devbox onboard generate --stdout
+  devbox onboard generate --file docs/DEVELOPMENT.md
+  devbox onboard generate --check`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+		pc, err := configManager.LoadProjectConfig(cwd)
+		if err != nil {
+			return fmt.Errorf("failed to load project config: %w", err)
+		}
+		if pc == nil {
+			return fmt.Errorf("no project config found in %s (checked devbox.json, devbox.project.json, .devbox.json)", cwd)
+		}
+		doc := renderOnboarding(pc, filepath.Base(cwd))
+		if onboardStdoutFlag {
+			fmt.Print(doc)
+			return nil
+		}
+
+		path := onboardFileFlag
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(cwd, path)
+		}
+		existing, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if onboardCheckFlag {
+			if string(existing) != doc {
+				return fmt.Errorf("%s is out of date with devbox.json\nhint: run 'devbox onboard generate' and commit the result", onboardFileFlag)
+			}
+			fmt.Printf("%s is up to date\n", onboardFileFlag)
+			return nil
+		}
+		if len(existing) > 0 && !strings.HasPrefix(string(existing), onboardMarker) && !forceFlag {
+			return fmt.Errorf("%s exists and was not generated by devbox\nhint: pass --force to replace it, or --file to write somewhere else", onboardFileFlag)
+		}
+		if string(existing) == doc {
+			fmt.Printf("%s is up to date\n", onboardFileFlag)
+			return nil
+		}
+		if dir := filepath.Dir(path); dir != cwd {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return fmt.Errorf("failed to create %s: %w", dir, err)
+			}
+		}
+		if err := os.WriteFile(path, []byte(doc), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		fmt.Printf("Wrote %s\n", path)
+		return nil
+	},
+}
+
+func init() {
+	onboardGenerateCmd.Flags().StringVar(&onboardFileFlag, "file", "ONBOARDING.md", "File to write, relative to the project folder")
+	onboardGenerateCmd.Flags().BoolVar(&onboardStdoutFlag, "stdout", false, "Print the document instead of writing it")
+	onboardGenerateCmd.Flags().BoolVar(&onboardCheckFlag, "check", false, "Fail if the file is missing or differs from devbox.json, without writing it")
+	onboardGenerateCmd.Flags().BoolVarP(&forceFlag, "force", "f", false, "Overwrite a file that devbox did not generate")
+	onboardGenerateCmd.MarkFlagsMutuallyExclusive("stdout", "check")
+	onboardCmd.AddCommand(onboardGenerateCmd)
+	rootCmd.AddCommand(onboardCmd)
+}
+
+func renderOnboarding(pc *config.ProjectConfig, folder string) string {
+	name := firstNonEmpty(pc.Name, folder)
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n\n# %s development environment\n\n", onboardMarker, name)
+	fmt.Fprintf(&b, "This project's development environment is defined in `devbox.json` and runs in an isolated box managed by [devbox](https://devbox.ar0.eu).\n")
+
+	b.WriteString("\n## Prerequisites\n\n")
+	for _, p := range onboardPrerequisites(pc) {
+		fmt.Fprintf(&b, "- %s\n", p)
+	}
+
+	b.WriteString("\n## Getting started\n\n")
+	b.WriteString("From the project folder:\n\n```bash\n")
+	fmt.Fprintf(&b, "devbox up\ndevbox shell %s\n```\n\n", name)
+	b.WriteString("`devbox up` creates and starts the box, and `devbox shell` opens a shell in it.\n\n")
+	if pc.Build != nil {
+		contextDir, dockerfile := pc.Build.Paths(".")
+		fmt.Fprintf(&b, "- The image is built from `%s` (context `%s`) the first time\n", dockerfile, contextDir)
+	} else {
+		fmt.Fprintf(&b, "- Image: `%s`\n", firstNonEmpty(pc.BaseImage, "ubuntu:22.04"))
+	}
+	fmt.Fprintf(&b, "- The project folder is available in the box at `%s`\n", firstNonEmpty(pc.WorkingDir, "/workspace"))
+	if n := len(pc.SetupCommands); n > 0 {
+		fmt.Fprintf(&b, "- The first `devbox up` runs %d setup command(s), so it takes a while:\n", n)
+		for _, c := range pc.SetupCommands {
+			fmt.Fprintf(&b, "  - `%s`\n", c)
+		}
+	}
+	if pc.TTL != "" {
+		fmt.Fprintf(&b, "- The box stops %s after it starts (`ttl`)\n", pc.TTL)
+	}
+	b.WriteString("- Run `devbox explain up` to see every command `devbox up` would run\n")
+
+	if len(pc.Ports) > 0 {
+		b.WriteString("\n## Ports\n\n| Host | Box |\n|------|-----|\n")
+		for _, p := range pc.Ports {
+			host, box := onboardPort(p)
+			fmt.Fprintf(&b, "| %s | %s |\n", host, box)
+		}
+	}
+
+	if len(pc.Services) > 0 {
+		b.WriteString("\n## Services\n\n`devbox up` starts these containers next to the box. Reach them from the box by name, for example `")
+		names := onboardServiceNames(pc.Services)
+		b.WriteString(names[0] + "`.\n\n| Service | Image | Ports | Starts after |\n|---------|-------|-------|--------------|\n")
+		for _, n := range names {
+			svc := pc.Services[n]
+			fmt.Fprintf(&b, "| %s | `%s` | %s | %s |\n", n, svc.Image, onboardList(svc.Ports), onboardList(svc.DependsOn))
+		}
+	}
+
+	if len(pc.Hooks) > 0 {
+		b.WriteString("\n## Hooks\n\nThese run automatically; pass `--no-hooks` to skip them.\n\n")
+		for _, event := range config.HookEvents {
+			for _, h := range pc.Hooks[event] {
+				where := "host"
+				if h.InBox() {
+					where = "box"
+				}
+				fmt.Fprintf(&b, "- `%s` (%s): `%s`\n", event, where, h.Run)
+			}
+		}
+	}
+
+	if len(pc.Environment) > 0 {
+		b.WriteString("\n## Environment\n\nSet in the box by `devbox.json`: ")
+		var vars []string
+		for _, k := range sortedKeys(pc.Environment) {
+			vars = append(vars, "`"+k+"`")
+		}
+		b.WriteString(strings.Join(vars, ", ") + ".\n")
+	}
+
+	if len(pc.Secrets) > 0 {
+		fmt.Fprintf(&b, "\n## Secrets\n\nResolved on your machine when the box is created; `devbox secrets list %s` shows which are missing.\n\n", name)
+		secrets := make([]string, 0, len(pc.Secrets))
+		for secret := range pc.Secrets {
+			secrets = append(secrets, secret)
+		}
+		sort.Strings(secrets)
+		for _, secret := range secrets {
+			scheme, ref := pc.Secrets[secret].Source(secret)
+			switch scheme {
+			case config.SecretSourceStore:
+				fmt.Fprintf(&b, "- `%s`: run `devbox secrets set %s %s`\n", secret, name, ref)
+			case config.SecretSourceEnv:
+				fmt.Fprintf(&b, "- `%s`: export `%s` on the host\n", secret, ref)
+			default:
+				fmt.Fprintf(&b, "- `%s`: from `%s`\n", secret, pc.Secrets[secret].From)
+			}
+		}
+	}
+
+	if len(pc.Volumes) > 0 {
+		b.WriteString("\n## Mounts\n\n")
+		for _, v := range pc.Volumes {
+			fmt.Fprintf(&b, "- `%s`\n", v)
+		}
+	}
+
+	b.WriteString("\n## Day to day\n\n")
+	fmt.Fprintf(&b, "- `devbox stop %s` stops the box; `devbox up` starts it again\n", name)
+	fmt.Fprintf(&b, "- `devbox verify %s` checks the box against `devbox.lock.json`\n", name)
+	fmt.Fprintf(&b, "- `devbox destroy %s` removes the box; your files stay in this folder\n", name)
+	return b.String()
+}
+
+func onboardPrerequisites(pc *config.ProjectConfig) []string {
+	var out []string
+	out = append(out, "Debian or Ubuntu Linux with devbox installed")
+	if pc.KubernetesBackend() {
+		line := "Access to a Kubernetes cluster through kubectl"
+		if pc.Kubernetes != nil && pc.Kubernetes.Context != "" {
+			line += fmt.Sprintf(" (context `%s`)", pc.Kubernetes.Context)
+		}
+		return append(out, line)
+	}
+	out = append(out, "Docker (or Podman or nerdctl) that your user can run")
+	if pc.DockerHost != "" {
+		out = append(out, fmt.Sprintf("Access to the Docker host `%s`", pc.DockerHost))
+	}
+	if pc.Gpus != "" {
+		out = append(out, "An NVIDIA GPU with nvidia-container-toolkit on the Docker host")
+	}
+	if pc.Resources != nil && (pc.Resources.CPUs != "" || pc.Resources.Memory != "") {
+		var parts []string
+		if pc.Resources.CPUs != "" {
+			parts = append(parts, pc.Resources.CPUs+" CPUs")
+		}
+		if pc.Resources.Memory != "" {
+			parts = append(parts, pc.Resources.Memory+" of memory")
+		}
+		out = append(out, "Room for a box limited to "+strings.Join(parts, " and "))
+	}
+	var ports []string
+	for _, p := range pc.Ports {
+		if host, _ := onboardPort(p); host != "random" {
+			ports = append(ports, host)
+		}
+	}
+	for _, n := range onboardServiceNames(pc.Services) {
+		for _, p := range pc.Services[n].Ports {
+			if host, _ := onboardPort(p); host != "random" {
+				ports = append(ports, host)
+			}
+		}
+	}
+	if len(ports) > 0 {
+		out = append(out, "Free host ports: "+strings.Join(ports, ", "))
+	}
+	return out
+}
+
+func onboardPort(mapping string) (host, box string) {
+	mapping = strings.TrimSpace(mapping)
+	i := strings.LastIndex(mapping, ":")
+	if i == -1 {
+		return "random", mapping
+	}
+	return mapping[:i], mapping[i+1:]
+}
+
+func onboardList(values []string) string {
+	if len(values) == 0 {
+		return "-"
+	}
+	return strings.Join(values, ", ")
+}
+
+func onboardServiceNames(services map[string]*config.Service) []string {
+	names := make([]string, 0, len(services))
+	for name := range services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,67 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"devbox/internal/config"
+)
+
+func TestRenderOnboarding(t *testing.T) {
+	pc := &config.ProjectConfig{
+		Name:          "webapp",
+		BaseImage:     "node:20",
+		SetupCommands: []string{"npm ci"},
+		Environment:   map[string]string{"NODE_ENV": "development", "API_URL": "http://localhost:8080"},
+		Ports:         []string{"3000:3000", "127.0.0.1:9229:9229", "8080/tcp"},
+		Volumes:       []string{"~/.npm:/root/.npm"},
+		Gpus:          "all",
+		Services: map[string]*config.Service{
+			"db":    {Image: "postgres:16", Ports: []string{"5432:5432"}},
+			"cache": {Image: "redis:7", DependsOn: []string{"db"}},
+		},
+		Hooks: map[string][]config.Hook{
+			config.HookPostUp: {{Run: "npm run seed", In: config.HookInBox}},
+			config.HookPreUp:  {{Run: "make tunnel"}},
+		},
+	}
+	doc := renderOnboarding(pc, "ignored")
+	if !strings.HasPrefix(doc, onboardMarker+"\n\n# webapp development environment\n") {
+		t.Errorf("renderOnboarding() header = %q", doc[:120])
+	}
+	for _, want := range []string{
+		"- An NVIDIA GPU with nvidia-container-toolkit on the Docker host\n",
+		"- Free host ports: 3000, 127.0.0.1:9229, 5432\n",
+		"devbox up\ndevbox shell webapp\n",
+		"- Image: `node:20`\n",
+		"- The first `devbox up` runs 1 setup command(s), so it takes a while:\n  - `npm ci`\n",
+		"| 127.0.0.1:9229 | 9229 |\n| random | 8080/tcp |\n",
+		"for example `cache`.",
+		"| cache | `redis:7` | - | db |\n| db | `postgres:16` | 5432:5432 | - |\n",
+		"- `pre_up` (host): `make tunnel`\n- `post_up` (box): `npm run seed`\n",
+		"Set in the box by `devbox.json`: `API_URL`, `NODE_ENV`.\n",
+		"- `~/.npm:/root/.npm`\n",
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("renderOnboarding() is missing %q in:\n%s", want, doc)
+		}
+	}
+	if strings.Contains(doc, "http://localhost:8080") {
+		t.Error("renderOnboarding() printed an environment value")
+	}
+	if renderOnboarding(pc, "ignored") != doc {
+		t.Error("renderOnboarding() is not deterministic")
+	}
+
+	minimal := renderOnboarding(&config.ProjectConfig{Build: &config.Build{Dockerfile: ".devbox/Dockerfile"}}, "api")
+	for _, want := range []string{"# api development environment", "built from `.devbox/Dockerfile` (context `.`)", "devbox shell api\n"} {
+		if !strings.Contains(minimal, want) {
+			t.Errorf("renderOnboarding() minimal is missing %q in:\n%s", want, minimal)
+		}
+	}
+	for _, section := range []string{"## Ports", "## Services", "## Hooks", "## Environment", "## Mounts"} {
+		if strings.Contains(minimal, section) {
+			t.Errorf("renderOnboarding() minimal has an empty %s section", section)
+		}
+	}
+}
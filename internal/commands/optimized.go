@@ -1,10 +1,8 @@
 package commands
 
 import (
+	"encoding/json"
 	"fmt"
-	"os"
-	"path/filepath"
-	"strings"
 	"time"
 
 	"devbox/internal/config"
@@ -24,6 +22,7 @@ type DockerClientInterface interface {
 	SetupDevboxInBoxWithUpdate(boxName, projectName string) error
 	ExecuteSetupCommandsWithOutput(boxName string, commands []string, showOutput bool) error
 	QueryPackagesParallel(boxName string) (aptList, pipList, npmList, yarnList, pnpmList []string)
+	ExecCapture(boxName, command string) (string, string, error)
 }
 
 func NewOptimizedSetup(dockerClient DockerClientInterface, configManager *config.ConfigManager) *OptimizedSetup {
@@ -61,7 +60,7 @@ func (optSetup *OptimizedSetup) OptimizedSystemUpdate(boxName string) error {
 }
 
 func (optSetup *OptimizedSetup) FastInit(projectName string, projectConfig *config.ProjectConfig, cfg *config.Config, workspacePath string, forceFlag bool) error {
-	boxName := fmt.Sprintf("devbox_%s", projectName)
+	boxName := boxNameFor(cfg, projectName)
 	baseImage := cfg.GetEffectiveBaseImage(&config.Project{
 		Name:      projectName,
 		BaseImage: "ubuntu:22.04",
@@ -85,9 +84,10 @@ func (optSetup *OptimizedSetup) FastInit(projectName string, projectConfig *conf
 	}
 
 	fmt.Printf("Creating box...\n")
-	configMap := make(map[string]interface{})
+	var configMap map[string]interface{}
 	if projectConfig != nil {
-
+		data, _ := json.Marshal(projectConfig)
+		_ = json.Unmarshal(data, &configMap)
 	}
 
 	boxID, err := optSetup.dockerClient.CreateBoxWithConfig(boxName, baseImage, workspacePath, workspaceBox, configMap)
@@ -101,7 +101,7 @@ func (optSetup *OptimizedSetup) FastInit(projectName string, projectConfig *conf
 	}
 
 	fmt.Printf("Waiting for box to be ready...\n")
-	if err := optSetup.dockerClient.WaitForBox(boxName, 30*time.Second); err != nil {
+	if err := optSetup.dockerClient.WaitForBox(boxName, useProjectTimeouts(projectConfig).Startup); err != nil {
 		return fmt.Errorf("box failed to start: %w", err)
 	}
 
@@ -119,7 +119,7 @@ func (optSetup *OptimizedSetup) FastInit(projectName string, projectConfig *conf
 		},
 	}
 
-	workerPool := parallel.NewWorkerPool(2, 10*time.Minute)
+	workerPool := parallel.NewWorkerPool(2, parallel.LoadConfig().SetupTimeout)
 	results := workerPool.Execute(setupTasks)
 
 	for i, err := range results {
@@ -130,7 +130,9 @@ func (optSetup *OptimizedSetup) FastInit(projectName string, projectConfig *conf
 
 	if projectConfig != nil && len(projectConfig.SetupCommands) > 0 {
 		fmt.Printf("Installing packages (%d commands)...\n", len(projectConfig.SetupCommands))
-		if err := optSetup.dockerClient.ExecuteSetupCommandsWithOutput(boxName, projectConfig.SetupCommands, false); err != nil {
+		if err := runTimedSetup(projectName, func() error {
+			return runSetupSteps(optSetup.dockerClient, boxName, projectConfig.SetupCommands, false)
+		}); err != nil {
 			return fmt.Errorf("failed to execute setup commands: %w", err)
 		}
 
@@ -140,14 +142,9 @@ func (optSetup *OptimizedSetup) FastInit(projectName string, projectConfig *conf
 	return nil
 }
 
-func (optSetup *OptimizedSetup) FastUp(projectConfig *config.ProjectConfig, projectName, boxName, baseImage, cwd, workspaceBox string) error {
+func (optSetup *OptimizedSetup) FastUp(projectConfig *config.ProjectConfig, configMap map[string]interface{}, projectName, boxName, baseImage, cwd, workspaceBox string) error {
 	fmt.Printf("Fast startup of environment...\n")
 
-	configMap := make(map[string]interface{})
-	if projectConfig != nil {
-
-	}
-
 	fmt.Printf("Creating optimized box...\n")
 	boxID, err := optSetup.dockerClient.CreateBoxWithConfig(boxName, baseImage, cwd, workspaceBox, configMap)
 	if err != nil {
@@ -159,7 +156,7 @@ func (optSetup *OptimizedSetup) FastUp(projectConfig *config.ProjectConfig, proj
 	}
 
 	fmt.Printf("Waiting for box startup...\n")
-	if err := optSetup.dockerClient.WaitForBox(boxName, 30*time.Second); err != nil {
+	if err := optSetup.dockerClient.WaitForBox(boxName, useProjectTimeouts(projectConfig).Startup); err != nil {
 		return fmt.Errorf("box failed to start: %w", err)
 	}
 
@@ -176,7 +173,7 @@ func (optSetup *OptimizedSetup) FastUp(projectConfig *config.ProjectConfig, proj
 		},
 	}
 
-	workerPool := parallel.NewWorkerPool(2, 10*time.Minute)
+	workerPool := parallel.NewWorkerPool(2, parallel.LoadConfig().SetupTimeout)
 	results := workerPool.Execute(setupTasks)
 
 	for i, err := range results {
@@ -185,48 +182,24 @@ func (optSetup *OptimizedSetup) FastUp(projectConfig *config.ProjectConfig, proj
 		}
 	}
 
-	lockfilePath := filepath.Join(cwd, "devbox.lock")
-	if _, err := os.Stat(lockfilePath); err == nil {
-		fmt.Printf("Processing lock file...\n")
-		if err := optSetup.processLockFile(boxName, lockfilePath); err != nil {
-			return fmt.Errorf("failed to process lock file: %w", err)
-		}
+	if err := optSetup.processLockFile(boxName, cwd); err != nil {
+		return fmt.Errorf("failed to process lock file: %w", err)
 	}
 
 	if projectConfig != nil && len(projectConfig.SetupCommands) > 0 {
 		fmt.Printf("Installing packages (%d commands)...\n", len(projectConfig.SetupCommands))
-		if err := optSetup.dockerClient.ExecuteSetupCommandsWithOutput(boxName, projectConfig.SetupCommands, false); err != nil {
+		if err := runTimedSetup(projectName, func() error {
+			return runSetupSteps(optSetup.dockerClient, boxName, projectConfig.SetupCommands, false)
+		}); err != nil {
 			return fmt.Errorf("failed to execute setup commands: %w", err)
 		}
-
-		_ = WriteLockFileForBox(boxName, projectName, cwd, baseImage, "")
 	}
 
 	return nil
 }
 
-func (optSetup *OptimizedSetup) processLockFile(boxName, lockfilePath string) error {
-	data, err := os.ReadFile(lockfilePath)
-	if err != nil {
-		return err
-	}
-
-	lines := strings.Split(string(data), "\n")
-	var cmds []string
-	for _, line := range lines {
-		cmd := strings.TrimSpace(line)
-		if cmd == "" || strings.HasPrefix(cmd, "#") {
-			continue
-		}
-		cmds = append(cmds, cmd)
-	}
-
-	if len(cmds) > 0 {
-		fmt.Printf("Replaying %d commands from lock file...\n", len(cmds))
-		return optSetup.dockerClient.ExecuteSetupCommandsWithOutput(boxName, cmds, false)
-	}
-
-	return nil
+func (optSetup *OptimizedSetup) processLockFile(boxName, workspacePath string) error {
+	return replayLegacyLock(optSetup.dockerClient, boxName, workspacePath)
 }
 
 func (optSetup *OptimizedSetup) PrewarmImage(image string) error {
@@ -0,0 +1,187 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+var outputFormatFlags = map[*cobra.Command]*string{}
+
+func addOutputFlag(cmd *cobra.Command, target *string) {
+	cmd.Flags().StringVarP(target, "output", "o", outputTable, "Output format: table, json or yaml")
+	outputFormatFlags[cmd] = target
+}
+
+func structuredOutput(cmd *cobra.Command) bool {
+	format, ok := outputFormatFlags[cmd]
+	return ok && (*format == outputJSON || *format == outputYAML)
+}
+
+func validateOutputFormat(format string) error {
+	switch format {
+	case outputTable, outputJSON, outputYAML:
+		return nil
+	}
+	return fmt.Errorf("invalid --output '%s' (expected table, json or yaml)", format)
+}
+
+func writeStructured(w io.Writer, format string, v interface{}) error {
+	if format == outputYAML {
+		data, err := encodeYAML(v)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+type yamlField struct {
+	key   string
+	value interface{}
+}
+
+type yamlMap []yamlField
+
+func encodeYAML(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	node, err := decodeOrdered(dec)
+	if err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	writeYAML(&b, node, 0)
+	return []byte(b.String()), nil
+}
+
+func decodeOrdered(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		m := yamlMap{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			m = append(m, yamlField{key: key.(string), value: value})
+		}
+		_, err := dec.Token()
+		return m, err
+	case json.Delim('['):
+		list := []interface{}{}
+		for dec.More() {
+			value, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		_, err := dec.Token()
+		return list, err
+	}
+	return tok, nil
+}
+
+func yamlBlock(v interface{}) bool {
+	switch n := v.(type) {
+	case yamlMap:
+		return len(n) > 0
+	case []interface{}:
+		return len(n) > 0
+	}
+	return false
+}
+
+func writeYAML(b *strings.Builder, v interface{}, indent int) {
+	pad := strings.Repeat(" ", indent)
+	switch n := v.(type) {
+	case yamlMap:
+		if len(n) == 0 {
+			b.WriteString(pad + "{}\n")
+		}
+		for _, f := range n {
+			if yamlBlock(f.value) {
+				fmt.Fprintf(b, "%s%s:\n", pad, yamlScalar(f.key))
+				writeYAML(b, f.value, indent+2)
+			} else {
+				fmt.Fprintf(b, "%s%s: %s\n", pad, yamlScalar(f.key), yamlInline(f.value))
+			}
+		}
+	case []interface{}:
+		if len(n) == 0 {
+			b.WriteString(pad + "[]\n")
+		}
+		for _, item := range n {
+			if !yamlBlock(item) {
+				fmt.Fprintf(b, "%s- %s\n", pad, yamlInline(item))
+				continue
+			}
+			var nested strings.Builder
+			writeYAML(&nested, item, indent+2)
+			b.WriteString(pad + "- " + strings.TrimPrefix(nested.String(), pad+"  "))
+		}
+	default:
+		b.WriteString(pad + yamlInline(v) + "\n")
+	}
+}
+
+func yamlInline(v interface{}) string {
+	switch n := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(n)
+	case json.Number:
+		return n.String()
+	case string:
+		return yamlScalar(n)
+	case yamlMap:
+		return "{}"
+	case []interface{}:
+		return "[]"
+	}
+	return fmt.Sprint(v)
+}
+
+func yamlScalar(s string) string {
+	switch strings.ToLower(s) {
+	case "", "null", "~", "true", "false", "yes", "no", "on", "off", "y", "n":
+		return strconv.Quote(s)
+	}
+	if s[0] >= '0' && s[0] <= '9' || s[0] == '.' || s[0] == '+' {
+		return strconv.Quote(s)
+	}
+	if strings.TrimSpace(s) != s || strings.ContainsAny(s, "\n\t\"\\") || strings.Contains(s, ": ") || strings.Contains(s, " #") ||
+		strings.HasSuffix(s, ":") || strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'%@`") {
+		return strconv.Quote(s)
+	}
+	return s
+}
@@ -0,0 +1,98 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncodeYAML(t *testing.T) {
+	checked := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	doc := struct {
+		Projects []listEntry    `json:"projects"`
+		Empty    []string       `json:"empty"`
+		Nested   [][]string     `json:"nested"`
+		Meta     map[string]int `json:"meta"`
+	}{
+		Projects: []listEntry{{
+			Project:      "web",
+			Box:          "devbox_web",
+			Status:       "running",
+			Health:       "none",
+			Drift:        "drifted",
+			DriftDetails: []string{"pip: missing requests", "yes"},
+			Config:       "devbox.json",
+			Workspace:    "/home/me/web app",
+			ExpiresAt:    &checked,
+			Ports:        []string{"8080:80", "3000"},
+		}},
+		Empty:  []string{},
+		Nested: [][]string{{"a", "b"}},
+		Meta:   map[string]int{},
+	}
+	got, err := encodeYAML(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `projects:
+  - project: web
+    box: devbox_web
+    status: running
+    health: none
+    drift: drifted
+    drift_details:
+      - "pip: missing requests"
+      - "yes"
+    config: devbox.json
+    workspace: /home/me/web app
+    expires_at: "2026-01-02T03:04:05Z"
+    ports:
+      - "8080:80"
+      - "3000"
+empty: []
+nested:
+  - - a
+    - b
+meta: {}
+`
+	if string(got) != want {
+		t.Errorf("encodeYAML() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestYAMLScalar(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain", "plain"},
+		{"", `""`},
+		{"true", `"true"`},
+		{"No", `"No"`},
+		{"1.5", `"1.5"`},
+		{"-v", `"-v"`},
+		{"# note", `"# note"`},
+		{"a: b", `"a: b"`},
+		{"trailing ", `"trailing "`},
+		{"line\nbreak", `"line\nbreak"`},
+		{"key:", `"key:"`},
+		{"x=1", "x=1"},
+		{"8080:80", `"8080:80"`},
+		{".inf", `".inf"`},
+	}
+	for _, tt := range tests {
+		if got := yamlScalar(tt.in); got != tt.want {
+			t.Errorf("yamlScalar(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestValidateOutputFormat(t *testing.T) {
+	for _, f := range []string{"table", "json", "yaml"} {
+		if err := validateOutputFormat(f); err != nil {
+			t.Errorf("validateOutputFormat(%q) error = %v", f, err)
+		}
+	}
+	if err := validateOutputFormat("xml"); err == nil || !strings.Contains(err.Error(), "xml") {
+		t.Errorf("validateOutputFormat(xml) error = %v", err)
+	}
+}
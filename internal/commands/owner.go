@@ -0,0 +1,105 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+
+	"devbox/internal/config"
+	"devbox/internal/docker"
+)
+
+var allUsersFlag bool
+
+func currentUserName() string {
+	name := ""
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	if name == "" {
+		name = os.Getenv("USER")
+	}
+	return sanitizeBoxNamePart(name)
+}
+
+func sanitizeBoxNamePart(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_', r == '-', r == '.':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('-')
+		}
+	}
+	return strings.Trim(b.String(), "-.")
+}
+
+func boxNameFor(cfg *config.Config, projectName string) string {
+	if cfg != nil && cfg.Settings != nil && cfg.Settings.UserBoxPrefix {
+		if u := currentUserName(); u != "" {
+			return fmt.Sprintf("devbox_%s_%s", u, projectName)
+		}
+	}
+	return fmt.Sprintf("devbox_%s", projectName)
+}
+
+func projectNameForBox(cfg *config.Config, boxName string) string {
+	if cfg != nil {
+		for _, p := range cfg.GetProjects() {
+			if p.BoxName == boxName {
+				return p.Name
+			}
+		}
+	}
+	name := strings.TrimPrefix(boxName, "devbox_")
+	if cfg != nil && cfg.Settings != nil && cfg.Settings.UserBoxPrefix {
+		if u := currentUserName(); u != "" {
+			name = strings.TrimPrefix(name, u+"_")
+		}
+	}
+	return name
+}
+
+func ownerName(uid string) string {
+	if uid == "" {
+		return "-"
+	}
+	if u, err := user.LookupId(uid); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return "uid " + uid
+}
+
+func filterOwnedBoxes(boxes []docker.BoxInfo, owner string, allUsers bool) ([]docker.BoxInfo, int) {
+	if allUsers {
+		return boxes, 0
+	}
+	var owned []docker.BoxInfo
+	skipped := 0
+	for _, box := range boxes {
+		if box.Owner == owner {
+			owned = append(owned, box)
+		} else {
+			skipped++
+		}
+	}
+	return owned, skipped
+}
+
+func ownedBoxes(boxes []docker.BoxInfo) []docker.BoxInfo {
+	owned, skipped := filterOwnedBoxes(boxes, docker.CurrentOwner(), allUsersFlag)
+	if skipped > 0 {
+		fmt.Printf("Skipping %d box(es) owned by other users or without an owner label (use --all-users to include them)\n", skipped)
+	}
+	return owned
+}
+
+func checkBoxOwnership(boxName string) error {
+	owner, err := dockerClient.GetBoxOwner(boxName)
+	if err != nil || owner == "" || owner == docker.CurrentOwner() {
+		return nil
+	}
+	return fmt.Errorf("box '%s' belongs to another user (%s); enable settings.user_box_prefix to namespace box names per user", boxName, ownerName(owner))
+}
@@ -0,0 +1,98 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+
+	"devbox/internal/config"
+	"devbox/internal/docker"
+)
+
+func TestSanitizeBoxNamePart(t *testing.T) {
+	tests := map[string]string{
+		"alice":        "alice",
+		"Bob.Smith":    "bob.smith",
+		`CORP\jdoe`:    "corp-jdoe",
+		"user@example": "user-example",
+		".hidden-":     "hidden",
+		"":             "",
+	}
+	for in, want := range tests {
+		if got := sanitizeBoxNamePart(in); got != want {
+			t.Errorf("sanitizeBoxNamePart(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestBoxNameFor(t *testing.T) {
+	cfg := &config.Config{Settings: &config.GlobalSettings{}}
+	if got := boxNameFor(cfg, "demo"); got != "devbox_demo" {
+		t.Errorf("boxNameFor() = %q, want devbox_demo", got)
+	}
+	cfg.Settings.UserBoxPrefix = true
+	user := currentUserName()
+	if user == "" {
+		t.Skip("no current user name available")
+	}
+	if got, want := boxNameFor(cfg, "demo"), "devbox_"+user+"_demo"; got != want {
+		t.Errorf("boxNameFor() = %q, want %q", got, want)
+	}
+}
+
+func TestFilterOwnedBoxes(t *testing.T) {
+	boxes := []docker.BoxInfo{
+		{Names: []string{"devbox_a"}, Owner: "1000"},
+		{Names: []string{"devbox_b"}, Owner: "1001"},
+		{Names: []string{"devbox_c"}},
+	}
+
+	owned, skipped := filterOwnedBoxes(boxes, "1000", false)
+	if len(owned) != 1 || owned[0].Names[0] != "devbox_a" || skipped != 2 {
+		t.Errorf("filterOwnedBoxes() = %v, %d; want [devbox_a], 2", owned, skipped)
+	}
+
+	owned, skipped = filterOwnedBoxes(boxes, "1000", true)
+	if len(owned) != 3 || skipped != 0 {
+		t.Errorf("filterOwnedBoxes(allUsers) = %v, %d; want all 3, 0", owned, skipped)
+	}
+}
+
+func TestReadOnlyCandidates(t *testing.T) {
+	boxes := []docker.BoxInfo{
+		{Names: []string{"devbox_api"}},
+		{Names: []string{"devbox_alice_api"}},
+		{Names: []string{"devbox_bob_api"}},
+		{Names: []string{"devbox_bob_web"}},
+		{Names: []string{"postgres"}},
+	}
+	if got := readOnlyCandidates(boxes, "api", "alice"); !reflect.DeepEqual(got, []string{"devbox_alice_api"}) {
+		t.Errorf("with owner = %v", got)
+	}
+	if got := readOnlyCandidates(boxes, "api", ""); !reflect.DeepEqual(got, []string{"devbox_alice_api", "devbox_api", "devbox_bob_api"}) {
+		t.Errorf("without owner = %v", got)
+	}
+	if got := readOnlyCandidates(boxes, "web", "carol"); len(got) != 0 {
+		t.Errorf("unknown owner = %v", got)
+	}
+}
+
+func TestProjectNameForBox(t *testing.T) {
+	cfg := &config.Config{
+		Settings: &config.GlobalSettings{},
+		Projects: map[string]*config.Project{"web": {Name: "web", BoxName: "custom-web"}},
+	}
+	if got := projectNameForBox(cfg, "custom-web"); got != "web" {
+		t.Errorf("projectNameForBox(custom-web) = %q, want web", got)
+	}
+	if got := projectNameForBox(cfg, "devbox_api"); got != "api" {
+		t.Errorf("projectNameForBox(devbox_api) = %q, want api", got)
+	}
+	cfg.Settings.UserBoxPrefix = true
+	user := currentUserName()
+	if user == "" {
+		t.Skip("no current user name available")
+	}
+	if got := projectNameForBox(cfg, boxNameFor(cfg, "api")); got != "api" {
+		t.Errorf("projectNameForBox(%s) = %q, want api", boxNameFor(cfg, "api"), got)
+	}
+}
@@ -0,0 +1,147 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"devbox/internal/config"
+	"devbox/internal/docker"
+)
+
+const packageSnapshotTTL = 2 * time.Minute
+
+var noCacheFlag bool
+
+type packageSnapshot struct {
+	StartedAt  string         `json:"started_at"`
+	Marker     string         `json:"marker"`
+	CapturedAt time.Time      `json:"captured_at"`
+	Packages   lockPackages   `json:"packages"`
+	Registries lockRegistries `json:"registries"`
+	AptSources lockAptSources `json:"apt_sources"`
+}
+
+func packageSnapshotPath(boxName string) string {
+	return filepath.Join(configManager.ConfigDir(), "cache", "packages", boxName+".json")
+}
+
+func (s *packageSnapshot) validFor(startedAt, marker string, now time.Time) bool {
+	if s == nil || startedAt == "" || s.StartedAt != startedAt || s.Marker != marker {
+		return false
+	}
+	age := now.Sub(s.CapturedAt)
+	return age >= 0 && age < packageSnapshotTTL
+}
+
+func readPackageSnapshot(boxName string) *packageSnapshot {
+	data, err := os.ReadFile(packageSnapshotPath(boxName))
+	if err != nil {
+		return nil
+	}
+	var s packageSnapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil
+	}
+	return &s
+}
+
+func writePackageSnapshot(boxName string, s *packageSnapshot) {
+	path := packageSnapshotPath(boxName)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
+	}
+	if data, err := json.Marshal(s); err == nil {
+		_ = os.WriteFile(path, data, 0644)
+	}
+}
+
+func dropPackageSnapshot(boxName string) {
+	_ = os.Remove(packageSnapshotPath(boxName))
+}
+
+func readPackageMarker(boxName string) string {
+	out, _, err := dockerClient.ExecCapture(boxName, "cat "+docker.PackageMarkerPath+" 2>/dev/null || true")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(out)
+}
+
+func cachedPackageSnapshot(boxName string) (cached *packageSnapshot, startedAt, marker string) {
+	startedAt, _ = dockerClient.GetStartedAt(boxName)
+	marker = readPackageMarker(boxName)
+	if !noCacheFlag {
+		if cached := readPackageSnapshot(boxName); cached.validFor(startedAt, marker, time.Now()) {
+			fmt.Printf("Using package snapshot from %s ago (--no-cache to re-query)\n", time.Since(cached.CapturedAt).Round(time.Second))
+			return cached, startedAt, marker
+		}
+	}
+	return nil, startedAt, marker
+}
+
+func loadPackageSnapshot(boxName string) *packageSnapshot {
+	cached, startedAt, marker := cachedPackageSnapshot(boxName)
+	if cached != nil {
+		return cached
+	}
+
+	s := &packageSnapshot{StartedAt: startedAt, Marker: marker, CapturedAt: time.Now()}
+	fmt.Printf("Gathering package information in parallel...\n")
+	s.Packages.Apt, s.Packages.Pip, s.Packages.Npm, s.Packages.Yarn, s.Packages.Pnpm = dockerClient.QueryPackagesParallel(boxName)
+	s.Packages.Extra = queryPluginPackages(boxName, loadExtraPackageManagers())
+	s.AptSources.SnapshotURL, s.AptSources.SourcesLists, s.AptSources.PinnedRelease = dockerClient.GetAptSources(boxName)
+	s.Registries.PipIndexURL, s.Registries.PipExtraIndex = dockerClient.GetPipRegistries(boxName)
+	s.Registries.NpmRegistry, s.Registries.YarnRegistry, s.Registries.PnpmRegistry = dockerClient.GetNodeRegistries(boxName)
+	if startedAt != "" {
+		writePackageSnapshot(boxName, s)
+	}
+	return s
+}
+
+func loadScopedSnapshot(boxName string, scope verifyScope) *packageSnapshot {
+	if scope.full() {
+		return loadPackageSnapshot(boxName)
+	}
+	if !scope.Packages && !scope.Registries && !scope.Sources {
+		return &packageSnapshot{}
+	}
+	if cached, _, _ := cachedPackageSnapshot(boxName); cached != nil {
+		return cached
+	}
+
+	s := &packageSnapshot{CapturedAt: time.Now()}
+	if scope.Packages {
+		fmt.Printf("Gathering %s package information...\n", strings.Join(scope.managerNames(), ", "))
+		var builtin []string
+		for _, m := range builtinPackageManagers {
+			if scope.wantsManager(m) {
+				builtin = append(builtin, m)
+			}
+		}
+		lists := dockerClient.QueryPackageLists(boxName, builtin)
+		s.Packages.Apt, s.Packages.Pip, s.Packages.Npm, s.Packages.Yarn, s.Packages.Pnpm = lists["apt"], lists["pip"], lists["npm"], lists["yarn"], lists["pnpm"]
+		var plugins []config.PluginPackageManager
+		for _, pm := range loadExtraPackageManagers() {
+			if scope.wantsManager(pm.Name) {
+				plugins = append(plugins, pm)
+			}
+		}
+		s.Packages.Extra = queryPluginPackages(boxName, plugins)
+	}
+	if scope.Sources {
+		s.AptSources.SnapshotURL, s.AptSources.SourcesLists, s.AptSources.PinnedRelease = dockerClient.GetAptSources(boxName)
+	}
+	if scope.Registries {
+		if scope.wantsManager("pip") {
+			s.Registries.PipIndexURL, s.Registries.PipExtraIndex = dockerClient.GetPipRegistries(boxName)
+		}
+		if scope.wantsManager("npm") || scope.wantsManager("yarn") || scope.wantsManager("pnpm") {
+			s.Registries.NpmRegistry, s.Registries.YarnRegistry, s.Registries.PnpmRegistry = dockerClient.GetNodeRegistries(boxName)
+		}
+	}
+	return s
+}
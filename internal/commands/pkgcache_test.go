@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPackageSnapshotValidFor(t *testing.T) {
+	now := time.Now()
+	snap := &packageSnapshot{StartedAt: "2026-01-01T00:00:00Z", Marker: "123", CapturedAt: now.Add(-30 * time.Second)}
+	tests := []struct {
+		name      string
+		snap      *packageSnapshot
+		startedAt string
+		marker    string
+		want      bool
+	}{
+		{"fresh", snap, "2026-01-01T00:00:00Z", "123", true},
+		{"restarted", snap, "2026-01-02T00:00:00Z", "123", false},
+		{"packages changed in box", snap, "2026-01-01T00:00:00Z", "456", false},
+		{"unknown start time", &packageSnapshot{CapturedAt: now}, "", "", false},
+		{"expired", &packageSnapshot{StartedAt: "s", CapturedAt: now.Add(-packageSnapshotTTL)}, "s", "", false},
+		{"from the future", &packageSnapshot{StartedAt: "s", CapturedAt: now.Add(time.Hour)}, "s", "", false},
+		{"missing", nil, "s", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.snap.validFor(tt.startedAt, tt.marker, now); got != tt.want {
+				t.Errorf("validFor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPackageSnapshotRoundTrip(t *testing.T) {
+	withTempConfigManager(t)
+
+	if readPackageSnapshot("devbox_demo") != nil {
+		t.Fatal("expected no cached snapshot")
+	}
+	snap := &packageSnapshot{
+		StartedAt:  "2026-01-01T00:00:00Z",
+		CapturedAt: time.Now(),
+		Packages:   lockPackages{Apt: []string{"curl=8.5.0"}, Pip: []string{"requests==2.31.0"}},
+		Registries: lockRegistries{NpmRegistry: "https://registry.npmjs.org/"},
+	}
+	writePackageSnapshot("devbox_demo", snap)
+	got := readPackageSnapshot("devbox_demo")
+	if got == nil || len(got.Packages.Apt) != 1 || got.Registries.NpmRegistry != snap.Registries.NpmRegistry {
+		t.Fatalf("readPackageSnapshot() = %+v", got)
+	}
+	dropPackageSnapshot("devbox_demo")
+	if readPackageSnapshot("devbox_demo") != nil {
+		t.Error("snapshot should be gone after drop")
+	}
+}
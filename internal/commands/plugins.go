@@ -0,0 +1,238 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"devbox/internal/config"
+)
+
+const pluginPrefix = "devbox-"
+
+var pluginCmd = &cobra.Command{
+	Use:   "plugin",
+	Short: "Inspect installed devbox plugins",
+}
+
+var pluginListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List plugin commands on PATH and extra package managers",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		commands := findPluginExecutables(filepath.SplitList(os.Getenv("PATH")))
+		fmt.Printf("Plugin commands:\n")
+		if len(commands) == 0 {
+			fmt.Printf("  (none; add an executable named %s<name> to PATH)\n", pluginPrefix)
+		}
+		for _, name := range sortedKeys(commands) {
+			shadowed := ""
+			if isBuiltinCommand(name) {
+				shadowed = " (shadowed by built-in command)"
+			}
+			fmt.Printf("  devbox %-14s %s%s\n", name, commands[name], shadowed)
+		}
+
+		manifests, err := configManager.LoadPlugins()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("\nPackage managers (%s):\n", configManager.PluginsDir())
+		plugins := map[string]bool{}
+		for _, pm := range config.PluginPackageManagers(manifests) {
+			plugins[pm.Name] = true
+		}
+		bundled := map[string]bool{}
+		for _, pm := range config.BundledPackageManagers {
+			bundled[pm.Name] = true
+		}
+		for _, pm := range config.WithBundledPackageManagers(config.PluginPackageManagers(manifests)) {
+			mode := "tracked"
+			if pm.Install != "" {
+				mode = "tracked, reconciled by apply"
+			}
+			switch {
+			case !plugins[pm.Name]:
+				mode += " (bundled)"
+			case bundled[pm.Name]:
+				mode += " (replaces bundled)"
+			}
+			fmt.Printf("  %-16s %s\n", pm.Name, mode)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(pluginCmd)
+	pluginCmd.AddCommand(pluginListCmd)
+}
+
+func isBuiltinCommand(name string) bool {
+	for _, c := range rootCmd.Commands() {
+		if c.Name() == name || c.HasAlias(name) {
+			return true
+		}
+	}
+	return name == "help"
+}
+
+func findPluginExecutables(dirs []string) map[string]string {
+	found := map[string]string{}
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			name := e.Name()
+			if e.IsDir() || !strings.HasPrefix(name, pluginPrefix) || len(name) == len(pluginPrefix) {
+				continue
+			}
+			plugin := strings.TrimPrefix(name, pluginPrefix)
+			if _, ok := found[plugin]; ok {
+				continue
+			}
+			info, err := e.Info()
+			if err != nil || info.Mode()&0111 == 0 {
+				continue
+			}
+			found[plugin] = filepath.Join(dir, name)
+		}
+	}
+	return found
+}
+
+func pluginCommandFor(args []string) (string, bool) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") || isBuiltinCommand(args[0]) {
+		return "", false
+	}
+	path, err := exec.LookPath(pluginPrefix + args[0])
+	if err != nil {
+		return "", false
+	}
+	return path, true
+}
+
+func runPluginCommand(path, name string, args []string) error {
+	cmd := exec.Command(path, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = os.Environ()
+	if exe, err := os.Executable(); err == nil {
+		cmd.Env = append(cmd.Env, "DEVBOX_BIN="+exe)
+	}
+	if cm, err := config.NewConfigManager(); err == nil {
+		cmd.Env = append(cmd.Env, "DEVBOX_CONFIG_DIR="+cm.ConfigDir())
+	}
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return fmt.Errorf("plugin '%s' exited with status %d", name, exitErr.ExitCode())
+		}
+		return fmt.Errorf("failed to run plugin '%s': %w", name, err)
+	}
+	return nil
+}
+
+func loadExtraPackageManagers() []config.PluginPackageManager {
+	manifests, err := configManager.LoadPlugins()
+	if err != nil {
+		fmt.Printf("Warning: ignoring plugin package managers: %v\n", err)
+		return config.WithBundledPackageManagers(nil)
+	}
+	return config.WithBundledPackageManagers(config.PluginPackageManagers(manifests))
+}
+
+func queryPluginPackages(boxName string, managers []config.PluginPackageManager) map[string][]string {
+	if len(managers) == 0 {
+		return nil
+	}
+	out := map[string][]string{}
+	for _, pm := range managers {
+		stdout, _, err := dockerClient.ExecCapture(boxName, pm.List)
+		if err != nil {
+			out[pm.Name] = nil
+			continue
+		}
+		var pkgs []string
+		for _, line := range strings.Split(stdout, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				pkgs = append(pkgs, line)
+			}
+		}
+		sort.Strings(pkgs)
+		out[pm.Name] = pkgs
+	}
+	return out
+}
+
+func expandPluginTemplate(tpl, name, version string) string {
+	return strings.NewReplacer(
+		"{name}", "'"+escapeBash(name)+"'",
+		"{version}", "'"+escapeBash(version)+"'",
+	).Replace(tpl)
+}
+
+func pluginReconcile(managers []config.PluginPackageManager, locked, current map[string][]string) (cmds []string, installs, removals int) {
+	for _, pm := range managers {
+		lockedList, ok := locked[pm.Name]
+		if !ok {
+			continue
+		}
+		lockM := parseMap(lockedList, pm.Separator)
+		curM := parseMap(current[pm.Name], pm.Separator)
+		for _, name := range sortedKeys(lockM) {
+			if curVer, ok := curM[name]; ok && curVer == lockM[name] {
+				continue
+			}
+			installs++
+			if pm.Install != "" {
+				cmds = append(cmds, expandPluginTemplate(pm.Install, name, lockM[name]))
+			}
+		}
+		extras := keysNotIn(curM, lockM)
+		sort.Strings(extras)
+		for _, name := range extras {
+			removals++
+			if pm.Remove != "" {
+				cmds = append(cmds, expandPluginTemplate(pm.Remove, name, curM[name]))
+			}
+		}
+	}
+	return cmds, installs, removals
+}
+
+func untrackedPluginManagers(managers []config.PluginPackageManager, locked map[string][]string) []string {
+	known := map[string]bool{}
+	for _, pm := range managers {
+		known[pm.Name] = true
+	}
+	var missing []string
+	for name := range locked {
+		if !known[name] {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+func sortedExtraKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
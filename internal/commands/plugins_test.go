@@ -0,0 +1,115 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"devbox/internal/config"
+)
+
+func TestFindPluginExecutables(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	files := []struct {
+		dir, name string
+		mode      os.FileMode
+	}{
+		{first, "devbox-terraform", 0755},
+		{first, "devbox-notes", 0644},
+		{first, "devbox-", 0755},
+		{first, "terraform", 0755},
+		{second, "devbox-terraform", 0755},
+		{second, "devbox-k8s", 0755},
+	}
+	for _, f := range files {
+		if err := os.WriteFile(filepath.Join(f.dir, f.name), []byte("#!/bin/sh\n"), f.mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got := findPluginExecutables([]string{first, "", filepath.Join(first, "missing"), second})
+	want := map[string]string{
+		"terraform": filepath.Join(first, "devbox-terraform"),
+		"k8s":       filepath.Join(second, "devbox-k8s"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findPluginExecutables() = %v, want %v", got, want)
+	}
+}
+
+func TestPluginCommandFor(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"devbox-terraform", "devbox-list"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir)
+
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{[]string{"terraform", "plan"}, true},
+		{[]string{"list"}, false},
+		{[]string{"--help"}, false},
+		{[]string{"missing"}, false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if _, got := pluginCommandFor(tt.args); got != tt.want {
+			t.Errorf("pluginCommandFor(%v) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestPluginReconcile(t *testing.T) {
+	managers := []config.PluginPackageManager{
+		{Name: "cargo", List: "cargo install --list", Install: "cargo install {name} --version {version}", Remove: "cargo uninstall {name}", Separator: "@"},
+		{Name: "gem", List: "gem list", Separator: "="},
+	}
+	locked := map[string][]string{
+		"cargo": {"ripgrep@14.1.0", "bat@0.24.0"},
+		"gem":   {"rake=13.0"},
+		"brew":  {"jq@1.7"},
+	}
+	current := map[string][]string{
+		"cargo": {"ripgrep@14.0.0", "fd-find@9.0.0"},
+		"gem":   {"rake=13.0", "rails=7.1"},
+	}
+
+	cmds, installs, removals := pluginReconcile(managers, locked, current)
+	want := []string{
+		"cargo install 'bat' --version '0.24.0'",
+		"cargo install 'ripgrep' --version '14.1.0'",
+		"cargo uninstall 'fd-find'",
+	}
+	if !reflect.DeepEqual(cmds, want) {
+		t.Errorf("pluginReconcile() cmds = %v, want %v", cmds, want)
+	}
+	if installs != 2 || removals != 2 {
+		t.Errorf("pluginReconcile() = %d install(s), %d removal(s); want 2, 2", installs, removals)
+	}
+	if missing := untrackedPluginManagers(managers, locked); !reflect.DeepEqual(missing, []string{"brew"}) {
+		t.Errorf("untrackedPluginManagers() = %v, want [brew]", missing)
+	}
+}
+
+func TestSnapshotDriftPluginPackages(t *testing.T) {
+	lf := &verifyLockFile{Packages: lockPackages{Extra: map[string][]string{
+		"cargo": {"ripgrep@14.1.0"},
+		"brew":  {"jq@1.7"},
+	}}}
+	snapshot := &packageSnapshot{Packages: lockPackages{Extra: map[string][]string{
+		"cargo": {"ripgrep@14.0.0"},
+		"gem":   {"rake=13.0"},
+	}}}
+	want := []string{
+		"brew packages are locked but no plugin registers the 'brew' package manager",
+		"cargo packages drifted",
+		"gem packages drifted",
+	}
+	if got := snapshotDrift(lf, snapshot); !reflect.DeepEqual(got, want) {
+		t.Errorf("snapshotDrift() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,143 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"devbox/internal/config"
+)
+
+type imagePolicySource struct {
+	Name   string
+	Policy *config.ImagePolicy
+}
+
+var policyCmd = &cobra.Command{
+	Use:   "policy <command>",
+	Short: "Show the image policy that applies to new and rebuilt boxes",
+	Long: `Show or test the base image policy. Policies come from the system-wide
+/etc/devbox/policy.json (managed by administrators) and settings.image_policy
+in ~/.devbox/config.json. An image must pass every policy that is configured.
+
+Available commands:
+  show              Show the active allow and deny patterns
+  check <image>...  Report whether images are allowed`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		switch args[0] {
+		case "show":
+			return showImagePolicy()
+		case "check":
+			if len(args) < 2 {
+				return fmt.Errorf("image reference required for check command")
+			}
+			return checkImages(args[1:])
+		default:
+			return fmt.Errorf("unknown policy command: %s", args[0])
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(policyCmd)
+}
+
+func imagePolicies(cfg *config.Config) ([]imagePolicySource, error) {
+	var sources []imagePolicySource
+	system, err := config.LoadSystemImagePolicy()
+	if err != nil {
+		return nil, err
+	}
+	if !system.Empty() {
+		sources = append(sources, imagePolicySource{Name: config.SystemPolicyPath, Policy: system})
+	}
+	if cfg != nil && cfg.Settings != nil && !cfg.Settings.ImagePolicy.Empty() {
+		if err := config.ValidateImagePolicy(cfg.Settings.ImagePolicy); err != nil {
+			return nil, fmt.Errorf("settings.image_policy: %w", err)
+		}
+		sources = append(sources, imagePolicySource{Name: "settings.image_policy", Policy: cfg.Settings.ImagePolicy})
+	}
+	return sources, nil
+}
+
+func evaluateImagePolicies(sources []imagePolicySource, image string) error {
+	for _, s := range sources {
+		if err := s.Policy.Check(image, s.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkImagePolicy(cfg *config.Config, image string) error {
+	sources, err := imagePolicies(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to load image policy: %w", err)
+	}
+	if err := evaluateImagePolicies(sources, image); err != nil {
+		return fmt.Errorf("%w\nhint: run 'devbox policy show' to see which images are allowed", err)
+	}
+	return nil
+}
+
+func showImagePolicy() error {
+	cfg, err := configManager.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	sources, err := imagePolicies(cfg)
+	if err != nil {
+		return err
+	}
+	if len(sources) == 0 {
+		fmt.Printf("No image policy configured; any base image is allowed.\n")
+		return nil
+	}
+	for i, s := range sources {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("%s:\n", s.Name)
+		printPatterns("Allow", s.Policy.Allow, "any image not denied")
+		printPatterns("Deny", s.Policy.Deny, "none")
+	}
+	fmt.Printf("\nPatterns match normalized references (ubuntu:22.04 is docker.io/library/ubuntu:22.04); '*' matches any characters.\n")
+	return nil
+}
+
+func printPatterns(label string, patterns []string, empty string) {
+	if len(patterns) == 0 {
+		fmt.Printf("  %s: %s\n", label, empty)
+		return
+	}
+	fmt.Printf("  %s:\n", label)
+	for _, p := range patterns {
+		fmt.Printf("    - %s\n", p)
+	}
+}
+
+func checkImages(images []string) error {
+	cfg, err := configManager.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	sources, err := imagePolicies(cfg)
+	if err != nil {
+		return err
+	}
+	var denied []string
+	for _, image := range images {
+		if err := evaluateImagePolicies(sources, image); err != nil {
+			fmt.Printf("denied  %s\n        %v\n", image, err)
+			denied = append(denied, image)
+			continue
+		}
+		fmt.Printf("allowed %s (%s)\n", image, config.NormalizeImageRef(image))
+	}
+	if len(denied) > 0 {
+		return fmt.Errorf("%d image(s) violate policy: %s", len(denied), strings.Join(denied, ", "))
+	}
+	return nil
+}
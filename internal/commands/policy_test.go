@@ -0,0 +1,92 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"devbox/internal/config"
+)
+
+func TestImagePoliciesCombineSystemAndSettings(t *testing.T) {
+	systemPath := filepath.Join(t.TempDir(), "policy.json")
+	if err := os.WriteFile(systemPath, []byte(`{"image_policy": {"allow": ["ghcr.io/acme/*", "ubuntu:*"]}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	orig := config.SystemPolicyPath
+	config.SystemPolicyPath = systemPath
+	defer func() { config.SystemPolicyPath = orig }()
+
+	cfg := &config.Config{Settings: &config.GlobalSettings{
+		ImagePolicy: &config.ImagePolicy{Deny: []string{"ubuntu:18.04"}},
+	}}
+	sources, err := imagePolicies(cfg)
+	if err != nil {
+		t.Fatalf("imagePolicies() error = %v", err)
+	}
+	if len(sources) != 2 || sources[0].Name != systemPath || sources[1].Name != "settings.image_policy" {
+		t.Fatalf("unexpected sources: %+v", sources)
+	}
+
+	tests := []struct {
+		image, wantSource string
+	}{
+		{"ubuntu:22.04", ""},
+		{"ghcr.io/acme/base:1", ""},
+		{"ubuntu:18.04", "settings.image_policy"},
+		{"python:3.12", systemPath},
+	}
+	for _, tt := range tests {
+		err := evaluateImagePolicies(sources, tt.image)
+		switch {
+		case tt.wantSource == "" && err != nil:
+			t.Errorf("%s: unexpected violation %v", tt.image, err)
+		case tt.wantSource != "" && (err == nil || !strings.Contains(err.Error(), tt.wantSource)):
+			t.Errorf("%s: error = %v, want violation from %s", tt.image, err, tt.wantSource)
+		}
+	}
+}
+
+func TestImagePoliciesNone(t *testing.T) {
+	orig := config.SystemPolicyPath
+	config.SystemPolicyPath = filepath.Join(t.TempDir(), "missing.json")
+	defer func() { config.SystemPolicyPath = orig }()
+
+	sources, err := imagePolicies(&config.Config{Settings: &config.GlobalSettings{}})
+	if err != nil || len(sources) != 0 {
+		t.Fatalf("imagePolicies() = %+v, %v; want none", sources, err)
+	}
+	if err := evaluateImagePolicies(sources, "anything:latest"); err != nil {
+		t.Errorf("no policy should allow everything, got %v", err)
+	}
+}
+
+func TestCheckProjectImagePolicyUsesDockerfile(t *testing.T) {
+	orig := config.SystemPolicyPath
+	config.SystemPolicyPath = filepath.Join(t.TempDir(), "missing.json")
+	defer func() { config.SystemPolicyPath = orig }()
+
+	workspace := t.TempDir()
+	dockerfile := "ARG BASE=ubuntu:22.04\nFROM ${BASE} AS dev\nFROM dev\n"
+	if err := os.WriteFile(filepath.Join(workspace, "Dockerfile"), []byte(dockerfile), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{Settings: &config.GlobalSettings{
+		ImagePolicy: &config.ImagePolicy{Allow: []string{"ubuntu:*"}},
+	}}
+	pc := &config.ProjectConfig{Name: "web", Build: &config.Build{}}
+	image := config.BuildImageTag("web")
+
+	if err := checkProjectImagePolicy(cfg, image, workspace, pc); err != nil {
+		t.Errorf("ubuntu base should be allowed, got %v", err)
+	}
+	pc.Build.Args = map[string]string{"BASE": "python:3.12"}
+	if err := checkProjectImagePolicy(cfg, image, workspace, pc); err == nil || !strings.Contains(err.Error(), "python:3.12") {
+		t.Errorf("build arg override should be checked, got %v", err)
+	}
+	pc.Build.Dockerfile = "missing.Dockerfile"
+	if err := checkProjectImagePolicy(cfg, image, workspace, pc); err == nil {
+		t.Error("missing Dockerfile should be an error")
+	}
+}
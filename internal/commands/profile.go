@@ -0,0 +1,162 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+
+	"devbox/internal/config"
+	"devbox/internal/docker"
+)
+
+func globalBoxDefaults(settings *config.GlobalSettings) docker.BoxDefaults {
+	defaults := docker.BoxDefaults{Environment: map[string]string{}}
+	if settings == nil {
+		return defaults
+	}
+	for k, v := range settings.DefaultEnvironment {
+		defaults.Environment[k] = v
+	}
+	set := func(value string, keys ...string) {
+		if value == "" {
+			return
+		}
+		for _, k := range keys {
+			defaults.Environment[k] = value
+		}
+	}
+	if p := settings.Proxy; p != nil {
+		set(p.HTTP, "HTTP_PROXY", "http_proxy")
+		set(p.HTTPS, "HTTPS_PROXY", "https_proxy")
+		set(p.NoProxy, "NO_PROXY", "no_proxy")
+	}
+	if m := settings.Mirrors; m != nil {
+		set(m.Pip, "PIP_INDEX_URL")
+		set(m.Npm, "NPM_CONFIG_REGISTRY")
+		set(m.Go, "GOPROXY")
+	}
+	if r := settings.DefaultResources; r != nil {
+		if _, err := config.ParseCPUs(r.CPUs); err == nil {
+			defaults.CPUs = config.NormalizeCPUs(r.CPUs)
+		}
+		if _, err := config.ParseMemory(r.Memory); err == nil {
+			defaults.Memory = config.NormalizeMemory(r.Memory)
+		}
+	}
+	if restart := settings.RestartPolicy(); config.ValidateRestartPolicy(restart) == nil {
+		defaults.Restart = restart
+	}
+	return defaults
+}
+
+func runProfileCommand(args []string) error {
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+	action := args[0]
+	if action != "list" && len(args) < 2 {
+		return fmt.Errorf("profile name required for profile %s", action)
+	}
+	var name string
+	if len(args) > 1 {
+		name = args[1]
+		if err := validateProjectName(name); err != nil {
+			return fmt.Errorf("invalid profile name: %w", err)
+		}
+	}
+
+	switch action {
+	case "list":
+		return listProfiles()
+	case "show":
+		profile, err := configManager.LoadProfile(name)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Profile '%s' (%s):\n", name, configManager.ProfilesDir())
+		printProfile(profile)
+		return nil
+	case "save":
+		return saveProfile(name)
+	case "use":
+		return useProfile(name)
+	default:
+		return fmt.Errorf("unknown profile command: %s (use list, show, save or use)", action)
+	}
+}
+
+func listProfiles() error {
+	cfg, err := configManager.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	names := configManager.ListProfiles()
+	if len(names) == 0 {
+		fmt.Printf("No profiles found in %s\n", configManager.ProfilesDir())
+		fmt.Printf("Save the current settings as a profile with: devbox config profile save <name>\n")
+		return nil
+	}
+	fmt.Printf("Profiles:\n")
+	for _, name := range names {
+		marker := " "
+		if cfg.Settings != nil && cfg.Settings.Profile == name {
+			marker = "*"
+		}
+		fmt.Printf("  %s %s\n", marker, name)
+	}
+	return nil
+}
+
+func saveProfile(name string) error {
+	cfg, err := configManager.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if err := configManager.SaveProfile(name, config.ProfileFromSettings(cfg.Settings)); err != nil {
+		return err
+	}
+	fmt.Printf("Saved current settings as profile '%s'\n", name)
+	return nil
+}
+
+func useProfile(name string) error {
+	profile, err := configManager.LoadProfile(name)
+	if err != nil {
+		return err
+	}
+	cfg, err := configManager.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	cfg.Settings.ApplyProfile(name, profile)
+	if err := configManager.Save(cfg); err != nil {
+		return fmt.Errorf("failed to save configuration: %w", err)
+	}
+	fmt.Printf("Switched to profile '%s'\n", name)
+	printProfile(profile)
+	fmt.Printf("\nNew boxes use these settings; recreate existing boxes with 'devbox update' to pick them up.\n")
+	return nil
+}
+
+func printProfile(profile *config.Profile) {
+	fmt.Printf("  Default base image: %s\n", firstNonEmpty(profile.DefaultBaseImage, "ubuntu:22.04"))
+	if p := profile.Proxy; p != nil {
+		fmt.Printf("  Proxy: http=%s https=%s no_proxy=%s\n", firstNonEmpty(p.HTTP, "-"), firstNonEmpty(p.HTTPS, "-"), firstNonEmpty(p.NoProxy, "-"))
+	}
+	if m := profile.Mirrors; m != nil {
+		fmt.Printf("  Mirrors: pip=%s npm=%s go=%s\n", firstNonEmpty(m.Pip, "-"), firstNonEmpty(m.Npm, "-"), firstNonEmpty(m.Go, "-"))
+	}
+	if r := profile.DefaultResources; r != nil {
+		fmt.Printf("  Default resources: cpus=%s memory=%s\n", firstNonEmpty(r.CPUs, "-"), firstNonEmpty(r.Memory, "-"))
+	}
+	if len(profile.DefaultEnvironment) > 0 {
+		keys := make([]string, 0, len(profile.DefaultEnvironment))
+		for k := range profile.DefaultEnvironment {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		fmt.Printf("  Default environment:\n")
+		for _, k := range keys {
+			fmt.Printf("    %s=%s\n", k, profile.DefaultEnvironment[k])
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package commands
+
+import (
+	"testing"
+
+	"devbox/internal/config"
+)
+
+func TestGlobalBoxDefaults(t *testing.T) {
+	settings := &config.GlobalSettings{
+		DefaultEnvironment: map[string]string{"EDITOR": "vim", "GOPROXY": "direct"},
+		Proxy:              &config.ProxySettings{HTTP: "http://proxy:3128", NoProxy: "localhost"},
+		Mirrors:            &config.MirrorSettings{Pip: "https://pypi.corp/simple", Go: "https://goproxy.corp"},
+		DefaultResources:   &config.Resources{CPUs: "2"},
+	}
+	d := globalBoxDefaults(settings)
+
+	want := map[string]string{
+		"EDITOR":        "vim",
+		"HTTP_PROXY":    "http://proxy:3128",
+		"http_proxy":    "http://proxy:3128",
+		"NO_PROXY":      "localhost",
+		"no_proxy":      "localhost",
+		"PIP_INDEX_URL": "https://pypi.corp/simple",
+		"GOPROXY":       "https://goproxy.corp",
+	}
+	if len(d.Environment) != len(want) {
+		t.Errorf("Environment = %v, want %v", d.Environment, want)
+	}
+	for k, v := range want {
+		if d.Environment[k] != v {
+			t.Errorf("Environment[%s] = %q, want %q", k, d.Environment[k], v)
+		}
+	}
+	if d.CPUs != "2" || d.Memory != "" {
+		t.Errorf("resources = %q/%q, want 2/empty", d.CPUs, d.Memory)
+	}
+
+	settings.DefaultResources = &config.Resources{CPUs: "two", Memory: "4096MB"}
+	if d := globalBoxDefaults(settings); d.CPUs != "" || d.Memory != "4g" {
+		t.Errorf("resources = %q/%q, want invalid cpus dropped and memory 4g", d.CPUs, d.Memory)
+	}
+
+	if d := globalBoxDefaults(nil); len(d.Environment) != 0 {
+		t.Errorf("nil settings should give no defaults, got %v", d.Environment)
+	}
+}
@@ -0,0 +1,279 @@
+package commands
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"devbox/internal/config"
+)
+
+const projectsExportVersion = 1
+
+var (
+	projectsImportMapFlag    []string
+	projectsImportDryRunFlag bool
+)
+
+type projectsExport struct {
+	Version    int               `json:"version"`
+	ExportedAt time.Time         `json:"exported_at"`
+	Home       string            `json:"home,omitempty"`
+	Projects   []*config.Project `json:"projects"`
+}
+
+type pathMapping struct {
+	From string
+	To   string
+}
+
+var projectsCmd = &cobra.Command{
+	Use:   "projects",
+	Short: "Export or import the project registry",
+	Long: `Export the registered projects from ~/.devbox/config.json and import them on another
+machine or after reinstalling the OS. Only registry entries are transferred: workspaces,
+boxes, and images stay where they are. Run 'devbox up <project>' to recreate a box after
+importing.`,
+}
+
+var projectsExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Print the project registry as JSON",
+	Long: `Print every registered project as JSON on stdout.
+
+Examples:
+  devbox projects export > projects.json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := configManager.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		homeDir, _ := os.UserHomeDir()
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(buildProjectsExport(cfg, homeDir, time.Now().UTC()))
+	},
+}
+
+var projectsImportCmd = &cobra.Command{
+	Use:   "import <file|->",
+	Short: "Register projects from an export",
+	Long: `Register the projects from a 'devbox projects export' file ('-' reads stdin).
+
+Paths under the exporting user's home directory are moved to yours. --map rewrites other
+path prefixes. When a workspace still cannot be found, devbox asks for its new location,
+or keeps the entry with a warning when stdin is not a terminal. Projects that are already
+registered are skipped unless --force is given.
+
+Examples:
+  devbox projects import projects.json
+  devbox projects import projects.json --map /mnt/old-home/me=/home/me
+  devbox projects import projects.json --dry-run`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var prompter *setupPrompter
+		if args[0] != "-" && stdinIsTerminal() && !projectsImportDryRunFlag {
+			prompter = &setupPrompter{in: bufio.NewReader(os.Stdin), out: os.Stdout}
+		}
+		return runProjectsImport(args[0], prompter)
+	},
+}
+
+func init() {
+	projectsImportCmd.Flags().StringArrayVar(&projectsImportMapFlag, "map", nil, "Rewrite paths starting with OLD to start with NEW (OLD=NEW, repeatable)")
+	projectsImportCmd.Flags().BoolVar(&projectsImportDryRunFlag, "dry-run", false, "Show what would be imported without changing the registry")
+	projectsImportCmd.Flags().BoolVarP(&forceFlag, "force", "f", false, "Replace projects that are already registered")
+	projectsCmd.AddCommand(projectsExportCmd)
+	projectsCmd.AddCommand(projectsImportCmd)
+	rootCmd.AddCommand(projectsCmd)
+}
+
+func buildProjectsExport(cfg *config.Config, homeDir string, now time.Time) projectsExport {
+	doc := projectsExport{Version: projectsExportVersion, ExportedAt: now, Home: homeDir, Projects: []*config.Project{}}
+	for _, name := range sortedProjectNames(cfg) {
+		doc.Projects = append(doc.Projects, cfg.Projects[name])
+	}
+	return doc
+}
+
+func parseProjectsExport(data []byte) (*projectsExport, error) {
+	var doc projectsExport
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid project export: %w", err)
+	}
+	if doc.Version == 0 || doc.Version > projectsExportVersion {
+		return nil, fmt.Errorf("unsupported project export version %d", doc.Version)
+	}
+	seen := map[string]bool{}
+	for _, p := range doc.Projects {
+		if p == nil {
+			return nil, fmt.Errorf("invalid project export: empty project entry")
+		}
+		if err := validateProjectName(p.Name); err != nil {
+			return nil, fmt.Errorf("invalid project export: %w", err)
+		}
+		if seen[p.Name] {
+			return nil, fmt.Errorf("invalid project export: project '%s' appears twice", p.Name)
+		}
+		seen[p.Name] = true
+		if p.WorkspacePath == "" {
+			return nil, fmt.Errorf("invalid project export: project '%s' has no workspace_path", p.Name)
+		}
+	}
+	sort.Slice(doc.Projects, func(i, j int) bool { return doc.Projects[i].Name < doc.Projects[j].Name })
+	return &doc, nil
+}
+
+func parsePathMappings(specs []string, homeDir string) ([]pathMapping, error) {
+	var mappings []pathMapping
+	for _, spec := range specs {
+		from, to, ok := strings.Cut(spec, "=")
+		if !ok || strings.TrimSpace(from) == "" || strings.TrimSpace(to) == "" {
+			return nil, fmt.Errorf("invalid --map '%s' (expected OLD=NEW)", spec)
+		}
+		from = filepath.Clean(expandHomePath(strings.TrimSpace(from), homeDir))
+		to = filepath.Clean(expandHomePath(strings.TrimSpace(to), homeDir))
+		if !filepath.IsAbs(from) || !filepath.IsAbs(to) {
+			return nil, fmt.Errorf("invalid --map '%s' (paths must be absolute)", spec)
+		}
+		mappings = append(mappings, pathMapping{From: from, To: to})
+	}
+	return mappings, nil
+}
+
+func remapProjectPaths(p *config.Project, mappings []pathMapping) {
+	workspace := filepath.Clean(p.WorkspacePath)
+	for _, m := range mappings {
+		rebased := rebasePath(workspace, m.From, m.To)
+		if rebased == workspace {
+			continue
+		}
+		p.WorkspacePath = rebased
+		p.ConfigFile = rebasePath(p.ConfigFile, m.From, m.To)
+		p.ArchivePath = rebasePath(p.ArchivePath, m.From, m.To)
+		return
+	}
+}
+
+func relocateProject(p *config.Project, workspace string) {
+	from := filepath.Clean(p.WorkspacePath)
+	p.WorkspacePath = workspace
+	p.ConfigFile = rebasePath(p.ConfigFile, from, workspace)
+	p.ArchivePath = rebasePath(p.ArchivePath, from, workspace)
+}
+
+func projectDataPath(p *config.Project) string {
+	if p.Status == projectStatusArchived && p.ArchivePath != "" {
+		return p.ArchivePath
+	}
+	return p.WorkspacePath
+}
+
+func runProjectsImport(source string, prompter *setupPrompter) error {
+	var data []byte
+	var err error
+	if source == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(source)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", source, err)
+	}
+	doc, err := parseProjectsExport(data)
+	if err != nil {
+		return err
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("failed to get home directory: %w", err)
+	}
+	mappings, err := parsePathMappings(projectsImportMapFlag, homeDir)
+	if err != nil {
+		return err
+	}
+	if doc.Home != "" && filepath.Clean(doc.Home) != homeDir {
+		mappings = append(mappings, pathMapping{From: filepath.Clean(doc.Home), To: homeDir})
+	}
+
+	cfg, err := configManager.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	boxOwners := map[string]string{}
+	for name, p := range cfg.GetProjects() {
+		boxOwners[p.BoxName] = name
+	}
+
+	imported, skipped := 0, 0
+	for _, p := range doc.Projects {
+		if existing, ok := cfg.GetProject(p.Name); ok && !forceFlag {
+			fmt.Printf("Skipping '%s': already registered at %s (use --force to replace)\n", p.Name, existing.WorkspacePath)
+			skipped++
+			continue
+		}
+		if owner, ok := boxOwners[p.BoxName]; ok && owner != p.Name {
+			fmt.Printf("Skipping '%s': box name '%s' is already used by project '%s'\n", p.Name, p.BoxName, owner)
+			skipped++
+			continue
+		}
+
+		remapProjectPaths(p, mappings)
+		if _, err := os.Stat(projectDataPath(p)); err != nil {
+			if prompter == nil {
+				fmt.Printf("Warning: %s for '%s' not found; registering it anyway\n", projectDataPath(p), p.Name)
+			} else {
+				answer, err := prompter.ask(fmt.Sprintf("Workspace for '%s' not found. New path, or 'skip'", p.Name), p.WorkspacePath, func(s string) error {
+					if s == "skip" {
+						return nil
+					}
+					if info, err := os.Stat(expandHomePath(s, homeDir)); err != nil || !info.IsDir() {
+						return fmt.Errorf("%s is not a directory", s)
+					}
+					return nil
+				})
+				if err != nil {
+					return err
+				}
+				if answer == "skip" {
+					skipped++
+					continue
+				}
+				abs, err := filepath.Abs(expandHomePath(answer, homeDir))
+				if err != nil {
+					return fmt.Errorf("invalid path %q: %w", answer, err)
+				}
+				relocateProject(p, abs)
+			}
+		}
+
+		fmt.Printf("  %-20s -> %s\n", p.Name, p.WorkspacePath)
+		cfg.AddProject(p)
+		boxOwners[p.BoxName] = p.Name
+		imported++
+	}
+
+	if projectsImportDryRunFlag {
+		fmt.Printf("Dry run: would import %d project(s), skip %d\n", imported, skipped)
+		return nil
+	}
+	if imported > 0 {
+		if err := configManager.Save(cfg); err != nil {
+			return fmt.Errorf("failed to save configuration: %w", err)
+		}
+	}
+	fmt.Printf("Imported %d project(s), skipped %d\n", imported, skipped)
+	if imported > 0 {
+		fmt.Printf("hint: run 'devbox up <project>' to recreate a project's box\n")
+	}
+	return nil
+}
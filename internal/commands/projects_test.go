@@ -0,0 +1,118 @@
+package commands
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"devbox/internal/config"
+)
+
+func TestParsePathMappings(t *testing.T) {
+	got, err := parsePathMappings([]string{"/mnt/old/me=/home/me/", "~/a=~/b"}, "/home/me")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []pathMapping{{"/mnt/old/me", "/home/me"}, {"/home/me/a", "/home/me/b"}}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("parsePathMappings() = %v, want %v", got, want)
+	}
+	for _, bad := range []string{"/a", "=/b", "rel=/b", "/a="} {
+		if _, err := parsePathMappings([]string{bad}, "/home/me"); err == nil {
+			t.Errorf("parsePathMappings(%q) should fail", bad)
+		}
+	}
+}
+
+func TestRemapProjectPaths(t *testing.T) {
+	p := &config.Project{
+		Name:          "web",
+		WorkspacePath: "/home/old/devbox/web",
+		ConfigFile:    "/home/old/devbox/web/devbox.json",
+		ArchivePath:   "/home/old/devbox/web/.devbox/backups/web.tar.gz",
+	}
+	remapProjectPaths(p, []pathMapping{{"/srv", "/data"}, {"/home/old", "/home/new"}, {"/home", "/mnt"}})
+	if p.WorkspacePath != "/home/new/devbox/web" || p.ConfigFile != "/home/new/devbox/web/devbox.json" || p.ArchivePath != "/home/new/devbox/web/.devbox/backups/web.tar.gz" {
+		t.Errorf("remapProjectPaths() = %+v", p)
+	}
+
+	relocateProject(p, "/work/web")
+	if p.WorkspacePath != "/work/web" || p.ConfigFile != "/work/web/devbox.json" {
+		t.Errorf("relocateProject() = %+v", p)
+	}
+}
+
+func TestParseProjectsExport(t *testing.T) {
+	tests := []struct {
+		name, data string
+		wantErr    bool
+	}{
+		{"valid", `{"version":1,"projects":[{"name":"b","workspace_path":"/b"},{"name":"a","workspace_path":"/a"}]}`, false},
+		{"missing version", `{"projects":[]}`, true},
+		{"newer version", `{"version":2,"projects":[]}`, true},
+		{"bad name", `{"version":1,"projects":[{"name":"a b","workspace_path":"/a"}]}`, true},
+		{"duplicate", `{"version":1,"projects":[{"name":"a","workspace_path":"/a"},{"name":"a","workspace_path":"/b"}]}`, true},
+		{"no workspace", `{"version":1,"projects":[{"name":"a"}]}`, true},
+	}
+	for _, tt := range tests {
+		doc, err := parseProjectsExport([]byte(tt.data))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: parseProjectsExport() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+		if err == nil && doc.Projects[0].Name != "a" {
+			t.Errorf("%s: projects should be sorted by name", tt.name)
+		}
+	}
+}
+
+func TestProjectsImport(t *testing.T) {
+	cm := withTempConfigManager(t)
+	home := filepath.Dir(cm.ConfigDir())
+	defer func() { forceFlag, projectsImportMapFlag = false, nil }()
+
+	if err := os.MkdirAll(filepath.Join(home, "devbox", "web"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	source := &config.Config{Projects: map[string]*config.Project{
+		"web": {Name: "web", BoxName: "devbox_web", WorkspacePath: "/home/old/devbox/web", ConfigFile: "/home/old/devbox/web/devbox.json"},
+		"api": {Name: "api", BoxName: "devbox_api", WorkspacePath: "/srv/api"},
+	}}
+	data, err := json.Marshal(buildProjectsExport(source, "/home/old", time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exportPath := filepath.Join(t.TempDir(), "projects.json")
+	if err := os.WriteFile(exportPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, _ := cm.Load()
+	cfg.AddProject(&config.Project{Name: "api", BoxName: "devbox_api", WorkspacePath: "/keep/api"})
+	if err := cm.Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	projectsImportMapFlag = []string{"/srv=/data"}
+	if err := runProjectsImport(exportPath, nil); err != nil {
+		t.Fatalf("runProjectsImport() error = %v", err)
+	}
+	cfg, _ = cm.Load()
+	web, ok := cfg.GetProject("web")
+	if !ok || web.WorkspacePath != filepath.Join(home, "devbox", "web") || web.ConfigFile != filepath.Join(home, "devbox", "web", "devbox.json") {
+		t.Errorf("imported web = %+v, want workspace under the new home", web)
+	}
+	if api, _ := cfg.GetProject("api"); api.WorkspacePath != "/keep/api" {
+		t.Errorf("existing project was replaced without --force: %+v", api)
+	}
+
+	forceFlag = true
+	if err := runProjectsImport(exportPath, nil); err != nil {
+		t.Fatalf("runProjectsImport(--force) error = %v", err)
+	}
+	cfg, _ = cm.Load()
+	if api, _ := cfg.GetProject("api"); api.WorkspacePath != "/data/api" {
+		t.Errorf("--force import of api = %+v, want --map applied", api)
+	}
+}
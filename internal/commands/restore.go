@@ -8,73 +8,111 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+
+	"devbox/internal/config"
+)
+
+var (
+	restoreAsFlag           string
+	restoreFromRegistryFlag string
+	restoreIdentityFlag     string
+	restorePassphraseFile   string
 )
 
 var restoreCmd = &cobra.Command{
 	Use:   "restore <project> <backup-dir>",
 	Short: "Restore a project's devbox environment from a backup directory",
-	Args:  cobra.ExactArgs(2),
-	RunE: func(cmd *cobra.Command, args []string) error {
-		projectName := args[0]
-		backupDir := args[1]
+	Long: `Restore a project's devbox environment from a backup directory.
 
-		cfg, err := configManager.Load()
-		if err != nil {
-			return fmt.Errorf("failed to load configuration: %w", err)
+Examples:
+  devbox restore myproject ./backup                 # Replace an existing project's box
+  devbox restore --as myproject-copy ./backup       # Create a new project from the backup
+  devbox restore myproject --from-registry ghcr.io/me/myproject:backup`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		want := 2
+		if strings.TrimSpace(restoreAsFlag) != "" {
+			want--
 		}
-		proj, ok := cfg.GetProject(projectName)
-		if !ok {
-			return fmt.Errorf("project '%s' not found", projectName)
+		if strings.TrimSpace(restoreFromRegistryFlag) != "" {
+			want--
 		}
+		return cobra.ExactArgs(want)(cmd, args)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		registryRef := strings.TrimSpace(restoreFromRegistryFlag)
 
-		imageTar := filepath.Join(backupDir, "image.tar")
-		metaPath := filepath.Join(backupDir, "metadata.json")
-		if _, err := os.Stat(imageTar); err != nil {
-			return fmt.Errorf("missing image tar at %s", imageTar)
-		}
-		metaBytes, err := os.ReadFile(metaPath)
-		if err != nil {
-			return fmt.Errorf("failed to read metadata: %w", err)
-		}
-		var manifest map[string]any
-		_ = json.Unmarshal(metaBytes, &manifest)
-
-		fmt.Printf("Loading image from %s...\n", imageTar)
-		imgID, err := dockerClient.LoadImage(imageTar)
+		cfg, err := configManager.Load()
 		if err != nil {
-			return fmt.Errorf("failed to load image: %w", err)
+			return fmt.Errorf("failed to load configuration: %w", err)
 		}
 
-		imageRef := ""
-		if v, ok := manifest["ImageTag"].(string); ok && v != "" {
-			imageRef = v
-		}
-		if imageRef == "" {
-			imageRef = imgID
+		var proj *config.Project
+		newProject := strings.TrimSpace(restoreAsFlag) != ""
+		if newProject {
+			projectName := strings.TrimSpace(restoreAsFlag)
+			if err := validateProjectName(projectName); err != nil {
+				return err
+			}
+			if _, exists := cfg.GetProject(projectName); exists {
+				return fmt.Errorf("project '%s' already exists. Use 'devbox restore %s <backup-dir>' to restore into it", projectName, projectName)
+			}
+			workspacePath, err := resolveWorkspacePath(projectName, workspacePathFlag)
+			if err != nil {
+				return err
+			}
+			proj = &config.Project{
+				Name:          projectName,
+				BoxName:       boxNameFor(cfg, projectName),
+				WorkspacePath: workspacePath,
+				Status:        "running",
+			}
+		} else {
+			var ok bool
+			proj, ok = cfg.GetProject(args[0])
+			if !ok {
+				return fmt.Errorf("project '%s' not found", args[0])
+			}
 		}
 
-		exists, err := dockerClient.BoxExists(proj.BoxName)
-		if err == nil && exists {
-			if !forceFlag {
-				return fmt.Errorf("box '%s' already exists. Use --force to overwrite", proj.BoxName)
+		var manifest *backupManifest
+		var imageRef string
+		requireEncrypted := cfg.Settings != nil && cfg.Settings.RequireEncryptedBackups
+		if registryRef != "" {
+			if requireEncrypted {
+				return fmt.Errorf("settings.require_encrypted_backups is enabled; registry backups are not encrypted")
+			}
+			manifest, err = pullRegistryBackup(registryRef)
+			if err != nil {
+				return err
 			}
-			_ = dockerClient.StopBox(proj.BoxName)
-			if err := dockerClient.RemoveBox(proj.BoxName); err != nil {
-				return fmt.Errorf("failed to remove existing box: %w", err)
+			imageRef = registryRef
+		} else {
+			var cleanup func()
+			manifest, imageRef, cleanup, err = loadBackupDir(args[len(args)-1], requireEncrypted, restoreIdentityFlag, restorePassphraseFile)
+			if err != nil {
+				return err
 			}
+			defer cleanup()
 		}
 
-		workspaceBox := "/workspace"
-		if pcfg, err := configManager.LoadProjectConfig(proj.WorkspacePath); err == nil && pcfg != nil && strings.TrimSpace(pcfg.WorkingDir) != "" {
-			workspaceBox = pcfg.WorkingDir
+		if newProject && manifest.DevboxConfig != nil {
+			manifest.DevboxConfig.Name = proj.Name
 		}
-
-		boxID, err := dockerClient.CreateBoxWithConfig(proj.BoxName, imageRef, proj.WorkspacePath, workspaceBox, nil)
+		projectConfig, err := recreateBoxFromBackup(cfg, proj, manifest, imageRef)
 		if err != nil {
-			return fmt.Errorf("failed to create box from image: %w", err)
+			return err
 		}
-		if err := dockerClient.StartBox(boxID); err != nil {
-			return fmt.Errorf("failed to start restored box: %w", err)
+
+		if newProject {
+			proj.BaseImage = imageRef
+			cfg.MergeProjectConfig(proj, projectConfig)
+			cfg.AddProject(proj)
+			if err := configManager.Save(cfg); err != nil {
+				return fmt.Errorf("failed to save configuration: %w", err)
+			}
+			fmt.Printf("Restore complete. Project '%s' created from backup of '%s'.\n", proj.Name, manifest.Project)
+			fmt.Printf("Workspace: %s\n", proj.WorkspacePath)
+			return nil
 		}
 
 		fmt.Printf("Restore complete. Box '%s' recreated from backup.\n", proj.BoxName)
@@ -85,4 +123,194 @@ var restoreCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(restoreCmd)
 	restoreCmd.Flags().BoolVarP(&forceFlag, "force", "f", false, "Overwrite existing box if present")
+	restoreCmd.Flags().StringVar(&restoreAsFlag, "as", "", "Restore into a new project with this name (creates the project and workspace)")
+	restoreCmd.Flags().StringVar(&workspacePathFlag, "path", "", "With --as, workspace directory for the new project (default: <workspace_root>/<project>)")
+	restoreCmd.Flags().StringVar(&restoreFromRegistryFlag, "from-registry", "", "Restore from an image reference pushed with 'devbox backup --to-registry'")
+	restoreCmd.Flags().StringVar(&restoreIdentityFlag, "identity", "", "age identity file for backups encrypted with --recipient (default: DEVBOX_AGE_IDENTITY)")
+	restoreCmd.Flags().StringVar(&restorePassphraseFile, "passphrase-file", "", "Read the decryption passphrase from this file (default: DEVBOX_BACKUP_PASSPHRASE)")
+}
+
+func loadBackupDir(dir string, requireEncrypted bool, identity, passphraseFile string) (*backupManifest, string, func(), error) {
+	backupDir, cleanup, err := openBackupDir(dir, requireEncrypted, identity, passphraseFile)
+	if err != nil {
+		return nil, "", cleanup, err
+	}
+	manifest, err := readBackupManifest(backupDir)
+	if err != nil {
+		cleanup()
+		return nil, "", func() {}, err
+	}
+
+	imageTar := filepath.Join(backupDir, "image.tar")
+	fmt.Printf("Loading image from %s...\n", imageTar)
+	imgID, err := dockerClient.LoadImage(imageTar)
+	if err != nil {
+		cleanup()
+		return nil, "", func() {}, fmt.Errorf("failed to load image: %w", err)
+	}
+
+	imageRef := strings.TrimSpace(manifest.ImageTag)
+	if imageRef == "" {
+		imageRef = imgID
+	}
+	return manifest, imageRef, cleanup, nil
+}
+
+func recreateBoxFromBackup(cfg *config.Config, proj *config.Project, manifest *backupManifest, imageRef string) (*config.ProjectConfig, error) {
+	exists, err := dockerClient.BoxExists(proj.BoxName)
+	if err == nil && exists {
+		if !forceFlag {
+			return nil, fmt.Errorf("box '%s' already exists. Use --force to overwrite", proj.BoxName)
+		}
+		_ = dockerClient.StopBox(proj.BoxName)
+		if err := dockerClient.RemoveBox(proj.BoxName); err != nil {
+			return nil, fmt.Errorf("failed to remove existing box: %w", err)
+		}
+	}
+
+	if err := os.MkdirAll(proj.WorkspacePath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create workspace directory: %w", err)
+	}
+	for _, name := range restoreWorkspaceFiles(proj.WorkspacePath, manifest) {
+		fmt.Printf("Restored %s from backup\n", name)
+	}
+
+	projectConfig := manifest.DevboxConfig
+	if projectConfig == nil {
+		projectConfig, _ = configManager.LoadProjectConfig(proj.WorkspacePath)
+	}
+	if err := checkHostCapacity(cfg, proj.BoxName, projectConfig, forceFlag); err != nil {
+		return nil, err
+	}
+	workspaceBox := "/workspace"
+	if projectConfig != nil && strings.TrimSpace(projectConfig.WorkingDir) != "" {
+		workspaceBox = projectConfig.WorkingDir
+	}
+	var configMap map[string]interface{}
+	if projectConfig != nil {
+		if data, err := json.Marshal(projectConfig); err == nil {
+			_ = json.Unmarshal(data, &configMap)
+		}
+	}
+
+	fmt.Printf("Recreating box '%s' from %s...\n", proj.BoxName, imageRef)
+	boxID, err := dockerClient.CreateBoxWithConfig(proj.BoxName, imageRef, proj.WorkspacePath, workspaceBox, configMap)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create box from image: %w", err)
+	}
+	if err := dockerClient.StartBox(boxID); err != nil {
+		return nil, fmt.Errorf("failed to start restored box: %w", err)
+	}
+	if err := dockerClient.WaitForBox(proj.BoxName, workspaceTimeouts(proj.WorkspacePath).Startup); err != nil {
+		return nil, fmt.Errorf("restored box failed to become ready: %w", err)
+	}
+	return projectConfig, nil
+}
+
+func openBackupDir(backupDir string, requireEncrypted bool, identity, passphraseFile string) (string, func(), error) {
+	noop := func() {}
+	imagePath, imageEncrypted := findBackupFile(backupDir, "image.tar")
+	if imagePath == "" {
+		return "", noop, fmt.Errorf("missing image tar at %s", filepath.Join(backupDir, "image.tar"))
+	}
+	metaPath, metaEncrypted := findBackupFile(backupDir, "metadata.json")
+	if metaPath == "" {
+		return "", noop, fmt.Errorf("failed to read metadata: %s not found", filepath.Join(backupDir, "metadata.json"))
+	}
+	if !imageEncrypted && !metaEncrypted {
+		if requireEncrypted {
+			return "", noop, fmt.Errorf("settings.require_encrypted_backups is enabled; refusing unencrypted backup %s", backupDir)
+		}
+		return backupDir, noop, nil
+	}
+
+	tmpDir, err := os.MkdirTemp("", "devbox-restore-")
+	if err != nil {
+		return "", noop, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	cleanup := func() { _ = os.RemoveAll(tmpDir) }
+	fmt.Printf("Decrypting backup...\n")
+	for name, src := range map[string]string{"image.tar": imagePath, "metadata.json": metaPath} {
+		dst := filepath.Join(tmpDir, name)
+		if src == filepath.Join(backupDir, name) {
+			if requireEncrypted {
+				cleanup()
+				return "", noop, fmt.Errorf("settings.require_encrypted_backups is enabled; %s is not encrypted", src)
+			}
+			abs, err := filepath.Abs(src)
+			if err != nil {
+				cleanup()
+				return "", noop, err
+			}
+			if err := os.Symlink(abs, dst); err != nil {
+				cleanup()
+				return "", noop, err
+			}
+			continue
+		}
+		if err := decryptBackupFile(src, dst, identity, passphraseFile); err != nil {
+			cleanup()
+			return "", noop, err
+		}
+	}
+	return tmpDir, cleanup, nil
+}
+
+func pullRegistryBackup(ref string) (*backupManifest, error) {
+	if err := dockerClient.RefreshImage(ref, false); err != nil {
+		return nil, fmt.Errorf("failed to pull backup image %s: %w", ref, err)
+	}
+	label, err := dockerClient.GetImageLabel(ref, backupManifestLabel)
+	if err != nil {
+		return nil, err
+	}
+	if label == "" {
+		fmt.Printf("Warning: image %s has no devbox backup metadata; restoring container state only\n", ref)
+		return &backupManifest{ImageTag: ref}, nil
+	}
+	return decodeBackupManifestLabel(label)
+}
+
+func readBackupManifest(backupDir string) (*backupManifest, error) {
+	metaBytes, err := os.ReadFile(filepath.Join(backupDir, "metadata.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata: %w", err)
+	}
+	var manifest backupManifest
+	if err := json.Unmarshal(metaBytes, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse metadata: %w", err)
+	}
+	if manifest.ImageTag == "" {
+		var legacy map[string]any
+		if err := json.Unmarshal(metaBytes, &legacy); err == nil {
+			if v, ok := legacy["ImageTag"].(string); ok {
+				manifest.ImageTag = v
+			}
+		}
+	}
+	return &manifest, nil
+}
+
+func restoreWorkspaceFiles(workspacePath string, manifest *backupManifest) []string {
+	var restored []string
+	if manifest.DevboxConfig != nil {
+		if existing, err := configManager.LoadProjectConfig(workspacePath); err == nil && existing == nil {
+			if err := configManager.SaveProjectConfig(workspacePath, manifest.DevboxConfig); err != nil {
+				fmt.Printf("Warning: failed to restore devbox.json: %v\n", err)
+			} else {
+				restored = append(restored, "devbox.json")
+			}
+		}
+	}
+	if len(manifest.LockFileJSON) > 0 {
+		lockPath := filepath.Join(workspacePath, "devbox.lock.json")
+		if _, err := os.Stat(lockPath); os.IsNotExist(err) {
+			if err := os.WriteFile(lockPath, manifest.LockFileJSON, 0644); err != nil {
+				fmt.Printf("Warning: failed to restore devbox.lock.json: %v\n", err)
+			} else {
+				restored = append(restored, "devbox.lock.json")
+			}
+		}
+	}
+	return restored
 }
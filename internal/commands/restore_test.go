@@ -0,0 +1,102 @@
+package commands
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"devbox/internal/config"
+)
+
+func TestReadBackupManifestRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	written := backupManifest{
+		Version:      1,
+		Project:      "demo",
+		ImageTag:     "devbox/demo:backup-20240101-000000",
+		DevboxConfig: &config.ProjectConfig{Name: "demo", BaseImage: "ubuntu:22.04"},
+		LockFileJSON: json.RawMessage(`{"version":1}`),
+	}
+	b, _ := json.MarshalIndent(written, "", "  ")
+	if err := os.WriteFile(filepath.Join(dir, "metadata.json"), b, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := readBackupManifest(dir)
+	if err != nil {
+		t.Fatalf("readBackupManifest: %v", err)
+	}
+	if got.ImageTag != written.ImageTag {
+		t.Errorf("ImageTag = %q, want %q", got.ImageTag, written.ImageTag)
+	}
+	if got.DevboxConfig == nil || got.DevboxConfig.BaseImage != "ubuntu:22.04" {
+		t.Errorf("DevboxConfig not round-tripped: %+v", got.DevboxConfig)
+	}
+}
+
+func TestReadBackupManifestLegacyKey(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "metadata.json"), []byte(`{"ImageTag":"devbox/old:backup"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readBackupManifest(dir)
+	if err != nil {
+		t.Fatalf("readBackupManifest: %v", err)
+	}
+	if got.ImageTag != "devbox/old:backup" {
+		t.Errorf("ImageTag = %q, want legacy value", got.ImageTag)
+	}
+}
+
+func TestRestoreWorkspaceFilesOnlyWhenMissing(t *testing.T) {
+	withTempConfigManager(t)
+
+	ws := t.TempDir()
+	existingLock := []byte(`{"version":2}`)
+	if err := os.WriteFile(filepath.Join(ws, "devbox.lock.json"), existingLock, 0644); err != nil {
+		t.Fatal(err)
+	}
+	manifest := &backupManifest{
+		DevboxConfig: &config.ProjectConfig{Name: "demo"},
+		LockFileJSON: json.RawMessage(`{"version":1}`),
+	}
+
+	restored := restoreWorkspaceFiles(ws, manifest)
+	if len(restored) != 1 || restored[0] != "devbox.json" {
+		t.Fatalf("restored = %v, want [devbox.json]", restored)
+	}
+	if data, _ := os.ReadFile(filepath.Join(ws, "devbox.lock.json")); string(data) != string(existingLock) {
+		t.Errorf("existing lockfile was overwritten: %s", data)
+	}
+	if _, err := os.Stat(filepath.Join(ws, "devbox.json")); err != nil {
+		t.Errorf("devbox.json not restored: %v", err)
+	}
+}
+
+func TestBackupManifestLabelRoundTrip(t *testing.T) {
+	in := backupManifest{
+		Version:      1,
+		Project:      "demo",
+		ImageTag:     "ghcr.io/acme/demo:backup",
+		DevboxConfig: &config.ProjectConfig{Name: "demo", WorkingDir: "/src"},
+		LockFileJSON: json.RawMessage(`{"version":1}`),
+	}
+	label, err := encodeBackupManifestLabel(in)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	out, err := decodeBackupManifestLabel(label)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if out.ImageTag != in.ImageTag || out.Project != in.Project {
+		t.Errorf("manifest mismatch: %+v", out)
+	}
+	if out.DevboxConfig == nil || out.DevboxConfig.WorkingDir != "/src" {
+		t.Errorf("DevboxConfig not round-tripped: %+v", out.DevboxConfig)
+	}
+	if _, err := decodeBackupManifestLabel("not base64!"); err == nil {
+		t.Error("expected error for invalid label")
+	}
+}
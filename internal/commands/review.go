@@ -0,0 +1,205 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"devbox/internal/config"
+)
+
+var (
+	reviewTTLFlag    string
+	reviewRemoteFlag string
+	reviewRepoFlag   string
+
+	allowReviewSecretsFlag bool
+)
+
+var prNumberPattern = regexp.MustCompile(`^#?([0-9]+)$`)
+
+var reviewCmd = &cobra.Command{
+	Use:   "review <pr-number|branch>",
+	Short: "Bring up a throwaway environment for a pull request or branch",
+	Long: `Fetch a pull request or branch into a temporary workspace, start an environment
+from its devbox.json, and print how to connect. Review environments are destroyed,
+workspace included, when their ttl runs out (enforced by 'devbox serve').
+
+The checkout's devbox.json is untrusted: its host hooks are skipped unless you
+pass --allow-host-hooks, box hooks run only inside the box, and boxes that ask
+for secrets fail to start unless you pass --allow-secrets.
+
+The repository is the current directory's remote (origin by default) or --repo.
+Numbers are fetched as GitHub pull requests (refs/pull/N/head) or, for GitLab
+remotes, merge requests (refs/merge-requests/N/head). Anything else is a branch.
+
+Examples:
+  devbox review 123
+  devbox review feature/login --ttl 2h
+  devbox review 42 --repo https://github.com/acme/webapp.git`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runReview(args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(reviewCmd)
+	reviewCmd.Flags().StringVar(&reviewTTLFlag, "ttl", "8h", "Destroy the review environment after this long")
+	reviewCmd.Flags().StringVar(&reviewRemoteFlag, "remote", "origin", "Git remote of the current repository to fetch from")
+	reviewCmd.Flags().BoolVar(&allowHostHooksFlag, "allow-host-hooks", false, "Run the checkout's \"in\": \"host\" hooks on this machine (they are skipped by default because the code is untrusted)")
+	reviewCmd.Flags().BoolVar(&allowReviewSecretsFlag, "allow-secrets", false, "Resolve the checkout's secrets on this machine and inject them into the box")
+	reviewCmd.Flags().StringVar(&reviewRepoFlag, "repo", "", "Repository URL or path to fetch from instead of the current repository's remote")
+}
+
+func reviewsDir() string {
+	return filepath.Join(configManager.ConfigDir(), "reviews")
+}
+
+func isReviewWorkspace(path string) bool {
+	if configManager == nil {
+		return false
+	}
+	rel, err := filepath.Rel(reviewsDir(), path)
+	return err == nil && rel != "." && !strings.HasPrefix(rel, "..")
+}
+
+func reviewRefspec(ref, repoURL string) (refspec, slug, label string) {
+	if m := prNumberPattern.FindStringSubmatch(ref); m != nil {
+		if strings.Contains(strings.ToLower(repoURL), "gitlab") {
+			return fmt.Sprintf("refs/merge-requests/%s/head", m[1]), "mr-" + m[1], "merge request !" + m[1]
+		}
+		return fmt.Sprintf("refs/pull/%s/head", m[1]), "pr-" + m[1], "pull request #" + m[1]
+	}
+	branch := strings.TrimPrefix(ref, "refs/heads/")
+	return "refs/heads/" + branch, reviewNamePart(branch), "branch " + branch
+}
+
+func reviewNamePart(s string) string {
+	return strings.Trim(strings.ReplaceAll(sanitizeBoxNamePart(s), ".", "-"), "-_")
+}
+
+func reviewProjectName(repoURL, slug string) string {
+	repo := strings.TrimSuffix(path.Base(strings.TrimRight(filepath.ToSlash(repoURL), "/")), ".git")
+	if i := strings.LastIndex(repo, ":"); i >= 0 {
+		repo = repo[i+1:]
+	}
+	return fmt.Sprintf("%s-review-%s", firstNonEmpty(reviewNamePart(repo), "repo"), slug)
+}
+
+func reviewRepoURL() (string, error) {
+	if reviewRepoFlag != "" {
+		if info, err := os.Stat(reviewRepoFlag); err == nil && info.IsDir() {
+			return filepath.Abs(reviewRepoFlag)
+		}
+		return reviewRepoFlag, nil
+	}
+	out, err := exec.Command("git", "remote", "get-url", reviewRemoteFlag).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to read git remote '%s'; run inside the repository or pass --repo", reviewRemoteFlag)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func runGit(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %s", args[0], firstNonEmpty(strings.TrimSpace(string(out)), err.Error()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func checkoutReview(repoURL, refspec, dir string) error {
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", filepath.Dir(dir), err)
+		}
+		fmt.Printf("Cloning %s...\n", repoURL)
+		if out, err := exec.Command("git", "clone", "--quiet", "--no-checkout", repoURL, dir).CombinedOutput(); err != nil {
+			_ = os.RemoveAll(dir)
+			return fmt.Errorf("failed to clone %s: %s", repoURL, firstNonEmpty(strings.TrimSpace(string(out)), err.Error()))
+		}
+	}
+	fmt.Printf("Fetching %s...\n", refspec)
+	if _, err := runGit(dir, "fetch", "--quiet", "origin", refspec); err != nil {
+		return err
+	}
+	_, err := runGit(dir, "checkout", "--quiet", "--force", "--detach", "FETCH_HEAD")
+	return err
+}
+
+func runReview(ref string) error {
+	if _, err := config.ParseTTL(reviewTTLFlag); err != nil {
+		return err
+	}
+	repoURL, err := reviewRepoURL()
+	if err != nil {
+		return err
+	}
+	refspec, slug, label := reviewRefspec(ref, repoURL)
+	if slug == "" {
+		return fmt.Errorf("invalid ref '%s'", ref)
+	}
+	projectName := reviewProjectName(repoURL, slug)
+	dir := filepath.Join(reviewsDir(), projectName)
+
+	cfg, err := configManager.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if p, ok := cfg.GetProject(projectName); ok && filepath.Clean(p.WorkspacePath) != dir {
+		return fmt.Errorf("project '%s' already exists at %s", projectName, p.WorkspacePath)
+	}
+
+	if err := checkoutReview(repoURL, refspec, dir); err != nil {
+		return err
+	}
+	projectConfig, err := configManager.LoadProjectConfig(dir)
+	if err != nil {
+		return fmt.Errorf("failed to load project config: %w", err)
+	}
+	if projectConfig == nil {
+		return fmt.Errorf("%s has no devbox.json; nothing to bring up (checkout kept in %s)", label, dir)
+	}
+
+	ttlUpFlag, ephemeralUpFlag, keepRunningUpFlag = reviewTTLFlag, true, true
+	if err := runUp(dir, projectName); err != nil {
+		return err
+	}
+
+	if cfg, err = configManager.Load(); err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	project := &config.Project{
+		Name:          projectName,
+		BoxName:       boxNameFor(cfg, projectName),
+		BaseImage:     cfg.GetEffectiveBaseImage(&config.Project{Name: projectName}, projectConfig),
+		WorkspacePath: dir,
+		Status:        "running",
+	}
+	cfg.MergeProjectConfig(project, projectConfig)
+	cfg.AddProject(project)
+	if err := configManager.Save(cfg); err != nil {
+		return fmt.Errorf("failed to save configuration: %w", err)
+	}
+
+	commit, _ := runGit(dir, "log", "-1", "--format=%h %s")
+	fmt.Printf("\nReview environment ready for %s\n", label)
+	fmt.Printf("  Commit:    %s\n", commit)
+	fmt.Printf("  Project:   %s\n", projectName)
+	fmt.Printf("  Workspace: %s\n", dir)
+	if len(projectConfig.Ports) > 0 {
+		fmt.Printf("  Ports:     %s\n", strings.Join(projectConfig.Ports, ", "))
+	}
+	fmt.Printf("\n  devbox shell %s      # open a shell\n", projectName)
+	fmt.Printf("  devbox review %s      # fetch new commits and update\n", ref)
+	fmt.Printf("  devbox destroy %s     # remove it now\n", projectName)
+	return nil
+}
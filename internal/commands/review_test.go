@@ -0,0 +1,94 @@
+package commands
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestReviewRefspec(t *testing.T) {
+	tests := []struct {
+		ref, url              string
+		wantRefspec, wantSlug string
+	}{
+		{"123", "git@github.com:acme/webapp.git", "refs/pull/123/head", "pr-123"},
+		{"#7", "https://github.com/acme/webapp", "refs/pull/7/head", "pr-7"},
+		{"42", "https://gitlab.example.com/acme/webapp.git", "refs/merge-requests/42/head", "mr-42"},
+		{"feature/Login.v2", "git@github.com:acme/webapp.git", "refs/heads/feature/Login.v2", "feature-login-v2"},
+		{"refs/heads/main", "/srv/git/webapp", "refs/heads/main", "main"},
+	}
+	for _, tt := range tests {
+		refspec, slug, _ := reviewRefspec(tt.ref, tt.url)
+		if refspec != tt.wantRefspec || slug != tt.wantSlug {
+			t.Errorf("reviewRefspec(%q, %q) = %q, %q; want %q, %q", tt.ref, tt.url, refspec, slug, tt.wantRefspec, tt.wantSlug)
+		}
+	}
+}
+
+func TestReviewProjectName(t *testing.T) {
+	tests := []struct {
+		url, want string
+	}{
+		{"git@github.com:acme/webapp.git", "webapp-review-pr-1"},
+		{"https://github.com/acme/Web.App/", "web-app-review-pr-1"},
+		{"git@host:webapp.git", "webapp-review-pr-1"},
+		{"/srv/git/api", "api-review-pr-1"},
+	}
+	for _, tt := range tests {
+		got := reviewProjectName(tt.url, "pr-1")
+		if got != tt.want {
+			t.Errorf("reviewProjectName(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+		if err := validateProjectName(got); err != nil {
+			t.Errorf("reviewProjectName(%q) = %q is not a valid project name: %v", tt.url, got, err)
+		}
+	}
+}
+
+func TestCheckoutReview(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	withTempConfigManager(t)
+
+	origin := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", origin, "-c", "user.name=t", "-c", "user.email=t@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "--quiet", "--initial-branch=main")
+	git("commit", "--quiet", "--allow-empty", "-m", "base")
+	if err := os.WriteFile(filepath.Join(origin, "devbox.json"), []byte(`{"name": "webapp"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("add", "devbox.json")
+	git("commit", "--quiet", "-m", "add devbox.json")
+	git("update-ref", "refs/pull/5/head", "HEAD")
+	git("reset", "--quiet", "--hard", "HEAD~1")
+
+	refspec, slug, _ := reviewRefspec("5", origin)
+	dir := filepath.Join(reviewsDir(), reviewProjectName(origin, slug))
+	if err := checkoutReview(origin, refspec, dir); err != nil {
+		t.Fatalf("checkoutReview() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "devbox.json")); err != nil {
+		t.Errorf("pull request checkout is missing devbox.json: %v", err)
+	}
+	if !isReviewWorkspace(dir) || isReviewWorkspace(reviewsDir()) || isReviewWorkspace(origin) {
+		t.Error("isReviewWorkspace() should only match folders inside the reviews directory")
+	}
+
+	if err := checkoutReview(origin, "refs/heads/main", dir); err != nil {
+		t.Fatalf("checkoutReview() on an existing clone error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "devbox.json")); !os.IsNotExist(err) {
+		t.Error("re-running should check out the new ref")
+	}
+	if err := checkoutReview(origin, "refs/heads/missing", dir); err == nil {
+		t.Error("unknown refs should fail")
+	}
+}
@@ -11,12 +11,15 @@ import (
 
 	"devbox/internal/config"
 	"devbox/internal/docker"
+	"devbox/internal/parallel"
 )
 
 var (
 	configManager *config.ConfigManager
 	dockerClient  *docker.Client
 	forceFlag     bool
+	parallelFlag  bool
+	workersFlag   int
 )
 
 var rootCmd = &cobra.Command{
@@ -35,18 +38,57 @@ var rootCmd = &cobra.Command{
 			return fmt.Errorf("failed to initialize config: %w", err)
 		}
 
+		if err := maybeRunFirstSetup(cmd); err != nil {
+			return fmt.Errorf("first-run setup failed: %w", err)
+		}
+		if cmd == setupCmd {
+			return nil
+		}
+
+		configureParallelism(cmd)
+
+		if commandProjectConfig(cmd, args).KubernetesBackend() {
+			return checkKubernetesCommand(cmd)
+		}
+
+		if err := configureEngine(); err != nil {
+			if cmd == doctorCmd {
+				return nil
+			}
+			return err
+		}
+		if err := configureDockerHost(cmd, args); err != nil {
+			return err
+		}
+		autodetectDockerSocket()
+
 		if err := docker.IsDockerAvailable(); err != nil {
-			return fmt.Errorf("docker availability check failed: %w", err)
+			if cmd == doctorCmd {
+				return nil
+			}
+			return dockerUnavailableError(err)
 		}
 
 		dockerClient, err = docker.NewClient()
 		if err != nil {
 			return fmt.Errorf("failed to initialize Docker client: %w", err)
 		}
+		if cfg, err := configManager.Load(); err == nil {
+			dockerClient.SetBoxDefaults(globalBoxDefaults(cfg.Settings))
+			dockerClient.SetStopTimeout(config.ResolveTimeouts(cfg.Settings, nil).Stop)
+		}
+		dockerClient.SetWorkspaceSync(docker.IsRemoteEndpoint(docker.DaemonEndpoint()))
+		dockerClient.SetSecretResolver(boxSecretResolver)
+		dockerClient.SetBoxMetadata(boxMetadataFunc(boxCreationSource(cmd)))
 
 		return nil
 	},
 	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		if structuredOutput(cmd) {
+			stdout := os.Stdout
+			os.Stdout = os.Stderr
+			defer func() { os.Stdout = stdout }()
+		}
 
 		if configManager != nil && dockerClient != nil {
 			if cfg, err := configManager.Load(); err == nil && cfg.Settings != nil && cfg.Settings.AutoStopOnExit {
@@ -71,6 +113,9 @@ var rootCmd = &cobra.Command{
 }
 
 func Execute() error {
+	if path, ok := pluginCommandFor(os.Args[1:]); ok {
+		return runPluginCommand(path, os.Args[1], os.Args[2:])
+	}
 	if err := rootCmd.Execute(); err != nil {
 		return fmt.Errorf("failed to execute root command: %w", err)
 	}
@@ -93,6 +138,48 @@ func init() {
 	rootCmd.AddCommand(completionCmd)
 
 	destroyCmd.Flags().BoolVarP(&forceFlag, "force", "f", false, "Force operation without confirmation")
+
+	rootCmd.PersistentFlags().BoolVar(&parallelFlag, "parallel", true, "Run setup commands and package queries in parallel (--parallel=false to disable)")
+	rootCmd.PersistentFlags().IntVar(&workersFlag, "workers", 0, "Number of parallel workers for setup commands and package queries")
+	rootCmd.PersistentFlags().StringVar(&engineFlag, "engine", "", "Container engine for this invocation: docker, podman or nerdctl (overrides DEVBOX_ENGINE and settings.engine)")
+	rootCmd.PersistentFlags().BoolVar(&noStartFlag, "no-start", false, "Never start a stopped box; fail instead")
+	rootCmd.PersistentFlags().BoolVar(&noHooksFlag, "no-hooks", false, "Skip the hooks in devbox.json for this invocation")
+}
+
+func configureParallelism(cmd *cobra.Command) {
+	if cfg, err := configManager.Load(); err == nil && cfg.Settings != nil {
+		parallel.SetSettings(parallel.Overrides{
+			EnableParallel:      cfg.Settings.EnableParallel,
+			MaxWorkers:          cfg.Settings.MaxWorkers,
+			SetupCommandWorkers: cfg.Settings.SetupWorkers,
+			PackageQueryWorkers: cfg.Settings.QueryWorkers,
+			SetupTimeout:        config.ResolveTimeouts(cfg.Settings, nil).Setup,
+			Schedules:           setupScheduleOverrides(cfg.Settings.SetupSchedule),
+		})
+	}
+
+	var flags parallel.Overrides
+	if cmd.Flags().Changed("parallel") {
+		enabled := parallelFlag
+		flags.EnableParallel = &enabled
+	}
+	if cmd.Flags().Changed("workers") && workersFlag > 0 {
+		flags.MaxWorkers = workersFlag
+		flags.SetupCommandWorkers = workersFlag
+		flags.PackageQueryWorkers = workersFlag
+	}
+	parallel.SetFlagOverrides(flags)
+}
+
+func setupScheduleOverrides(schedules map[string]config.SetupSchedule) map[string]parallel.ScheduleOverride {
+	if len(schedules) == 0 {
+		return nil
+	}
+	out := make(map[string]parallel.ScheduleOverride, len(schedules))
+	for key, s := range schedules {
+		out[key] = parallel.ScheduleOverride{Priority: s.Priority, Weight: s.Weight}
+	}
+	return out
 }
 
 func validateProjectName(name string) error {
@@ -118,5 +205,11 @@ func getWorkspacePath(projectName string) (string, error) {
 		return "", fmt.Errorf("failed to get home directory: %w", err)
 	}
 
-	return filepath.Join(homeDir, "devbox", projectName), nil
+	root := filepath.Join(homeDir, "devbox")
+	if configManager != nil {
+		if cfg, err := configManager.Load(); err == nil && cfg.Settings != nil && cfg.Settings.WorkspaceRoot != "" {
+			root = expandHomePath(cfg.Settings.WorkspaceRoot, homeDir)
+		}
+	}
+	return filepath.Join(root, projectName), nil
 }
@@ -32,6 +32,9 @@ var runCmd = &cobra.Command{
 		if !exists {
 			return fmt.Errorf("project '%s' not found. Run 'devbox init %s' first", projectName, projectName)
 		}
+		if projectConfig := kubernetesProjectConfig(project); projectConfig != nil {
+			return kubeRun(project, projectConfig, command)
+		}
 
 		exists, err = dockerClient.BoxExists(project.BoxName)
 		if err != nil {
@@ -48,12 +51,18 @@ var runCmd = &cobra.Command{
 		}
 
 		if status != "running" {
+			if err := confirmAutoStart(project.BoxName); err != nil {
+				return err
+			}
+			projectConfig, _ := configManager.LoadProjectConfig(project.WorkspacePath)
+			reportHostCapacity(cfg, project.BoxName, projectConfig)
 			fmt.Printf("Starting box '%s'...\n", project.BoxName)
 			if err := dockerClient.StartBox(project.BoxName); err != nil {
 				return fmt.Errorf("failed to start box: %w", err)
 			}
 		}
 
+		ensureProjectSyncAgent(projectName, project)
 		if err := docker.RunCommand(project.BoxName, command); err != nil {
 			return fmt.Errorf("failed to run command: %w", err)
 		}
@@ -0,0 +1,472 @@
+package commands
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"devbox/internal/config"
+	"devbox/internal/docker"
+)
+
+var secretsOutputFlag string
+
+type secretStatus struct {
+	Name       string `json:"name"`
+	Source     string `json:"source"`
+	InjectedAs string `json:"injected_as"`
+	Status     string `json:"status"`
+}
+
+var secretsCmd = &cobra.Command{
+	Use:   "secrets",
+	Short: "Manage secrets injected into boxes",
+	Long: `Manage the secrets declared in devbox.json's secrets section. Secrets are resolved on
+the host when a box is created and injected as environment variables or files, so their
+values never appear in devbox.json or the lockfile.
+
+Values set with 'devbox secrets set' are encrypted under ~/.devbox/secrets. The key is
+DEVBOX_SECRETS_PASSPHRASE when set, otherwise a random key generated in
+~/.devbox/secrets/key on first use.
+
+Examples:
+  echo -n "$TOKEN" | devbox secrets set myproject NPM_TOKEN
+  devbox secrets list myproject
+  devbox secrets rm myproject NPM_TOKEN`,
+	Args: cobra.NoArgs,
+}
+
+var secretsSetCmd = &cobra.Command{
+	Use:   "set <project> <name>",
+	Short: "Store an encrypted secret value read from stdin",
+	Long: `Store the value of a secret for a project, read from stdin. A single trailing newline
+is removed. The value is used by secrets without a "from" source, or with store://<name>,
+the next time the box is created; run 'devbox update <project>' to recreate it.
+
+Examples:
+  echo -n "$TOKEN" | devbox secrets set myproject NPM_TOKEN
+  devbox secrets set myproject DB_PASSWORD < password.txt`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		project, name := args[0], args[1]
+		if err := validateProjectName(project); err != nil {
+			return err
+		}
+		if !config.IsSecretName(name) {
+			return fmt.Errorf("invalid secret name '%s' (use letters, digits, and underscores, not starting with a digit)", name)
+		}
+		if stdinIsTerminal() {
+			fmt.Printf("Enter the value for %s, then press Ctrl-D:\n", name)
+		}
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read secret value: %w", err)
+		}
+		value := strings.TrimSuffix(strings.TrimSuffix(string(data), "\n"), "\r")
+		if value == "" {
+			return fmt.Errorf("secret value is empty")
+		}
+		store, err := loadSecretStore(project)
+		if err != nil {
+			return err
+		}
+		store[name] = value
+		if err := saveSecretStore(project, store); err != nil {
+			return err
+		}
+		fmt.Printf("Stored secret %s for project '%s'\n", name, project)
+		return nil
+	},
+}
+
+var secretsListCmd = &cobra.Command{
+	Use:   "list <project>",
+	Short: "List a project's secrets without their values",
+	Long: `List the secrets declared in the project's devbox.json and the values stored with
+'devbox secrets set', with where each comes from, how it is injected, and whether it can
+be resolved. Values are never printed. pass:// and op:// sources are not checked, since
+looking them up may prompt.
+
+Examples:
+  devbox secrets list myproject
+  devbox secrets list myproject -o json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFormat(secretsOutputFlag); err != nil {
+			return err
+		}
+		if err := validateProjectName(args[0]); err != nil {
+			return err
+		}
+		if secretsOutputFlag == outputTable {
+			return runSecretsList(os.Stdout, args[0], secretsOutputFlag)
+		}
+		stdout := os.Stdout
+		return withStdoutToStderr(func() error {
+			return runSecretsList(stdout, args[0], secretsOutputFlag)
+		})
+	},
+}
+
+var secretsRmCmd = &cobra.Command{
+	Use:   "rm <project> <name>...",
+	Short: "Remove stored secret values",
+	Args:  cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		project := args[0]
+		if err := validateProjectName(project); err != nil {
+			return err
+		}
+		store, err := loadSecretStore(project)
+		if err != nil {
+			return err
+		}
+		var missing []string
+		for _, name := range args[1:] {
+			if _, ok := store[name]; !ok {
+				missing = append(missing, name)
+				continue
+			}
+			delete(store, name)
+			fmt.Printf("Removed secret %s from project '%s'\n", name, project)
+		}
+		if err := saveSecretStore(project, store); err != nil {
+			return err
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("no stored value for %s in project '%s'", strings.Join(missing, ", "), project)
+		}
+		return nil
+	},
+}
+
+func init() {
+	addOutputFlag(secretsListCmd, &secretsOutputFlag)
+	secretsCmd.AddCommand(secretsSetCmd)
+	secretsCmd.AddCommand(secretsListCmd)
+	secretsCmd.AddCommand(secretsRmCmd)
+	rootCmd.AddCommand(secretsCmd)
+}
+
+func secretsDir() string {
+	return filepath.Join(configManager.ConfigDir(), "secrets")
+}
+
+func secretStorePath(project string) string {
+	return filepath.Join(secretsDir(), project+encryptedSuffix)
+}
+
+func secretsPassphrase(create bool) (string, error) {
+	if p := os.Getenv("DEVBOX_SECRETS_PASSPHRASE"); p != "" {
+		return p, nil
+	}
+	keyPath := filepath.Join(secretsDir(), "key")
+	data, err := os.ReadFile(keyPath)
+	if err == nil {
+		if key := strings.TrimSpace(string(data)); key != "" {
+			return key, nil
+		}
+		return "", fmt.Errorf("secrets key %s is empty", keyPath)
+	}
+	if !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to read secrets key: %w", err)
+	}
+	if !create {
+		return "", fmt.Errorf("no secrets key: set DEVBOX_SECRETS_PASSPHRASE or store a value with 'devbox secrets set'")
+	}
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate secrets key: %w", err)
+	}
+	if err := os.MkdirAll(secretsDir(), 0700); err != nil {
+		return "", fmt.Errorf("failed to create secrets directory: %w", err)
+	}
+	key := hex.EncodeToString(raw)
+	if err := os.WriteFile(keyPath, []byte(key+"\n"), 0600); err != nil {
+		return "", fmt.Errorf("failed to write secrets key: %w", err)
+	}
+	return key, nil
+}
+
+func loadSecretStore(project string) (map[string]string, error) {
+	store := map[string]string{}
+	data, err := os.ReadFile(secretStorePath(project))
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secrets for '%s': %w", project, err)
+	}
+	passphrase, err := secretsPassphrase(false)
+	if err != nil {
+		return nil, err
+	}
+	var plain bytes.Buffer
+	if err := decryptStream(&plain, bytes.NewReader(data), passphrase); err != nil {
+		return nil, fmt.Errorf("failed to decrypt secrets for '%s' (wrong DEVBOX_SECRETS_PASSPHRASE or corrupted file)", project)
+	}
+	if err := json.Unmarshal(plain.Bytes(), &store); err != nil {
+		return nil, fmt.Errorf("failed to parse secrets for '%s': %w", project, err)
+	}
+	return store, nil
+}
+
+func saveSecretStore(project string, store map[string]string) error {
+	path := secretStorePath(project)
+	if len(store) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove secrets for '%s': %w", project, err)
+		}
+		return nil
+	}
+	passphrase, err := secretsPassphrase(true)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(store)
+	if err != nil {
+		return err
+	}
+	var enc bytes.Buffer
+	if err := encryptStream(&enc, bytes.NewReader(data), passphrase); err != nil {
+		return fmt.Errorf("failed to encrypt secrets: %w", err)
+	}
+	if err := os.MkdirAll(secretsDir(), 0700); err != nil {
+		return fmt.Errorf("failed to create secrets directory: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, enc.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to write secrets: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write secrets: %w", err)
+	}
+	return nil
+}
+
+func secretFilePath(ref, workspace string) string {
+	if ref == "~" || strings.HasPrefix(ref, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, strings.TrimPrefix(ref, "~"))
+		}
+	}
+	if !filepath.IsAbs(ref) && workspace != "" {
+		return filepath.Join(workspace, ref)
+	}
+	return ref
+}
+
+func resolveSecret(project, name string, s config.Secret, workspace string, store map[string]string) ([]byte, error) {
+	scheme, ref := s.Source(name)
+	switch scheme {
+	case config.SecretSourceEnv:
+		value, ok := os.LookupEnv(ref)
+		if !ok {
+			return nil, fmt.Errorf("secret %s: environment variable %s is not set", name, ref)
+		}
+		return []byte(value), nil
+	case config.SecretSourceFile:
+		data, err := os.ReadFile(secretFilePath(ref, workspace))
+		if err != nil {
+			return nil, fmt.Errorf("secret %s: %w", name, err)
+		}
+		return data, nil
+	case config.SecretSourcePass:
+		return secretCommandOutput(name, "pass", "show", ref)
+	case config.SecretSourceOp:
+		return secretCommandOutput(name, "op", "read", ref)
+	case config.SecretSourceStore:
+		value, ok := store[ref]
+		if !ok {
+			return nil, fmt.Errorf("secret %s: no stored value for %s\nhint: run 'devbox secrets set %s %s'", name, ref, project, ref)
+		}
+		return []byte(value), nil
+	}
+	return nil, fmt.Errorf("secret %s: unsupported source '%s'", name, s.From)
+}
+
+func secretCommandOutput(name, tool string, args ...string) ([]byte, error) {
+	if _, err := exec.LookPath(tool); err != nil {
+		return nil, fmt.Errorf("secret %s: %s is not installed", name, tool)
+	}
+	cmd := exec.Command(tool, args...)
+	cmd.Stdin = os.Stdin
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("secret %s: %s %s failed: %s", name, tool, args[0], msg)
+		}
+		return nil, fmt.Errorf("secret %s: %s %s failed: %w", name, tool, args[0], err)
+	}
+	return out, nil
+}
+
+func secretEnvValue(scheme string, raw []byte) string {
+	value := string(raw)
+	if scheme == config.SecretSourcePass {
+		value, _, _ = strings.Cut(value, "\n")
+	}
+	return strings.TrimRight(value, "\r\n")
+}
+
+func secretsFromConfig(cfgMap map[string]interface{}) (map[string]config.Secret, error) {
+	raw, ok := cfgMap["secrets"]
+	if !ok {
+		return nil, nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	var secrets map[string]config.Secret
+	if err := json.Unmarshal(data, &secrets); err != nil {
+		return nil, fmt.Errorf("invalid secrets: %w", err)
+	}
+	return secrets, nil
+}
+
+func resolveBoxSecrets(project, workspace string, secrets map[string]config.Secret) (*docker.SecretValues, error) {
+	var store map[string]string
+	for name, s := range secrets {
+		if scheme, _ := s.Source(name); scheme == config.SecretSourceStore {
+			loaded, err := loadSecretStore(project)
+			if err != nil {
+				return nil, err
+			}
+			store = loaded
+			break
+		}
+	}
+	return resolveBoxSecretsWithStore(project, workspace, secrets, store)
+}
+
+func resolveBoxSecretsWithStore(project, workspace string, secrets map[string]config.Secret, store map[string]string) (*docker.SecretValues, error) {
+	values := &docker.SecretValues{Env: map[string]string{}, Files: map[string][]byte{}}
+	names := make([]string, 0, len(secrets))
+	for name := range secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		s := secrets[name]
+		raw, err := resolveSecret(project, name, s, workspace, store)
+		if err != nil {
+			return nil, err
+		}
+		if s.File != "" {
+			values.Files[s.File] = raw
+			continue
+		}
+		scheme, _ := s.Source(name)
+		values.Env[name] = secretEnvValue(scheme, raw)
+	}
+	return values, nil
+}
+
+func boxSecretResolver(boxName, workspaceHost string, cfgMap map[string]interface{}) (*docker.SecretValues, error) {
+	secrets, err := secretsFromConfig(cfgMap)
+	if err != nil || len(secrets) == 0 {
+		return &docker.SecretValues{}, err
+	}
+	if isReviewWorkspace(workspaceHost) && !allowReviewSecretsFlag {
+		return nil, fmt.Errorf("refusing to resolve secrets for review checkout %s: its devbox.json is untrusted\nhint: pass --allow-secrets to 'devbox review' if you trust this change", workspaceHost)
+	}
+	project, _ := cfgMap["name"].(string)
+	if cfg, err := configManager.Load(); err == nil {
+		for name, p := range cfg.GetProjects() {
+			if p.BoxName == boxName {
+				project = name
+				break
+			}
+		}
+	}
+	if project == "" {
+		project = filepath.Base(workspaceHost)
+	}
+	return resolveBoxSecrets(project, workspaceHost, secrets)
+}
+
+func secretStatuses(project, workspace string, secrets map[string]config.Secret, store map[string]string) []secretStatus {
+	var out []secretStatus
+	for name, s := range secrets {
+		scheme, _ := s.Source(name)
+		st := secretStatus{Name: name, Source: firstNonEmpty(s.From, "store://"+name), InjectedAs: "env", Status: "ok"}
+		if s.File != "" {
+			st.InjectedAs = s.File
+		}
+		switch scheme {
+		case config.SecretSourcePass, config.SecretSourceOp:
+			st.Status = "not checked"
+		default:
+			if _, err := resolveSecret(project, name, s, workspace, store); err != nil {
+				st.Status = "missing"
+			}
+		}
+		out = append(out, st)
+	}
+	for name := range store {
+		used := false
+		for n, s := range secrets {
+			if scheme, ref := s.Source(n); scheme == config.SecretSourceStore && ref == name {
+				used = true
+				break
+			}
+		}
+		if !used {
+			out = append(out, secretStatus{Name: name, Source: "store://" + name, InjectedAs: "-", Status: "unused"})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func runSecretsList(w io.Writer, project, format string) error {
+	store, err := loadSecretStore(project)
+	if err != nil {
+		return err
+	}
+	var secrets map[string]config.Secret
+	workspace := ""
+	if cfg, err := configManager.Load(); err == nil {
+		if p, ok := cfg.GetProject(project); ok {
+			workspace = p.WorkspacePath
+			if pc, err := configManager.LoadProjectConfig(p.WorkspacePath); err == nil && pc != nil {
+				secrets = pc.Secrets
+			}
+		}
+	}
+	return printSecretStatuses(w, secretStatuses(project, workspace, secrets, store), format)
+}
+
+func printSecretStatuses(out io.Writer, statuses []secretStatus, format string) error {
+	if statuses == nil {
+		statuses = []secretStatus{}
+	}
+	if format == outputJSON || format == outputYAML {
+		return writeStructured(out, format, statuses)
+	}
+	if len(statuses) == 0 {
+		fmt.Fprintln(out, "No secrets declared or stored.")
+		return nil
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSOURCE\tINJECTED AS\tSTATUS")
+	for _, s := range statuses {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", s.Name, s.Source, s.InjectedAs, s.Status)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,132 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"devbox/internal/config"
+)
+
+func TestSecretStoreRoundTrip(t *testing.T) {
+	withTempConfigManager(t)
+	t.Setenv("DEVBOX_SECRETS_PASSPHRASE", "")
+
+	if store, err := loadSecretStore("web"); err != nil || len(store) != 0 {
+		t.Fatalf("loadSecretStore() on empty store = %v, %v", store, err)
+	}
+	if err := saveSecretStore("web", map[string]string{"NPM_TOKEN": "npm_abc"}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(secretStorePath("web"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data[:len(backupCryptMagic)]) != backupCryptMagic {
+		t.Errorf("store file is not encrypted")
+	}
+	if info, err := os.Stat(filepath.Join(secretsDir(), "key")); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("secrets key = %v, %v, want mode 0600", info, err)
+	}
+	store, err := loadSecretStore("web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(store, map[string]string{"NPM_TOKEN": "npm_abc"}) {
+		t.Errorf("loadSecretStore() = %v", store)
+	}
+
+	t.Setenv("DEVBOX_SECRETS_PASSPHRASE", "wrong")
+	if _, err := loadSecretStore("web"); err == nil {
+		t.Error("loadSecretStore() with the wrong passphrase succeeded")
+	}
+	t.Setenv("DEVBOX_SECRETS_PASSPHRASE", "")
+
+	if err := saveSecretStore("web", map[string]string{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(secretStorePath("web")); !os.IsNotExist(err) {
+		t.Errorf("store file still exists after removing every secret: %v", err)
+	}
+}
+
+func TestResolveBoxSecrets(t *testing.T) {
+	workspace := t.TempDir()
+	if err := os.WriteFile(filepath.Join(workspace, ".pgpass"), []byte("db:5432:*:dev:hunter2\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GH_TOKEN", "ghp_abc")
+	store := map[string]string{"NPM_TOKEN": "npm_abc"}
+	secrets := map[string]config.Secret{
+		"GITHUB_TOKEN": {From: "env://GH_TOKEN"},
+		"PGPASS":       {From: "file://.pgpass"},
+		"PGPASS_FILE":  {From: "file://.pgpass", File: "/home/dev/.pgpass"},
+	}
+	values, err := resolveBoxSecretsWithStore("web", workspace, secrets, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantEnv := map[string]string{"GITHUB_TOKEN": "ghp_abc", "PGPASS": "db:5432:*:dev:hunter2"}
+	if !reflect.DeepEqual(values.Env, wantEnv) {
+		t.Errorf("Env = %v, want %v", values.Env, wantEnv)
+	}
+	if got := string(values.Files["/home/dev/.pgpass"]); got != "db:5432:*:dev:hunter2\n" {
+		t.Errorf("Files[/home/dev/.pgpass] = %q", got)
+	}
+
+	if _, err := resolveSecret("web", "NPM_TOKEN", config.Secret{}, workspace, store); err != nil {
+		t.Errorf("resolveSecret(store) error = %v", err)
+	}
+	if _, err := resolveSecret("web", "MISSING", config.Secret{}, workspace, store); err == nil {
+		t.Error("resolveSecret() for a missing stored value succeeded")
+	}
+	if _, err := resolveSecret("web", "A", config.Secret{From: "env://DEVBOX_TEST_UNSET"}, workspace, store); err == nil {
+		t.Error("resolveSecret() for an unset environment variable succeeded")
+	}
+	if got := secretEnvValue(config.SecretSourcePass, []byte("s3cret\nurl: https://db\n")); got != "s3cret" {
+		t.Errorf("secretEnvValue(pass) = %q, want the first line", got)
+	}
+}
+
+func TestBoxSecretResolverRefusesReviews(t *testing.T) {
+	withTempConfigManager(t)
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "hunter2")
+	cfgMap := map[string]interface{}{"secrets": map[string]interface{}{"AWS": map[string]interface{}{"from": "env://AWS_SECRET_ACCESS_KEY"}}}
+	review := filepath.Join(reviewsDir(), "webapp-review-pr-7")
+
+	if values, err := boxSecretResolver("devbox_webapp-review-pr-7", review, cfgMap); err == nil {
+		t.Fatalf("boxSecretResolver() for a review checkout = %v, want an error", values.Env)
+	}
+	allowReviewSecretsFlag = true
+	defer func() { allowReviewSecretsFlag = false }()
+	values, err := boxSecretResolver("devbox_webapp-review-pr-7", review, cfgMap)
+	if err != nil {
+		t.Fatalf("boxSecretResolver() with --allow-secrets error = %v", err)
+	}
+	if values.Env["AWS"] != "hunter2" {
+		t.Errorf("Env[AWS] = %q, want the resolved value", values.Env["AWS"])
+	}
+}
+
+func TestSecretStatuses(t *testing.T) {
+	t.Setenv("GH_TOKEN", "ghp_abc")
+	secrets := map[string]config.Secret{
+		"GITHUB_TOKEN": {From: "env://GH_TOKEN"},
+		"NPM_TOKEN":    {},
+		"DB_PASSWORD":  {From: "op://dev/db/password"},
+		"DEPLOY_KEY":   {From: "file://missing-key", File: "/run/secrets/deploy"},
+	}
+	store := map[string]string{"NPM_TOKEN": "npm_abc", "OLD_TOKEN": "x"}
+	got := secretStatuses("web", t.TempDir(), secrets, store)
+	want := []secretStatus{
+		{Name: "DB_PASSWORD", Source: "op://dev/db/password", InjectedAs: "env", Status: "not checked"},
+		{Name: "DEPLOY_KEY", Source: "file://missing-key", InjectedAs: "/run/secrets/deploy", Status: "missing"},
+		{Name: "GITHUB_TOKEN", Source: "env://GH_TOKEN", InjectedAs: "env", Status: "ok"},
+		{Name: "NPM_TOKEN", Source: "store://NPM_TOKEN", InjectedAs: "env", Status: "ok"},
+		{Name: "OLD_TOKEN", Source: "store://OLD_TOKEN", InjectedAs: "-", Status: "unused"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("secretStatuses() =\n%+v\nwant\n%+v", got, want)
+	}
+}
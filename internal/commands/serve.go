@@ -0,0 +1,182 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveMetricsAddr   string
+	serveDriftInterval time.Duration
+	serveNotifyFlag    bool
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve devbox metrics, watch boxes for drift, and enforce box ttls",
+	Long: `Run a long-lived process for monitoring and housekeeping.
+
+Always stops (or, for boxes started with 'devbox up --ephemeral', destroys) boxes whose
+ttl has run out, checking once a minute. When settings.gc.interval is set, also runs
+'devbox gc' without prompting each time the interval elapses.
+
+With --metrics, exposes Prometheus metrics at /metrics: box states, health, CPU, memory,
+and disk usage for every registered project, plus setup durations and apply/verify
+results recorded by earlier devbox commands.
+
+With --drift-interval, checks every running box against its devbox.lock.json on that
+interval. Results show in the DRIFT column of 'devbox list'. When a box starts drifting,
+devbox sends the drift.detected webhook and, with --notify, a desktop notification.
+
+Examples:
+  devbox serve --metrics :9090            # Scrape http://host:9090/metrics
+  devbox serve --metrics 127.0.0.1:9090   # Only reachable from this machine
+  devbox serve --drift-interval 30m --notify
+  devbox serve                            # Only enforce ttls`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if serveDriftInterval != 0 && serveDriftInterval < minDriftInterval {
+			return fmt.Errorf("--drift-interval must be at least %s", minDriftInterval)
+		}
+		if serveNotifyFlag && serveDriftInterval == 0 {
+			return fmt.Errorf("--notify requires --drift-interval")
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		go runTTLWatch(ctx)
+		go runGCWatch(ctx)
+		if serveDriftInterval > 0 {
+			fmt.Printf("Checking running boxes for drift every %s\n", serveDriftInterval)
+			go runDriftWatch(ctx, serveDriftInterval, serveNotifyFlag)
+		}
+		if serveMetricsAddr == "" {
+			fmt.Printf("Enforcing box ttls (Ctrl+C to stop)\n")
+			<-ctx.Done()
+			return nil
+		}
+
+		var mu sync.Mutex
+		mux := http.NewServeMux()
+		mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			var buf bytes.Buffer
+			writeMetricFamilies(&buf, collectMetricFamilies())
+			w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+			_, _ = w.Write(buf.Bytes())
+		})
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/" {
+				http.NotFound(w, r)
+				return
+			}
+			fmt.Fprintln(w, "devbox metrics: /metrics")
+		})
+
+		server := &http.Server{Addr: serveMetricsAddr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+		go func() {
+			<-ctx.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_ = server.Shutdown(ctx)
+		}()
+
+		fmt.Printf("Serving devbox metrics on %s/metrics (Ctrl+C to stop)\n", serveMetricsAddr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("metrics server failed: %w", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+	serveCmd.Flags().StringVar(&serveMetricsAddr, "metrics", "", "Address to expose Prometheus metrics on, e.g. :9090")
+	serveCmd.Flags().DurationVar(&serveDriftInterval, "drift-interval", 0, "Check running boxes against their lockfiles on this interval, e.g. 30m (0 disables)")
+	serveCmd.Flags().BoolVar(&serveNotifyFlag, "notify", false, "Show a desktop notification when a box starts drifting")
+}
+
+func collectMetricFamilies() []*metricFamily {
+	up := &metricFamily{name: "devbox_up", help: "Whether devbox could read its configuration and the container engine.", kind: "gauge"}
+	families := []*metricFamily{up}
+
+	cfg, err := configManager.Load()
+	if err != nil {
+		up.add(0)
+		return families
+	}
+
+	projectByBox := map[string]string{}
+	var boxNames []string
+	for name, p := range cfg.GetProjects() {
+		projectByBox[p.BoxName] = name
+		boxNames = append(boxNames, p.BoxName)
+	}
+	sort.Strings(boxNames)
+	rows := collectBoxUsage(cfg, boxNames)
+	up.add(1)
+
+	projects := &metricFamily{name: "devbox_projects", help: "Number of registered devbox projects.", kind: "gauge"}
+	projects.add(float64(len(boxNames)))
+	running := &metricFamily{name: "devbox_box_running", help: "Whether the project's box is running.", kind: "gauge"}
+	state := &metricFamily{name: "devbox_box_state", help: "Current container state of the project's box (1 for the active state).", kind: "gauge"}
+	healthy := &metricFamily{name: "devbox_box_healthy", help: "Health check result of the project's box: 1 healthy, 0 unhealthy or starting. Absent without a health_check.", kind: "gauge"}
+	cpu := &metricFamily{name: "devbox_box_cpu_percent", help: "CPU usage of the project's box in percent of one core.", kind: "gauge"}
+	memory := &metricFamily{name: "devbox_box_memory_bytes", help: "Memory used by the project's box.", kind: "gauge"}
+	disk := &metricFamily{name: "devbox_box_disk_bytes", help: "Writable layer size of the project's box.", kind: "gauge"}
+
+	for _, r := range rows {
+		labels := []string{"project", projectByBox[r.Box], "box", r.Box}
+		isRunning := 0.0
+		if r.State == "running" {
+			isRunning = 1
+		}
+		running.add(isRunning, labels...)
+		state.add(1, append(labels, "state", r.State)...)
+		if r.State == "running" {
+			cpu.add(r.CPU, labels...)
+			memory.add(float64(r.Memory), labels...)
+			if h, err := dockerClient.GetHealth(r.Box); err == nil && h.Configured() {
+				value := 0.0
+				if h.Status == "healthy" {
+					value = 1
+				}
+				healthy.add(value, labels...)
+			}
+		}
+		disk.add(float64(r.Disk), labels...)
+	}
+
+	footprint := &metricFamily{name: "devbox_disk_bytes", help: "Disk used by devbox, by kind.", kind: "gauge"}
+	footprint.add(float64(devboxImageBytes(rows)), "kind", "images")
+	footprint.add(float64(devboxVolumeBytes(rows)), "kind", "volumes")
+
+	drifted := &metricFamily{name: "devbox_box_drifted", help: "Whether the last drift check found the project's box out of sync with its lockfile. Absent until devbox serve --drift-interval has checked it.", kind: "gauge"}
+	records := loadDriftRecords()
+	for _, r := range rows {
+		project := projectByBox[r.Box]
+		rec, ok := records[project]
+		if !ok || (rec.Status != driftDrifted && rec.Status != driftNone) {
+			continue
+		}
+		value := 0.0
+		if rec.Status == driftDrifted {
+			value = 1
+		}
+		drifted.add(value, "project", project, "box", r.Box)
+	}
+
+	families = append(families, projects, running, state, healthy, cpu, memory, disk, drifted, footprint)
+	return append(families, storeMetricFamilies(loadMetricsStore())...)
+}
@@ -0,0 +1,174 @@
+package commands
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"devbox/internal/config"
+	"devbox/internal/docker"
+)
+
+func serviceNetworkName(boxName string) string {
+	return boxName + "_net"
+}
+
+func serviceContainerName(boxName, service string) string {
+	return boxName + "." + service
+}
+
+func serviceHash(svc *config.Service) string {
+	data, _ := json.Marshal(svc)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+func serviceSpecs(boxName, workspaceHost string, services map[string]*config.Service) ([]docker.ServiceSpec, error) {
+	order, err := config.ServiceStartOrder(services)
+	if err != nil {
+		return nil, err
+	}
+	specs := make([]docker.ServiceSpec, 0, len(order))
+	for _, name := range order {
+		svc := services[name]
+		specs = append(specs, docker.ServiceSpec{
+			Name:          serviceContainerName(boxName, name),
+			Service:       name,
+			Box:           boxName,
+			Network:       serviceNetworkName(boxName),
+			Image:         svc.Image,
+			Environment:   svc.Environment,
+			Ports:         svc.Ports,
+			Volumes:       svc.Volumes,
+			WorkspaceHost: workspaceHost,
+			Hash:          serviceHash(svc),
+		})
+	}
+	return specs, nil
+}
+
+func joinServiceNetwork(boxName string, pc *config.ProjectConfig) error {
+	if len(pc.Services) == 0 {
+		return nil
+	}
+	return dockerClient.ConnectNetwork(serviceNetworkName(boxName), boxName)
+}
+
+func checkServiceImages(cfg *config.Config, services map[string]*config.Service) error {
+	names := make([]string, 0, len(services))
+	for name := range services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := checkImagePolicy(cfg, services[name].Image); err != nil {
+			return fmt.Errorf("services.%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func upServices(boxName, workspaceHost string, services map[string]*config.Service) error {
+	existing, err := dockerClient.ListServices(boxName)
+	if err != nil {
+		return err
+	}
+	if len(services) == 0 && len(existing) == 0 {
+		return nil
+	}
+	current := map[string]docker.ServiceInfo{}
+	for _, s := range existing {
+		if _, ok := services[s.Service]; !ok {
+			fmt.Printf("Removing service '%s' (no longer in devbox.json)...\n", s.Service)
+			if err := dockerClient.RemoveBox(s.Name); err != nil {
+				return fmt.Errorf("failed to remove service %s: %w", s.Service, err)
+			}
+			continue
+		}
+		current[s.Service] = s
+	}
+	if len(services) == 0 {
+		return dockerClient.RemoveNetwork(serviceNetworkName(boxName))
+	}
+
+	specs, err := serviceSpecs(boxName, workspaceHost, services)
+	if err != nil {
+		return err
+	}
+	if err := dockerClient.EnsureNetwork(serviceNetworkName(boxName)); err != nil {
+		return err
+	}
+	for _, spec := range specs {
+		if s, ok := current[spec.Service]; ok {
+			if s.Hash == spec.Hash {
+				if !s.Running() {
+					fmt.Printf("Starting service '%s'...\n", spec.Service)
+					if err := dockerClient.StartBox(spec.Name); err != nil {
+						return fmt.Errorf("failed to start service %s: %w", spec.Service, err)
+					}
+				}
+				continue
+			}
+			fmt.Printf("Recreating service '%s' (definition changed)...\n", spec.Service)
+			if err := dockerClient.RemoveBox(spec.Name); err != nil {
+				return fmt.Errorf("failed to remove service %s: %w", spec.Service, err)
+			}
+		}
+		if err := dockerClient.PullImage(spec.Image); err != nil {
+			return err
+		}
+		fmt.Printf("Starting service '%s' (%s)...\n", spec.Service, spec.Image)
+		if err := dockerClient.CreateService(spec); err != nil {
+			return err
+		}
+		if err := dockerClient.StartBox(spec.Name); err != nil {
+			return fmt.Errorf("failed to start service %s: %w", spec.Service, err)
+		}
+	}
+	return nil
+}
+
+func stopServices(boxName string) error {
+	services, err := dockerClient.ListServices(boxName)
+	if err != nil {
+		return err
+	}
+	for i := len(services) - 1; i >= 0; i-- {
+		s := services[i]
+		if !s.Running() {
+			continue
+		}
+		fmt.Printf("Stopping service '%s'...\n", s.Service)
+		if err := dockerClient.StopBox(s.Name); err != nil {
+			return fmt.Errorf("failed to stop service %s: %w", s.Service, err)
+		}
+	}
+	return nil
+}
+
+func removeServices(boxName string) error {
+	services, err := dockerClient.ListServices(boxName)
+	if err != nil {
+		return err
+	}
+	for _, s := range services {
+		fmt.Printf("Removing service '%s'...\n", s.Service)
+		if err := dockerClient.RemoveBox(s.Name); err != nil {
+			return fmt.Errorf("failed to remove service %s: %w", s.Service, err)
+		}
+	}
+	return dockerClient.RemoveNetwork(serviceNetworkName(boxName))
+}
+
+func printServices(boxName string) {
+	services, err := dockerClient.ListServices(boxName)
+	if err != nil || len(services) == 0 {
+		return
+	}
+	fmt.Printf("Services (network %s):\n", serviceNetworkName(boxName))
+	for _, s := range services {
+		fmt.Printf("  %s\t%s\t%s\t%s\n", s.Service, s.State, s.Image, s.Name)
+	}
+}
@@ -0,0 +1,51 @@
+package commands
+
+import (
+	"testing"
+
+	"devbox/internal/config"
+)
+
+func TestServiceSpecs(t *testing.T) {
+	services := map[string]*config.Service{
+		"api":   {Image: "api:dev", DependsOn: []string{"db", "cache"}},
+		"db":    {Image: "postgres:16", Volumes: []string{"pgdata:/var/lib/postgresql/data"}},
+		"cache": {Image: "redis:7"},
+	}
+	specs, err := serviceSpecs("devbox_web", "/home/me/web", services)
+	if err != nil {
+		t.Fatalf("serviceSpecs() error = %v", err)
+	}
+	var order []string
+	for _, s := range specs {
+		order = append(order, s.Service)
+		if s.Network != "devbox_web_net" || s.Box != "devbox_web" || s.WorkspaceHost != "/home/me/web" {
+			t.Errorf("spec %s = %+v", s.Service, s)
+		}
+	}
+	if got := order; len(got) != 3 || got[0] != "cache" || got[1] != "db" || got[2] != "api" {
+		t.Errorf("start order = %v, want [cache db api]", got)
+	}
+	if specs[1].Name != "devbox_web.db" {
+		t.Errorf("container name = %q, want devbox_web.db", specs[1].Name)
+	}
+
+	if _, err := serviceSpecs("devbox_web", "", map[string]*config.Service{
+		"a": {Image: "x", DependsOn: []string{"b"}},
+		"b": {Image: "x", DependsOn: []string{"a"}},
+	}); err == nil {
+		t.Error("serviceSpecs() should reject a depends_on cycle")
+	}
+}
+
+func TestServiceHash(t *testing.T) {
+	a := &config.Service{Image: "postgres:16", Environment: map[string]string{"A": "1", "B": "2"}}
+	b := &config.Service{Image: "postgres:16", Environment: map[string]string{"B": "2", "A": "1"}}
+	if serviceHash(a) != serviceHash(b) {
+		t.Error("hash should not depend on map order")
+	}
+	c := &config.Service{Image: "postgres:17", Environment: a.Environment}
+	if serviceHash(a) == serviceHash(c) {
+		t.Error("hash should change when the image changes")
+	}
+}
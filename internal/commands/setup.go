@@ -0,0 +1,266 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"devbox/internal/config"
+)
+
+var setupDefaultsFlag bool
+
+var firstRunSkipCommands = map[string]bool{
+	"setup":                         true,
+	"completion":                    true,
+	"version":                       true,
+	"help":                          true,
+	cobra.ShellCompRequestCmd:       true,
+	cobra.ShellCompNoDescRequestCmd: true,
+}
+
+var setupCmd = &cobra.Command{
+	Use:   "setup",
+	Short: "Choose global defaults for devbox",
+	Long: `Walk through the global defaults devbox uses for new projects: default base image,
+workspace root, auto-stop, parallelism, and shell completion. Answers are written to
+the settings in ~/.devbox/config.json.
+
+setup runs automatically the first time devbox is used from a terminal. Run it again
+at any time to change your answers; the current values are offered as defaults.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !setupDefaultsFlag && !stdinIsTerminal() {
+			return fmt.Errorf("stdin is not a terminal; use 'devbox setup --defaults' to accept the defaults")
+		}
+		return runSetup(cmd.Root(), setupDefaultsFlag)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(setupCmd)
+	setupCmd.Flags().BoolVar(&setupDefaultsFlag, "defaults", false, "Write the default answers without prompting")
+}
+
+func maybeRunFirstSetup(cmd *cobra.Command) error {
+	if configManager.Exists() || firstRunSkipCommands[cmd.Name()] || !stdinIsTerminal() {
+		return nil
+	}
+	fmt.Printf("Welcome to devbox! Let's pick a few defaults before '%s' runs.\n", cmd.CommandPath())
+	fmt.Printf("Press Enter to accept the suggested value. Run 'devbox setup' later to change them.\n\n")
+	return runSetup(cmd.Root(), false)
+}
+
+func runSetup(root *cobra.Command, useDefaults bool) error {
+	cfg, err := configManager.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	shell := detectShell()
+	if useDefaults {
+		shell = ""
+	} else {
+		p := &setupPrompter{in: bufio.NewReader(os.Stdin), out: os.Stdout}
+		if shell, err = runSetupWizard(p, cfg.Settings, homeDir, shell); err != nil {
+			return err
+		}
+	}
+
+	workspaceRoot := expandHomePath(firstNonEmpty(cfg.Settings.WorkspaceRoot, "~/devbox"), homeDir)
+	if err := os.MkdirAll(workspaceRoot, 0755); err != nil {
+		return fmt.Errorf("failed to create workspace root %s: %w", workspaceRoot, err)
+	}
+	if err := configManager.Save(cfg); err != nil {
+		return fmt.Errorf("failed to save settings: %w", err)
+	}
+	fmt.Printf("\nSettings saved to %s\n", filepath.Join(configManager.ConfigDir(), "config.json"))
+
+	if shell != "" {
+		path, err := installCompletion(root, shell, homeDir)
+		if err != nil {
+			fmt.Printf("Warning: failed to install %s completion: %v\n", shell, err)
+			fmt.Printf("hint: see 'devbox completion --help' to install it manually\n")
+			return nil
+		}
+		fmt.Printf("Installed %s completion to %s\n", shell, path)
+		if shell == "zsh" {
+			fmt.Printf("hint: make sure ~/.zshrc adds %s to fpath before running compinit:\n", filepath.Dir(path))
+			fmt.Printf("  fpath=(%s $fpath)\n", filepath.Dir(path))
+		}
+		fmt.Printf("Start a new shell for completion to take effect.\n")
+	}
+	return nil
+}
+
+func runSetupWizard(p *setupPrompter, settings *config.GlobalSettings, homeDir, shell string) (string, error) {
+	image, err := p.ask("Default base image for new projects", firstNonEmpty(settings.DefaultBaseImage, "ubuntu:22.04"), func(s string) error {
+		if strings.ContainsAny(s, " \t") {
+			return fmt.Errorf("image names cannot contain spaces")
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	settings.DefaultBaseImage = image
+
+	root, err := p.ask("Workspace root for project folders", firstNonEmpty(settings.WorkspaceRoot, "~/devbox"), func(s string) error {
+		if !filepath.IsAbs(expandHomePath(s, homeDir)) {
+			return fmt.Errorf("use an absolute path or one starting with ~/")
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	settings.WorkspaceRoot = filepath.Clean(expandHomePath(root, homeDir))
+	if settings.WorkspaceRoot == filepath.Join(homeDir, "devbox") {
+		settings.WorkspaceRoot = ""
+	}
+
+	if settings.AutoStopOnExit, err = p.confirm("Stop idle boxes automatically when devbox exits?", settings.AutoStopOnExit); err != nil {
+		return "", err
+	}
+
+	enabled := settings.EnableParallel == nil || *settings.EnableParallel
+	if enabled, err = p.confirm("Run setup commands and package queries in parallel?", enabled); err != nil {
+		return "", err
+	}
+	settings.EnableParallel = &enabled
+	if enabled {
+		workers, err := p.ask("Maximum parallel workers (0 = based on CPU count)", strconv.Itoa(settings.MaxWorkers), func(s string) error {
+			if n, err := strconv.Atoi(s); err != nil || n < 0 {
+				return fmt.Errorf("enter a whole number of 0 or more")
+			}
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+		settings.MaxWorkers, _ = strconv.Atoi(workers)
+	}
+
+	if shell == "" {
+		return "", nil
+	}
+	install, err := p.confirm(fmt.Sprintf("Install %s completion for devbox?", shell), true)
+	if err != nil || !install {
+		return "", err
+	}
+	return shell, nil
+}
+
+type setupPrompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+func (p *setupPrompter) ask(question, def string, validate func(string) error) (string, error) {
+	for {
+		fmt.Fprintf(p.out, "%s [%s]: ", question, def)
+		line, err := p.in.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", fmt.Errorf("failed to read input: %w", err)
+		}
+		answer := strings.TrimSpace(line)
+		if answer == "" {
+			answer = def
+		}
+		verr := validate(answer)
+		if verr == nil {
+			return answer, nil
+		}
+		fmt.Fprintf(p.out, "error: %v\n", verr)
+		if err == io.EOF {
+			return "", fmt.Errorf("no valid answer for %q", question)
+		}
+	}
+}
+
+func (p *setupPrompter) confirm(question string, def bool) (bool, error) {
+	hint := "y/N"
+	if def {
+		hint = "Y/n"
+	}
+	answer, err := p.ask(question, hint, func(s string) error {
+		switch strings.ToLower(s) {
+		case "y", "yes", "n", "no", "y/n":
+			return nil
+		}
+		return fmt.Errorf("answer y or n")
+	})
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(answer) {
+	case "y", "yes":
+		return true, nil
+	case "n", "no":
+		return false, nil
+	}
+	return def, nil
+}
+
+func expandHomePath(path, homeDir string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		return filepath.Join(homeDir, strings.TrimPrefix(path, "~"))
+	}
+	return path
+}
+
+func detectShell() string {
+	switch shell := filepath.Base(os.Getenv("SHELL")); shell {
+	case "bash", "zsh", "fish":
+		return shell
+	}
+	return ""
+}
+
+func completionInstallPath(shell, homeDir string) (string, error) {
+	switch shell {
+	case "bash":
+		dataHome := firstNonEmpty(os.Getenv("XDG_DATA_HOME"), filepath.Join(homeDir, ".local", "share"))
+		return filepath.Join(dataHome, "bash-completion", "completions", "devbox"), nil
+	case "zsh":
+		return filepath.Join(homeDir, ".zsh", "completions", "_devbox"), nil
+	case "fish":
+		configHome := firstNonEmpty(os.Getenv("XDG_CONFIG_HOME"), filepath.Join(homeDir, ".config"))
+		return filepath.Join(configHome, "fish", "completions", "devbox.fish"), nil
+	}
+	return "", fmt.Errorf("unsupported shell %q", shell)
+}
+
+func installCompletion(root *cobra.Command, shell, homeDir string) (string, error) {
+	path, err := completionInstallPath(shell, homeDir)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	switch shell {
+	case "bash":
+		err = root.GenBashCompletion(f)
+	case "zsh":
+		err = root.GenZshCompletion(f)
+	case "fish":
+		err = root.GenFishCompletion(f, true)
+	}
+	return path, err
+}
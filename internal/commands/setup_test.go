@@ -0,0 +1,115 @@
+package commands
+
+import (
+	"bufio"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"devbox/internal/config"
+)
+
+func TestRunSetupWizard(t *testing.T) {
+	home := "/home/dev"
+	tests := []struct {
+		name      string
+		input     string
+		shell     string
+		want      config.GlobalSettings
+		wantShell string
+	}{
+		{
+			name:      "accept defaults",
+			input:     "\n\n\n\n\n\n",
+			shell:     "bash",
+			want:      config.GlobalSettings{DefaultBaseImage: "ubuntu:22.04", AutoStopOnExit: true},
+			wantShell: "bash",
+		},
+		{
+			name:  "custom answers",
+			input: "debian:12\n~/code/boxes\nn\ny\n4\nn\n",
+			shell: "zsh",
+			want: config.GlobalSettings{
+				DefaultBaseImage: "debian:12",
+				WorkspaceRoot:    "/home/dev/code/boxes",
+				MaxWorkers:       4,
+			},
+		},
+		{
+			name:  "retry invalid answers and disable parallelism",
+			input: "ubuntu 24.04\nubuntu:24.04\nrelative/dir\n/srv/devbox\nmaybe\ny\nno\n",
+			want: config.GlobalSettings{
+				DefaultBaseImage: "ubuntu:24.04",
+				WorkspaceRoot:    "/srv/devbox",
+				AutoStopOnExit:   true,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := &config.GlobalSettings{DefaultBaseImage: "ubuntu:22.04", AutoStopOnExit: true}
+			p := &setupPrompter{in: bufio.NewReader(strings.NewReader(tt.input)), out: io.Discard}
+			shell, err := runSetupWizard(p, settings, home, tt.shell)
+			if err != nil {
+				t.Fatalf("runSetupWizard() error = %v", err)
+			}
+			if shell != tt.wantShell {
+				t.Errorf("completion shell = %q, want %q", shell, tt.wantShell)
+			}
+			if settings.DefaultBaseImage != tt.want.DefaultBaseImage || settings.WorkspaceRoot != tt.want.WorkspaceRoot ||
+				settings.AutoStopOnExit != tt.want.AutoStopOnExit || settings.MaxWorkers != tt.want.MaxWorkers {
+				t.Errorf("settings = %+v, want %+v", *settings, tt.want)
+			}
+			wantParallel := tt.name != "retry invalid answers and disable parallelism"
+			if settings.EnableParallel == nil || *settings.EnableParallel != wantParallel {
+				t.Errorf("EnableParallel = %v, want %t", settings.EnableParallel, wantParallel)
+			}
+		})
+	}
+}
+
+func TestRunSetupWizardEOF(t *testing.T) {
+	settings := &config.GlobalSettings{}
+	p := &setupPrompter{in: bufio.NewReader(strings.NewReader("")), out: io.Discard}
+	if _, err := runSetupWizard(p, settings, "/home/dev", ""); err != nil {
+		t.Fatalf("runSetupWizard() on empty input should accept defaults, got %v", err)
+	}
+	if settings.DefaultBaseImage != "ubuntu:22.04" {
+		t.Errorf("DefaultBaseImage = %q, want ubuntu:22.04", settings.DefaultBaseImage)
+	}
+}
+
+func TestCompletionInstallPath(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("XDG_CONFIG_HOME", "")
+	home := "/home/dev"
+	tests := map[string]string{
+		"bash": filepath.Join(home, ".local/share/bash-completion/completions/devbox"),
+		"zsh":  filepath.Join(home, ".zsh/completions/_devbox"),
+		"fish": filepath.Join(home, ".config/fish/completions/devbox.fish"),
+	}
+	for shell, want := range tests {
+		if got, err := completionInstallPath(shell, home); err != nil || got != want {
+			t.Errorf("completionInstallPath(%q) = %q, %v; want %q", shell, got, err, want)
+		}
+	}
+	if _, err := completionInstallPath("tcsh", home); err == nil {
+		t.Error("completionInstallPath(tcsh) should fail")
+	}
+}
+
+func TestExpandHomePath(t *testing.T) {
+	tests := map[string]string{
+		"~":          "/home/dev",
+		"~/devbox":   "/home/dev/devbox",
+		"/srv/boxes": "/srv/boxes",
+		"~other/x":   "~other/x",
+	}
+	for in, want := range tests {
+		if got := expandHomePath(in, "/home/dev"); got != want {
+			t.Errorf("expandHomePath(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
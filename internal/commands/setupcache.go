@@ -0,0 +1,92 @@
+package commands
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+const setupStateFile = "/etc/devbox-setup-steps"
+
+func setupStepHashes(commands []string) []string {
+	hashes := make([]string, len(commands))
+	prev := ""
+	for i, cmd := range commands {
+		sum := sha256.Sum256([]byte(prev + "\x00" + strings.TrimSpace(cmd)))
+		prev = hex.EncodeToString(sum[:])
+		hashes[i] = prev
+	}
+	return hashes
+}
+
+func cachedSetupSteps(done, hashes []string) int {
+	n := 0
+	for n < len(done) && n < len(hashes) && done[n] == hashes[n] {
+		n++
+	}
+	return n
+}
+
+func readSetupState(client DockerClientInterface, boxName string) ([]string, bool) {
+	out, _, err := client.ExecCapture(boxName, "cat "+setupStateFile+" 2>/dev/null || echo __none__")
+	if err != nil {
+		return nil, false
+	}
+	if strings.TrimSpace(out) == "__none__" {
+		return nil, false
+	}
+	var done []string
+	for _, line := range strings.Split(out, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			done = append(done, line)
+		}
+	}
+	return done, true
+}
+
+func writeSetupState(client DockerClientInterface, boxName string, hashes []string) error {
+	content := strings.Join(hashes, "\n")
+	if content != "" {
+		content += "\n"
+	}
+	script := fmt.Sprintf("printf '%%s' '%s' > %s", escapeBash(content), setupStateFile)
+	if _, stderr, err := client.ExecCapture(boxName, script); err != nil {
+		return fmt.Errorf("failed to record setup state: %s", firstNonEmpty(strings.TrimSpace(stderr), err.Error()))
+	}
+	return nil
+}
+
+func runSetupSteps(client DockerClientInterface, boxName string, commands []string, noCache bool) error {
+	hashes := setupStepHashes(commands)
+	skip := 0
+	if !noCache {
+		done, _ := readSetupState(client, boxName)
+		skip = cachedSetupSteps(done, hashes)
+	}
+	switch {
+	case skip == len(commands):
+		fmt.Printf("Setup commands unchanged; skipping all %d step(s) (--no-cache to re-run)\n", skip)
+		return nil
+	case skip > 0:
+		fmt.Printf("Skipping %d unchanged setup step(s); running %d (--no-cache to re-run all)\n", skip, len(commands)-skip)
+	}
+	defer dropPackageSnapshot(boxName)
+	if err := client.ExecuteSetupCommandsWithOutput(boxName, commands[skip:], false); err != nil {
+		if werr := writeSetupState(client, boxName, hashes[:skip]); werr != nil {
+			fmt.Printf("Warning: %v\n", werr)
+		}
+		return err
+	}
+	if err := writeSetupState(client, boxName, hashes); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+	return nil
+}
+
+func adoptSetupState(client DockerClientInterface, boxName string, commands []string) (bool, error) {
+	if _, ok := readSetupState(client, boxName); ok {
+		return false, nil
+	}
+	return true, writeSetupState(client, boxName, setupStepHashes(commands))
+}
@@ -0,0 +1,122 @@
+package commands
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeSetupClient struct {
+	state   *string
+	ran     [][]string
+	failRun bool
+}
+
+func (f *fakeSetupClient) PullImage(string) error { return nil }
+func (f *fakeSetupClient) CreateBoxWithConfig(string, string, string, string, interface{}) (string, error) {
+	return "", nil
+}
+func (f *fakeSetupClient) StartBox(string) error                           { return nil }
+func (f *fakeSetupClient) WaitForBox(string, time.Duration) error          { return nil }
+func (f *fakeSetupClient) SetupDevboxInBoxWithUpdate(string, string) error { return nil }
+func (f *fakeSetupClient) QueryPackagesParallel(string) ([]string, []string, []string, []string, []string) {
+	return nil, nil, nil, nil, nil
+}
+
+func (f *fakeSetupClient) ExecuteSetupCommandsWithOutput(_ string, commands []string, _ bool) error {
+	f.ran = append(f.ran, commands)
+	if f.failRun {
+		return errors.New("step failed")
+	}
+	return nil
+}
+
+func (f *fakeSetupClient) ExecCapture(_ string, command string) (string, string, error) {
+	if strings.HasPrefix(command, "cat ") {
+		if f.state == nil {
+			return "__none__\n", "", nil
+		}
+		return *f.state, "", nil
+	}
+	start := strings.Index(command, "'%s' '") + len("'%s' '")
+	end := strings.LastIndex(command, "' > ")
+	s := command[start:end]
+	f.state = &s
+	return "", "", nil
+}
+
+func TestSetupStepHashes(t *testing.T) {
+	a := setupStepHashes([]string{"apt install -y git", "pip install ruff==0.4.4", "echo done"})
+	b := setupStepHashes([]string{"apt install -y git", "pip install ruff==0.5.0", "echo done"})
+	if a[0] != b[0] {
+		t.Errorf("first step hash changed although the command did not")
+	}
+	if a[1] == b[1] || a[2] == b[2] {
+		t.Errorf("hashes after a changed step must change too")
+	}
+	if got := cachedSetupSteps(a, b); got != 1 {
+		t.Errorf("cachedSetupSteps() = %d, want 1", got)
+	}
+	if got := cachedSetupSteps(a, a[:2]); got != 2 {
+		t.Errorf("cachedSetupSteps() with fewer steps = %d, want 2", got)
+	}
+	if got := cachedSetupSteps(nil, a); got != 0 {
+		t.Errorf("cachedSetupSteps(nil) = %d, want 0", got)
+	}
+}
+
+func TestRunSetupSteps(t *testing.T) {
+	withTempConfigManager(t)
+	steps := []string{"apt install -y git", "pip install ruff==0.4.4", "echo done"}
+	client := &fakeSetupClient{}
+
+	writePackageSnapshot("box", &packageSnapshot{StartedAt: "s", CapturedAt: time.Now()})
+	if err := runSetupSteps(client, "box", steps, false); err != nil {
+		t.Fatalf("first run: %v", err)
+	}
+	if readPackageSnapshot("box") != nil {
+		t.Error("package snapshot should be dropped after setup steps run")
+	}
+	edited := []string{steps[0], steps[1], "echo changed"}
+	if err := runSetupSteps(client, "box", edited, false); err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+	if err := runSetupSteps(client, "box", edited, false); err != nil {
+		t.Fatalf("third run: %v", err)
+	}
+	if err := runSetupSteps(client, "box", edited, true); err != nil {
+		t.Fatalf("no-cache run: %v", err)
+	}
+	want := [][]string{steps, {"echo changed"}, edited}
+	if !reflect.DeepEqual(client.ran, want) {
+		t.Errorf("ran = %v, want %v", client.ran, want)
+	}
+
+	client.failRun = true
+	if err := runSetupSteps(client, "box", []string{steps[0], "false"}, false); err == nil {
+		t.Fatalf("expected failure")
+	}
+	done, _ := readSetupState(client, "box")
+	if !reflect.DeepEqual(done, setupStepHashes(steps[:1])) {
+		t.Errorf("state after failure = %v, want only the unchanged first step", done)
+	}
+}
+
+func TestAdoptSetupState(t *testing.T) {
+	steps := []string{"apt install -y git"}
+	client := &fakeSetupClient{}
+	adopted, err := adoptSetupState(client, "box", steps)
+	if err != nil || !adopted {
+		t.Fatalf("adoptSetupState() = %v, %v; want true, nil", adopted, err)
+	}
+	adopted, err = adoptSetupState(client, "box", []string{"other"})
+	if err != nil || adopted {
+		t.Fatalf("adoptSetupState() on recorded box = %v, %v; want false, nil", adopted, err)
+	}
+	done, _ := readSetupState(client, "box")
+	if !reflect.DeepEqual(done, setupStepHashes(steps)) {
+		t.Errorf("state = %v, want hashes of the adopted steps", done)
+	}
+}
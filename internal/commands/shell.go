@@ -3,25 +3,51 @@ package commands
 import (
 	"fmt"
 	"os/exec"
+	"sort"
+	"strings"
 
 	"github.com/spf13/cobra"
 
+	"devbox/internal/config"
 	"devbox/internal/docker"
 )
 
-var keepRunningFlag bool
+var (
+	keepRunningFlag bool
+	noBridgeFlag    bool
+	readOnlyFlag    bool
+	shellOwnerFlag  string
+	shellTmuxFlag   bool
+)
 
 var shellCmd = &cobra.Command{
 	Use:   "shell <project>",
 	Short: "Open an interactive shell in the project box",
-	Long:  `Attach an interactive bash shell to the specified project's box.`,
-	Args:  cobra.ExactArgs(1),
+	Long: `Attach an interactive bash shell to the specified project's box.
+
+With --read-only, the shell runs as an unprivileged user with the workspace and
+every other mount remounted read-only, so you can look around a teammate's box
+on a shared server without changing it.
+
+With --tmux, the shell is a tmux session inside the box, laid out with the windows
+and panes from the "tmux" section of devbox.json. The session is created on first
+attach and reused afterwards, so detaching (Ctrl-b d) keeps its programs running.`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		projectName := args[0]
 
 		if err := validateProjectName(projectName); err != nil {
 			return err
 		}
+		if shellOwnerFlag != "" && !readOnlyFlag {
+			return fmt.Errorf("--owner requires --read-only")
+		}
+		if shellTmuxFlag && readOnlyFlag {
+			return fmt.Errorf("--tmux can't be combined with --read-only")
+		}
+		if readOnlyFlag {
+			return runReadOnlyShell(projectName, shellOwnerFlag)
+		}
 
 		cfg, err := configManager.Load()
 		if err != nil {
@@ -32,6 +58,12 @@ var shellCmd = &cobra.Command{
 		if !exists {
 			return fmt.Errorf("project '%s' not found. Run 'devbox init %s' first", projectName, projectName)
 		}
+		if projectConfig := kubernetesProjectConfig(project); projectConfig != nil {
+			if shellTmuxFlag {
+				return fmt.Errorf("--tmux is not available with the kubernetes backend yet")
+			}
+			return kubeShell(project, projectConfig)
+		}
 
 		exists, err = dockerClient.BoxExists(project.BoxName)
 		if err != nil {
@@ -48,6 +80,11 @@ var shellCmd = &cobra.Command{
 		}
 
 		if status != "running" {
+			if err := confirmAutoStart(project.BoxName); err != nil {
+				return err
+			}
+			projectConfig, _ := configManager.LoadProjectConfig(project.WorkspacePath)
+			reportHostCapacity(cfg, project.BoxName, projectConfig)
 			fmt.Printf("Starting box '%s'...\n", project.BoxName)
 			if err := dockerClient.StartBox(project.BoxName); err != nil {
 				return fmt.Errorf("failed to start box: %w", err)
@@ -55,18 +92,48 @@ var shellCmd = &cobra.Command{
 		}
 
 		checkCmd := exec.Command(engineCmd(), "exec", project.BoxName, "test", "-f", "/etc/devbox-initialized")
-		if checkCmd.Run() != nil {
+		wrapperCmd := exec.Command(engineCmd(), "exec", project.BoxName, "grep", "-q", "DEVBOX_BRIDGE", "/usr/local/bin/devbox")
+		if checkCmd.Run() != nil || (!noBridgeFlag && wrapperCmd.Run() != nil) {
 			fmt.Printf("Setting up devbox commands in box...\n")
 			if err := dockerClient.SetupDevboxInBox(project.BoxName, projectName); err != nil {
 				return fmt.Errorf("failed to setup devbox in box: %w", err)
 			}
 		}
 
-		fmt.Printf("Attaching to box '%s'...\n", project.BoxName)
-		if err := docker.AttachShell(project.BoxName); err != nil {
-			return fmt.Errorf("failed to attach shell: %w", err)
+		var env []string
+		if !noBridgeFlag {
+			bridge, err := startHostBridge(project)
+			if err != nil {
+				fmt.Printf("Warning: host bridge unavailable (%v); 'devbox lock/verify/apply' will not work inside the box\n", err)
+			} else {
+				defer bridge.Close()
+				env = bridge.Env()
+			}
 		}
 
+		ensureProjectSyncAgent(projectName, project)
+		hooks := projectHookTarget(project)
+		if err := runHooks(config.HookPreShell, hooks); err != nil {
+			return err
+		}
+		if shellTmuxFlag {
+			var tmux *config.Tmux
+			if projectConfig, err := configManager.LoadProjectConfig(project.WorkspacePath); err == nil {
+				tmux = projectConfig.Tmux
+			}
+			session := tmuxSessionName(projectName, tmux)
+			fmt.Printf("Attaching to tmux session '%s' in box '%s'...\n", session, project.BoxName)
+			if err := docker.AttachTmuxWithEnv(project.BoxName, tmuxScript(session, tmux, envNames(env)), env); err != nil {
+				return err
+			}
+		} else {
+			fmt.Printf("Attaching to box '%s'...\n", project.BoxName)
+			if err := docker.AttachShellWithEnv(project.BoxName, env); err != nil {
+				return fmt.Errorf("failed to attach shell: %w", err)
+			}
+		}
+		hookErr := runHooks(config.HookPostShell, hooks)
+
 		if !keepRunningFlag {
 			cfg, err := configManager.Load()
 			if err == nil && cfg.Settings != nil && cfg.Settings.AutoStopOnExit {
@@ -82,10 +149,81 @@ var shellCmd = &cobra.Command{
 			}
 		}
 
-		return nil
+		return hookErr
 	},
 }
 
+func runReadOnlyShell(projectName, owner string) error {
+	cfg, err := configManager.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	boxName, err := readOnlyTarget(cfg, projectName, owner)
+	if err != nil {
+		return err
+	}
+
+	status, err := dockerClient.GetBoxStatus(boxName)
+	if err != nil {
+		return fmt.Errorf("failed to get box status: %w", err)
+	}
+	if status != "running" {
+		return fmt.Errorf("box '%s' is %s; read-only shells never start boxes", boxName, status)
+	}
+
+	mounts, err := dockerClient.GetMountDestinations(boxName)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Attaching read-only to box '%s' (%d mount(s) read-only, unprivileged user)...\n", boxName, len(mounts))
+	return docker.AttachReadOnlyShell(boxName, mounts)
+}
+
+func readOnlyTarget(cfg *config.Config, projectName, owner string) (string, error) {
+	if owner == "" {
+		if project, ok := cfg.GetProject(projectName); ok {
+			return project.BoxName, nil
+		}
+	}
+	boxes, err := dockerClient.ListBoxes()
+	if err != nil {
+		return "", err
+	}
+	candidates := readOnlyCandidates(boxes, projectName, sanitizeBoxNamePart(owner))
+	switch len(candidates) {
+	case 0:
+		if owner != "" {
+			return "", fmt.Errorf("no box for project '%s' owned by '%s'", projectName, owner)
+		}
+		return "", fmt.Errorf("project '%s' not found and no shared box matches it", projectName)
+	case 1:
+		return candidates[0], nil
+	}
+	return "", fmt.Errorf("several boxes match project '%s': %s; pick one with --owner <user>", projectName, strings.Join(candidates, ", "))
+}
+
+func readOnlyCandidates(boxes []docker.BoxInfo, projectName, owner string) []string {
+	var names []string
+	for _, box := range boxes {
+		for _, name := range box.Names {
+			name = strings.TrimPrefix(name, "/")
+			switch {
+			case owner != "" && name == fmt.Sprintf("devbox_%s_%s", owner, projectName):
+			case owner == "" && (name == "devbox_"+projectName || (strings.HasPrefix(name, "devbox_") && strings.HasSuffix(name, "_"+projectName))):
+			default:
+				continue
+			}
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
 func init() {
+	shellCmd.Flags().BoolVar(&readOnlyFlag, "read-only", false, "Attach as an unprivileged user with all mounts read-only (for reviewing shared boxes)")
+	shellCmd.Flags().StringVar(&shellOwnerFlag, "owner", "", "With --read-only, open the box of this user's project (devbox_<owner>_<project>)")
 	shellCmd.Flags().BoolVar(&keepRunningFlag, "keep-running", false, "Keep the box running after exiting the shell")
+	shellCmd.Flags().BoolVar(&shellTmuxFlag, "tmux", false, "Start or attach to a tmux session laid out from the \"tmux\" section of devbox.json")
+	shellCmd.Flags().BoolVar(&noBridgeFlag, "no-bridge", false, "Don't expose 'devbox lock/verify/apply' to the shell through the host bridge")
 }
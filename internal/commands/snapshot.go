@@ -0,0 +1,482 @@
+package commands
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"devbox/internal/config"
+)
+
+const snapshotTagPrefix = "snap-"
+
+var (
+	snapshotMessageFlag string
+	snapshotOutputFlag  string
+)
+
+var snapshotNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,100}$`)
+
+type snapshotEntry struct {
+	Name      string          `json:"name"`
+	Image     string          `json:"image"`
+	ImageID   string          `json:"image_id,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	Message   string          `json:"message,omitempty"`
+	Packages  *lockPackages   `json:"packages,omitempty"`
+	Lockfile  json.RawMessage `json:"lockfile,omitempty"`
+}
+
+type snapshotListEntry struct {
+	Name      string    `json:"name"`
+	Image     string    `json:"image"`
+	CreatedAt time.Time `json:"created_at"`
+	Message   string    `json:"message,omitempty"`
+	Size      int64     `json:"size"`
+	Missing   bool      `json:"missing,omitempty"`
+}
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Create, list, diff, restore, and delete box snapshots",
+	Long: `Save the state of a project's box as an image and roll back to it later, for example
+after a setup command broke the box.
+
+Snapshots are tagged devbox/<project>:snap-<name>. Their metadata, including the installed
+packages and devbox.lock.json at the time, is kept in ~/.devbox/snapshots/<project>.json.
+Snapshots are never removed by 'devbox gc'.`,
+}
+
+var snapshotCreateCmd = &cobra.Command{
+	Use:   "create <project> [name]",
+	Short: "Snapshot a project's box",
+	Long: `Commit the project's box to devbox/<project>:snap-<name>. Without a name the current
+UTC time is used. When the box is running, its installed packages are recorded so
+'devbox snapshot diff' can show what changed later.
+
+Examples:
+  devbox snapshot create myproject
+  devbox snapshot create myproject before-cuda -m "before installing CUDA"`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := ""
+		if len(args) > 1 {
+			name = args[1]
+		}
+		return createSnapshot(args[0], name, snapshotMessageFlag)
+	},
+}
+
+var snapshotListCmd = &cobra.Command{
+	Use:   "list <project>",
+	Short: "List a project's snapshots",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFormat(snapshotOutputFlag); err != nil {
+			return err
+		}
+		return listSnapshots(args[0], snapshotOutputFlag)
+	},
+}
+
+var snapshotDiffCmd = &cobra.Command{
+	Use:   "diff <project> <name>",
+	Short: "Show packages changed since a snapshot",
+	Long: `Compare the packages recorded with a snapshot against the packages installed in the box
+now. Lines start with '+' for added, '-' for removed, and '~' for changed versions.
+
+Examples:
+  devbox snapshot diff myproject before-cuda`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return diffSnapshot(args[0], args[1])
+	},
+}
+
+var snapshotRestoreCmd = &cobra.Command{
+	Use:   "restore <project> <name>",
+	Short: "Replace a project's box with a snapshot",
+	Long: `Recreate the project's box from a snapshot image. The workspace is mounted as usual, so
+files in it are not rolled back. devbox.lock.json is restored when it was saved with the
+snapshot.
+
+Examples:
+  devbox snapshot restore myproject before-cuda
+  devbox snapshot restore myproject before-cuda --force`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return restoreSnapshot(args[0], args[1])
+	},
+}
+
+var snapshotDeleteCmd = &cobra.Command{
+	Use:   "delete <project> <name>...",
+	Short: "Delete snapshots",
+	Args:  cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return deleteSnapshots(args[0], args[1:])
+	},
+}
+
+func init() {
+	snapshotCreateCmd.Flags().StringVarP(&snapshotMessageFlag, "message", "m", "", "Describe the snapshot")
+	addOutputFlag(snapshotListCmd, &snapshotOutputFlag)
+	snapshotRestoreCmd.Flags().BoolVarP(&forceFlag, "force", "f", false, "Restore without asking for confirmation")
+	snapshotCmd.AddCommand(snapshotCreateCmd)
+	snapshotCmd.AddCommand(snapshotListCmd)
+	snapshotCmd.AddCommand(snapshotDiffCmd)
+	snapshotCmd.AddCommand(snapshotRestoreCmd)
+	snapshotCmd.AddCommand(snapshotDeleteCmd)
+	rootCmd.AddCommand(snapshotCmd)
+}
+
+func snapshotStorePath(projectName string) string {
+	return filepath.Join(configManager.ConfigDir(), "snapshots", projectName+".json")
+}
+
+func loadSnapshots(projectName string) ([]snapshotEntry, error) {
+	data, err := os.ReadFile(snapshotStorePath(projectName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshots: %w", err)
+	}
+	var entries []snapshotEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("invalid snapshot metadata in %s: %w", snapshotStorePath(projectName), err)
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].CreatedAt.Before(entries[j].CreatedAt) })
+	return entries, nil
+}
+
+func saveSnapshots(projectName string, entries []snapshotEntry) error {
+	path := snapshotStorePath(projectName)
+	if len(entries) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to save snapshots: %w", err)
+		}
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to save snapshots: %w", err)
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to save snapshots: %w", err)
+	}
+	return nil
+}
+
+func findSnapshot(entries []snapshotEntry, name string) (int, bool) {
+	for i, e := range entries {
+		if e.Name == name {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+func validateSnapshotName(name string) error {
+	if !snapshotNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid snapshot name '%s' (use letters, digits, '.', '_' and '-', starting with a letter or digit)", name)
+	}
+	return nil
+}
+
+func snapshotImageRef(projectName, name string) string {
+	return fmt.Sprintf("devbox/%s:%s%s", projectName, snapshotTagPrefix, name)
+}
+
+func loadSnapshotProject(projectName string) (*config.Project, error) {
+	cfg, err := configManager.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+	project, ok := cfg.GetProject(projectName)
+	if !ok {
+		return nil, fmt.Errorf("project '%s' not found", projectName)
+	}
+	return project, nil
+}
+
+func createSnapshot(projectName, name, message string) error {
+	project, err := loadSnapshotProject(projectName)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	if name == "" {
+		name = now.Format("20060102-150405")
+	}
+	if err := validateSnapshotName(name); err != nil {
+		return err
+	}
+	entries, err := loadSnapshots(projectName)
+	if err != nil {
+		return err
+	}
+	if _, ok := findSnapshot(entries, name); ok {
+		return fmt.Errorf("snapshot '%s' already exists for project '%s'", name, projectName)
+	}
+
+	status, err := dockerClient.GetBoxStatus(project.BoxName)
+	if err != nil {
+		return fmt.Errorf("failed to get box status: %w", err)
+	}
+	if status == "not found" {
+		return fmt.Errorf("box '%s' not found; run 'devbox up %s' first", project.BoxName, projectName)
+	}
+	if err := checkBoxOwnership(project.BoxName); err != nil {
+		return err
+	}
+
+	entry := snapshotEntry{Name: name, Image: snapshotImageRef(projectName, name), CreatedAt: now, Message: message}
+	if status == "running" {
+		entry.Packages = &loadPackageSnapshot(project.BoxName).Packages
+	} else {
+		fmt.Printf("Box '%s' is %s; packages are not recorded, so 'devbox snapshot diff' will not work for this snapshot\n", project.BoxName, status)
+	}
+	if data, err := os.ReadFile(filepath.Join(project.WorkspacePath, "devbox.lock.json")); err == nil && json.Valid(data) {
+		entry.Lockfile = data
+	}
+
+	fmt.Printf("Snapshotting %s as %s...\n", project.BoxName, entry.Image)
+	labels := map[string]string{"devbox.project": projectName, "devbox.snapshot": name}
+	if entry.ImageID, err = dockerClient.CommitContainerWithLabels(project.BoxName, entry.Image, labels); err != nil {
+		return fmt.Errorf("failed to snapshot %s: %w", project.BoxName, err)
+	}
+	if err := saveSnapshots(projectName, append(entries, entry)); err != nil {
+		_ = dockerClient.RemoveImage(entry.Image)
+		return err
+	}
+	fmt.Printf("Created snapshot '%s' (%s)\n", name, formatBytes(measureImage(entry.Image).Size))
+	return nil
+}
+
+func listSnapshots(projectName, format string) error {
+	if _, err := loadSnapshotProject(projectName); err != nil {
+		return err
+	}
+	entries, err := loadSnapshots(projectName)
+	if err != nil {
+		return err
+	}
+	rows := []snapshotListEntry{}
+	for _, e := range entries {
+		row := snapshotListEntry{Name: e.Name, Image: e.Image, CreatedAt: e.CreatedAt, Message: e.Message}
+		if size, err := dockerClient.GetImageSize(e.Image); err == nil {
+			row.Size = size
+		} else {
+			row.Missing = true
+		}
+		rows = append(rows, row)
+	}
+	if format == outputJSON || format == outputYAML {
+		return writeStructured(os.Stdout, format, rows)
+	}
+	if len(rows) == 0 {
+		fmt.Printf("No snapshots for '%s'. Create one with 'devbox snapshot create %s'\n", projectName, projectName)
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tCREATED\tSIZE\tMESSAGE")
+	for _, r := range rows {
+		size := formatBytes(r.Size)
+		if r.Missing {
+			size = "missing"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Name, r.CreatedAt.Local().Format("2006-01-02 15:04"), size, r.Message)
+	}
+	return w.Flush()
+}
+
+func diffSnapshot(projectName, name string) error {
+	project, err := loadSnapshotProject(projectName)
+	if err != nil {
+		return err
+	}
+	entries, err := loadSnapshots(projectName)
+	if err != nil {
+		return err
+	}
+	i, ok := findSnapshot(entries, name)
+	if !ok {
+		return fmt.Errorf("snapshot '%s' not found for project '%s'", name, projectName)
+	}
+	if entries[i].Packages == nil {
+		return fmt.Errorf("snapshot '%s' has no recorded packages (the box was not running when it was taken)", name)
+	}
+
+	status, err := dockerClient.GetBoxStatus(project.BoxName)
+	if err != nil {
+		return fmt.Errorf("failed to get box status: %w", err)
+	}
+	if status == "not found" {
+		return fmt.Errorf("box '%s' not found; run 'devbox up %s' first", project.BoxName, projectName)
+	}
+	if status != "running" {
+		if err := startStoppedBox(project.BoxName); err != nil {
+			return err
+		}
+	}
+	current := loadPackageSnapshot(project.BoxName).Packages
+
+	lines := snapshotPackageDiff(*entries[i].Packages, current)
+	if len(lines) == 0 {
+		fmt.Printf("No package changes since snapshot '%s'\n", name)
+		return nil
+	}
+	fmt.Printf("Package changes since snapshot '%s' (%s):\n", name, entries[i].CreatedAt.Local().Format("2006-01-02 15:04"))
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+	return nil
+}
+
+func snapshotPackageDiff(before, after lockPackages) []string {
+	lists := func(p lockPackages) map[string][]string {
+		m := map[string][]string{"apt": p.Apt, "pip": p.Pip, "npm": p.Npm, "yarn": p.Yarn, "pnpm": p.Pnpm}
+		for name, pkgs := range p.Extra {
+			m[name] = pkgs
+		}
+		return m
+	}
+	beforeLists, afterLists := lists(before), lists(after)
+	managers := map[string]string{}
+	for name := range beforeLists {
+		managers[name] = name
+	}
+	for name := range afterLists {
+		managers[name] = name
+	}
+
+	var lines []string
+	for _, manager := range sortedKeys(managers) {
+		was, now := specsByName(manager, beforeLists[manager]), specsByName(manager, afterLists[manager])
+		names := map[string]string{}
+		for n, spec := range was {
+			names[n] = spec
+		}
+		for n, spec := range now {
+			names[n] = spec
+		}
+		for _, n := range sortedKeys(names) {
+			oldSpec, hadOld := was[n]
+			newSpec, hasNew := now[n]
+			switch {
+			case !hadOld:
+				lines = append(lines, fmt.Sprintf("+ %s %s", manager, newSpec))
+			case !hasNew:
+				lines = append(lines, fmt.Sprintf("- %s %s", manager, oldSpec))
+			case oldSpec != newSpec:
+				lines = append(lines, fmt.Sprintf("~ %s %s -> %s", manager, oldSpec, newSpec))
+			}
+		}
+	}
+	return lines
+}
+
+func specsByName(manager string, specs []string) map[string]string {
+	m := map[string]string{}
+	for _, spec := range specs {
+		if spec = strings.TrimSpace(spec); spec != "" {
+			m[legacyPackageName(manager, spec)] = spec
+		}
+	}
+	return m
+}
+
+func restoreSnapshot(projectName, name string) error {
+	project, err := loadSnapshotProject(projectName)
+	if err != nil {
+		return err
+	}
+	entries, err := loadSnapshots(projectName)
+	if err != nil {
+		return err
+	}
+	i, ok := findSnapshot(entries, name)
+	if !ok {
+		return fmt.Errorf("snapshot '%s' not found for project '%s'", name, projectName)
+	}
+	entry := entries[i]
+	if _, err := dockerClient.GetImageSize(entry.Image); err != nil {
+		return fmt.Errorf("snapshot image %s is missing; delete the snapshot with 'devbox snapshot delete %s %s'", entry.Image, projectName, name)
+	}
+	if err := checkBoxOwnership(project.BoxName); err != nil {
+		return err
+	}
+
+	if !forceFlag {
+		prompter := &setupPrompter{in: bufio.NewReader(os.Stdin), out: os.Stdout}
+		ok, err := prompter.confirm(fmt.Sprintf("Restore '%s' to snapshot '%s'? The current box will be replaced", projectName, name), false)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Printf("Restore cancelled.\n")
+			return nil
+		}
+	}
+
+	if err := recreateBoxFromImage(project, entry.Image); err != nil {
+		return err
+	}
+	if len(entry.Lockfile) > 0 {
+		if err := os.WriteFile(filepath.Join(project.WorkspacePath, "devbox.lock.json"), entry.Lockfile, 0644); err != nil {
+			fmt.Printf("Warning: failed to restore devbox.lock.json: %v\n", err)
+		} else {
+			fmt.Printf("Restored devbox.lock.json from snapshot\n")
+		}
+	}
+	fmt.Printf("Restored %s to snapshot '%s'\n", projectName, name)
+	return nil
+}
+
+func deleteSnapshots(projectName string, names []string) error {
+	if _, err := loadSnapshotProject(projectName); err != nil {
+		return err
+	}
+	entries, err := loadSnapshots(projectName)
+	if err != nil {
+		return err
+	}
+	var failed []string
+	for _, name := range names {
+		i, ok := findSnapshot(entries, name)
+		if !ok {
+			fmt.Printf("error: snapshot '%s' not found for project '%s'\n", name, projectName)
+			failed = append(failed, name)
+			continue
+		}
+		if _, err := dockerClient.GetImageSize(entries[i].Image); err == nil {
+			if err := dockerClient.RemoveImage(entries[i].Image); err != nil {
+				fmt.Printf("error: %v\n", err)
+				failed = append(failed, name)
+				continue
+			}
+		}
+		entries = append(entries[:i], entries[i+1:]...)
+		fmt.Printf("Deleted snapshot '%s'\n", name)
+	}
+	if err := saveSnapshots(projectName, entries); err != nil {
+		return err
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to delete %d snapshot(s): %s", len(failed), strings.Join(failed, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestValidateSnapshotName(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{"before-cuda", false},
+		{"20260102-150405", false},
+		{"v1.2_rc", false},
+		{"", true},
+		{"-leading", true},
+		{".hidden", true},
+		{"has space", true},
+		{"a/b", true},
+	}
+	for _, tt := range tests {
+		if err := validateSnapshotName(tt.name); (err != nil) != tt.wantErr {
+			t.Errorf("validateSnapshotName(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSnapshotPackageDiff(t *testing.T) {
+	before := lockPackages{
+		Apt:   []string{"curl=7.88", "git=2.39"},
+		Pip:   []string{"requests==2.31.0"},
+		Extra: map[string][]string{"cargo": {"ripgrep@14.0.0"}},
+	}
+	after := lockPackages{
+		Apt:  []string{"curl=7.88", "git=2.40", "htop=3.2"},
+		Npm:  []string{"typescript@5.4.0"},
+		Pnpm: nil,
+	}
+	want := []string{
+		"~ apt git=2.39 -> git=2.40",
+		"+ apt htop=3.2",
+		"- cargo ripgrep@14.0.0",
+		"+ npm typescript@5.4.0",
+		"- pip requests==2.31.0",
+	}
+	if got := snapshotPackageDiff(before, after); !reflect.DeepEqual(got, want) {
+		t.Errorf("snapshotPackageDiff() = %q, want %q", got, want)
+	}
+	if got := snapshotPackageDiff(before, before); len(got) != 0 {
+		t.Errorf("snapshotPackageDiff() of identical packages = %q, want none", got)
+	}
+}
+
+func TestSnapshotStoreRoundTrip(t *testing.T) {
+	withTempConfigManager(t)
+
+	if entries, err := loadSnapshots("web"); err != nil || len(entries) != 0 {
+		t.Fatalf("loadSnapshots() on empty store = %v, %v", entries, err)
+	}
+
+	now := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	entries := []snapshotEntry{
+		{Name: "second", Image: snapshotImageRef("web", "second"), CreatedAt: now.Add(time.Hour)},
+		{Name: "first", Image: snapshotImageRef("web", "first"), CreatedAt: now, Packages: &lockPackages{Apt: []string{"git=2.39"}}},
+	}
+	if err := saveSnapshots("web", entries); err != nil {
+		t.Fatal(err)
+	}
+	got, err := loadSnapshots("web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Name != "first" || got[1].Name != "second" {
+		t.Fatalf("loadSnapshots() = %+v, want first then second", got)
+	}
+	if got[0].Image != "devbox/web:snap-first" || got[0].Packages == nil || got[0].Packages.Apt[0] != "git=2.39" {
+		t.Errorf("loadSnapshots()[0] = %+v", got[0])
+	}
+	if i, ok := findSnapshot(got, "second"); !ok || i != 1 {
+		t.Errorf("findSnapshot(second) = %d, %v", i, ok)
+	}
+
+	if err := saveSnapshots("web", nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := loadSnapshots("web"); len(got) != 0 {
+		t.Errorf("loadSnapshots() after clearing = %+v, want none", got)
+	}
+}
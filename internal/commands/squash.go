@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"fmt"
+
+	"devbox/internal/docker"
+)
+
+type imageFootprint struct {
+	Size   int64
+	Layers int
+}
+
+func (f imageFootprint) String() string {
+	unit := "layers"
+	if f.Layers == 1 {
+		unit = "layer"
+	}
+	return fmt.Sprintf("%s (%d %s)", formatBytes(f.Size), f.Layers, unit)
+}
+
+func squashReport(before, after imageFootprint) string {
+	out := fmt.Sprintf("Image size: %s -> %s", before, after)
+	if before.Size > 0 && after.Size < before.Size {
+		out += fmt.Sprintf(", %.0f%% smaller", float64(before.Size-after.Size)*100/float64(before.Size))
+	}
+	return out
+}
+
+func measureImage(ref string) imageFootprint {
+	size, _ := dockerClient.GetImageSize(ref)
+	layers, _ := dockerClient.GetImageLayerCount(ref)
+	return imageFootprint{Size: size, Layers: layers}
+}
+
+func commitBoxImage(boxName, imageTag string, labels map[string]string, squash bool) error {
+	id, err := dockerClient.CommitContainerWithLabels(boxName, imageTag, labels)
+	if err != nil {
+		return fmt.Errorf("failed to commit container: %w", err)
+	}
+	before := measureImage(imageTag)
+	if !squash {
+		fmt.Printf("Image size: %s\n", before)
+		return nil
+	}
+
+	imageConfig, err := dockerClient.GetImageConfig(imageTag)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Squashing '%s' into a single layer...\n", imageTag)
+	if err := dockerClient.SquashContainer(boxName, imageTag, docker.ImageConfigChanges(imageConfig)); err != nil {
+		return err
+	}
+	if err := dockerClient.RemoveImage(id); err != nil {
+		fmt.Printf("Warning: failed to remove unsquashed image: %v\n", err)
+	}
+	fmt.Println(squashReport(before, measureImage(imageTag)))
+	return nil
+}
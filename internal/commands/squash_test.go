@@ -0,0 +1,18 @@
+package commands
+
+import "testing"
+
+func TestSquashReport(t *testing.T) {
+	tests := []struct {
+		before, after imageFootprint
+		want          string
+	}{
+		{imageFootprint{2048 << 20, 14}, imageFootprint{1024 << 20, 1}, "Image size: 2.0GiB (14 layers) -> 1.0GiB (1 layer), 50% smaller"},
+		{imageFootprint{100 << 20, 3}, imageFootprint{100 << 20, 1}, "Image size: 100.0MiB (3 layers) -> 100.0MiB (1 layer)"},
+	}
+	for _, tt := range tests {
+		if got := squashReport(tt.before, tt.after); got != tt.want {
+			t.Errorf("squashReport() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"devbox/internal/parallel"
+)
+
+const (
+	sshBoxPort        = 2222
+	sshInstallCommand = "apt-get update -qq >/dev/null && apt-get install -y -qq openssh-server >/dev/null"
+)
+
+var (
+	sshKeyFlag         string
+	sshWriteConfigFlag bool
+	sshStdioFlag       bool
+)
+
+var sshCmd = &cobra.Command{
+	Use:   "ssh <project>",
+	Short: "Provision an SSH server in a box and print an SSH config entry for it",
+	Long: `Install and start OpenSSH in the project's box, authorize your public key, and print an
+SSH config entry so tools such as VS Code Remote-SSH and JetBrains Gateway can connect.
+
+If the box publishes port 2222, the entry connects to that host port directly. Otherwise it
+tunnels through 'devbox ssh <project> --stdio', so no port has to be mapped.
+
+Examples:
+  devbox ssh myproject                      # Provision and print the config entry
+  devbox ssh myproject --write-config       # Also add it to ~/.ssh/config
+  devbox ssh myproject --key ~/.ssh/work.pub`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projectName := args[0]
+		if err := validateProjectName(projectName); err != nil {
+			return err
+		}
+		cfg, err := configManager.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		project, ok := cfg.GetProject(projectName)
+		if !ok {
+			return fmt.Errorf("project '%s' not found", projectName)
+		}
+		if sshStdioFlag {
+			return sshStdio(project.BoxName)
+		}
+		return provisionSSH(projectName, project.BoxName)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(sshCmd)
+	sshCmd.Flags().StringVar(&sshKeyFlag, "key", "", "Public key to authorize (default: ~/.ssh/id_ed25519.pub, id_ecdsa.pub or id_rsa.pub)")
+	sshCmd.Flags().BoolVar(&sshWriteConfigFlag, "write-config", false, "Add or update the entry in ~/.ssh/config")
+	sshCmd.Flags().BoolVar(&sshStdioFlag, "stdio", false, "Relay stdin/stdout to the box's SSH server (used as a ProxyCommand)")
+	_ = sshCmd.Flags().MarkHidden("stdio")
+}
+
+func sshHostAlias(projectName string) string {
+	return "devbox-" + projectName
+}
+
+func findSSHPublicKey(homeDir string) (string, error) {
+	if sshKeyFlag != "" {
+		return sshKeyFlag, nil
+	}
+	for _, name := range []string{"id_ed25519.pub", "id_ecdsa.pub", "id_rsa.pub"} {
+		path := filepath.Join(homeDir, ".ssh", name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no SSH public key found in %s; create one with 'ssh-keygen -t ed25519' or pass --key", filepath.Join(homeDir, ".ssh"))
+}
+
+func sshStartScript(port int) string {
+	return fmt.Sprintf(`mkdir -p /run/sshd && { kill -0 "$(cat /run/sshd.pid 2>/dev/null)" 2>/dev/null || /usr/sbin/sshd -p %d -o PasswordAuthentication=no -o PermitRootLogin=prohibit-password; }`, port)
+}
+
+func sshProvisionScript(publicKey string, port int) string {
+	key := escapeBash(strings.TrimSpace(publicKey))
+	return strings.Join([]string{
+		"set -e",
+		fmt.Sprintf("if [ ! -x /usr/sbin/sshd ]; then export DEBIAN_FRONTEND=noninteractive; (%s); fi", parallel.WrapAptLock(sshInstallCommand)),
+		"mkdir -p ~/.ssh && chmod 700 ~/.ssh && touch ~/.ssh/authorized_keys && chmod 600 ~/.ssh/authorized_keys",
+		fmt.Sprintf("grep -qxF '%s' ~/.ssh/authorized_keys || echo '%s' >> ~/.ssh/authorized_keys", key, key),
+		"ssh-keygen -A >/dev/null",
+		sshStartScript(port),
+		"id -un",
+	}, "\n")
+}
+
+func sshConfigEntry(alias, user, identityFile, hostPort, proxyCommand string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Host %s\n", alias)
+	if hostPort != "" {
+		fmt.Fprintf(&b, "  HostName 127.0.0.1\n  Port %s\n", hostPort)
+	} else {
+		fmt.Fprintf(&b, "  HostName %s\n  ProxyCommand %s\n", alias, proxyCommand)
+	}
+	fmt.Fprintf(&b, "  User %s\n", user)
+	if identityFile != "" {
+		fmt.Fprintf(&b, "  IdentityFile %s\n", identityFile)
+	}
+	b.WriteString("  StrictHostKeyChecking no\n  UserKnownHostsFile /dev/null\n  LogLevel ERROR\n")
+	return b.String()
+}
+
+func upsertSSHConfigBlock(content, alias, entry string) string {
+	begin := "# devbox:" + alias + " begin\n"
+	end := "# devbox:" + alias + " end\n"
+	block := begin + entry + end
+	if i := strings.Index(content, begin); i >= 0 {
+		if j := strings.Index(content[i:], end); j >= 0 {
+			return content[:i] + block + content[i+j+len(end):]
+		}
+	}
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	if content != "" {
+		content += "\n"
+	}
+	return content + block
+}
+
+func publishedSSHPort(boxName string) string {
+	ports, _ := dockerClient.GetPortMappings(boxName)
+	for _, line := range ports {
+		if mapping, ok := lockPortMapping(line); ok {
+			host, container, _ := strings.Cut(mapping, ":")
+			if strings.TrimSuffix(container, "/tcp") == strconv.Itoa(sshBoxPort) {
+				return host
+			}
+		}
+	}
+	return ""
+}
+
+func provisionSSH(projectName, boxName string) error {
+	status, err := dockerClient.GetBoxStatus(boxName)
+	if err != nil {
+		return fmt.Errorf("failed to get box status: %w", err)
+	}
+	if status != "running" {
+		if err := startStoppedBox(boxName); err != nil {
+			return err
+		}
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("failed to get home directory: %w", err)
+	}
+	keyPath, err := findSSHPublicKey(homeDir)
+	if err != nil {
+		return err
+	}
+	publicKey, err := os.ReadFile(keyPath)
+	if err != nil {
+		return fmt.Errorf("failed to read public key: %w", err)
+	}
+
+	fmt.Printf("Setting up OpenSSH in '%s'...\n", boxName)
+	out, stderr, err := dockerClient.ExecCapture(boxName, sshProvisionScript(string(publicKey), sshBoxPort))
+	if err != nil {
+		if lockErr := parallel.AptLockError(boxName, sshInstallCommand, stderr, err); lockErr != nil {
+			return fmt.Errorf("failed to set up sshd: %w", lockErr)
+		}
+		return fmt.Errorf("failed to set up sshd: %s", firstNonEmpty(strings.TrimSpace(stderr), err.Error()))
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	user := firstNonEmpty(strings.TrimSpace(lines[len(lines)-1]), "root")
+
+	identityFile := ""
+	if private := strings.TrimSuffix(keyPath, ".pub"); private != keyPath {
+		if _, err := os.Stat(private); err == nil {
+			identityFile = private
+		}
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		exe = "devbox"
+	}
+	alias := sshHostAlias(projectName)
+	entry := sshConfigEntry(alias, user, identityFile, publishedSSHPort(boxName), fmt.Sprintf("%q ssh %s --stdio", exe, projectName))
+
+	fmt.Printf("\n%s\n", entry)
+	if sshWriteConfigFlag {
+		configPath := filepath.Join(homeDir, ".ssh", "config")
+		existing, err := os.ReadFile(configPath)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to read %s: %w", configPath, err)
+		}
+		if err := os.MkdirAll(filepath.Dir(configPath), 0700); err != nil {
+			return fmt.Errorf("failed to create %s: %w", filepath.Dir(configPath), err)
+		}
+		if err := os.WriteFile(configPath, []byte(upsertSSHConfigBlock(string(existing), alias, entry)), 0600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", configPath, err)
+		}
+		fmt.Printf("Updated %s\n", configPath)
+	} else {
+		fmt.Printf("hint: add this to ~/.ssh/config, or re-run with --write-config\n")
+	}
+	fmt.Printf("Connect with: ssh %s\n", alias)
+	return nil
+}
+
+type stdioConn struct {
+	io.Reader
+	io.Writer
+}
+
+func sshStdio(boxName string) error {
+	status, err := dockerClient.GetBoxStatus(boxName)
+	if err != nil {
+		return fmt.Errorf("failed to get box status: %w", err)
+	}
+	if status != "running" {
+		if err := dockerClient.StartBox(boxName); err != nil {
+			return fmt.Errorf("failed to start box '%s': %w", boxName, err)
+		}
+	}
+	if _, stderr, err := dockerClient.ExecCapture(boxName, sshStartScript(sshBoxPort)); err != nil {
+		return fmt.Errorf("failed to start sshd: %s", firstNonEmpty(strings.TrimSpace(stderr), err.Error()))
+	}
+	return dockerClient.ForwardConn(boxName, sshBoxPort, stdioConn{os.Stdin, os.Stdout})
+}
@@ -0,0 +1,45 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"devbox/internal/parallel"
+)
+
+func TestSSHProvisionScript(t *testing.T) {
+	script := sshProvisionScript("ssh-ed25519 AAAA me@laptop's\n", 2222)
+	if !strings.Contains(script, `grep -qxF 'ssh-ed25519 AAAA me@laptop'\''s' ~/.ssh/authorized_keys`) {
+		t.Errorf("key should be quoted for bash and added once:\n%s", script)
+	}
+	if !strings.Contains(script, "/usr/sbin/sshd -p 2222") {
+		t.Errorf("sshd should listen on the box port:\n%s", script)
+	}
+	if !strings.Contains(script, "("+parallel.WrapAptLock(sshInstallCommand)+")") {
+		t.Errorf("openssh-server should be installed under the devbox apt lock:\n%s", script)
+	}
+}
+
+func TestSSHConfigEntry(t *testing.T) {
+	direct := sshConfigEntry("devbox-web", "root", "/home/me/.ssh/id_ed25519", "2222", "")
+	if !strings.Contains(direct, "HostName 127.0.0.1\n  Port 2222\n") || strings.Contains(direct, "ProxyCommand") {
+		t.Errorf("published port entry = \n%s", direct)
+	}
+	proxied := sshConfigEntry("devbox-web", "dev", "", "", `"/usr/bin/devbox" ssh web --stdio`)
+	if !strings.Contains(proxied, `ProxyCommand "/usr/bin/devbox" ssh web --stdio`) || strings.Contains(proxied, "IdentityFile") {
+		t.Errorf("proxied entry = \n%s", proxied)
+	}
+}
+
+func TestUpsertSSHConfigBlock(t *testing.T) {
+	entry := "Host devbox-web\n  Port 1\n"
+	got := upsertSSHConfigBlock("Host github.com\n  User git", "devbox-web", entry)
+	want := "Host github.com\n  User git\n\n# devbox:devbox-web begin\nHost devbox-web\n  Port 1\n# devbox:devbox-web end\n"
+	if got != want {
+		t.Fatalf("append = %q, want %q", got, want)
+	}
+	updated := upsertSSHConfigBlock(got+"Host other\n", "devbox-web", "Host devbox-web\n  Port 2\n")
+	if strings.Count(updated, "devbox-web begin") != 1 || !strings.Contains(updated, "Port 2") || strings.Contains(updated, "Port 1") || !strings.HasSuffix(updated, "Host other\n") {
+		t.Errorf("update = %q", updated)
+	}
+}
@@ -0,0 +1,242 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"devbox/internal/config"
+	"devbox/internal/docker"
+)
+
+var (
+	statsAllFlag     bool
+	statsSummaryFlag bool
+)
+
+type boxUsage struct {
+	Project string
+	Box     string
+	State   string
+	CPU     float64
+	Memory  int64
+	Disk    int64
+	Image   string
+	Volumes []string
+}
+
+type devboxFootprint struct {
+	Boxes      int
+	Running    int
+	CPU        float64
+	Memory     int64
+	Containers int64
+	Images     int64
+	Volumes    int64
+}
+
+func (f devboxFootprint) Disk() int64 {
+	return f.Containers + f.Images + f.Volumes
+}
+
+var statsCmd = &cobra.Command{
+	Use:   "stats [project]",
+	Short: "Show CPU, memory, and disk usage of devbox boxes",
+	Long: `Show CPU, memory, and disk usage for one project's box or, with --all, every devbox box.
+
+Examples:
+  devbox stats myproject          # Usage for one box
+  devbox stats --all              # Usage for every box
+  devbox stats --all --summary    # Devbox's total footprint compared with host capacity`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 && !statsAllFlag {
+			return fmt.Errorf("specify a project or use --all")
+		}
+		if len(args) == 1 && statsAllFlag {
+			return fmt.Errorf("cannot combine a project name with --all")
+		}
+
+		cfg, err := configManager.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+
+		var targets []string
+		if len(args) == 1 {
+			project, ok := cfg.GetProject(args[0])
+			if !ok {
+				return fmt.Errorf("project '%s' not found", args[0])
+			}
+			targets = []string{project.BoxName}
+		} else {
+			boxes, err := dockerClient.ListBoxes()
+			if err != nil {
+				return fmt.Errorf("failed to list boxes: %w", err)
+			}
+			for _, b := range boxes {
+				if len(b.Names) > 0 {
+					targets = append(targets, b.Names[0])
+				}
+			}
+			sort.Strings(targets)
+		}
+		if len(targets) == 0 {
+			fmt.Println("No devbox containers found.")
+			return nil
+		}
+
+		rows := collectBoxUsage(cfg, targets)
+		printBoxUsage(rows)
+
+		if statsSummaryFlag {
+			fp := summarizeFootprint(rows, devboxImageBytes(rows), devboxVolumeBytes(rows))
+			fmt.Println()
+			printFootprint(fp, readHostResources())
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+	statsCmd.Flags().BoolVar(&statsAllFlag, "all", false, "Show every devbox box")
+	statsCmd.Flags().BoolVar(&statsSummaryFlag, "summary", false, "Total devbox CPU, memory, and disk (containers, images, volumes) against host capacity")
+}
+
+func parsePercent(s string) float64 {
+	v, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "%")), 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+func parseMemUsage(s string) int64 {
+	used, _, _ := strings.Cut(s, "/")
+	return docker.ParseSize(strings.TrimSpace(used))
+}
+
+func collectBoxUsage(cfg *config.Config, boxNames []string) []boxUsage {
+	projectByBox := map[string]string{}
+	for name, p := range cfg.GetProjects() {
+		projectByBox[p.BoxName] = name
+	}
+	images := map[string]string{}
+	if boxes, err := dockerClient.ListBoxes(); err == nil {
+		for _, b := range boxes {
+			if len(b.Names) > 0 {
+				images[b.Names[0]] = b.Image
+			}
+		}
+	}
+
+	var rows []boxUsage
+	for _, box := range boxNames {
+		row := boxUsage{Project: projectByBox[box], Box: box, Image: images[box], State: "not found"}
+		if row.Project == "" {
+			row.Project = "-"
+		}
+		if state, err := dockerClient.GetBoxStatus(box); err == nil {
+			row.State = state
+		}
+		if row.State == "running" {
+			if stats, err := dockerClient.GetContainerStats(box); err == nil && stats != nil {
+				row.CPU = parsePercent(stats.CPUPercent)
+				row.Memory = parseMemUsage(stats.MemUsage)
+			}
+		}
+		row.Disk, _ = dockerClient.GetContainerSize(box)
+		row.Volumes, _ = dockerClient.GetVolumeNames(box)
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func printBoxUsage(rows []boxUsage) {
+	fmt.Printf("%-20s %-24s %-10s %8s %12s %10s\n", "PROJECT", "BOX", "STATE", "CPU", "MEMORY", "DISK")
+	for _, r := range rows {
+		cpu, mem := "-", "-"
+		if r.State == "running" {
+			cpu = fmt.Sprintf("%.1f%%", r.CPU)
+			mem = formatBytes(r.Memory)
+		}
+		fmt.Printf("%-20s %-24s %-10s %8s %12s %10s\n", r.Project, r.Box, r.State, cpu, mem, formatBytes(r.Disk))
+	}
+}
+
+func devboxImageBytes(rows []boxUsage) int64 {
+	refs := map[string]bool{}
+	for _, r := range rows {
+		if r.Image != "" {
+			refs[r.Image] = true
+		}
+	}
+	if imgs, err := dockerClient.ListImages("devbox/*"); err == nil {
+		for _, img := range imgs {
+			refs[img.ID] = true
+		}
+	}
+	var total int64
+	for ref := range refs {
+		if size, err := dockerClient.GetImageSize(ref); err == nil {
+			total += size
+		}
+	}
+	return total
+}
+
+func devboxVolumeBytes(rows []boxUsage) int64 {
+	sizes, err := dockerClient.GetVolumeSizes()
+	if err != nil {
+		return 0
+	}
+	seen := map[string]bool{}
+	var total int64
+	for _, r := range rows {
+		for _, v := range r.Volumes {
+			if !seen[v] {
+				seen[v] = true
+				total += sizes[v]
+			}
+		}
+	}
+	return total
+}
+
+func summarizeFootprint(rows []boxUsage, imageBytes, volumeBytes int64) devboxFootprint {
+	fp := devboxFootprint{Boxes: len(rows), Images: imageBytes, Volumes: volumeBytes}
+	for _, r := range rows {
+		if r.State == "running" {
+			fp.Running++
+		}
+		fp.CPU += r.CPU
+		fp.Memory += r.Memory
+		fp.Containers += r.Disk
+	}
+	return fp
+}
+
+func shareOf(part, whole int64) string {
+	if whole <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", float64(part)*100/float64(whole))
+}
+
+func printFootprint(fp devboxFootprint, host hostResources) {
+	fmt.Printf("DEVBOX FOOTPRINT (%d box(es), %d running)\n", fp.Boxes, fp.Running)
+	cpuShare := "-"
+	if host.CPUs > 0 {
+		cpuShare = fmt.Sprintf("%.1f%%", fp.CPU/float64(host.CPUs))
+	}
+	fmt.Printf("CPU:    %.1f%% of one core (%s of %d host CPUs)\n", fp.CPU, cpuShare, host.CPUs)
+	fmt.Printf("Memory: %s (%s of %s host memory)\n", formatBytes(fp.Memory), shareOf(fp.Memory, host.MemTotal), formatBytes(host.MemTotal))
+	fmt.Printf("Disk:   %s (%s of %s; %s free)\n", formatBytes(fp.Disk()), shareOf(fp.Disk(), host.DiskTotal), formatBytes(host.DiskTotal), formatBytes(host.DiskFree))
+	fmt.Printf("  containers: %s\n", formatBytes(fp.Containers))
+	fmt.Printf("  images:     %s\n", formatBytes(fp.Images))
+	fmt.Printf("  volumes:    %s\n", formatBytes(fp.Volumes))
+}
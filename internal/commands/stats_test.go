@@ -0,0 +1,38 @@
+package commands
+
+import "testing"
+
+func TestParseStatsFields(t *testing.T) {
+	if got := parsePercent("12.5%"); got != 12.5 {
+		t.Errorf("parsePercent = %v, want 12.5", got)
+	}
+	if got := parsePercent("--"); got != 0 {
+		t.Errorf("parsePercent(--) = %v, want 0", got)
+	}
+	if got := parseMemUsage("256MiB / 15.5GiB"); got != 256<<20 {
+		t.Errorf("parseMemUsage = %d, want %d", got, 256<<20)
+	}
+}
+
+func TestSummarizeFootprint(t *testing.T) {
+	rows := []boxUsage{
+		{Box: "devbox_a", State: "running", CPU: 150, Memory: 1 << 30, Disk: 100},
+		{Box: "devbox_b", State: "exited", Disk: 50},
+	}
+	fp := summarizeFootprint(rows, 1000, 10)
+	if fp.Boxes != 2 || fp.Running != 1 {
+		t.Errorf("counts = %d/%d", fp.Boxes, fp.Running)
+	}
+	if fp.CPU != 150 || fp.Memory != 1<<30 {
+		t.Errorf("cpu/mem = %v/%d", fp.CPU, fp.Memory)
+	}
+	if fp.Disk() != 1160 {
+		t.Errorf("Disk() = %d, want 1160", fp.Disk())
+	}
+	if got := shareOf(25, 100); got != "25.0%" {
+		t.Errorf("shareOf = %s", got)
+	}
+	if got := shareOf(1, 0); got != "-" {
+		t.Errorf("shareOf with unknown total = %s", got)
+	}
+}
@@ -11,9 +11,37 @@ import (
 var statusCmd = &cobra.Command{
 	Use:   "status [project]",
 	Short: "Show detailed status for a devbox project",
-	Long:  "Displays container state, resource usage, uptime, ports, mounts, and other diagnostics for the project's box.",
-	Args:  cobra.MaximumNArgs(1),
+	Long: `Displays container state, resource usage, uptime, ports, mounts, and other diagnostics for the project's box.
+
+With --output json or --output yaml (--json is shorthand for --output json), prints a
+machine-readable health document (state, health, drift against devbox.lock.json, uptime,
+and resource usage) for the project, or an array covering every registered project when
+no project is given. Suitable for monitoring checks.`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if statusJSONFlag {
+			if cmd.Flags().Changed("output") && statusOutputFlag != outputJSON {
+				return fmt.Errorf("--json conflicts with --output %s", statusOutputFlag)
+			}
+			statusOutputFlag = outputJSON
+		}
+		if err := validateOutputFormat(statusOutputFlag); err != nil {
+			return err
+		}
+		if statusOutputFlag != outputTable {
+			cfg, err := configManager.Load()
+			if err != nil {
+				return fmt.Errorf("failed to load configuration: %w", err)
+			}
+			if len(args) == 0 {
+				return printStatusDocs(cfg, sortedProjectNames(cfg), false, statusOutputFlag)
+			}
+			if _, ok := cfg.GetProject(args[0]); !ok {
+				return fmt.Errorf("project '%s' not found", args[0])
+			}
+			return printStatusDocs(cfg, args, true, statusOutputFlag)
+		}
+
 		var projectName string
 		if len(args) == 1 {
 			projectName = args[0]
@@ -27,12 +55,19 @@ var statusCmd = &cobra.Command{
 				fmt.Println("No devbox containers found.")
 				return nil
 			}
+			if endpoint, source := dockerEndpoint(); endpoint != "" {
+				fmt.Printf("Docker host: %s (%s)\n", endpoint, source)
+			}
 			fmt.Println("Devbox containers:")
 			for _, b := range boxes {
 				name := ""
 				if len(b.Names) > 0 {
 					name = b.Names[0]
 				}
+				if origin := boxOrigin(b.Metadata); origin != "" {
+					fmt.Printf("- %s\t%s\t%s\t%s\n", name, b.Status, b.Image, origin)
+					continue
+				}
 				fmt.Printf("- %s\t%s\t%s\n", name, b.Status, b.Image)
 			}
 			fmt.Println("\nTip: devbox status <project> for detailed view.")
@@ -52,10 +87,13 @@ var statusCmd = &cobra.Command{
 		if !ok {
 			return fmt.Errorf("project '%s' not found", projectName)
 		}
+		if projectConfig := kubernetesProjectConfig(project); projectConfig != nil {
+			return kubeStatus(projectName, project, projectConfig)
+		}
 
 		box := project.BoxName
 		if box == "" {
-			box = fmt.Sprintf("devbox_%s", projectName)
+			box = boxNameFor(cfg, projectName)
 		}
 
 		exists, err := dockerClient.BoxExists(box)
@@ -64,6 +102,7 @@ var statusCmd = &cobra.Command{
 		}
 		if !exists {
 			fmt.Printf("Project: %s\nBox: %s (not found)\n", projectName, box)
+			printServices(box)
 			return nil
 		}
 
@@ -80,7 +119,28 @@ var statusCmd = &cobra.Command{
 		fmt.Printf("Project: %s\n", projectName)
 		fmt.Printf("Box: %s\n", box)
 		fmt.Printf("Image: %s\n", project.BaseImage)
+		if endpoint, source := dockerEndpoint(); endpoint != "" {
+			fmt.Printf("Docker host: %s (%s)\n", endpoint, source)
+		}
 		fmt.Printf("State: %s\n", status)
+		if md, err := dockerClient.GetBoxMetadata(box); err == nil && md.Known() {
+			if createdBy := md.CreatedBy(); createdBy != "" {
+				fmt.Printf("Created by: %s\n", createdBy)
+			}
+			if mismatch := boxMetadataMismatch(md, projectName, project.WorkspacePath); mismatch != "" {
+				fmt.Printf("Warning: box was created for %s\n", mismatch)
+			}
+			if configChangedSinceCreate(md, project.WorkspacePath) {
+				fmt.Printf("Config: devbox.json changed since the box was created\n")
+				fmt.Printf("hint: run 'devbox update %s' to recreate the box with it\n", projectName)
+			}
+		}
+		if health, err := dockerClient.GetHealth(box); err == nil && health.Configured() {
+			fmt.Printf("Health: %s\n", healthLabel(health))
+			if probe := lastProbeSummary(health); probe != "" {
+				fmt.Printf("Last health check: %s\n", probe)
+			}
+		}
 		if uptime > 0 {
 			fmt.Printf("Uptime: %s\n", humanizeDuration(uptime))
 		} else {
@@ -99,6 +159,7 @@ var statusCmd = &cobra.Command{
 				fmt.Printf("PIDs: %s\n", stats.PIDs)
 			}
 		}
+		printBoxGPUs(box, status == "running")
 		if len(ports) > 0 {
 			fmt.Printf("Ports:\n  %s\n", strings.Join(ports, "\n  "))
 		} else {
@@ -107,6 +168,17 @@ var statusCmd = &cobra.Command{
 		if len(mounts) > 0 {
 			fmt.Printf("Mounts:\n  %s\n", strings.Join(mounts, "\n  "))
 		}
+		if status == "running" {
+			if skew, err := measureClockSkew(box); err != nil {
+				fmt.Printf("Clock: unknown (%v)\n", err)
+			} else if clockSkewExceeded(skew) {
+				fmt.Printf("Clock: warning: %s\n", describeClockSkew(skew))
+				fmt.Printf("hint: TLS and apt fail with a skewed clock; run 'devbox maintenance --sync-time'\n")
+			} else {
+				fmt.Printf("Clock: %s\n", describeClockSkew(skew))
+			}
+		}
+		printServices(box)
 
 		return nil
 	},
@@ -128,4 +200,26 @@ func humanizeDuration(d time.Duration) string {
 
 func init() {
 	rootCmd.AddCommand(statusCmd)
+	statusCmd.Flags().BoolVar(&statusJSONFlag, "json", false, "Print a machine-readable health document (same as --output json)")
+	addOutputFlag(statusCmd, &statusOutputFlag)
+	statusCmd.Flags().BoolVar(&statusNoDriftFlag, "no-drift", false, "With --output json or yaml, skip comparing the box against devbox.lock.json")
+}
+
+func printBoxGPUs(box string, running bool) {
+	requested, err := dockerClient.GetRequestedGPUs(box)
+	if err != nil || requested == "" {
+		return
+	}
+	fmt.Printf("GPUs: %s\n", requested)
+	if !running {
+		return
+	}
+	gpus, err := dockerClient.GetGPUs(box)
+	if err != nil {
+		fmt.Printf("  unavailable: %v\n", err)
+		return
+	}
+	for _, gpu := range gpus {
+		fmt.Printf("  [%d] %s: %.0f%% util, %s / %s memory\n", gpu.Index, gpu.Name, gpu.Utilization, formatBytes(gpu.MemoryUsed), formatBytes(gpu.MemoryTotal))
+	}
 }
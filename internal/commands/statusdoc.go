@@ -0,0 +1,175 @@
+package commands
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"devbox/internal/config"
+	"devbox/internal/docker"
+)
+
+var (
+	statusJSONFlag    bool
+	statusOutputFlag  string
+	statusNoDriftFlag bool
+)
+
+const (
+	driftNone    = "none"
+	driftDrifted = "drifted"
+	driftNoLock  = "no_lock"
+	driftUnknown = "unknown"
+)
+
+type projectStatusDoc struct {
+	Project       string           `json:"project"`
+	Box           string           `json:"box"`
+	Image         string           `json:"image"`
+	DockerHost    string           `json:"docker_host,omitempty"`
+	Backend       string           `json:"backend,omitempty"`
+	Cluster       string           `json:"cluster,omitempty"`
+	State         string           `json:"state"`
+	Running       bool             `json:"running"`
+	Health        string           `json:"health"`
+	FailingStreak int              `json:"failing_streak,omitempty"`
+	UptimeSeconds int64            `json:"uptime_seconds"`
+	CPUPercent    float64          `json:"cpu_percent"`
+	MemoryBytes   int64            `json:"memory_bytes"`
+	MemoryPercent float64          `json:"memory_percent"`
+	DiskBytes     int64            `json:"disk_bytes"`
+	GPUs          string           `json:"gpus,omitempty"`
+	GPUDevices    []docker.GPUInfo `json:"gpu_devices,omitempty"`
+	ClockSkew     *float64         `json:"clock_skew_seconds,omitempty"`
+	CreatedBy     string           `json:"created_by,omitempty"`
+	ConfigChanged bool             `json:"config_changed,omitempty"`
+	Drift         string           `json:"drift"`
+	DriftDetails  []string         `json:"drift_details,omitempty"`
+	OK            bool             `json:"ok"`
+	CheckedAt     time.Time        `json:"checked_at"`
+}
+
+func (d *projectStatusDoc) evaluate() {
+	d.OK = d.Running && d.Health != "unhealthy" && d.Drift != driftDrifted
+}
+
+func collectProjectStatus(cfg *config.Config, projectName string, project *config.Project) *projectStatusDoc {
+	box := project.BoxName
+	if box == "" {
+		box = boxNameFor(cfg, projectName)
+	}
+	doc := &projectStatusDoc{
+		Project:   projectName,
+		Box:       box,
+		Image:     project.BaseImage,
+		State:     "not found",
+		Health:    "none",
+		Drift:     driftUnknown,
+		CheckedAt: time.Now().UTC(),
+	}
+	defer doc.evaluate()
+	if projectConfig := kubernetesProjectConfig(project); projectConfig != nil {
+		collectKubernetesStatus(doc, projectConfig)
+		return doc
+	}
+	doc.DockerHost, _ = dockerEndpoint()
+
+	if exists, err := dockerClient.BoxExists(box); err != nil || !exists {
+		return doc
+	}
+	if status, err := dockerClient.GetBoxStatus(box); err == nil {
+		doc.State = status
+	}
+	doc.Running = doc.State == "running"
+	if md, err := dockerClient.GetBoxMetadata(box); err == nil {
+		doc.CreatedBy = md.CreatedBy()
+		doc.ConfigChanged = configChangedSinceCreate(md, project.WorkspacePath)
+	}
+	if health, err := dockerClient.GetHealth(box); err == nil && health.Configured() {
+		doc.Health = health.Status
+		doc.FailingStreak = health.FailingStreak
+	}
+	doc.DiskBytes, _ = dockerClient.GetContainerSize(box)
+	doc.GPUs, _ = dockerClient.GetRequestedGPUs(box)
+	if !doc.Running {
+		return doc
+	}
+	if doc.GPUs != "" {
+		doc.GPUDevices, _ = dockerClient.GetGPUs(box)
+	}
+
+	if uptime, err := dockerClient.GetUptime(box); err == nil {
+		doc.UptimeSeconds = int64(uptime.Seconds())
+	}
+	if stats, err := dockerClient.GetContainerStats(box); err == nil && stats != nil {
+		doc.CPUPercent = parsePercent(stats.CPUPercent)
+		doc.MemoryBytes = parseMemUsage(stats.MemUsage)
+		doc.MemoryPercent = parsePercent(stats.MemPercent)
+	}
+	if skew, err := measureClockSkew(box); err == nil {
+		seconds := skew.Seconds()
+		doc.ClockSkew = &seconds
+	}
+
+	if statusNoDriftFlag {
+		return doc
+	}
+	doc.Drift, doc.DriftDetails = measureDrift(project, box)
+	return doc
+}
+
+func measureDrift(project *config.Project, box string) (string, []string) {
+	data, err := os.ReadFile(filepath.Join(project.WorkspacePath, "devbox.lock.json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return driftNoLock, nil
+		}
+		return driftUnknown, nil
+	}
+	var lf verifyLockFile
+	if err := json.Unmarshal(data, &lf); err != nil {
+		return driftUnknown, nil
+	}
+	rules, err := loadIgnoreRules(project.WorkspacePath, lf.Ignore)
+	if err != nil {
+		return driftUnknown, nil
+	}
+	snapshot := loadPackageSnapshot(box)
+	stripIgnoredPackages(rules, &lf.Packages, &snapshot.Packages, pluginSeparators())
+	drifts := snapshotDrift(&lf, snapshot)
+	current, imageEnv := currentContainer(box)
+	stripIgnoredEnv(rules, &lf.Container, &current, imageEnv)
+	drifts = append(drifts, containerDriftStrings(diffContainer(lf.Container, current, imageEnv))...)
+	if len(drifts) > 0 {
+		return driftDrifted, drifts
+	}
+	return driftNone, nil
+}
+
+func printStatusDocs(cfg *config.Config, projectNames []string, single bool, format string) error {
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+	docs := make([]*projectStatusDoc, 0, len(projectNames))
+	for _, name := range projectNames {
+		if project, ok := cfg.GetProject(name); ok {
+			docs = append(docs, collectProjectStatus(cfg, name, project))
+		}
+	}
+	os.Stdout = stdout
+
+	if single && len(docs) == 1 {
+		return writeStructured(stdout, format, docs[0])
+	}
+	return writeStructured(stdout, format, docs)
+}
+
+func sortedProjectNames(cfg *config.Config) []string {
+	names := make([]string, 0, len(cfg.GetProjects()))
+	for name := range cfg.GetProjects() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,45 @@
+package commands
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestProjectStatusDocEvaluate(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  projectStatusDoc
+		want bool
+	}{
+		{"running healthy in sync", projectStatusDoc{Running: true, Health: "healthy", Drift: driftNone}, true},
+		{"running without health check or lock", projectStatusDoc{Running: true, Health: "none", Drift: driftNoLock}, true},
+		{"stopped", projectStatusDoc{Running: false, Health: "none", Drift: driftUnknown}, false},
+		{"unhealthy", projectStatusDoc{Running: true, Health: "unhealthy", Drift: driftNone}, false},
+		{"drifted", projectStatusDoc{Running: true, Health: "healthy", Drift: driftDrifted}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.doc.evaluate()
+			if tt.doc.OK != tt.want {
+				t.Errorf("OK = %v, want %v", tt.doc.OK, tt.want)
+			}
+		})
+	}
+}
+
+func TestProjectStatusDocJSON(t *testing.T) {
+	doc := projectStatusDoc{Project: "demo", Box: "devbox_demo", State: "running", Running: true, Health: "healthy", Drift: driftNone, OK: true}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{`"project":"demo"`, `"state":"running"`, `"health":"healthy"`, `"drift":"none"`, `"ok":true`, `"uptime_seconds":0`} {
+		if !strings.Contains(string(data), key) {
+			t.Errorf("JSON %s missing %s", data, key)
+		}
+	}
+	if strings.Contains(string(data), "clock_skew_seconds") {
+		t.Errorf("clock_skew_seconds should be omitted when unknown: %s", data)
+	}
+}
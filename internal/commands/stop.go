@@ -4,12 +4,14 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
+
+	"devbox/internal/config"
 )
 
 var stopCmd = &cobra.Command{
 	Use:   "stop <project>",
 	Short: "Stop a project's box",
-	Long:  `Stop the Docker box for the specified project if it's running.`,
+	Long:  `Stop the Docker box for the specified project if it's running, along with any services from its devbox.json.`,
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		projectName := args[0]
@@ -27,6 +29,9 @@ var stopCmd = &cobra.Command{
 		if !exists {
 			return fmt.Errorf("project '%s' not found. Run 'devbox init %s' first", projectName, projectName)
 		}
+		if projectConfig := kubernetesProjectConfig(project); projectConfig != nil {
+			return kubeStop(project, projectConfig)
+		}
 
 		exists, err = dockerClient.BoxExists(project.BoxName)
 		if err != nil {
@@ -35,7 +40,7 @@ var stopCmd = &cobra.Command{
 
 		if !exists {
 			fmt.Printf("Box '%s' not found. Nothing to stop.\n", project.BoxName)
-			return nil
+			return stopServices(project.BoxName)
 		}
 
 		status, err := dockerClient.GetBoxStatus(project.BoxName)
@@ -45,15 +50,23 @@ var stopCmd = &cobra.Command{
 
 		if status != "running" {
 			fmt.Printf("Box '%s' is not running.\n", project.BoxName)
-			return nil
+			return stopServices(project.BoxName)
 		}
 
+		hooks := projectHookTarget(project)
+		if err := runHooks(config.HookPreStop, hooks); err != nil {
+			return err
+		}
 		fmt.Printf("Stopping box '%s'...\n", project.BoxName)
+		stopSyncAgent(projectName, false)
 		if err := dockerClient.StopBox(project.BoxName); err != nil {
 			return fmt.Errorf("failed to stop box: %w", err)
 		}
+		if err := stopServices(project.BoxName); err != nil {
+			return err
+		}
 
 		fmt.Printf("Stopped '%s'\n", project.BoxName)
-		return nil
+		return runHooks(config.HookPostStop, hooks)
 	},
 }
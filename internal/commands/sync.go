@@ -0,0 +1,484 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"devbox/internal/config"
+	"devbox/internal/docker"
+	filesync "devbox/internal/sync"
+)
+
+const (
+	syncAgentGrace     = 30 * time.Second
+	syncBoxCheckPeriod = 10 * time.Second
+	syncHeartbeat      = 30 * time.Second
+	syncFlushTimeout   = 2 * time.Minute
+)
+
+var (
+	syncPullFlag   bool
+	syncOutputFlag string
+)
+
+var syncPulledFiles = []string{workspaceDataDirName + "/journal", workspaceDataDirName + "/history"}
+
+var syncCmd = &cobra.Command{
+	Use:   "sync <project>",
+	Short: "Copy the workspace between this machine and a box's workspace volume",
+	Long: `Copy workspace files for a project whose workspace lives in a volume instead of a bind
+mount: boxes on a remote Docker host (docker_host), and projects with workspace.mode "sync".
+The volume is filled from this machine when the box is first created.
+
+By default local files are copied into the box. --pull copies the box's files back.
+Files are copied over existing ones; nothing is deleted on either side.
+
+Projects with workspace.mode "sync" also run a background agent that pushes local changes
+into the box and copies the box's .devbox/journal and .devbox/history back, so
+'devbox lock' sees packages installed in the box; see 'devbox sync status' and
+'devbox sync flush'.
+
+Examples:
+  devbox sync myproject
+  devbox sync myproject --pull
+  devbox sync status
+  devbox sync flush myproject`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSync(args[0], syncPullFlag)
+	},
+}
+
+var syncStatusCmd = &cobra.Command{
+	Use:   "status [project]",
+	Short: "Show the workspace sync agents",
+	Long: `Show the background sync agent of each project with workspace.mode "sync": whether it is
+running, when it last checked and pushed changes, how many files it tracks, and how many
+changes are waiting because the last push failed.
+
+Examples:
+  devbox sync status
+  devbox sync status myproject -o json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFormat(syncOutputFlag); err != nil {
+			return err
+		}
+		return showSyncStatus(args, syncOutputFlag)
+	},
+}
+
+var syncFlushCmd = &cobra.Command{
+	Use:   "flush <project>",
+	Short: "Push pending workspace changes into the box now",
+	Long: `Push local workspace changes into the box right away and wait until they are in. The
+running sync agent does the push; without one, devbox pushes the changes itself and starts
+the agent.
+
+Examples:
+  devbox sync flush myproject`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return flushSync(args[0])
+	},
+}
+
+var syncAgentCmd = &cobra.Command{
+	Use:    "agent <project>",
+	Hidden: true,
+	Args:   cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSyncAgent(args[0])
+	},
+}
+
+func init() {
+	syncCmd.Flags().BoolVar(&syncPullFlag, "pull", false, "Copy files from the box to this machine")
+	addOutputFlag(syncStatusCmd, &syncOutputFlag)
+	syncCmd.AddCommand(syncStatusCmd)
+	syncCmd.AddCommand(syncFlushCmd)
+	syncCmd.AddCommand(syncAgentCmd)
+	rootCmd.AddCommand(syncCmd)
+}
+
+func projectWorkspaceBox(projectConfig *config.ProjectConfig) string {
+	if projectConfig != nil && projectConfig.WorkingDir != "" {
+		return projectConfig.WorkingDir
+	}
+	return "/workspace"
+}
+
+func runSync(projectName string, pull bool) error {
+	cfg, err := configManager.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	project, ok := cfg.GetProject(projectName)
+	if !ok {
+		return fmt.Errorf("project '%s' not found", projectName)
+	}
+	projectConfig, _ := configManager.LoadProjectConfig(project.WorkspacePath)
+	if projectConfig.KubernetesBackend() {
+		return kubeSync(project, projectConfig, pull)
+	}
+	if !dockerClient.WorkspaceSync() && (projectConfig == nil || !projectConfig.Workspace.Synced()) {
+		return fmt.Errorf("project '%s' uses a local Docker host; its workspace is bind-mounted and needs no sync", projectName)
+	}
+	exists, err := dockerClient.BoxExists(project.BoxName)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("box '%s' not found; run 'devbox up %s' first", project.BoxName, projectName)
+	}
+
+	workspaceBox := projectWorkspaceBox(projectConfig)
+	if pull {
+		fmt.Printf("Copying %s:%s to %s...\n", project.BoxName, workspaceBox, project.WorkspacePath)
+		if err := dockerClient.SyncWorkspaceFromBox(project.BoxName, workspaceBox, project.WorkspacePath); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("Copying %s to %s:%s (volume %s)...\n", project.WorkspacePath, project.BoxName, workspaceBox, docker.WorkspaceVolumeName(project.BoxName))
+		if err := dockerClient.SyncWorkspaceToBox(project.BoxName, project.WorkspacePath, workspaceBox); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Workspace synced\n")
+	return nil
+}
+
+func syncStatePath(projectName, suffix string) string {
+	return filepath.Join(configManager.ConfigDir(), "sync", projectName+suffix)
+}
+
+func loadSyncedProject(projectName string) (*config.Project, *config.ProjectConfig, error) {
+	cfg, err := configManager.Load()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+	project, ok := cfg.GetProject(projectName)
+	if !ok {
+		return nil, nil, fmt.Errorf("project '%s' not found", projectName)
+	}
+	projectConfig, err := configManager.LoadProjectConfig(project.WorkspacePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load project config: %w", err)
+	}
+	if projectConfig == nil || !projectConfig.Workspace.Synced() {
+		return nil, nil, fmt.Errorf("project '%s' does not use workspace.mode \"sync\"", projectName)
+	}
+	return project, projectConfig, nil
+}
+
+func newProjectSyncer(projectName string, project *config.Project, projectConfig *config.ProjectConfig) *filesync.Syncer {
+	return &filesync.Syncer{
+		Box:       project.BoxName,
+		Root:      project.WorkspacePath,
+		Dest:      projectWorkspaceBox(projectConfig),
+		Ignore:    projectConfig.Workspace.Ignore,
+		Pull:      syncPulledFiles,
+		Transport: dockerClient,
+		Index:     filesync.LoadIndex(syncStatePath(projectName, ".index.json")),
+	}
+}
+
+func workspaceInVolume(project *config.Project) bool {
+	if dockerClient.WorkspaceSync() {
+		return true
+	}
+	projectConfig, err := configManager.LoadProjectConfig(project.WorkspacePath)
+	return err == nil && projectConfig != nil && projectConfig.Workspace.Synced()
+}
+
+func ensureProjectSyncAgent(projectName string, project *config.Project) {
+	if projectConfig, err := configManager.LoadProjectConfig(project.WorkspacePath); err == nil {
+		ensureSyncAgent(projectName, projectConfig)
+	}
+}
+
+func ensureSyncAgent(projectName string, projectConfig *config.ProjectConfig) {
+	if projectConfig == nil || !projectConfig.Workspace.Synced() {
+		return
+	}
+	if status, _ := filesync.LoadStatus(syncStatePath(projectName, ".json")); status.Running() {
+		return
+	}
+	if err := startSyncAgent(projectName); err != nil {
+		fmt.Printf("Warning: failed to start the workspace sync agent: %v\n", err)
+		return
+	}
+	fmt.Printf("Workspace sync: agent started (see 'devbox sync status %s')\n", projectName)
+}
+
+func startSyncAgent(projectName string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate devbox binary: %w", err)
+	}
+	logPath := syncStatePath(projectName, ".log")
+	if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+		return err
+	}
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+	cmd := exec.Command(exe, "sync", "agent", projectName)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	return cmd.Process.Release()
+}
+
+func stopSyncAgent(projectName string, removeState bool) {
+	statusPath := syncStatePath(projectName, ".json")
+	if status, _ := filesync.LoadStatus(statusPath); status.Running() {
+		_ = syscall.Kill(status.PID, syscall.SIGTERM)
+		for i := 0; i < 20 && status.Running(); i++ {
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+	if removeState {
+		for _, suffix := range []string{".json", ".index.json", ".log"} {
+			_ = os.Remove(syncStatePath(projectName, suffix))
+		}
+	}
+}
+
+func runSyncAgent(projectName string) error {
+	project, projectConfig, err := loadSyncedProject(projectName)
+	if err != nil {
+		return err
+	}
+	statusPath := syncStatePath(projectName, ".json")
+	if existing, _ := filesync.LoadStatus(statusPath); existing.Running() && existing.PID != os.Getpid() {
+		return nil
+	}
+	syncer := newProjectSyncer(projectName, project, projectConfig)
+	now := time.Now()
+	status := &filesync.Status{
+		PID:       os.Getpid(),
+		Box:       project.BoxName,
+		Workspace: syncer.Root,
+		Target:    syncer.Dest,
+		StartedAt: now,
+		Files:     len(syncer.Index),
+	}
+	if err := filesync.SaveStatus(statusPath, status); err != nil {
+		return err
+	}
+
+	flush := make(chan os.Signal, 1)
+	signal.Notify(flush, syscall.SIGUSR1)
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGTERM, os.Interrupt, syscall.SIGHUP)
+	ticker := time.NewTicker(projectConfig.Workspace.SyncInterval())
+	defer ticker.Stop()
+
+	var boxCheckedAt, downSince, savedAt time.Time
+	boxReady := false
+	for {
+		flushed := false
+		select {
+		case <-stop:
+			status.PID = 0
+			return filesync.SaveStatus(statusPath, status)
+		case <-flush:
+			flushed = true
+		case <-ticker.C:
+		}
+		now := time.Now()
+		prevErr, pushed := status.LastError, false
+		if flushed || !boxReady || now.Sub(boxCheckedAt) >= syncBoxCheckPeriod {
+			boxCheckedAt = now
+			var reason string
+			boxReady, reason = syncTargetReady(project.BoxName)
+			if !boxReady {
+				status.LastError = reason
+				if downSince.IsZero() {
+					downSince = now
+				}
+				if now.Sub(downSince) >= syncAgentGrace {
+					status.PID = 0
+					return filesync.SaveStatus(statusPath, status)
+				}
+			} else {
+				downSince = time.Time{}
+			}
+		}
+		if boxReady {
+			res, err := syncer.Pass()
+			status.CheckedAt = now
+			status.Files = res.Files
+			status.Pending = res.Pending
+			if err != nil {
+				status.LastError = err.Error()
+				boxReady = false
+			} else {
+				status.LastError = ""
+				if res.Pushed+res.Removed > 0 {
+					pushed = true
+					status.SyncedAt = now
+					status.Pushed += res.Pushed
+					status.Removed += res.Removed
+					if err := filesync.SaveIndex(syncStatePath(projectName, ".index.json"), syncer.Index); err != nil {
+						status.LastError = err.Error()
+					}
+				}
+			}
+		}
+		if flushed {
+			status.FlushedAt = now
+		}
+		if flushed || pushed || status.LastError != prevErr || now.Sub(savedAt) >= syncHeartbeat {
+			if err := filesync.SaveStatus(statusPath, status); err == nil {
+				savedAt = now
+			}
+		}
+	}
+}
+
+func syncTargetReady(boxName string) (bool, string) {
+	state, err := dockerClient.GetBoxStatus(boxName)
+	if err != nil {
+		return false, fmt.Sprintf("box '%s' not found", boxName)
+	}
+	if state != "running" {
+		return false, fmt.Sprintf("box '%s' is %s", boxName, state)
+	}
+	if dockerClient.GetWorkspacePath(boxName) != "" {
+		return false, fmt.Sprintf("box '%s' bind-mounts the workspace; recreate it with 'devbox update' to use the workspace volume", boxName)
+	}
+	return true, ""
+}
+
+func flushSync(projectName string) error {
+	project, projectConfig, err := loadSyncedProject(projectName)
+	if err != nil {
+		return err
+	}
+	statusPath := syncStatePath(projectName, ".json")
+	status, _ := filesync.LoadStatus(statusPath)
+	if status.Running() {
+		requested := time.Now()
+		if err := syscall.Kill(status.PID, syscall.SIGUSR1); err != nil {
+			return fmt.Errorf("failed to signal the sync agent: %w", err)
+		}
+		deadline := requested.Add(syncFlushTimeout)
+		for time.Now().Before(deadline) {
+			time.Sleep(100 * time.Millisecond)
+			current, err := filesync.LoadStatus(statusPath)
+			if err != nil || current == nil || current.FlushedAt.Before(requested) {
+				continue
+			}
+			if current.LastError != "" {
+				return fmt.Errorf("sync failed: %s", current.LastError)
+			}
+			fmt.Printf("Workspace synced (%d files tracked)\n", current.Files)
+			return nil
+		}
+		return fmt.Errorf("timed out waiting for the sync agent (pid %d)", status.PID)
+	}
+
+	if ready, reason := syncTargetReady(project.BoxName); !ready {
+		return fmt.Errorf("%s; run 'devbox up %s' first", reason, projectName)
+	}
+	syncer := newProjectSyncer(projectName, project, projectConfig)
+	res, err := syncer.Pass()
+	if err != nil {
+		return err
+	}
+	if res.Pushed+res.Removed > 0 {
+		if err := filesync.SaveIndex(syncStatePath(projectName, ".index.json"), syncer.Index); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Workspace synced: %d pushed, %d removed, %d pulled (%d files tracked)\n", res.Pushed, res.Removed, res.Pulled, res.Files)
+	ensureSyncAgent(projectName, projectConfig)
+	return nil
+}
+
+type syncStatusEntry struct {
+	Project   string    `json:"project"`
+	Box       string    `json:"box"`
+	Agent     string    `json:"agent"`
+	PID       int       `json:"pid,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+	SyncedAt  time.Time `json:"synced_at"`
+	Files     int       `json:"files"`
+	Pending   int       `json:"pending"`
+	LastError string    `json:"last_error,omitempty"`
+}
+
+func showSyncStatus(args []string, format string) error {
+	cfg, err := configManager.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	names := args
+	if len(names) == 0 {
+		names = sortedProjectNames(cfg)
+	} else if _, _, err := loadSyncedProject(names[0]); err != nil {
+		return err
+	}
+	rows := []syncStatusEntry{}
+	for _, name := range names {
+		project, ok := cfg.GetProject(name)
+		if !ok {
+			continue
+		}
+		if projectConfig, err := configManager.LoadProjectConfig(project.WorkspacePath); err != nil || projectConfig == nil || !projectConfig.Workspace.Synced() {
+			continue
+		}
+		row := syncStatusEntry{Project: name, Box: project.BoxName, Agent: "stopped"}
+		if status, _ := filesync.LoadStatus(syncStatePath(name, ".json")); status != nil {
+			if status.Running() {
+				row.Agent, row.PID = "running", status.PID
+			}
+			row.CheckedAt, row.SyncedAt = status.CheckedAt, status.SyncedAt
+			row.Files, row.Pending, row.LastError = status.Files, status.Pending, status.LastError
+		}
+		rows = append(rows, row)
+	}
+	if format == outputJSON || format == outputYAML {
+		return writeStructured(os.Stdout, format, rows)
+	}
+	if len(rows) == 0 {
+		fmt.Println("No projects use workspace.mode \"sync\".")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PROJECT\tAGENT\tLAST CHECK\tLAST PUSH\tFILES\tPENDING\tERROR")
+	for _, r := range rows {
+		agent := r.Agent
+		if r.PID > 0 {
+			agent = fmt.Sprintf("running (pid %d)", r.PID)
+		}
+		errText := r.LastError
+		if errText == "" {
+			errText = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%d\t%s\n", r.Project, agent, syncTimeLabel(r.CheckedAt), syncTimeLabel(r.SyncedAt), r.Files, r.Pending, errText)
+	}
+	return w.Flush()
+}
+
+func syncTimeLabel(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return humanizeDuration(time.Since(t)) + " ago"
+}
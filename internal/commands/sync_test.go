@@ -0,0 +1,37 @@
+package commands
+
+import (
+	"testing"
+
+	"devbox/internal/config"
+)
+
+func TestSyncCommandRouting(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"sync", "myproject"}, "sync"},
+		{[]string{"sync", "status"}, "status"},
+		{[]string{"sync", "flush", "myproject"}, "flush"},
+		{[]string{"sync", "agent", "myproject"}, "agent"},
+	}
+	for _, tt := range tests {
+		cmd, _, err := rootCmd.Find(tt.args)
+		if err != nil {
+			t.Fatalf("Find(%q) error = %v", tt.args, err)
+		}
+		if cmd.Name() != tt.want {
+			t.Errorf("Find(%q) = %s, want %s", tt.args, cmd.Name(), tt.want)
+		}
+	}
+}
+
+func TestProjectWorkspaceBox(t *testing.T) {
+	if got := projectWorkspaceBox(nil); got != "/workspace" {
+		t.Errorf("projectWorkspaceBox(nil) = %s", got)
+	}
+	if got := projectWorkspaceBox(&config.ProjectConfig{WorkingDir: "/src"}); got != "/src" {
+		t.Errorf("projectWorkspaceBox() = %s, want /src", got)
+	}
+}
@@ -0,0 +1,30 @@
+package commands
+
+import (
+	"devbox/internal/config"
+	"devbox/internal/parallel"
+)
+
+func boxTimeouts(pc *config.ProjectConfig) config.BoxTimeouts {
+	var settings *config.GlobalSettings
+	if configManager != nil {
+		if cfg, err := configManager.Load(); err == nil {
+			settings = cfg.Settings
+		}
+	}
+	return config.ResolveTimeouts(settings, pc)
+}
+
+func workspaceTimeouts(workspacePath string) config.BoxTimeouts {
+	var pc *config.ProjectConfig
+	if configManager != nil && workspacePath != "" {
+		pc, _ = configManager.LoadProjectConfig(workspacePath)
+	}
+	return boxTimeouts(pc)
+}
+
+func useProjectTimeouts(pc *config.ProjectConfig) config.BoxTimeouts {
+	timeouts := boxTimeouts(pc)
+	parallel.SetProjectOverrides(parallel.Overrides{SetupTimeout: timeouts.Setup})
+	return timeouts
+}
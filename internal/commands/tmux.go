@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"devbox/internal/config"
+)
+
+func tmuxSessionName(projectName string, t *config.Tmux) string {
+	if t != nil && t.Session != "" {
+		return t.Session
+	}
+	return projectName
+}
+
+func envNames(env []string) []string {
+	names := make([]string, 0, len(env))
+	for _, e := range env {
+		name, _, _ := strings.Cut(e, "=")
+		names = append(names, name)
+	}
+	return names
+}
+
+func tmuxScript(session string, t *config.Tmux, envNames []string) string {
+	q := func(s string) string { return "'" + escapeBash(s) + "'" }
+	target := q("=" + session)
+	var b strings.Builder
+	b.WriteString("command -v tmux >/dev/null 2>&1 || { echo 'error: tmux is not installed in the box' >&2; echo \"hint: add 'apt-get install -y tmux' to setup_commands\" >&2; exit 127; }\n")
+	b.WriteString("export PS1='devbox($PROJECT_NAME):\\w\\$ '\n")
+	fmt.Fprintf(&b, "if ! tmux has-session -t %s 2>/dev/null; then\n", target)
+	var windows []config.TmuxWindow
+	if t != nil {
+		windows = t.Windows
+	}
+	if len(windows) == 0 {
+		fmt.Fprintf(&b, "\ttmux new-session -d -s %s\n", q(session))
+	}
+	for i, w := range windows {
+		dir := ""
+		if w.Dir != "" {
+			dir = " -c " + q(w.Dir)
+		}
+		if i == 0 {
+			fmt.Fprintf(&b, "\tpane=$(tmux new-session -d -P -F '#{pane_id}' -s %s -n %s%s)\n", q(session), q(w.Name), dir)
+		} else {
+			fmt.Fprintf(&b, "\tpane=$(tmux new-window -d -P -F '#{pane_id}' -t %s -n %s%s)\n", q("="+session+":"), q(w.Name), dir)
+		}
+		layout := w.Layout
+		if layout == "" {
+			layout = "tiled"
+		}
+		for j, pane := range w.Panes {
+			if j > 0 {
+				fmt.Fprintf(&b, "\tpane=$(tmux split-window -d -P -F '#{pane_id}' -t \"$pane\"%s)\n", dir)
+				fmt.Fprintf(&b, "\ttmux select-layout -t \"$pane\" %s\n", layout)
+			}
+			if strings.TrimSpace(pane) != "" {
+				fmt.Fprintf(&b, "\ttmux send-keys -t \"$pane\" -l %s\n", q(pane))
+				fmt.Fprintf(&b, "\ttmux send-keys -t \"$pane\" Enter\n")
+			}
+		}
+	}
+	if len(windows) > 0 {
+		fmt.Fprintf(&b, "\ttmux select-window -t %s\n", q("="+session+":"+windows[0].Name))
+	}
+	b.WriteString("fi\n")
+	for _, name := range envNames {
+		fmt.Fprintf(&b, "[ -n \"$%s\" ] && tmux set-environment -t %s %s \"$%s\"\n", name, target, name, name)
+	}
+	fmt.Fprintf(&b, "exec tmux attach-session -t %s\n", target)
+	return b.String()
+}
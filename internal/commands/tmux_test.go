@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"devbox/internal/config"
+)
+
+const fakeTmux = `#!/bin/sh
+printf '%s\n' "$*" >> "$TMUX_LOG"
+case "$1" in
+	has-session) [ -n "$TMUX_EXISTS" ] ;;
+	new-session|new-window|split-window) n=$(wc -l < "$TMUX_LOG"); echo "%$n" ;;
+esac
+`
+
+func runTmuxScript(t *testing.T, script string, exists bool) string {
+	t.Helper()
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not available")
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "tmux"), []byte(fakeTmux), 0755); err != nil {
+		t.Fatal(err)
+	}
+	log := filepath.Join(dir, "log")
+	cmd := exec.Command(bash, "-c", script)
+	cmd.Env = []string{"PATH=" + dir + ":/usr/bin:/bin", "TMUX_LOG=" + log, "DEVBOX_BRIDGE=127.0.0.1:4000"}
+	if exists {
+		cmd.Env = append(cmd.Env, "TMUX_EXISTS=1")
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("script failed: %v: %s", err, out)
+	}
+	data, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestTmuxScript(t *testing.T) {
+	layout := &config.Tmux{Windows: []config.TmuxWindow{
+		{Name: "editor", Panes: []string{"vim ."}},
+		{Name: "server", Dir: "/workspace/api", Layout: "even-horizontal", Panes: []string{"npm run dev", "", "echo 'it''s up' && tail -F log"}},
+	}}
+	script := tmuxScript(tmuxSessionName("web", layout), layout, []string{"DEVBOX_BRIDGE", "DEVBOX_BRIDGE_TOKEN"})
+
+	want := strings.Join([]string{
+		"has-session -t =web",
+		"new-session -d -P -F #{pane_id} -s web -n editor",
+		"send-keys -t %2 -l vim .",
+		"send-keys -t %2 Enter",
+		"new-window -d -P -F #{pane_id} -t =web: -n server -c /workspace/api",
+		"send-keys -t %5 -l npm run dev",
+		"send-keys -t %5 Enter",
+		"split-window -d -P -F #{pane_id} -t %5 -c /workspace/api",
+		"select-layout -t %8 even-horizontal",
+		"split-window -d -P -F #{pane_id} -t %8 -c /workspace/api",
+		"select-layout -t %10 even-horizontal",
+		"send-keys -t %10 -l echo 'it''s up' && tail -F log",
+		"send-keys -t %10 Enter",
+		"select-window -t =web:editor",
+		"set-environment -t =web DEVBOX_BRIDGE 127.0.0.1:4000",
+		"attach-session -t =web",
+	}, "\n") + "\n"
+	if got := runTmuxScript(t, script, false); got != want {
+		t.Errorf("tmux calls for a new session =\n%s\nwant\n%s", got, want)
+	}
+
+	want = "has-session -t =web\nset-environment -t =web DEVBOX_BRIDGE 127.0.0.1:4000\nattach-session -t =web\n"
+	if got := runTmuxScript(t, script, true); got != want {
+		t.Errorf("tmux calls for an existing session =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestTmuxScriptWithoutLayout(t *testing.T) {
+	script := tmuxScript(tmuxSessionName("web", nil), nil, nil)
+	want := "has-session -t =web\nnew-session -d -s web\nattach-session -t =web\n"
+	if got := runTmuxScript(t, script, false); got != want {
+		t.Errorf("tmux calls without a layout =\n%s\nwant\n%s", got, want)
+	}
+	if got := tmuxSessionName("web", &config.Tmux{Session: "team"}); got != "team" {
+		t.Errorf("tmuxSessionName() = %q, want team", got)
+	}
+}
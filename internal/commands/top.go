@@ -0,0 +1,263 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	procClockTicks = 100
+	procPageSize   = 4096
+	procSampleCmd  = `for p in /proc/[0-9]*; do s=$(cat "$p/stat" 2>/dev/null) || continue; c=$(tr '\0' ' ' < "$p/cmdline" 2>/dev/null); printf '%s\t%s\n' "$s" "$c"; done`
+)
+
+var (
+	topIntervalFlag time.Duration
+	topLimitFlag    int
+	topOnceFlag     bool
+	topSortFlag     string
+)
+
+type procSample struct {
+	PID     int
+	PPID    int
+	Comm    string
+	State   string
+	Ticks   uint64
+	RSS     int64
+	Command string
+}
+
+type procUsage struct {
+	PID     int
+	State   string
+	CPU     float64
+	RSS     int64
+	Command string
+}
+
+var topCmd = &cobra.Command{
+	Use:   "top <project>",
+	Short: "Show live per-process CPU and memory usage inside a project's box",
+	Long: `Sample /proc inside the project's box and show per-process CPU and memory,
+refreshing until interrupted. Falls back to 'docker top' when the box cannot be sampled.
+
+Examples:
+  devbox top myproject                 # Refresh every 2s
+  devbox top myproject --once          # Print one snapshot
+  devbox top myproject --sort mem -n 5 # Top 5 processes by memory`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projectName := args[0]
+		if err := validateProjectName(projectName); err != nil {
+			return err
+		}
+		if topSortFlag != "cpu" && topSortFlag != "mem" {
+			return fmt.Errorf("--sort must be 'cpu' or 'mem'")
+		}
+		if topIntervalFlag < 500*time.Millisecond {
+			topIntervalFlag = 500 * time.Millisecond
+		}
+
+		cfg, err := configManager.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		project, ok := cfg.GetProject(projectName)
+		if !ok {
+			return fmt.Errorf("project '%s' not found", projectName)
+		}
+		status, err := dockerClient.GetBoxStatus(project.BoxName)
+		if err != nil {
+			return fmt.Errorf("failed to get box status: %w", err)
+		}
+		if status != "running" {
+			return fmt.Errorf("box '%s' is not running (state: %s)", project.BoxName, status)
+		}
+
+		prev, err := sampleBoxProcesses(project.BoxName)
+		if err != nil {
+			fmt.Printf("Warning: could not sample /proc in box (%v); showing 'docker top' instead\n", err)
+			out, err := dockerClient.TopProcesses(project.BoxName, "-eo", "pid,pcpu,rss,args")
+			if err != nil {
+				return err
+			}
+			fmt.Print(out)
+			return nil
+		}
+
+		interrupt := make(chan os.Signal, 1)
+		signal.Notify(interrupt, os.Interrupt)
+		defer signal.Stop(interrupt)
+
+		last := time.Now()
+		for {
+			select {
+			case <-interrupt:
+				return nil
+			case <-time.After(topIntervalFlag):
+			}
+			cur, err := sampleBoxProcesses(project.BoxName)
+			if err != nil {
+				return fmt.Errorf("failed to sample processes: %w", err)
+			}
+			now := time.Now()
+			usage := processUsage(prev, cur, now.Sub(last))
+			sortProcessUsage(usage, topSortFlag)
+
+			if !topOnceFlag {
+				fmt.Print("\033[H\033[2J")
+			}
+			printProcessUsage(projectName, project.BoxName, usage, topLimitFlag)
+			if topOnceFlag {
+				return nil
+			}
+			prev, last = cur, now
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(topCmd)
+	topCmd.Flags().DurationVar(&topIntervalFlag, "interval", 2*time.Second, "Refresh interval")
+	topCmd.Flags().IntVarP(&topLimitFlag, "limit", "n", 15, "Number of processes to show (0 for all)")
+	topCmd.Flags().BoolVar(&topOnceFlag, "once", false, "Print a single snapshot and exit")
+	topCmd.Flags().StringVar(&topSortFlag, "sort", "cpu", "Sort by 'cpu' or 'mem'")
+}
+
+func sampleBoxProcesses(boxName string) (map[int]procSample, error) {
+	out, _, err := dockerClient.ExecCapture(boxName, procSampleCmd)
+	if err != nil {
+		return nil, err
+	}
+	samples := parseProcSamples(out)
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("no processes found in /proc")
+	}
+	return samples, nil
+}
+
+func parseProcStat(line string) (procSample, bool) {
+	open := strings.IndexByte(line, '(')
+	end := strings.LastIndexByte(line, ')')
+	if open <= 0 || end < open {
+		return procSample{}, false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(line[:open]))
+	if err != nil {
+		return procSample{}, false
+	}
+	fields := strings.Fields(line[end+1:])
+	if len(fields) < 22 {
+		return procSample{}, false
+	}
+	utime, err1 := strconv.ParseUint(fields[11], 10, 64)
+	stime, err2 := strconv.ParseUint(fields[12], 10, 64)
+	rss, err3 := strconv.ParseInt(fields[21], 10, 64)
+	ppid, err4 := strconv.Atoi(fields[1])
+	if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
+		return procSample{}, false
+	}
+	return procSample{
+		PID:   pid,
+		PPID:  ppid,
+		Comm:  line[open+1 : end],
+		State: fields[0],
+		Ticks: utime + stime,
+		RSS:   rss * procPageSize,
+	}, true
+}
+
+func parseProcSamples(out string) map[int]procSample {
+	samples := map[int]procSample{}
+	for _, line := range strings.Split(out, "\n") {
+		stat, cmdline, _ := strings.Cut(line, "\t")
+		s, ok := parseProcStat(stat)
+		if !ok {
+			continue
+		}
+		s.Command = strings.TrimSpace(cmdline)
+		if s.Command == "" {
+			s.Command = "[" + s.Comm + "]"
+		}
+		samples[s.PID] = s
+	}
+
+	sampler := map[int]bool{}
+	for pid, s := range samples {
+		if strings.Contains(s.Command, "for p in /proc/") {
+			sampler[pid] = true
+		}
+	}
+	for changed := len(sampler) > 0; changed; {
+		changed = false
+		for pid, s := range samples {
+			if !sampler[pid] && sampler[s.PPID] {
+				sampler[pid] = true
+				changed = true
+			}
+		}
+	}
+	for pid := range sampler {
+		delete(samples, pid)
+	}
+	return samples
+}
+
+func processUsage(prev, cur map[int]procSample, elapsed time.Duration) []procUsage {
+	var usage []procUsage
+	secs := elapsed.Seconds()
+	for pid, c := range cur {
+		u := procUsage{PID: pid, State: c.State, RSS: c.RSS, Command: c.Command}
+		if p, ok := prev[pid]; ok && secs > 0 && c.Ticks >= p.Ticks {
+			u.CPU = float64(c.Ticks-p.Ticks) / procClockTicks / secs * 100
+		}
+		usage = append(usage, u)
+	}
+	return usage
+}
+
+func sortProcessUsage(usage []procUsage, by string) {
+	sort.Slice(usage, func(i, j int) bool {
+		a, b := usage[i], usage[j]
+		if by == "mem" && a.RSS != b.RSS {
+			return a.RSS > b.RSS
+		}
+		if a.CPU != b.CPU {
+			return a.CPU > b.CPU
+		}
+		if a.RSS != b.RSS {
+			return a.RSS > b.RSS
+		}
+		return a.PID < b.PID
+	})
+}
+
+func printProcessUsage(projectName, boxName string, usage []procUsage, limit int) {
+	var totalCPU float64
+	var totalRSS int64
+	for _, u := range usage {
+		totalCPU += u.CPU
+		totalRSS += u.RSS
+	}
+	fmt.Printf("devbox top - %s (%s) - %s\n", projectName, boxName, time.Now().Format("15:04:05"))
+	fmt.Printf("Processes: %d   CPU: %.1f%%   Memory (RSS): %s\n\n", len(usage), totalCPU, formatBytes(totalRSS))
+	fmt.Printf("%7s %-5s %7s %10s  %s\n", "PID", "STATE", "CPU%", "RSS", "COMMAND")
+	for i, u := range usage {
+		if limit > 0 && i >= limit {
+			break
+		}
+		command := u.Command
+		if len(command) > 80 {
+			command = command[:77] + "..."
+		}
+		fmt.Printf("%7d %-5s %7.1f %10s  %s\n", u.PID, u.State, u.CPU, formatBytes(u.RSS), command)
+	}
+}
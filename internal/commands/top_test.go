@@ -0,0 +1,61 @@
+package commands
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+const statTail = " S 1 1 1 0 -1 4194560 100 0 0 0 %s %s 0 0 20 0 1 0 100 1000000 %s 18446744073709551615"
+
+func procStatLine(pid, comm, utime, stime, rss string) string {
+	return pid + " (" + comm + ")" + fmt.Sprintf(statTail, utime, stime, rss)
+}
+
+func TestParseProcStat(t *testing.T) {
+	s, ok := parseProcStat(procStatLine("42", "node (dev) server", "150", "50", "2560"))
+	if !ok {
+		t.Fatal("expected line to parse")
+	}
+	if s.PID != 42 || s.PPID != 1 || s.Comm != "node (dev) server" || s.State != "S" {
+		t.Errorf("unexpected sample: %+v", s)
+	}
+	if s.Ticks != 200 || s.RSS != 2560*procPageSize {
+		t.Errorf("ticks/rss = %d/%d", s.Ticks, s.RSS)
+	}
+	if _, ok := parseProcStat("garbage"); ok {
+		t.Error("garbage should not parse")
+	}
+}
+
+func TestParseProcSamplesSkipsSampler(t *testing.T) {
+	out := procStatLine("1", "sleep", "0", "0", "10") + "\tsleep infinity\n" +
+		procStatLine("50", "bash", "1", "1", "10") + "\tbash -lc for p in /proc/[0-9]*; do ...\n"
+	child := procStatLine("51", "cat", "0", "0", "10")
+	out += child[:len("51 (cat) S ")] + "50" + child[len("51 (cat) S 1"):] + "\tcat /proc/51/stat\n"
+
+	samples := parseProcSamples(out)
+	if len(samples) != 1 {
+		t.Fatalf("expected only the workload process, got %v", samples)
+	}
+	if samples[1].Command != "sleep infinity" {
+		t.Errorf("command = %q", samples[1].Command)
+	}
+}
+
+func TestProcessUsage(t *testing.T) {
+	prev := map[int]procSample{1: {PID: 1, Ticks: 100}, 2: {PID: 2, Ticks: 50}}
+	cur := map[int]procSample{1: {PID: 1, Ticks: 300, RSS: 10}, 2: {PID: 2, Ticks: 60, RSS: 99}, 3: {PID: 3, Ticks: 5}}
+	usage := processUsage(prev, cur, 2*time.Second)
+	sortProcessUsage(usage, "cpu")
+	if usage[0].PID != 1 || usage[0].CPU != 100 {
+		t.Errorf("top process = %+v, want pid 1 at 100%%", usage[0])
+	}
+	if usage[1].PID != 2 || usage[1].CPU != 5 {
+		t.Errorf("second process = %+v, want pid 2 at 5%%", usage[1])
+	}
+	sortProcessUsage(usage, "mem")
+	if usage[0].PID != 2 {
+		t.Errorf("mem sort should put pid 2 first, got %+v", usage[0])
+	}
+}
@@ -0,0 +1,153 @@
+package commands
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/spf13/cobra"
+
+	"devbox/internal/config"
+	"devbox/internal/docker"
+)
+
+const tryLabel = "devbox.try"
+
+var tryRmFlag bool
+
+var tryCmd = &cobra.Command{
+	Use:   "try <image|template> [-- <command> [args...]]",
+	Short: "Run a command or shell in a throwaway box",
+	Long: `Start a temporary, unregistered box from an image or template with the current
+directory mounted at /workspace, run a command (or an interactive shell), and remove
+the box when it exits. Nothing is added to the project registry.
+
+Examples:
+  devbox try ubuntu:24.04                     # Scratch shell in a fresh Ubuntu box
+  devbox try python -- python3 -c 'print(1)'  # Run a command with the python template
+  devbox try node:20 --rm=false -- npm test   # Keep the box afterwards for inspection`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		target := args[0]
+		command := args[1:]
+
+		cfg, err := configManager.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+
+		projectConfig, image := resolveTryTarget(target)
+		suffix := make([]byte, 3)
+		if _, err := rand.Read(suffix); err != nil {
+			return err
+		}
+		boxName := "devbox_try_" + hex.EncodeToString(suffix)
+
+		if err := checkImagePolicy(cfg, image); err != nil {
+			return err
+		}
+		if err := checkHostCapacity(cfg, boxName, projectConfig, forceFlag); err != nil {
+			return err
+		}
+		if err := dockerClient.PullImage(image); err != nil {
+			return fmt.Errorf("failed to pull image: %w", err)
+		}
+
+		configMap := tryConfigMap(projectConfig)
+		fmt.Printf("Creating throwaway box '%s' from %s...\n", boxName, image)
+		boxID, err := dockerClient.CreateBoxWithConfig(boxName, image, cwd, "/workspace", configMap)
+		if err != nil {
+			return fmt.Errorf("failed to create box: %w", err)
+		}
+
+		interrupt := make(chan os.Signal, 1)
+		signal.Notify(interrupt, os.Interrupt)
+		defer signal.Stop(interrupt)
+
+		defer func() {
+			if !tryRmFlag {
+				fmt.Printf("Kept box '%s'. Remove it with: docker rm -f %s\n", boxName, boxName)
+				return
+			}
+			fmt.Printf("Removing throwaway box '%s'...\n", boxName)
+			_ = dockerClient.StopBox(boxName)
+			if err := dockerClient.RemoveBox(boxName); err != nil {
+				fmt.Printf("Warning: failed to remove box: %v\n", err)
+			}
+		}()
+
+		if err := dockerClient.StartBox(boxID); err != nil {
+			return fmt.Errorf("failed to start box: %w", err)
+		}
+		if err := dockerClient.WaitForBox(boxName, useProjectTimeouts(projectConfig).Startup); err != nil {
+			return fmt.Errorf("box failed to start: %w", err)
+		}
+		if projectConfig != nil && len(projectConfig.SetupCommands) > 0 {
+			fmt.Printf("Running template setup (%d commands)...\n", len(projectConfig.SetupCommands))
+			if err := dockerClient.ExecuteSetupCommandsWithOutput(boxName, projectConfig.SetupCommands, false); err != nil {
+				return fmt.Errorf("failed to execute setup commands: %w", err)
+			}
+		}
+		if err := dockerClient.SetupDevboxInBox(boxName, "try"); err != nil {
+			fmt.Printf("Warning: failed to set up devbox commands in box: %v\n", err)
+		}
+
+		if len(command) == 0 {
+			if err := docker.AttachShell(boxName); err != nil {
+				return fmt.Errorf("failed to attach shell: %w", err)
+			}
+			return nil
+		}
+		if err := docker.RunCommand(boxName, command); err != nil {
+			return fmt.Errorf("failed to run command: %w", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(tryCmd)
+	tryCmd.Flags().BoolVar(&tryRmFlag, "rm", true, "Remove the box when the command or shell exits (--rm=false keeps it)")
+	tryCmd.Flags().BoolVarP(&forceFlag, "force", "f", false, "Start even when host resources look insufficient")
+}
+
+func resolveTryTarget(target string) (*config.ProjectConfig, string) {
+	for _, name := range configManager.GetAvailableTemplates() {
+		if name != target {
+			continue
+		}
+		if pc, err := configManager.CreateProjectConfigFromTemplate(target, "try"); err == nil && pc != nil {
+			image := pc.BaseImage
+			if image == "" {
+				image = "ubuntu:22.04"
+			}
+			return pc, image
+		}
+	}
+	return nil, target
+}
+
+func tryConfigMap(projectConfig *config.ProjectConfig) map[string]interface{} {
+	configMap := map[string]interface{}{}
+	if projectConfig != nil {
+		if data, err := json.Marshal(projectConfig); err == nil {
+			_ = json.Unmarshal(data, &configMap)
+		}
+	}
+	delete(configMap, "ports")
+	configMap["restart"] = "no"
+	labels, _ := configMap["labels"].(map[string]interface{})
+	if labels == nil {
+		labels = map[string]interface{}{}
+	}
+	labels[tryLabel] = "true"
+	configMap["labels"] = labels
+	return configMap
+}
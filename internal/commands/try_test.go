@@ -0,0 +1,46 @@
+package commands
+
+import (
+	"testing"
+
+	"devbox/internal/config"
+)
+
+func TestResolveTryTarget(t *testing.T) {
+	withTempConfigManager(t)
+
+	pc, image := resolveTryTarget("python")
+	if pc == nil || image != "ubuntu:22.04" || len(pc.SetupCommands) == 0 {
+		t.Errorf("resolveTryTarget(python) = %+v, %q; want template config on ubuntu:22.04", pc, image)
+	}
+
+	pc, image = resolveTryTarget("alpine:3.19")
+	if pc != nil || image != "alpine:3.19" {
+		t.Errorf("resolveTryTarget(alpine:3.19) = %+v, %q; want plain image", pc, image)
+	}
+}
+
+func TestTryConfigMap(t *testing.T) {
+	pc := &config.ProjectConfig{
+		Ports:       []string{"8000:8000"},
+		Environment: map[string]string{"A": "1"},
+		Labels:      map[string]string{"team": "x"},
+		Restart:     "unless-stopped",
+	}
+	m := tryConfigMap(pc)
+	if _, ok := m["ports"]; ok {
+		t.Error("ports should be dropped for throwaway boxes")
+	}
+	if m["restart"] != "no" {
+		t.Errorf("restart = %v, want no", m["restart"])
+	}
+	labels := m["labels"].(map[string]interface{})
+	if labels[tryLabel] != "true" || labels["team"] != "x" {
+		t.Errorf("labels = %v", labels)
+	}
+
+	m = tryConfigMap(nil)
+	if m["labels"].(map[string]interface{})[tryLabel] != "true" {
+		t.Errorf("nil config labels = %v", m["labels"])
+	}
+}
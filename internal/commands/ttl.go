@@ -0,0 +1,180 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"devbox/internal/config"
+)
+
+const ttlCheckInterval = time.Minute
+
+var (
+	ttlUpFlag       string
+	ephemeralUpFlag bool
+)
+
+type ttlEntry struct {
+	Project   string    `json:"project"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Destroy   bool      `json:"destroy,omitempty"`
+}
+
+func ttlStorePath() string {
+	return filepath.Join(configManager.ConfigDir(), "ttl.json")
+}
+
+func loadTTLEntries() map[string]ttlEntry {
+	entries := map[string]ttlEntry{}
+	if data, err := os.ReadFile(ttlStorePath()); err == nil {
+		_ = json.Unmarshal(data, &entries)
+	}
+	return entries
+}
+
+func saveTTLEntries(entries map[string]ttlEntry) error {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(ttlStorePath(), data, 0644)
+}
+
+func effectiveTTL(flag string, projectConfig *config.ProjectConfig) string {
+	if flag != "" {
+		return flag
+	}
+	if projectConfig != nil {
+		return projectConfig.TTL
+	}
+	return ""
+}
+
+func recordBoxTTL(boxName, projectName, ttl string, destroy bool) error {
+	entries := loadTTLEntries()
+	if ttl == "" {
+		if _, ok := entries[boxName]; !ok {
+			return nil
+		}
+		delete(entries, boxName)
+		return saveTTLEntries(entries)
+	}
+	d, err := config.ParseTTL(ttl)
+	if err != nil {
+		return err
+	}
+	entry := ttlEntry{Project: projectName, ExpiresAt: time.Now().Add(d).UTC(), Destroy: destroy}
+	entries[boxName] = entry
+	if err := saveTTLEntries(entries); err != nil {
+		return fmt.Errorf("failed to record ttl: %w", err)
+	}
+	fmt.Printf("Box '%s' will be %s at %s (ttl %s; enforced by 'devbox serve').\n", boxName, ttlActionVerb(entry), entry.ExpiresAt.Local().Format("2006-01-02 15:04"), ttl)
+	return nil
+}
+
+func ttlActionVerb(e ttlEntry) string {
+	if e.Destroy {
+		return "destroyed"
+	}
+	return "stopped"
+}
+
+func dueTTLBoxes(entries map[string]ttlEntry, now time.Time) []string {
+	var due []string
+	for box, e := range entries {
+		if !now.Before(e.ExpiresAt) {
+			due = append(due, box)
+		}
+	}
+	sort.Strings(due)
+	return due
+}
+
+func ttlRemaining(e ttlEntry, now time.Time) string {
+	left := e.ExpiresAt.Sub(now)
+	if left <= 0 {
+		return "expired"
+	}
+	if left < time.Minute {
+		return "<1m"
+	}
+	return left.Round(time.Minute).String()
+}
+
+func expireBoxes(now time.Time) {
+	entries := loadTTLEntries()
+	due := dueTTLBoxes(entries, now)
+	if len(due) == 0 {
+		return
+	}
+	for _, box := range due {
+		e := entries[box]
+		if err := expireBox(box, e); err != nil {
+			fmt.Printf("%s ttl: %s: %v\n", now.Local().Format("15:04:05"), box, err)
+			continue
+		}
+		fmt.Printf("%s ttl: %s expired and was %s\n", now.Local().Format("15:04:05"), box, ttlActionVerb(e))
+		delete(entries, box)
+	}
+	if err := saveTTLEntries(entries); err != nil {
+		fmt.Printf("Warning: failed to update ttl records: %v\n", err)
+	}
+}
+
+func expireBox(box string, e ttlEntry) error {
+	exists, err := dockerClient.BoxExists(box)
+	if err != nil {
+		return err
+	}
+	if exists {
+		if err := dockerClient.StopBox(box); err != nil {
+			return fmt.Errorf("failed to stop box: %w", err)
+		}
+		if e.Destroy {
+			if err := dockerClient.RemoveBox(box); err != nil {
+				return fmt.Errorf("failed to remove box: %w", err)
+			}
+		}
+	}
+	if !e.Destroy {
+		return stopServices(box)
+	}
+	if err := removeServices(box); err != nil {
+		return err
+	}
+	cfg, err := configManager.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if p, ok := cfg.GetProject(e.Project); ok && p.BoxName == box {
+		cfg.RemoveProject(e.Project)
+		if err := configManager.Save(cfg); err != nil {
+			return fmt.Errorf("failed to save configuration: %w", err)
+		}
+		if isReviewWorkspace(p.WorkspacePath) {
+			if err := os.RemoveAll(p.WorkspacePath); err != nil {
+				return fmt.Errorf("failed to remove review workspace: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+func runTTLWatch(ctx context.Context) {
+	expireBoxes(time.Now())
+	ticker := time.NewTicker(ttlCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			expireBoxes(now)
+		}
+	}
+}
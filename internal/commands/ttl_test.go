@@ -0,0 +1,61 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"devbox/internal/config"
+)
+
+func TestDueTTLBoxes(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	entries := map[string]ttlEntry{
+		"devbox_pr-12": {Project: "pr-12", ExpiresAt: now.Add(-time.Minute), Destroy: true},
+		"devbox_pr-13": {Project: "pr-13", ExpiresAt: now},
+		"devbox_api":   {Project: "api", ExpiresAt: now.Add(time.Hour)},
+	}
+	if got := dueTTLBoxes(entries, now); !reflect.DeepEqual(got, []string{"devbox_pr-12", "devbox_pr-13"}) {
+		t.Errorf("dueTTLBoxes() = %v", got)
+	}
+	if got := ttlRemaining(entries["devbox_api"], now.Add(-29*time.Second)); got != "1h0m0s" {
+		t.Errorf("ttlRemaining() = %q", got)
+	}
+	if got := ttlRemaining(entries["devbox_pr-12"], now); got != "expired" {
+		t.Errorf("ttlRemaining() of expired entry = %q", got)
+	}
+}
+
+func TestRecordBoxTTL(t *testing.T) {
+	withTempConfigManager(t)
+
+	pc := &config.ProjectConfig{TTL: "1d"}
+	if got := effectiveTTL("", pc); got != "1d" {
+		t.Errorf("effectiveTTL() = %q, want devbox.json value", got)
+	}
+	if got := effectiveTTL("30m", pc); got != "30m" {
+		t.Errorf("effectiveTTL() = %q, want flag value", got)
+	}
+
+	before := time.Now()
+	if err := recordBoxTTL("devbox_pr-7", "pr-7", "2h", true); err != nil {
+		t.Fatalf("recordBoxTTL() error = %v", err)
+	}
+	e, ok := loadTTLEntries()["devbox_pr-7"]
+	if !ok || !e.Destroy || e.Project != "pr-7" {
+		t.Fatalf("recorded entry = %+v, %v", e, ok)
+	}
+	if d := e.ExpiresAt.Sub(before); d < 2*time.Hour-time.Second || d > 2*time.Hour+time.Minute {
+		t.Errorf("expiry %s after recording, want about 2h", d)
+	}
+
+	if err := recordBoxTTL("devbox_pr-7", "pr-7", "", false); err != nil {
+		t.Fatalf("recordBoxTTL() clearing error = %v", err)
+	}
+	if _, ok := loadTTLEntries()["devbox_pr-7"]; ok {
+		t.Error("up without a ttl should clear the old deadline")
+	}
+	if err := recordBoxTTL("devbox_pr-7", "pr-7", "soon", false); err == nil {
+		t.Error("invalid ttl should be rejected")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -24,7 +25,14 @@ var (
 	upDotfilesPath string
 )
 
-var keepRunningUpFlag bool
+var (
+	keepRunningUpFlag bool
+	applyLockUpFlag   bool
+	noApplyLockUpFlag bool
+	waitUpFlag        bool
+	waitTimeoutUpFlag time.Duration
+	noCacheUpFlag     bool
+)
 
 var upCmd = &cobra.Command{
 	Use:   "up",
@@ -36,136 +44,153 @@ var upCmd = &cobra.Command{
 		if err != nil {
 			return fmt.Errorf("failed to get current directory: %w", err)
 		}
+		return runUp(cwd, "")
+	},
+}
 
-		projectConfig, err := configManager.LoadProjectConfig(cwd)
-		if err != nil {
-			return fmt.Errorf("failed to load project config: %w", err)
-		}
-		if projectConfig == nil {
-			return fmt.Errorf("no project config found in %s (checked devbox.json, devbox.project.json, .devbox.json)", cwd)
-		}
+func runUp(cwd, nameOverride string) error {
+	projectConfig, err := configManager.LoadProjectConfig(cwd)
+	if err != nil {
+		return fmt.Errorf("failed to load project config: %w", err)
+	}
+	if projectConfig == nil {
+		return fmt.Errorf("no project config found in %s (checked devbox.json, devbox.project.json, .devbox.json)", cwd)
+	}
 
-		if err := configManager.ValidateProjectConfig(projectConfig); err != nil {
-			return fmt.Errorf("invalid devbox.json: %w", err)
-		}
+	if err := configManager.ValidateProjectConfig(projectConfig); err != nil {
+		return fmt.Errorf("invalid devbox.json: %w", err)
+	}
+	if err := checkConfigCompatibility(projectConfig); err != nil {
+		return err
+	}
+	if err := checkRestartPolicy(projectConfig); err != nil {
+		return err
+	}
+	useProjectTimeouts(projectConfig)
+	warnWorkspaceFS(cwd, projectConfig)
 
-		projectName := projectConfig.Name
-		if projectName == "" {
+	projectName := firstNonEmpty(nameOverride, projectConfig.Name, filepath.Base(cwd))
 
-			projectName = filepath.Base(cwd)
-		}
+	cfg, err := configManager.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load global config: %w", err)
+	}
+	if err := migrateWorkspaceLayout(cfg, cwd); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+	if err := ensureWorkspaceDataDir(cwd); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
 
-		cfg, err := configManager.Load()
-		if err != nil {
-			return fmt.Errorf("failed to load global config: %w", err)
+	boxName := boxNameFor(cfg, projectName)
+	baseImage := cfg.GetEffectiveBaseImage(&config.Project{Name: projectName, BaseImage: projectConfig.BaseImage}, projectConfig)
+	ttl := effectiveTTL(ttlUpFlag, projectConfig)
+	if ttl != "" {
+		if _, err := config.ParseTTL(ttl); err != nil {
+			return err
 		}
+	} else if ephemeralUpFlag {
+		return fmt.Errorf("--ephemeral requires --ttl or a ttl in devbox.json")
+	}
+	if err := checkProjectImagePolicy(cfg, baseImage, cwd, projectConfig); err != nil {
+		return err
+	}
+	if err := checkServiceImages(cfg, projectConfig.Services); err != nil {
+		return err
+	}
+
+	workspaceBox := "/workspace"
+	if projectConfig.WorkingDir != "" {
+		workspaceBox = projectConfig.WorkingDir
+	}
+	if projectConfig.KubernetesBackend() {
+		return upKubernetes(cfg, projectName, boxName, baseImage, cwd, workspaceBox, projectConfig)
+	}
+	hooks := hookTarget{project: projectName, box: boxName, workspace: cwd, config: projectConfig}
+	if err := runHooks(config.HookPreUp, hooks); err != nil {
+		return err
+	}
 
-		boxName := fmt.Sprintf("devbox_%s", projectName)
-		baseImage := cfg.GetEffectiveBaseImage(&config.Project{Name: projectName, BaseImage: projectConfig.BaseImage}, projectConfig)
+	exists, err := dockerClient.BoxExists(boxName)
+	if err != nil {
+		return fmt.Errorf("failed to check box existence: %w", err)
+	}
 
-		workspaceBox := "/workspace"
-		if projectConfig.WorkingDir != "" {
-			workspaceBox = projectConfig.WorkingDir
+	if exists {
+		if err := checkBoxOwnership(boxName); err != nil {
+			return err
 		}
-
-		exists, err := dockerClient.BoxExists(boxName)
+		if projectConfig.Build != nil {
+			if err := ensureBaseImage(baseImage, cwd, projectConfig, noCacheUpFlag, false); err != nil {
+				return err
+			}
+			warnStaleBuild(boxName, baseImage, projectName)
+		}
+		status, err := dockerClient.GetBoxStatus(boxName)
 		if err != nil {
-			return fmt.Errorf("failed to check box existence: %w", err)
+			return fmt.Errorf("failed to get box status: %w", err)
 		}
-
-		if exists {
-			status, err := dockerClient.GetBoxStatus(boxName)
-			if err != nil {
-				return fmt.Errorf("failed to get box status: %w", err)
-			}
-			if status != "running" {
-				if err := dockerClient.StartBox(boxName); err != nil {
-					return fmt.Errorf("failed to start existing box: %w", err)
-				}
-			}
-
-			checkCmd := exec.Command(engineCmd(), "exec", boxName, "test", "-f", "/etc/devbox-initialized")
-			if checkCmd.Run() != nil {
-				if err := dockerClient.SetupDevboxInBox(boxName, projectName); err != nil {
-					return fmt.Errorf("failed to setup devbox in existing box: %w", err)
-				}
+		if status != "running" {
+			if err := checkHostCapacity(cfg, boxName, projectConfig, forceFlag); err != nil {
+				return err
 			}
-			fmt.Printf("Environment is up.\n")
-			fmt.Printf("Workspace: %s\n", cwd)
-			fmt.Printf("Box: %s\n", boxName)
-			fmt.Printf("Image: %s\n", baseImage)
-			fmt.Printf("Tip: run 'devbox shell %s' to enter the environment.\n", projectName)
-
-			if cfg.Settings != nil && cfg.Settings.AutoStopOnExit && !keepRunningUpFlag {
-				if idle, err := dockerClient.IsContainerIdle(boxName); err == nil && idle {
-					fmt.Printf("Stopping box '%s' (auto-stop: idle)...\n", boxName)
-					if err := dockerClient.StopBox(boxName); err != nil {
-						fmt.Printf("Warning: failed to stop box: %v\n", err)
-					}
-				}
+			if err := dockerClient.StartBox(boxName); err != nil {
+				return fmt.Errorf("failed to start existing box: %w", err)
 			}
-			return nil
 		}
-
-		fmt.Printf("Setting up box '%s' with image '%s'...\n", boxName, baseImage)
-		if err := dockerClient.PullImage(baseImage); err != nil {
-			return fmt.Errorf("failed to pull base image: %w", err)
+		if err := upServices(boxName, cwd, projectConfig.Services); err != nil {
+			return err
 		}
-
-		var configMap map[string]interface{}
-		if projectConfig != nil {
-			data, _ := json.Marshal(projectConfig)
-			_ = json.Unmarshal(data, &configMap)
+		if err := joinServiceNetwork(boxName, projectConfig); err != nil {
+			return err
 		}
 
-		if cfg.Settings != nil && cfg.Settings.AutoStopOnExit {
-			if configMap == nil {
-				configMap = map[string]interface{}{}
-			}
-			if _, ok := configMap["restart"]; !ok {
-				configMap["restart"] = "no"
+		checkCmd := exec.Command(engineCmd(), "exec", boxName, "test", "-f", "/etc/devbox-initialized")
+		if checkCmd.Run() != nil {
+			if err := dockerClient.SetupDevboxInBox(boxName, projectName); err != nil {
+				return fmt.Errorf("failed to setup devbox in existing box: %w", err)
 			}
 		}
-
-		var dotfiles []string
-		if len(projectConfig.Dotfiles) > 0 {
-			dotfiles = append(dotfiles, projectConfig.Dotfiles...)
-		}
-		if upDotfilesPath != "" {
-			dotfiles = append(dotfiles, upDotfilesPath)
-		}
-		if len(dotfiles) > 0 {
-			arr := make([]interface{}, 0, len(dotfiles))
-			for _, s := range dotfiles {
-				arr = append(arr, s)
+		if len(projectConfig.SetupCommands) > 0 {
+			adopted := false
+			if !noCacheUpFlag {
+				adopted, err = adoptSetupState(dockerClient, boxName, projectConfig.SetupCommands)
+				if err != nil {
+					fmt.Printf("Warning: %v\n", err)
+				}
 			}
-			if configMap == nil {
-				configMap = map[string]interface{}{}
+			if !adopted {
+				if err := runTimedSetup(projectName, func() error {
+					return runSetupSteps(dockerClient, boxName, projectConfig.SetupCommands, noCacheUpFlag)
+				}); err != nil {
+					return fmt.Errorf("failed to execute setup commands: %w", err)
+				}
 			}
-			configMap["dotfiles"] = arr
 		}
-
-		optimizedSetup := NewOptimizedSetup(dockerClient, configManager)
-		if err := optimizedSetup.FastUp(projectConfig, projectName, boxName, baseImage, cwd, workspaceBox); err != nil {
-			return fmt.Errorf("failed to start environment: %w", err)
+		if err := applyGitGuards(boxName, workspaceBox, projectConfig); err != nil {
+			fmt.Printf("Warning: %v\n", err)
 		}
-
 		fmt.Printf("Environment is up.\n")
 		fmt.Printf("Workspace: %s\n", cwd)
 		fmt.Printf("Box: %s\n", boxName)
 		fmt.Printf("Image: %s\n", baseImage)
+		printRemoteWorkspace(projectName, boxName)
+		ensureSyncAgent(projectName, projectConfig)
+		printServices(boxName)
 		fmt.Printf("Tip: run 'devbox shell %s' to enter the environment.\n", projectName)
 
-		_ = WriteLockFileForBox(boxName, projectName, cwd, baseImage, "")
-
-		if cfg.Settings != nil && cfg.Settings.AutoApplyLock {
-			lockPath := filepath.Join(cwd, "devbox.lock.json")
-			if _, err := os.Stat(lockPath); err == nil {
-				if err := applyLockInline(projectName, lockPath); err != nil {
-					fmt.Printf("Warning: failed to auto-apply lockfile: %v\n", err)
-				}
+		if waitUpFlag {
+			if err := waitForBoxHealth(boxName, waitTimeoutUpFlag); err != nil {
+				return err
 			}
 		}
+		if err := runHooks(config.HookPostUp, hooks); err != nil {
+			return err
+		}
+		if err := recordBoxTTL(boxName, projectName, ttl, ephemeralUpFlag); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+		notifyWebhooks(newWebhookEvent(config.WebhookUpFinished, projectName, boxName, fmt.Sprintf("Environment '%s' is up", projectName)))
 
 		if cfg.Settings != nil && cfg.Settings.AutoStopOnExit && !keepRunningUpFlag {
 			if idle, err := dockerClient.IsContainerIdle(boxName); err == nil && idle {
@@ -176,110 +201,152 @@ var upCmd = &cobra.Command{
 			}
 		}
 		return nil
-	},
-}
-
-func init() {
-	upCmd.Flags().StringVar(&upDotfilesPath, "dotfiles", "", "Path to local dotfiles directory to mount into the box")
-	upCmd.Flags().BoolVar(&keepRunningUpFlag, "keep-running", false, "Keep the box running after 'up' finishes")
-}
+	}
 
-func applyLockInline(projectName, lockPath string) error {
-	cfg, err := configManager.Load()
-	if err != nil {
+	if err := checkHostCapacity(cfg, boxName, projectConfig, forceFlag); err != nil {
 		return err
 	}
-	proj, ok := cfg.GetProject(projectName)
-	if !ok {
-		return fmt.Errorf("project '%s' not registered", projectName)
+
+	image := pinLockedImage(baseImage, cwd, projectConfig)
+	fmt.Printf("Setting up box '%s' with image '%s'...\n", boxName, image)
+	if err := ensureBaseImage(image, cwd, projectConfig, noCacheUpFlag, false); err != nil {
+		if image != baseImage {
+			return fmt.Errorf("failed to prepare base image: %w\nhint: run 'devbox up --ignore-digest' to use %s instead of the pinned digest", err, baseImage)
+		}
+		return fmt.Errorf("failed to prepare base image: %w", err)
 	}
-	exists, err := dockerClient.BoxExists(proj.BoxName)
-	if err != nil {
+
+	configMap := upConfigMap(projectConfig, boxName, upDotfilesPath)
+	if err := upServices(boxName, cwd, projectConfig.Services); err != nil {
 		return err
 	}
-	if !exists {
-		return fmt.Errorf("box '%s' not found", proj.BoxName)
+
+	optimizedSetup := NewOptimizedSetup(dockerClient, configManager)
+	if err := optimizedSetup.FastUp(projectConfig, configMap, projectName, boxName, image, cwd, workspaceBox); err != nil {
+		return fmt.Errorf("failed to start environment: %w", err)
 	}
-	status, err := dockerClient.GetBoxStatus(proj.BoxName)
-	if err != nil {
+	if err := joinServiceNetwork(boxName, projectConfig); err != nil {
 		return err
 	}
-	if status != "running" {
-		if err := dockerClient.StartBox(proj.BoxName); err != nil {
-			return fmt.Errorf("failed to start box: %w", err)
+	if err := applyGitGuards(boxName, workspaceBox, projectConfig); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+
+	fmt.Printf("Environment is up.\n")
+	fmt.Printf("Workspace: %s\n", cwd)
+	fmt.Printf("Box: %s\n", boxName)
+	fmt.Printf("Image: %s\n", baseImage)
+	printRemoteWorkspace(projectName, boxName)
+	ensureSyncAgent(projectName, projectConfig)
+	printServices(boxName)
+	fmt.Printf("Tip: run 'devbox shell %s' to enter the environment.\n", projectName)
+
+	lockPath := filepath.Join(cwd, "devbox.lock.json")
+	if _, err := os.Stat(lockPath); err == nil && shouldApplyLockOnUp(cfg) {
+		if err := applyLockInline(projectName, lockPath); err != nil {
+			fmt.Printf("Warning: failed to auto-apply lockfile: %v\n", err)
 		}
 	}
+	_ = WriteLockFileForBox(boxName, projectName, cwd, baseImage, "")
 
-	data, err := os.ReadFile(lockPath)
-	if err != nil {
-		return err
+	if waitUpFlag {
+		if err := waitForBoxHealth(boxName, waitTimeoutUpFlag); err != nil {
+			return err
+		}
 	}
-	var lf struct {
-		Packages   struct{ Apt, Pip, Npm, Yarn, Pnpm []string } `json:"packages"`
-		Registries struct {
-			PipIndexURL   string   `json:"pip_index_url"`
-			PipExtraIndex []string `json:"pip_extra_index_urls"`
-			NpmRegistry   string   `json:"npm_registry"`
-			YarnRegistry  string   `json:"yarn_registry"`
-			PnpmRegistry  string   `json:"pnpm_registry"`
-		} `json:"registries"`
-		AptSources struct {
-			SourcesLists  []string `json:"sources_lists"`
-			PinnedRelease string   `json:"pinned_release"`
-		} `json:"apt_sources"`
-	}
-	if err := json.Unmarshal(data, &lf); err != nil {
+	if err := runHooks(config.HookPostUp, hooks); err != nil {
 		return err
 	}
+	if err := recordBoxTTL(boxName, projectName, ttl, ephemeralUpFlag); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+	notifyWebhooks(newWebhookEvent(config.WebhookUpFinished, projectName, boxName, fmt.Sprintf("Environment '%s' is up", projectName)))
 
-	var cmds []string
-	if len(lf.AptSources.SourcesLists) > 0 {
-		heredoc := "cat > /etc/apt/sources.list <<'EOF'\n" + strings.Join(lf.AptSources.SourcesLists, "\n") + "\nEOF"
-		cmds = append(cmds,
-			"cp /etc/apt/sources.list /etc/apt/sources.list.bak 2>/dev/null || true",
-			"rm -f /etc/apt/sources.list.d/*.list 2>/dev/null || true",
-			heredoc,
-			"apt update -y",
-		)
-	}
-	if lf.AptSources.PinnedRelease != "" {
-		cmds = append(cmds, fmt.Sprintf("bash -lc 'echo APT::Default-Release \"%s\"; > /etc/apt/apt.conf.d/99defaultrelease'", lf.AptSources.PinnedRelease))
-	}
-	if lf.Registries.PipIndexURL != "" || len(lf.Registries.PipExtraIndex) > 0 {
-		var b strings.Builder
-		b.WriteString("cat > /etc/pip.conf <<'EOF'\n[global]\n")
-		if lf.Registries.PipIndexURL != "" {
-			b.WriteString("index-url = " + lf.Registries.PipIndexURL + "\n")
-		}
-		for _, u := range lf.Registries.PipExtraIndex {
-			if s := strings.TrimSpace(u); s != "" {
-				b.WriteString("extra-index-url = " + s + "\n")
+	if cfg.Settings != nil && cfg.Settings.AutoStopOnExit && !keepRunningUpFlag {
+		if idle, err := dockerClient.IsContainerIdle(boxName); err == nil && idle {
+			fmt.Printf("Stopping box '%s' (auto-stop: idle)...\n", boxName)
+			if err := dockerClient.StopBox(boxName); err != nil {
+				fmt.Printf("Warning: failed to stop box: %v\n", err)
 			}
 		}
-		b.WriteString("EOF")
-		cmds = append(cmds, b.String())
 	}
-	if lf.Registries.NpmRegistry != "" {
-		cmds = append(cmds, fmt.Sprintf("npm config set registry %s -g", lf.Registries.NpmRegistry))
+	return nil
+}
+
+func upConfigMap(projectConfig *config.ProjectConfig, boxName, dotfilesPath string) map[string]interface{} {
+	var configMap map[string]interface{}
+	if projectConfig != nil {
+		data, _ := json.Marshal(projectConfig)
+		_ = json.Unmarshal(data, &configMap)
+	}
+	if configMap == nil {
+		configMap = map[string]interface{}{}
+	}
+
+	var dotfiles []interface{}
+	if projectConfig != nil {
+		for _, s := range projectConfig.Dotfiles {
+			dotfiles = append(dotfiles, s)
+		}
+	}
+	if dotfilesPath != "" {
+		dotfiles = append(dotfiles, dotfilesPath)
 	}
-	if lf.Registries.YarnRegistry != "" {
-		cmds = append(cmds, fmt.Sprintf("yarn config set npmRegistryServer %s -g", lf.Registries.YarnRegistry))
+	if len(dotfiles) > 0 {
+		configMap["dotfiles"] = dotfiles
 	}
-	if lf.Registries.PnpmRegistry != "" {
-		cmds = append(cmds, fmt.Sprintf("pnpm config set registry %s -g", lf.Registries.PnpmRegistry))
+
+	if projectConfig != nil && len(projectConfig.Services) > 0 && projectConfig.Network == "" {
+		configMap["network"] = serviceNetworkName(boxName)
 	}
+	return configMap
+}
 
-	if err := dockerClient.ExecuteSetupCommandsWithOutput(proj.BoxName, cmds, false); err != nil {
-		return err
+func init() {
+	upCmd.Flags().StringVar(&upDotfilesPath, "dotfiles", "", "Path to local dotfiles directory to mount into the box")
+	upCmd.Flags().BoolVar(&keepRunningUpFlag, "keep-running", false, "Keep the box running after 'up' finishes")
+	upCmd.Flags().BoolVar(&applyLockUpFlag, "apply-lock", false, "Apply devbox.lock.json after startup (overrides settings.auto_apply_lock)")
+	upCmd.Flags().BoolVar(&noApplyLockUpFlag, "no-apply-lock", false, "Skip applying devbox.lock.json after startup (overrides settings.auto_apply_lock)")
+	upCmd.Flags().BoolVarP(&forceFlag, "force", "f", false, "Start even when host resources look insufficient")
+	upCmd.Flags().BoolVar(&waitUpFlag, "wait", false, "Wait until the box's health check reports healthy")
+	upCmd.Flags().BoolVar(&ignoreDigestFlag, "ignore-digest", false, "Create a new box from base_image's tag even when devbox.lock.json pins an image digest")
+	upCmd.Flags().BoolVar(&noCacheUpFlag, "no-cache", false, "Re-run every setup command on an existing box and build the image from devbox.json's build section without the layer cache")
+	upCmd.Flags().DurationVar(&waitTimeoutUpFlag, "wait-timeout", 2*time.Minute, "Maximum time to wait with --wait")
+	upCmd.Flags().StringVar(&ttlUpFlag, "ttl", "", "Stop the box this long after 'up', e.g. 4h or 1d (overrides ttl in devbox.json; needs 'devbox serve')")
+	upCmd.Flags().BoolVar(&ephemeralUpFlag, "ephemeral", false, "With a ttl, destroy the box and forget the project instead of stopping it")
+	upCmd.MarkFlagsMutuallyExclusive("apply-lock", "no-apply-lock")
+}
+
+func shouldApplyLockOnUp(cfg *config.Config) bool {
+	if noApplyLockUpFlag {
+		return false
 	}
+	if applyLockUpFlag {
+		return true
+	}
+	return cfg.Settings != nil && cfg.Settings.AutoApplyLock
+}
 
-	curApt, curPip, curNpm, curYarn, curPnpm := dockerClient.QueryPackagesParallel(proj.BoxName)
-	actions := buildReconcileActions(lockPackages{Apt: lf.Packages.Apt, Pip: lf.Packages.Pip, Npm: lf.Packages.Npm, Yarn: lf.Packages.Yarn, Pnpm: lf.Packages.Pnpm}, curApt, curPip, curNpm, curYarn, curPnpm)
-	if len(actions) > 0 {
-		if err := dockerClient.ExecuteSetupCommandsWithOutput(proj.BoxName, actions, true); err != nil {
-			return err
-		}
+func applyLockInline(projectName, lockPath string) error {
+	cfg, err := configManager.Load()
+	if err != nil {
+		return err
+	}
+	proj, ok := cfg.GetProject(projectName)
+	if !ok {
+		return fmt.Errorf("project '%s' not registered", projectName)
+	}
+	if err := ensureBoxRunning(proj.BoxName); err != nil {
+		return err
+	}
+	lf, err := loadLockFile(lockPath)
+	if err != nil {
+		return err
+	}
+	summary, err := applyLockToBox(proj.BoxName, proj.WorkspacePath, lf)
+	if err != nil {
+		return err
 	}
-	fmt.Println("Applied devbox.lock.json")
+	fmt.Printf("Applied devbox.lock.json: %s\n", summary)
 	return nil
 }
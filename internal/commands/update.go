@@ -3,14 +3,14 @@ package commands
 import (
 	"encoding/json"
 	"fmt"
-	"os"
-	"path/filepath"
-	"strings"
-	"time"
 
 	"github.com/spf13/cobra"
+
+	"devbox/internal/config"
 )
 
+var noCacheUpdateFlag bool
+
 var updateCmd = &cobra.Command{
 	Use:   "update [project]",
 	Short: "Pull latest base image(s) and rebuild box(es)",
@@ -30,6 +30,14 @@ var updateCmd = &cobra.Command{
 }
 
 func updateSingleProject(projectName string) error {
+	if err := rebuildProject(projectName); err != nil {
+		notifyWebhooks(newWebhookEvent(config.WebhookUpdateFailed, projectName, "", fmt.Sprintf("Update of '%s' failed", projectName), err.Error()))
+		return err
+	}
+	return nil
+}
+
+func rebuildProject(projectName string) error {
 	cfg, err := configManager.Load()
 	if err != nil {
 		return fmt.Errorf("failed to load configuration: %w", err)
@@ -42,10 +50,15 @@ func updateSingleProject(projectName string) error {
 
 	projectConfig, _ := configManager.LoadProjectConfig(project.WorkspacePath)
 	baseImage := cfg.GetEffectiveBaseImage(project, projectConfig)
+	if err := checkProjectImagePolicy(cfg, baseImage, project.WorkspacePath, projectConfig); err != nil {
+		return err
+	}
 
-	fmt.Printf("Pulling latest base image for '%s': %s\n", projectName, baseImage)
-	if err := dockerClient.RunDockerCommand([]string{"pull", baseImage}); err != nil {
-		return fmt.Errorf("failed to pull base image %s: %w", baseImage, err)
+	if projectConfig == nil || projectConfig.Build == nil {
+		fmt.Printf("Pulling latest base image for '%s': %s\n", projectName, baseImage)
+	}
+	if err := ensureBaseImage(baseImage, project.WorkspacePath, projectConfig, noCacheUpdateFlag, true); err != nil {
+		return fmt.Errorf("failed to update base image %s: %w", baseImage, err)
 	}
 
 	existsBox, err := dockerClient.BoxExists(project.BoxName)
@@ -83,7 +96,7 @@ func updateSingleProject(projectName string) error {
 		return fmt.Errorf("failed to start box: %w", err)
 	}
 
-	if err := dockerClient.WaitForBox(project.BoxName, 30*time.Second); err != nil {
+	if err := dockerClient.WaitForBox(project.BoxName, workspaceTimeouts(project.WorkspacePath).Startup); err != nil {
 		return fmt.Errorf("box failed to become ready: %w", err)
 	}
 
@@ -96,30 +109,15 @@ func updateSingleProject(projectName string) error {
 	}
 
 	if project.WorkspacePath != "" {
-		lockfilePath := filepath.Join(project.WorkspacePath, "devbox.lock")
-		if _, err := os.Stat(lockfilePath); err == nil {
-			fmt.Printf("Replaying recorded package installs from devbox.lock...\n")
-			if data, readErr := os.ReadFile(lockfilePath); readErr == nil {
-				lines := strings.Split(string(data), "\n")
-				var cmds []string
-				for _, line := range lines {
-					cmd := strings.TrimSpace(line)
-					if cmd == "" || strings.HasPrefix(cmd, "#") {
-						continue
-					}
-					cmds = append(cmds, cmd)
-				}
-				if len(cmds) > 0 {
-					if err := dockerClient.ExecuteSetupCommandsWithOutput(project.BoxName, cmds, false); err != nil {
-						fmt.Printf("warning: failed to replay devbox.lock commands: %v\n", err)
-					}
-				}
-			}
+		if err := replayLegacyLock(dockerClient, project.BoxName, project.WorkspacePath); err != nil {
+			fmt.Printf("warning: failed to replay devbox.lock commands: %v\n", err)
 		}
 	}
 
 	if projectConfig != nil && len(projectConfig.SetupCommands) > 0 {
-		if err := dockerClient.ExecuteSetupCommandsWithOutput(project.BoxName, projectConfig.SetupCommands, false); err != nil {
+		if err := runTimedSetup(projectName, func() error {
+			return runSetupSteps(dockerClient, project.BoxName, projectConfig.SetupCommands, noCacheUpdateFlag)
+		}); err != nil {
 			fmt.Printf("warning: failed to execute setup commands: %v\n", err)
 		}
 	}
@@ -173,5 +171,5 @@ func updateAllProjects() error {
 }
 
 func init() {
-
+	updateCmd.Flags().BoolVar(&noCacheUpdateFlag, "no-cache", false, "Re-run every setup command and build the image from devbox.json's build section without the layer cache")
 }
@@ -3,84 +3,358 @@ package commands
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
 
 	"github.com/spf13/cobra"
+
+	"devbox/internal/config"
 )
 
 type verifyLockFile struct {
-	Version    int            `json:"version"`
-	Project    string         `json:"project"`
-	BoxName    string         `json:"box_name"`
-	Packages   lockPackages   `json:"packages"`
-	Registries lockRegistries `json:"registries"`
-	AptSources lockAptSources `json:"apt_sources"`
+	Version    int                 `json:"version"`
+	Project    string              `json:"project"`
+	BoxName    string              `json:"box_name"`
+	Packages   lockPackages        `json:"packages"`
+	Registries lockRegistries      `json:"registries"`
+	AptSources lockAptSources      `json:"apt_sources"`
+	Container  lockContainer       `json:"container"`
+	Filesystem *lockFilesystem     `json:"filesystem,omitempty"`
+	Ignore     *config.IgnoreRules `json:"ignore,omitempty"`
+}
+
+var builtinPackageManagers = []string{"apt", "pip", "npm", "yarn", "pnpm"}
+
+var (
+	verifyPackagesFlag   bool
+	verifyRegistriesFlag bool
+	verifySourcesFlag    bool
+	verifyContainerFlag  bool
+	verifyFilesystemFlag bool
+	verifyManagersFlag   []string
+	verifyOutputFlag     string
+)
+
+type verifyResult struct {
+	Project  string   `json:"project"`
+	Box      string   `json:"box"`
+	Scope    []string `json:"scope"`
+	Managers []string `json:"managers,omitempty"`
+	OK       bool     `json:"ok"`
+	Drift    []string `json:"drift"`
+	Ignored  []string `json:"ignored,omitempty"`
+}
+
+type verifyScope struct {
+	Packages   bool
+	Registries bool
+	Sources    bool
+	Container  bool
+	Filesystem bool
+	Managers   map[string]bool
+}
+
+func fullVerifyScope() verifyScope {
+	return verifyScope{Packages: true, Registries: true, Sources: true, Container: true, Filesystem: true}
+}
+
+func newVerifyScope(packages, registries, sources, container, filesystem bool, managers, known []string) (verifyScope, error) {
+	scope := verifyScope{Packages: packages, Registries: registries, Sources: sources, Container: container, Filesystem: filesystem}
+	if !packages && !registries && !sources && !container && !filesystem {
+		if len(managers) == 0 {
+			return fullVerifyScope(), nil
+		}
+		scope.Packages = true
+	}
+	if len(managers) == 0 {
+		return scope, nil
+	}
+	allowed := map[string]bool{}
+	for _, k := range known {
+		allowed[k] = true
+	}
+	scope.Managers = map[string]bool{}
+	for _, m := range managers {
+		m = strings.ToLower(strings.TrimSpace(m))
+		if m == "" {
+			continue
+		}
+		if !allowed[m] {
+			return verifyScope{}, fmt.Errorf("unknown package manager '%s' (known: %s)", m, strings.Join(known, ", "))
+		}
+		scope.Managers[m] = true
+	}
+	if len(scope.Managers) == 0 {
+		scope.Managers = nil
+	}
+	return scope, nil
+}
+
+func (s verifyScope) full() bool {
+	return s.Packages && s.Registries && s.Sources && s.Container && s.Filesystem && len(s.Managers) == 0
+}
+
+func (s verifyScope) wantsManager(name string) bool {
+	return len(s.Managers) == 0 || s.Managers[name]
+}
+
+func (s verifyScope) managerNames() []string {
+	if len(s.Managers) == 0 {
+		return []string{"all"}
+	}
+	names := make([]string, 0, len(s.Managers))
+	for m := range s.Managers {
+		names = append(names, m)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s verifyScope) parts() []string {
+	var parts []string
+	if s.Packages {
+		parts = append(parts, "packages")
+	}
+	if s.Registries {
+		parts = append(parts, "registries")
+	}
+	if s.Sources {
+		parts = append(parts, "sources")
+	}
+	if s.Container {
+		parts = append(parts, "container")
+	}
+	if s.Filesystem {
+		parts = append(parts, "filesystem")
+	}
+	return parts
+}
+
+func (s verifyScope) String() string {
+	out := strings.Join(s.parts(), ", ")
+	if len(s.Managers) > 0 {
+		out += " for " + strings.Join(s.managerNames(), ", ")
+	}
+	return out
+}
+
+func verifyManagerNames(lf *verifyLockFile) []string {
+	names := append([]string{}, builtinPackageManagers...)
+	seen := map[string]bool{}
+	for _, n := range names {
+		seen[n] = true
+	}
+	var extra []string
+	for _, pm := range loadExtraPackageManagers() {
+		if !seen[pm.Name] {
+			seen[pm.Name] = true
+			extra = append(extra, pm.Name)
+		}
+	}
+	for name := range lf.Packages.Extra {
+		if !seen[name] {
+			seen[name] = true
+			extra = append(extra, name)
+		}
+	}
+	sort.Strings(extra)
+	return append(names, extra...)
 }
 
 var verifyCmd = &cobra.Command{
 	Use:   "verify <project>",
 	Short: "Verify current box matches devbox.lock.json exactly",
-	Args:  cobra.ExactArgs(1),
+	Long: `Verify that the box matches devbox.lock.json exactly.
+
+By default every check runs. Pass one or more scope flags to run only those checks,
+and --managers to limit package and registry checks to specific package managers.
+
+Examples:
+  devbox verify myproject
+  devbox verify myproject --packages --managers pip,npm
+  devbox verify myproject --registries --sources --container
+  devbox verify myproject --output json     # Structured result on stdout, still exits 1 on drift`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		projectName := args[0]
+		if err := validateOutputFormat(verifyOutputFlag); err != nil {
+			return err
+		}
+		if verifyOutputFlag == outputTable {
+			return runVerify(args[0], nil)
+		}
+		stdout := os.Stdout
+		return withStdoutToStderr(func() error {
+			return runVerify(args[0], stdout)
+		})
+	},
+}
 
-		cfg, err := configManager.Load()
+func runVerify(projectName string, structuredOut io.Writer) error {
+	cfg, err := configManager.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	proj, ok := cfg.GetProject(projectName)
+	if !ok {
+		return fmt.Errorf("project '%s' not found", projectName)
+	}
+
+	lockPath := filepath.Join(proj.WorkspacePath, "devbox.lock.json")
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", lockPath, err)
+	}
+	var lf verifyLockFile
+	if err := json.Unmarshal(data, &lf); err != nil {
+		return fmt.Errorf("invalid lockfile: %w", err)
+	}
+	scope := fullVerifyScope()
+	if verifyPackagesFlag || verifyRegistriesFlag || verifySourcesFlag || verifyContainerFlag || verifyFilesystemFlag || len(verifyManagersFlag) > 0 {
+		scope, err = newVerifyScope(verifyPackagesFlag, verifyRegistriesFlag, verifySourcesFlag, verifyContainerFlag, verifyFilesystemFlag, verifyManagersFlag, verifyManagerNames(&lf))
 		if err != nil {
-			return fmt.Errorf("failed to load config: %w", err)
+			return err
 		}
-		proj, ok := cfg.GetProject(projectName)
-		if !ok {
-			return fmt.Errorf("project '%s' not found", projectName)
+	}
+
+	exists, err := dockerClient.BoxExists(proj.BoxName)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("box '%s' not found; run 'devbox up %s' first", proj.BoxName, projectName)
+	}
+	status, err := dockerClient.GetBoxStatus(proj.BoxName)
+	if err != nil {
+		return err
+	}
+	if status != "running" {
+		if err := startStoppedBox(proj.BoxName); err != nil {
+			return err
 		}
+	}
+	if release, err := dockerClient.UseExecSession(proj.BoxName); err == nil {
+		defer release()
+	}
+
+	rules, err := loadIgnoreRules(proj.WorkspacePath, lf.Ignore)
+	if err != nil {
+		return err
+	}
+	snapshot := loadScopedSnapshot(proj.BoxName, scope)
+	ignored := stripIgnoredPackages(rules, &lf.Packages, &snapshot.Packages, pluginSeparators())
+	drifts := scopedSnapshotDrift(&lf, snapshot, scope)
+	if scope.Container {
+		current, imageEnv := currentContainer(proj.BoxName)
+		ignored = append(ignored, stripIgnoredEnv(rules, &lf.Container, &current, imageEnv)...)
+		drifts = append(drifts, containerDriftStrings(diffContainer(lf.Container, current, imageEnv))...)
+	}
 
-		lockPath := filepath.Join(proj.WorkspacePath, "devbox.lock.json")
-		data, err := os.ReadFile(lockPath)
+	if scope.Filesystem && lf.Filesystem != nil && len(lf.Filesystem.Paths) > 0 {
+		current, err := dockerClient.GetFileHashes(proj.BoxName, lf.Filesystem.Paths, lf.Filesystem.Exclude)
 		if err != nil {
-			return fmt.Errorf("failed to read %s: %w", lockPath, err)
+			return fmt.Errorf("failed to hash box filesystem: %w", err)
+		}
+		added, removed, modified := diffFileHashes(lf.Filesystem.Files, current)
+		for _, p := range modified {
+			drifts = append(drifts, fmt.Sprintf("file modified: %s", p))
+		}
+		for _, p := range added {
+			drifts = append(drifts, fmt.Sprintf("file added: %s", p))
 		}
-		var lf verifyLockFile
-		if err := json.Unmarshal(data, &lf); err != nil {
-			return fmt.Errorf("invalid lockfile: %w", err)
+		for _, p := range removed {
+			drifts = append(drifts, fmt.Sprintf("file removed: %s", p))
 		}
+	}
 
-		exists, err := dockerClient.BoxExists(proj.BoxName)
-		if err != nil {
-			return err
+	if len(ignored) > 0 {
+		fmt.Printf("Ignoring %d difference(s) matched by ignore rules:\n", len(ignored))
+		for _, d := range ignored {
+			fmt.Printf(" ~ %s\n", d)
+		}
+	}
+
+	if structuredOut != nil {
+		result := verifyResult{
+			Project: projectName,
+			Box:     proj.BoxName,
+			Scope:   scope.parts(),
+			OK:      len(drifts) == 0,
+			Drift:   append([]string{}, drifts...),
+			Ignored: ignored,
 		}
-		if !exists {
-			return fmt.Errorf("box '%s' not found; run 'devbox up %s' first", proj.BoxName, projectName)
+		if len(scope.Managers) > 0 {
+			result.Managers = scope.managerNames()
 		}
-		status, err := dockerClient.GetBoxStatus(proj.BoxName)
-		if err != nil {
+		if err := writeStructured(structuredOut, verifyOutputFlag, result); err != nil {
 			return err
 		}
-		if status != "running" {
-			if err := dockerClient.StartBox(proj.BoxName); err != nil {
-				return fmt.Errorf("failed to start box: %w", err)
-			}
+	}
+
+	if len(drifts) > 0 {
+		fmt.Println("error: verification failed. Drift detected:")
+		for _, d := range drifts {
+			fmt.Printf(" - %s\n", d)
 		}
+		fmt.Printf("hint: run 'devbox diff %s' to see which packages, registries, and apt sources changed\n", projectName)
+		notifyWebhooks(newWebhookEvent(config.WebhookDriftDetected, projectName, proj.BoxName, fmt.Sprintf("Environment '%s' drifted from devbox.lock.json", projectName), drifts...))
+		return fmt.Errorf("environment does not match lockfile")
+	}
 
-		aptSnapshot, aptSources, aptRelease := dockerClient.GetAptSources(proj.BoxName)
-		npmReg, yarnReg, pnpmReg := dockerClient.GetNodeRegistries(proj.BoxName)
-		pipIndex, pipExtras := dockerClient.GetPipRegistries(proj.BoxName)
+	if !scope.full() {
+		fmt.Printf("Environment matches devbox.lock.json (checked %s)\n", scope)
+		return nil
+	}
+	fmt.Println("Environment matches devbox.lock.json")
+	return nil
+}
 
-		var drifts []string
+func snapshotDrift(lf *verifyLockFile, snapshot *packageSnapshot) []string {
+	return scopedSnapshotDrift(lf, snapshot, fullVerifyScope())
+}
 
-		if lf.AptSources.SnapshotURL != "" && normalizeURL(lf.AptSources.SnapshotURL) != normalizeURL(aptSnapshot) {
-			drifts = append(drifts, fmt.Sprintf("APT snapshot mismatch: lock=%s current=%s", lf.AptSources.SnapshotURL, aptSnapshot))
-		}
-		if lf.AptSources.PinnedRelease != "" && strings.TrimSpace(lf.AptSources.PinnedRelease) != strings.TrimSpace(aptRelease) {
-			drifts = append(drifts, fmt.Sprintf("APT release mismatch: lock=%s current=%s", lf.AptSources.PinnedRelease, aptRelease))
-		}
-		if len(lf.AptSources.SourcesLists) > 0 {
-			if !stringSetEqual(lf.AptSources.SourcesLists, aptSources) {
-				drifts = append(drifts, "APT sources.list entries drifted")
-			}
+func scopedSnapshotDrift(lf *verifyLockFile, snapshot *packageSnapshot, scope verifyScope) []string {
+	var drifts []string
+	if scope.Sources {
+		drifts = append(drifts, sourcesDrift(lf, snapshot)...)
+	}
+	if scope.Registries {
+		drifts = append(drifts, registriesDrift(lf, snapshot, scope)...)
+	}
+	if scope.Packages {
+		drifts = append(drifts, packagesDrift(lf, snapshot, scope)...)
+	}
+	return drifts
+}
+
+func sourcesDrift(lf *verifyLockFile, snapshot *packageSnapshot) []string {
+	aptSnapshot, aptSources, aptRelease := snapshot.AptSources.SnapshotURL, snapshot.AptSources.SourcesLists, snapshot.AptSources.PinnedRelease
+
+	var drifts []string
+
+	if lf.AptSources.SnapshotURL != "" && normalizeURL(lf.AptSources.SnapshotURL) != normalizeURL(aptSnapshot) {
+		drifts = append(drifts, fmt.Sprintf("APT snapshot mismatch: lock=%s current=%s", lf.AptSources.SnapshotURL, aptSnapshot))
+	}
+	if lf.AptSources.PinnedRelease != "" && strings.TrimSpace(lf.AptSources.PinnedRelease) != strings.TrimSpace(aptRelease) {
+		drifts = append(drifts, fmt.Sprintf("APT release mismatch: lock=%s current=%s", lf.AptSources.PinnedRelease, aptRelease))
+	}
+	if len(lf.AptSources.SourcesLists) > 0 {
+		if !stringSetEqual(lf.AptSources.SourcesLists, aptSources) {
+			drifts = append(drifts, "APT sources.list entries drifted")
 		}
+	}
+	return drifts
+}
+
+func registriesDrift(lf *verifyLockFile, snapshot *packageSnapshot, scope verifyScope) []string {
+	npmReg, yarnReg, pnpmReg := snapshot.Registries.NpmRegistry, snapshot.Registries.YarnRegistry, snapshot.Registries.PnpmRegistry
+	pipIndex, pipExtras := snapshot.Registries.PipIndexURL, snapshot.Registries.PipExtraIndex
 
+	var drifts []string
+
+	if scope.wantsManager("pip") {
 		if lf.Registries.PipIndexURL != "" && normalizeURL(lf.Registries.PipIndexURL) != normalizeURL(pipIndex) {
 			drifts = append(drifts, fmt.Sprintf("pip index-url mismatch: lock=%s current=%s", lf.Registries.PipIndexURL, pipIndex))
 		}
@@ -89,45 +363,58 @@ var verifyCmd = &cobra.Command{
 				drifts = append(drifts, "pip extra-index-urls drifted")
 			}
 		}
+	}
 
-		if lf.Registries.NpmRegistry != "" && normalizeURL(lf.Registries.NpmRegistry) != normalizeURL(npmReg) {
-			drifts = append(drifts, fmt.Sprintf("npm registry mismatch: lock=%s current=%s", lf.Registries.NpmRegistry, npmReg))
-		}
-		if lf.Registries.YarnRegistry != "" && normalizeURL(lf.Registries.YarnRegistry) != normalizeURL(yarnReg) {
-			drifts = append(drifts, fmt.Sprintf("yarn registry mismatch: lock=%s current=%s", lf.Registries.YarnRegistry, yarnReg))
-		}
-		if lf.Registries.PnpmRegistry != "" && normalizeURL(lf.Registries.PnpmRegistry) != normalizeURL(pnpmReg) {
-			drifts = append(drifts, fmt.Sprintf("pnpm registry mismatch: lock=%s current=%s", lf.Registries.PnpmRegistry, pnpmReg))
-		}
+	if scope.wantsManager("npm") && lf.Registries.NpmRegistry != "" && normalizeURL(lf.Registries.NpmRegistry) != normalizeURL(npmReg) {
+		drifts = append(drifts, fmt.Sprintf("npm registry mismatch: lock=%s current=%s", lf.Registries.NpmRegistry, npmReg))
+	}
+	if scope.wantsManager("yarn") && lf.Registries.YarnRegistry != "" && normalizeURL(lf.Registries.YarnRegistry) != normalizeURL(yarnReg) {
+		drifts = append(drifts, fmt.Sprintf("yarn registry mismatch: lock=%s current=%s", lf.Registries.YarnRegistry, yarnReg))
+	}
+	if scope.wantsManager("pnpm") && lf.Registries.PnpmRegistry != "" && normalizeURL(lf.Registries.PnpmRegistry) != normalizeURL(pnpmReg) {
+		drifts = append(drifts, fmt.Sprintf("pnpm registry mismatch: lock=%s current=%s", lf.Registries.PnpmRegistry, pnpmReg))
+	}
+	return drifts
+}
 
-		aptList, pipList, npmList, yarnList, pnpmList := dockerClient.QueryPackagesParallel(proj.BoxName)
-		if !stringSetEqual(lf.Packages.Apt, aptList) {
-			drifts = append(drifts, "APT packages drifted")
+func packagesDrift(lf *verifyLockFile, snapshot *packageSnapshot, scope verifyScope) []string {
+	var drifts []string
+	builtin := []struct {
+		name, label    string
+		locked, actual []string
+	}{
+		{"apt", "APT", lf.Packages.Apt, snapshot.Packages.Apt},
+		{"pip", "pip", lf.Packages.Pip, snapshot.Packages.Pip},
+		{"npm", "npm", lf.Packages.Npm, snapshot.Packages.Npm},
+		{"yarn", "yarn", lf.Packages.Yarn, snapshot.Packages.Yarn},
+		{"pnpm", "pnpm", lf.Packages.Pnpm, snapshot.Packages.Pnpm},
+	}
+	for _, m := range builtin {
+		if scope.wantsManager(m.name) && !stringSetEqual(normalizePackageList(m.name, m.locked), normalizePackageList(m.name, m.actual)) {
+			drifts = append(drifts, m.label+" packages drifted")
 		}
-		if !stringSetEqual(lf.Packages.Pip, pipList) {
-			drifts = append(drifts, "pip packages drifted")
+	}
+	for _, name := range sortedExtraKeys(lf.Packages.Extra) {
+		if !scope.wantsManager(name) {
+			continue
 		}
-		if !stringSetEqual(lf.Packages.Npm, npmList) {
-			drifts = append(drifts, "npm packages drifted")
+		if _, ok := snapshot.Packages.Extra[name]; !ok {
+			drifts = append(drifts, fmt.Sprintf("%s packages are locked but no plugin registers the '%s' package manager", name, name))
+			continue
 		}
-		if !stringSetEqual(lf.Packages.Yarn, yarnList) {
-			drifts = append(drifts, "yarn packages drifted")
+		if !stringSetEqual(lf.Packages.Extra[name], snapshot.Packages.Extra[name]) {
+			drifts = append(drifts, fmt.Sprintf("%s packages drifted", name))
 		}
-		if !stringSetEqual(lf.Packages.Pnpm, pnpmList) {
-			drifts = append(drifts, "pnpm packages drifted")
+	}
+	for _, name := range sortedExtraKeys(snapshot.Packages.Extra) {
+		if !scope.wantsManager(name) {
+			continue
 		}
-
-		if len(drifts) > 0 {
-			fmt.Println("error: verification failed. Drift detected:")
-			for _, d := range drifts {
-				fmt.Printf(" - %s\n", d)
-			}
-			return fmt.Errorf("environment does not match lockfile")
+		if _, ok := lf.Packages.Extra[name]; !ok && len(snapshot.Packages.Extra[name]) > 0 {
+			drifts = append(drifts, fmt.Sprintf("%s packages drifted", name))
 		}
-
-		fmt.Println("Environment matches devbox.lock.json")
-		return nil
-	},
+	}
+	return drifts
 }
 
 func normalizeURL(s string) string {
@@ -160,6 +447,35 @@ func stringSetEqual(a, b []string) bool {
 	return true
 }
 
+func diffFileHashes(locked, current map[string]string) (added, removed, modified []string) {
+	for path, sum := range current {
+		lockedSum, ok := locked[path]
+		if !ok {
+			added = append(added, path)
+		} else if lockedSum != sum {
+			modified = append(modified, path)
+		}
+	}
+	for path := range locked {
+		if _, ok := current[path]; !ok {
+			removed = append(removed, path)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	sort.Strings(modified)
+	return added, removed, modified
+}
+
 func init() {
 	rootCmd.AddCommand(verifyCmd)
+	verifyCmd.RunE = withResultMetric("verify", verifyCmd.RunE)
+	verifyCmd.Flags().BoolVar(&noCacheFlag, "no-cache", false, "Re-query packages and registries instead of reusing a recent snapshot")
+	verifyCmd.Flags().BoolVar(&verifyPackagesFlag, "packages", false, "Check package sets")
+	verifyCmd.Flags().BoolVar(&verifyRegistriesFlag, "registries", false, "Check pip and npm/yarn/pnpm registry settings")
+	verifyCmd.Flags().BoolVar(&verifySourcesFlag, "sources", false, "Check apt sources, snapshot URL, and release")
+	verifyCmd.Flags().BoolVar(&verifyContainerFlag, "container", false, "Check container settings and environment")
+	verifyCmd.Flags().BoolVar(&verifyFilesystemFlag, "filesystem", false, "Check file hashes recorded in the lock's filesystem section")
+	verifyCmd.Flags().StringSliceVar(&verifyManagersFlag, "managers", nil, "Limit package and registry checks to these package managers (apt, pip, npm, yarn, pnpm, cargo, go, gem, composer, conda, or a plugin manager)")
+	addOutputFlag(verifyCmd, &verifyOutputFlag)
 }
@@ -0,0 +1,154 @@
+package commands
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffFileHashes(t *testing.T) {
+	locked := map[string]string{
+		"/etc/a":              "1",
+		"/etc/b":              "2",
+		"/usr/local/bin/c":    "3",
+		"/usr/local/bin/gone": "4",
+	}
+	current := map[string]string{
+		"/etc/a":             "1",
+		"/etc/b":             "changed",
+		"/usr/local/bin/c":   "3",
+		"/usr/local/bin/new": "5",
+	}
+
+	added, removed, modified := diffFileHashes(locked, current)
+
+	if !reflect.DeepEqual(added, []string{"/usr/local/bin/new"}) {
+		t.Errorf("added = %v", added)
+	}
+	if !reflect.DeepEqual(removed, []string{"/usr/local/bin/gone"}) {
+		t.Errorf("removed = %v", removed)
+	}
+	if !reflect.DeepEqual(modified, []string{"/etc/b"}) {
+		t.Errorf("modified = %v", modified)
+	}
+}
+
+func TestDiffFileHashesNoDrift(t *testing.T) {
+	files := map[string]string{"/etc/a": "1"}
+	added, removed, modified := diffFileHashes(files, files)
+	if len(added)+len(removed)+len(modified) != 0 {
+		t.Errorf("expected no drift, got added=%v removed=%v modified=%v", added, removed, modified)
+	}
+}
+
+func TestDiffContainer(t *testing.T) {
+	locked := lockContainer{
+		Restart:   "no",
+		Network:   "bridge",
+		Resources: map[string]string{"cpus": "2", "memory": "2048MB"},
+	}
+	tests := []struct {
+		name      string
+		current   lockContainer
+		want      []string
+		updatable int
+	}{
+		{"in sync", lockContainer{Restart: "no", Network: "bridge", User: "dev", Resources: map[string]string{"cpus": "2", "memory": "2048MB"}}, nil, 0},
+		{"restart and limits", lockContainer{Restart: "unless-stopped", Network: "bridge", Resources: map[string]string{"cpus": "2"}}, []string{"restart", "memory"}, 2},
+		{"network", lockContainer{Restart: "no", Network: "host", Resources: map[string]string{"cpus": "2", "memory": "2048MB"}}, []string{"network"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			drifts := diffContainer(locked, tt.current, nil)
+			var fields []string
+			updatable := 0
+			for _, d := range drifts {
+				fields = append(fields, d.Field)
+				if d.updatable() {
+					updatable++
+				}
+			}
+			if strings.Join(fields, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("drift fields = %v, want %v", fields, tt.want)
+			}
+			if updatable != tt.updatable {
+				t.Errorf("updatable = %d, want %d", updatable, tt.updatable)
+			}
+		})
+	}
+
+	if got := (containerDrift{Field: "memory", Locked: "2048MB"}).String(); got != "container memory mismatch: lock=2048MB current=(none)" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestNewVerifyScope(t *testing.T) {
+	known := []string{"apt", "pip", "npm", "yarn", "pnpm", "cargo"}
+	tests := []struct {
+		name                                             string
+		packages, registries, sources, container, fsFlag bool
+		managers                                         []string
+		want                                             string
+		wantErr                                          bool
+	}{
+		{name: "defaults to everything", want: "packages, registries, sources, container, filesystem"},
+		{name: "packages only", packages: true, want: "packages"},
+		{name: "managers imply packages", managers: []string{"PIP", "cargo"}, want: "packages for cargo, pip"},
+		{name: "registries for npm", registries: true, managers: []string{"npm"}, want: "registries for npm"},
+		{name: "several scopes", sources: true, container: true, want: "sources, container"},
+		{name: "unknown manager", managers: []string{"brew"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scope, err := newVerifyScope(tt.packages, tt.registries, tt.sources, tt.container, tt.fsFlag, tt.managers, known)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newVerifyScope() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && scope.String() != tt.want {
+				t.Errorf("scope = %q, want %q", scope.String(), tt.want)
+			}
+		})
+	}
+	if !fullVerifyScope().full() {
+		t.Errorf("fullVerifyScope().full() = false")
+	}
+}
+
+func TestScopedSnapshotDrift(t *testing.T) {
+	lf := &verifyLockFile{
+		Packages:   lockPackages{Apt: []string{"curl=8"}, Pip: []string{"requests==2.31.0"}, Npm: []string{"typescript@5.4.5"}},
+		Registries: lockRegistries{PipIndexURL: "https://pypi.example/simple", NpmRegistry: "https://npm.example"},
+		AptSources: lockAptSources{PinnedRelease: "jammy"},
+	}
+	snapshot := &packageSnapshot{
+		Packages:   lockPackages{Apt: []string{"curl=7"}, Pip: []string{"requests==2.32.0"}, Npm: []string{"typescript@5.4.5"}},
+		Registries: lockRegistries{PipIndexURL: "https://pypi.org/simple", NpmRegistry: "https://npm.example/"},
+		AptSources: lockAptSources{PinnedRelease: "noble"},
+	}
+	tests := []struct {
+		name  string
+		scope verifyScope
+		want  []string
+	}{
+		{"full", fullVerifyScope(), []string{
+			"APT release mismatch: lock=jammy current=noble",
+			"pip index-url mismatch: lock=https://pypi.example/simple current=https://pypi.org/simple",
+			"APT packages drifted",
+			"pip packages drifted",
+		}},
+		{"packages for npm", verifyScope{Packages: true, Managers: map[string]bool{"npm": true}}, nil},
+		{"packages and registries for pip", verifyScope{Packages: true, Registries: true, Managers: map[string]bool{"pip": true}}, []string{
+			"pip index-url mismatch: lock=https://pypi.example/simple current=https://pypi.org/simple",
+			"pip packages drifted",
+		}},
+		{"sources", verifyScope{Sources: true}, []string{"APT release mismatch: lock=jammy current=noble"}},
+		{"container only", verifyScope{Container: true}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scopedSnapshotDrift(lf, snapshot, tt.scope); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("scopedSnapshotDrift() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
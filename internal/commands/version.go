@@ -16,6 +16,7 @@ var versionCmd = &cobra.Command{
 	Long:  `Display the version and build information for devbox.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Printf("devbox (v%s)\n", Version)
+		fmt.Printf("engine: %s\n", describeEngine())
 	},
 }
 
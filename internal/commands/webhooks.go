@@ -0,0 +1,186 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"devbox/internal/config"
+)
+
+const webhookTimeout = 5 * time.Second
+
+type webhookEvent struct {
+	Event   string   `json:"event"`
+	Project string   `json:"project"`
+	Box     string   `json:"box,omitempty"`
+	Host    string   `json:"host"`
+	Time    string   `json:"time"`
+	Message string   `json:"message"`
+	Details []string `json:"details,omitempty"`
+}
+
+func newWebhookEvent(event, project, box, message string, details ...string) webhookEvent {
+	host, _ := os.Hostname()
+	return webhookEvent{
+		Event:   event,
+		Project: project,
+		Box:     box,
+		Host:    host,
+		Time:    time.Now().UTC().Format(time.RFC3339),
+		Message: message,
+		Details: details,
+	}
+}
+
+func renderWebhookPayload(hook config.Webhook, ev webhookEvent) ([]byte, error) {
+	if hook.Template == "" {
+		return json.Marshal(ev)
+	}
+	tpl, err := template.New("webhook").Funcs(config.WebhookTemplateFuncs).Parse(hook.Template)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook template: %w", err)
+	}
+	var b bytes.Buffer
+	if err := tpl.Execute(&b, ev); err != nil {
+		return nil, fmt.Errorf("failed to render webhook template: %w", err)
+	}
+	return b.Bytes(), nil
+}
+
+func postWebhook(client *http.Client, hook config.Webhook, ev webhookEvent) error {
+	payload, err := renderWebhookPayload(hook, ev)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "devbox/"+strings.TrimPrefix(Version, "v"))
+	req.Header.Set("X-Devbox-Event", ev.Event)
+	for k, v := range hook.Headers {
+		req.Header.Set(k, os.ExpandEnv(v))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+func configuredWebhooks() []config.Webhook {
+	if configManager == nil {
+		return nil
+	}
+	cfg, err := configManager.Load()
+	if err != nil || cfg.Settings == nil {
+		return nil
+	}
+	return cfg.Settings.Webhooks
+}
+
+func notifyWebhooks(ev webhookEvent) {
+	client := &http.Client{Timeout: webhookTimeout}
+	for _, hook := range configuredWebhooks() {
+		if !hook.Wants(ev.Event) {
+			continue
+		}
+		if err := config.ValidateWebhook(hook); err != nil {
+			fmt.Printf("Warning: skipping webhook: %v\n", err)
+			continue
+		}
+		if err := postWebhook(client, hook, ev); err != nil {
+			fmt.Printf("Warning: %s webhook to %s failed: %v\n", ev.Event, webhookHost(hook.URL), err)
+		}
+	}
+}
+
+func webhookHost(rawURL string) string {
+	if i := strings.Index(rawURL, "://"); i != -1 {
+		rest := rawURL[i+3:]
+		if j := strings.IndexAny(rest, "/?#"); j != -1 {
+			rest = rest[:j]
+		}
+		if k := strings.LastIndex(rest, "@"); k != -1 {
+			rest = rest[k+1:]
+		}
+		return rest
+	}
+	return rawURL
+}
+
+func runWebhooksCommand(args []string) error {
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+	hooks := configuredWebhooks()
+	switch args[0] {
+	case "list":
+		if len(hooks) == 0 {
+			fmt.Printf("No webhooks configured. Add them under settings.webhooks in %s\n", configManager.ConfigDir())
+			return nil
+		}
+		fmt.Printf("Webhooks:\n")
+		for i, hook := range hooks {
+			events := "all events"
+			if len(hook.Events) > 0 {
+				events = strings.Join(hook.Events, ", ")
+			}
+			status := ""
+			if err := config.ValidateWebhook(hook); err != nil {
+				status = fmt.Sprintf(" (invalid: %v)", err)
+			}
+			fmt.Printf("  %d. %s -> %s%s\n", i+1, events, webhookHost(hook.URL), status)
+		}
+		return nil
+	case "test":
+		event := config.WebhookUpFinished
+		if len(args) > 1 {
+			event = args[1]
+		}
+		if !config.IsWebhookEvent(event) {
+			return fmt.Errorf("unknown webhook event '%s' (allowed: %s)", event, strings.Join(config.WebhookEvents, ", "))
+		}
+		ev := newWebhookEvent(event, "example", "devbox_example", "This is a test notification from devbox")
+		client := &http.Client{Timeout: webhookTimeout}
+		sent, failed := 0, 0
+		for _, hook := range hooks {
+			if !hook.Wants(event) {
+				continue
+			}
+			if err := config.ValidateWebhook(hook); err != nil {
+				fmt.Printf("error: %s: %v\n", webhookHost(hook.URL), err)
+				failed++
+				continue
+			}
+			if err := postWebhook(client, hook, ev); err != nil {
+				fmt.Printf("error: %s: %v\n", webhookHost(hook.URL), err)
+				failed++
+				continue
+			}
+			fmt.Printf("Sent %s to %s\n", event, webhookHost(hook.URL))
+			sent++
+		}
+		if sent+failed == 0 {
+			fmt.Printf("No webhooks subscribe to '%s'\n", event)
+			return nil
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d webhook(s) failed", failed)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown webhooks command: %s (use list or test)", args[0])
+	}
+}
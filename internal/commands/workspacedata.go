@@ -0,0 +1,130 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"devbox/internal/config"
+)
+
+const (
+	workspaceDataDirName = ".devbox"
+	legacyJournalName    = "devbox.lock"
+	legacyBackupsDirName = ".devbox_backups"
+)
+
+const workspaceDataIgnore = "backups/\nlogs/\njournal\nhistory\n"
+
+func workspaceDataPath(workspacePath string, elem ...string) string {
+	return filepath.Join(append([]string{workspacePath, workspaceDataDirName}, elem...)...)
+}
+
+func journalPath(workspacePath string) string {
+	return workspaceDataPath(workspacePath, "journal")
+}
+
+func journalPaths(workspacePath string) []string {
+	return []string{journalPath(workspacePath), filepath.Join(workspacePath, legacyJournalName)}
+}
+
+func backupsDir(workspacePath string) string {
+	return workspaceDataPath(workspacePath, "backups")
+}
+
+func ensureWorkspaceDataDir(workspacePath string) error {
+	dir := workspaceDataPath(workspacePath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	ignore := filepath.Join(dir, ".gitignore")
+	existing, err := os.ReadFile(ignore)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", ignore, err)
+	}
+	content := string(existing)
+	present := map[string]bool{}
+	for _, line := range strings.Split(content, "\n") {
+		present[strings.TrimSpace(line)] = true
+	}
+	for _, entry := range strings.Split(strings.TrimSpace(workspaceDataIgnore), "\n") {
+		if present[entry] {
+			continue
+		}
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		content += entry + "\n"
+	}
+	if content == string(existing) {
+		return nil
+	}
+	if err := os.WriteFile(ignore, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", ignore, err)
+	}
+	return nil
+}
+
+func migrateWorkspaceLayout(cfg *config.Config, workspacePath string) error {
+	legacyJournal := filepath.Join(workspacePath, legacyJournalName)
+	legacyBackups := filepath.Join(workspacePath, legacyBackupsDirName)
+	_, journalErr := os.Stat(legacyJournal)
+	_, backupsErr := os.Stat(legacyBackups)
+	if journalErr != nil && backupsErr != nil {
+		return nil
+	}
+	if err := ensureWorkspaceDataDir(workspacePath); err != nil {
+		return err
+	}
+
+	if journalErr == nil {
+		data, err := os.ReadFile(legacyJournal)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", legacyJournal, err)
+		}
+		f, err := os.OpenFile(journalPath(workspacePath), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", journalPath(workspacePath), err)
+		}
+		if len(data) > 0 && !strings.HasSuffix(string(data), "\n") {
+			data = append(data, '\n')
+		}
+		_, err = f.Write(data)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w", journalPath(workspacePath), err)
+		}
+		if err := os.Remove(legacyJournal); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", legacyJournal, err)
+		}
+		fmt.Printf("Moved %s to %s\n", legacyJournalName, filepath.Join(workspaceDataDirName, "journal"))
+	}
+
+	if backupsErr == nil {
+		target := backupsDir(workspacePath)
+		if _, err := os.Stat(target); err == nil {
+			fmt.Printf("Warning: both %s and %s exist; move old backups manually\n", legacyBackupsDirName, filepath.Join(workspaceDataDirName, "backups"))
+			return nil
+		}
+		if err := os.Rename(legacyBackups, target); err != nil {
+			return fmt.Errorf("failed to move %s: %w", legacyBackups, err)
+		}
+		changed := false
+		for _, p := range cfg.Projects {
+			if rebased := rebasePath(p.ArchivePath, legacyBackups, target); rebased != p.ArchivePath {
+				p.ArchivePath = rebased
+				changed = true
+			}
+		}
+		if changed {
+			if err := configManager.Save(cfg); err != nil {
+				return fmt.Errorf("failed to save configuration: %w", err)
+			}
+		}
+		fmt.Printf("Moved %s to %s\n", legacyBackupsDirName, filepath.Join(workspaceDataDirName, "backups"))
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"devbox/internal/config"
+)
+
+func TestMigrateWorkspaceLayout(t *testing.T) {
+	withTempConfigManager(t)
+
+	ws := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(ws, ".devbox_backups", "archive-1"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(ws, "devbox.lock"), []byte("apt install git"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(workspaceDataPath(ws), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(journalPath(ws), []byte("pip install flask\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{Projects: map[string]*config.Project{
+		"web": {Name: "web", WorkspacePath: ws, ArchivePath: filepath.Join(ws, ".devbox_backups", "archive-1")},
+	}}
+
+	if err := migrateWorkspaceLayout(cfg, ws); err != nil {
+		t.Fatalf("migrateWorkspaceLayout() error = %v", err)
+	}
+	data, err := os.ReadFile(journalPath(ws))
+	if err != nil || string(data) != "pip install flask\napt install git\n" {
+		t.Errorf("journal = %q, %v; want the legacy journal appended", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(ws, "devbox.lock")); !os.IsNotExist(err) {
+		t.Error("legacy journal should be removed")
+	}
+	if _, err := os.Stat(filepath.Join(backupsDir(ws), "archive-1")); err != nil {
+		t.Errorf("backups were not moved: %v", err)
+	}
+	if got, want := cfg.Projects["web"].ArchivePath, filepath.Join(backupsDir(ws), "archive-1"); got != want {
+		t.Errorf("ArchivePath = %q, want %q", got, want)
+	}
+	if data, err := os.ReadFile(workspaceDataPath(ws, ".gitignore")); err != nil || string(data) != workspaceDataIgnore {
+		t.Errorf(".gitignore = %q, %v", data, err)
+	}
+
+	if err := migrateWorkspaceLayout(cfg, ws); err != nil {
+		t.Errorf("second migrateWorkspaceLayout() error = %v", err)
+	}
+}
+
+func TestEnsureWorkspaceDataDirMergesIgnore(t *testing.T) {
+	ws := t.TempDir()
+	if err := os.MkdirAll(workspaceDataPath(ws), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(workspaceDataPath(ws, ".gitignore"), []byte("backups/\nlogs/\njournal\nlocal.env"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := ensureWorkspaceDataDir(ws); err != nil {
+			t.Fatalf("ensureWorkspaceDataDir() error = %v", err)
+		}
+	}
+	data, err := os.ReadFile(workspaceDataPath(ws, ".gitignore"))
+	if want := "backups/\nlogs/\njournal\nlocal.env\nhistory\n"; err != nil || string(data) != want {
+		t.Errorf(".gitignore = %q, %v; want %q", data, err, want)
+	}
+}
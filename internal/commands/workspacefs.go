@@ -0,0 +1,174 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"devbox/internal/config"
+)
+
+const crlfScanLimit = 2000
+
+var permissionlessFilesystems = map[string]string{
+	"9p":      "a Windows drive mounted into WSL",
+	"drvfs":   "a Windows drive mounted into WSL",
+	"ntfs":    "NTFS",
+	"ntfs3":   "NTFS",
+	"fuseblk": "a FUSE filesystem (often NTFS or exFAT)",
+	"vfat":    "FAT",
+	"exfat":   "exFAT",
+	"cifs":    "an SMB network share",
+	"smb3":    "an SMB network share",
+}
+
+func mountFSType(mounts io.Reader, path string) string {
+	best, fsType := "", ""
+	scanner := bufio.NewScanner(mounts)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 {
+			continue
+		}
+		mountPoint := strings.ReplaceAll(fields[1], `\040`, " ")
+		if mountPoint != "/" && path != mountPoint && !strings.HasPrefix(path, mountPoint+"/") {
+			continue
+		}
+		if len(mountPoint) >= len(best) {
+			best, fsType = mountPoint, fields[2]
+		}
+	}
+	return fsType
+}
+
+func workspaceFSWarning(workspacePath string) string {
+	if runtime.GOOS != "linux" {
+		return ""
+	}
+	abs, err := filepath.Abs(workspacePath)
+	if err != nil {
+		return ""
+	}
+	f, err := os.Open("/proc/mounts")
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	fsType := mountFSType(f, abs)
+	desc, ok := permissionlessFilesystems[fsType]
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("workspace is on %s (%s); file permissions and executable bits will not behave like a Linux filesystem", desc, fsType)
+}
+
+func findCRLFScripts(workspacePath string) []string {
+	var found []string
+	scanned := 0
+	_ = filepath.WalkDir(workspacePath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			switch d.Name() {
+			case ".git", "node_modules", ".venv", "venv", "vendor":
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if scanned >= crlfScanLimit {
+			return filepath.SkipAll
+		}
+		scanned++
+		if hasCRLFShebang(path) {
+			if rel, err := filepath.Rel(workspacePath, path); err == nil {
+				found = append(found, rel)
+			}
+		}
+		return nil
+	})
+	return found
+}
+
+func hasCRLFShebang(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	head := make([]byte, 256)
+	n, _ := io.ReadFull(f, head)
+	head = head[:n]
+	if !bytes.HasPrefix(head, []byte("#!")) {
+		return false
+	}
+	line, _, _ := bytes.Cut(head, []byte("\n"))
+	return bytes.HasSuffix(line, []byte("\r"))
+}
+
+func workspaceFileIssues(workspacePath string) []string {
+	var issues []string
+	if w := workspaceFSWarning(workspacePath); w != "" {
+		issues = append(issues, w)
+	}
+	if scripts := findCRLFScripts(workspacePath); len(scripts) > 0 {
+		example := scripts[0]
+		if len(scripts) > 1 {
+			example = fmt.Sprintf("%s and %d more", example, len(scripts)-1)
+		}
+		issues = append(issues, fmt.Sprintf("%d script(s) have CRLF line endings and will fail with 'bad interpreter' in the box (%s)", len(scripts), example))
+	}
+	return issues
+}
+
+func gitGuardScript(workspaceBox string, g *config.GitGuards) string {
+	if g == nil || (g.AutoCRLF == "" && g.FileMode == nil) {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("command -v git >/dev/null 2>&1 || exit 0")
+	if g.AutoCRLF != "" {
+		fmt.Fprintf(&b, "; git config --global core.autocrlf '%s'", escapeBash(g.AutoCRLF))
+	}
+	if g.FileMode != nil {
+		fmt.Fprintf(&b, "; git config --global core.fileMode %t", *g.FileMode)
+		ws := escapeBash(workspaceBox)
+		fmt.Fprintf(&b, "; if git -C '%s' rev-parse --git-dir >/dev/null 2>&1; then git -C '%s' config core.fileMode %t; fi", ws, ws, *g.FileMode)
+	}
+	return b.String()
+}
+
+func applyGitGuards(boxName, workspaceBox string, projectConfig *config.ProjectConfig) error {
+	if projectConfig == nil {
+		return nil
+	}
+	script := gitGuardScript(workspaceBox, projectConfig.GitGuards)
+	if script == "" {
+		return nil
+	}
+	fmt.Printf("Applying git guards (line endings, file mode)...\n")
+	if _, stderr, err := dockerClient.ExecCapture(boxName, script); err != nil {
+		if s := strings.TrimSpace(stderr); s != "" {
+			return fmt.Errorf("failed to apply git guards: %s", s)
+		}
+		return fmt.Errorf("failed to apply git guards: %w", err)
+	}
+	return nil
+}
+
+func warnWorkspaceFS(workspacePath string, projectConfig *config.ProjectConfig) {
+	w := workspaceFSWarning(workspacePath)
+	if w == "" {
+		return
+	}
+	fmt.Printf("Warning: %s\n", w)
+	if projectConfig == nil || projectConfig.GitGuards == nil {
+		fmt.Printf("hint: add \"git_guards\": {\"autocrlf\": \"input\", \"file_mode\": false} to devbox.json to keep git from reporting every file as changed\n")
+	}
+}
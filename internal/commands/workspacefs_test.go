@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"devbox/internal/config"
+)
+
+func TestMountFSType(t *testing.T) {
+	mounts := `/dev/sdc / ext4 rw,relatime 0 0
+C:\134 /mnt/c 9p rw,noatime 0 0
+/dev/sdd /mnt/c/work\040dir ext4 rw 0 0
+`
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/home/me/devbox/app", "ext4"},
+		{"/mnt/c/Users/me/app", "9p"},
+		{"/mnt/c", "9p"},
+		{"/mnt/cdrom", "ext4"},
+		{"/mnt/c/work dir/app", "ext4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := mountFSType(strings.NewReader(mounts), tt.path); got != tt.want {
+				t.Errorf("mountFSType(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindCRLFScripts(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"build.sh":              "#!/bin/bash\r\necho hi\r\n",
+		"ok.sh":                 "#!/bin/bash\necho hi\n",
+		"notes.txt":             "windows\r\ntext\r\n",
+		"node_modules/x/run.sh": "#!/bin/sh\r\n",
+		"scripts/deploy":        "#!/usr/bin/env bash\r\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got := findCRLFScripts(dir)
+	want := []string{"build.sh", filepath.Join("scripts", "deploy")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findCRLFScripts() = %v, want %v", got, want)
+	}
+}
+
+func TestGitGuardScript(t *testing.T) {
+	off := false
+	if got := gitGuardScript("/workspace", nil); got != "" {
+		t.Errorf("nil guards should produce no script, got %q", got)
+	}
+	got := gitGuardScript("/workspace", &config.GitGuards{AutoCRLF: "input", FileMode: &off})
+	for _, part := range []string{
+		"git config --global core.autocrlf 'input'",
+		"git config --global core.fileMode false",
+		"git -C '/workspace' config core.fileMode false",
+	} {
+		if !strings.Contains(got, part) {
+			t.Errorf("script %q missing %q", got, part)
+		}
+	}
+}
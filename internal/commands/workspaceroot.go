@@ -0,0 +1,240 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"devbox/internal/config"
+)
+
+var (
+	workspacePathFlag   string
+	workspaceRootMove   bool
+	workspaceRootDryRun bool
+)
+
+type workspaceMove struct {
+	Project *config.Project
+	From    string
+	To      string
+}
+
+func resolveWorkspacePath(projectName, pathFlag string) (string, error) {
+	if strings.TrimSpace(pathFlag) == "" {
+		return getWorkspacePath(projectName)
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	path, err := filepath.Abs(expandHomePath(strings.TrimSpace(pathFlag), homeDir))
+	if err != nil {
+		return "", fmt.Errorf("invalid --path %q: %w", pathFlag, err)
+	}
+	return path, nil
+}
+
+func currentWorkspaceRoot(settings *config.GlobalSettings, homeDir string) string {
+	if settings != nil && settings.WorkspaceRoot != "" {
+		return filepath.Clean(expandHomePath(settings.WorkspaceRoot, homeDir))
+	}
+	return filepath.Join(homeDir, "devbox")
+}
+
+func planWorkspaceMoves(cfg *config.Config, oldRoot, newRoot string) []workspaceMove {
+	var moves []workspaceMove
+	for _, name := range sortedProjectNames(cfg) {
+		p := cfg.Projects[name]
+		from := filepath.Clean(p.WorkspacePath)
+		if p.WorkspacePath == "" || from != filepath.Join(oldRoot, name) {
+			continue
+		}
+		to := filepath.Join(newRoot, name)
+		if from == to {
+			continue
+		}
+		moves = append(moves, workspaceMove{Project: p, From: from, To: to})
+	}
+	return moves
+}
+
+func rebasePath(path, from, to string) string {
+	if path == from {
+		return to
+	}
+	if strings.HasPrefix(path, from+string(filepath.Separator)) {
+		return filepath.Join(to, strings.TrimPrefix(path, from))
+	}
+	return path
+}
+
+func runWorkspaceRootCommand(args []string) error {
+	cfg, err := configManager.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("failed to get home directory: %w", err)
+	}
+	oldRoot := currentWorkspaceRoot(cfg.Settings, homeDir)
+
+	if len(args) == 0 {
+		fmt.Printf("Workspace root: %s\n", oldRoot)
+		for _, name := range sortedProjectNames(cfg) {
+			p := cfg.Projects[name]
+			if filepath.Clean(p.WorkspacePath) != filepath.Join(oldRoot, name) {
+				fmt.Printf("  %s lives outside the root: %s\n", name, p.WorkspacePath)
+			}
+		}
+		return nil
+	}
+
+	newRoot := filepath.Clean(expandHomePath(strings.TrimSpace(args[0]), homeDir))
+	if !filepath.IsAbs(newRoot) {
+		return fmt.Errorf("workspace root must be an absolute path or start with ~/")
+	}
+	moves := planWorkspaceMoves(cfg, oldRoot, newRoot)
+
+	if workspaceRootDryRun {
+		fmt.Printf("Would set workspace root: %s -> %s\n", oldRoot, newRoot)
+		if !workspaceRootMove {
+			return nil
+		}
+		for _, m := range moves {
+			fmt.Printf("  would move %s: %s -> %s\n", m.Project.Name, m.From, m.To)
+		}
+		return nil
+	}
+
+	if newRoot == filepath.Join(homeDir, "devbox") {
+		cfg.Settings.WorkspaceRoot = ""
+	} else {
+		cfg.Settings.WorkspaceRoot = newRoot
+	}
+	if err := os.MkdirAll(newRoot, 0755); err != nil {
+		return fmt.Errorf("failed to create workspace root %s: %w", newRoot, err)
+	}
+	if err := configManager.Save(cfg); err != nil {
+		return fmt.Errorf("failed to save configuration: %w", err)
+	}
+	fmt.Printf("Workspace root set to %s\n", newRoot)
+
+	if !workspaceRootMove {
+		if len(moves) > 0 {
+			fmt.Printf("%d existing project(s) stay in %s. New projects go to the new root.\n", len(moves), oldRoot)
+			fmt.Printf("hint: run 'devbox config workspace-root %s --move' to move them too\n", newRoot)
+		}
+		return nil
+	}
+
+	var failed []string
+	for _, m := range moves {
+		if err := moveProjectWorkspace(m); err != nil {
+			fmt.Printf("error: %s: %v\n", m.Project.Name, err)
+			failed = append(failed, m.Project.Name)
+			continue
+		}
+		if err := configManager.Save(cfg); err != nil {
+			return fmt.Errorf("failed to save configuration: %w", err)
+		}
+	}
+	fmt.Printf("Moved %d of %d project(s) to %s\n", len(moves)-len(failed), len(moves), newRoot)
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to move: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+func moveProjectWorkspace(m workspaceMove) error {
+	if _, err := os.Stat(m.To); err == nil {
+		return fmt.Errorf("%s already exists", m.To)
+	}
+	p := m.Project
+
+	wasRunning := false
+	hasBox := false
+	if p.Status != projectStatusArchived {
+		if exists, err := dockerClient.BoxExists(p.BoxName); err == nil && exists {
+			hasBox = true
+			if status, err := dockerClient.GetBoxStatus(p.BoxName); err == nil && status == "running" {
+				wasRunning = true
+			}
+			fmt.Printf("Stopping box '%s'...\n", p.BoxName)
+			if err := dockerClient.StopBox(p.BoxName); err != nil && wasRunning {
+				return fmt.Errorf("failed to stop box: %w", err)
+			}
+		}
+	}
+
+	fmt.Printf("Moving %s -> %s...\n", m.From, m.To)
+	if err := moveDir(m.From, m.To); err != nil {
+		return err
+	}
+	p.WorkspacePath = m.To
+	p.ConfigFile = rebasePath(p.ConfigFile, m.From, m.To)
+	p.ArchivePath = rebasePath(p.ArchivePath, m.From, m.To)
+
+	if !hasBox {
+		return nil
+	}
+	return remountBox(p, wasRunning)
+}
+
+func moveDir(from, to string) error {
+	if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(to), err)
+	}
+	err := os.Rename(from, to)
+	if err == nil || !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+	if out, err := exec.Command("cp", "-a", from, to).CombinedOutput(); err != nil {
+		_ = os.RemoveAll(to)
+		return fmt.Errorf("failed to copy workspace: %s", firstNonEmpty(strings.TrimSpace(string(out)), err.Error()))
+	}
+	return os.RemoveAll(from)
+}
+
+func remountBox(p *config.Project, start bool) error {
+	imageRef := fmt.Sprintf("devbox/%s:remount-%s", p.Name, time.Now().UTC().Format("20060102-150405"))
+	fmt.Printf("Saving box '%s' as %s...\n", p.BoxName, imageRef)
+	if _, err := dockerClient.CommitContainer(p.BoxName, imageRef); err != nil {
+		return fmt.Errorf("failed to snapshot box: %w", err)
+	}
+	if err := dockerClient.RemoveBox(p.BoxName); err != nil {
+		return fmt.Errorf("failed to remove box: %w", err)
+	}
+
+	projectConfig, _ := configManager.LoadProjectConfig(p.WorkspacePath)
+	workspaceBox := "/workspace"
+	if projectConfig != nil && projectConfig.WorkingDir != "" {
+		workspaceBox = projectConfig.WorkingDir
+	}
+	var configMap map[string]interface{}
+	if projectConfig != nil {
+		if data, err := json.Marshal(projectConfig); err == nil {
+			_ = json.Unmarshal(data, &configMap)
+		}
+	}
+
+	fmt.Printf("Recreating box '%s' with workspace %s...\n", p.BoxName, p.WorkspacePath)
+	boxID, err := dockerClient.CreateBoxWithConfig(p.BoxName, imageRef, p.WorkspacePath, workspaceBox, configMap)
+	if err != nil {
+		return fmt.Errorf("failed to recreate box from %s: %w", imageRef, err)
+	}
+	if !start {
+		return nil
+	}
+	if err := dockerClient.StartBox(boxID); err != nil {
+		return fmt.Errorf("failed to start box: %w", err)
+	}
+	return dockerClient.WaitForBox(p.BoxName, workspaceTimeouts(p.WorkspacePath).Startup)
+}
@@ -0,0 +1,86 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"devbox/internal/config"
+)
+
+func TestPlanWorkspaceMoves(t *testing.T) {
+	cfg := &config.Config{Projects: map[string]*config.Project{
+		"api":     {Name: "api", WorkspacePath: "/home/dev/devbox/api"},
+		"web":     {Name: "web", WorkspacePath: "/home/dev/devbox/web/"},
+		"elsewhr": {Name: "elsewhr", WorkspacePath: "/srv/custom/elsewhr"},
+		"renamed": {Name: "renamed", WorkspacePath: "/home/dev/devbox/old-name"},
+	}}
+
+	moves := planWorkspaceMoves(cfg, "/home/dev/devbox", "/data/boxes")
+	if len(moves) != 2 {
+		t.Fatalf("expected 2 moves, got %d: %+v", len(moves), moves)
+	}
+	if moves[0].Project.Name != "api" || moves[0].From != "/home/dev/devbox/api" || moves[0].To != "/data/boxes/api" {
+		t.Errorf("moves[0] = %+v", moves[0])
+	}
+	if moves[1].Project.Name != "web" || moves[1].From != "/home/dev/devbox/web" || moves[1].To != "/data/boxes/web" {
+		t.Errorf("moves[1] = %+v", moves[1])
+	}
+
+	if moves := planWorkspaceMoves(cfg, "/home/dev/devbox", "/home/dev/devbox"); len(moves) != 0 {
+		t.Errorf("same root should plan no moves, got %+v", moves)
+	}
+}
+
+func TestRebasePath(t *testing.T) {
+	tests := []struct {
+		path, want string
+	}{
+		{"/home/dev/devbox/api", "/data/api"},
+		{"/home/dev/devbox/api/devbox.json", "/data/api/devbox.json"},
+		{"/home/dev/devbox/api-v2/devbox.json", "/home/dev/devbox/api-v2/devbox.json"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := rebasePath(tt.path, "/home/dev/devbox/api", "/data/api"); got != tt.want {
+			t.Errorf("rebasePath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestMoveDir(t *testing.T) {
+	root := t.TempDir()
+	from := filepath.Join(root, "old", "api")
+	if err := os.MkdirAll(from, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(from, "devbox.json"), []byte(`{"name":"api"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	to := filepath.Join(root, "new", "nested", "api")
+	if err := moveDir(from, to); err != nil {
+		t.Fatalf("moveDir() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(to, "devbox.json")); err != nil {
+		t.Errorf("moved file missing: %v", err)
+	}
+	if _, err := os.Stat(from); !os.IsNotExist(err) {
+		t.Errorf("source should be gone, stat err = %v", err)
+	}
+}
+
+func TestResolveWorkspacePathFlag(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	got, err := resolveWorkspacePath("api", "~/src/api")
+	if err != nil || got != filepath.Join(home, "src", "api") {
+		t.Errorf("resolveWorkspacePath(~/src/api) = %q, %v", got, err)
+	}
+	got, err = resolveWorkspacePath("api", "/srv/api/")
+	if err != nil || got != "/srv/api" {
+		t.Errorf("resolveWorkspacePath(/srv/api/) = %q, %v", got, err)
+	}
+}
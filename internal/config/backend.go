@@ -0,0 +1,56 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+)
+
+const (
+	BackendDocker     = "docker"
+	BackendKubernetes = "kubernetes"
+)
+
+type Kubernetes struct {
+	Context      string `json:"context,omitempty"`
+	Namespace    string `json:"namespace,omitempty"`
+	StorageClass string `json:"storage_class,omitempty"`
+	Storage      string `json:"storage,omitempty"`
+}
+
+var kubeQuantity = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?(Ki|Mi|Gi|Ti|Pi|k|M|G|T|P)?$`)
+
+func (pc *ProjectConfig) KubernetesBackend() bool {
+	return pc != nil && pc.Backend == BackendKubernetes
+}
+
+func ValidateBackend(pc *ProjectConfig) error {
+	switch pc.Backend {
+	case "", BackendDocker:
+		if pc.Kubernetes != nil {
+			return fmt.Errorf("kubernetes settings need \"backend\": \"kubernetes\"")
+		}
+		return nil
+	case BackendKubernetes:
+	default:
+		return fmt.Errorf("invalid backend '%s' (expected %s or %s)", pc.Backend, BackendDocker, BackendKubernetes)
+	}
+	if pc.Build != nil {
+		return fmt.Errorf("the kubernetes backend cannot build images; push the image to a registry and set base_image")
+	}
+	if len(pc.Services) > 0 {
+		return fmt.Errorf("the kubernetes backend does not support services yet")
+	}
+	if len(pc.Secrets) > 0 {
+		return fmt.Errorf("the kubernetes backend does not support secrets yet; use a Kubernetes Secret in the cluster")
+	}
+	if pc.CreateUser {
+		return fmt.Errorf("create_user is not available with the kubernetes backend; set user to an account that exists in the image")
+	}
+	if pc.Workspace.Synced() {
+		return fmt.Errorf("workspace.mode \"sync\" is not available with the kubernetes backend; use 'devbox sync' to copy files")
+	}
+	if pc.Kubernetes != nil && pc.Kubernetes.Storage != "" && !kubeQuantity.MatchString(pc.Kubernetes.Storage) {
+		return fmt.Errorf("invalid kubernetes.storage '%s' (expected a quantity such as 10Gi)", pc.Kubernetes.Storage)
+	}
+	return nil
+}
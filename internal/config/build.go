@@ -0,0 +1,94 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type Build struct {
+	Dockerfile string            `json:"dockerfile,omitempty"`
+	Context    string            `json:"context,omitempty"`
+	Args       map[string]string `json:"args,omitempty"`
+	Target     string            `json:"target,omitempty"`
+}
+
+func BuildImageTag(projectName string) string {
+	return fmt.Sprintf("devbox/%s:build", projectName)
+}
+
+func (b *Build) Paths(workspace string) (contextDir, dockerfile string) {
+	contextDir = b.Context
+	if contextDir == "" {
+		contextDir = "."
+	}
+	if !filepath.IsAbs(contextDir) {
+		contextDir = filepath.Join(workspace, contextDir)
+	}
+	dockerfile = b.Dockerfile
+	if dockerfile == "" {
+		dockerfile = "Dockerfile"
+	}
+	if !filepath.IsAbs(dockerfile) {
+		dockerfile = filepath.Join(contextDir, dockerfile)
+	}
+	return filepath.Clean(contextDir), filepath.Clean(dockerfile)
+}
+
+func (b *Build) BaseImages(workspace string) ([]string, error) {
+	_, dockerfile := b.Paths(workspace)
+	data, err := os.ReadFile(dockerfile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read build.dockerfile: %w", err)
+	}
+	return DockerfileBaseImages(string(data), b.Args), nil
+}
+
+func DockerfileBaseImages(dockerfile string, buildArgs map[string]string) []string {
+	args := map[string]string{}
+	stages := map[string]bool{}
+	seen := map[string]bool{}
+	var images []string
+	fromSeen := false
+
+	scanner := bufio.NewScanner(strings.NewReader(dockerfile))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		switch strings.ToUpper(fields[0]) {
+		case "ARG":
+			if fromSeen {
+				continue
+			}
+			name, value, _ := strings.Cut(fields[1], "=")
+			args[name] = strings.Trim(value, `"'`)
+		case "FROM":
+			fromSeen = true
+			rest := fields[1:]
+			for len(rest) > 0 && strings.HasPrefix(rest[0], "--") {
+				rest = rest[1:]
+			}
+			if len(rest) == 0 {
+				continue
+			}
+			image := os.Expand(rest[0], func(key string) string {
+				if v, ok := buildArgs[key]; ok {
+					return v
+				}
+				return args[key]
+			})
+			if image != "scratch" && !stages[strings.ToLower(image)] && !seen[image] {
+				seen[image] = true
+				images = append(images, image)
+			}
+			if len(rest) >= 3 && strings.EqualFold(rest[1], "AS") {
+				stages[strings.ToLower(rest[2])] = true
+			}
+		}
+	}
+	return images
+}
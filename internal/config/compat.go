@@ -0,0 +1,87 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const SchemaVersion = 1
+
+var SchemaVersions = map[int]string{
+	1: "1.0",
+}
+
+const (
+	StrictnessWarn   = "warn"
+	StrictnessStrict = "strict"
+	StrictnessIgnore = "ignore"
+)
+
+var fieldSuggestions = map[string]string{
+	"image":       "base_image",
+	"env":         "environment",
+	"workdir":     "working_dir",
+	"setup":       "setup_commands",
+	"commands":    "setup_commands",
+	"healthcheck": "health_check",
+	"cap_add":     "capabilities",
+}
+
+func (cfg *ProjectConfig) CompatibilityWarnings() []string {
+	return cfg.warnings
+}
+
+func compatibilityWarnings(data []byte, schemaVersion int) []string {
+	var warnings []string
+	if schemaVersion > SchemaVersion {
+		warnings = append(warnings, fmt.Sprintf("schema_version %d is newer than this devbox supports (%d); fields it does not know are ignored, upgrade devbox for full support", schemaVersion, SchemaVersion))
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return warnings
+	}
+	for _, field := range unknownFields(raw, reflect.TypeOf(ProjectConfig{}), "") {
+		msg := fmt.Sprintf("unknown field %q (ignored)", field)
+		if s, ok := fieldSuggestions[field]; ok {
+			msg = fmt.Sprintf("unknown field %q (ignored; did you mean %q?)", field, s)
+		}
+		warnings = append(warnings, msg)
+	}
+	return warnings
+}
+
+func unknownFields(raw map[string]json.RawMessage, t reflect.Type, prefix string) []string {
+	known := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		known[name] = f.Type
+	}
+
+	var unknown []string
+	for key, value := range raw {
+		ft, ok := known[key]
+		if !ok {
+			unknown = append(unknown, prefix+key)
+			continue
+		}
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() != reflect.Struct {
+			continue
+		}
+		var nested map[string]json.RawMessage
+		if err := json.Unmarshal(value, &nested); err == nil {
+			unknown = append(unknown, unknownFields(nested, ft, prefix+key+".")...)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
@@ -6,7 +6,6 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
-	"time"
 
 	"errors"
 
@@ -19,12 +18,62 @@ type Config struct {
 }
 
 type GlobalSettings struct {
-	DefaultBaseImage    string            `json:"default_base_image,omitempty"`
-	DefaultEnvironment  map[string]string `json:"default_environment,omitempty"`
-	ConfigTemplatesPath string            `json:"config_templates_path,omitempty"`
-	AutoUpdate          bool              `json:"auto_update,omitempty"`
-	AutoStopOnExit      bool              `json:"auto_stop_on_exit,omitempty"`
-	AutoApplyLock       bool              `json:"auto_apply_lock,omitempty"`
+	DefaultBaseImage        string                   `json:"default_base_image,omitempty"`
+	WorkspaceRoot           string                   `json:"workspace_root,omitempty"`
+	DefaultEnvironment      map[string]string        `json:"default_environment,omitempty"`
+	ConfigTemplatesPath     string                   `json:"config_templates_path,omitempty"`
+	AutoUpdate              bool                     `json:"auto_update,omitempty"`
+	AutoStopOnExit          bool                     `json:"auto_stop_on_exit,omitempty"`
+	AutoApplyLock           bool                     `json:"auto_apply_lock,omitempty"`
+	AutoStart               string                   `json:"auto_start,omitempty"`
+	Engine                  string                   `json:"engine,omitempty"`
+	DockerHost              string                   `json:"docker_host,omitempty"`
+	UserBoxPrefix           bool                     `json:"user_box_prefix,omitempty"`
+	GC                      *GCPolicy                `json:"gc,omitempty"`
+	EnableParallel          *bool                    `json:"enable_parallel,omitempty"`
+	MaxWorkers              int                      `json:"max_workers,omitempty"`
+	SetupWorkers            int                      `json:"setup_workers,omitempty"`
+	QueryWorkers            int                      `json:"query_workers,omitempty"`
+	SetupSchedule           map[string]SetupSchedule `json:"setup_schedule,omitempty"`
+	RequireEncryptedBackups bool                     `json:"require_encrypted_backups,omitempty"`
+	ConfigStrictness        string                   `json:"config_strictness,omitempty"`
+	Profile                 string                   `json:"profile,omitempty"`
+	Mirrors                 *MirrorSettings          `json:"mirrors,omitempty"`
+	Proxy                   *ProxySettings           `json:"proxy,omitempty"`
+	DefaultResources        *Resources               `json:"default_resources,omitempty"`
+	DefaultRestart          string                   `json:"default_restart,omitempty"`
+	Webhooks                []Webhook                `json:"webhooks,omitempty"`
+	ImagePolicy             *ImagePolicy             `json:"image_policy,omitempty"`
+	Timeouts                *Timeouts                `json:"timeouts,omitempty"`
+}
+
+type MirrorSettings struct {
+	Pip string `json:"pip,omitempty"`
+	Npm string `json:"npm,omitempty"`
+	Go  string `json:"go,omitempty"`
+}
+
+type ProxySettings struct {
+	HTTP    string `json:"http,omitempty"`
+	HTTPS   string `json:"https,omitempty"`
+	NoProxy string `json:"no_proxy,omitempty"`
+}
+
+type SetupSchedule struct {
+	Priority *int `json:"priority,omitempty"`
+	Weight   int  `json:"weight,omitempty"`
+}
+
+type GCPolicy struct {
+	StaleAfterDays   int    `json:"stale_after_days,omitempty"`
+	RemoveStaleBoxes bool   `json:"remove_stale_boxes,omitempty"`
+	RemoveOrphans    bool   `json:"remove_orphans,omitempty"`
+	KeepBackups      int    `json:"keep_backups,omitempty"`
+	PruneImages      bool   `json:"prune_images,omitempty"`
+	PruneVolumes     bool   `json:"prune_volumes,omitempty"`
+	TrimCacheVolumes bool   `json:"trim_cache_volumes,omitempty"`
+	ScheduledPrune   bool   `json:"scheduled_prune,omitempty"`
+	Interval         string `json:"interval,omitempty"`
 }
 
 type Project struct {
@@ -34,26 +83,47 @@ type Project struct {
 	WorkspacePath string `json:"workspace_path"`
 	Status        string `json:"status,omitempty"`
 	ConfigFile    string `json:"config_file,omitempty"`
+	ArchivePath   string `json:"archive_path,omitempty"`
 }
 
 type ProjectConfig struct {
-	Name          string            `json:"name"`
-	BaseImage     string            `json:"base_image,omitempty"`
-	SetupCommands []string          `json:"setup_commands,omitempty"`
-	Environment   map[string]string `json:"environment,omitempty"`
-	Ports         []string          `json:"ports,omitempty"`
-	Volumes       []string          `json:"volumes,omitempty"`
-	Dotfiles      []string          `json:"dotfiles,omitempty"`
-	WorkingDir    string            `json:"working_dir,omitempty"`
-	Shell         string            `json:"shell,omitempty"`
-	User          string            `json:"user,omitempty"`
-	Capabilities  []string          `json:"capabilities,omitempty"`
-	Labels        map[string]string `json:"labels,omitempty"`
-	Network       string            `json:"network,omitempty"`
-	Restart       string            `json:"restart,omitempty"`
-	HealthCheck   *HealthCheck      `json:"health_check,omitempty"`
-	Resources     *Resources        `json:"resources,omitempty"`
-	Gpus          string            `json:"gpus,omitempty"`
+	Schema        string              `json:"$schema,omitempty"`
+	SchemaVersion int                 `json:"schema_version,omitempty"`
+	Name          string              `json:"name"`
+	BaseImage     string              `json:"base_image,omitempty"`
+	Build         *Build              `json:"build,omitempty"`
+	SetupCommands []string            `json:"setup_commands,omitempty"`
+	Environment   map[string]string   `json:"environment,omitempty"`
+	Ports         []string            `json:"ports,omitempty"`
+	Volumes       []string            `json:"volumes,omitempty"`
+	Dotfiles      []string            `json:"dotfiles,omitempty"`
+	WorkingDir    string              `json:"working_dir,omitempty"`
+	Shell         string              `json:"shell,omitempty"`
+	User          string              `json:"user,omitempty"`
+	CreateUser    bool                `json:"create_user,omitempty"`
+	Capabilities  []string            `json:"capabilities,omitempty"`
+	Labels        map[string]string   `json:"labels,omitempty"`
+	Network       string              `json:"network,omitempty"`
+	DockerHost    string              `json:"docker_host,omitempty"`
+	Workspace     *Workspace          `json:"workspace,omitempty"`
+	Backend       string              `json:"backend,omitempty"`
+	Kubernetes    *Kubernetes         `json:"kubernetes,omitempty"`
+	Restart       string              `json:"restart,omitempty"`
+	HealthCheck   *HealthCheck        `json:"health_check,omitempty"`
+	Resources     *Resources          `json:"resources,omitempty"`
+	Gpus          string              `json:"gpus,omitempty"`
+	FSManifest    []string            `json:"fs_manifest,omitempty"`
+	GitGuards     *GitGuards          `json:"git_guards,omitempty"`
+	Ignore        *IgnoreRules        `json:"ignore,omitempty"`
+	TTL           string              `json:"ttl,omitempty"`
+	Timeouts      *Timeouts           `json:"timeouts,omitempty"`
+	Services      map[string]*Service `json:"services,omitempty"`
+	Hooks         map[string][]Hook   `json:"hooks,omitempty"`
+	Secrets       map[string]Secret   `json:"secrets,omitempty"`
+	Tmux          *Tmux               `json:"tmux,omitempty"`
+
+	warnings   []string
+	normalized []string
 }
 
 type HealthCheck struct {
@@ -69,6 +139,11 @@ type Resources struct {
 	Memory string `json:"memory,omitempty"`
 }
 
+type GitGuards struct {
+	AutoCRLF string `json:"autocrlf,omitempty"`
+	FileMode *bool  `json:"file_mode,omitempty"`
+}
+
 type ConfigTemplate struct {
 	Name        string        `json:"name"`
 	Description string        `json:"description"`
@@ -97,6 +172,15 @@ func NewConfigManager() (*ConfigManager, error) {
 	return &ConfigManager{configPath: configPath}, nil
 }
 
+func (cm *ConfigManager) ConfigDir() string {
+	return filepath.Dir(cm.configPath)
+}
+
+func (cm *ConfigManager) Exists() bool {
+	_, err := os.Stat(cm.configPath)
+	return err == nil
+}
+
 func (cm *ConfigManager) Load() (*Config, error) {
 	config := &Config{
 		Projects: make(map[string]*Project),
@@ -174,10 +258,16 @@ func (cm *ConfigManager) LoadProjectConfig(projectPath string) (*ProjectConfig,
 		return nil, fmt.Errorf("failed to read project config file: %w", err)
 	}
 
+	return ParseProjectConfig(data)
+}
+
+func ParseProjectConfig(data []byte) (*ProjectConfig, error) {
 	var projectConfig ProjectConfig
 	if err := json.Unmarshal(data, &projectConfig); err != nil {
 		return nil, fmt.Errorf("failed to parse project config file: %w", err)
 	}
+	projectConfig.warnings = compatibilityWarnings(data, projectConfig.SchemaVersion)
+	projectConfig.normalized = projectConfig.normalize()
 
 	return &projectConfig, nil
 }
@@ -233,50 +323,94 @@ func (cm *ConfigManager) ValidateProjectConfig(cfg *ProjectConfig) error {
 	}
 
 	for _, port := range cfg.Ports {
-		if !strings.Contains(port, ":") && !strings.Contains(port, "/") {
-
-			return fmt.Errorf("invalid port mapping '%s' (expected host:container or container[/proto])", port)
+		if err := validatePortMapping(port); err != nil {
+			return err
 		}
 	}
 	for _, volume := range cfg.Volumes {
-		if !strings.Contains(volume, ":") {
-			return fmt.Errorf("invalid volume mapping '%s' (expected host:container)", volume)
+		if err := validateVolumeMapping(volume); err != nil {
+			return err
 		}
 	}
-	if cfg.HealthCheck != nil {
-		if len(cfg.HealthCheck.Test) > 0 && cfg.HealthCheck.Test[0] == "NONE" && len(cfg.HealthCheck.Test) > 1 {
-			return fmt.Errorf("health_check.test cannot have arguments when set to NONE")
+	if err := ValidateServices(cfg.Services); err != nil {
+		return err
+	}
+	if cfg.Build != nil && cfg.BaseImage != "" {
+		return fmt.Errorf("set either base_image or build, not both (the image built from build.dockerfile is the base image)")
+	}
+	if err := ValidateIgnoreRules(cfg.Ignore); err != nil {
+		return err
+	}
+	if err := ValidateWorkspace(cfg.Workspace); err != nil {
+		return err
+	}
+	if err := ValidateResources("resources", cfg.Resources); err != nil {
+		return err
+	}
+	if err := ValidateGpus(cfg.Gpus); err != nil {
+		return err
+	}
+	if err := ValidateCreateUser(cfg); err != nil {
+		return err
+	}
+	if err := ValidateBackend(cfg); err != nil {
+		return err
+	}
+	if cfg.TTL != "" {
+		if _, err := ParseTTL(cfg.TTL); err != nil {
+			return err
 		}
+	}
+	if err := ValidateTimeouts(cfg.Timeouts); err != nil {
+		return err
+	}
+	if err := ValidateHooks(cfg.Hooks); err != nil {
+		return err
+	}
+	if err := ValidateSecrets(cfg.Secrets, cfg.Environment); err != nil {
+		return err
+	}
+	if err := ValidateTmux(cfg.Tmux); err != nil {
+		return err
+	}
+	return ValidateHealthCheck(cfg.HealthCheck)
+}
 
-		if cfg.HealthCheck.Interval != "" {
-			if _, err := time.ParseDuration(strings.ReplaceAll(cfg.HealthCheck.Interval, "m", "m0s")); err != nil && !durationLike(cfg.HealthCheck.Interval) {
-
-			}
-		}
+func validatePortMapping(port string) error {
+	if !strings.Contains(port, ":") && !strings.Contains(port, "/") {
+		return fmt.Errorf("invalid port mapping '%s' (expected host:container or container[/proto])", port)
 	}
 	return nil
 }
 
-func durationLike(s string) bool {
+func validateVolumeMapping(volume string) error {
+	if !strings.Contains(volume, ":") {
+		return fmt.Errorf("invalid volume mapping '%s' (expected host:container)", volume)
+	}
+	if host, _, _ := strings.Cut(volume, ":"); ambiguousVolumeHost(host) {
+		return fmt.Errorf("invalid volume mapping '%s': relative host paths must start with ./ or ../ (resolved against the workspace), e.g. './%s'", volume, volume)
+	}
+	return nil
+}
 
-	for _, suf := range []string{"ns", "us", "ms", "s", "m", "h"} {
-		if strings.HasSuffix(s, suf) {
-			return true
-		}
+func ambiguousVolumeHost(host string) bool {
+	if host == "" || filepath.IsAbs(host) || strings.HasPrefix(host, "~") || strings.HasPrefix(host, ".") {
+		return false
 	}
-	return false
+	return strings.ContainsAny(host, `/\`)
 }
 
 func (cm *ConfigManager) GetDefaultProjectConfig(projectName string) *ProjectConfig {
 	return &ProjectConfig{
-		Name:        projectName,
-		BaseImage:   "ubuntu:22.04",
-		WorkingDir:  "/workspace",
-		Shell:       "/bin/bash",
-		User:        "root",
-		Restart:     "unless-stopped",
-		Environment: make(map[string]string),
-		Labels:      make(map[string]string),
+		Schema:        ProjectConfigSchemaURL,
+		SchemaVersion: SchemaVersion,
+		Name:          projectName,
+		BaseImage:     "ubuntu:22.04",
+		WorkingDir:    "/workspace",
+		Shell:         "/bin/bash",
+		User:          "root",
+		Environment:   make(map[string]string),
+		Labels:        make(map[string]string),
 
 		Volumes:       []string{},
 		SetupCommands: []string{},
@@ -359,6 +493,8 @@ func (cm *ConfigManager) CreateProjectConfigFromTemplate(templateName, projectNa
 			var cfg ProjectConfig
 			_ = json.Unmarshal(data, &cfg)
 			cfg.Name = projectName
+			cfg.Schema = ProjectConfigSchemaURL
+			cfg.SchemaVersion = SchemaVersion
 			return &cfg, nil
 		}
 		return nil, fmt.Errorf("template '%s' not found", templateName)
@@ -368,6 +504,8 @@ func (cm *ConfigManager) CreateProjectConfigFromTemplate(templateName, projectNa
 	var config ProjectConfig
 	json.Unmarshal(configData, &config)
 	config.Name = projectName
+	config.Schema = ProjectConfigSchemaURL
+	config.SchemaVersion = SchemaVersion
 
 	return &config, nil
 }
@@ -505,7 +643,29 @@ func (config *Config) MergeProjectConfig(project *Project, projectConfig *Projec
 	}
 }
 
+func (config *Config) GetGCPolicy() *GCPolicy {
+	if config.Settings == nil || config.Settings.GC == nil {
+		return &GCPolicy{
+			StaleAfterDays: 30,
+			RemoveOrphans:  true,
+			KeepBackups:    3,
+			PruneImages:    true,
+		}
+	}
+	policy := *config.Settings.GC
+	if policy.StaleAfterDays <= 0 {
+		policy.StaleAfterDays = 30
+	}
+	if policy.KeepBackups <= 0 {
+		policy.KeepBackups = 3
+	}
+	return &policy
+}
+
 func (config *Config) GetEffectiveBaseImage(project *Project, projectConfig *ProjectConfig) string {
+	if projectConfig != nil && projectConfig.Build != nil {
+		return BuildImageTag(project.Name)
+	}
 	if projectConfig != nil && projectConfig.BaseImage != "" {
 		return projectConfig.BaseImage
 	}
@@ -518,46 +678,197 @@ func (config *Config) GetEffectiveBaseImage(project *Project, projectConfig *Pro
 	return "ubuntu:22.04"
 }
 
+const ProjectConfigSchemaURL = "https://devbox.ar0.eu/devbox.schema.json"
+
 const ProjectConfigJSONSchema = `{
 	"$schema": "http://json-schema.org/draft-07/schema#",
+	"$id": "https://devbox.ar0.eu/devbox.schema.json",
 	"title": "Devbox Project Config",
+	"description": "Project configuration for devbox (devbox.json)",
 	"type": "object",
 	"required": ["name"],
 	"properties": {
-		"name": {"type": "string", "minLength": 1},
-		"base_image": {"type": "string"},
-		"setup_commands": {"type": "array", "items": {"type": "string"}},
-		"environment": {"type": "object", "additionalProperties": {"type": "string"}},
-		"ports": {"type": "array", "items": {"type": "string"}},
-		"volumes": {"type": "array", "items": {"type": "string"}},
-		"dotfiles": {"type": "array", "items": {"type": "string"}},
-		"working_dir": {"type": "string"},
-		"shell": {"type": "string"},
-		"user": {"type": "string"},
-		"capabilities": {"type": "array", "items": {"type": "string"}},
-		"labels": {"type": "object", "additionalProperties": {"type": "string"}},
-		"network": {"type": "string"},
-		"restart": {"type": "string"},
+		"$schema": {"type": "string", "description": "JSON Schema used by editors to validate this file"},
+		"schema_version": {"type": "integer", "minimum": 1, "description": "devbox.json schema version the file was written for"},
+		"name": {"type": "string", "minLength": 1, "description": "Project name"},
+		"base_image": {"type": "string", "description": "Docker image the box is created from"},
+		"build": {
+			"type": "object",
+			"description": "Build the base image from a Dockerfile instead of pulling base_image",
+			"properties": {
+				"dockerfile": {"type": "string", "description": "Dockerfile path, relative to context (default Dockerfile)"},
+				"context": {"type": "string", "description": "Build context, relative to the workspace (default .)"},
+				"args": {"type": "object", "additionalProperties": {"type": "string"}, "description": "Build arguments passed as --build-arg"},
+				"target": {"type": "string", "description": "Stage of a multi-stage Dockerfile to build"}
+			},
+			"additionalProperties": false,
+			"examples": [{"dockerfile": ".devbox/Dockerfile", "args": {"GO_VERSION": "1.22"}, "target": "dev"}]
+		},
+		"setup_commands": {"type": "array", "items": {"type": "string"}, "description": "Shell commands run once when the box is created"},
+		"environment": {"type": "object", "additionalProperties": {"type": "string"}, "description": "Environment variables set in the box"},
+		"ports": {"type": "array", "items": {"type": "string"}, "description": "Port mappings (host:container or container[/proto])"},
+		"volumes": {"type": "array", "items": {"type": "string", "pattern": "^[^:]+:.+$", "examples": ["./data:/data", "~/.cache/pip:/root/.cache/pip", "pgdata:/var/lib/postgresql/data"]}, "description": "Extra mounts (host:container[:options]); host paths starting with ./ or ../ are relative to the workspace, ~ to your home directory, and bare names are named volumes"},
+		"dotfiles": {"type": "array", "items": {"type": "string"}, "description": "Host dotfile directories mounted at /dotfiles"},
+		"working_dir": {"type": "string", "description": "Working directory inside the box"},
+		"shell": {"type": "string", "description": "Shell used by devbox shell"},
+		"user": {"type": "string", "description": "User commands run as inside the box"},
+		"create_user": {"type": "boolean", "description": "Create a user in the box with your host UID/GID (named by user, default dev) and run shells and exec as it, so files in /workspace stay owned by you"},
+		"capabilities": {"type": "array", "items": {"type": "string"}, "description": "Linux capabilities added to the box"},
+		"labels": {"type": "object", "additionalProperties": {"type": "string"}, "description": "Docker labels added to the box"},
+		"network": {"type": "string", "description": "Docker network the box joins"},
+		"docker_host": {"type": "string", "minLength": 1, "description": "Docker daemon for this project: a DOCKER_HOST URL or a docker context name; remote daemons get a synced workspace volume instead of a bind mount", "examples": ["ssh://me@build-server", "tcp://10.0.0.5:2376", "build-server"]},
+		"workspace": {
+			"type": "object",
+			"description": "How the workspace reaches the box",
+			"properties": {
+				"mode": {"type": "string", "enum": ["bind", "sync"], "description": "bind mounts the workspace (default); sync keeps it in a volume that a background agent updates from this machine"},
+				"ignore": {"type": "array", "items": {"type": "string"}, "description": "Glob patterns for paths the sync agent skips, matched against each file name and its workspace-relative path", "examples": [["node_modules", "*.log", "build/*"]]},
+				"interval": {"type": "string", "description": "How often the sync agent checks for changes (default 1s, minimum 100ms)", "examples": ["500ms", "2s"]}
+			},
+			"additionalProperties": false,
+			"examples": [{"mode": "sync", "ignore": ["node_modules", ".venv"]}]
+		},
+		"backend": {"type": "string", "enum": ["docker", "kubernetes"], "description": "Where the box runs: docker (default) or kubernetes (experimental) as a pod in a kubeconfig cluster"},
+		"kubernetes": {
+			"type": "object",
+			"description": "Cluster settings for backend kubernetes (experimental)",
+			"properties": {
+				"context": {"type": "string", "description": "kubeconfig context; defaults to the current context"},
+				"namespace": {"type": "string", "description": "Namespace for the pod and its workspace claim; defaults to the context's namespace"},
+				"storage_class": {"type": "string", "description": "StorageClass of the workspace PersistentVolumeClaim; defaults to the cluster default"},
+				"storage": {"type": "string", "description": "Size of the workspace claim (default 10Gi)", "examples": ["10Gi", "50Gi"]}
+			},
+			"additionalProperties": false,
+			"examples": [{"context": "dev-cluster", "namespace": "dev-alice", "storage": "20Gi"}]
+		},
+		"ttl": {"type": "string", "description": "Stop the box this long after devbox up or init (enforced by devbox serve), e.g. 4h or 1d12h", "pattern": "^([0-9]+(d|h|m|s))+$", "examples": ["45m", "4h", "1d12h"]},
+		"timeouts": {
+			"type": "object",
+			"description": "How long devbox waits for this box; each defaults to settings.timeouts",
+			"properties": {
+				"startup": {"type": "string", "description": "Time to wait for the box to start running (default 30s)", "examples": ["90s", "3m"]},
+				"stop": {"type": "string", "description": "Grace period before a stopping box is killed (default 2s)", "examples": ["10s"]},
+				"setup": {"type": "string", "description": "Time limit for each batch of setup commands (default 10m)", "examples": ["30m", "1h"]}
+			},
+			"additionalProperties": false,
+			"examples": [{"startup": "2m", "setup": "30m"}]
+		},
+		"restart": {"type": "string", "description": "Docker restart policy; defaults to settings.default_restart", "pattern": "^(no|always|unless-stopped|on-failure(:[1-9][0-9]*)?)$", "examples": ["no", "unless-stopped", "always", "on-failure", "on-failure:3"]},
 		"health_check": {
 			"type": "object",
+			"description": "Docker health check for the box",
 			"properties": {
-				"test": {"type": "array", "items": {"type": "string"}},
-				"interval": {"type": "string"},
-				"timeout": {"type": "string"},
-				"start_period": {"type": "string"},
-				"retries": {"type": "integer", "minimum": 0}
+				"test": {"type": "array", "items": {"type": "string"}, "description": "Health check command: [\"CMD\", args...], [\"CMD-SHELL\", command], or [\"NONE\"] to disable the image's health check", "examples": [["CMD", "curl", "-f", "http://localhost"], ["CMD-SHELL", "pg_isready || exit 1"], ["NONE"]]},
+				"interval": {"type": "string", "description": "Time between checks, as a duration, e.g. 30s"},
+				"timeout": {"type": "string", "description": "Time before a check is considered hung, as a duration, e.g. 10s"},
+				"start_period": {"type": "string", "description": "Grace period after start during which failures don't count, as a duration, e.g. 5s"},
+				"retries": {"type": "integer", "minimum": 0, "description": "Consecutive failures before the box is unhealthy"}
 			},
 			"additionalProperties": false
 		},
 		"resources": {
 			"type": "object",
+			"description": "Resource limits for the box",
+			"properties": {
+				"cpus": {"type": "string", "description": "CPU limit as a positive number, e.g. 2 or 1.5"},
+				"memory": {"type": "string", "description": "Memory limit with a binary unit (b, k, m, g, or t; kb, mib, and gib also work), at least 6m, e.g. 2g or 512m"}
+			},
+			"additionalProperties": false
+		},
+		"git_guards": {
+			"type": "object",
+			"description": "Git settings applied inside the box to avoid line-ending and file-mode noise on mounted workspaces",
 			"properties": {
-				"cpus": {"type": "string"},
-				"memory": {"type": "string"}
+				"autocrlf": {"type": "string", "enum": ["input", "true", "false"], "description": "core.autocrlf for the box (input converts CRLF to LF on commit)"},
+				"file_mode": {"type": "boolean", "description": "core.fileMode for the box and the workspace repository; false ignores executable-bit changes"}
 			},
 			"additionalProperties": false
 		},
-		"gpus": {"type": "string"}
+		"ignore": {
+			"type": "object",
+			"description": "Drift that devbox verify skips and reports as ignored",
+			"properties": {
+				"packages": {"type": "object", "additionalProperties": {"type": "array", "items": {"type": "string"}}, "description": "Package name glob patterns per package manager (apt, pip, npm, yarn, pnpm, a plugin manager, or * for all)", "examples": [{"apt": ["gdb", "strace"], "pip": ["ipdb", "debugpy*"]}]},
+				"env": {"type": "array", "items": {"type": "string"}, "description": "Environment variable name glob patterns", "examples": [["DEBUG*", "HISTFILE"]]}
+			},
+			"additionalProperties": false
+		},
+		"services": {
+			"type": "object",
+			"description": "Extra containers (databases, caches, queues) started with the box on a per-project network; the box reaches each one by its service name",
+			"propertyNames": {"pattern": "^[a-z0-9][a-z0-9_-]*$"},
+			"additionalProperties": {
+				"type": "object",
+				"properties": {
+					"image": {"type": "string", "description": "Image the service runs with its default command", "examples": ["postgres:16", "redis:7"]},
+					"environment": {"type": "object", "additionalProperties": {"type": "string"}, "description": "Environment variables set in the service"},
+					"ports": {"type": "array", "items": {"type": "string"}, "description": "Port mappings published on the host (host:container)"},
+					"volumes": {"type": "array", "items": {"type": "string", "pattern": "^[^:]+:.+$"}, "description": "Mounts (host:container[:options]), resolved like the box's volumes"},
+					"depends_on": {"type": "array", "items": {"type": "string"}, "description": "Services started before this one"}
+				},
+				"required": ["image"],
+				"additionalProperties": false
+			},
+			"examples": [{"db": {"image": "postgres:16", "environment": {"POSTGRES_PASSWORD": "dev"}, "volumes": ["pgdata:/var/lib/postgresql/data"]}, "cache": {"image": "redis:7"}}]
+		},
+		"hooks": {
+			"type": "object",
+			"description": "Commands run around lifecycle events; a failing hook stops the command unless continue_on_error is set, and a failing pre_ hook skips the action",
+			"propertyNames": {"enum": ["pre_up", "post_up", "pre_shell", "post_shell", "pre_stop", "post_stop", "pre_destroy", "post_destroy"]},
+			"additionalProperties": {
+				"type": "array",
+				"items": {
+					"type": "object",
+					"properties": {
+						"run": {"type": "string", "minLength": 1, "description": "Shell command; host hooks run with sh -c in the workspace, box hooks with bash -c in working_dir"},
+						"in": {"type": "string", "enum": ["host", "box"], "description": "Where the command runs (default host); box is not allowed for pre_up, post_stop and post_destroy"},
+						"continue_on_error": {"type": "boolean", "description": "Warn instead of failing when the command exits non-zero"}
+					},
+					"required": ["run"],
+					"additionalProperties": false
+				}
+			},
+			"examples": [{"pre_up": [{"run": "ssh -fNL 5433:db.internal:5432 bastion"}], "post_up": [{"run": "make seed", "in": "box"}], "pre_destroy": [{"run": "rm -rf .cache", "in": "box", "continue_on_error": true}]}]
+		},
+		"secrets": {
+			"type": "object",
+			"description": "Secrets resolved on the host when the box is created and injected as environment variables or files; values never appear in devbox.json, the lockfile, or the docker command line",
+			"propertyNames": {"pattern": "^[A-Za-z_][A-Za-z0-9_]*$"},
+			"additionalProperties": {
+				"type": "object",
+				"properties": {
+					"from": {"type": "string", "pattern": "^(env|file|pass|op|store)://.+$", "description": "Where the value comes from: env://VAR, file://path, pass://entry, op://vault/item/field, or store://name; defaults to the value set with 'devbox secrets set' under the secret's name"},
+					"file": {"type": "string", "pattern": "^/", "description": "Write the value to this path in the box instead of setting an environment variable"}
+				},
+				"additionalProperties": false
+			},
+			"examples": [{"GITHUB_TOKEN": {"from": "env://GITHUB_TOKEN"}, "NPM_TOKEN": {}, "DB_PASSWORD": {"from": "op://dev/postgres/password"}, "SSH_DEPLOY_KEY": {"from": "pass://deploy/ssh", "file": "/home/dev/.ssh/deploy_key"}}]
+		},
+		"gpus": {"type": "string", "description": "NVIDIA GPUs passed to the box: all, a count, or device ids (device=0,1 or GPU-<uuid>); needs nvidia-container-toolkit on the Docker host", "examples": ["all", "1", "device=0,1"]},
+		"fs_manifest": {"type": "array", "items": {"type": "string"}, "description": "Paths recorded in the lockfile's filesystem manifest"},
+		"tmux": {
+			"type": "object",
+			"description": "Terminal layout for 'devbox shell --tmux': a tmux session with these windows, created on first attach and reused afterwards",
+			"properties": {
+				"session": {"type": "string", "pattern": "^[A-Za-z0-9_-]+$", "description": "tmux session name (default: the project name)"},
+				"windows": {
+					"type": "array",
+					"items": {
+						"type": "object",
+						"properties": {
+							"name": {"type": "string", "pattern": "^[A-Za-z0-9_-]+$", "description": "Window name"},
+							"dir": {"type": "string", "pattern": "^/", "description": "Starting directory in the box (default: working_dir)"},
+							"layout": {"type": "string", "enum": ["even-horizontal", "even-vertical", "main-horizontal", "main-vertical", "tiled"], "description": "tmux layout applied after the panes are split"},
+							"panes": {"type": "array", "items": {"type": "string"}, "description": "One command per pane, typed into a shell so the pane stays open when it exits; an empty string is a plain shell"}
+						},
+						"required": ["name"],
+						"additionalProperties": false
+					}
+				}
+			},
+			"additionalProperties": false,
+			"examples": [{"windows": [{"name": "editor", "panes": ["vim ."]}, {"name": "server", "layout": "even-horizontal", "panes": ["npm run dev", "npm test -- --watch"]}, {"name": "logs", "dir": "/workspace/log", "panes": ["tail -F development.log"]}]}]
+		}
 	},
 	"additionalProperties": false
 }`
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -240,3 +241,190 @@ func TestConfig_GetProject(t *testing.T) {
 		t.Error("Project should be nil for non-existing project")
 	}
 }
+
+func TestConfigManager_Profiles(t *testing.T) {
+	cm := &ConfigManager{configPath: filepath.Join(t.TempDir(), "config.json")}
+
+	if names := cm.ListProfiles(); len(names) != 0 {
+		t.Fatalf("expected no profiles, got %v", names)
+	}
+	if _, err := cm.LoadProfile("work"); err == nil {
+		t.Fatal("expected error loading a missing profile")
+	}
+
+	work := &Profile{
+		DefaultBaseImage: "registry.corp.example/ubuntu:22.04",
+		Proxy:            &ProxySettings{HTTP: "http://proxy.corp:3128", NoProxy: "localhost"},
+		Mirrors:          &MirrorSettings{Pip: "https://pypi.corp/simple"},
+		DefaultResources: &Resources{Memory: "4g"},
+	}
+	if err := cm.SaveProfile("work", work); err != nil {
+		t.Fatalf("SaveProfile() error = %v", err)
+	}
+	if err := cm.SaveProfile("home", &Profile{}); err != nil {
+		t.Fatalf("SaveProfile() error = %v", err)
+	}
+	if names := cm.ListProfiles(); len(names) != 2 || names[0] != "home" || names[1] != "work" {
+		t.Fatalf("ListProfiles() = %v", names)
+	}
+
+	loaded, err := cm.LoadProfile("work")
+	if err != nil {
+		t.Fatalf("LoadProfile() error = %v", err)
+	}
+	settings := &GlobalSettings{DefaultBaseImage: "ubuntu:22.04", AutoUpdate: true}
+	settings.ApplyProfile("work", loaded)
+	if settings.Profile != "work" || settings.DefaultBaseImage != work.DefaultBaseImage {
+		t.Errorf("ApplyProfile() did not switch image/profile: %+v", settings)
+	}
+	if settings.Proxy == nil || settings.Proxy.HTTP != "http://proxy.corp:3128" {
+		t.Errorf("ApplyProfile() proxy = %+v", settings.Proxy)
+	}
+	if !settings.AutoUpdate {
+		t.Error("ApplyProfile() should leave unrelated settings alone")
+	}
+
+	home, _ := cm.LoadProfile("home")
+	settings.ApplyProfile("home", home)
+	if settings.Proxy != nil || settings.Mirrors != nil || settings.DefaultResources != nil {
+		t.Errorf("switching to an empty profile should clear network settings: %+v", settings)
+	}
+	if settings.DefaultBaseImage != "ubuntu:22.04" {
+		t.Errorf("DefaultBaseImage = %q, want ubuntu:22.04", settings.DefaultBaseImage)
+	}
+}
+
+func TestConfigManager_LoadPlugins(t *testing.T) {
+	cm := &ConfigManager{configPath: filepath.Join(t.TempDir(), "config.json")}
+
+	if manifests, err := cm.LoadPlugins(); err != nil || len(manifests) != 0 {
+		t.Fatalf("LoadPlugins() without directory = %v, %v", manifests, err)
+	}
+	if err := os.MkdirAll(cm.PluginsDir(), 0755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(cm.PluginsDir(), name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("rust.json", `{"package_managers": [{"name": "cargo", "list": "cargo install --list", "install": "cargo install {name} --version {version}"}]}`)
+	write("notes.txt", "ignored")
+
+	manifests, err := cm.LoadPlugins()
+	if err != nil {
+		t.Fatalf("LoadPlugins() error = %v", err)
+	}
+	if len(manifests) != 1 || manifests[0].Name != "rust" {
+		t.Fatalf("LoadPlugins() = %+v", manifests)
+	}
+	managers := PluginPackageManagers(manifests)
+	if len(managers) != 1 || managers[0].Separator != "@" {
+		t.Errorf("PluginPackageManagers() = %+v, want cargo with default separator", managers)
+	}
+
+	invalid := []struct {
+		name    string
+		content string
+	}{
+		{"builtin", `{"package_managers": [{"name": "pip", "list": "pip list"}]}`},
+		{"no list", `{"package_managers": [{"name": "gem"}]}`},
+		{"separator", `{"package_managers": [{"name": "gem", "list": "gem list", "separator": ":"}]}`},
+		{"duplicate", `{"package_managers": [{"name": "cargo", "list": "cargo install --list"}]}`},
+		{"malformed", `{"package_managers": [`},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			write("zz.json", tt.content)
+			defer os.Remove(filepath.Join(cm.PluginsDir(), "zz.json"))
+			if _, err := cm.LoadPlugins(); err == nil {
+				t.Error("expected LoadPlugins() to fail")
+			}
+		})
+	}
+}
+
+func TestWithBundledPackageManagers(t *testing.T) {
+	if err := ValidatePluginManifest(&PluginManifest{PackageManagers: BundledPackageManagers}); err != nil {
+		t.Fatalf("bundled package managers are invalid: %v", err)
+	}
+	for _, pm := range BundledPackageManagers {
+		if pm.Install == "" || pm.Remove == "" {
+			t.Errorf("bundled package manager %s has no install or remove command", pm.Name)
+		}
+	}
+
+	custom := PluginPackageManager{Name: "gem", List: "gem list", Separator: "="}
+	brew := PluginPackageManager{Name: "brew", List: "brew list --versions"}
+	managers := WithBundledPackageManagers([]PluginPackageManager{custom, brew})
+	var names []string
+	for _, pm := range managers {
+		names = append(names, pm.Name)
+		if pm.Name == "gem" && !reflect.DeepEqual(pm, custom) {
+			t.Errorf("plugin gem = %+v, want it to replace the bundled one", pm)
+		}
+	}
+	want := []string{"brew", "cargo", "composer", "conda", "gem", "go"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("WithBundledPackageManagers() = %v, want %v", names, want)
+	}
+}
+
+func TestConfigManager_LoadProjectConfigCompatibility(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want []string
+	}{
+		{"known fields", `{"name": "demo", "schema_version": 1, "resources": {"cpus": "2"}}`, nil},
+		{"unknown top-level", `{"name": "demo", "image": "ubuntu", "sandbox": true}`, []string{
+			`unknown field "image" (ignored; did you mean "base_image"?)`,
+			`unknown field "sandbox" (ignored)`,
+		}},
+		{"unknown nested", `{"name": "demo", "health_check": {"test": ["CMD", "true"], "jitter": "1s"}}`, []string{
+			`unknown field "health_check.jitter" (ignored)`,
+		}},
+		{"newer schema", `{"name": "demo", "schema_version": 99}`, []string{
+			"schema_version 99 is newer than this devbox supports (1); fields it does not know are ignored, upgrade devbox for full support",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "devbox.json"), []byte(tt.json), 0644); err != nil {
+				t.Fatal(err)
+			}
+			cm := &ConfigManager{}
+			cfg, err := cm.LoadProjectConfig(dir)
+			if err != nil {
+				t.Fatalf("LoadProjectConfig() error = %v", err)
+			}
+			got := cfg.CompatibilityWarnings()
+			if len(got) != len(tt.want) {
+				t.Fatalf("warnings = %q, want %q", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("warning[%d] = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestConfigManager_Exists(t *testing.T) {
+	cm := &ConfigManager{configPath: filepath.Join(t.TempDir(), "config.json")}
+	if cm.Exists() {
+		t.Fatal("Exists() = true before the first save")
+	}
+	if err := cm.Save(&Config{Settings: &GlobalSettings{WorkspaceRoot: "/srv/devbox"}}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if !cm.Exists() {
+		t.Fatal("Exists() = false after save")
+	}
+	cfg, err := cm.Load()
+	if err != nil || cfg.Settings.WorkspaceRoot != "/srv/devbox" {
+		t.Fatalf("Load() = %+v, %v", cfg.Settings, err)
+	}
+}
@@ -2,8 +2,13 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
+	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewConfigManager(t *testing.T) {
@@ -296,3 +301,831 @@ func containsAtIndex(s, substr string) bool {
 	}
 	return false
 }
+
+func TestProjectConfigSchemaReference(t *testing.T) {
+	cm := &ConfigManager{}
+	cfg := cm.GetDefaultProjectConfig("demo")
+	if cfg.Schema != ProjectConfigSchemaURL {
+		t.Errorf("default config $schema = %q, want %q", cfg.Schema, ProjectConfigSchemaURL)
+	}
+	if err := cm.ValidateProjectConfig(cfg); err != nil {
+		t.Errorf("config with $schema should validate: %v", err)
+	}
+
+	var parsed ProjectConfig
+	if err := json.Unmarshal([]byte(`{"$schema": "./devbox.schema.json", "name": "demo"}`), &parsed); err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Schema != "./devbox.schema.json" {
+		t.Errorf("Schema = %q", parsed.Schema)
+	}
+}
+
+func TestPublishedSchemaMatches(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "..", "docs", "public", "devbox.schema.json"))
+	if err != nil {
+		t.Fatalf("failed to read published schema: %v", err)
+	}
+	if strings.TrimSpace(string(data)) != ProjectConfigJSONSchema {
+		t.Error("docs/public/devbox.schema.json is out of date; regenerate it with 'devbox config schema docs/public/devbox.schema.json --force'")
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("published schema is not valid JSON: %v", err)
+	}
+	if schema["$id"] != ProjectConfigSchemaURL {
+		t.Errorf("$id = %v, want %s", schema["$id"], ProjectConfigSchemaURL)
+	}
+}
+
+func TestValidateRestartPolicy(t *testing.T) {
+	tests := []struct {
+		policy  string
+		wantErr bool
+	}{
+		{"", false},
+		{"no", false},
+		{"always", false},
+		{"unless-stopped", false},
+		{"on-failure", false},
+		{"on-failure:3", false},
+		{"on-failure:0", true},
+		{"always:2", true},
+		{"sometimes", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			if err := ValidateRestartPolicy(tt.policy); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateRestartPolicy(%q) error = %v, wantErr %v", tt.policy, err, tt.wantErr)
+			}
+		})
+	}
+
+	cm := &ConfigManager{}
+	pc := cm.GetDefaultProjectConfig("demo")
+	pc.Restart = "sometimes"
+	if err := cm.ValidateProjectConfig(pc); err == nil {
+		t.Error("schema should reject an unknown restart policy")
+	}
+}
+
+func TestGlobalSettingsRestartPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings *GlobalSettings
+		want     string
+	}{
+		{"nil settings", nil, "unless-stopped"},
+		{"auto stop", &GlobalSettings{AutoStopOnExit: true}, "no"},
+		{"keep running", &GlobalSettings{}, "unless-stopped"},
+		{"explicit default wins", &GlobalSettings{AutoStopOnExit: true, DefaultRestart: "on-failure"}, "on-failure"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.settings.RestartPolicy(); got != tt.want {
+				t.Errorf("RestartPolicy() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateProjectConfigVolumes(t *testing.T) {
+	tests := []struct {
+		volume  string
+		wantErr bool
+	}{
+		{"./data:/data", false},
+		{"../shared:/shared", false},
+		{"~/.cache:/root/.cache", false},
+		{"/srv/data:/data", false},
+		{"pgdata:/var/lib/postgresql/data", false},
+		{"data/cache:/cache", true},
+		{"/data", true},
+	}
+	cm := &ConfigManager{}
+	for _, tt := range tests {
+		t.Run(tt.volume, func(t *testing.T) {
+			pc := cm.GetDefaultProjectConfig("demo")
+			pc.Volumes = []string{tt.volume}
+			if err := cm.ValidateProjectConfig(pc); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateProjectConfig(%q) error = %v, wantErr %v", tt.volume, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateWebhook(t *testing.T) {
+	tests := []struct {
+		name    string
+		hook    Webhook
+		wantErr bool
+	}{
+		{"all events", Webhook{URL: "https://hooks.example.com/T000"}, false},
+		{"known events", Webhook{URL: "http://bot.internal:8080/devbox", Events: []string{WebhookUpFinished, WebhookDriftDetected}}, false},
+		{"wildcard", Webhook{URL: "https://hooks.example.com", Events: []string{"*"}}, false},
+		{"template", Webhook{URL: "https://hooks.example.com", Template: `{"text": {{json .Message}}}`}, false},
+		{"unknown event", Webhook{URL: "https://hooks.example.com", Events: []string{"down.finished"}}, true},
+		{"not http", Webhook{URL: "ftp://hooks.example.com"}, true},
+		{"no host", Webhook{URL: "https://"}, true},
+		{"bad template", Webhook{URL: "https://hooks.example.com", Template: "{{.Message"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateWebhook(tt.hook); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateWebhook() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	hook := Webhook{Events: []string{WebhookUpdateFailed}}
+	if hook.Wants(WebhookUpFinished) || !hook.Wants(WebhookUpdateFailed) {
+		t.Error("Wants() should only match subscribed events")
+	}
+	if !(Webhook{}).Wants(WebhookDriftDetected) {
+		t.Error("a webhook without events should receive every event")
+	}
+}
+
+func TestLintProjectConfig(t *testing.T) {
+	limits := &Resources{Memory: "2g"}
+	tests := []struct {
+		name string
+		pc   ProjectConfig
+		want []string
+	}{
+		{"clean", ProjectConfig{
+			BaseImage:     "ubuntu:22.04",
+			SetupCommands: []string{"apt update && apt install -y curl", "pip install requests==2.31.0 -r requirements.txt", "npm i -g typescript@5.4.5", "npm install"},
+			Environment:   map[string]string{"TOKENIZERS_PARALLELISM": "false", "API_TOKEN": "${API_TOKEN}"},
+			Capabilities:  []string{"NET_ADMIN"},
+			Resources:     limits,
+		}, nil},
+		{"apt upgrade", ProjectConfig{SetupCommands: []string{"apt-get update && apt-get -y dist-upgrade"}, Resources: limits}, []string{LintAptUpgrade}},
+		{"unpinned pip", ProjectConfig{SetupCommands: []string{"python3 -m pip install --upgrade 'flask>=2' black==24.1.0"}, Resources: limits}, []string{LintUnpinnedPip}},
+		{"unpinned npm", ProjectConfig{SetupCommands: []string{"npm install -g @angular/cli@^17 pnpm@9.1.0 && yarn global add serve"}, Resources: limits}, []string{LintUnpinnedNpm, LintUnpinnedNpm}},
+		{"latest image", ProjectConfig{BaseImage: "python", Resources: limits}, []string{LintLatestTag}},
+		{"digest image", ProjectConfig{BaseImage: "registry.local:5000/python@sha256:abc", Resources: limits}, nil},
+		{"secret env", ProjectConfig{Environment: map[string]string{"DB_PASSWORD": "hunter2", "GH": "ghp_abcdefghijklmnop"}, Resources: limits}, []string{LintSecretEnv, LintSecretEnv}},
+		{"broad caps", ProjectConfig{Capabilities: []string{"CAP_SYS_ADMIN", "all"}, Resources: limits}, []string{LintBroadCapabilities, LintBroadCapabilities}},
+		{"no limits", ProjectConfig{}, []string{LintResourceLimits}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, f := range LintProjectConfig(&tt.pc, nil) {
+				got = append(got, f.Rule)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LintProjectConfig() rules = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if findings := LintProjectConfig(&ProjectConfig{}, &GlobalSettings{DefaultResources: &Resources{CPUs: "2"}}); len(findings) != 0 {
+		t.Errorf("global default_resources should satisfy %s, got %v", LintResourceLimits, findings)
+	}
+}
+
+func TestFixProjectConfig(t *testing.T) {
+	pc := &ProjectConfig{
+		SetupCommands: []string{
+			"apt-get upgrade -y",
+			"apt update && apt upgrade -y && apt install -y git",
+			"apt upgrade -y || true",
+			"pip install requests",
+		},
+	}
+	findings := LintProjectConfig(pc, nil)
+	if fixed := FixProjectConfig(pc, findings); fixed != 2 {
+		t.Errorf("FixProjectConfig() fixed %d, want 2", fixed)
+	}
+	want := []string{"apt update && apt install -y git", "apt upgrade -y || true", "pip install requests"}
+	if !reflect.DeepEqual(pc.SetupCommands, want) {
+		t.Errorf("SetupCommands = %q, want %q", pc.SetupCommands, want)
+	}
+}
+
+func TestIgnoreRules(t *testing.T) {
+	rules := MergeIgnoreRules(
+		&IgnoreRules{Packages: map[string][]string{"apt": {"gdb", "strace*"}}},
+		nil,
+		&IgnoreRules{Packages: map[string][]string{"*": {"debugpy"}}, Env: []string{"DEBUG*"}},
+	)
+	tests := []struct {
+		manager, name string
+		want          bool
+	}{
+		{"apt", "gdb", true},
+		{"apt", "strace-dbgsym", true},
+		{"apt", "curl", false},
+		{"pip", "gdb", false},
+		{"pip", "DebugPy", true},
+		{"cargo", "debugpy", true},
+	}
+	for _, tt := range tests {
+		if got := rules.IgnoresPackage(tt.manager, tt.name); got != tt.want {
+			t.Errorf("IgnoresPackage(%q, %q) = %v, want %v", tt.manager, tt.name, got, tt.want)
+		}
+	}
+	if !rules.IgnoresEnv("DEBUG_LEVEL") || rules.IgnoresEnv("PATH") {
+		t.Errorf("IgnoresEnv() did not match DEBUG* as expected")
+	}
+	if !(*IgnoreRules)(nil).Empty() || rules.Empty() {
+		t.Errorf("Empty() mismatch")
+	}
+
+	if err := ValidateIgnoreRules(rules); err != nil {
+		t.Errorf("ValidateIgnoreRules() = %v", err)
+	}
+	if err := ValidateIgnoreRules(&IgnoreRules{Packages: map[string][]string{"pip": {"[bad"}}}); err == nil {
+		t.Errorf("ValidateIgnoreRules() accepted a malformed pattern")
+	}
+	if err := ValidateIgnoreRules(&IgnoreRules{Env: []string{" "}}); err == nil {
+		t.Errorf("ValidateIgnoreRules() accepted an empty pattern")
+	}
+}
+
+func TestNormalizeImageRef(t *testing.T) {
+	tests := map[string]string{
+		"ubuntu":                        "docker.io/library/ubuntu",
+		"ubuntu:22.04":                  "docker.io/library/ubuntu:22.04",
+		"acme/tool:1":                   "docker.io/acme/tool:1",
+		"ghcr.io/acme/base:2024":        "ghcr.io/acme/base:2024",
+		"localhost/dev:latest":          "localhost/dev:latest",
+		"registry:5000/team/img":        "registry:5000/team/img",
+		"ubuntu:22.04@sha256:abc":       "docker.io/library/ubuntu:22.04@sha256:abc",
+		"docker.io/library/debian:12.5": "docker.io/library/debian:12.5",
+	}
+	for in, want := range tests {
+		if got := NormalizeImageRef(in); got != want {
+			t.Errorf("NormalizeImageRef(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestImagePolicyCheck(t *testing.T) {
+	policy := &ImagePolicy{
+		Allow: []string{"ghcr.io/acme/*", "ubuntu:22.04", "debian"},
+		Deny:  []string{"ghcr.io/acme/experimental/*"},
+	}
+	tests := []struct {
+		image   string
+		allowed bool
+	}{
+		{"ghcr.io/acme/base:1.0", true},
+		{"ghcr.io/acme/team/python:3.12", true},
+		{"ghcr.io/acme/experimental/rust:nightly", false},
+		{"ubuntu:22.04", true},
+		{"docker.io/library/ubuntu:22.04@sha256:abc", true},
+		{"ubuntu:24.04", false},
+		{"debian:12", true},
+		{"randomuser/debian:12", false},
+		{"ghcr.io/other/base", false},
+	}
+	for _, tt := range tests {
+		err := policy.Check(tt.image, "test")
+		if tt.allowed && err != nil {
+			t.Errorf("Check(%q) = %v, want allowed", tt.image, err)
+		}
+		if !tt.allowed {
+			var perr *ImagePolicyError
+			if !errors.As(err, &perr) {
+				t.Errorf("Check(%q) = %v, want *ImagePolicyError", tt.image, err)
+			}
+		}
+	}
+
+	denyOnly := &ImagePolicy{Deny: []string{"docker.io/*"}}
+	if err := denyOnly.Check("ubuntu:22.04", "test"); err == nil || !strings.Contains(err.Error(), "denied by pattern 'docker.io/*'") {
+		t.Errorf("deny-only policy should block Docker Hub images, got %v", err)
+	}
+	if err := denyOnly.Check("quay.io/acme/app", "test"); err != nil {
+		t.Errorf("deny-only policy should allow other registries, got %v", err)
+	}
+	if err := (&ImagePolicy{Allow: []string{"*"}}).Check("anything/at:all", "test"); err != nil {
+		t.Errorf("'*' should allow everything, got %v", err)
+	}
+	if err := ValidateImagePolicy(&ImagePolicy{Deny: []string{" "}}); err == nil {
+		t.Error("ValidateImagePolicy() should reject empty patterns")
+	}
+}
+
+func TestParseTTL(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "45m", want: 45 * time.Minute},
+		{in: "4h", want: 4 * time.Hour},
+		{in: "1d12h", want: 36 * time.Hour},
+		{in: "2d", want: 48 * time.Hour},
+		{in: "1h30m", want: 90 * time.Minute},
+		{in: "30s", wantErr: true},
+		{in: "12h1d", wantErr: true},
+		{in: "4 hours", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTTL(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseTTL(%q) = %s, %v; want %s, err=%t", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateProjectConfigServices(t *testing.T) {
+	tests := []struct {
+		name     string
+		services map[string]*Service
+		wantErr  string
+	}{
+		{name: "valid", services: map[string]*Service{
+			"db":    {Image: "postgres:16", Ports: []string{"5432:5432"}, Volumes: []string{"pgdata:/var/lib/postgresql/data"}},
+			"cache": {Image: "redis:7", DependsOn: []string{"db"}},
+		}},
+		{name: "missing image", services: map[string]*Service{"db": {}}, wantErr: "image is required"},
+		{name: "bad name", services: map[string]*Service{"My DB": {Image: "postgres:16"}}, wantErr: "My DB"},
+		{name: "unknown dependency", services: map[string]*Service{"app": {Image: "x", DependsOn: []string{"db"}}}, wantErr: "unknown service 'db'"},
+		{name: "self dependency", services: map[string]*Service{"app": {Image: "x", DependsOn: []string{"app"}}}, wantErr: "itself"},
+		{name: "cycle", services: map[string]*Service{
+			"a": {Image: "x", DependsOn: []string{"b"}},
+			"b": {Image: "x", DependsOn: []string{"a"}},
+		}, wantErr: "cycle"},
+		{name: "bad volume", services: map[string]*Service{"db": {Image: "x", Volumes: []string{"data/pg:/data"}}}, wantErr: "services.db"},
+	}
+	cm := &ConfigManager{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pc := cm.GetDefaultProjectConfig("demo")
+			pc.Services = tt.services
+			err := cm.ValidateProjectConfig(pc)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateProjectConfig() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateProjectConfig() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestServiceStartOrder(t *testing.T) {
+	services := map[string]*Service{
+		"worker": {Image: "x", DependsOn: []string{"queue", "db"}},
+		"queue":  {Image: "x"},
+		"db":     {Image: "x"},
+		"api":    {Image: "x", DependsOn: []string{"db"}},
+	}
+	got, err := ServiceStartOrder(services)
+	if err != nil {
+		t.Fatalf("ServiceStartOrder() error = %v", err)
+	}
+	want := []string{"db", "queue", "api", "worker"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ServiceStartOrder() = %v, want %v", got, want)
+	}
+}
+
+func TestDockerfileBaseImages(t *testing.T) {
+	dockerfile := `# syntax=docker/dockerfile:1
+ARG GO_VERSION=1.21
+ARG DISTRO="bookworm"
+FROM --platform=$BUILDPLATFORM golang:${GO_VERSION}-${DISTRO} AS build
+RUN go build ./...
+FROM build AS test
+ARG GO_VERSION=ignored
+from debian:${DISTRO}-slim as dev
+COPY --from=build /out /usr/local/bin
+FROM scratch
+FROM debian:bookworm-slim
+`
+	got := DockerfileBaseImages(dockerfile, map[string]string{"GO_VERSION": "1.22"})
+	want := []string{"golang:1.22-bookworm", "debian:bookworm-slim"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DockerfileBaseImages() = %v, want %v", got, want)
+	}
+}
+
+func TestBuildPaths(t *testing.T) {
+	tests := []struct {
+		build                   Build
+		wantContext, wantDocker string
+	}{
+		{Build{}, "/ws", "/ws/Dockerfile"},
+		{Build{Context: "docker", Dockerfile: "dev.Dockerfile"}, "/ws/docker", "/ws/docker/dev.Dockerfile"},
+		{Build{Context: "..", Dockerfile: "/srv/Dockerfile"}, "/", "/srv/Dockerfile"},
+	}
+	for _, tt := range tests {
+		ctx, df := tt.build.Paths("/ws")
+		if ctx != tt.wantContext || df != tt.wantDocker {
+			t.Errorf("Paths(%+v) = %s, %s; want %s, %s", tt.build, ctx, df, tt.wantContext, tt.wantDocker)
+		}
+	}
+}
+
+func TestBuildConfig(t *testing.T) {
+	cm := &ConfigManager{}
+	pc := cm.GetDefaultProjectConfig("web")
+	pc.Build = &Build{Dockerfile: ".devbox/Dockerfile", Args: map[string]string{"V": "1"}}
+	if err := cm.ValidateProjectConfig(pc); err == nil || !strings.Contains(err.Error(), "either base_image or build") {
+		t.Errorf("base_image with build should be rejected, got %v", err)
+	}
+	pc.BaseImage = ""
+	if err := cm.ValidateProjectConfig(pc); err != nil {
+		t.Errorf("ValidateProjectConfig() error = %v", err)
+	}
+	cfg := &Config{Settings: &GlobalSettings{DefaultBaseImage: "debian:12"}}
+	if got := cfg.GetEffectiveBaseImage(&Project{Name: "web", BaseImage: "ubuntu:22.04"}, pc); got != "devbox/web:build" {
+		t.Errorf("GetEffectiveBaseImage() = %s, want devbox/web:build", got)
+	}
+}
+
+func TestValidateEngine(t *testing.T) {
+	tests := []struct {
+		engine  string
+		wantErr bool
+	}{
+		{"", false},
+		{"docker", false},
+		{"podman", false},
+		{"nerdctl", false},
+		{"/usr/local/bin/podman", false},
+		{"lxc", true},
+		{"Docker", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.engine, func(t *testing.T) {
+			if err := ValidateEngine(tt.engine); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateEngine(%q) error = %v, wantErr %v", tt.engine, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateWorkspace(t *testing.T) {
+	tests := []struct {
+		name      string
+		workspace *Workspace
+		wantErr   bool
+	}{
+		{"nil", nil, false},
+		{"bind", &Workspace{Mode: "bind"}, false},
+		{"sync", &Workspace{Mode: "sync", Ignore: []string{"node_modules", "*.log"}, Interval: "500ms"}, false},
+		{"unknown mode", &Workspace{Mode: "nfs"}, true},
+		{"bad pattern", &Workspace{Mode: "sync", Ignore: []string{"[a"}}, true},
+		{"blank pattern", &Workspace{Mode: "sync", Ignore: []string{" "}}, true},
+		{"bad interval", &Workspace{Mode: "sync", Interval: "soon"}, true},
+		{"short interval", &Workspace{Mode: "sync", Interval: "10ms"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateWorkspace(tt.workspace); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateWorkspace() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+	if got := (&Workspace{Mode: "sync", Interval: "2s"}).SyncInterval(); got != 2*time.Second {
+		t.Errorf("SyncInterval() = %s, want 2s", got)
+	}
+	if got := (*Workspace)(nil).SyncInterval(); got != DefaultSyncInterval {
+		t.Errorf("SyncInterval() of nil = %s, want %s", got, DefaultSyncInterval)
+	}
+}
+
+func TestValidateGpus(t *testing.T) {
+	tests := []struct {
+		value   string
+		wantErr bool
+	}{
+		{"", false},
+		{"all", false},
+		{"2", false},
+		{"device=0", false},
+		{"0,1", false},
+		{"device=GPU-3a2b7c1d-0e9f-4a1b-8c2d-1234567890ab", false},
+		{"MIG-GPU-3a2b/1/0", false},
+		{"0", true},
+		{"-1", true},
+		{"device=", true},
+		{"nvidia", true},
+		{"device=0;rm", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if err := ValidateGpus(tt.value); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateGpus(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateBackend(t *testing.T) {
+	tests := []struct {
+		name    string
+		pc      ProjectConfig
+		wantErr bool
+	}{
+		{"default", ProjectConfig{}, false},
+		{"docker", ProjectConfig{Backend: BackendDocker}, false},
+		{"kubernetes", ProjectConfig{Backend: BackendKubernetes, Kubernetes: &Kubernetes{Namespace: "dev", Storage: "20Gi"}}, false},
+		{"unknown backend", ProjectConfig{Backend: "nomad"}, true},
+		{"kubernetes settings without backend", ProjectConfig{Kubernetes: &Kubernetes{Namespace: "dev"}}, true},
+		{"kubernetes with build", ProjectConfig{Backend: BackendKubernetes, Build: &Build{Dockerfile: "Dockerfile"}}, true},
+		{"kubernetes with services", ProjectConfig{Backend: BackendKubernetes, Services: map[string]*Service{"db": {Image: "postgres:16"}}}, true},
+		{"kubernetes with secrets", ProjectConfig{Backend: BackendKubernetes, Secrets: map[string]Secret{"NPM_TOKEN": {}}}, true},
+		{"kubernetes with sync workspace", ProjectConfig{Backend: BackendKubernetes, Workspace: &Workspace{Mode: WorkspaceModeSync}}, true},
+		{"kubernetes with create_user", ProjectConfig{Backend: BackendKubernetes, CreateUser: true}, true},
+		{"bad storage", ProjectConfig{Backend: BackendKubernetes, Kubernetes: &Kubernetes{Storage: "20GB"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateBackend(&tt.pc); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateBackend() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateCreateUser(t *testing.T) {
+	tests := []struct {
+		name    string
+		pc      ProjectConfig
+		wantErr bool
+	}{
+		{"off", ProjectConfig{User: "1000:1000"}, false},
+		{"default name", ProjectConfig{CreateUser: true}, false},
+		{"named", ProjectConfig{CreateUser: true, User: "alice"}, false},
+		{"root", ProjectConfig{CreateUser: true, User: "root"}, true},
+		{"uid", ProjectConfig{CreateUser: true, User: "1000"}, true},
+		{"uid and gid", ProjectConfig{CreateUser: true, User: "1000:1000"}, true},
+		{"uppercase", ProjectConfig{CreateUser: true, User: "Alice"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateCreateUser(&tt.pc); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCreateUser() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateTmux(t *testing.T) {
+	tests := []struct {
+		name    string
+		tmux    *Tmux
+		wantErr bool
+	}{
+		{"nil", nil, false},
+		{"session only", &Tmux{Session: "web-dev"}, false},
+		{"windows", &Tmux{Windows: []TmuxWindow{{Name: "editor", Panes: []string{"vim ."}}, {Name: "server", Dir: "/workspace/api", Layout: "tiled", Panes: []string{"make run", ""}}}}, false},
+		{"bad session", &Tmux{Session: "web:dev"}, true},
+		{"missing name", &Tmux{Windows: []TmuxWindow{{Panes: []string{"vim"}}}}, true},
+		{"dotted name", &Tmux{Windows: []TmuxWindow{{Name: "logs.1"}}}, true},
+		{"duplicate name", &Tmux{Windows: []TmuxWindow{{Name: "logs"}, {Name: "logs"}}}, true},
+		{"relative dir", &Tmux{Windows: []TmuxWindow{{Name: "logs", Dir: "log"}}}, true},
+		{"unknown layout", &Tmux{Windows: []TmuxWindow{{Name: "logs", Layout: "grid"}}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateTmux(tt.tmux); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateTmux() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func FuzzParseProjectConfig(f *testing.F) {
+	f.Add([]byte(`{"base_image":"ubuntu:22.04","setup_commands":["apt-get update"],"environment":{"TZ":"UTC"}}`))
+	f.Add([]byte(`{"dotfiles":["~/dotfiles","/opt/dotfiles"],"gpus":"device=0,1","restart":"on-failure"}`))
+	f.Add([]byte(`{"health_check":{"test":["CMD","true"],"interval":"30s","start_period":"10s"},"resources":{"cpus":"2","memory":"4g"}}`))
+	f.Add([]byte(`{"backend":"kubernetes","kubernetes":{"namespace":"dev"},"workspace":{"mode":"sync"}}`))
+	f.Add([]byte(`{"schema_version":"0.1","volumes":["data:/data"],"ports":["8080:80"]}`))
+	f.Add([]byte(`null`))
+	cm := &ConfigManager{}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		pc, err := ParseProjectConfig(data)
+		if err != nil {
+			return
+		}
+		first, err := json.Marshal(pc)
+		if err != nil {
+			t.Fatalf("failed to re-encode parsed config: %v", err)
+		}
+		again, err := ParseProjectConfig(first)
+		if err != nil {
+			t.Fatalf("re-encoded config %s does not parse: %v", first, err)
+		}
+		second, err := json.Marshal(again)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(first) != string(second) {
+			t.Errorf("config is not stable across a round trip:\n%s\n%s", first, second)
+		}
+		_ = cm.ValidateProjectConfig(pc)
+		_ = ValidateBackend(pc)
+	})
+}
+
+func TestValidateTimeouts(t *testing.T) {
+	tests := []struct {
+		name     string
+		timeouts *Timeouts
+		wantErr  bool
+	}{
+		{"nil", nil, false},
+		{"empty", &Timeouts{}, false},
+		{"all", &Timeouts{Startup: "90s", Stop: "10s", Setup: "1h30m"}, false},
+		{"unitless", &Timeouts{Startup: "90"}, true},
+		{"zero", &Timeouts{Stop: "0s"}, true},
+		{"negative", &Timeouts{Setup: "-5m"}, true},
+		{"days", &Timeouts{Setup: "1d"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateTimeouts(tt.timeouts); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateTimeouts(%+v) error = %v, wantErr %v", tt.timeouts, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResolveTimeouts(t *testing.T) {
+	defaults := BoxTimeouts{Startup: DefaultStartupTimeout, Stop: DefaultStopTimeout, Setup: DefaultSetupTimeout}
+	if got := ResolveTimeouts(nil, nil); got != defaults {
+		t.Errorf("ResolveTimeouts(nil, nil) = %+v, want %+v", got, defaults)
+	}
+
+	settings := &GlobalSettings{Timeouts: &Timeouts{Startup: "2m", Stop: "5s"}}
+	project := &ProjectConfig{Timeouts: &Timeouts{Startup: "5m", Setup: "bogus"}}
+	want := BoxTimeouts{Startup: 5 * time.Minute, Stop: 5 * time.Second, Setup: DefaultSetupTimeout}
+	if got := ResolveTimeouts(settings, project); got != want {
+		t.Errorf("ResolveTimeouts() = %+v, want %+v", got, want)
+	}
+}
+
+func TestValidateHooks(t *testing.T) {
+	tests := []struct {
+		name    string
+		hooks   map[string][]Hook
+		wantErr bool
+	}{
+		{"nil", nil, false},
+		{"host and box", map[string][]Hook{HookPreUp: {{Run: "make tunnel"}}, HookPostUp: {{Run: "make seed", In: HookInBox}}}, false},
+		{"explicit host", map[string][]Hook{HookPostDestroy: {{Run: "rm -rf .cache", In: HookInHost}}}, false},
+		{"unknown event", map[string][]Hook{"post_build": {{Run: "true"}}}, true},
+		{"empty run", map[string][]Hook{HookPreShell: {{Run: "  "}}}, true},
+		{"bad target", map[string][]Hook{HookPreShell: {{Run: "true", In: "vm"}}}, true},
+		{"box before up", map[string][]Hook{HookPreUp: {{Run: "true", In: HookInBox}}}, true},
+		{"box after stop", map[string][]Hook{HookPostStop: {{Run: "true", In: HookInBox}}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateHooks(tt.hooks); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateHooks(%+v) error = %v, wantErr %v", tt.hooks, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateSecrets(t *testing.T) {
+	tests := []struct {
+		name    string
+		secrets map[string]Secret
+		env     map[string]string
+		wantErr bool
+	}{
+		{"nil", nil, nil, false},
+		{"store default", map[string]Secret{"NPM_TOKEN": {}}, nil, false},
+		{"sources", map[string]Secret{"A": {From: "env://GH_TOKEN"}, "B": {From: "file://~/.pgpass"}, "C": {From: "pass://dev/db"}, "D": {From: "op://dev/db/password"}, "E": {From: "store://shared"}}, nil, false},
+		{"file target", map[string]Secret{"KEY": {From: "pass://deploy", File: "/run/secrets/key"}}, map[string]string{"KEY": "x"}, false},
+		{"bad name", map[string]Secret{"1TOKEN": {}}, nil, true},
+		{"unknown scheme", map[string]Secret{"A": {From: "vault://kv/a"}}, nil, true},
+		{"no scheme", map[string]Secret{"A": {From: "GH_TOKEN"}}, nil, true},
+		{"empty ref", map[string]Secret{"A": {From: "env://"}}, nil, true},
+		{"bad env name", map[string]Secret{"A": {From: "env://GH-TOKEN"}}, nil, true},
+		{"relative file", map[string]Secret{"A": {File: "secrets/a"}}, nil, true},
+		{"directory file", map[string]Secret{"A": {File: "/run/secrets/"}}, nil, true},
+		{"also in environment", map[string]Secret{"A": {}}, map[string]string{"A": "x"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateSecrets(tt.secrets, tt.env); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateSecrets(%+v) error = %v, wantErr %v", tt.secrets, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateHealthCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		hc      *HealthCheck
+		wantErr bool
+	}{
+		{"nil", nil, false},
+		{"cmd", &HealthCheck{Test: []string{"CMD", "curl", "-f", "http://localhost"}, Interval: "30s", Timeout: "5s", StartPeriod: "1m30s", Retries: 3}, false},
+		{"cmd-shell", &HealthCheck{Test: []string{"CMD-SHELL", "pg_isready || exit 1"}}, false},
+		{"none", &HealthCheck{Test: []string{"NONE"}}, false},
+		{"timing only", &HealthCheck{Interval: "500ms"}, false},
+		{"none with args", &HealthCheck{Test: []string{"NONE", "x"}}, true},
+		{"none with timing", &HealthCheck{Test: []string{"NONE"}, StartPeriod: "5s"}, true},
+		{"cmd without command", &HealthCheck{Test: []string{"CMD"}}, true},
+		{"cmd-shell with two commands", &HealthCheck{Test: []string{"CMD-SHELL", "a", "b"}}, true},
+		{"garbage interval", &HealthCheck{Interval: "5xs"}, true},
+		{"no unit", &HealthCheck{Timeout: "30"}, true},
+		{"garbage start period", &HealthCheck{StartPeriod: "soon"}, true},
+		{"zero", &HealthCheck{Interval: "0s"}, true},
+		{"negative", &HealthCheck{Timeout: "-5s"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateHealthCheck(tt.hc); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateHealthCheck(%+v) error = %v, wantErr %v", tt.hc, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseMemory(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int64
+		norm    string
+		wantErr bool
+	}{
+		{"512m", 512 << 20, "512m", false},
+		{"4g", 4 << 30, "4g", false},
+		{"4GB", 4 << 30, "4g", false},
+		{"4 GiB", 4 << 30, "4g", false},
+		{"4096m", 4 << 30, "4g", false},
+		{"1.5g", 3 << 29, "1536m", false},
+		{"1t", 1 << 40, "1t", false},
+		{" 2048k ", 2 << 20, "2m", false},
+		{"1000", 1000, "1000b", false},
+		{"4x", 0, "4x", true},
+		{"lots", 0, "lots", true},
+		{"-1g", 0, "-1g", true},
+		{"", 0, "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseMemory(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseMemory(%q) = %d, %v; want %d (err=%t)", tt.in, got, err, tt.want, tt.wantErr)
+		}
+		if norm := NormalizeMemory(tt.in); norm != tt.norm {
+			t.Errorf("NormalizeMemory(%q) = %q, want %q", tt.in, norm, tt.norm)
+		}
+	}
+}
+
+func TestValidateResources(t *testing.T) {
+	tests := []struct {
+		name    string
+		r       *Resources
+		wantErr bool
+	}{
+		{"nil", nil, false},
+		{"valid", &Resources{CPUs: "1.5", Memory: "2g"}, false},
+		{"cpus only", &Resources{CPUs: "0.25"}, false},
+		{"zero cpus", &Resources{CPUs: "0"}, true},
+		{"negative cpus", &Resources{CPUs: "-2"}, true},
+		{"word cpus", &Resources{CPUs: "two"}, true},
+		{"too precise cpus", &Resources{CPUs: "0.0000000001"}, true},
+		{"garbage memory", &Resources{Memory: "4gbs"}, true},
+		{"tiny memory", &Resources{Memory: "4m"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateResources("resources", tt.r); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateResources(%+v) error = %v, wantErr %v", tt.r, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProjectConfigNormalize(t *testing.T) {
+	pc, err := ParseProjectConfig([]byte(`{"name":"web","resources":{"cpus":"2.0","memory":"4096MB"},"health_check":{"interval":" 30s "}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pc.Resources.CPUs != "2" || pc.Resources.Memory != "4g" || pc.HealthCheck.Interval != "30s" {
+		t.Errorf("normalized config = %+v / %+v", pc.Resources, pc.HealthCheck)
+	}
+	want := []string{"resources.cpus: '2.0' -> '2'", "resources.memory: '4096MB' -> '4g'", "health_check.interval: ' 30s ' -> '30s'"}
+	if !reflect.DeepEqual(pc.Normalized(), want) {
+		t.Errorf("Normalized() = %q, want %q", pc.Normalized(), want)
+	}
+
+	pc, err = ParseProjectConfig([]byte(`{"name":"web","resources":{"memory":"4x"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pc.Resources.Memory != "4x" || len(pc.Normalized()) != 0 {
+		t.Errorf("invalid memory was rewritten: %q, %q", pc.Resources.Memory, pc.Normalized())
+	}
+}
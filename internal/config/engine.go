@@ -0,0 +1,24 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+const DefaultEngine = "docker"
+
+var ContainerEngines = []string{"docker", "podman", "nerdctl"}
+
+func ValidateEngine(engine string) error {
+	if engine == "" {
+		return nil
+	}
+	name := filepath.Base(engine)
+	for _, allowed := range ContainerEngines {
+		if name == allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported container engine '%s' (allowed: %s)", engine, strings.Join(ContainerEngines, ", "))
+}
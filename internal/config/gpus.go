@@ -0,0 +1,29 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var gpuDeviceID = regexp.MustCompile(`^([0-9]+|(GPU|MIG)-[0-9A-Za-z/-]+)$`)
+
+func ValidateGpus(value string) error {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "all" {
+		return nil
+	}
+	if n, err := strconv.Atoi(value); err == nil {
+		if n < 1 {
+			return fmt.Errorf("invalid gpus '%s': the GPU count must be at least 1", value)
+		}
+		return nil
+	}
+	for _, id := range strings.Split(strings.TrimPrefix(value, "device="), ",") {
+		if !gpuDeviceID.MatchString(strings.TrimSpace(id)) {
+			return fmt.Errorf("invalid gpus '%s' (expected all, a count such as 2, or device ids such as device=0,1 or GPU-<uuid>)", value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+func (h *HealthCheck) Disabled() bool {
+	return h != nil && len(h.Test) > 0 && h.Test[0] == "NONE"
+}
+
+func ValidateHealthCheck(h *HealthCheck) error {
+	if h == nil {
+		return nil
+	}
+	if len(h.Test) > 0 {
+		switch h.Test[0] {
+		case "NONE":
+			if len(h.Test) > 1 {
+				return fmt.Errorf("health_check.test cannot have arguments when set to NONE")
+			}
+			if h.Interval != "" || h.Timeout != "" || h.StartPeriod != "" || h.Retries > 0 {
+				return fmt.Errorf("health_check.test NONE disables the health check; remove interval, timeout, start_period, and retries")
+			}
+		case "CMD":
+			if len(h.Test) < 2 {
+				return fmt.Errorf("health_check.test: CMD needs a command, e.g. [\"CMD\", \"curl\", \"-f\", \"http://localhost\"]")
+			}
+		case "CMD-SHELL":
+			if len(h.Test) != 2 || strings.TrimSpace(h.Test[1]) == "" {
+				return fmt.Errorf("health_check.test: CMD-SHELL takes exactly one shell command, e.g. [\"CMD-SHELL\", \"curl -f http://localhost || exit 1\"]")
+			}
+		}
+	}
+	for _, field := range []struct{ name, value string }{
+		{"interval", h.Interval},
+		{"timeout", h.Timeout},
+		{"start_period", h.StartPeriod},
+	} {
+		if field.value == "" {
+			continue
+		}
+		if err := validateHealthDuration(field.value); err != nil {
+			return fmt.Errorf("health_check.%s: %w", field.name, err)
+		}
+	}
+	return nil
+}
+
+func validateHealthDuration(value string) error {
+	d, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil {
+		return fmt.Errorf("invalid duration '%s' (use a number with a unit, such as 30s, 1m30s, or 500ms)", value)
+	}
+	if d < time.Millisecond {
+		return fmt.Errorf("duration '%s' must be at least 1ms", value)
+	}
+	return nil
+}
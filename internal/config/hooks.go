@@ -0,0 +1,74 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	HookPreUp       = "pre_up"
+	HookPostUp      = "post_up"
+	HookPreShell    = "pre_shell"
+	HookPostShell   = "post_shell"
+	HookPreStop     = "pre_stop"
+	HookPostStop    = "post_stop"
+	HookPreDestroy  = "pre_destroy"
+	HookPostDestroy = "post_destroy"
+)
+
+var HookEvents = []string{HookPreUp, HookPostUp, HookPreShell, HookPostShell, HookPreStop, HookPostStop, HookPreDestroy, HookPostDestroy}
+
+const (
+	HookInHost = "host"
+	HookInBox  = "box"
+)
+
+type Hook struct {
+	Run             string `json:"run"`
+	In              string `json:"in,omitempty"`
+	ContinueOnError bool   `json:"continue_on_error,omitempty"`
+}
+
+func (h Hook) InBox() bool {
+	return h.In == HookInBox
+}
+
+func IsHookEvent(event string) bool {
+	for _, e := range HookEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+func HookBoxAvailable(event string) bool {
+	switch event {
+	case HookPreUp, HookPostStop, HookPostDestroy:
+		return false
+	}
+	return true
+}
+
+func ValidateHooks(hooks map[string][]Hook) error {
+	for event, list := range hooks {
+		if !IsHookEvent(event) {
+			return fmt.Errorf("unknown hook '%s' (allowed: %s)", event, strings.Join(HookEvents, ", "))
+		}
+		for i, h := range list {
+			if strings.TrimSpace(h.Run) == "" {
+				return fmt.Errorf("hooks.%s[%d]: run is required", event, i)
+			}
+			switch h.In {
+			case "", HookInHost:
+			case HookInBox:
+				if !HookBoxAvailable(event) {
+					return fmt.Errorf("hooks.%s[%d]: the box isn't running during %s; use \"in\": \"host\"", event, i, event)
+				}
+			default:
+				return fmt.Errorf("hooks.%s[%d]: invalid in '%s' (use host or box)", event, i, h.In)
+			}
+		}
+	}
+	return nil
+}
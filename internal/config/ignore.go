@@ -0,0 +1,89 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+type IgnoreRules struct {
+	Packages map[string][]string `json:"packages,omitempty"`
+	Env      []string            `json:"env,omitempty"`
+}
+
+func (r *IgnoreRules) Empty() bool {
+	return r == nil || (len(r.Packages) == 0 && len(r.Env) == 0)
+}
+
+func (r *IgnoreRules) IgnoresPackage(manager, name string) bool {
+	if r == nil {
+		return false
+	}
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, key := range []string{manager, "*"} {
+		for _, pattern := range r.Packages[key] {
+			if ok, _ := path.Match(strings.ToLower(pattern), name); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (r *IgnoreRules) IgnoresEnv(name string) bool {
+	if r == nil {
+		return false
+	}
+	for _, pattern := range r.Env {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func MergeIgnoreRules(rules ...*IgnoreRules) *IgnoreRules {
+	merged := &IgnoreRules{}
+	for _, r := range rules {
+		if r == nil {
+			continue
+		}
+		for manager, patterns := range r.Packages {
+			if merged.Packages == nil {
+				merged.Packages = map[string][]string{}
+			}
+			merged.Packages[manager] = append(merged.Packages[manager], patterns...)
+		}
+		merged.Env = append(merged.Env, r.Env...)
+	}
+	return merged
+}
+
+func ValidateIgnoreRules(r *IgnoreRules) error {
+	if r == nil {
+		return nil
+	}
+	for _, manager := range sortedSliceKeys(r.Packages) {
+		for _, pattern := range r.Packages[manager] {
+			if _, err := path.Match(pattern, ""); err != nil || strings.TrimSpace(pattern) == "" {
+				return fmt.Errorf("invalid ignore pattern '%s' for %s packages", pattern, manager)
+			}
+		}
+	}
+	for _, pattern := range r.Env {
+		if _, err := path.Match(pattern, ""); err != nil || strings.TrimSpace(pattern) == "" {
+			return fmt.Errorf("invalid ignore pattern '%s' for env", pattern)
+		}
+	}
+	return nil
+}
+
+func sortedSliceKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var SystemPolicyPath = "/etc/devbox/policy.json"
+
+type ImagePolicy struct {
+	Allow []string `json:"allow,omitempty"`
+	Deny  []string `json:"deny,omitempty"`
+}
+
+type ImagePolicyError struct {
+	Image  string
+	Reason string
+	Source string
+}
+
+func (e *ImagePolicyError) Error() string {
+	return fmt.Sprintf("policy violation: image '%s' %s (%s)", e.Image, e.Reason, e.Source)
+}
+
+func (p *ImagePolicy) Empty() bool {
+	return p == nil || (len(p.Allow) == 0 && len(p.Deny) == 0)
+}
+
+func ValidateImagePolicy(p *ImagePolicy) error {
+	if p == nil {
+		return nil
+	}
+	for _, list := range [][]string{p.Allow, p.Deny} {
+		for _, pattern := range list {
+			if strings.TrimSpace(pattern) == "" {
+				return fmt.Errorf("image_policy patterns cannot be empty")
+			}
+			if strings.ContainsAny(pattern, " \t") {
+				return fmt.Errorf("invalid image_policy pattern %q: patterns cannot contain spaces", pattern)
+			}
+		}
+	}
+	return nil
+}
+
+func LoadSystemImagePolicy() (*ImagePolicy, error) {
+	data, err := os.ReadFile(SystemPolicyPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", SystemPolicyPath, err)
+	}
+	var doc struct {
+		ImagePolicy *ImagePolicy `json:"image_policy"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", SystemPolicyPath, err)
+	}
+	if err := ValidateImagePolicy(doc.ImagePolicy); err != nil {
+		return nil, fmt.Errorf("%s: %w", SystemPolicyPath, err)
+	}
+	return doc.ImagePolicy, nil
+}
+
+func NormalizeImageRef(ref string) string {
+	ref = strings.TrimSpace(ref)
+	name, suffix := ref, ""
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, suffix = name[:i], name[i:]
+	}
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, suffix = name[:i], name[i:]+suffix
+	}
+	first, _, hasSlash := strings.Cut(name, "/")
+	switch {
+	case !hasSlash:
+		name = "docker.io/library/" + name
+	case !strings.ContainsAny(first, ".:") && first != "localhost":
+		name = "docker.io/" + name
+	}
+	return name + suffix
+}
+
+func imageRefCandidates(ref string) []string {
+	full := NormalizeImageRef(ref)
+	noDigest, _, _ := strings.Cut(full, "@")
+	repo := noDigest
+	if i := strings.LastIndex(repo, ":"); i > strings.LastIndex(repo, "/") {
+		repo = repo[:i]
+	}
+	return []string{full, noDigest, repo}
+}
+
+func normalizePattern(pattern string) string {
+	pattern = strings.TrimSpace(pattern)
+	if strings.HasPrefix(pattern, "*") {
+		return pattern
+	}
+	return NormalizeImageRef(pattern)
+}
+
+func globMatch(pattern, s string) bool {
+	expr := regexp.QuoteMeta(pattern)
+	expr = strings.ReplaceAll(expr, `\*`, ".*")
+	expr = strings.ReplaceAll(expr, `\?`, ".")
+	matched, _ := regexp.MatchString("^"+expr+"$", s)
+	return matched
+}
+
+func matchImagePattern(patterns []string, ref string) (string, bool) {
+	candidates := imageRefCandidates(ref)
+	for _, pattern := range patterns {
+		np := normalizePattern(pattern)
+		for _, c := range candidates {
+			if globMatch(np, c) {
+				return pattern, true
+			}
+		}
+	}
+	return "", false
+}
+
+func (p *ImagePolicy) Check(image, source string) error {
+	if p.Empty() {
+		return nil
+	}
+	if pattern, ok := matchImagePattern(p.Deny, image); ok {
+		return &ImagePolicyError{Image: image, Reason: fmt.Sprintf("is denied by pattern '%s'", pattern), Source: source}
+	}
+	if len(p.Allow) > 0 {
+		if _, ok := matchImagePattern(p.Allow, image); !ok {
+			return &ImagePolicyError{Image: image, Reason: "matches no allowed pattern", Source: source}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	LintAptUpgrade        = "no-apt-upgrade"
+	LintUnpinnedPip       = "pin-pip"
+	LintUnpinnedNpm       = "pin-npm"
+	LintLatestTag         = "no-latest-tag"
+	LintSecretEnv         = "no-secrets-in-env"
+	LintBroadCapabilities = "no-broad-capabilities"
+	LintResourceLimits    = "resource-limits"
+)
+
+type LintFinding struct {
+	Rule    string
+	Field   string
+	Message string
+	Fixable bool
+}
+
+func (f LintFinding) String() string {
+	return fmt.Sprintf("[%s] %s: %s", f.Rule, f.Field, f.Message)
+}
+
+var (
+	aptUpgradePattern   = regexp.MustCompile(`\bapt(-get)?\s+(-\S+\s+)*(upgrade|dist-upgrade|full-upgrade)\b`)
+	shellSeparator      = regexp.MustCompile(`\s*(&&|\|\||;|\|)\s*`)
+	secretKeyPattern    = regexp.MustCompile(`(?i)(^|_)(PASSWORD|PASSWD|SECRET|TOKEN|API_?KEY|PRIVATE_KEY|ACCESS_KEY|CREDENTIALS?)(_|$)`)
+	secretValuePattern  = regexp.MustCompile(`^(ghp_|gho_|ghs_|github_pat_|glpat-|xox[abpr]-|sk-|sk_live_|AKIA|ASIA)[A-Za-z0-9_\-]{8,}`)
+	pipSpecSuffix       = regexp.MustCompile(`[<>=!~\[;].*$`)
+	pipExecutable       = regexp.MustCompile(`^pip(\d+(\.\d+)?)?$`)
+	exactNpmVersion     = regexp.MustCompile(`^v?\d+\.\d+\.\d+([-+][0-9A-Za-z.\-]+)?$`)
+	broadCapabilities   = map[string]bool{"ALL": true, "SYS_ADMIN": true}
+	pipRequirementFlags = map[string]bool{"-r": true, "--requirement": true, "-c": true, "--constraint": true, "-e": true, "--editable": true}
+	pipValueFlags       = map[string]bool{"-i": true, "--index-url": true, "--extra-index-url": true, "-t": true, "--target": true, "--prefix": true, "--root": true, "-f": true, "--find-links": true, "--trusted-host": true}
+)
+
+func LintProjectConfig(pc *ProjectConfig, settings *GlobalSettings) []LintFinding {
+	var findings []LintFinding
+	add := func(rule, field, msg string, fixable bool) {
+		findings = append(findings, LintFinding{Rule: rule, Field: field, Message: msg, Fixable: fixable})
+	}
+
+	for i, cmd := range pc.SetupCommands {
+		field := fmt.Sprintf("setup_commands[%d]", i)
+		if aptUpgradePattern.MatchString(cmd) {
+			add(LintAptUpgrade, field, "apt upgrade makes builds depend on the day they run; pin the packages you need instead", onlyAndChains(cmd))
+		}
+		for _, segment := range shellSeparator.Split(cmd, -1) {
+			for _, pkg := range unpinnedPipPackages(segment) {
+				add(LintUnpinnedPip, field, fmt.Sprintf("pip package %s has no exact version; use %s==<version>", pkg, pkg), false)
+			}
+			for _, pkg := range unpinnedNpmPackages(segment) {
+				add(LintUnpinnedNpm, field, fmt.Sprintf("npm package %s has no exact version; use %s@<version>", pkg, pkg), false)
+			}
+		}
+	}
+
+	if image := strings.TrimSpace(pc.BaseImage); image != "" && usesLatestTag(image) {
+		add(LintLatestTag, "base_image", fmt.Sprintf("%s resolves to whatever 'latest' is today; pin a version tag or digest", image), false)
+	}
+
+	for _, k := range sortedStringKeys(pc.Environment) {
+		v := pc.Environment[k]
+		if strings.TrimSpace(v) == "" || strings.HasPrefix(v, "$") {
+			continue
+		}
+		if secretKeyPattern.MatchString(k) || secretValuePattern.MatchString(v) {
+			add(LintSecretEnv, "environment."+k, "looks like a secret committed in devbox.json; move it to the secrets section instead", false)
+		}
+	}
+
+	for i, c := range pc.Capabilities {
+		name := strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(c)), "CAP_")
+		if broadCapabilities[name] {
+			add(LintBroadCapabilities, fmt.Sprintf("capabilities[%d]", i), fmt.Sprintf("%s grants nearly full control of the host kernel; add only the specific capabilities you need", c), false)
+		}
+	}
+
+	if !hasResourceLimits(pc.Resources) && (settings == nil || !hasResourceLimits(settings.DefaultResources)) {
+		add(LintResourceLimits, "resources", "no cpus or memory limit; one runaway process can starve the host", false)
+	}
+	return findings
+}
+
+func FixProjectConfig(pc *ProjectConfig, findings []LintFinding) int {
+	fix := map[string]bool{}
+	for _, f := range findings {
+		if f.Rule == LintAptUpgrade && f.Fixable {
+			fix[f.Field] = true
+		}
+	}
+	if len(fix) == 0 {
+		return 0
+	}
+	var commands []string
+	for i, cmd := range pc.SetupCommands {
+		if !fix[fmt.Sprintf("setup_commands[%d]", i)] {
+			commands = append(commands, cmd)
+			continue
+		}
+		if kept := dropAptUpgrade(cmd); kept != "" {
+			commands = append(commands, kept)
+		}
+	}
+	pc.SetupCommands = commands
+	return len(fix)
+}
+
+func onlyAndChains(cmd string) bool {
+	return !strings.ContainsAny(strings.ReplaceAll(cmd, "&&", ""), ";|")
+}
+
+func dropAptUpgrade(cmd string) string {
+	var kept []string
+	for _, part := range strings.Split(cmd, "&&") {
+		part = strings.TrimSpace(part)
+		if part == "" || aptUpgradePattern.MatchString(part) {
+			continue
+		}
+		kept = append(kept, part)
+	}
+	return strings.Join(kept, " && ")
+}
+
+func unpinnedPipPackages(segment string) []string {
+	fields := strings.Fields(segment)
+	start := -1
+	for i := 0; i+1 < len(fields); i++ {
+		base := fields[i]
+		if j := strings.LastIndex(base, "/"); j != -1 {
+			base = base[j+1:]
+		}
+		if pipExecutable.MatchString(base) && fields[i+1] == "install" {
+			start = i + 2
+			break
+		}
+	}
+	if start == -1 {
+		return nil
+	}
+	var unpinned []string
+	for i := start; i < len(fields); i++ {
+		arg := strings.Trim(fields[i], `'"`)
+		if pipRequirementFlags[arg] || pipValueFlags[arg] {
+			i++
+			continue
+		}
+		if strings.HasPrefix(arg, "-") || arg == "" {
+			continue
+		}
+		if strings.Contains(arg, "==") || strings.Contains(arg, "://") || strings.Contains(arg, " @ ") ||
+			strings.HasPrefix(arg, ".") || strings.HasPrefix(arg, "/") ||
+			strings.HasSuffix(arg, ".whl") || strings.HasSuffix(arg, ".tar.gz") || strings.HasSuffix(arg, ".zip") {
+			continue
+		}
+		unpinned = append(unpinned, pipSpecSuffix.ReplaceAllString(arg, ""))
+	}
+	return unpinned
+}
+
+func unpinnedNpmPackages(segment string) []string {
+	fields := strings.Fields(segment)
+	start := -1
+	for i := 0; i+1 < len(fields); i++ {
+		tool, verb := fields[i], fields[i+1]
+		switch {
+		case tool == "npm" && (verb == "install" || verb == "i" || verb == "add"):
+			start = i + 2
+		case (tool == "pnpm" || tool == "yarn") && verb == "add":
+			start = i + 2
+		case tool == "yarn" && verb == "global" && i+2 < len(fields) && fields[i+2] == "add":
+			start = i + 3
+		}
+		if start != -1 {
+			break
+		}
+	}
+	if start == -1 {
+		return nil
+	}
+	var unpinned []string
+	for _, f := range fields[start:] {
+		arg := strings.Trim(f, `'"`)
+		if arg == "" || strings.HasPrefix(arg, "-") || (strings.Contains(arg, "/") && !strings.HasPrefix(arg, "@")) {
+			continue
+		}
+		name, version := arg, ""
+		if i := strings.LastIndex(arg, "@"); i > 0 {
+			name, version = arg[:i], arg[i+1:]
+		}
+		if !exactNpmVersion.MatchString(version) {
+			unpinned = append(unpinned, name)
+		}
+	}
+	return unpinned
+}
+
+func usesLatestTag(image string) bool {
+	if strings.Contains(image, "@sha256:") {
+		return false
+	}
+	name := image
+	if i := strings.LastIndex(name, "/"); i != -1 {
+		name = name[i+1:]
+	}
+	tag := ""
+	if i := strings.LastIndex(name, ":"); i != -1 {
+		tag = name[i+1:]
+	}
+	return tag == "" || tag == "latest"
+}
+
+func hasResourceLimits(r *Resources) bool {
+	return r != nil && (strings.TrimSpace(r.CPUs) != "" || strings.TrimSpace(r.Memory) != "")
+}
+
+func sortedStringKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
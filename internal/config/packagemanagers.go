@@ -0,0 +1,61 @@
+package config
+
+import "sort"
+
+var BundledPackageManagers = []PluginPackageManager{
+	{
+		Name:      "cargo",
+		List:      `if command -v cargo >/dev/null 2>&1; then cargo install --list | awk '/^[^ ]/ {sub(/:$/, "", $2); sub(/^v/, "", $2); print $1 "@" $2}'; fi`,
+		Install:   `cargo install --locked {name} --version {version}`,
+		Remove:    `cargo uninstall {name}`,
+		Separator: "@",
+	},
+	{
+		Name: "go",
+		List: `if command -v go >/dev/null 2>&1; then d="$(go env GOBIN)"; d="${d:-$(go env GOPATH)/bin}"; ` +
+			`for f in "$d"/*; do { go version -m "$f" 2>/dev/null || true; } | awk '$1 == "path" {p = $2} $1 == "mod" {v = $3} END {if (p != "" && v != "" && v != "(devel)") print p "@" v}'; done; fi`,
+		Install: `go install {name}@{version}`,
+		Remove: `n={name}; b="${n##*/}"; case "$b" in v[0-9]*) n="${n%/*}"; b="${n##*/}" ;; esac; ` +
+			`d="$(go env GOBIN)"; rm -f "${d:-$(go env GOPATH)/bin}/$b"`,
+		Separator: "@",
+	},
+	{
+		Name:      "gem",
+		List:      `if command -v gem >/dev/null 2>&1 && command -v ruby >/dev/null 2>&1; then ruby -e 'Gem::Specification.each { |s| puts "#{s.name}@#{s.version}" unless s.default_gem? }' | sort -u; fi`,
+		Install:   `gem install {name} --version {version} --no-document`,
+		Remove:    `gem uninstall {name} --all --executables --ignore-dependencies`,
+		Separator: "@",
+	},
+	{
+		Name: "composer",
+		List: `if command -v composer >/dev/null 2>&1; then { COMPOSER_ALLOW_SUPERUSER=1 composer global show --direct --format=json --no-interaction 2>/dev/null || true; } | ` +
+			`php -r '$d = json_decode(stream_get_contents(STDIN), true); foreach (($d["installed"] ?? []) as $p) { echo $p["name"], "@", $p["version"], "\n"; }'; fi`,
+		Install:   `COMPOSER_ALLOW_SUPERUSER=1 composer global require --no-interaction {name}:{version}`,
+		Remove:    `COMPOSER_ALLOW_SUPERUSER=1 composer global remove --no-interaction {name}`,
+		Separator: "@",
+	},
+	{
+		Name: "conda",
+		List: `if command -v conda >/dev/null 2>&1; then conda env list 2>/dev/null | awk '!/^#/ && NF && $1 !~ /^\// {print $1}' | while read -r env; do ` +
+			`{ conda list -n "$env" --export 2>/dev/null || true; } | awk -F= -v env="$env" '!/^#/ && NF && $3 != "pypi_0" {print env "/" $1 "=" $2}'; done; fi`,
+		Install: `n={name}; v={version}; e="${n%%/*}"; ` +
+			`conda env list | awk '{print $1}' | grep -qx "$e" || conda create -y -n "$e"; conda install -y -n "$e" "${n#*/}=$v"`,
+		Remove:    `n={name}; conda remove -y -n "${n%%/*}" "${n#*/}"`,
+		Separator: "=",
+	},
+}
+
+func WithBundledPackageManagers(plugins []PluginPackageManager) []PluginPackageManager {
+	out := append([]PluginPackageManager{}, plugins...)
+	registered := map[string]bool{}
+	for _, pm := range plugins {
+		registered[pm.Name] = true
+	}
+	for _, pm := range BundledPackageManagers {
+		if !registered[pm.Name] {
+			out = append(out, pm)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
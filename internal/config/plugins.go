@@ -0,0 +1,118 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var builtinPackageManagers = map[string]bool{
+	"apt":  true,
+	"pip":  true,
+	"npm":  true,
+	"yarn": true,
+	"pnpm": true,
+}
+
+var pluginVersionSeparators = map[string]bool{
+	"=":  true,
+	"==": true,
+	"@":  true,
+}
+
+type PluginManifest struct {
+	Name            string                 `json:"name"`
+	Description     string                 `json:"description,omitempty"`
+	PackageManagers []PluginPackageManager `json:"package_managers,omitempty"`
+}
+
+type PluginPackageManager struct {
+	Name      string `json:"name"`
+	List      string `json:"list"`
+	Install   string `json:"install,omitempty"`
+	Remove    string `json:"remove,omitempty"`
+	Separator string `json:"separator,omitempty"`
+}
+
+func (cm *ConfigManager) PluginsDir() string {
+	return filepath.Join(cm.ConfigDir(), "plugins")
+}
+
+func (cm *ConfigManager) LoadPlugins() ([]*PluginManifest, error) {
+	entries, err := os.ReadDir(cm.PluginsDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read plugins directory: %w", err)
+	}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+
+	var manifests []*PluginManifest
+	seen := map[string]string{}
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(cm.PluginsDir(), name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read plugin manifest %s: %w", name, err)
+		}
+		var m PluginManifest
+		if err := json.Unmarshal(data, &m); err != nil {
+			return nil, fmt.Errorf("failed to parse plugin manifest %s: %w", name, err)
+		}
+		if strings.TrimSpace(m.Name) == "" {
+			m.Name = strings.TrimSuffix(name, ".json")
+		}
+		if err := ValidatePluginManifest(&m); err != nil {
+			return nil, fmt.Errorf("invalid plugin manifest %s: %w", name, err)
+		}
+		for _, pm := range m.PackageManagers {
+			if owner, ok := seen[pm.Name]; ok {
+				return nil, fmt.Errorf("package manager '%s' is registered by both '%s' and '%s'", pm.Name, owner, m.Name)
+			}
+			seen[pm.Name] = m.Name
+		}
+		manifests = append(manifests, &m)
+	}
+	return manifests, nil
+}
+
+func ValidatePluginManifest(m *PluginManifest) error {
+	for i := range m.PackageManagers {
+		pm := &m.PackageManagers[i]
+		if strings.TrimSpace(pm.Name) == "" {
+			return fmt.Errorf("package_managers[%d]: name is required", i)
+		}
+		if builtinPackageManagers[pm.Name] {
+			return fmt.Errorf("package manager '%s' is built in and cannot be replaced by a plugin", pm.Name)
+		}
+		if strings.TrimSpace(pm.List) == "" {
+			return fmt.Errorf("package manager '%s': list command is required", pm.Name)
+		}
+		if pm.Separator == "" {
+			pm.Separator = "@"
+		}
+		if !pluginVersionSeparators[pm.Separator] {
+			return fmt.Errorf("package manager '%s': separator must be one of '=', '==' or '@'", pm.Name)
+		}
+	}
+	return nil
+}
+
+func PluginPackageManagers(manifests []*PluginManifest) []PluginPackageManager {
+	var out []PluginPackageManager
+	for _, m := range manifests {
+		out = append(out, m.PackageManagers...)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
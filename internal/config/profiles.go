@@ -0,0 +1,92 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type Profile struct {
+	DefaultBaseImage   string            `json:"default_base_image,omitempty"`
+	DefaultEnvironment map[string]string `json:"default_environment,omitempty"`
+	Mirrors            *MirrorSettings   `json:"mirrors,omitempty"`
+	Proxy              *ProxySettings    `json:"proxy,omitempty"`
+	DefaultResources   *Resources        `json:"default_resources,omitempty"`
+}
+
+func (cm *ConfigManager) ProfilesDir() string {
+	return filepath.Join(cm.ConfigDir(), "profiles")
+}
+
+func (cm *ConfigManager) ListProfiles() []string {
+	entries, err := os.ReadDir(cm.ProfilesDir())
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		names = append(names, strings.TrimSuffix(e.Name(), ".json"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (cm *ConfigManager) LoadProfile(name string) (*Profile, error) {
+	data, err := os.ReadFile(filepath.Join(cm.ProfilesDir(), name+".json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("profile '%s' not found", name)
+		}
+		return nil, fmt.Errorf("failed to read profile: %w", err)
+	}
+	var profile Profile
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return nil, fmt.Errorf("failed to parse profile '%s': %w", name, err)
+	}
+	return &profile, nil
+}
+
+func (cm *ConfigManager) SaveProfile(name string, profile *Profile) error {
+	if err := os.MkdirAll(cm.ProfilesDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create profiles directory: %w", err)
+	}
+	data, err := json.MarshalIndent(profile, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal profile: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(cm.ProfilesDir(), name+".json"), data, 0644); err != nil {
+		return fmt.Errorf("failed to write profile: %w", err)
+	}
+	return nil
+}
+
+func ProfileFromSettings(settings *GlobalSettings) *Profile {
+	if settings == nil {
+		return &Profile{}
+	}
+	return &Profile{
+		DefaultBaseImage:   settings.DefaultBaseImage,
+		DefaultEnvironment: settings.DefaultEnvironment,
+		Mirrors:            settings.Mirrors,
+		Proxy:              settings.Proxy,
+		DefaultResources:   settings.DefaultResources,
+	}
+}
+
+func (settings *GlobalSettings) ApplyProfile(name string, profile *Profile) {
+	settings.Profile = name
+	settings.DefaultBaseImage = profile.DefaultBaseImage
+	if settings.DefaultBaseImage == "" {
+		settings.DefaultBaseImage = "ubuntu:22.04"
+	}
+	settings.DefaultEnvironment = profile.DefaultEnvironment
+	settings.Mirrors = profile.Mirrors
+	settings.Proxy = profile.Proxy
+	settings.DefaultResources = profile.DefaultResources
+}
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const minMemoryBytes = 6 << 20
+
+var memoryPattern = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)?) ?([bkmgt]?)(?:ib|b)?$`)
+
+var memoryUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{"t", 1 << 40},
+	{"g", 1 << 30},
+	{"m", 1 << 20},
+	{"k", 1 << 10},
+	{"b", 1},
+}
+
+func ParseMemory(value string) (int64, error) {
+	s := strings.ToLower(strings.TrimSpace(value))
+	m := memoryPattern.FindStringSubmatch(s)
+	if m == nil {
+		return 0, fmt.Errorf("invalid size '%s' (use a number with a b, k, m, g, or t unit, such as 512m or 4g)", value)
+	}
+	n, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size '%s'", value)
+	}
+	mult := int64(1)
+	for _, u := range memoryUnits {
+		if u.suffix == m[2] {
+			mult = u.bytes
+		}
+	}
+	bytes := n * float64(mult)
+	if bytes > math.MaxInt64 {
+		return 0, fmt.Errorf("size '%s' is too large", value)
+	}
+	return int64(bytes), nil
+}
+
+func FormatMemory(bytes int64) string {
+	for _, u := range memoryUnits {
+		if bytes >= u.bytes && bytes%u.bytes == 0 {
+			return strconv.FormatInt(bytes/u.bytes, 10) + u.suffix
+		}
+	}
+	return strconv.FormatInt(bytes, 10) + "b"
+}
+
+func ParseCPUs(value string) (float64, error) {
+	n, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+		return 0, fmt.Errorf("invalid CPU count '%s' (use a number such as 2 or 1.5)", value)
+	}
+	if n <= 0 {
+		return 0, fmt.Errorf("CPU count '%s' must be greater than 0", value)
+	}
+	if n*1e9 != math.Trunc(n*1e9) {
+		return 0, fmt.Errorf("CPU count '%s' has more than 9 decimal places", value)
+	}
+	return n, nil
+}
+
+func NormalizeMemory(value string) string {
+	bytes, err := ParseMemory(value)
+	if err != nil {
+		return value
+	}
+	return FormatMemory(bytes)
+}
+
+func NormalizeCPUs(value string) string {
+	n, err := ParseCPUs(value)
+	if err != nil {
+		return value
+	}
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+func ValidateResources(field string, r *Resources) error {
+	if r == nil {
+		return nil
+	}
+	if r.CPUs != "" {
+		if _, err := ParseCPUs(r.CPUs); err != nil {
+			return fmt.Errorf("%s.cpus: %w", field, err)
+		}
+	}
+	if r.Memory != "" {
+		bytes, err := ParseMemory(r.Memory)
+		if err != nil {
+			return fmt.Errorf("%s.memory: %w", field, err)
+		}
+		if bytes < minMemoryBytes {
+			return fmt.Errorf("%s.memory: '%s' is below the 6m minimum docker allows", field, r.Memory)
+		}
+	}
+	return nil
+}
+
+func (pc *ProjectConfig) normalize() []string {
+	var changes []string
+	set := func(field string, value *string, normalized string) {
+		if normalized != *value {
+			changes = append(changes, fmt.Sprintf("%s: '%s' -> '%s'", field, *value, normalized))
+			*value = normalized
+		}
+	}
+	if r := pc.Resources; r != nil {
+		if r.CPUs != "" {
+			set("resources.cpus", &r.CPUs, NormalizeCPUs(r.CPUs))
+		}
+		if r.Memory != "" {
+			set("resources.memory", &r.Memory, NormalizeMemory(r.Memory))
+		}
+	}
+	if h := pc.HealthCheck; h != nil {
+		set("health_check.interval", &h.Interval, strings.TrimSpace(h.Interval))
+		set("health_check.timeout", &h.Timeout, strings.TrimSpace(h.Timeout))
+		set("health_check.start_period", &h.StartPeriod, strings.TrimSpace(h.StartPeriod))
+	}
+	return changes
+}
+
+func (pc *ProjectConfig) Normalized() []string {
+	return pc.normalized
+}
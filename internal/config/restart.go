@@ -0,0 +1,50 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const DefaultRestartPolicy = "unless-stopped"
+
+var RestartPolicies = []string{"no", "always", "unless-stopped", "on-failure"}
+
+func ValidateRestartPolicy(policy string) error {
+	if policy == "" {
+		return nil
+	}
+	name, retries, hasRetries := strings.Cut(policy, ":")
+	if hasRetries {
+		if name != "on-failure" {
+			return fmt.Errorf("invalid restart policy '%s': only on-failure accepts a retry count", policy)
+		}
+		if n, err := strconv.Atoi(retries); err != nil || n < 1 {
+			return fmt.Errorf("invalid restart policy '%s': retry count must be a positive integer", policy)
+		}
+		return nil
+	}
+	for _, allowed := range RestartPolicies {
+		if name == allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid restart policy '%s' (allowed: %s, on-failure:N)", policy, strings.Join(RestartPolicies, ", "))
+}
+
+func RestartSurvivesReboot(policy string) bool {
+	return policy == "always" || policy == "unless-stopped"
+}
+
+func (settings *GlobalSettings) RestartPolicy() string {
+	if settings == nil {
+		return DefaultRestartPolicy
+	}
+	if settings.DefaultRestart != "" {
+		return settings.DefaultRestart
+	}
+	if settings.AutoStopOnExit {
+		return "no"
+	}
+	return DefaultRestartPolicy
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+const (
+	SecretSourceEnv   = "env"
+	SecretSourceFile  = "file"
+	SecretSourcePass  = "pass"
+	SecretSourceOp    = "op"
+	SecretSourceStore = "store"
+)
+
+var SecretSources = []string{SecretSourceEnv, SecretSourceFile, SecretSourcePass, SecretSourceOp, SecretSourceStore}
+
+var secretNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+type Secret struct {
+	From string `json:"from,omitempty"`
+	File string `json:"file,omitempty"`
+}
+
+func (s Secret) Source(name string) (scheme, ref string) {
+	if s.From == "" {
+		return SecretSourceStore, name
+	}
+	scheme, ref, _ = strings.Cut(s.From, "://")
+	if scheme == SecretSourceOp {
+		ref = s.From
+	}
+	return scheme, ref
+}
+
+func IsSecretName(name string) bool {
+	return secretNamePattern.MatchString(name)
+}
+
+func ValidateSecrets(secrets map[string]Secret, environment map[string]string) error {
+	for name, s := range secrets {
+		if !IsSecretName(name) {
+			return fmt.Errorf("invalid secret name '%s' (use letters, digits, and underscores, not starting with a digit)", name)
+		}
+		if _, ok := environment[name]; ok && s.File == "" {
+			return fmt.Errorf("secrets.%s: also set in environment; remove it from one of them", name)
+		}
+		if s.From != "" {
+			scheme, ref, ok := strings.Cut(s.From, "://")
+			if !ok || !isSecretSource(scheme) {
+				return fmt.Errorf("secrets.%s: invalid from '%s' (use %s://...)", name, s.From, strings.Join(SecretSources, "://, "))
+			}
+			if strings.TrimSpace(ref) == "" {
+				return fmt.Errorf("secrets.%s: from '%s' is missing a name or path", name, s.From)
+			}
+			if scheme == SecretSourceEnv && !IsSecretName(ref) {
+				return fmt.Errorf("secrets.%s: invalid environment variable name '%s'", name, ref)
+			}
+		}
+		if s.File != "" && (!path.IsAbs(s.File) || strings.HasSuffix(s.File, "/")) {
+			return fmt.Errorf("secrets.%s: file '%s' must be an absolute path to a file in the box", name, s.File)
+		}
+	}
+	return nil
+}
+
+func isSecretSource(scheme string) bool {
+	for _, s := range SecretSources {
+		if s == scheme {
+			return true
+		}
+	}
+	return false
+}
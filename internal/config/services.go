@@ -0,0 +1,101 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type Service struct {
+	Image       string            `json:"image"`
+	Environment map[string]string `json:"environment,omitempty"`
+	Ports       []string          `json:"ports,omitempty"`
+	Volumes     []string          `json:"volumes,omitempty"`
+	DependsOn   []string          `json:"depends_on,omitempty"`
+}
+
+var serviceNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+func ValidateServices(services map[string]*Service) error {
+	for _, name := range sortedServiceNames(services) {
+		svc := services[name]
+		if !serviceNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid service name '%s' (use lowercase letters, digits, '-' and '_')", name)
+		}
+		if svc == nil || strings.TrimSpace(svc.Image) == "" {
+			return fmt.Errorf("services.%s: image is required", name)
+		}
+		for _, port := range svc.Ports {
+			if err := validatePortMapping(port); err != nil {
+				return fmt.Errorf("services.%s: %w", name, err)
+			}
+		}
+		for _, volume := range svc.Volumes {
+			if err := validateVolumeMapping(volume); err != nil {
+				return fmt.Errorf("services.%s: %w", name, err)
+			}
+		}
+		for _, dep := range svc.DependsOn {
+			if dep == name {
+				return fmt.Errorf("services.%s: a service cannot depend on itself", name)
+			}
+			if _, ok := services[dep]; !ok {
+				return fmt.Errorf("services.%s: depends on unknown service '%s'", name, dep)
+			}
+		}
+	}
+	_, err := ServiceStartOrder(services)
+	return err
+}
+
+func ServiceStartOrder(services map[string]*Service) ([]string, error) {
+	var order []string
+	started := map[string]bool{}
+	for len(order) < len(services) {
+		var ready []string
+		for _, name := range sortedServiceNames(services) {
+			if started[name] {
+				continue
+			}
+			if dependenciesStarted(services[name], services, started) {
+				ready = append(ready, name)
+			}
+		}
+		if len(ready) == 0 {
+			var blocked []string
+			for _, name := range sortedServiceNames(services) {
+				if !started[name] {
+					blocked = append(blocked, name)
+				}
+			}
+			return nil, fmt.Errorf("services have a depends_on cycle: %s", strings.Join(blocked, ", "))
+		}
+		for _, name := range ready {
+			started[name] = true
+		}
+		order = append(order, ready...)
+	}
+	return order, nil
+}
+
+func dependenciesStarted(svc *Service, services map[string]*Service, started map[string]bool) bool {
+	if svc == nil {
+		return true
+	}
+	for _, dep := range svc.DependsOn {
+		if _, ok := services[dep]; ok && !started[dep] {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedServiceNames(services map[string]*Service) []string {
+	names := make([]string, 0, len(services))
+	for name := range services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultStartupTimeout = 30 * time.Second
+	DefaultStopTimeout    = 2 * time.Second
+	DefaultSetupTimeout   = 10 * time.Minute
+)
+
+type Timeouts struct {
+	Startup string `json:"startup,omitempty"`
+	Stop    string `json:"stop,omitempty"`
+	Setup   string `json:"setup,omitempty"`
+}
+
+type BoxTimeouts struct {
+	Startup time.Duration
+	Stop    time.Duration
+	Setup   time.Duration
+}
+
+func ParseTimeout(field, s string) (time.Duration, error) {
+	d, err := time.ParseDuration(strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid timeouts.%s '%s' (use a duration such as 90s or 5m)", field, s)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("timeouts.%s must be positive", field)
+	}
+	return d, nil
+}
+
+func ValidateTimeouts(t *Timeouts) error {
+	if t == nil {
+		return nil
+	}
+	for _, f := range t.fields() {
+		if *f.value == "" {
+			continue
+		}
+		if _, err := ParseTimeout(f.name, *f.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type timeoutField struct {
+	name   string
+	value  *string
+	target func(*BoxTimeouts) *time.Duration
+}
+
+func (t *Timeouts) fields() []timeoutField {
+	return []timeoutField{
+		{"startup", &t.Startup, func(b *BoxTimeouts) *time.Duration { return &b.Startup }},
+		{"stop", &t.Stop, func(b *BoxTimeouts) *time.Duration { return &b.Stop }},
+		{"setup", &t.Setup, func(b *BoxTimeouts) *time.Duration { return &b.Setup }},
+	}
+}
+
+func ResolveTimeouts(settings *GlobalSettings, pc *ProjectConfig) BoxTimeouts {
+	resolved := BoxTimeouts{Startup: DefaultStartupTimeout, Stop: DefaultStopTimeout, Setup: DefaultSetupTimeout}
+	var layers []*Timeouts
+	if settings != nil {
+		layers = append(layers, settings.Timeouts)
+	}
+	if pc != nil {
+		layers = append(layers, pc.Timeouts)
+	}
+	for _, t := range layers {
+		if t == nil {
+			continue
+		}
+		for _, f := range t.fields() {
+			if *f.value == "" {
+				continue
+			}
+			if d, err := ParseTimeout(f.name, *f.value); err == nil {
+				*f.target(&resolved) = d
+			}
+		}
+	}
+	return resolved
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var TmuxLayouts = []string{"even-horizontal", "even-vertical", "main-horizontal", "main-vertical", "tiled"}
+
+var tmuxName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+type Tmux struct {
+	Session string       `json:"session,omitempty"`
+	Windows []TmuxWindow `json:"windows,omitempty"`
+}
+
+type TmuxWindow struct {
+	Name   string   `json:"name"`
+	Dir    string   `json:"dir,omitempty"`
+	Layout string   `json:"layout,omitempty"`
+	Panes  []string `json:"panes,omitempty"`
+}
+
+func ValidateTmux(t *Tmux) error {
+	if t == nil {
+		return nil
+	}
+	if t.Session != "" && !tmuxName.MatchString(t.Session) {
+		return fmt.Errorf("invalid tmux.session '%s' (use letters, digits, - and _)", t.Session)
+	}
+	seen := map[string]bool{}
+	for i, w := range t.Windows {
+		if !tmuxName.MatchString(w.Name) {
+			return fmt.Errorf("tmux.windows[%d]: invalid name '%s' (use letters, digits, - and _)", i, w.Name)
+		}
+		if seen[w.Name] {
+			return fmt.Errorf("tmux.windows[%d]: duplicate window name '%s'", i, w.Name)
+		}
+		seen[w.Name] = true
+		if w.Dir != "" && !strings.HasPrefix(w.Dir, "/") {
+			return fmt.Errorf("tmux.windows[%d]: dir '%s' must be an absolute path in the box", i, w.Dir)
+		}
+		if w.Layout != "" && !isTmuxLayout(w.Layout) {
+			return fmt.Errorf("tmux.windows[%d]: invalid layout '%s' (allowed: %s)", i, w.Layout, strings.Join(TmuxLayouts, ", "))
+		}
+	}
+	return nil
+}
+
+func isTmuxLayout(layout string) bool {
+	for _, l := range TmuxLayouts {
+		if l == layout {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const MinTTL = time.Minute
+
+var ttlPattern = regexp.MustCompile(`^([0-9]+(d|h|m|s))+$`)
+
+func ParseTTL(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if !ttlPattern.MatchString(s) {
+		return 0, fmt.Errorf("invalid ttl '%s' (use a duration such as 45m, 4h, 1d12h)", s)
+	}
+	var total time.Duration
+	if days, rest, ok := strings.Cut(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid ttl '%s' (days must come first, e.g. 1d12h)", s)
+		}
+		total = time.Duration(n) * 24 * time.Hour
+		s = rest
+	}
+	if s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return 0, fmt.Errorf("invalid ttl '%s': %w", s, err)
+		}
+		total += d
+	}
+	if total < MinTTL {
+		return 0, fmt.Errorf("ttl must be at least %s", MinTTL)
+	}
+	return total, nil
+}
@@ -0,0 +1,21 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+)
+
+var boxUserName = regexp.MustCompile(`^[a-z_][a-z0-9_-]{0,31}$`)
+
+func ValidateCreateUser(pc *ProjectConfig) error {
+	if !pc.CreateUser || pc.User == "" {
+		return nil
+	}
+	if pc.User == "root" {
+		return fmt.Errorf("create_user makes a non-root user; remove \"user\": \"root\" or turn create_user off")
+	}
+	if !boxUserName.MatchString(pc.User) {
+		return fmt.Errorf("invalid user '%s' for create_user (expected a login name such as dev, not a uid or uid:gid)", pc.User)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"text/template"
+)
+
+const (
+	WebhookUpFinished    = "up.finished"
+	WebhookDriftDetected = "drift.detected"
+	WebhookUpdateFailed  = "update.failed"
+)
+
+var WebhookEvents = []string{WebhookUpFinished, WebhookDriftDetected, WebhookUpdateFailed}
+
+var WebhookTemplateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"join": strings.Join,
+}
+
+type Webhook struct {
+	URL      string            `json:"url"`
+	Events   []string          `json:"events,omitempty"`
+	Template string            `json:"template,omitempty"`
+	Headers  map[string]string `json:"headers,omitempty"`
+}
+
+func (w Webhook) Wants(event string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == event || e == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+func ValidateWebhook(w Webhook) error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid webhook url '%s': must be an http or https URL", w.URL)
+	}
+	for _, e := range w.Events {
+		if e != "*" && !IsWebhookEvent(e) {
+			return fmt.Errorf("unknown webhook event '%s' (allowed: %s, *)", e, strings.Join(WebhookEvents, ", "))
+		}
+	}
+	if w.Template != "" {
+		if _, err := template.New("webhook").Funcs(WebhookTemplateFuncs).Parse(w.Template); err != nil {
+			return fmt.Errorf("invalid webhook template: %w", err)
+		}
+	}
+	return nil
+}
+
+func IsWebhookEvent(event string) bool {
+	for _, e := range WebhookEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
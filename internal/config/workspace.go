@@ -0,0 +1,62 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	WorkspaceModeBind   = "bind"
+	WorkspaceModeSync   = "sync"
+	DefaultSyncInterval = time.Second
+	minSyncInterval     = 100 * time.Millisecond
+)
+
+type Workspace struct {
+	Mode     string   `json:"mode,omitempty"`
+	Ignore   []string `json:"ignore,omitempty"`
+	Interval string   `json:"interval,omitempty"`
+}
+
+func (w *Workspace) Synced() bool {
+	return w != nil && w.Mode == WorkspaceModeSync
+}
+
+func (w *Workspace) SyncInterval() time.Duration {
+	if w == nil || w.Interval == "" {
+		return DefaultSyncInterval
+	}
+	d, err := time.ParseDuration(w.Interval)
+	if err != nil || d < minSyncInterval {
+		return DefaultSyncInterval
+	}
+	return d
+}
+
+func ValidateWorkspace(w *Workspace) error {
+	if w == nil {
+		return nil
+	}
+	switch w.Mode {
+	case "", WorkspaceModeBind, WorkspaceModeSync:
+	default:
+		return fmt.Errorf("invalid workspace.mode '%s' (expected %s or %s)", w.Mode, WorkspaceModeBind, WorkspaceModeSync)
+	}
+	for _, pattern := range w.Ignore {
+		if _, err := path.Match(pattern, ""); err != nil || strings.TrimSpace(pattern) == "" {
+			return fmt.Errorf("invalid workspace.ignore pattern '%s'", pattern)
+		}
+	}
+	if w.Interval != "" {
+		d, err := time.ParseDuration(w.Interval)
+		if err != nil {
+			return fmt.Errorf("invalid workspace.interval '%s': %w", w.Interval, err)
+		}
+		if d < minSyncInterval {
+			return fmt.Errorf("workspace.interval '%s' is below the minimum of %s", w.Interval, minSyncInterval)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,377 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/strslice"
+	"github.com/docker/docker/client"
+	"github.com/docker/go-connections/nat"
+	"github.com/docker/go-units"
+)
+
+const apiPingTimeout = 2 * time.Second
+
+func useEngineAPI(engine, endpoint string) bool {
+	if filepath.Base(engine) != "docker" {
+		return false
+	}
+	if endpoint == "" {
+		return true
+	}
+	u, err := url.Parse(endpoint)
+	return err == nil && u.Scheme != "ssh"
+}
+
+func newAPIClient() *client.Client {
+	endpoint := DaemonEndpoint()
+	if !useEngineAPI(dockerCmd(), endpoint) {
+		return nil
+	}
+	opts := []client.Opt{client.FromEnv, client.WithAPIVersionNegotiation()}
+	if endpoint != "" {
+		opts = append(opts, client.WithHost(endpoint))
+	}
+	api, err := client.NewClientWithOpts(opts...)
+	if err != nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), apiPingTimeout)
+	defer cancel()
+	if _, err := api.Ping(ctx); err != nil {
+		api.Close()
+		return nil
+	}
+	return api
+}
+
+type createSpec struct {
+	Name   string
+	Config *container.Config
+	Host   *container.HostConfig
+}
+
+func createSpecFromArgs(args []string, env map[string]string) (*createSpec, error) {
+	if len(args) == 0 || args[0] != "create" {
+		return nil, fmt.Errorf("not a create command")
+	}
+	spec := &createSpec{
+		Config: &container.Config{Labels: map[string]string{}},
+		Host:   &container.HostConfig{},
+	}
+	var ports []string
+	i := 1
+	for ; i < len(args) && strings.HasPrefix(args[i], "-"); i++ {
+		flag := args[i]
+		switch flag {
+		case "-it":
+			spec.Config.Tty = true
+			spec.Config.OpenStdin = true
+			spec.Config.AttachStdin = true
+			spec.Config.AttachStdout = true
+			spec.Config.AttachStderr = true
+			continue
+		case "--no-healthcheck":
+			spec.Config.Healthcheck = &container.HealthConfig{Test: []string{"NONE"}}
+			continue
+		}
+		if i+1 >= len(args) {
+			return nil, fmt.Errorf("flag %s has no value", flag)
+		}
+		i++
+		value := args[i]
+		var err error
+		switch flag {
+		case "--name":
+			spec.Name = value
+		case "--workdir":
+			spec.Config.WorkingDir = value
+		case "--user":
+			spec.Config.User = value
+		case "--label":
+			key, val, _ := strings.Cut(value, "=")
+			spec.Config.Labels[key] = val
+		case "-e":
+			if key, _, ok := strings.Cut(value, "="); ok && key != "" {
+				spec.Config.Env = append(spec.Config.Env, value)
+			} else if val, ok := env[value]; ok {
+				spec.Config.Env = append(spec.Config.Env, value+"="+val)
+			} else if val, ok := os.LookupEnv(value); ok {
+				spec.Config.Env = append(spec.Config.Env, value+"="+val)
+			}
+		case "-p":
+			ports = append(ports, value)
+		case "-v":
+			if strings.Contains(value, ":") {
+				spec.Host.Binds = append(spec.Host.Binds, value)
+			} else {
+				if spec.Config.Volumes == nil {
+					spec.Config.Volumes = map[string]struct{}{}
+				}
+				spec.Config.Volumes[value] = struct{}{}
+			}
+		case "--mount":
+			var m mount.Mount
+			if m, err = parseMountSpec(value); err == nil {
+				spec.Host.Mounts = append(spec.Host.Mounts, m)
+			}
+		case "--cap-add":
+			spec.Host.CapAdd = append(spec.Host.CapAdd, value)
+		case "--network":
+			spec.Host.NetworkMode = container.NetworkMode(value)
+		case "--restart":
+			spec.Host.RestartPolicy, err = parseRestartPolicy(value)
+		case "--stop-timeout":
+			var seconds int
+			if seconds, err = strconv.Atoi(value); err == nil {
+				spec.Config.StopTimeout = &seconds
+			}
+		case "--cpus":
+			var cpus float64
+			if cpus, err = strconv.ParseFloat(value, 64); err == nil {
+				spec.Host.NanoCPUs = int64(cpus * 1e9)
+			}
+		case "--memory":
+			spec.Host.Memory, err = units.RAMInBytes(value)
+		case "--gpus":
+			var req container.DeviceRequest
+			if req, err = gpuDeviceRequest(value); err == nil {
+				spec.Host.DeviceRequests = append(spec.Host.DeviceRequests, req)
+			}
+		case "--health-cmd", "--health-interval", "--health-timeout", "--health-start-period", "--health-retries":
+			err = applyHealthFlag(spec.Config, flag, value)
+		default:
+			return nil, fmt.Errorf("unsupported create flag %s", flag)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s value '%s': %w", flag, value, err)
+		}
+	}
+	if i >= len(args) {
+		return nil, fmt.Errorf("create command has no image")
+	}
+	spec.Config.Image = args[i]
+	spec.Config.Cmd = strslice.StrSlice(args[i+1:])
+	if len(ports) > 0 {
+		exposed, bindings, err := nat.ParsePortSpecs(ports)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -p value: %w", err)
+		}
+		spec.Config.ExposedPorts = exposed
+		spec.Host.PortBindings = bindings
+	}
+	return spec, nil
+}
+
+func parseMountSpec(value string) (mount.Mount, error) {
+	var m mount.Mount
+	for _, field := range strings.Split(value, ",") {
+		key, val, _ := strings.Cut(field, "=")
+		switch key {
+		case "type":
+			m.Type = mount.Type(val)
+		case "source", "src":
+			m.Source = val
+		case "target", "dst", "destination":
+			m.Target = val
+		case "readonly", "ro":
+			m.ReadOnly = val == "" || val == "true" || val == "1"
+		case "volume-label":
+			if m.VolumeOptions == nil {
+				m.VolumeOptions = &mount.VolumeOptions{Labels: map[string]string{}}
+			}
+			k, v, _ := strings.Cut(val, "=")
+			m.VolumeOptions.Labels[k] = v
+		default:
+			return m, fmt.Errorf("unsupported mount option %s", key)
+		}
+	}
+	if m.Type == "" || m.Target == "" {
+		return m, fmt.Errorf("mount needs a type and a target")
+	}
+	return m, nil
+}
+
+func parseRestartPolicy(value string) (container.RestartPolicy, error) {
+	name, count, hasCount := strings.Cut(value, ":")
+	policy := container.RestartPolicy{Name: container.RestartPolicyMode(name)}
+	switch policy.Name {
+	case container.RestartPolicyDisabled, container.RestartPolicyAlways, container.RestartPolicyUnlessStopped:
+		if hasCount {
+			return policy, fmt.Errorf("only on-failure takes a retry count")
+		}
+	case container.RestartPolicyOnFailure:
+		if hasCount {
+			n, err := strconv.Atoi(count)
+			if err != nil {
+				return policy, err
+			}
+			policy.MaximumRetryCount = n
+		}
+	default:
+		return policy, fmt.Errorf("unknown restart policy")
+	}
+	return policy, nil
+}
+
+func gpuDeviceRequest(value string) (container.DeviceRequest, error) {
+	req := container.DeviceRequest{Capabilities: [][]string{{"gpu"}}}
+	value = strings.Trim(value, `"`)
+	switch {
+	case value == "all":
+		req.Count = -1
+	case strings.HasPrefix(value, "device="):
+		req.DeviceIDs = strings.Split(strings.TrimPrefix(value, "device="), ",")
+	default:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return req, err
+		}
+		req.Count = n
+	}
+	return req, nil
+}
+
+func applyHealthFlag(config *container.Config, flag, value string) error {
+	if config.Healthcheck == nil {
+		config.Healthcheck = &container.HealthConfig{}
+	}
+	hc := config.Healthcheck
+	var err error
+	switch flag {
+	case "--health-cmd":
+		hc.Test = []string{"CMD-SHELL", value}
+	case "--health-interval":
+		hc.Interval, err = time.ParseDuration(value)
+	case "--health-timeout":
+		hc.Timeout, err = time.ParseDuration(value)
+	case "--health-start-period":
+		hc.StartPeriod, err = time.ParseDuration(value)
+	case "--health-retries":
+		hc.Retries, err = strconv.Atoi(value)
+	}
+	return err
+}
+
+func (c *Client) apiCreate(ctx context.Context, spec *createSpec) (string, error) {
+	resp, err := c.api.ContainerCreate(ctx, spec.Config, spec.Host, nil, nil, spec.Name)
+	if client.IsErrNotFound(err) {
+		if err = c.apiPull(ctx, spec.Config.Image); err == nil {
+			resp, err = c.api.ContainerCreate(ctx, spec.Config, spec.Host, nil, nil, spec.Name)
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	return resp.ID, nil
+}
+
+func (c *Client) apiPull(ctx context.Context, ref string) error {
+	fmt.Printf("Pulling image %s...\n", ref)
+	body, err := c.api.ImagePull(ctx, ref, image.PullOptions{})
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	dec := json.NewDecoder(body)
+	for {
+		var msg struct {
+			Error string `json:"error"`
+		}
+		if err := dec.Decode(&msg); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if msg.Error != "" {
+			return errors.New(msg.Error)
+		}
+	}
+}
+
+func (c *Client) inspectBox(ctx context.Context, boxName string) (types.ContainerJSON, bool, error) {
+	info, err := c.api.ContainerInspect(ctx, boxName)
+	if err != nil {
+		if client.IsErrNotFound(err) {
+			return info, false, nil
+		}
+		return info, false, fmt.Errorf("failed to inspect box: %w", err)
+	}
+	return info, true, nil
+}
+
+func (c *Client) apiContainerStats(ctx context.Context, boxName string) (*ContainerStats, error) {
+	resp, err := c.api.ContainerStats(ctx, boxName, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stats: %w", err)
+	}
+	defer resp.Body.Close()
+	var s types.StatsJSON
+	if err := json.NewDecoder(resp.Body).Decode(&s); err != nil {
+		return nil, fmt.Errorf("failed to parse stats: %w", err)
+	}
+	return statsFromAPI(s), nil
+}
+
+func statsFromAPI(s types.StatsJSON) *ContainerStats {
+	cpu := 0.0
+	cpuDelta := float64(s.CPUStats.CPUUsage.TotalUsage) - float64(s.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(s.CPUStats.SystemUsage) - float64(s.PreCPUStats.SystemUsage)
+	online := float64(s.CPUStats.OnlineCPUs)
+	if online == 0 {
+		online = float64(len(s.CPUStats.CPUUsage.PercpuUsage))
+	}
+	if cpuDelta > 0 && systemDelta > 0 {
+		cpu = cpuDelta / systemDelta * online * 100
+	}
+
+	mem := float64(s.MemoryStats.Usage)
+	for _, key := range []string{"total_inactive_file", "inactive_file"} {
+		if v, ok := s.MemoryStats.Stats[key]; ok && float64(v) < mem {
+			mem -= float64(v)
+			break
+		}
+	}
+	limit := float64(s.MemoryStats.Limit)
+	memPercent := 0.0
+	if limit > 0 {
+		memPercent = mem / limit * 100
+	}
+
+	var rx, tx float64
+	for _, n := range s.Networks {
+		rx += float64(n.RxBytes)
+		tx += float64(n.TxBytes)
+	}
+	var read, write float64
+	for _, e := range s.BlkioStats.IoServiceBytesRecursive {
+		switch strings.ToLower(e.Op) {
+		case "read":
+			read += float64(e.Value)
+		case "write":
+			write += float64(e.Value)
+		}
+	}
+
+	return &ContainerStats{
+		CPUPercent: fmt.Sprintf("%.2f%%", cpu),
+		MemUsage:   units.BytesSize(mem) + " / " + units.BytesSize(limit),
+		MemPercent: fmt.Sprintf("%.2f%%", memPercent),
+		NetIO:      units.HumanSizeWithPrecision(rx, 3) + " / " + units.HumanSizeWithPrecision(tx, 3),
+		BlockIO:    units.HumanSizeWithPrecision(read, 3) + " / " + units.HumanSizeWithPrecision(write, 3),
+		PIDs:       strconv.FormatUint(s.PidsStats.Current, 10),
+	}
+}
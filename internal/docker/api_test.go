@@ -0,0 +1,235 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/client"
+	"github.com/docker/go-connections/nat"
+)
+
+func TestUseEngineAPI(t *testing.T) {
+	tests := []struct {
+		engine   string
+		endpoint string
+		want     bool
+	}{
+		{"docker", "", true},
+		{"/usr/local/bin/docker", "unix:///home/dev/.colima/default/docker.sock", true},
+		{"docker", "tcp://10.0.0.5:2376", true},
+		{"docker", "ssh://dev@build-host", false},
+		{"podman", "", false},
+		{"nerdctl", "unix:///run/containerd/containerd.sock", false},
+	}
+	for _, tt := range tests {
+		if got := useEngineAPI(tt.engine, tt.endpoint); got != tt.want {
+			t.Errorf("useEngineAPI(%q, %q) = %v, want %v", tt.engine, tt.endpoint, got, tt.want)
+		}
+	}
+}
+
+func TestCreateSpecFromArgs(t *testing.T) {
+	t.Setenv("HOST_ONLY", "from-host")
+	args := []string{
+		"create",
+		"--name", "devbox_api",
+		"--mount", "type=bind,source=/src/api,target=/workspace",
+		"--workdir", "/workspace",
+		"--label", "devbox.owner=1000",
+		"-it",
+		"--restart", "on-failure:3",
+		"-e", "APP_ENV=dev",
+		"-e", "DB_PASSWORD",
+		"-e", "HOST_ONLY",
+		"-e", "MISSING",
+		"-p", "127.0.0.1:5432:5432",
+		"-v", "/src/api/data:/data",
+		"-v", "/cache",
+		"--workdir", "/workspace/api",
+		"--user", "1000:1000",
+		"--cap-add", "SYS_PTRACE",
+		"--stop-timeout", "45",
+		"--network", "api_net",
+		"--cpus", "1.5",
+		"--memory", "4g",
+		"--gpus", `"device=0,1"`,
+		"--health-cmd", "curl -fsS http://localhost/health",
+		"--health-interval", "30s",
+		"--health-retries", "3",
+		"ubuntu:22.04", "sleep", "infinity",
+	}
+	spec, err := createSpecFromArgs(args, map[string]string{"DB_PASSWORD": "hunter2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, host := spec.Config, spec.Host
+	if spec.Name != "devbox_api" || cfg.Image != "ubuntu:22.04" || strings.Join(cfg.Cmd, " ") != "sleep infinity" {
+		t.Errorf("name/image/cmd = %q %q %q", spec.Name, cfg.Image, cfg.Cmd)
+	}
+	if cfg.WorkingDir != "/workspace/api" || cfg.User != "1000:1000" || !cfg.Tty || !cfg.OpenStdin {
+		t.Errorf("config = %+v", cfg)
+	}
+	if want := []string{"APP_ENV=dev", "DB_PASSWORD=hunter2", "HOST_ONLY=from-host"}; !reflect.DeepEqual(cfg.Env, want) {
+		t.Errorf("env = %q, want %q", cfg.Env, want)
+	}
+	if cfg.Labels["devbox.owner"] != "1000" {
+		t.Errorf("labels = %v", cfg.Labels)
+	}
+	if cfg.StopTimeout == nil || *cfg.StopTimeout != 45 {
+		t.Errorf("stop timeout = %v, want 45", cfg.StopTimeout)
+	}
+	if _, ok := cfg.Volumes["/cache"]; !ok || !reflect.DeepEqual(host.Binds, []string{"/src/api/data:/data"}) {
+		t.Errorf("volumes = %v binds = %v", cfg.Volumes, host.Binds)
+	}
+	if want := []mount.Mount{{Type: mount.TypeBind, Source: "/src/api", Target: "/workspace"}}; !reflect.DeepEqual(host.Mounts, want) {
+		t.Errorf("mounts = %+v", host.Mounts)
+	}
+	if want := (container.RestartPolicy{Name: container.RestartPolicyOnFailure, MaximumRetryCount: 3}); host.RestartPolicy != want {
+		t.Errorf("restart = %+v", host.RestartPolicy)
+	}
+	if host.NanoCPUs != 1500000000 || host.Memory != 4<<30 || host.NetworkMode != "api_net" {
+		t.Errorf("cpus = %d memory = %d network = %s", host.NanoCPUs, host.Memory, host.NetworkMode)
+	}
+	if len(host.DeviceRequests) != 1 || !reflect.DeepEqual(host.DeviceRequests[0].DeviceIDs, []string{"0", "1"}) {
+		t.Errorf("device requests = %+v", host.DeviceRequests)
+	}
+	if want := []nat.PortBinding{{HostIP: "127.0.0.1", HostPort: "5432"}}; !reflect.DeepEqual(host.PortBindings["5432/tcp"], want) {
+		t.Errorf("port bindings = %v", host.PortBindings)
+	}
+	hc := cfg.Healthcheck
+	if hc == nil || !reflect.DeepEqual(hc.Test, []string{"CMD-SHELL", "curl -fsS http://localhost/health"}) || hc.Interval != 30*time.Second || hc.Retries != 3 {
+		t.Errorf("healthcheck = %+v", hc)
+	}
+}
+
+func TestCreateSpecFromArgsRejects(t *testing.T) {
+	tests := [][]string{
+		{"run", "ubuntu:22.04"},
+		{"create", "--privileged", "ubuntu:22.04"},
+		{"create", "--name"},
+		{"create", "--name", "devbox_web"},
+		{"create", "--restart", "sometimes", "ubuntu:22.04"},
+		{"create", "--memory", "lots", "ubuntu:22.04"},
+		{"create", "--mount", "type=bind,source=/a,target=/b,bind-propagation=rshared", "ubuntu:22.04"},
+	}
+	for _, args := range tests {
+		if _, err := createSpecFromArgs(args, nil); err == nil {
+			t.Errorf("createSpecFromArgs(%q) succeeded, want an error so the CLI is used", args)
+		}
+	}
+}
+
+func TestCreateSpecFromGoldenArgs(t *testing.T) {
+	files, _ := filepath.Glob(filepath.Join("testdata", "create", "*.golden"))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		args := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+		if _, err := createSpecFromArgs(args, nil); err != nil {
+			t.Errorf("%s: %v", filepath.Base(file), err)
+		}
+	}
+}
+
+func TestStatsFromAPI(t *testing.T) {
+	var s types.StatsJSON
+	s.CPUStats.CPUUsage.TotalUsage = 3_000_000
+	s.PreCPUStats.CPUUsage.TotalUsage = 1_000_000
+	s.CPUStats.SystemUsage = 20_000_000
+	s.PreCPUStats.SystemUsage = 10_000_000
+	s.CPUStats.OnlineCPUs = 2
+	s.MemoryStats.Usage = 150 << 20
+	s.MemoryStats.Stats = map[string]uint64{"inactive_file": 50 << 20}
+	s.MemoryStats.Limit = 1 << 30
+	s.Networks = map[string]types.NetworkStats{"eth0": {RxBytes: 1500, TxBytes: 500}}
+	s.BlkioStats.IoServiceBytesRecursive = []types.BlkioStatEntry{{Op: "Read", Value: 4096}, {Op: "Write", Value: 0}}
+	s.PidsStats.Current = 7
+
+	want := &ContainerStats{
+		CPUPercent: "40.00%",
+		MemUsage:   "100MiB / 1GiB",
+		MemPercent: "9.77%",
+		NetIO:      "1.5kB / 500B",
+		BlockIO:    "4.1kB / 0B",
+		PIDs:       "7",
+	}
+	if got := statsFromAPI(s); !reflect.DeepEqual(got, want) {
+		t.Errorf("statsFromAPI() = %+v, want %+v", got, want)
+	}
+}
+
+func TestCreateContainerPullsMissingImage(t *testing.T) {
+	tests := []struct {
+		name      string
+		pullError string
+		wantID    string
+		wantPulls int
+	}{
+		{"pulls and retries", "", "api-box", 1},
+		{"falls back to the CLI", "pull access denied for private/app", "cli-box", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pulled, pulls := false, 0
+			daemon := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case strings.HasSuffix(r.URL.Path, "/containers/create"):
+					if !pulled {
+						w.WriteHeader(http.StatusNotFound)
+						fmt.Fprint(w, `{"message":"No such image: private/app:1.0"}`)
+						return
+					}
+					w.WriteHeader(http.StatusCreated)
+					fmt.Fprint(w, `{"Id":"api-box"}`)
+				case strings.HasSuffix(r.URL.Path, "/images/create"):
+					pulls++
+					if q := r.URL.Query(); q.Get("fromImage") != "private/app" || q.Get("tag") != "1.0" {
+						t.Errorf("pulled %s:%s, want private/app:1.0", q.Get("fromImage"), q.Get("tag"))
+					}
+					if tt.pullError != "" {
+						fmt.Fprintf(w, `{"status":"Pulling from private/app"}`+"\n"+`{"error":%q}`+"\n", tt.pullError)
+						return
+					}
+					pulled = true
+					fmt.Fprint(w, `{"status":"Downloaded newer image for private/app:1.0"}`+"\n")
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer daemon.Close()
+
+			api, err := client.NewClientWithOpts(client.WithHost("tcp://"+strings.TrimPrefix(daemon.URL, "http://")), client.WithVersion("1.45"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer api.Close()
+			script := filepath.Join(t.TempDir(), "engine")
+			if err := os.WriteFile(script, []byte("#!/bin/sh\necho cli-box\n"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			t.Setenv("DEVBOX_ENGINE", script)
+
+			args := []string{"create", "--name", "devbox_app", "private/app:1.0", "sleep", "infinity"}
+			id, err := (&Client{api: api}).createContainer(context.Background(), args, nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if id != tt.wantID || pulls != tt.wantPulls {
+				t.Errorf("createContainer() = %q after %d pulls, want %q after %d", id, pulls, tt.wantID, tt.wantPulls)
+			}
+		})
+	}
+}
@@ -3,28 +3,77 @@ package docker
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"devbox/internal/parallel"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
 )
 
-type Client struct{}
+type Client struct {
+	defaults       BoxDefaults
+	stopTimeout    time.Duration
+	workspaceSync  bool
+	sessions       map[string]*ExecSession
+	sessionsMu     sync.Mutex
+	secretResolver SecretResolver
+	metadata       BoxMetadataFunc
+	api            *client.Client
+}
+
+type BoxDefaults struct {
+	Environment map[string]string
+	CPUs        string
+	Memory      string
+	Restart     string
+}
+
+func (c *Client) SetBoxDefaults(defaults BoxDefaults) {
+	c.defaults = defaults
+}
+
+func (c *Client) SetStopTimeout(timeout time.Duration) {
+	c.stopTimeout = timeout
+}
 
 func NewClient() (*Client, error) {
-	return &Client{}, nil
+	return &Client{api: newAPIClient()}, nil
 }
 
 func (c *Client) Close() error {
+	if c.api != nil {
+		return c.api.Close()
+	}
 	return nil
 }
 
+const OwnerLabel = "devbox.owner"
+
+const StopTimeoutLabel = "devbox.stop-timeout"
+
+const defaultStopTimeout = 2 * time.Second
+
+const PackageMarkerPath = "/tmp/.devbox-pkg-changed"
+
+const HistoryFilePath = "/workspace/.devbox/history"
+
+func CurrentOwner() string {
+	return strconv.Itoa(os.Getuid())
+}
+
 func dockerCmd() string {
 	if eng := strings.TrimSpace(os.Getenv("DEVBOX_ENGINE")); eng != "" {
 		return eng
@@ -41,21 +90,57 @@ func IsDockerAvailable() error {
 }
 
 func (c *Client) PullImage(image string) error {
-	cmd := exec.Command(dockerCmd(), "images", "-q", image)
-	output, err := cmd.Output()
-	if err == nil && len(strings.TrimSpace(string(output))) > 0 {
+	if c.imageExists(image) {
 		return nil
 	}
 
 	fmt.Printf("Pulling image %s...\n", image)
-	cmd = exec.Command(dockerCmd(), "pull", image)
+	if err := c.pull(image); err != nil {
+		return fmt.Errorf("failed to pull image %s: %w", image, err)
+	}
+	return nil
+}
+
+type BuildOptions struct {
+	Tag        string
+	ContextDir string
+	Dockerfile string
+	Args       map[string]string
+	Target     string
+	NoCache    bool
+	Pull       bool
+}
+
+func BuildArgs(opts BuildOptions) []string {
+	args := []string{"build", "--tag", opts.Tag, "--file", opts.Dockerfile, "--label", fmt.Sprintf("%s=%s", OwnerLabel, CurrentOwner())}
+	keys := make([]string, 0, len(opts.Args))
+	for k := range opts.Args {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		args = append(args, "--build-arg", fmt.Sprintf("%s=%s", k, opts.Args[k]))
+	}
+	if opts.Target != "" {
+		args = append(args, "--target", opts.Target)
+	}
+	if opts.NoCache {
+		args = append(args, "--no-cache")
+	}
+	if opts.Pull {
+		args = append(args, "--pull")
+	}
+	return append(args, opts.ContextDir)
+}
+
+func (c *Client) BuildImage(opts BuildOptions) error {
+	fmt.Printf("Building image %s from %s...\n", opts.Tag, opts.Dockerfile)
+	cmd := exec.Command(dockerCmd(), BuildArgs(opts)...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
-
 	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to pull image %s: %w", image, err)
+		return fmt.Errorf("failed to build image %s: %w", opts.Tag, err)
 	}
-
 	return nil
 }
 
@@ -64,34 +149,63 @@ func (c *Client) CreateBox(name, image, workspaceHost, workspaceBox string) (str
 }
 
 func (c *Client) CreateBoxWithConfig(name, image, workspaceHost, workspaceBox string, projectConfig interface{}) (string, error) {
-	args := []string{
-		"create",
-		"--name", name,
-		"--mount", fmt.Sprintf("type=bind,source=%s,target=%s", workspaceHost, workspaceBox),
-		"--workdir", workspaceBox,
-		"-it",
-	}
+	return c.CreateBoxWithConfigContext(context.Background(), name, image, workspaceHost, workspaceBox, projectConfig)
+}
 
-	if projectConfig != nil {
-		if config, ok := projectConfig.(map[string]interface{}); ok {
-			args = c.applyProjectConfigToArgs(args, config)
+func (c *Client) CreateBoxWithConfigContext(ctx context.Context, name, image, workspaceHost, workspaceBox string, projectConfig interface{}) (string, error) {
+	config, _ := projectConfig.(map[string]interface{})
+	if gpus, _ := config["gpus"].(string); strings.TrimSpace(gpus) != "" {
+		if err := CheckGPUSupport(); err != nil {
+			return "", fmt.Errorf("gpus '%s' requested but GPU support is missing: %w", strings.TrimSpace(gpus), err)
 		}
 	}
+	seedVolume := (c.workspaceSync || SyncedWorkspace(config)) && !c.volumeExists(WorkspaceVolumeName(name))
 
-	hasRestart := false
-	for i := 0; i < len(args); i++ {
-		if args[i] == "--restart" {
-			hasRestart = true
-			break
+	secrets := &SecretValues{}
+	if _, ok := config["secrets"]; ok && c.secretResolver != nil {
+		resolved, err := c.secretResolver(name, workspaceHost, config)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve secrets: %w", err)
 		}
+		secrets = resolved
 	}
-	if !hasRestart {
-		args = append(args, "--restart", "unless-stopped")
+
+	boxID, err := c.createContainer(ctx, c.CreateArgs(name, image, workspaceHost, workspaceBox, config), config, secrets.Env)
+	if err != nil {
+		return "", err
+	}
+	if err := c.copySecretFiles(name, secrets.Files, config); err != nil {
+		return boxID, err
+	}
+	if seedVolume {
+		fmt.Printf("Copying %s to the workspace volume...\n", workspaceHost)
+		if err := c.SyncWorkspaceToBox(name, workspaceHost, workspaceBox); err != nil {
+			return boxID, err
+		}
 	}
+	return boxID, nil
+}
 
-	args = append(args, image, "sleep", "infinity")
+func (c *Client) createContainer(ctx context.Context, args []string, config map[string]interface{}, secretEnv map[string]string) (string, error) {
+	if c.api != nil {
+		if spec, err := createSpecFromArgs(args, secretEnv); err == nil {
+			id, err := c.apiCreate(ctx, spec)
+			if err == nil {
+				return id, nil
+			}
+			if ctx.Err() != nil {
+				return "", fmt.Errorf("failed to create box: %w", err)
+			}
+		}
+	}
 
-	cmd := exec.Command(dockerCmd(), args...)
+	cmd := exec.CommandContext(ctx, dockerCmd(), args...)
+	if len(secretEnv) > 0 {
+		cmd.Env = os.Environ()
+		for _, key := range SecretEnvNames(config) {
+			cmd.Env = append(cmd.Env, key+"="+secretEnv[key])
+		}
+	}
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
@@ -103,24 +217,130 @@ func (c *Client) CreateBoxWithConfig(name, image, workspaceHost, workspaceBox st
 		}
 		return "", fmt.Errorf("failed to create box: %w", err)
 	}
+	return strings.TrimSpace(stdout.String()), nil
+}
 
-	boxID := strings.TrimSpace(stdout.String())
-	return boxID, nil
+func (c *Client) CreateArgs(name, image, workspaceHost, workspaceBox string, config map[string]interface{}) []string {
+	mount := fmt.Sprintf("type=bind,source=%s,target=%s", workspaceHost, workspaceBox)
+	if c.workspaceSync || SyncedWorkspace(config) {
+		mount = fmt.Sprintf("type=volume,source=%s,target=%s,volume-label=%s=%s", WorkspaceVolumeName(name), workspaceBox, OwnerLabel, CurrentOwner())
+	}
+	args := []string{
+		"create",
+		"--name", name,
+		"--mount", mount,
+		"--workdir", workspaceBox,
+		"--label", fmt.Sprintf("%s=%s", OwnerLabel, CurrentOwner()),
+		"-it",
+	}
+	if c.metadata != nil {
+		args = append(args, metadataArgs(c.metadata(name, workspaceHost, config))...)
+	}
+	if u, ok := hostBoxUser(config); ok {
+		args = append(args, "--label", fmt.Sprintf("%s=%s", UserLabel, u))
+	}
+
+	if config = c.defaults.apply(config); config != nil {
+		args = c.applyProjectConfigToArgs(args, config, workspaceHost)
+	}
+	if restart, _ := config["restart"].(string); restart == "" {
+		args = append(args, "--restart", "unless-stopped")
+	}
+
+	return append(args, image, "sleep", "infinity")
+}
+
+func dotfilesMount(index int) string {
+	if index == 0 {
+		return "/dotfiles"
+	}
+	return fmt.Sprintf("/dotfiles-%d", index+1)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func ResolveVolume(volume, workspaceHost string) string {
+	host, rest, ok := strings.Cut(volume, ":")
+	if !ok {
+		return volume
+	}
+	switch {
+	case host == "~" || strings.HasPrefix(host, "~/"):
+		if home, err := os.UserHomeDir(); err == nil {
+			host = filepath.Join(home, strings.TrimPrefix(host, "~"))
+		}
+	case host == "." || host == ".." || strings.HasPrefix(host, "./") || strings.HasPrefix(host, "../"):
+		if workspaceHost != "" {
+			host = filepath.Join(workspaceHost, host)
+		}
+	}
+	return host + ":" + rest
+}
+
+func (d BoxDefaults) apply(config map[string]interface{}) map[string]interface{} {
+	if len(d.Environment) == 0 && d.CPUs == "" && d.Memory == "" && d.Restart == "" {
+		return config
+	}
+	merged := map[string]interface{}{}
+	for k, v := range config {
+		merged[k] = v
+	}
+
+	env := map[string]interface{}{}
+	for k, v := range d.Environment {
+		env[k] = v
+	}
+	if projectEnv, ok := config["environment"].(map[string]interface{}); ok {
+		for k, v := range projectEnv {
+			env[k] = v
+		}
+	}
+	if len(env) > 0 {
+		merged["environment"] = env
+	}
+
+	resources := map[string]interface{}{}
+	if projectResources, ok := config["resources"].(map[string]interface{}); ok {
+		for k, v := range projectResources {
+			resources[k] = v
+		}
+	}
+	if cpus, _ := resources["cpus"].(string); cpus == "" && d.CPUs != "" {
+		resources["cpus"] = d.CPUs
+	}
+	if memory, _ := resources["memory"].(string); memory == "" && d.Memory != "" {
+		resources["memory"] = d.Memory
+	}
+	if len(resources) > 0 {
+		merged["resources"] = resources
+	}
+	if restart, _ := config["restart"].(string); restart == "" && d.Restart != "" {
+		merged["restart"] = d.Restart
+	}
+	return merged
 }
 
-func (c *Client) applyProjectConfigToArgs(args []string, config map[string]interface{}) []string {
+func (c *Client) applyProjectConfigToArgs(args []string, config map[string]interface{}, workspaceHost string) []string {
 
 	if restart, ok := config["restart"].(string); ok && restart != "" {
 		args = append(args, "--restart", restart)
 	}
 
 	if env, ok := config["environment"].(map[string]interface{}); ok {
-		for key, value := range env {
-			if valueStr, ok := value.(string); ok {
+		for _, key := range sortedKeys(env) {
+			if valueStr, ok := env[key].(string); ok {
 				args = append(args, "-e", fmt.Sprintf("%s=%s", key, valueStr))
 			}
 		}
 	}
+	args = append(args, secretArgs(config)...)
 
 	if ports, ok := config["ports"].([]interface{}); ok {
 		for _, port := range ports {
@@ -133,17 +353,16 @@ func (c *Client) applyProjectConfigToArgs(args []string, config map[string]inter
 	if volumes, ok := config["volumes"].([]interface{}); ok {
 		for _, volume := range volumes {
 			if volumeStr, ok := volume.(string); ok {
-				if strings.HasPrefix(volumeStr, "~") {
-					if home, err := os.UserHomeDir(); err == nil {
-						volumeStr = filepath.Join(home, strings.TrimPrefix(volumeStr, "~"))
-					}
+				if c.workspaceSync && localMountSource(volumeStr) {
+					fmt.Printf("Warning: volume '%s' is resolved on the remote Docker host, not this machine\n", volumeStr)
 				}
-				args = append(args, "-v", volumeStr)
+				args = append(args, "-v", ResolveVolume(volumeStr, workspaceHost))
 			}
 		}
 	}
 
 	if dotfiles, ok := config["dotfiles"].([]interface{}); ok {
+		seen := map[string]bool{}
 		for _, item := range dotfiles {
 			pathStr, ok := item.(string)
 			if !ok || pathStr == "" {
@@ -155,8 +374,14 @@ func (c *Client) applyProjectConfigToArgs(args []string, config map[string]inter
 					host = filepath.Join(home, strings.TrimPrefix(host, "~"))
 				}
 			}
-			args = append(args, "-v", fmt.Sprintf("%s:%s", host, "/dotfiles"))
-			break
+			if seen[host] {
+				continue
+			}
+			if c.workspaceSync {
+				fmt.Printf("Warning: dotfiles '%s' are mounted from the remote Docker host, not this machine\n", pathStr)
+			}
+			args = append(args, "-v", fmt.Sprintf("%s:%s", host, dotfilesMount(len(seen))))
+			seen[host] = true
 		}
 	}
 
@@ -164,7 +389,7 @@ func (c *Client) applyProjectConfigToArgs(args []string, config map[string]inter
 		args = append(args, "--workdir", workingDir)
 	}
 
-	if user, ok := config["user"].(string); ok && user != "" {
+	if user, ok := config["user"].(string); ok && user != "" && !CreatesUser(config) {
 		args = append(args, "--user", user)
 	}
 
@@ -177,13 +402,22 @@ func (c *Client) applyProjectConfigToArgs(args []string, config map[string]inter
 	}
 
 	if labels, ok := config["labels"].(map[string]interface{}); ok {
-		for key, value := range labels {
-			if valueStr, ok := value.(string); ok {
+		for _, key := range sortedKeys(labels) {
+			if valueStr, ok := labels[key].(string); ok {
 				args = append(args, "--label", fmt.Sprintf("%s=%s", key, valueStr))
 			}
 		}
 	}
 
+	if timeouts, ok := config["timeouts"].(map[string]interface{}); ok {
+		if stop, _ := timeouts["stop"].(string); stop != "" {
+			if d, err := time.ParseDuration(strings.TrimSpace(stop)); err == nil && d > 0 {
+				seconds := strconv.Itoa(durationSeconds(d))
+				args = append(args, "--stop-timeout", seconds, "--label", fmt.Sprintf("%s=%s", StopTimeoutLabel, seconds))
+			}
+		}
+	}
+
 	if network, ok := config["network"].(string); ok && network != "" {
 		args = append(args, "--network", network)
 	}
@@ -198,33 +432,71 @@ func (c *Client) applyProjectConfigToArgs(args []string, config map[string]inter
 	}
 
 	if gpus, ok := config["gpus"].(string); ok && strings.TrimSpace(gpus) != "" {
-		args = append(args, "--gpus", strings.TrimSpace(gpus))
+		args = append(args, "--gpus", GPUFlag(gpus))
 	}
 
 	if healthCheck, ok := config["health_check"].(map[string]interface{}); ok {
-		if test, ok := healthCheck["test"].([]interface{}); ok && len(test) > 0 {
-			var testArgs []string
-			for _, t := range test {
-				if testStr, ok := t.(string); ok {
-					testArgs = append(testArgs, testStr)
-				}
-			}
-			if len(testArgs) > 0 {
-				args = append(args, "--health-cmd", strings.Join(testArgs, " "))
+		args = append(args, healthCheckArgs(healthCheck)...)
+	}
+
+	return args
+}
+
+func healthCheckArgs(healthCheck map[string]interface{}) []string {
+	var testArgs []string
+	if test, ok := healthCheck["test"].([]interface{}); ok {
+		for _, t := range test {
+			if testStr, ok := t.(string); ok {
+				testArgs = append(testArgs, testStr)
 			}
 		}
-		if interval, ok := healthCheck["interval"].(string); ok && interval != "" {
-			args = append(args, "--health-interval", interval)
-		}
-		if timeout, ok := healthCheck["timeout"].(string); ok && timeout != "" {
-			args = append(args, "--health-timeout", timeout)
-		}
-		if retries, ok := healthCheck["retries"].(float64); ok && retries > 0 {
-			args = append(args, "--health-retries", fmt.Sprintf("%.0f", retries))
+	}
+	if len(testArgs) > 0 && testArgs[0] == "NONE" {
+		return []string{"--no-healthcheck"}
+	}
+	var args []string
+	if cmd := healthCmd(testArgs); cmd != "" {
+		args = append(args, "--health-cmd", cmd)
+	}
+	if interval, ok := healthCheck["interval"].(string); ok && interval != "" {
+		args = append(args, "--health-interval", interval)
+	}
+	if timeout, ok := healthCheck["timeout"].(string); ok && timeout != "" {
+		args = append(args, "--health-timeout", timeout)
+	}
+	if startPeriod, ok := healthCheck["start_period"].(string); ok && startPeriod != "" {
+		args = append(args, "--health-start-period", startPeriod)
+	}
+	if retries, ok := healthCheck["retries"].(float64); ok && retries > 0 {
+		args = append(args, "--health-retries", fmt.Sprintf("%.0f", retries))
+	}
+	return args
+}
+
+func healthCmd(test []string) string {
+	if len(test) == 0 {
+		return ""
+	}
+	switch test[0] {
+	case "CMD-SHELL":
+		return strings.Join(test[1:], " ")
+	case "CMD":
+		quoted := make([]string, 0, len(test)-1)
+		for _, arg := range test[1:] {
+			quoted = append(quoted, shellWord(arg))
 		}
+		return strings.Join(quoted, " ")
 	}
+	return strings.Join(test, " ")
+}
 
-	return args
+func shellWord(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:=@%+,", r))
+	}) == -1 {
+		return s
+	}
+	return shellQuote(s)
 }
 
 func (c *Client) ExecuteSetupCommands(boxName string, commands []string) error {
@@ -240,23 +512,29 @@ func (c *Client) ExecuteSetupCommandsWithOutput(boxName string, commands []strin
 		fmt.Printf("Executing setup commands in box '%s'...\n", boxName)
 	}
 
+	logger := parallel.NewStepLogger(c.GetWorkspacePath(boxName))
+
 	config := parallel.LoadConfig()
 	if config.EnableParallel {
 
 		executor := parallel.NewSetupCommandExecutor(boxName, showOutput, config.SetupCommandWorkers)
+		executor.SetStepLogger(logger)
 		if err := executor.ExecuteParallel(commands); err != nil {
 
 			fmt.Printf("Parallel execution failed, falling back to sequential: %v\n", err)
-			return c.ExecuteSetupCommandsSequential(boxName, commands, showOutput)
+			return c.executeSetupCommandsSequential(boxName, commands, showOutput, logger)
 		}
 	} else {
 
-		return c.ExecuteSetupCommandsSequential(boxName, commands, showOutput)
+		return c.executeSetupCommandsSequential(boxName, commands, showOutput, logger)
 	}
 
 	if showOutput {
 		fmt.Printf("Setup commands completed successfully!\n")
 	}
+	if dir := logger.Dir(); dir != "" && showOutput {
+		fmt.Printf("Step logs: %s\n", dir)
+	}
 	return nil
 }
 
@@ -264,41 +542,56 @@ func (c *Client) ExecuteSetupCommandsSequential(boxName string, commands []strin
 	if len(commands) == 0 {
 		return nil
 	}
+	return c.executeSetupCommandsSequential(boxName, commands, showOutput, parallel.NewStepLogger(c.GetWorkspacePath(boxName)))
+}
+
+func (c *Client) executeSetupCommandsSequential(boxName string, commands []string, showOutput bool, logger *parallel.StepLogger) error {
+	if len(commands) == 0 {
+		return nil
+	}
 
 	if showOutput {
 		fmt.Printf("Executing setup commands in box '%s'...\n", boxName)
 	}
 
+	timeout := parallel.LoadConfig().SetupTimeout
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	start := time.Now()
+
 	for i, command := range commands {
 		if showOutput {
 			fmt.Printf("Step %d/%d: %s\n", i+1, len(commands), command)
 		}
 
-		wrapped := ". /root/.bashrc >/dev/null 2>&1 || true; " + command
-		cmd := exec.Command(dockerCmd(), "exec", boxName, "bash", "-lc", wrapped)
+		wrapped := parallel.WithShellInit(parallel.WrapAptLock(command), false)
+		cmd := exec.CommandContext(ctx, dockerCmd(), "exec", boxName, "bash", "-c", wrapped)
 
+		var output, stderr bytes.Buffer
 		if showOutput {
-			cmd.Stdout = os.Stdout
-			cmd.Stderr = os.Stderr
-
-			if err := cmd.Run(); err != nil {
-				return fmt.Errorf("setup command failed: %s: %w", command, err)
-			}
+			cmd.Stdout = io.MultiWriter(os.Stdout, &output)
+			cmd.Stderr = io.MultiWriter(os.Stderr, &output, &stderr)
 		} else {
-			var stdout, stderr bytes.Buffer
-			cmd.Stdout = &stdout
-			cmd.Stderr = &stderr
+			cmd.Stdout = &output
+			cmd.Stderr = io.MultiWriter(&output, &stderr)
+		}
 
-			if err := cmd.Run(); err != nil {
+		err := cmd.Run()
+		if ctx.Err() != nil {
+			err = fmt.Errorf("%w after %s (step %d/%d); raise timeouts.setup in devbox.json or settings", parallel.ErrTaskTimeout, time.Since(start).Round(time.Second), i+1, len(commands))
+		}
+		logPath := logger.Write("step", i+1, command, output.String(), err)
+		if err != nil {
+			if lockErr := parallel.AptLockError(boxName, command, stderr.String(), err); lockErr != nil {
+				return parallel.WithLogPath(lockErr, logPath)
+			}
+			if !showOutput {
 				fmt.Printf("Command failed: %s\n", command)
-				if stderr.Len() > 0 {
-					fmt.Printf("Error output: %s\n", stderr.String())
+				if output.Len() > 0 {
+					fmt.Printf("Output: %s\n", output.String())
 				}
-				if stdout.Len() > 0 {
-					fmt.Printf("Standard output: %s\n", stdout.String())
-				}
-				return fmt.Errorf("setup command failed: %s: %w", command, err)
 			}
+			return parallel.WithLogPath(fmt.Errorf("setup command failed: %s: %w", command, err), logPath)
 		}
 	}
 
@@ -308,32 +601,86 @@ func (c *Client) ExecuteSetupCommandsSequential(boxName string, commands []strin
 	return nil
 }
 
+func (c *Client) GetWorkspacePath(boxName string) string {
+	template := `{{.Config.WorkingDir}}{{"\n"}}{{range .Mounts}}{{if eq .Type "bind"}}{{.Source}}|{{.Destination}}{{"\n"}}{{end}}{{end}}`
+	out, err := exec.Command(dockerCmd(), "inspect", "--format", template, boxName).Output()
+	if err != nil {
+		return ""
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(lines) == 0 {
+		return ""
+	}
+	workDir := strings.TrimSpace(lines[0])
+	fallback := ""
+	for _, line := range lines[1:] {
+		parts := strings.SplitN(strings.TrimSpace(line), "|", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		if parts[1] == workDir && workDir != "" {
+			return parts[0]
+		}
+		if parts[1] == "/workspace" {
+			fallback = parts[0]
+		}
+	}
+	return fallback
+}
+
 func (c *Client) QueryPackagesParallel(boxName string) (aptList, pipList, npmList, yarnList, pnpmList []string) {
+	lists := c.QueryPackageLists(boxName, nil)
+	return lists["apt"], lists["pip"], lists["npm"], lists["yarn"], lists["pnpm"]
+}
+
+func (c *Client) QueryPackageLists(boxName string, managers []string) map[string][]string {
+	queries := parallel.SelectPackageQueries(managers)
+	if len(queries) == 0 {
+		return map[string][]string{}
+	}
 	config := parallel.LoadConfig()
 	if !config.EnableParallel {
 
-		return c.queryPackagesSequential(boxName)
+		return c.queryPackagesSequential(boxName, queries)
 	}
 
-	executor := parallel.NewPackageQueryExecutor(boxName)
+	executor := parallel.NewPackageQueryExecutor(boxName, config.PackageQueryWorkers)
 
-	packageLists, err := executor.QueryAllPackages()
+	packageLists, err := executor.QueryPackages(queries)
 	if err != nil {
 		fmt.Printf("Warning: parallel package query failed, falling back to sequential: %v\n", err)
 
-		return c.queryPackagesSequential(boxName)
+		return c.queryPackagesSequential(boxName, queries)
 	}
 
-	return packageLists["apt"], packageLists["pip"], packageLists["npm"], packageLists["yarn"], packageLists["pnpm"]
+	return packageLists
 }
 
-func (c *Client) queryPackagesSequential(boxName string) (aptList, pipList, npmList, yarnList, pnpmList []string) {
-
-	return nil, nil, nil, nil, nil
+func (c *Client) queryPackagesSequential(boxName string, queries []parallel.PackageQuery) map[string][]string {
+	lists := map[string][]string{}
+	for _, query := range queries {
+		out, _, err := c.ExecCapture(boxName, query.Command)
+		if err != nil {
+			fmt.Printf("Warning: failed to query %s packages: %v\n", query.Name, err)
+			continue
+		}
+		lists[query.Name] = parallel.ParsePackageQuery(query.Name, out)
+	}
+	return lists
 }
 
 func (c *Client) StartBox(boxID string) error {
-	cmd := exec.Command(dockerCmd(), "start", boxID)
+	return c.StartBoxContext(context.Background(), boxID)
+}
+
+func (c *Client) StartBoxContext(ctx context.Context, boxID string) error {
+	if c.api != nil {
+		if err := c.api.ContainerStart(ctx, boxID, container.StartOptions{}); err != nil {
+			return fmt.Errorf("failed to start box: %w", err)
+		}
+		return nil
+	}
+	cmd := exec.CommandContext(ctx, dockerCmd(), "start", boxID)
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 
@@ -356,6 +703,15 @@ func (c *Client) SetupDevboxInBoxWithUpdate(boxName, projectName string) error {
 }
 
 func (c *Client) setupDevboxInBoxWithOptions(boxName, projectName string, forceUpdate bool) error {
+	home := "/root"
+	owner := ""
+	if u, ok := c.GetBoxUser(boxName); ok {
+		if err := c.EnsureBoxUser(boxName, u); err != nil {
+			return err
+		}
+		home = u.Home()
+		owner = u.Spec()
+	}
 
 	checkCmd := exec.Command(dockerCmd(), "exec", boxName, "test", "-f", "/etc/devbox-initialized")
 	isFirstTime := checkCmd.Run() != nil
@@ -390,6 +746,9 @@ case "$1" in
         echo "  devbox status   - Show box information"
         echo "  devbox help     - Show this help"
         echo "  devbox host     - Run command on host (experimental)"
+        echo "  devbox lock     - Regenerate devbox.lock.json (via host bridge)"
+        echo "  devbox verify   - Verify the box against devbox.lock.json (via host bridge)"
+        echo "  devbox apply    - Apply devbox.lock.json (via host bridge)"
         ;;
 	"help"|"--help"|"-h")
 		echo "Devbox box commands"
@@ -399,6 +758,10 @@ case "$1" in
         echo "  devbox status       - Show box and project information"
         echo "  devbox help         - Show this help message"
         echo "  devbox host <cmd>   - Execute command on host (experimental)"
+        echo "  devbox lock [--show|--fs-manifest|--no-cache]"
+        echo "                      - Regenerate devbox.lock.json on the host"
+        echo "  devbox verify       - Verify the box against devbox.lock.json"
+        echo "  devbox apply        - Apply devbox.lock.json to the box"
         echo ""
 	echo "Your project files are in: /workspace"
 	echo "You are in an Ubuntu box with full package management"
@@ -423,8 +786,34 @@ case "$1" in
 		echo "error: host command execution not yet implemented"
 		echo "hint: Exit the box and run commands on the host instead"
         ;;
+    "lock"|"verify"|"apply")
+		if [ -z "$DEVBOX_BRIDGE" ] || [ -z "$DEVBOX_BRIDGE_TOKEN" ]; then
+			echo "error: 'devbox $1' inside the box needs the host bridge, which 'devbox shell' provides"
+			echo "hint: Run 'devbox $1 $PROJECT_NAME' on the host instead"
+			exit 1
+		fi
+		req="$DEVBOX_BRIDGE_TOKEN"
+		for arg in "$@"; do
+			req="$req"$'\t'"$arg"
+		done
+		if ! { exec 3<>"/dev/tcp/${DEVBOX_BRIDGE%:*}/${DEVBOX_BRIDGE##*:}"; } 2>/dev/null; then
+			echo "error: cannot reach the devbox host bridge at $DEVBOX_BRIDGE"
+			echo "hint: The bridge only runs while the 'devbox shell' session that opened this shell is attached"
+			exit 1
+		fi
+		printf '%s\n' "$req" >&3
+		code=1
+		while IFS= read -r line <&3; do
+			case "$line" in
+				"__DEVBOX_BRIDGE_EXIT__ "*) code="${line#__DEVBOX_BRIDGE_EXIT__ }"; break ;;
+				*) printf '%s\n' "$line" ;;
+			esac
+		done
+		exec 3<&-
+		exit "$code"
+		;;
     "version")
-        echo "devbox box wrapper v1.0"
+        echo "devbox box wrapper v1.1"
         echo "Box: $BOX_NAME"
         echo "Project: $PROJECT_NAME"
         ;;
@@ -437,7 +826,7 @@ case "$1" in
 		echo "hint: Use \"devbox help\" to see available commands inside the box"
         echo ""
         echo "Available commands:"
-        echo "  exit, status, help, host, version"
+        echo "  exit, status, help, host, lock, verify, apply, version"
         echo ""
         echo "Note: 'devbox exit' is handled by the shell function for proper exit behavior"
         exit 1
@@ -454,13 +843,14 @@ chmod +x /usr/local/bin/devbox`
 		return fmt.Errorf("failed to install devbox wrapper in box: %w", err)
 	}
 
+	bashrc := home + "/.bashrc"
 	welcomeCmd := `# Remove any existing devbox configurations
-sed -i '/# Devbox welcome message/,/^$/d' /root/.bashrc 2>/dev/null || true
-sed -i '/devbox_exit()/,/^}$/d' /root/.bashrc 2>/dev/null || true
-sed -i '/devbox() {/,/^}$/d' /root/.bashrc 2>/dev/null || true
-	sed -i '/# Devbox package tracking start/,/# Devbox package tracking end/d' /root/.bashrc 2>/dev/null || true
+sed -i '/# Devbox welcome message/,/^$/d' ` + bashrc + ` 2>/dev/null || true
+sed -i '/devbox_exit()/,/^}$/d' ` + bashrc + ` 2>/dev/null || true
+sed -i '/devbox() {/,/^}$/d' ` + bashrc + ` 2>/dev/null || true
+	sed -i '/# Devbox package tracking start/,/# Devbox package tracking end/d' ` + bashrc + ` 2>/dev/null || true
 
-cat >> /root/.bashrc << 'BASHRC_EOF'
+cat >> ` + bashrc + ` << 'BASHRC_EOF'
 
 if [ -t 1 ]; then
 	echo "Welcome to devbox project: ` + projectName + `"
@@ -470,25 +860,26 @@ if [ -t 1 ]; then
     echo ""
 fi
 
-if [ -d "/dotfiles" ]; then
-	if [ -f "/dotfiles/.bashrc" ]; then
-		. /dotfiles/.bashrc
+for dotfiles in /dotfiles /dotfiles-*; do
+	[ -d "$dotfiles" ] || continue
+	if [ -f "$dotfiles/.bashrc" ]; then
+		. "$dotfiles/.bashrc"
 	fi
 	for f in .gitconfig .vimrc .zshrc .bash_profile; do
-		if [ -f "/dotfiles/$f" ]; then
-			ln -sf "/dotfiles/$f" "/root/$f"
+		if [ -f "$dotfiles/$f" ]; then
+			ln -sf "$dotfiles/$f" "$HOME/$f"
 		fi
 	done
-	if [ -d "/dotfiles/.config" ]; then
-		mkdir -p /root/.config
-		for item in /dotfiles/.config/*; do
+	if [ -d "$dotfiles/.config" ]; then
+		mkdir -p "$HOME/.config"
+		for item in "$dotfiles"/.config/*; do
 			base=$(basename "$item")
-			if [ ! -e "/root/.config/$base" ]; then
-				ln -s "$item" "/root/.config/$base"
+			if [ ! -e "$HOME/.config/$base" ] || [ -L "$HOME/.config/$base" ]; then
+				ln -sfn "$item" "$HOME/.config/$base"
 			fi
 		done
 	fi
-fi
+done
 
 devbox_exit() {
 	echo "Exiting devbox shell for project \"` + projectName + `\""
@@ -503,10 +894,25 @@ devbox() {
     /usr/local/bin/devbox "$@"
 }
 
-export DEVBOX_LOCKFILE="${DEVBOX_LOCKFILE:-/workspace/devbox.lock}"
+export DEVBOX_LOCKFILE="${DEVBOX_LOCKFILE:-/workspace/.devbox/journal}"
+export DEVBOX_HISTFILE="${DEVBOX_HISTFILE-` + HistoryFilePath + `}"
+
+if [ -n "$DEVBOX_HISTFILE" ] && mkdir -p "$(dirname "$DEVBOX_HISTFILE")" 2>/dev/null && [ -w "$(dirname "$DEVBOX_HISTFILE")" ]; then
+	HISTFILE="$DEVBOX_HISTFILE"
+	HISTSIZE="${DEVBOX_HISTSIZE:-10000}"
+	HISTFILESIZE="${DEVBOX_HISTSIZE:-10000}"
+	HISTCONTROL=ignoreboth
+	shopt -s histappend
+	case "$PROMPT_COMMAND" in
+		*"history -a"*) ;;
+		*) PROMPT_COMMAND="history -a${PROMPT_COMMAND:+; $PROMPT_COMMAND}" ;;
+	esac
+fi
 
 devbox_record_cmd() {
 	local cmd="$1"
+	date +%s%N > ` + PackageMarkerPath + ` 2>/dev/null || true
+	[ -n "$DEVBOX_LOCKFILE" ] && mkdir -p "$(dirname "$DEVBOX_LOCKFILE")" 2>/dev/null
 	if [ -n "$DEVBOX_LOCKFILE" ] && [ -w "$(dirname "$DEVBOX_LOCKFILE")" ]; then
 		if [ ! -f "$DEVBOX_LOCKFILE" ] || ! grep -Fxq "$cmd" "$DEVBOX_LOCKFILE" 2>/dev/null; then
 			echo "$cmd" >> "$DEVBOX_LOCKFILE"
@@ -591,6 +997,9 @@ yarn()     { _devbox_wrap_and_record "$YARN_BIN" yarn "$@"; }
 pnpm()     { _devbox_wrap_and_record "$PNPM_BIN" pnpm "$@"; }
 corepack(){ _devbox_wrap_and_record "$COREPACK_BIN" corepack "$@"; }
 BASHRC_EOF`
+	if owner != "" {
+		welcomeCmd += "\nchown " + owner + " " + bashrc
+	}
 
 	cmd = exec.Command(dockerCmd(), "exec", boxName, "bash", "-c", welcomeCmd)
 	if err := cmd.Run(); err != nil {
@@ -602,17 +1011,23 @@ BASHRC_EOF`
 }
 
 func (c *Client) StopBox(boxName string) error {
+	return c.StopBoxContext(context.Background(), boxName)
+}
 
-	timeoutSec := 2
-	if v := strings.TrimSpace(os.Getenv("DEVBOX_STOP_TIMEOUT")); v != "" {
-		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
-			timeoutSec = n
+func (c *Client) StopBoxContext(ctx context.Context, boxName string) error {
+	timeout := c.stopTimeoutSeconds(ctx, boxName)
+	if c.api != nil {
+		if err := c.api.ContainerStop(ctx, boxName, container.StopOptions{Timeout: &timeout}); err != nil {
+			if killErr := c.api.ContainerKill(ctx, boxName, "KILL"); killErr != nil {
+				return fmt.Errorf("failed to stop box: %w", err)
+			}
 		}
+		return nil
 	}
-	cmd := exec.Command(dockerCmd(), "stop", "--time", fmt.Sprintf("%d", timeoutSec), boxName)
+	cmd := exec.CommandContext(ctx, dockerCmd(), "stop", "--time", strconv.Itoa(timeout), boxName)
 	if err := cmd.Run(); err != nil {
 
-		if killErr := exec.Command(dockerCmd(), "kill", boxName).Run(); killErr != nil {
+		if killErr := exec.CommandContext(ctx, dockerCmd(), "kill", boxName).Run(); killErr != nil {
 			return fmt.Errorf("failed to stop box: %w", err)
 		}
 		return nil
@@ -620,6 +1035,42 @@ func (c *Client) StopBox(boxName string) error {
 	return nil
 }
 
+func (c *Client) stopTimeoutSeconds(ctx context.Context, boxName string) int {
+	if v := strings.TrimSpace(os.Getenv("DEVBOX_STOP_TIMEOUT")); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			return n
+		}
+	}
+	if label, err := c.boxLabel(ctx, boxName, StopTimeoutLabel); err == nil {
+		if n, err := strconv.Atoi(label); err == nil && n >= 0 {
+			return n
+		}
+	}
+	if c.stopTimeout <= 0 {
+		return durationSeconds(defaultStopTimeout)
+	}
+	return durationSeconds(c.stopTimeout)
+}
+
+func (c *Client) boxLabel(ctx context.Context, boxName, key string) (string, error) {
+	if c.api != nil {
+		info, found, err := c.inspectBox(ctx, boxName)
+		if err != nil || !found || info.Config == nil {
+			return "", fmt.Errorf("failed to inspect box %s", boxName)
+		}
+		return info.Config.Labels[key], nil
+	}
+	out, err := exec.CommandContext(ctx, dockerCmd(), "inspect", "--format", fmt.Sprintf("{{index .Config.Labels %q}}", key), boxName).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect box %s: %w", boxName, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func durationSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
+
 func (c *Client) RemoveBox(boxName string) error {
 
 	cmd := exec.Command(dockerCmd(), "rm", "-f", boxName)
@@ -637,7 +1088,15 @@ func (c *Client) RemoveBox(boxName string) error {
 }
 
 func (c *Client) BoxExists(boxName string) (bool, error) {
-	cmd := exec.Command(dockerCmd(), "inspect", boxName)
+	return c.BoxExistsContext(context.Background(), boxName)
+}
+
+func (c *Client) BoxExistsContext(ctx context.Context, boxName string) (bool, error) {
+	if c.api != nil {
+		_, found, err := c.inspectBox(ctx, boxName)
+		return found, err
+	}
+	cmd := exec.CommandContext(ctx, dockerCmd(), "inspect", boxName)
 	err := cmd.Run()
 	if err != nil {
 		if exitError, ok := err.(*exec.ExitError); ok && exitError.ExitCode() == 1 {
@@ -649,7 +1108,24 @@ func (c *Client) BoxExists(boxName string) (bool, error) {
 }
 
 func (c *Client) GetBoxStatus(boxName string) (string, error) {
-	cmd := exec.Command(dockerCmd(), "inspect", "--format", "{{.State.Status}}", boxName)
+	return c.GetBoxStatusContext(context.Background(), boxName)
+}
+
+func (c *Client) GetBoxStatusContext(ctx context.Context, boxName string) (string, error) {
+	if c.api != nil {
+		info, found, err := c.inspectBox(ctx, boxName)
+		if err != nil {
+			return "", err
+		}
+		if !found {
+			return "not found", nil
+		}
+		if info.State == nil {
+			return "", nil
+		}
+		return info.State.Status, nil
+	}
+	cmd := exec.CommandContext(ctx, dockerCmd(), "inspect", "--format", "{{.State.Status}}", boxName)
 	output, err := cmd.Output()
 	if err != nil {
 		if exitError, ok := err.(*exec.ExitError); ok && exitError.ExitCode() == 1 {
@@ -661,26 +1137,77 @@ func (c *Client) GetBoxStatus(boxName string) (string, error) {
 }
 
 func AttachShell(boxName string) error {
+	return AttachShellWithEnv(boxName, nil)
+}
+
+func AttachShellWithEnv(boxName string, env []string) error {
+	if err := attachScript(boxName, env, "export PS1='devbox(\\$PROJECT_NAME):\\w\\$ '; exec /bin/bash -l"); err != nil {
+		return fmt.Errorf("failed to attach shell: %w", err)
+	}
+	return nil
+}
+
+func AttachTmuxWithEnv(boxName, script string, env []string) error {
+	if err := attachScript(boxName, env, script); err != nil {
+		return fmt.Errorf("failed to attach tmux session: %w", err)
+	}
+	return nil
+}
 
-	cmd := exec.Command(dockerCmd(), "exec", "-it",
-		"-e", fmt.Sprintf("DEVBOX_BOX_NAME=%s", boxName),
-		boxName, "/bin/bash", "-c",
-		"export PS1='devbox(\\$PROJECT_NAME):\\w\\$ '; exec /bin/bash")
+func attachScript(boxName string, env []string, script string) error {
+	args := []string{"exec", "-it", "-e", fmt.Sprintf("DEVBOX_BOX_NAME=%s", boxName)}
+	if user := boxExecUser(boxName); user != "" {
+		args = append(args, "--user", user)
+	}
+	for _, e := range env {
+		args = append(args, "-e", e)
+	}
+	args = append(args, boxName, "/bin/bash", "-c", script)
+	cmd := exec.Command(dockerCmd(), args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+const readOnlyUID = 65534
+
+func ReadOnlyShellScript(mounts []string) string {
+	var b strings.Builder
+	b.WriteString("set -e\n")
+	b.WriteString("command -v setpriv >/dev/null 2>&1 || { echo 'devbox: read-only shells need setpriv (util-linux) in the box' >&2; exit 127; }\n")
+	for _, m := range mounts {
+		fmt.Fprintf(&b, "mount -o remount,bind,ro %s\n", shellQuote(m))
+	}
+	fmt.Fprintf(&b, "export HOME=/tmp PS1='devbox(read-only:$PROJECT_NAME):\\w\\$ '\n")
+	fmt.Fprintf(&b, "exec setpriv --reuid=%d --regid=%d --clear-groups --no-new-privs --inh-caps=-all --bounding-set=-all /bin/bash --norc\n", readOnlyUID, readOnlyUID)
+	return b.String()
+}
+
+func AttachReadOnlyShell(boxName string, mounts []string) error {
+	args := []string{"exec", "-it", "--privileged", "-e", fmt.Sprintf("DEVBOX_BOX_NAME=%s", boxName), "-e", "DEVBOX_READ_ONLY=1",
+		boxName, "unshare", "--mount", "--propagation", "private", "/bin/sh", "-c", ReadOnlyShellScript(mounts)}
+	cmd := exec.Command(dockerCmd(), args...)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 
 	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to attach shell: %w", err)
+		return fmt.Errorf("failed to attach read-only shell: %w", err)
 	}
 	return nil
 }
 
+func runCommandArgs(boxName, user string, command []string) []string {
+	args := []string{"exec", "-it"}
+	if user != "" {
+		args = append(args, "--user", user)
+	}
+	return append(args, boxName, "bash", "-c", parallel.WithShellInit(strings.Join(command, " "), true))
+}
+
 func RunCommand(boxName string, command []string) error {
-	cmdStr := strings.Join(command, " ")
-	wrapped := ". /root/.bashrc >/dev/null 2>&1 || true; " + cmdStr
-	args := []string{"exec", "-it", boxName, "bash", "-lc", wrapped}
-	cmd := exec.Command(dockerCmd(), args...)
+	cmd := exec.Command(dockerCmd(), runCommandArgs(boxName, boxExecUser(boxName), command)...)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -693,33 +1220,90 @@ func RunCommand(boxName string, command []string) error {
 
 func (c *Client) WaitForBox(boxName string, timeout time.Duration) error {
 	start := time.Now()
-	for {
-		if time.Since(start) > timeout {
-			return fmt.Errorf("timeout waiting for box to be ready")
-		}
-
-		status, err := c.GetBoxStatus(boxName)
+	delay := waitInitialDelay
+	status := ""
+	for attempt := 1; ; attempt++ {
+		var err error
+		status, err = c.GetBoxStatus(boxName)
 		if err != nil {
 			return fmt.Errorf("failed to get box status: %w", err)
 		}
-
 		if status == "running" {
 			return nil
 		}
 
-		time.Sleep(500 * time.Millisecond)
+		elapsed := time.Since(start)
+		if elapsed >= timeout {
+			return fmt.Errorf("timed out waiting for box '%s' to start after %s (%d checks, last status %q); raise timeouts.startup in devbox.json or settings", boxName, elapsed.Round(100*time.Millisecond), attempt, status)
+		}
+		time.Sleep(minDuration(delay, timeout-elapsed))
+		delay = nextBackoff(delay)
 	}
 }
 
-type BoxInfo struct {
-	Names  []string
-	Status string
-	Image  string
+const (
+	waitInitialDelay = 100 * time.Millisecond
+	waitMaxDelay     = 2 * time.Second
+)
+
+func nextBackoff(delay time.Duration) time.Duration {
+	if delay *= 2; delay > waitMaxDelay {
+		return waitMaxDelay
+	}
+	return delay
 }
 
-func (c *Client) ListBoxes() ([]BoxInfo, error) {
-	cmd := exec.Command(dockerCmd(), "ps", "-a", "--format", "{{.Names}}\t{{.Status}}\t{{.Image}}")
-	var stdout, stderr bytes.Buffer
+func minDuration(a, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func (c *Client) GetHealth(boxName string) (*HealthStatus, error) {
+	out, err := exec.Command(dockerCmd(), "inspect", "--format", "{{json .State.Health}}", boxName).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect box health: %w", err)
+	}
+	return parseHealth(out)
+}
+
+func (c *Client) WaitForHealthy(boxName string, timeout time.Duration) (*HealthStatus, error) {
+	start := time.Now()
+	for {
+		health, err := c.GetHealth(boxName)
+		if err != nil {
+			return nil, err
+		}
+		switch health.Status {
+		case "healthy", "none":
+			return health, nil
+		case "unhealthy":
+			return health, fmt.Errorf("box is unhealthy after %d failed check(s)", health.FailingStreak)
+		}
+		if time.Since(start) > timeout {
+			return health, fmt.Errorf("timeout waiting for box to become healthy (status: %s)", health.Status)
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+type BoxInfo struct {
+	Names    []string
+	State    string
+	Status   string
+	Image    string
+	Owner    string
+	Metadata BoxMetadata
+}
+
+func (b BoxInfo) Running() bool {
+	return b.State == "running"
+}
+
+func (c *Client) ListBoxes() ([]BoxInfo, error) {
+	cmd := exec.Command(dockerCmd(), "ps", "-a", "--format", boxListFormat)
+	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
@@ -731,33 +1315,10 @@ func (c *Client) ListBoxes() ([]BoxInfo, error) {
 		return nil, fmt.Errorf("failed to list boxes: %w", err)
 	}
 
-	var boxes []BoxInfo
-	scanner := bufio.NewScanner(&stdout)
-	for scanner.Scan() {
-		line := scanner.Text()
-		if line == "" {
-			continue
-		}
-
-		parts := strings.Split(line, "\t")
-		if len(parts) != 3 {
-			continue
-		}
-
-		name := parts[0]
-		if strings.HasPrefix(name, "devbox_") {
-			boxes = append(boxes, BoxInfo{
-				Names:  []string{name},
-				Status: parts[1],
-				Image:  parts[2],
-			})
-		}
-	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("failed to scan containers: %w", err)
+	boxes, err := parseBoxList(stdout.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse box list: %w", err)
 	}
-
 	return boxes, nil
 }
 
@@ -782,7 +1343,20 @@ type ContainerStats struct {
 }
 
 func (c *Client) CommitContainer(containerName, imageTag string) (string, error) {
-	args := []string{"commit", containerName, imageTag}
+	return c.CommitContainerWithLabels(containerName, imageTag, nil)
+}
+
+func (c *Client) CommitContainerWithLabels(containerName, imageTag string, labels map[string]string) (string, error) {
+	args := []string{"commit"}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		args = append(args, "--change", fmt.Sprintf("LABEL %s=%q", k, labels[k]))
+	}
+	args = append(args, containerName, imageTag)
 	cmd := exec.Command(dockerCmd(), args...)
 	var out, errb bytes.Buffer
 	cmd.Stdout = &out
@@ -793,6 +1367,56 @@ func (c *Client) CommitContainer(containerName, imageTag string) (string, error)
 	return strings.TrimSpace(out.String()), nil
 }
 
+func (c *Client) PushImage(imageRef string) error {
+	fmt.Printf("Pushing image %s...\n", imageRef)
+	cmd := exec.Command(dockerCmd(), "push", imageRef)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to push image %s: %w", imageRef, err)
+	}
+	return nil
+}
+
+func (c *Client) GetImageLabel(imageRef, key string) (string, error) {
+	format := fmt.Sprintf("{{ index .Config.Labels %q }}", key)
+	out, err := exec.Command(dockerCmd(), "image", "inspect", "--format", format, imageRef).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect image %s: %w", imageRef, err)
+	}
+	v := strings.TrimSpace(string(out))
+	if v == "<no value>" {
+		v = ""
+	}
+	return v, nil
+}
+
+func (c *Client) GetBoxGateway(boxName string) (string, error) {
+	out, err := exec.Command(dockerCmd(), "container", "inspect", "--format", "{{range .NetworkSettings.Networks}}{{.Gateway}} {{end}}", boxName).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect box %s: %w", boxName, err)
+	}
+	for _, gw := range strings.Fields(string(out)) {
+		if gw != "" && gw != "<no value>" {
+			return gw, nil
+		}
+	}
+	return "", nil
+}
+
+func (c *Client) GetBoxOwner(boxName string) (string, error) {
+	format := fmt.Sprintf("{{ index .Config.Labels %q }}", OwnerLabel)
+	out, err := exec.Command(dockerCmd(), "container", "inspect", "--format", format, boxName).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect box %s: %w", boxName, err)
+	}
+	v := strings.TrimSpace(string(out))
+	if v == "<no value>" {
+		v = ""
+	}
+	return v, nil
+}
+
 func (c *Client) SaveImage(imageRef, tarPath string) error {
 	f, err := os.Create(tarPath)
 	if err != nil {
@@ -830,9 +1454,14 @@ func (c *Client) LoadImage(tarPath string) (string, error) {
 }
 
 func (c *Client) GetContainerStats(boxName string) (*ContainerStats, error) {
+	return c.GetContainerStatsContext(context.Background(), boxName)
+}
 
-	format := "{{.CPUPerc}}\t{{.MemUsage}}\t{{.MemPerc}}\t{{.NetIO}}\t{{.BlockIO}}\t{{.PIDs}}"
-	cmd := exec.Command(dockerCmd(), "stats", "--no-stream", "--format", format, boxName)
+func (c *Client) GetContainerStatsContext(ctx context.Context, boxName string) (*ContainerStats, error) {
+	if c.api != nil {
+		return c.apiContainerStats(ctx, boxName)
+	}
+	cmd := exec.CommandContext(ctx, dockerCmd(), "stats", "--no-stream", "--format", statsFormat, boxName)
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
@@ -843,24 +1472,11 @@ func (c *Client) GetContainerStats(boxName string) (*ContainerStats, error) {
 		}
 		return nil, fmt.Errorf("failed to get stats: %w", err)
 	}
-	line := strings.TrimSpace(stdout.String())
-	if line == "" {
-
-		return &ContainerStats{}, nil
-	}
-	parts := strings.Split(line, "\t")
-
-	for len(parts) < 6 {
-		parts = append(parts, "")
+	stats, err := parseContainerStats(stdout.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse stats: %w", err)
 	}
-	return &ContainerStats{
-		CPUPercent: strings.TrimSpace(parts[0]),
-		MemUsage:   strings.TrimSpace(parts[1]),
-		MemPercent: strings.TrimSpace(parts[2]),
-		NetIO:      strings.TrimSpace(parts[3]),
-		BlockIO:    strings.TrimSpace(parts[4]),
-		PIDs:       strings.TrimSpace(parts[5]),
-	}, nil
+	return stats, nil
 }
 
 func (c *Client) GetContainerID(boxName string) (string, error) {
@@ -872,19 +1488,53 @@ func (c *Client) GetContainerID(boxName string) (string, error) {
 	return strings.TrimSpace(string(out)), nil
 }
 
-func (c *Client) GetUptime(boxName string) (time.Duration, error) {
-	cmd := exec.Command(dockerCmd(), "inspect", "--format", "{{.State.StartedAt}}\t{{.State.Running}}", boxName)
-	out, err := cmd.Output()
+func (c *Client) GetStartedAt(boxName string) (string, error) {
+	return c.GetStartedAtContext(context.Background(), boxName)
+}
+
+func (c *Client) GetStartedAtContext(ctx context.Context, boxName string) (string, error) {
+	if c.api != nil {
+		info, found, err := c.inspectBox(ctx, boxName)
+		if err != nil {
+			return "", fmt.Errorf("failed to inspect container: %w", err)
+		}
+		if !found || info.State == nil {
+			return "", fmt.Errorf("failed to inspect container: no such container: %s", boxName)
+		}
+		return info.State.StartedAt, nil
+	}
+	out, err := exec.CommandContext(ctx, dockerCmd(), "inspect", "--format", "{{.State.StartedAt}}", boxName).Output()
 	if err != nil {
-		return 0, fmt.Errorf("failed to inspect container: %w", err)
+		return "", fmt.Errorf("failed to inspect container: %w", err)
 	}
-	s := strings.TrimSpace(string(out))
-	parts := strings.Split(s, "\t")
-	if len(parts) < 2 {
-		return 0, nil
+	return strings.TrimSpace(string(out)), nil
+}
+
+func (c *Client) GetUptime(boxName string) (time.Duration, error) {
+	var startedAt, running string
+	if c.api != nil {
+		info, found, err := c.inspectBox(context.Background(), boxName)
+		if err != nil {
+			return 0, fmt.Errorf("failed to inspect container: %w", err)
+		}
+		if !found || info.State == nil {
+			return 0, fmt.Errorf("failed to inspect container: no such container: %s", boxName)
+		}
+		startedAt, running = info.State.StartedAt, strconv.FormatBool(info.State.Running)
+	} else {
+		cmd := exec.Command(dockerCmd(), "inspect", "--format", "{{.State.StartedAt}}\t{{.State.Running}}", boxName)
+		out, err := cmd.Output()
+		if err != nil {
+			return 0, fmt.Errorf("failed to inspect container: %w", err)
+		}
+		s := strings.TrimSpace(string(out))
+		parts := strings.Split(s, "\t")
+		if len(parts) < 2 {
+			return 0, nil
+		}
+		startedAt = strings.TrimSpace(parts[0])
+		running = strings.TrimSpace(parts[1])
 	}
-	startedAt := strings.TrimSpace(parts[0])
-	running := strings.TrimSpace(parts[1])
 	if running != "true" {
 		return 0, nil
 	}
@@ -944,6 +1594,71 @@ func (c *Client) GetMounts(boxName string) ([]string, error) {
 	return mounts, nil
 }
 
+func (c *Client) GetMountDestinations(boxName string) ([]string, error) {
+	out, err := exec.Command(dockerCmd(), "inspect", "--format", "{{range .Mounts}}{{.Destination}}\n{{end}}", boxName).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mounts: %w", err)
+	}
+	var dests []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			dests = append(dests, line)
+		}
+	}
+	sort.Strings(dests)
+	return dests, nil
+}
+
+func (c *Client) TopProcesses(boxName string, psArgs ...string) (string, error) {
+	args := append([]string{"top", boxName}, psArgs...)
+	cmd := exec.Command(dockerCmd(), args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if s := strings.TrimSpace(stderr.String()); s != "" {
+			return "", fmt.Errorf("failed to list processes: %s", s)
+		}
+		return "", fmt.Errorf("failed to list processes: %w", err)
+	}
+	return stdout.String(), nil
+}
+
+func (c *Client) GetVolumeNames(boxName string) ([]string, error) {
+	template := `{{range .Mounts}}{{if eq .Type "volume"}}{{.Name}}
+{{end}}{{end}}`
+	out, err := exec.Command(dockerCmd(), "inspect", "--format", template, boxName).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get volumes: %w", err)
+	}
+	var names []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			names = append(names, line)
+		}
+	}
+	return names, nil
+}
+
+func (c *Client) GetVolumeSizes() (map[string]int64, error) {
+	out, err := exec.Command(dockerCmd(), "system", "df", "-v", "--format", "{{json .Volumes}}").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get volume sizes: %w", err)
+	}
+	var vols []struct {
+		Name string `json:"Name"`
+		Size string `json:"Size"`
+	}
+	if err := json.Unmarshal(bytes.TrimSpace(out), &vols); err != nil {
+		return nil, fmt.Errorf("failed to parse volume sizes: %w", err)
+	}
+	sizes := make(map[string]int64, len(vols))
+	for _, v := range vols {
+		sizes[v.Name] = ParseSize(v.Size)
+	}
+	return sizes, nil
+}
+
 func (c *Client) IsContainerIdle(boxName string) (bool, error) {
 	stats, err := c.GetContainerStats(boxName)
 	if err != nil {
@@ -961,8 +1676,14 @@ func (c *Client) IsContainerIdle(boxName string) (bool, error) {
 }
 
 func (c *Client) ExecCapture(boxName, command string) (string, string, error) {
-	wrapped := ". /root/.bashrc >/dev/null 2>&1 || true; set -o pipefail; " + command
-	cmd := exec.Command(dockerCmd(), "exec", boxName, "bash", "-lc", wrapped)
+	if s := c.execSession(boxName); s != nil {
+		stdout, stderr, err := s.Run(command)
+		if !errors.Is(err, errExecSession) {
+			return stdout, stderr, err
+		}
+	}
+	wrapped := parallel.WithShellInit("set -o pipefail; "+command, false)
+	cmd := exec.Command(dockerCmd(), "exec", boxName, "bash", "-c", wrapped)
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
@@ -1160,3 +1881,411 @@ func (c *Client) GetContainerMeta(boxName string) (map[string]string, string, st
 	}
 	return env, ins.Config.WorkingDir, ins.Config.User, ins.HostConfig.RestartPolicy.Name, ins.Config.Labels, ins.HostConfig.CapAdd, resources, ins.HostConfig.NetworkMode
 }
+
+func (c *Client) GetBoxImageEnv(boxName string) (map[string]string, error) {
+	out, err := exec.Command(dockerCmd(), "inspect", "--type=container", "--format", "{{.Image}}", boxName).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect box: %w", err)
+	}
+	out, err = exec.Command(dockerCmd(), "inspect", "--type=image", "--format", "{{json .Config.Env}}", strings.TrimSpace(string(out))).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect image: %w", err)
+	}
+	var list []string
+	if err := json.Unmarshal(out, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse image environment: %w", err)
+	}
+	env := map[string]string{}
+	for _, e := range list {
+		if k, v, ok := strings.Cut(e, "="); ok {
+			env[k] = v
+		}
+	}
+	return env, nil
+}
+
+func (c *Client) UpdateContainer(boxName, restart, cpus, memory string) error {
+	args := []string{"update"}
+	if restart != "" {
+		args = append(args, "--restart", restart)
+	}
+	if cpus != "" {
+		args = append(args, "--cpus", cpus)
+	}
+	if memory != "" {
+		args = append(args, "--memory", memory)
+	}
+	if len(args) == 1 {
+		return nil
+	}
+	args = append(args, boxName)
+	cmd := exec.Command(dockerCmd(), args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if s := strings.TrimSpace(stderr.String()); s != "" {
+			return fmt.Errorf("failed to update box: %s", s)
+		}
+		return fmt.Errorf("failed to update box: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) GetFileHashes(boxName string, paths []string, exclude []string) (map[string]string, error) {
+	if len(paths) == 0 {
+		return map[string]string{}, nil
+	}
+	quoted := make([]string, 0, len(paths))
+	for _, p := range paths {
+		quoted = append(quoted, "'"+strings.ReplaceAll(p, "'", "'\\''")+"'")
+	}
+	var prune strings.Builder
+	for _, e := range exclude {
+		prune.WriteString(" ! -path '")
+		prune.WriteString(strings.ReplaceAll(e, "'", "'\\''"))
+		prune.WriteString("'")
+	}
+	command := "find " + strings.Join(quoted, " ") + " -xdev -type f" + prune.String() + " -print0 2>/dev/null | xargs -0 -r sha256sum 2>/dev/null || true"
+	out, stderr, err := c.ExecCapture(boxName, command)
+	if err != nil {
+		if s := strings.TrimSpace(stderr); s != "" {
+			return nil, fmt.Errorf("failed to hash files: %s", s)
+		}
+		return nil, fmt.Errorf("failed to hash files: %w", err)
+	}
+	hashes := map[string]string{}
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		parts := strings.SplitN(line, "  ", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		hashes[parts[1]] = strings.TrimSpace(parts[0])
+	}
+	return hashes, nil
+}
+
+type ImageInfo struct {
+	Repository string
+	Tag        string
+	ID         string
+	CreatedAt  time.Time
+}
+
+func (c *Client) ListImages(reference string) ([]ImageInfo, error) {
+	args := []string{"images", "--no-trunc", "--format", imageListFormat}
+	if reference != "" {
+		args = append(args, "--filter", "reference="+reference)
+	}
+	cmd := exec.Command(dockerCmd(), args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if s := strings.TrimSpace(stderr.String()); s != "" {
+			return nil, fmt.Errorf("failed to list images: %s", s)
+		}
+		return nil, fmt.Errorf("failed to list images: %w", err)
+	}
+
+	images, err := parseImageList(stdout.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse image list: %w", err)
+	}
+	return images, nil
+}
+
+func (c *Client) ContainerImages() (map[string]string, error) {
+	out, err := exec.Command(dockerCmd(), "ps", "-aq", "--no-trunc").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+	ids := strings.Fields(string(out))
+	if len(ids) == 0 {
+		return map[string]string{}, nil
+	}
+	cmd := exec.Command(dockerCmd(), append([]string{"inspect", "--format", containerImageFormat}, ids...)...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if s := strings.TrimSpace(stderr.String()); s != "" {
+			return nil, fmt.Errorf("failed to inspect containers: %s", s)
+		}
+		return nil, fmt.Errorf("failed to inspect containers: %w", err)
+	}
+	images, err := parseContainerImages(stdout.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse container images: %w", err)
+	}
+	return images, nil
+}
+
+func (c *Client) RemoveImage(ref string) error {
+	cmd := exec.Command(dockerCmd(), "rmi", ref)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if s := strings.TrimSpace(stderr.String()); s != "" {
+			return fmt.Errorf("failed to remove image: %s", s)
+		}
+		return fmt.Errorf("failed to remove image: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) GetDockerRootDir() string {
+	out, err := exec.Command(dockerCmd(), "info", "--format", "{{.DockerRootDir}}").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func (c *Client) GetImageSize(ref string) (int64, error) {
+	out, err := exec.Command(dockerCmd(), "image", "inspect", "--format", "{{.Size}}", ref).Output()
+	if err != nil {
+		return 0, fmt.Errorf("failed to inspect image: %w", err)
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+}
+
+func (c *Client) GetContainerSize(boxName string) (int64, error) {
+	out, err := exec.Command(dockerCmd(), "inspect", "--size", "--format", "{{.SizeRw}}", boxName).Output()
+	if err != nil {
+		return 0, fmt.Errorf("failed to inspect container: %w", err)
+	}
+	s := strings.TrimSpace(string(out))
+	if s == "" || s == "<no value>" {
+		return 0, nil
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
+
+func (c *Client) GetLastUsed(boxName string) (time.Time, error) {
+	out, err := exec.Command(dockerCmd(), "inspect", "--format", "{{.State.Running}}\t{{.State.StartedAt}}\t{{.State.FinishedAt}}\t{{.Created}}", boxName).Output()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to inspect container: %w", err)
+	}
+	parts := strings.Split(strings.TrimSpace(string(out)), "\t")
+	if len(parts) > 0 && parts[0] == "true" {
+		return time.Now(), nil
+	}
+	var latest time.Time
+	for _, p := range parts[1:] {
+		if t, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(p)); err == nil && t.After(latest) {
+			latest = t
+		}
+	}
+	return latest, nil
+}
+
+func (c *Client) Prune(resource string, filters ...string) (int64, error) {
+	args := []string{resource, "prune", "-f"}
+	for _, f := range filters {
+		args = append(args, "--filter", f)
+	}
+	cmd := exec.Command(dockerCmd(), args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if s := strings.TrimSpace(stderr.String()); s != "" {
+			return 0, fmt.Errorf("failed to prune %ss: %s", resource, s)
+		}
+		return 0, fmt.Errorf("failed to prune %ss: %w", resource, err)
+	}
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		if i := strings.Index(line, "Total reclaimed space:"); i != -1 {
+			return ParseSize(strings.TrimSpace(line[i+len("Total reclaimed space:"):])), nil
+		}
+	}
+	return 0, nil
+}
+
+func ParseSize(s string) int64 {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0
+	}
+	units := []struct {
+		suffix string
+		mult   float64
+	}{
+		{"KiB", 1 << 10}, {"MiB", 1 << 20}, {"GiB", 1 << 30}, {"TiB", 1 << 40},
+		{"kB", 1e3}, {"KB", 1e3}, {"MB", 1e6}, {"GB", 1e9}, {"TB", 1e12},
+		{"B", 1},
+	}
+	for _, u := range units {
+		if strings.HasSuffix(s, u.suffix) {
+			n, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(s, u.suffix)), 64)
+			if err != nil {
+				return 0
+			}
+			return int64(n * u.mult)
+		}
+	}
+	n, _ := strconv.ParseInt(s, 10, 64)
+	return n
+}
+
+type ImageConfig struct {
+	Env          []string            `json:"Env"`
+	Cmd          []string            `json:"Cmd"`
+	Entrypoint   []string            `json:"Entrypoint"`
+	WorkingDir   string              `json:"WorkingDir"`
+	User         string              `json:"User"`
+	Labels       map[string]string   `json:"Labels"`
+	ExposedPorts map[string]struct{} `json:"ExposedPorts"`
+	StopSignal   string              `json:"StopSignal"`
+}
+
+func (c *Client) GetImageConfig(ref string) (*ImageConfig, error) {
+	out, err := exec.Command(dockerCmd(), "image", "inspect", "--format", "{{json .Config}}", ref).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect image: %w", err)
+	}
+	var cfg ImageConfig
+	if err := json.Unmarshal(out, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse image config: %w", err)
+	}
+	return &cfg, nil
+}
+
+func (c *Client) GetImageLayerCount(ref string) (int, error) {
+	out, err := exec.Command(dockerCmd(), "image", "inspect", "--format", "{{len .RootFS.Layers}}", ref).Output()
+	if err != nil {
+		return 0, fmt.Errorf("failed to inspect image: %w", err)
+	}
+	return strconv.Atoi(strings.TrimSpace(string(out)))
+}
+
+func ImageConfigChanges(cfg *ImageConfig) []string {
+	if cfg == nil {
+		return nil
+	}
+	var changes []string
+	for _, e := range cfg.Env {
+		if k, v, ok := strings.Cut(e, "="); ok {
+			changes = append(changes, fmt.Sprintf("ENV %s=%q", k, v))
+		}
+	}
+	keys := make([]string, 0, len(cfg.Labels))
+	for k := range cfg.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		changes = append(changes, fmt.Sprintf("LABEL %s=%q", k, cfg.Labels[k]))
+	}
+	ports := make([]string, 0, len(cfg.ExposedPorts))
+	for p := range cfg.ExposedPorts {
+		ports = append(ports, p)
+	}
+	sort.Strings(ports)
+	for _, p := range ports {
+		changes = append(changes, "EXPOSE "+p)
+	}
+	if cfg.WorkingDir != "" {
+		changes = append(changes, "WORKDIR "+cfg.WorkingDir)
+	}
+	if cfg.User != "" {
+		changes = append(changes, "USER "+cfg.User)
+	}
+	if cfg.StopSignal != "" {
+		changes = append(changes, "STOPSIGNAL "+cfg.StopSignal)
+	}
+	if len(cfg.Entrypoint) > 0 {
+		b, _ := json.Marshal(cfg.Entrypoint)
+		changes = append(changes, "ENTRYPOINT "+string(b))
+	}
+	if len(cfg.Cmd) > 0 {
+		b, _ := json.Marshal(cfg.Cmd)
+		changes = append(changes, "CMD "+string(b))
+	}
+	return changes
+}
+
+func (c *Client) SquashContainer(containerName, imageTag string, changes []string) error {
+	export := exec.Command(dockerCmd(), "export", containerName)
+	args := []string{"import"}
+	for _, ch := range changes {
+		args = append(args, "--change", ch)
+	}
+	args = append(args, "-", imageTag)
+	imp := exec.Command(dockerCmd(), args...)
+
+	pipe, err := export.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to squash image: %w", err)
+	}
+	imp.Stdin = pipe
+	var exportErr, importErr bytes.Buffer
+	export.Stderr = &exportErr
+	imp.Stderr = &importErr
+
+	if err := imp.Start(); err != nil {
+		return fmt.Errorf("failed to start docker import: %w", err)
+	}
+	if err := export.Run(); err != nil {
+		_ = imp.Wait()
+		return fmt.Errorf("docker export failed: %s", firstLine(exportErr.String(), err))
+	}
+	if err := imp.Wait(); err != nil {
+		return fmt.Errorf("docker import failed: %s", firstLine(importErr.String(), err))
+	}
+	return nil
+}
+
+func firstLine(stderr string, err error) string {
+	if s := strings.TrimSpace(stderr); s != "" {
+		line, _, _ := strings.Cut(s, "\n")
+		return line
+	}
+	return err.Error()
+}
+
+type ExecOptions struct {
+	TTY     bool
+	User    string
+	Workdir string
+	Env     []string
+}
+
+func execArgs(boxName string, command []string, opts ExecOptions) []string {
+	args := []string{"exec", "-i"}
+	if opts.TTY {
+		args = append(args, "-t")
+	}
+	if opts.User != "" {
+		args = append(args, "--user", opts.User)
+	}
+	if opts.Workdir != "" {
+		args = append(args, "--workdir", opts.Workdir)
+	}
+	for _, e := range opts.Env {
+		args = append(args, "--env", e)
+	}
+	args = append(args, boxName, "bash", "-c", parallel.ShellInit(true)+`exec "$@"`, "devbox")
+	return append(args, command...)
+}
+
+func (c *Client) Exec(boxName string, command []string, opts ExecOptions) (int, error) {
+	if opts.User == "" {
+		opts.User = boxExecUser(boxName)
+	}
+	cmd := exec.Command(dockerCmd(), execArgs(boxName, command, opts)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode(), nil
+		}
+		return -1, fmt.Errorf("failed to exec in %s: %w", boxName, err)
+	}
+	return 0, nil
+}
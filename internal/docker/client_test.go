@@ -1,7 +1,19 @@
 package docker
 
 import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"devbox/internal/parallel"
 )
 
 func TestNewClient(t *testing.T) {
@@ -53,3 +65,468 @@ func containsAtIndex(s, substr string) bool {
 	}
 	return false
 }
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"0B", 0},
+		{"512B", 512},
+		{"1.5kB", 1500},
+		{"2MB", 2000000},
+		{"1GB", 1000000000},
+		{"1KiB", 1024},
+		{"3GiB", 3 << 30},
+		{"", 0},
+		{"garbage", 0},
+	}
+	for _, tt := range tests {
+		if got := ParseSize(tt.in); got != tt.want {
+			t.Errorf("ParseSize(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestBoxDefaultsApply(t *testing.T) {
+	d := BoxDefaults{Environment: map[string]string{"HTTP_PROXY": "http://proxy:3128", "EDITOR": "vim"}, Memory: "4g"}
+
+	merged := d.apply(map[string]interface{}{
+		"environment": map[string]interface{}{"EDITOR": "nano"},
+		"resources":   map[string]interface{}{"cpus": "1"},
+	})
+	env := merged["environment"].(map[string]interface{})
+	if env["EDITOR"] != "nano" || env["HTTP_PROXY"] != "http://proxy:3128" {
+		t.Errorf("environment = %v", env)
+	}
+	res := merged["resources"].(map[string]interface{})
+	if res["cpus"] != "1" || res["memory"] != "4g" {
+		t.Errorf("resources = %v", res)
+	}
+
+	if merged := d.apply(nil); merged["environment"] == nil {
+		t.Error("defaults should apply when there is no project config")
+	}
+	if got := (BoxDefaults{}).apply(nil); got != nil {
+		t.Errorf("empty defaults should leave config nil, got %v", got)
+	}
+
+	r := BoxDefaults{Restart: "no"}
+	if got := r.apply(nil)["restart"]; got != "no" {
+		t.Errorf("default restart = %v, want no", got)
+	}
+	if got := r.apply(map[string]interface{}{"restart": "always"})["restart"]; got != "always" {
+		t.Errorf("project restart = %v, want always", got)
+	}
+}
+
+func TestResolveVolume(t *testing.T) {
+	home, _ := os.UserHomeDir()
+	tests := []struct {
+		volume string
+		want   string
+	}{
+		{"./data:/data", "/work/app/data:/data"},
+		{"../shared:/shared:ro", "/work/shared:/shared:ro"},
+		{".:/src", "/work/app:/src"},
+		{"~/.cache:/root/.cache", home + "/.cache:/root/.cache"},
+		{"/abs:/abs", "/abs:/abs"},
+		{"pgdata:/var/lib/postgresql/data", "pgdata:/var/lib/postgresql/data"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.volume, func(t *testing.T) {
+			if got := ResolveVolume(tt.volume, "/work/app"); got != tt.want {
+				t.Errorf("ResolveVolume(%q) = %q, want %q", tt.volume, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImageConfigChanges(t *testing.T) {
+	cfg := &ImageConfig{
+		Env:          []string{"PATH=/usr/local/bin:/usr/bin", "GREETING=hello world"},
+		Cmd:          []string{"sleep", "infinity"},
+		WorkingDir:   "/workspace",
+		Labels:       map[string]string{"devbox.project": "web", "a": `say "hi"`},
+		ExposedPorts: map[string]struct{}{"8080/tcp": {}, "3000/tcp": {}},
+	}
+	want := []string{
+		`ENV PATH="/usr/local/bin:/usr/bin"`,
+		`ENV GREETING="hello world"`,
+		`LABEL a="say \"hi\""`,
+		`LABEL devbox.project="web"`,
+		"EXPOSE 3000/tcp",
+		"EXPOSE 8080/tcp",
+		"WORKDIR /workspace",
+		`CMD ["sleep","infinity"]`,
+	}
+	got := ImageConfigChanges(cfg)
+	if len(got) != len(want) {
+		t.Fatalf("ImageConfigChanges() = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("change %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+	if ImageConfigChanges(nil) != nil {
+		t.Error("nil config should produce no changes")
+	}
+}
+
+func TestReadOnlyShellScript(t *testing.T) {
+	script := ReadOnlyShellScript([]string{"/workspace", "/data/it's"})
+	for _, want := range []string{
+		"mount -o remount,bind,ro '/workspace'\n",
+		`mount -o remount,bind,ro '/data/it'\''s'` + "\n",
+		"--reuid=65534 --regid=65534 --clear-groups --no-new-privs --inh-caps=-all --bounding-set=-all",
+		"command -v setpriv",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script missing %q:\n%s", want, script)
+		}
+	}
+	if strings.Index(script, "mount -o") > strings.Index(script, "exec setpriv") {
+		t.Error("mounts must be remounted before dropping privileges")
+	}
+}
+
+func TestServiceCreateArgs(t *testing.T) {
+	c := &Client{}
+	args := c.ServiceCreateArgs(ServiceSpec{
+		Name:          "devbox_web.db",
+		Service:       "db",
+		Box:           "devbox_web",
+		Network:       "devbox_web_net",
+		Image:         "postgres:16",
+		Environment:   map[string]string{"POSTGRES_USER": "dev", "POSTGRES_PASSWORD": "dev"},
+		Ports:         []string{"5432:5432"},
+		Volumes:       []string{"./initdb:/docker-entrypoint-initdb.d"},
+		WorkspaceHost: "/home/me/web",
+		Hash:          "abc123",
+	})
+	got := strings.Join(args, " ")
+	for _, want := range []string{
+		"--name devbox_web.db --network devbox_web_net --network-alias db",
+		"--label devbox.service=db --label devbox.box=devbox_web --label devbox.service-hash=abc123",
+		"--restart unless-stopped",
+		"-e POSTGRES_PASSWORD=dev -e POSTGRES_USER=dev",
+		"-p 5432:5432",
+		"-v /home/me/web/initdb:/docker-entrypoint-initdb.d",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("args missing %q:\n%s", want, got)
+		}
+	}
+	if args[len(args)-1] != "postgres:16" {
+		t.Errorf("image must be last so the service runs its default command, got %q", args[len(args)-1])
+	}
+
+	c.SetBoxDefaults(BoxDefaults{Restart: "no"})
+	if got := strings.Join(c.ServiceCreateArgs(ServiceSpec{Image: "redis:7"}), " "); !strings.Contains(got, "--restart no") {
+		t.Errorf("restart default not applied: %s", got)
+	}
+}
+
+func TestBuildArgs(t *testing.T) {
+	args := BuildArgs(BuildOptions{
+		Tag:        "devbox/web:build",
+		ContextDir: "/ws",
+		Dockerfile: "/ws/.devbox/Dockerfile",
+		Args:       map[string]string{"NODE": "20", "GO": "1.22"},
+		Target:     "dev",
+		NoCache:    true,
+	})
+	got := strings.Join(args, " ")
+	for _, want := range []string{
+		"build --tag devbox/web:build --file /ws/.devbox/Dockerfile",
+		"--build-arg GO=1.22 --build-arg NODE=20",
+		"--target dev",
+		"--no-cache",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("args missing %q: %s", want, got)
+		}
+	}
+	if strings.Contains(got, "--pull") {
+		t.Errorf("--pull should only be passed when requested: %s", got)
+	}
+	if args[len(args)-1] != "/ws" {
+		t.Errorf("context must be the last argument, got %q", args[len(args)-1])
+	}
+}
+
+func TestExecArgs(t *testing.T) {
+	wrap := []string{"bash", "-c", parallel.ShellInit(true) + `exec "$@"`, "devbox"}
+	tests := []struct {
+		command []string
+		opts    ExecOptions
+		prefix  []string
+	}{
+		{[]string{"go", "test", "./..."}, ExecOptions{}, []string{"exec", "-i", "devbox_web"}},
+		{[]string{"make"}, ExecOptions{TTY: true, User: "1000", Workdir: "/workspace/api", Env: []string{"CI=1", "TOKEN"}},
+			[]string{"exec", "-i", "-t", "--user", "1000", "--workdir", "/workspace/api", "--env", "CI=1", "--env", "TOKEN", "devbox_web"}},
+	}
+	for _, tt := range tests {
+		got := execArgs("devbox_web", tt.command, tt.opts)
+		want := append(append(append([]string{}, tt.prefix...), wrap...), tt.command...)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("execArgs(%v) = %q, want %q", tt.command, got, want)
+		}
+	}
+}
+
+func TestRunCommandArgs(t *testing.T) {
+	script := parallel.WithShellInit("npm test", true)
+	tests := []struct {
+		user string
+		want []string
+	}{
+		{"", []string{"exec", "-it", "devbox_web", "bash", "-c", script}},
+		{"1000:1000", []string{"exec", "-it", "--user", "1000:1000", "devbox_web", "bash", "-c", script}},
+	}
+	for _, tt := range tests {
+		if got := runCommandArgs("devbox_web", tt.user, []string{"npm", "test"}); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("runCommandArgs(%q) = %q, want %q", tt.user, got, tt.want)
+		}
+	}
+}
+
+func TestIsRemoteEndpoint(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		want     bool
+	}{
+		{"", false},
+		{"unix:///var/run/docker.sock", false},
+		{"unix:///run/user/1000/docker.sock", false},
+		{"npipe:////./pipe/docker_engine", false},
+		{"tcp://localhost:2375", false},
+		{"tcp://127.0.0.1:2376", false},
+		{"tcp://[::1]:2376", false},
+		{"tcp://10.0.0.5:2376", true},
+		{"ssh://me@build-server", true},
+		{"ssh://build-server:2222", true},
+		{"build-server", false},
+	}
+	for _, tt := range tests {
+		if got := IsRemoteEndpoint(tt.endpoint); got != tt.want {
+			t.Errorf("IsRemoteEndpoint(%q) = %v, want %v", tt.endpoint, got, tt.want)
+		}
+	}
+}
+
+func TestLocalMountSource(t *testing.T) {
+	tests := []struct {
+		spec string
+		want bool
+	}{
+		{"./data:/data", true},
+		{"../shared:/shared", true},
+		{"~/.cache/pip:/root/.cache/pip", true},
+		{"/srv/data:/data:ro", true},
+		{"pgdata:/var/lib/postgresql/data", false},
+		{"/data", false},
+	}
+	for _, tt := range tests {
+		if got := localMountSource(tt.spec); got != tt.want {
+			t.Errorf("localMountSource(%q) = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestSocketCandidates(t *testing.T) {
+	t.Setenv("COLIMA_HOME", "")
+	t.Setenv("LIMA_HOME", "/opt/lima")
+	candidates := SocketCandidates("/home/dev", "", 1000)
+	paths := map[string][]string{}
+	for _, c := range candidates {
+		paths[c.Name] = append(paths[c.Name], c.Path)
+	}
+	want := map[string]string{
+		"rootless Docker":   "/run/user/1000/docker.sock",
+		"Colima":            "/home/dev/.colima/default/docker.sock",
+		"Lima":              "/opt/lima/docker/sock/docker.sock",
+		"Docker Desktop":    "/home/dev/.docker/desktop/docker.sock",
+		"Podman Docker API": "/run/user/1000/podman/podman.sock",
+	}
+	for name, path := range want {
+		if len(paths[name]) == 0 || paths[name][0] != path {
+			t.Errorf("SocketCandidates() %s = %v, want first %s", name, paths[name], path)
+		}
+	}
+	if candidates[0].Name != "rootless Docker" {
+		t.Errorf("SocketCandidates()[0] = %+v, want rootless Docker first", candidates[0])
+	}
+	if got := SocketCandidates("/home/dev", "/tmp/xdg", 1000)[0].Endpoint(); got != "unix:///tmp/xdg/docker.sock" {
+		t.Errorf("Endpoint() with XDG_RUNTIME_DIR = %s", got)
+	}
+}
+
+func TestSocketReachable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "d.sock")
+	if SocketReachable(path) {
+		t.Fatalf("SocketReachable(%s) = true before listening", path)
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	defer l.Close()
+	if !SocketReachable(path) {
+		t.Errorf("SocketReachable(%s) = false while listening", path)
+	}
+}
+
+func TestWriteTar(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "src"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "src", "main.go"), []byte("package main"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("src/main.go", filepath.Join(root, "link")); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := writeTar(&buf, root, []string{"src", "src/main.go", "link", "gone.txt"}); err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(&buf)
+	var names []string
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, hdr.Name)
+		switch hdr.Name {
+		case "src/main.go":
+			if data, _ := io.ReadAll(tr); string(data) != "package main" {
+				t.Errorf("src/main.go content = %q", data)
+			}
+		case "link":
+			if hdr.Typeflag != tar.TypeSymlink || hdr.Linkname != "src/main.go" {
+				t.Errorf("link header = %+v", hdr)
+			}
+		}
+	}
+	if want := []string{"src/", "src/main.go", "link"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("writeTar() entries = %q, want %q", names, want)
+	}
+}
+
+func TestGPUFlag(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"all", "all"},
+		{" 2 ", "2"},
+		{"device=0", `"device=0"`},
+		{"0,1", `"device=0,1"`},
+		{"device=GPU-3a2b,GPU-9f1c", `"device=GPU-3a2b,GPU-9f1c"`},
+	}
+	for _, tt := range tests {
+		if got := GPUFlag(tt.value); got != tt.want {
+			t.Errorf("GPUFlag(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+	args := (&Client{}).applyProjectConfigToArgs(nil, map[string]interface{}{"gpus": "0,1"}, "/ws")
+	if !reflect.DeepEqual(args, []string{"--gpus", `"device=0,1"`}) {
+		t.Errorf("applyProjectConfigToArgs() = %q", args)
+	}
+}
+
+func TestParseDeviceRequests(t *testing.T) {
+	tests := []struct {
+		data string
+		want string
+	}{
+		{`null`, ""},
+		{`[{"Driver":"","Count":-1,"DeviceIDs":null,"Capabilities":[["gpu"]],"Options":{}}]`, "all"},
+		{`[{"Driver":"","Count":2,"DeviceIDs":null,"Capabilities":[["gpu"]]}]`, "2"},
+		{`[{"Driver":"","Count":0,"DeviceIDs":["0","1"],"Capabilities":[["gpu"]]}]`, "device=0,1"},
+		{`[{"Driver":"","Count":-1,"Capabilities":[["tpu"]]}]`, ""},
+	}
+	for _, tt := range tests {
+		if got := parseDeviceRequests([]byte(tt.data)); got != tt.want {
+			t.Errorf("parseDeviceRequests(%s) = %q, want %q", tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestParseNvidiaSMI(t *testing.T) {
+	out := "0, NVIDIA A100-SXM4-80GB, 1024, 81920, 37\n1, NVIDIA A100-SXM4-80GB, 0, 81920, 0\n"
+	gpus, err := parseNvidiaSMI([]byte(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []GPUInfo{
+		{Index: 0, Name: "NVIDIA A100-SXM4-80GB", MemoryUsed: 1024 << 20, MemoryTotal: 81920 << 20, Utilization: 37},
+		{Index: 1, Name: "NVIDIA A100-SXM4-80GB", MemoryUsed: 0, MemoryTotal: 81920 << 20, Utilization: 0},
+	}
+	if !reflect.DeepEqual(gpus, want) {
+		t.Errorf("parseNvidiaSMI() = %+v, want %+v", gpus, want)
+	}
+	if _, err := parseNvidiaSMI([]byte("No devices were found")); err == nil {
+		t.Error("parseNvidiaSMI() accepted unexpected output")
+	}
+}
+
+func TestWaitForBoxTimeout(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "engine")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho created\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DEVBOX_ENGINE", script)
+
+	err := (&Client{}).WaitForBox("devbox_slow", 350*time.Millisecond)
+	if err == nil {
+		t.Fatal("WaitForBox() should time out while the box is not running")
+	}
+	for _, want := range []string{"devbox_slow", "checks, ", `last status "created"`, "timeouts.startup"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("WaitForBox() error = %q, want it to mention %q", err, want)
+		}
+	}
+}
+
+func TestNextBackoff(t *testing.T) {
+	delay := waitInitialDelay
+	var got []time.Duration
+	for i := 0; i < 7; i++ {
+		got = append(got, delay)
+		delay = nextBackoff(delay)
+	}
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, 1600 * time.Millisecond, 2 * time.Second, 2 * time.Second}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("backoff = %v, want %v", got, want)
+	}
+}
+
+func TestStopTimeoutSeconds(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "engine")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\n[ \"$4\" = labeled ] && echo 45\nexit 0\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DEVBOX_ENGINE", script)
+
+	if got := (&Client{}).stopTimeoutSeconds(context.Background(), "plain"); got != 2 {
+		t.Errorf("default stop timeout = %d, want 2", got)
+	}
+	if got := (&Client{stopTimeout: 1500 * time.Millisecond}).stopTimeoutSeconds(context.Background(), "plain"); got != 2 {
+		t.Errorf("settings stop timeout = %d, want 1500ms rounded up to 2", got)
+	}
+	if got := (&Client{stopTimeout: time.Minute}).stopTimeoutSeconds(context.Background(), "labeled"); got != 45 {
+		t.Errorf("labeled stop timeout = %d, want the box's 45", got)
+	}
+	t.Setenv("DEVBOX_STOP_TIMEOUT", "0")
+	if got := (&Client{}).stopTimeoutSeconds(context.Background(), "labeled"); got != 0 {
+		t.Errorf("DEVBOX_STOP_TIMEOUT stop timeout = %d, want 0", got)
+	}
+}
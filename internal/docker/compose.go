@@ -0,0 +1,30 @@
+package docker
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+func ComposeConfig(file string) ([]byte, error) {
+	args := []string{"compose", "--project-directory", filepath.Dir(file), "-f", file, "config", "--format", "json", "--no-interpolate"}
+	cmd := exec.Command(dockerCmd(), args...)
+	if exec.Command(dockerCmd(), "compose", "version").Run() != nil {
+		if _, err := exec.LookPath("docker-compose"); err != nil {
+			return nil, fmt.Errorf("'%s compose' is not available\nhint: install the Compose plugin (or docker-compose v2) to read compose files", dockerCmd())
+		}
+		cmd = exec.Command("docker-compose", args[1:]...)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("failed to read %s: %s", file, msg)
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	return stdout.Bytes(), nil
+}
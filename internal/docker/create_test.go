@@ -0,0 +1,298 @@
+package docker
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"devbox/internal/config"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func fixtureConfig(t testing.TB, data []byte) map[string]interface{} {
+	t.Helper()
+	pc, err := config.ParseProjectConfig(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := json.Marshal(pc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(encoded, &m); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func renderArgs(args []string) string {
+	owner := OwnerLabel + "=" + CurrentOwner()
+	var b strings.Builder
+	for _, arg := range args {
+		arg = strings.ReplaceAll(arg, owner, OwnerLabel+"=<uid>")
+		b.WriteString(arg)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func TestCreateArgsGolden(t *testing.T) {
+	t.Setenv("HOME", "/home/dev")
+	defer func(orig func() (int, int)) { hostIDs = orig }(hostIDs)
+	hostIDs = func() (int, int) { return 1000, 1000 }
+	tests := []struct {
+		name   string
+		client *Client
+		box    string
+		image  string
+	}{
+		{"minimal", &Client{}, "devbox_web", "ubuntu:22.04"},
+		{"full", &Client{}, "devbox_api", "ubuntu@sha256:0123abcd"},
+		{"dotfiles", &Client{}, "devbox_web", "ubuntu:22.04"},
+		{"gpus", &Client{}, "devbox_ml", "nvidia/cuda:12.4.1-base-ubuntu22.04"},
+		{"defaults", &Client{defaults: BoxDefaults{
+			Environment: map[string]string{"TZ": "UTC", "HTTP_PROXY": "http://proxy:3128"},
+			CPUs:        "2",
+			Memory:      "8g",
+			Restart:     "no",
+		}}, "devbox_web", "ubuntu:22.04"},
+		{"sync", &Client{}, "devbox_web", "node:20"},
+		{"remote", &Client{workspaceSync: true}, "devbox_ml", "python:3.12"},
+		{"secrets", &Client{}, "devbox_api", "node:20"},
+		{"nohealthcheck", &Client{}, "devbox_web", "postgres:16"},
+		{"createuser", &Client{}, "devbox_web", "node:20"},
+		{"metadata", &Client{metadata: func(boxName, workspaceHost string, config map[string]interface{}) BoxMetadata {
+			name, _ := config["name"].(string)
+			return BoxMetadata{Project: name, Workspace: workspaceHost, ConfigHash: "0123456789ab", Version: "1.0", Source: "up"}
+		}}, "devbox_web", "ubuntu:22.04"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", "create", tt.name+".json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			args := tt.client.CreateArgs(tt.box, tt.image, "/home/dev/devbox/"+strings.TrimPrefix(tt.box, "devbox_"), "/workspace", fixtureConfig(t, data))
+			got := renderArgs(args)
+
+			golden := filepath.Join("testdata", "create", tt.name+".golden")
+			if *updateGolden {
+				if err := os.WriteFile(golden, []byte(got), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run 'go test ./docker -run TestCreateArgsGolden -update' to create it)", err)
+			}
+			if got != string(want) {
+				t.Errorf("docker create args for %s changed:\n--- got\n%s--- want\n%s", tt.name, got, want)
+			}
+		})
+	}
+}
+
+func TestCreateArgsWithoutConfig(t *testing.T) {
+	args := (&Client{}).CreateArgs("devbox_web", "ubuntu:22.04", "/src/web", "/workspace", nil)
+	want := "create\n--name\ndevbox_web\n--mount\ntype=bind,source=/src/web,target=/workspace\n--workdir\n/workspace\n--label\n" +
+		OwnerLabel + "=<uid>\n-it\n--restart\nunless-stopped\nubuntu:22.04\nsleep\ninfinity\n"
+	if got := renderArgs(args); got != want {
+		t.Errorf("CreateArgs() without config =\n%s", got)
+	}
+}
+
+func TestHealthCmd(t *testing.T) {
+	tests := []struct {
+		test []string
+		want string
+	}{
+		{nil, ""},
+		{[]string{"CMD-SHELL", "curl -fsS http://localhost/health || exit 1"}, "curl -fsS http://localhost/health || exit 1"},
+		{[]string{"CMD", "curl", "-f", "http://localhost:8080/health"}, "curl -f http://localhost:8080/health"},
+		{[]string{"CMD", "pg_isready", "-U", "dev user", "it's"}, `pg_isready -U 'dev user' 'it'\''s'`},
+		{[]string{"CMD", "true", ""}, "true ''"},
+		{[]string{"curl", "-f", "http://localhost"}, "curl -f http://localhost"},
+	}
+	for _, tt := range tests {
+		if got := healthCmd(tt.test); got != tt.want {
+			t.Errorf("healthCmd(%q) = %q, want %q", tt.test, got, tt.want)
+		}
+	}
+}
+
+func TestSecretArchive(t *testing.T) {
+	files := map[string][]byte{"/run/secrets/db": []byte("hunter2"), "/home/node/.ssh/key": []byte("-----BEGIN KEY-----\n")}
+	data, err := secretArchive(files, fileOwner{UID: 1000, GID: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(bytes.NewReader(data))
+	var names []string
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(tr)
+		if string(body) != string(files["/"+hdr.Name]) {
+			t.Errorf("%s = %q, want %q", hdr.Name, body, files["/"+hdr.Name])
+		}
+		if hdr.Mode != 0400 || hdr.Uid != 1000 || hdr.Gid != 1000 {
+			t.Errorf("%s mode = %o owner = %d:%d, want 400 1000:1000", hdr.Name, hdr.Mode, hdr.Uid, hdr.Gid)
+		}
+		names = append(names, hdr.Name)
+	}
+	if strings.Join(names, ",") != "home/node/.ssh/key,run/secrets/db" {
+		t.Errorf("archive entries = %v", names)
+	}
+}
+
+func TestSecretFileOwner(t *testing.T) {
+	defer func(orig func() (int, int)) { hostIDs = orig }(hostIDs)
+	hostIDs = func() (int, int) { return 501, 20 }
+	passwd := []byte("root:x:0:0:root:/root:/bin/bash\nnode:x:1000:1000::/home/node:/bin/bash\n")
+	group := []byte("root:x:0:\nstaff:x:50:\n")
+	tests := []struct {
+		name    string
+		config  map[string]interface{}
+		want    fileOwner
+		wantErr bool
+	}{
+		{"root", nil, fileOwner{0, 0}, false},
+		{"named root", map[string]interface{}{"user": "root"}, fileOwner{0, 0}, false},
+		{"create_user", map[string]interface{}{"create_user": true, "user": "alice"}, fileOwner{501, 20}, false},
+		{"named", map[string]interface{}{"user": "node"}, fileOwner{1000, 1000}, false},
+		{"named with group", map[string]interface{}{"user": "node:staff"}, fileOwner{1000, 50}, false},
+		{"numeric", map[string]interface{}{"user": "1001:1002"}, fileOwner{1001, 1002}, false},
+		{"unknown user", map[string]interface{}{"user": "ghost"}, fileOwner{}, true},
+		{"unknown group", map[string]interface{}{"user": "node:ghosts"}, fileOwner{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := secretFileOwner(tt.config, passwd, group)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("secretFileOwner() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("secretFileOwner() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+	if needsIDFiles(map[string]interface{}{"user": "1000"}) || !needsIDFiles(map[string]interface{}{"user": "node"}) {
+		t.Error("needsIDFiles() should only read /etc/passwd for named users")
+	}
+}
+
+func TestHostBoxUser(t *testing.T) {
+	defer func(orig func() (int, int)) { hostIDs = orig }(hostIDs)
+	tests := []struct {
+		name     string
+		uid, gid int
+		config   map[string]interface{}
+		want     string
+	}{
+		{"off", 1000, 1000, map[string]interface{}{"user": "node"}, ""},
+		{"default name", 1000, 1000, map[string]interface{}{"create_user": true}, "dev:1000:1000"},
+		{"named", 501, 20, map[string]interface{}{"create_user": true, "user": "alice"}, "alice:501:20"},
+		{"host root", 0, 0, map[string]interface{}{"create_user": true}, ""},
+		{"no uids", -1, -1, map[string]interface{}{"create_user": true}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hostIDs = func() (int, int) { return tt.uid, tt.gid }
+			got := ""
+			if u, ok := hostBoxUser(tt.config); ok {
+				got = u.String()
+			}
+			if got != tt.want {
+				t.Errorf("hostBoxUser() = %q, want %q", got, tt.want)
+			}
+			if got == "" {
+				return
+			}
+			parsed, ok := ParseBoxUser(got + "\n")
+			if !ok || parsed.String() != got || parsed.Spec() != got[strings.Index(got, ":")+1:] {
+				t.Errorf("ParseBoxUser(%q) = %+v, %v", got, parsed, ok)
+			}
+		})
+	}
+	for _, bad := range []string{"", "<no value>", "dev:0:0", "dev:x:1", ":1000:1000", "dev:1000"} {
+		if u, ok := ParseBoxUser(bad); ok {
+			t.Errorf("ParseBoxUser(%q) = %+v, want not ok", bad, u)
+		}
+	}
+}
+
+func checkCreateArgs(args []string, name, image string) string {
+	n := len(args)
+	if n < 4 || args[0] != "create" {
+		return "does not start with create"
+	}
+	if args[n-3] != image || args[n-2] != "sleep" || args[n-1] != "infinity" {
+		return "does not end with the image and sleep infinity"
+	}
+	restarts := 0
+	for i := 1; i < n-3; i++ {
+		switch flag := args[i]; {
+		case flag == "-it" || flag == "--no-healthcheck":
+			continue
+		case !strings.HasPrefix(flag, "-"):
+			return "value " + flag + " is not preceded by a flag"
+		case i+1 >= n-3:
+			return "flag " + flag + " has no value"
+		case flag == "--name" && args[i+1] != name:
+			return "--name is not the box name"
+		case flag == "--restart":
+			restarts++
+		}
+		i++
+	}
+	if restarts != 1 {
+		return "expected exactly one --restart"
+	}
+	return ""
+}
+
+func FuzzCreateArgs(f *testing.F) {
+	files, _ := filepath.Glob(filepath.Join("testdata", "create", "*.json"))
+	for _, file := range files {
+		if data, err := os.ReadFile(file); err == nil {
+			f.Add(data, false)
+		}
+	}
+	f.Add([]byte(`{"ports":["--restart"],"environment":{"A":"-it"},"restart":""}`), true)
+	f.Add([]byte(`{"labels":{"":""},"dotfiles":["~"],"health_check":{"test":[]}}`), false)
+	f.Fuzz(func(t *testing.T, data []byte, remote bool) {
+		pc, err := config.ParseProjectConfig(data)
+		if err != nil {
+			return
+		}
+		encoded, err := json.Marshal(pc)
+		if err != nil {
+			t.Fatalf("failed to re-encode parsed config: %v", err)
+		}
+		var m map[string]interface{}
+		if err := json.Unmarshal(encoded, &m); err != nil {
+			t.Fatal(err)
+		}
+		stdout := os.Stdout
+		os.Stdout, _ = os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+		args := (&Client{workspaceSync: remote}).CreateArgs("devbox_fuzz", "ubuntu:22.04", "/src/fuzz", "/workspace", m)
+		os.Stdout.Close()
+		os.Stdout = stdout
+		if problem := checkCreateArgs(args, "devbox_fuzz", "ubuntu:22.04"); problem != "" {
+			t.Errorf("CreateArgs(%s) %s: %q", data, problem, args)
+		}
+	})
+}
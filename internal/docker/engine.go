@@ -0,0 +1,63 @@
+package docker
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+type EngineInfo struct {
+	Name     string
+	Path     string
+	Version  string
+	Rootless bool
+	Compose  bool
+}
+
+func Engine() string {
+	return dockerCmd()
+}
+
+func DetectEngine() (EngineInfo, error) {
+	info := EngineInfo{Name: filepath.Base(dockerCmd())}
+	path, err := exec.LookPath(dockerCmd())
+	if err != nil {
+		return info, err
+	}
+	info.Path = path
+	for _, format := range []string{"{{.Server.Version}}", "{{.Client.Version}}"} {
+		if out, err := exec.Command(path, "version", "--format", format).Output(); err == nil {
+			if v := strings.TrimSpace(string(out)); v != "" && v != "<no value>" {
+				info.Version = v
+				break
+			}
+		}
+	}
+	info.Rootless = engineRootless(path, info.Name)
+	info.Compose = exec.Command(path, "compose", "version").Run() == nil
+	return info, nil
+}
+
+func engineRootless(path, name string) bool {
+	if name == "podman" {
+		out, err := exec.Command(path, "info", "--format", "{{.Host.Security.Rootless}}").Output()
+		return err == nil && strings.TrimSpace(string(out)) == "true"
+	}
+	out, err := exec.Command(path, "info", "--format", "{{json .SecurityOptions}}").Output()
+	return err == nil && strings.Contains(string(out), "rootless")
+}
+
+func (e EngineInfo) Capabilities() string {
+	var caps []string
+	if e.Rootless {
+		caps = append(caps, "rootless")
+	} else {
+		caps = append(caps, "rootful")
+	}
+	if e.Compose {
+		caps = append(caps, "compose")
+	} else {
+		caps = append(caps, "no compose")
+	}
+	return strings.Join(caps, ", ")
+}
@@ -0,0 +1,220 @@
+package docker
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	boxListFormat = fmt.Sprintf(`{"name":{{json .Names}},"state":{{json .State}},"status":{{json .Status}},"image":{{json .Image}},"owner":{{json (.Label %q)}},"service":{{json (.Label %q)}},"project":{{json (.Label %q)}},"workspace":{{json (.Label %q)}},"config_hash":{{json (.Label %q)}},"version":{{json (.Label %q)}},"source":{{json (.Label %q)}}}`, OwnerLabel, ServiceLabel, ProjectLabel, WorkspaceLabel, ConfigHashLabel, VersionLabel, SourceLabel)
+
+	serviceListFormat = fmt.Sprintf(`{"name":{{json .Names}},"state":{{json .State}},"status":{{json .Status}},"image":{{json .Image}},"service":{{json (.Label %q)}},"hash":{{json (.Label %q)}}}`, ServiceLabel, ServiceHashLabel)
+
+	statsFormat = `{"cpu":{{json .CPUPerc}},"mem_usage":{{json .MemUsage}},"mem_percent":{{json .MemPerc}},"net_io":{{json .NetIO}},"block_io":{{json .BlockIO}},"pids":{{json .PIDs}}}`
+
+	imageListFormat = `{"repository":{{json .Repository}},"tag":{{json .Tag}},"id":{{json .ID}},"created_at":{{json .CreatedAt}}}`
+
+	containerImageFormat = `{"name":{{json .Name}},"image":{{json .Image}}}`
+)
+
+type boxListEntry struct {
+	Name    string `json:"name"`
+	State   string `json:"state"`
+	Status  string `json:"status"`
+	Image   string `json:"image"`
+	Owner   string `json:"owner"`
+	Service string `json:"service"`
+	BoxMetadata
+}
+
+type serviceListEntry struct {
+	Name    string `json:"name"`
+	State   string `json:"state"`
+	Status  string `json:"status"`
+	Image   string `json:"image"`
+	Service string `json:"service"`
+	Hash    string `json:"hash"`
+}
+
+type statsEntry struct {
+	CPU        string `json:"cpu"`
+	MemUsage   string `json:"mem_usage"`
+	MemPercent string `json:"mem_percent"`
+	NetIO      string `json:"net_io"`
+	BlockIO    string `json:"block_io"`
+	PIDs       string `json:"pids"`
+}
+
+type containerImageEntry struct {
+	Name  string `json:"name"`
+	Image string `json:"image"`
+}
+
+type imageListEntry struct {
+	Repository string `json:"repository"`
+	Tag        string `json:"tag"`
+	ID         string `json:"id"`
+	CreatedAt  string `json:"created_at"`
+}
+
+func decodeJSONLines[T any](out []byte) ([]T, error) {
+	var entries []T
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var entry T
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return nil, fmt.Errorf("unexpected output %q: %w", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func parseBoxList(out []byte) ([]BoxInfo, error) {
+	entries, err := decodeJSONLines[boxListEntry](out)
+	if err != nil {
+		return nil, err
+	}
+	var boxes []BoxInfo
+	for _, e := range entries {
+		if (!strings.HasPrefix(e.Name, "devbox_") && !e.Known()) || e.Service != "" {
+			continue
+		}
+		boxes = append(boxes, BoxInfo{
+			Names:    []string{e.Name},
+			State:    strings.ToLower(strings.TrimSpace(e.State)),
+			Status:   e.Status,
+			Image:    e.Image,
+			Owner:    strings.TrimSpace(e.Owner),
+			Metadata: e.BoxMetadata,
+		})
+	}
+	return boxes, nil
+}
+
+func parseServiceList(out []byte) ([]ServiceInfo, error) {
+	entries, err := decodeJSONLines[serviceListEntry](out)
+	if err != nil {
+		return nil, err
+	}
+	var services []ServiceInfo
+	for _, e := range entries {
+		if e.Service == "" {
+			continue
+		}
+		services = append(services, ServiceInfo{
+			Name:    e.Name,
+			Service: e.Service,
+			State:   strings.ToLower(strings.TrimSpace(e.State)),
+			Status:  e.Status,
+			Image:   e.Image,
+			Hash:    e.Hash,
+		})
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].Service < services[j].Service })
+	return services, nil
+}
+
+func parseContainerImages(out []byte) (map[string]string, error) {
+	entries, err := decodeJSONLines[containerImageEntry](out)
+	if err != nil {
+		return nil, err
+	}
+	images := make(map[string]string, len(entries))
+	for _, e := range entries {
+		images[strings.TrimPrefix(e.Name, "/")] = e.Image
+	}
+	return images, nil
+}
+
+func parseContainerStats(out []byte) (*ContainerStats, error) {
+	entries, err := decodeJSONLines[statsEntry](out)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return &ContainerStats{}, nil
+	}
+	e := entries[0]
+	return &ContainerStats{
+		CPUPercent: strings.TrimSpace(e.CPU),
+		MemUsage:   strings.TrimSpace(e.MemUsage),
+		MemPercent: strings.TrimSpace(e.MemPercent),
+		NetIO:      strings.TrimSpace(e.NetIO),
+		BlockIO:    strings.TrimSpace(e.BlockIO),
+		PIDs:       strings.TrimSpace(e.PIDs),
+	}, nil
+}
+
+func parseImageList(out []byte) ([]ImageInfo, error) {
+	entries, err := decodeJSONLines[imageListEntry](out)
+	if err != nil {
+		return nil, err
+	}
+	images := make([]ImageInfo, 0, len(entries))
+	for _, e := range entries {
+		created, _ := time.Parse("2006-01-02 15:04:05 -0700 MST", strings.TrimSpace(e.CreatedAt))
+		images = append(images, ImageInfo{
+			Repository: e.Repository,
+			Tag:        e.Tag,
+			ID:         e.ID,
+			CreatedAt:  created,
+		})
+	}
+	return images, nil
+}
+
+type HealthStatus struct {
+	Status        string
+	FailingStreak int
+	LastOutput    string
+	LastExitCode  int
+	LastCheck     time.Time
+}
+
+func (h *HealthStatus) Configured() bool {
+	return h != nil && h.Status != "" && h.Status != "none"
+}
+
+func parseHealth(out []byte) (*HealthStatus, error) {
+	out = bytes.TrimSpace(out)
+	if len(out) == 0 || string(out) == "null" || string(out) == "<no value>" {
+		return &HealthStatus{Status: "none"}, nil
+	}
+	var raw struct {
+		Status        string `json:"Status"`
+		FailingStreak int    `json:"FailingStreak"`
+		Log           []struct {
+			End      time.Time `json:"End"`
+			ExitCode int       `json:"ExitCode"`
+			Output   string    `json:"Output"`
+		} `json:"Log"`
+	}
+	if err := json.Unmarshal(out, &raw); err != nil {
+		return nil, fmt.Errorf("unexpected health output %q: %w", out, err)
+	}
+	h := &HealthStatus{Status: strings.ToLower(raw.Status), FailingStreak: raw.FailingStreak}
+	if h.Status == "" {
+		h.Status = "none"
+	}
+	if n := len(raw.Log); n > 0 {
+		last := raw.Log[n-1]
+		h.LastOutput = strings.TrimSpace(last.Output)
+		h.LastExitCode = last.ExitCode
+		h.LastCheck = last.End
+	}
+	return h, nil
+}
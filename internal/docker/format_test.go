@@ -0,0 +1,154 @@
+package docker
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseBoxList(t *testing.T) {
+	out := []byte(`{"name":"devbox_web","state":"running","status":"Läuft seit 3 Stunden","image":"ubuntu:22.04","owner":"1000"}
+{"name":"postgres","state":"running","status":"Up 2 days","image":"postgres:16","owner":""}
+{"name":"devbox_web.db","state":"running","status":"Up 3 hours","image":"postgres:16","owner":"1000","service":"db"}
+
+{"name":"devbox_api","state":"exited","status":"Beendet (0) vor 2 Tagen","image":"devbox/api:latest","owner":""}
+{"name":"renamed-box","state":"exited","status":"Exited (0) 1 day ago","image":"ubuntu:24.04","owner":"1000","project":"docs","workspace":"/home/me/devbox/docs","config_hash":"0123456789ab","version":"1.0","source":"up"}
+`)
+	boxes, err := parseBoxList(out)
+	if err != nil {
+		t.Fatalf("parseBoxList: %v", err)
+	}
+	if len(boxes) != 3 {
+		t.Fatalf("got %d boxes, want 3: %+v", len(boxes), boxes)
+	}
+	if b := boxes[0]; b.Names[0] != "devbox_web" || !b.Running() || b.Owner != "1000" || b.Image != "ubuntu:22.04" {
+		t.Errorf("boxes[0] = %+v", b)
+	}
+	if b := boxes[1]; b.Names[0] != "devbox_api" || b.Running() || b.State != "exited" || b.Owner != "" || b.Metadata.Known() {
+		t.Errorf("boxes[1] = %+v", b)
+	}
+	want := BoxMetadata{Project: "docs", Workspace: "/home/me/devbox/docs", ConfigHash: "0123456789ab", Version: "1.0", Source: "up"}
+	if b := boxes[2]; b.Names[0] != "renamed-box" || b.Metadata != want {
+		t.Errorf("boxes[2] = %+v, want metadata %+v", b, want)
+	}
+}
+
+func TestParseBoxListRejectsGarbage(t *testing.T) {
+	if _, err := parseBoxList([]byte("devbox_web\tUp 3 hours\tubuntu\n")); err == nil {
+		t.Error("expected error for non-JSON output")
+	}
+}
+
+func TestParseServiceList(t *testing.T) {
+	out := []byte(`{"name":"devbox_web.db","state":"running","status":"Up 3 hours","image":"postgres:16","service":"db","hash":"abc123"}
+{"name":"devbox_web.cache","state":"exited","status":"Exited (0) 1 hour ago","image":"redis:7","service":"cache","hash":"def456"}
+{"name":"devbox_web","state":"running","status":"Up 3 hours","image":"ubuntu:22.04","service":"","hash":""}
+`)
+	services, err := parseServiceList(out)
+	if err != nil {
+		t.Fatalf("parseServiceList: %v", err)
+	}
+	if len(services) != 2 {
+		t.Fatalf("got %d services, want 2: %+v", len(services), services)
+	}
+	if s := services[0]; s.Service != "cache" || s.Running() || s.Name != "devbox_web.cache" || s.Hash != "def456" {
+		t.Errorf("services[0] = %+v", s)
+	}
+	if s := services[1]; s.Service != "db" || !s.Running() || s.Image != "postgres:16" {
+		t.Errorf("services[1] = %+v", s)
+	}
+}
+
+func TestParseContainerStats(t *testing.T) {
+	out := []byte(`{"cpu":"0.25%","mem_usage":"12.5MiB / 1.944GiB","mem_percent":"0.63%","net_io":"1.2kB / 0B","block_io":"0B / 0B","pids":"3"}` + "\n")
+	stats, err := parseContainerStats(out)
+	if err != nil {
+		t.Fatalf("parseContainerStats: %v", err)
+	}
+	want := ContainerStats{CPUPercent: "0.25%", MemUsage: "12.5MiB / 1.944GiB", MemPercent: "0.63%", NetIO: "1.2kB / 0B", BlockIO: "0B / 0B", PIDs: "3"}
+	if *stats != want {
+		t.Errorf("stats = %+v, want %+v", *stats, want)
+	}
+
+	empty, err := parseContainerStats(nil)
+	if err != nil || *empty != (ContainerStats{}) {
+		t.Errorf("parseContainerStats(nil) = %+v, %v", empty, err)
+	}
+}
+
+func TestParseImageList(t *testing.T) {
+	out := []byte(`{"repository":"devbox/web","tag":"backup-20240101","id":"sha256:abc","created_at":"2024-01-01 10:00:00 +0000 UTC"}
+{"repository":"<none>","tag":"<none>","id":"sha256:def","created_at":"not a date"}
+`)
+	images, err := parseImageList(out)
+	if err != nil {
+		t.Fatalf("parseImageList: %v", err)
+	}
+	if len(images) != 2 {
+		t.Fatalf("got %d images, want 2", len(images))
+	}
+	if images[0].Tag != "backup-20240101" || !images[0].CreatedAt.Equal(time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("images[0] = %+v", images[0])
+	}
+	if images[1].ID != "sha256:def" || !images[1].CreatedAt.IsZero() {
+		t.Errorf("images[1] = %+v", images[1])
+	}
+}
+
+func TestParseContainerImages(t *testing.T) {
+	out := []byte(`{"name":"/devbox_web","image":"sha256:abc"}
+{"name":"/devbox_web.db","image":"sha256:def"}
+`)
+	images, err := parseContainerImages(out)
+	if err != nil {
+		t.Fatalf("parseContainerImages: %v", err)
+	}
+	want := map[string]string{"devbox_web": "sha256:abc", "devbox_web.db": "sha256:def"}
+	if !reflect.DeepEqual(images, want) {
+		t.Errorf("parseContainerImages() = %v, want %v", images, want)
+	}
+	if _, err := parseContainerImages([]byte("devbox_web sha256:abc\n")); err == nil {
+		t.Error("parseContainerImages() accepted non-JSON output")
+	}
+}
+
+func TestParseHealth(t *testing.T) {
+	tests := []struct {
+		name       string
+		in         string
+		status     string
+		streak     int
+		output     string
+		exitCode   int
+		configured bool
+	}{
+		{name: "no health check", in: "null\n", status: "none"},
+		{name: "empty", in: "", status: "none"},
+		{name: "starting", in: `{"Status":"starting","FailingStreak":0,"Log":[]}`, status: "starting", configured: true},
+		{
+			name:       "unhealthy",
+			in:         `{"Status":"unhealthy","FailingStreak":3,"Log":[{"Start":"2024-01-01T10:00:00Z","End":"2024-01-01T10:00:01Z","ExitCode":0,"Output":"ok"},{"Start":"2024-01-01T10:00:30Z","End":"2024-01-01T10:00:31.5Z","ExitCode":7,"Output":"curl: (7) Failed to connect\n"}]}`,
+			status:     "unhealthy",
+			streak:     3,
+			output:     "curl: (7) Failed to connect",
+			exitCode:   7,
+			configured: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := parseHealth([]byte(tt.in))
+			if err != nil {
+				t.Fatalf("parseHealth: %v", err)
+			}
+			if h.Status != tt.status || h.FailingStreak != tt.streak || h.LastOutput != tt.output || h.LastExitCode != tt.exitCode || h.Configured() != tt.configured {
+				t.Errorf("parseHealth() = %+v", h)
+			}
+		})
+	}
+
+	h, _ := parseHealth([]byte(tests[3].in))
+	if want := time.Date(2024, 1, 1, 10, 0, 31, 500000000, time.UTC); !h.LastCheck.Equal(want) {
+		t.Errorf("LastCheck = %v, want %v", h.LastCheck, want)
+	}
+}
@@ -0,0 +1,39 @@
+package docker
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+)
+
+func ForwardScript(port int) string {
+	return fmt.Sprintf(`if command -v socat >/dev/null 2>&1; then exec socat - TCP:127.0.0.1:%[1]d; fi
+exec 3<>/dev/tcp/127.0.0.1/%[1]d 4<&0 || exit 1
+cat <&3 &
+cat <&4 >&3 &
+wait -n
+kill $(jobs -p) 2>/dev/null`, port)
+}
+
+func (c *Client) ForwardConn(boxName string, port int, conn io.ReadWriter) error {
+	cmd := exec.Command(dockerCmd(), "exec", "-i", boxName, "bash", "-c", ForwardScript(port))
+	var stderr bytes.Buffer
+	cmd.Stdout = conn
+	cmd.Stderr = &stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start forward: %w", err)
+	}
+	go func() {
+		_, _ = io.Copy(stdin, conn)
+		_ = stdin.Close()
+	}()
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("forward to port %d in %s: %s", port, boxName, firstLine(stderr.String(), err))
+	}
+	return nil
+}
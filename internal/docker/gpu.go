@@ -0,0 +1,142 @@
+package docker
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type GPUInfo struct {
+	Index       int     `json:"index"`
+	Name        string  `json:"name"`
+	MemoryUsed  int64   `json:"memory_used_bytes"`
+	MemoryTotal int64   `json:"memory_total_bytes"`
+	Utilization float64 `json:"utilization_percent"`
+}
+
+var cdiSpecPaths = []string{"/etc/cdi/nvidia.yaml", "/etc/cdi/nvidia.json", "/var/run/cdi/nvidia.yaml", "/var/run/cdi/nvidia.json"}
+
+func GPUFlag(value string) string {
+	value = strings.TrimSpace(value)
+	if value == "all" {
+		return value
+	}
+	if _, err := strconv.Atoi(value); err == nil {
+		return value
+	}
+	return `"device=` + strings.TrimPrefix(value, "device=") + `"`
+}
+
+func CheckGPUSupport() error {
+	switch engine := filepath.Base(dockerCmd()); engine {
+	case "podman":
+		for _, path := range cdiSpecPaths {
+			if _, err := os.Stat(path); err == nil {
+				return nil
+			}
+		}
+		return fmt.Errorf("no NVIDIA CDI spec found for podman; install nvidia-container-toolkit and run 'sudo nvidia-ctk cdi generate --output=/etc/cdi/nvidia.yaml'")
+	case "nerdctl":
+		if _, err := exec.LookPath("nvidia-container-cli"); err == nil {
+			return nil
+		}
+		return fmt.Errorf("nvidia-container-cli not found; install nvidia-container-toolkit to use gpus with nerdctl")
+	}
+	out, err := exec.Command(dockerCmd(), "info", "--format", "{{json .Runtimes}}").Output()
+	if err == nil {
+		var runtimes map[string]interface{}
+		if json.Unmarshal(out, &runtimes) == nil {
+			if _, ok := runtimes["nvidia"]; ok {
+				return nil
+			}
+		}
+	}
+	if !IsRemoteEndpoint(DaemonEndpoint()) {
+		if _, err := exec.LookPath("nvidia-container-runtime-hook"); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("the Docker daemon has no nvidia runtime; install nvidia-container-toolkit, run 'sudo nvidia-ctk runtime configure --runtime=docker' and restart Docker")
+}
+
+func (c *Client) GetRequestedGPUs(boxName string) (string, error) {
+	out, err := exec.Command(dockerCmd(), "inspect", "--format", "{{json .HostConfig.DeviceRequests}}", boxName).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect container: %w", err)
+	}
+	return parseDeviceRequests(out), nil
+}
+
+func parseDeviceRequests(data []byte) string {
+	var requests []struct {
+		Count        int
+		DeviceIDs    []string
+		Capabilities [][]string
+	}
+	if json.Unmarshal(data, &requests) != nil {
+		return ""
+	}
+	for _, r := range requests {
+		gpu := false
+		for _, caps := range r.Capabilities {
+			for _, c := range caps {
+				gpu = gpu || c == "gpu"
+			}
+		}
+		if !gpu {
+			continue
+		}
+		switch {
+		case len(r.DeviceIDs) > 0:
+			return "device=" + strings.Join(r.DeviceIDs, ",")
+		case r.Count < 0:
+			return "all"
+		case r.Count > 0:
+			return strconv.Itoa(r.Count)
+		}
+	}
+	return ""
+}
+
+func (c *Client) GetGPUs(boxName string) ([]GPUInfo, error) {
+	cmd := exec.Command(dockerCmd(), "exec", boxName, "nvidia-smi", "--query-gpu=index,name,memory.used,memory.total,utilization.gpu", "--format=csv,noheader,nounits")
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("nvidia-smi failed in box: %w", err)
+	}
+	return parseNvidiaSMI(out)
+}
+
+func parseNvidiaSMI(data []byte) ([]GPUInfo, error) {
+	var gpus []GPUInfo
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		fields := strings.Split(line, ",")
+		if len(fields) != 5 {
+			return nil, fmt.Errorf("unexpected nvidia-smi output: %s", line)
+		}
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		index, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("unexpected nvidia-smi output: %s", line)
+		}
+		gpu := GPUInfo{Index: index, Name: fields[1]}
+		if used, err := strconv.ParseInt(fields[2], 10, 64); err == nil {
+			gpu.MemoryUsed = used << 20
+		}
+		if total, err := strconv.ParseInt(fields[3], 10, 64); err == nil {
+			gpu.MemoryTotal = total << 20
+		}
+		gpu.Utilization, _ = strconv.ParseFloat(fields[4], 64)
+		gpus = append(gpus, gpu)
+	}
+	return gpus, nil
+}
@@ -0,0 +1,108 @@
+package docker
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+const (
+	ProjectLabel    = "devbox.project"
+	WorkspaceLabel  = "devbox.workspace"
+	ConfigHashLabel = "devbox.config-hash"
+	VersionLabel    = "devbox.version"
+	SourceLabel     = "devbox.source"
+)
+
+var metadataLabels = []string{ProjectLabel, WorkspaceLabel, ConfigHashLabel, VersionLabel, SourceLabel}
+
+type BoxMetadata struct {
+	Project    string `json:"project,omitempty"`
+	Workspace  string `json:"workspace,omitempty"`
+	ConfigHash string `json:"config_hash,omitempty"`
+	Version    string `json:"version,omitempty"`
+	Source     string `json:"source,omitempty"`
+}
+
+type BoxMetadataFunc func(boxName, workspaceHost string, config map[string]interface{}) BoxMetadata
+
+func (c *Client) SetBoxMetadata(fn BoxMetadataFunc) {
+	c.metadata = fn
+}
+
+func (m BoxMetadata) Known() bool {
+	return m.Project != ""
+}
+
+func (m BoxMetadata) CreatedBy() string {
+	if m.Version == "" && m.Source == "" {
+		return ""
+	}
+	s := "devbox"
+	if m.Version != "" {
+		s += " v" + m.Version
+	}
+	if m.Source != "" {
+		s += " (" + m.Source + ")"
+	}
+	return s
+}
+
+func (m BoxMetadata) Labels() map[string]string {
+	labels := map[string]string{}
+	for key, value := range map[string]string{
+		ProjectLabel:    m.Project,
+		WorkspaceLabel:  m.Workspace,
+		ConfigHashLabel: m.ConfigHash,
+		VersionLabel:    m.Version,
+		SourceLabel:     m.Source,
+	} {
+		if value != "" {
+			labels[key] = value
+		}
+	}
+	return labels
+}
+
+func ParseBoxMetadata(labels map[string]string) BoxMetadata {
+	return BoxMetadata{
+		Project:    labels[ProjectLabel],
+		Workspace:  labels[WorkspaceLabel],
+		ConfigHash: labels[ConfigHashLabel],
+		Version:    labels[VersionLabel],
+		Source:     labels[SourceLabel],
+	}
+}
+
+func IsMetadataLabel(key string) bool {
+	for _, l := range metadataLabels {
+		if l == key {
+			return true
+		}
+	}
+	return false
+}
+
+func metadataArgs(m BoxMetadata) []string {
+	labels := m.Labels()
+	var args []string
+	for _, key := range metadataLabels {
+		if value, ok := labels[key]; ok {
+			args = append(args, "--label", fmt.Sprintf("%s=%s", key, value))
+		}
+	}
+	return args
+}
+
+func (c *Client) GetBoxMetadata(boxName string) (BoxMetadata, error) {
+	out, err := exec.Command(dockerCmd(), "container", "inspect", "--format", "{{json .Config.Labels}}", boxName).Output()
+	if err != nil {
+		return BoxMetadata{}, fmt.Errorf("failed to inspect box %s: %w", boxName, err)
+	}
+	var labels map[string]string
+	if err := json.Unmarshal([]byte(strings.TrimSpace(string(out))), &labels); err != nil {
+		return BoxMetadata{}, fmt.Errorf("unexpected labels for box %s: %w", boxName, err)
+	}
+	return ParseBoxMetadata(labels), nil
+}
@@ -0,0 +1,100 @@
+package docker
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultPullRetries = 3
+
+var pullRetryDelay = 5 * time.Second
+
+var rateLimitMarkers = []string{
+	"toomanyrequests",
+	"too many requests",
+	"pull rate limit",
+	"rate limit exceeded",
+}
+
+type RateLimitError struct {
+	Image    string
+	Attempts int
+	Message  string
+}
+
+func (e *RateLimitError) Error() string {
+	engine := filepath.Base(dockerCmd())
+	return fmt.Sprintf("registry rate limit reached pulling %s after %d attempt(s): %s\n"+
+		"hint: run '%s login' to pull with your account's higher limit, or configure a registry mirror (\"registry-mirrors\" in /etc/docker/daemon.json)", e.Image, e.Attempts, e.Message, engine)
+}
+
+func IsRateLimited(output string) bool {
+	lower := strings.ToLower(output)
+	for _, marker := range rateLimitMarkers {
+		if strings.Contains(lower, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+func pullRetries() int {
+	if v := strings.TrimSpace(os.Getenv("DEVBOX_PULL_RETRIES")); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			return n
+		}
+	}
+	return defaultPullRetries
+}
+
+func (c *Client) pull(image string) error {
+	retries := pullRetries()
+	delay := pullRetryDelay
+	for attempt := 1; ; attempt++ {
+		cmd := exec.Command(dockerCmd(), "pull", image)
+		var stderr bytes.Buffer
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = &stderr
+		err := cmd.Run()
+		if err == nil {
+			return nil
+		}
+		output := strings.TrimSpace(stderr.String())
+		if !IsRateLimited(output) {
+			if output != "" {
+				fmt.Fprintln(os.Stderr, output)
+			}
+			return err
+		}
+		if attempt > retries {
+			return &RateLimitError{Image: image, Attempts: attempt, Message: firstLine(output, err)}
+		}
+		fmt.Printf("Registry rate limit hit pulling %s; retrying in %s (attempt %d/%d)...\n", image, delay, attempt+1, retries+1)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+func (c *Client) RefreshImage(image string, allowCached bool) error {
+	err := c.pull(image)
+	if err == nil {
+		return nil
+	}
+	if _, limited := err.(*RateLimitError); limited && allowCached && c.imageExists(image) {
+		fmt.Printf("Warning: %v\n", err)
+		fmt.Printf("Warning: using the cached copy of %s, which may be out of date\n", image)
+		return nil
+	}
+	return err
+}
+
+func (c *Client) imageExists(image string) bool {
+	output, err := exec.Command(dockerCmd(), "images", "-q", image).Output()
+	return err == nil && len(strings.TrimSpace(string(output))) > 0
+}
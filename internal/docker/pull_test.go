@@ -0,0 +1,112 @@
+package docker
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIsRateLimited(t *testing.T) {
+	tests := []struct {
+		output string
+		want   bool
+	}{
+		{"Error response from daemon: toomanyrequests: You have reached your pull rate limit. You may increase the limit by authenticating and upgrading: https://www.docker.com/increase-rate-limit", true},
+		{"Error: initializing source docker://ubuntu:22.04: reading manifest 22.04 in docker.io/library/ubuntu: toomanyrequests: too many requests", true},
+		{"failed to resolve reference \"docker.io/library/node:20\": unexpected status from HEAD request: 429 Too Many Requests", true},
+		{"Error response from daemon: manifest for ubuntu:99.04 not found: manifest unknown", false},
+		{"Error response from daemon: pull access denied for private/app, repository does not exist or may require 'docker login'", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := IsRateLimited(tt.output); got != tt.want {
+			t.Errorf("IsRateLimited(%q) = %v, want %v", tt.output, got, tt.want)
+		}
+	}
+}
+
+func fakePullEngine(t *testing.T, failures int, message string) string {
+	t.Helper()
+	dir := t.TempDir()
+	count := filepath.Join(dir, "pulls")
+	script := filepath.Join(dir, "engine")
+	body := "#!/bin/sh\n" +
+		"case \"$1\" in\n" +
+		"images) [ -f " + filepath.Join(dir, "cached") + " ] && echo 0123abcd; exit 0 ;;\n" +
+		"pull) echo x >> " + count + "; n=$(wc -l < " + count + ")\n" +
+		"  if [ \"$n\" -le " + strconv.Itoa(failures) + " ]; then echo '" + message + "' >&2; exit 1; fi; echo pulled ;;\n" +
+		"esac\n"
+	if err := os.WriteFile(script, []byte(body), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DEVBOX_ENGINE", script)
+	delay := pullRetryDelay
+	pullRetryDelay = time.Millisecond
+	t.Cleanup(func() { pullRetryDelay = delay })
+	return dir
+}
+
+func pullCount(t *testing.T, dir string) int {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, "pulls"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Count(string(data), "x")
+}
+
+func TestPullRetriesRateLimit(t *testing.T) {
+	const limited = "Error response from daemon: toomanyrequests: You have reached your pull rate limit."
+
+	dir := fakePullEngine(t, 2, limited)
+	if err := (&Client{}).PullImage("ubuntu:22.04"); err != nil {
+		t.Fatalf("PullImage() error = %v, want success on the third attempt", err)
+	}
+	if n := pullCount(t, dir); n != 3 {
+		t.Errorf("PullImage() pulled %d times, want 3", n)
+	}
+
+	dir = fakePullEngine(t, 9, limited)
+	t.Setenv("DEVBOX_PULL_RETRIES", "1")
+	err := (&Client{}).PullImage("ubuntu:22.04")
+	var rateLimit *RateLimitError
+	if !errors.As(err, &rateLimit) || rateLimit.Attempts != 2 {
+		t.Fatalf("PullImage() error = %v, want a RateLimitError after 2 attempts", err)
+	}
+	if !strings.Contains(err.Error(), "login") || !strings.Contains(err.Error(), "registry-mirrors") {
+		t.Errorf("PullImage() error = %q, want login and mirror hints", err)
+	}
+	if n := pullCount(t, dir); n != 2 {
+		t.Errorf("PullImage() pulled %d times, want 2", n)
+	}
+
+	dir = fakePullEngine(t, 9, "Error response from daemon: manifest unknown")
+	if err := (&Client{}).PullImage("ubuntu:99.04"); err == nil || errors.As(err, &rateLimit) {
+		t.Errorf("PullImage() error = %v, want a plain pull failure", err)
+	}
+	if n := pullCount(t, dir); n != 1 {
+		t.Errorf("PullImage() retried a non-rate-limit failure %d times", n)
+	}
+}
+
+func TestRefreshImageFallsBackToCache(t *testing.T) {
+	t.Setenv("DEVBOX_PULL_RETRIES", "0")
+	dir := fakePullEngine(t, 9, "toomanyrequests: too many requests")
+
+	if err := (&Client{}).RefreshImage("node:20", true); err == nil {
+		t.Error("RefreshImage() without a cached image should fail")
+	}
+	if err := os.WriteFile(filepath.Join(dir, "cached"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := (&Client{}).RefreshImage("node:20", true); err != nil {
+		t.Errorf("RefreshImage() with a cached image = %v, want the cached copy", err)
+	}
+	if err := (&Client{}).RefreshImage("node:20", false); err == nil {
+		t.Error("RefreshImage() without allowCached should fail")
+	}
+}
@@ -0,0 +1,324 @@
+package docker
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+func WorkspaceVolumeName(boxName string) string {
+	return boxName + "_workspace"
+}
+
+func (c *Client) SetWorkspaceSync(enabled bool) {
+	c.workspaceSync = enabled
+}
+
+func (c *Client) WorkspaceSync() bool {
+	return c.workspaceSync
+}
+
+func SyncedWorkspace(config map[string]interface{}) bool {
+	workspace, _ := config["workspace"].(map[string]interface{})
+	mode, _ := workspace["mode"].(string)
+	return mode == "sync"
+}
+
+func IsDockerHostURL(value string) bool {
+	return strings.Contains(value, "://")
+}
+
+func currentDockerContext() string {
+	if v := strings.TrimSpace(os.Getenv("DOCKER_CONTEXT")); v != "" {
+		return v
+	}
+	dir := os.Getenv("DOCKER_CONFIG")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".docker")
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if err != nil {
+		return ""
+	}
+	var cfg struct {
+		CurrentContext string `json:"currentContext"`
+	}
+	if json.Unmarshal(data, &cfg) != nil {
+		return ""
+	}
+	return cfg.CurrentContext
+}
+
+func ContextEndpoint(name string) (string, error) {
+	out, err := exec.Command("docker", "context", "inspect", name, "--format", "{{.Endpoints.docker.Host}}").Output()
+	if err != nil {
+		return "", fmt.Errorf("docker context '%s' not found", name)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func DaemonEndpoint() string {
+	if host := strings.TrimSpace(os.Getenv("DOCKER_HOST")); host != "" {
+		return host
+	}
+	if host := strings.TrimSpace(os.Getenv("CONTAINER_HOST")); host != "" && filepath.Base(dockerCmd()) == "podman" {
+		return host
+	}
+	if filepath.Base(dockerCmd()) != "docker" {
+		return ""
+	}
+	if name := currentDockerContext(); name != "" && name != "default" {
+		endpoint, _ := ContextEndpoint(name)
+		return endpoint
+	}
+	return ""
+}
+
+func IsRemoteEndpoint(endpoint string) bool {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme == "" {
+		return false
+	}
+	switch u.Scheme {
+	case "unix", "npipe", "fd":
+		return false
+	}
+	host := u.Hostname()
+	if host == "" || host == "localhost" {
+		return false
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return false
+	}
+	return true
+}
+
+func (c *Client) volumeExists(name string) bool {
+	return exec.Command(dockerCmd(), "volume", "inspect", name).Run() == nil
+}
+
+func (c *Client) RemoveVolume(name string) error {
+	cmd := exec.Command(dockerCmd(), "volume", "rm", name)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if s := strings.TrimSpace(stderr.String()); s != "" {
+			return fmt.Errorf("failed to remove volume: %s", s)
+		}
+		return fmt.Errorf("failed to remove volume: %w", err)
+	}
+	return nil
+}
+
+type VolumeInfo struct {
+	Name  string
+	Owner string
+}
+
+func (c *Client) ListDanglingVolumes() ([]VolumeInfo, error) {
+	format := fmt.Sprintf("{{.Name}}\t{{.Label %q}}", OwnerLabel)
+	cmd := exec.Command(dockerCmd(), "volume", "ls", "--filter", "dangling=true", "--format", format)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if s := strings.TrimSpace(stderr.String()); s != "" {
+			return nil, fmt.Errorf("failed to list volumes: %s", s)
+		}
+		return nil, fmt.Errorf("failed to list volumes: %w", err)
+	}
+	var vols []VolumeInfo
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		name, owner, _ := strings.Cut(strings.TrimSpace(line), "\t")
+		if name != "" {
+			vols = append(vols, VolumeInfo{Name: name, Owner: owner})
+		}
+	}
+	return vols, nil
+}
+
+func (c *Client) SyncWorkspaceToBox(boxName, workspaceHost, workspaceBox string) error {
+	return c.copyWorkspace(strings.TrimRight(workspaceHost, "/")+"/.", boxName+":"+workspaceBox)
+}
+
+func (c *Client) SyncWorkspaceFromBox(boxName, workspaceBox, workspaceHost string) error {
+	return c.copyWorkspace(boxName+":"+strings.TrimRight(workspaceBox, "/")+"/.", workspaceHost)
+}
+
+func (c *Client) copyWorkspace(src, dst string) error {
+	cmd := exec.Command(dockerCmd(), "cp", src, dst)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if s := strings.TrimSpace(stderr.String()); s != "" {
+			return fmt.Errorf("failed to sync workspace: %s", s)
+		}
+		return fmt.Errorf("failed to sync workspace: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) CopyFilesToBox(boxName, root, dest string, files []string) error {
+	if len(files) == 0 {
+		return nil
+	}
+	cmd := exec.Command(dockerCmd(), "exec", "-i", boxName, "tar", "-xf", "-", "-C", dest)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("failed to sync workspace: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to sync workspace: %w", err)
+	}
+	writeErr := writeTar(stdin, root, files)
+	stdin.Close()
+	if err := cmd.Wait(); err != nil {
+		if s := strings.TrimSpace(stderr.String()); s != "" {
+			return fmt.Errorf("failed to sync workspace: %s", s)
+		}
+		return fmt.Errorf("failed to sync workspace: %w", err)
+	}
+	if writeErr != nil {
+		return fmt.Errorf("failed to sync workspace: %w", writeErr)
+	}
+	return nil
+}
+
+func writeTar(w io.Writer, root string, files []string) error {
+	tw := tar.NewWriter(w)
+	for _, rel := range files {
+		path := filepath.Join(root, rel)
+		info, err := os.Lstat(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+		hdr, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			continue
+		}
+		hdr.Name = filepath.ToSlash(rel)
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+		if !info.Mode().IsRegular() {
+			hdr.Size = 0
+			if err := tw.WriteHeader(hdr); err != nil {
+				return err
+			}
+			continue
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			f.Close()
+			return err
+		}
+		n, err := io.CopyN(tw, f, hdr.Size)
+		f.Close()
+		if err == io.EOF {
+			_, err = tw.Write(make([]byte, hdr.Size-n))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+func (c *Client) RemoveFilesInBox(boxName, dest string, files []string) error {
+	if len(files) == 0 {
+		return nil
+	}
+	cmd := exec.Command(dockerCmd(), "exec", "-i", boxName, "sh", "-c", `cd "$1" && xargs -0 rm -rf --`, "devbox", dest)
+	cmd.Stdin = strings.NewReader(strings.Join(files, "\x00") + "\x00")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if s := strings.TrimSpace(stderr.String()); s != "" {
+			return fmt.Errorf("failed to remove synced files: %s", s)
+		}
+		return fmt.Errorf("failed to remove synced files: %w", err)
+	}
+	return nil
+}
+
+const readFilesScript = `cd "$1" 2>/dev/null || exit 0
+shift
+for f; do
+	shift
+	[ -f "$f" ] && set -- "$@" "$f"
+done
+[ $# -eq 0 ] || exec tar -cf - "$@"`
+
+func (c *Client) ReadFilesFromBox(boxName, dest string, files []string) (map[string][]byte, error) {
+	if len(files) == 0 {
+		return nil, nil
+	}
+	args := append([]string{"exec", boxName, "sh", "-c", readFilesScript, "devbox", dest}, files...)
+	cmd := exec.Command(dockerCmd(), args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if s := strings.TrimSpace(stderr.String()); s != "" {
+			return nil, fmt.Errorf("failed to read files from box: %s", s)
+		}
+		return nil, fmt.Errorf("failed to read files from box: %w", err)
+	}
+	return readTarFiles(bytes.NewReader(out))
+}
+
+func readTarFiles(r io.Reader) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read files from box: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read files from box: %w", err)
+		}
+		files[strings.TrimPrefix(hdr.Name, "./")] = data
+	}
+}
+
+func localMountSource(spec string) bool {
+	host, _, ok := strings.Cut(spec, ":")
+	return ok && (strings.HasPrefix(host, "/") || strings.HasPrefix(host, ".") || strings.HasPrefix(host, "~"))
+}
@@ -0,0 +1,203 @@
+package docker
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const SecretsLabel = "devbox.secrets"
+
+type SecretValues struct {
+	Env   map[string]string
+	Files map[string][]byte
+}
+
+type SecretResolver func(boxName, workspaceHost string, config map[string]interface{}) (*SecretValues, error)
+
+func (c *Client) SetSecretResolver(resolver SecretResolver) {
+	c.secretResolver = resolver
+}
+
+func SecretEnvNames(config map[string]interface{}) []string {
+	secrets, _ := config["secrets"].(map[string]interface{})
+	var names []string
+	for name, raw := range secrets {
+		s, _ := raw.(map[string]interface{})
+		if file, _ := s["file"].(string); file == "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func secretArgs(config map[string]interface{}) []string {
+	names := SecretEnvNames(config)
+	if len(names) == 0 {
+		return nil
+	}
+	var args []string
+	for _, name := range names {
+		args = append(args, "-e", name)
+	}
+	return append(args, "--label", fmt.Sprintf("%s=%s", SecretsLabel, strings.Join(names, ",")))
+}
+
+func ParseSecretsLabel(value string) map[string]bool {
+	names := map[string]bool{}
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+type fileOwner struct {
+	UID int
+	GID int
+}
+
+func secretFileOwner(config map[string]interface{}, passwd, group []byte) (fileOwner, error) {
+	if u, ok := hostBoxUser(config); ok {
+		return fileOwner{UID: u.UID, GID: u.GID}, nil
+	}
+	user, _ := config["user"].(string)
+	name, grp, _ := strings.Cut(user, ":")
+	switch name {
+	case "", "root", "0":
+		name = "0"
+	}
+	owner := fileOwner{}
+	if uid, err := strconv.Atoi(name); err == nil {
+		owner.UID = uid
+	} else {
+		entry, ok := lookupIDFile(passwd, name)
+		if !ok {
+			return owner, fmt.Errorf("user '%s' is not in the image's /etc/passwd", name)
+		}
+		owner.UID, owner.GID = entry[0], entry[1]
+	}
+	if grp == "" {
+		return owner, nil
+	}
+	if gid, err := strconv.Atoi(grp); err == nil {
+		owner.GID = gid
+		return owner, nil
+	}
+	entry, ok := lookupIDFile(group, grp)
+	if !ok {
+		return owner, fmt.Errorf("group '%s' is not in the image's /etc/group", grp)
+	}
+	owner.GID = entry[0]
+	return owner, nil
+}
+
+func lookupIDFile(data []byte, name string) ([2]int, bool) {
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Split(strings.TrimSpace(line), ":")
+		if len(fields) < 3 || fields[0] != name {
+			continue
+		}
+		id, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return [2]int{}, false
+		}
+		gid := 0
+		if len(fields) > 3 {
+			gid, _ = strconv.Atoi(fields[3])
+		}
+		return [2]int{id, gid}, true
+	}
+	return [2]int{}, false
+}
+
+func needsIDFiles(config map[string]interface{}) bool {
+	if CreatesUser(config) {
+		return false
+	}
+	user, _ := config["user"].(string)
+	name, grp, _ := strings.Cut(user, ":")
+	_, nameErr := strconv.Atoi(name)
+	_, grpErr := strconv.Atoi(grp)
+	return (name != "" && name != "root" && nameErr != nil) || (grp != "" && grpErr != nil)
+}
+
+func secretArchive(files map[string][]byte, owner fileOwner) ([]byte, error) {
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, p := range paths {
+		hdr := &tar.Header{
+			Name:    strings.TrimPrefix(p, "/"),
+			Mode:    0400,
+			Uid:     owner.UID,
+			Gid:     owner.GID,
+			Size:    int64(len(files[p])),
+			ModTime: time.Now(),
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(files[p]); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (c *Client) readBoxFile(boxName, path string) ([]byte, error) {
+	out, err := exec.Command(dockerCmd(), "cp", boxName+":"+path, "-").Output()
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(bytes.NewReader(out))
+	if _, err := tr.Next(); err != nil {
+		return nil, err
+	}
+	return io.ReadAll(tr)
+}
+
+func (c *Client) copySecretFiles(boxName string, files map[string][]byte, config map[string]interface{}) error {
+	if len(files) == 0 {
+		return nil
+	}
+	var passwd, group []byte
+	if needsIDFiles(config) {
+		passwd, _ = c.readBoxFile(boxName, "/etc/passwd")
+		group, _ = c.readBoxFile(boxName, "/etc/group")
+	}
+	owner, err := secretFileOwner(config, passwd, group)
+	if err != nil {
+		return fmt.Errorf("failed to find the owner for secret files: %w", err)
+	}
+	archive, err := secretArchive(files, owner)
+	if err != nil {
+		return fmt.Errorf("failed to pack secret files: %w", err)
+	}
+	cmd := exec.Command(dockerCmd(), "cp", "--archive", "-", boxName+":/")
+	cmd.Stdin = bytes.NewReader(archive)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("failed to copy secret files into box: %s", msg)
+		}
+		return fmt.Errorf("failed to copy secret files into box: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package docker
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+const (
+	ServiceLabel     = "devbox.service"
+	ServiceBoxLabel  = "devbox.box"
+	ServiceHashLabel = "devbox.service-hash"
+)
+
+type ServiceSpec struct {
+	Name          string
+	Service       string
+	Box           string
+	Network       string
+	Image         string
+	Environment   map[string]string
+	Ports         []string
+	Volumes       []string
+	WorkspaceHost string
+	Hash          string
+}
+
+type ServiceInfo struct {
+	Name    string
+	Service string
+	State   string
+	Status  string
+	Image   string
+	Hash    string
+}
+
+func (s ServiceInfo) Running() bool {
+	return s.State == "running"
+}
+
+func (c *Client) ServiceCreateArgs(spec ServiceSpec) []string {
+	args := []string{
+		"create",
+		"--name", spec.Name,
+		"--network", spec.Network,
+		"--network-alias", spec.Service,
+		"--label", fmt.Sprintf("%s=%s", OwnerLabel, CurrentOwner()),
+		"--label", fmt.Sprintf("%s=%s", ServiceLabel, spec.Service),
+		"--label", fmt.Sprintf("%s=%s", ServiceBoxLabel, spec.Box),
+		"--label", fmt.Sprintf("%s=%s", ServiceHashLabel, spec.Hash),
+	}
+	restart := "unless-stopped"
+	if c.defaults.Restart != "" {
+		restart = c.defaults.Restart
+	}
+	args = append(args, "--restart", restart)
+	keys := make([]string, 0, len(spec.Environment))
+	for k := range spec.Environment {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		args = append(args, "-e", fmt.Sprintf("%s=%s", k, spec.Environment[k]))
+	}
+	for _, port := range spec.Ports {
+		args = append(args, "-p", port)
+	}
+	for _, volume := range spec.Volumes {
+		args = append(args, "-v", ResolveVolume(volume, spec.WorkspaceHost))
+	}
+	return append(args, spec.Image)
+}
+
+func (c *Client) CreateService(spec ServiceSpec) error {
+	cmd := exec.Command(dockerCmd(), c.ServiceCreateArgs(spec)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to create service %s: %s", spec.Service, firstLine(stderr.String(), err))
+	}
+	return nil
+}
+
+func (c *Client) ListServices(boxName string) ([]ServiceInfo, error) {
+	cmd := exec.Command(dockerCmd(), "ps", "-a", "--filter", fmt.Sprintf("label=%s=%s", ServiceBoxLabel, boxName), "--format", serviceListFormat)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to list services: %s", firstLine(stderr.String(), err))
+	}
+	services, err := parseServiceList(stdout.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse service list: %w", err)
+	}
+	return services, nil
+}
+
+func (c *Client) EnsureNetwork(name string) error {
+	if exec.Command(dockerCmd(), "network", "inspect", name).Run() == nil {
+		return nil
+	}
+	cmd := exec.Command(dockerCmd(), "network", "create", "--label", fmt.Sprintf("%s=%s", OwnerLabel, CurrentOwner()), name)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to create network %s: %s", name, firstLine(stderr.String(), err))
+	}
+	return nil
+}
+
+func (c *Client) ConnectNetwork(network, boxName string) error {
+	cmd := exec.Command(dockerCmd(), "network", "connect", network, boxName)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if strings.Contains(stderr.String(), "already exists") {
+			return nil
+		}
+		return fmt.Errorf("failed to connect %s to network %s: %s", boxName, network, firstLine(stderr.String(), err))
+	}
+	return nil
+}
+
+func (c *Client) RemoveNetwork(name string) error {
+	if exec.Command(dockerCmd(), "network", "inspect", name).Run() != nil {
+		return nil
+	}
+	cmd := exec.Command(dockerCmd(), "network", "rm", name)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to remove network %s: %s", name, firstLine(stderr.String(), err))
+	}
+	return nil
+}
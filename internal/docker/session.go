@@ -0,0 +1,187 @@
+package docker
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+
+	"devbox/internal/parallel"
+)
+
+var errExecSession = errors.New("exec session unavailable")
+
+type ExecSession struct {
+	boxName string
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	stdout  *bufio.Reader
+	marker  string
+	mu      sync.Mutex
+	broken  bool
+
+	errMu   sync.Mutex
+	errCond *sync.Cond
+	errBuf  strings.Builder
+	errDone bool
+}
+
+func (c *Client) OpenExecSession(boxName string) (*ExecSession, error) {
+	cmd := exec.Command(dockerCmd(), "exec", "-i", boxName, "bash")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, 8)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start exec session: %w", err)
+	}
+
+	s := &ExecSession{
+		boxName: boxName,
+		cmd:     cmd,
+		stdin:   stdin,
+		stdout:  bufio.NewReader(stdout),
+		marker:  "__DEVBOX_EXEC_" + hex.EncodeToString(nonce) + "__",
+	}
+	s.errCond = sync.NewCond(&s.errMu)
+	go s.collectStderr(stderr)
+
+	if _, _, err := s.Run(parallel.ShellInit(false)); err != nil {
+		s.Close()
+		return nil, fmt.Errorf("failed to start exec session: %w", err)
+	}
+	return s, nil
+}
+
+func (s *ExecSession) Run(command string) (string, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.broken {
+		return "", "", errExecSession
+	}
+
+	script := fmt.Sprintf("( set -o pipefail; eval %s ) </dev/null; printf '%s %%d\\n' \"$?\"; printf '%s\\n' >&2\n",
+		shellQuote(command), s.marker, s.marker)
+	if _, err := io.WriteString(s.stdin, script); err != nil {
+		s.broken = true
+		return "", "", fmt.Errorf("%w: %v", errExecSession, err)
+	}
+
+	var stdout strings.Builder
+	code := -1
+	for {
+		line, err := s.stdout.ReadString('\n')
+		if i := strings.Index(line, s.marker+" "); i != -1 {
+			stdout.WriteString(line[:i])
+			code, _ = strconv.Atoi(strings.TrimSpace(line[i+len(s.marker)+1:]))
+			break
+		}
+		stdout.WriteString(line)
+		if err != nil {
+			s.broken = true
+			return stdout.String(), "", fmt.Errorf("%w: %v", errExecSession, err)
+		}
+	}
+
+	stderr := s.takeStderr()
+	if code != 0 {
+		return stdout.String(), stderr, fmt.Errorf("exec failed: exit status %d", code)
+	}
+	return stdout.String(), stderr, nil
+}
+
+func (s *ExecSession) collectStderr(r io.Reader) {
+	buf := make([]byte, 4096)
+	for {
+		n, err := r.Read(buf)
+		s.errMu.Lock()
+		s.errBuf.Write(buf[:n])
+		if err != nil {
+			s.errDone = true
+		}
+		s.errCond.Broadcast()
+		s.errMu.Unlock()
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (s *ExecSession) takeStderr() string {
+	s.errMu.Lock()
+	defer s.errMu.Unlock()
+	end := s.marker + "\n"
+	for !strings.Contains(s.errBuf.String(), end) && !s.errDone {
+		s.errCond.Wait()
+	}
+	all := s.errBuf.String()
+	s.errBuf.Reset()
+	i := strings.Index(all, end)
+	if i == -1 {
+		return all
+	}
+	s.errBuf.WriteString(all[i+len(end):])
+	return all[:i]
+}
+
+func (s *ExecSession) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.broken = true
+	_ = s.stdin.Close()
+	s.errMu.Lock()
+	for !s.errDone {
+		s.errCond.Wait()
+	}
+	s.errMu.Unlock()
+	return s.cmd.Wait()
+}
+
+func (c *Client) UseExecSession(boxName string) (func(), error) {
+	s, err := c.OpenExecSession(boxName)
+	if err != nil {
+		return func() {}, err
+	}
+	c.sessionsMu.Lock()
+	if c.sessions == nil {
+		c.sessions = map[string]*ExecSession{}
+	}
+	c.sessions[boxName] = s
+	c.sessionsMu.Unlock()
+	return func() {
+		c.sessionsMu.Lock()
+		if c.sessions[boxName] == s {
+			delete(c.sessions, boxName)
+		}
+		c.sessionsMu.Unlock()
+		_ = s.Close()
+	}, nil
+}
+
+func (c *Client) execSession(boxName string) *ExecSession {
+	c.sessionsMu.Lock()
+	defer c.sessionsMu.Unlock()
+	return c.sessions[boxName]
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
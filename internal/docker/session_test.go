@@ -0,0 +1,83 @@
+package docker
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func fakeEngine(t *testing.T) {
+	t.Helper()
+	script := filepath.Join(t.TempDir(), "engine")
+	body := "#!/bin/sh\nshift\nwhile [ \"${1#-}\" != \"$1\" ]; do shift; done\nshift\nexec \"$@\"\n"
+	if err := os.WriteFile(script, []byte(body), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DEVBOX_ENGINE", script)
+}
+
+func TestExecSession(t *testing.T) {
+	fakeEngine(t)
+	c := &Client{}
+	release, err := c.UseExecSession("box")
+	if err != nil {
+		t.Fatalf("UseExecSession() error = %v", err)
+	}
+	defer release()
+
+	tests := []struct {
+		name       string
+		command    string
+		wantOut    string
+		wantErrOut string
+		errLines   int
+		wantErr    bool
+	}{
+		{name: "stdout", command: "echo hello; echo world", wantOut: "hello\nworld\n"},
+		{name: "no trailing newline", command: "printf abc", wantOut: "abc"},
+		{name: "quotes", command: `echo 'it'"'"'s'`, wantOut: "it's\n"},
+		{name: "stderr", command: "echo out; echo oops >&2", wantOut: "out\n", wantErrOut: "oops\n"},
+		{name: "exit code", command: "false", wantErr: true},
+		{name: "pipefail", command: "false | cat", wantErr: true},
+		{name: "exit stays in subshell", command: "exit 3", wantErr: true},
+		{name: "syntax error", command: "echo )", wantErr: true},
+		{name: "stdin is not the session", command: "cat", wantOut: ""},
+		{name: "after errors", command: "echo still here", wantOut: "still here\n"},
+		{name: "large stderr", command: "seq 1 20000 >&2; echo done", wantOut: "done\n", errLines: 20000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, errOut, err := c.ExecCapture("box", tt.command)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExecCapture(%q) error = %v, wantErr %v", tt.command, err, tt.wantErr)
+			}
+			if out != tt.wantOut {
+				t.Errorf("stdout = %q, want %q", out, tt.wantOut)
+			}
+			if tt.wantErrOut != "" && errOut != tt.wantErrOut {
+				t.Errorf("stderr = %q, want %q", errOut, tt.wantErrOut)
+			}
+			if n := strings.Count(errOut, "\n"); tt.errLines > 0 && n != tt.errLines {
+				t.Errorf("stderr lines = %d, want %d", n, tt.errLines)
+			}
+		})
+	}
+}
+
+func TestExecCaptureFallsBackAfterRelease(t *testing.T) {
+	fakeEngine(t)
+	c := &Client{}
+	release, err := c.UseExecSession("box")
+	if err != nil {
+		t.Fatalf("UseExecSession() error = %v", err)
+	}
+	release()
+	if s := c.execSession("box"); s != nil {
+		t.Fatal("session should be removed after release")
+	}
+	out, _, err := c.ExecCapture("box", "echo direct")
+	if err != nil || out != "direct\n" {
+		t.Errorf("ExecCapture() = %q, %v", out, err)
+	}
+}
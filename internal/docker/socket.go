@@ -0,0 +1,91 @@
+package docker
+
+import (
+	"encoding/json"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const DefaultSocketPath = "/var/run/docker.sock"
+
+type SocketCandidate struct {
+	Name string
+	Path string
+}
+
+func (s SocketCandidate) Endpoint() string {
+	return "unix://" + s.Path
+}
+
+func SocketCandidates(home, runtimeDir string, uid int) []SocketCandidate {
+	if runtimeDir == "" {
+		runtimeDir = "/run/user/" + strconv.Itoa(uid)
+	}
+	colimaHome := os.Getenv("COLIMA_HOME")
+	if colimaHome == "" {
+		colimaHome = filepath.Join(home, ".colima")
+	}
+	limaHome := os.Getenv("LIMA_HOME")
+	if limaHome == "" {
+		limaHome = filepath.Join(home, ".lima")
+	}
+	return []SocketCandidate{
+		{"rootless Docker", filepath.Join(runtimeDir, "docker.sock")},
+		{"Colima", filepath.Join(colimaHome, "default", "docker.sock")},
+		{"Colima", filepath.Join(colimaHome, "docker.sock")},
+		{"Lima", filepath.Join(limaHome, "docker", "sock", "docker.sock")},
+		{"Lima", filepath.Join(limaHome, "default", "sock", "docker.sock")},
+		{"Docker Desktop", filepath.Join(home, ".docker", "desktop", "docker.sock")},
+		{"Rancher Desktop", filepath.Join(home, ".rd", "docker.sock")},
+		{"Podman Docker API", filepath.Join(runtimeDir, "podman", "podman.sock")},
+	}
+}
+
+func SocketReachable(path string) bool {
+	conn, err := net.DialTimeout("unix", path, 500*time.Millisecond)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+func contextSocketCandidates() []SocketCandidate {
+	out, err := exec.Command("docker", "context", "ls", "--format", "{{json .}}").Output()
+	if err != nil {
+		return nil
+	}
+	var candidates []SocketCandidate
+	for _, line := range strings.Split(string(out), "\n") {
+		var ctx struct {
+			Name           string `json:"Name"`
+			DockerEndpoint string `json:"DockerEndpoint"`
+		}
+		if json.Unmarshal([]byte(strings.TrimSpace(line)), &ctx) != nil || ctx.Name == "default" {
+			continue
+		}
+		if path := strings.TrimPrefix(ctx.DockerEndpoint, "unix://"); path != ctx.DockerEndpoint {
+			candidates = append(candidates, SocketCandidate{Name: "docker context " + ctx.Name, Path: path})
+		}
+	}
+	return candidates
+}
+
+func DetectSocket() (SocketCandidate, bool) {
+	if filepath.Base(dockerCmd()) != "docker" || DaemonEndpoint() != "" || SocketReachable(DefaultSocketPath) {
+		return SocketCandidate{}, false
+	}
+	home, _ := os.UserHomeDir()
+	candidates := append(SocketCandidates(home, os.Getenv("XDG_RUNTIME_DIR"), os.Getuid()), contextSocketCandidates()...)
+	for _, c := range candidates {
+		if SocketReachable(c.Path) {
+			return c, true
+		}
+	}
+	return SocketCandidate{}, false
+}
@@ -0,0 +1,135 @@
+package docker
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+const (
+	UserLabel      = "devbox.user"
+	DefaultBoxUser = "dev"
+)
+
+var hostIDs = func() (int, int) {
+	return os.Getuid(), os.Getgid()
+}
+
+type BoxUser struct {
+	Name string
+	UID  int
+	GID  int
+}
+
+func (u BoxUser) String() string {
+	return fmt.Sprintf("%s:%d:%d", u.Name, u.UID, u.GID)
+}
+
+func (u BoxUser) Spec() string {
+	return fmt.Sprintf("%d:%d", u.UID, u.GID)
+}
+
+func (u BoxUser) Home() string {
+	return "/home/" + u.Name
+}
+
+func ParseBoxUser(value string) (BoxUser, bool) {
+	parts := strings.Split(strings.TrimSpace(value), ":")
+	if len(parts) != 3 || parts[0] == "" {
+		return BoxUser{}, false
+	}
+	uid, err := strconv.Atoi(parts[1])
+	if err != nil || uid <= 0 {
+		return BoxUser{}, false
+	}
+	gid, err := strconv.Atoi(parts[2])
+	if err != nil || gid < 0 {
+		return BoxUser{}, false
+	}
+	return BoxUser{Name: parts[0], UID: uid, GID: gid}, true
+}
+
+func CreatesUser(config map[string]interface{}) bool {
+	create, _ := config["create_user"].(bool)
+	return create
+}
+
+func hostBoxUser(config map[string]interface{}) (BoxUser, bool) {
+	if !CreatesUser(config) {
+		return BoxUser{}, false
+	}
+	uid, gid := hostIDs()
+	if uid <= 0 || gid < 0 {
+		return BoxUser{}, false
+	}
+	name, _ := config["user"].(string)
+	if name == "" {
+		name = DefaultBoxUser
+	}
+	return BoxUser{Name: name, UID: uid, GID: gid}, true
+}
+
+func (c *Client) GetBoxUser(boxName string) (BoxUser, bool) {
+	return lookupBoxUser(boxName)
+}
+
+func lookupBoxUser(boxName string) (BoxUser, bool) {
+	out, err := exec.Command(dockerCmd(), "inspect", "--format", fmt.Sprintf("{{index .Config.Labels %q}}", UserLabel), boxName).Output()
+	if err != nil {
+		return BoxUser{}, false
+	}
+	return ParseBoxUser(string(out))
+}
+
+func boxExecUser(boxName string) string {
+	if u, ok := lookupBoxUser(boxName); ok {
+		return u.Spec()
+	}
+	return ""
+}
+
+const ensureUserScript = `set -e
+name="$1"; uid="$2"; gid="$3"; home="/home/$1"
+if ! command -v useradd >/dev/null 2>&1; then
+	echo "useradd is not installed in the box" >&2
+	exit 127
+fi
+if ! getent group "$gid" >/dev/null; then
+	if getent group "$name" >/dev/null; then
+		groupmod -g "$gid" "$name"
+	else
+		groupadd -g "$gid" "$name"
+	fi
+fi
+existing="$(getent passwd "$uid" | cut -d: -f1)"
+if [ -z "$existing" ]; then
+	if id -u "$name" >/dev/null 2>&1; then
+		usermod -u "$uid" -g "$gid" -d "$home" -m "$name"
+	else
+		useradd -m -d "$home" -u "$uid" -g "$gid" -s /bin/bash "$name"
+	fi
+elif [ "$existing" != "$name" ]; then
+	usermod -l "$name" -g "$gid" -d "$home" -m "$existing"
+fi
+mkdir -p "$home"
+chown "$uid:$gid" "$home"
+if [ -d /etc/sudoers.d ]; then
+	echo "$name ALL=(ALL) NOPASSWD:ALL" > "/etc/sudoers.d/devbox-$name"
+	chmod 0440 "/etc/sudoers.d/devbox-$name"
+fi`
+
+func (c *Client) EnsureBoxUser(boxName string, u BoxUser) error {
+	cmd := exec.Command(dockerCmd(), "exec", "--user", "0", boxName, "bash", "-c", ensureUserScript, "devbox", u.Name, strconv.Itoa(u.UID), strconv.Itoa(u.GID))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("failed to create user '%s' (uid %d, gid %d) in box: %s\nhint: create_user needs useradd/groupadd in the image (shadow-utils or passwd)", u.Name, u.UID, u.GID, msg)
+		}
+		return fmt.Errorf("failed to create user '%s' in box: %w", u.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+package kube
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const boxContainer = "box"
+
+type Client struct {
+	Context   string
+	Namespace string
+}
+
+type PodInfo struct {
+	Name      string
+	Namespace string
+	Phase     string
+	Ready     bool
+	Node      string
+	IP        string
+	Image     string
+	Restarts  int
+	StartedAt time.Time
+}
+
+func Available() error {
+	if _, err := exec.LookPath("kubectl"); err != nil {
+		return fmt.Errorf("kubectl not found in PATH; the kubernetes backend needs kubectl and a kubeconfig")
+	}
+	return nil
+}
+
+func (c *Client) command(args ...string) *exec.Cmd {
+	var full []string
+	if c.Context != "" {
+		full = append(full, "--context", c.Context)
+	}
+	if c.Namespace != "" {
+		full = append(full, "--namespace", c.Namespace)
+	}
+	return exec.Command("kubectl", append(full, args...)...)
+}
+
+func (c *Client) run(stdin io.Reader, args ...string) (string, error) {
+	cmd := c.command(args...)
+	cmd.Stdin = stdin
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if s := strings.TrimSpace(stderr.String()); s != "" {
+			return stdout.String(), fmt.Errorf("kubectl %s: %s", args[0], s)
+		}
+		return stdout.String(), fmt.Errorf("kubectl %s: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
+
+func (c *Client) Describe() string {
+	context := c.Context
+	if context == "" {
+		if out, err := c.run(nil, "config", "current-context"); err == nil {
+			context = strings.TrimSpace(out)
+		}
+	}
+	namespace := c.Namespace
+	if namespace == "" {
+		namespace = "default namespace"
+	}
+	return fmt.Sprintf("context %s, %s", firstNonEmpty(context, "current"), namespace)
+}
+
+func (c *Client) CheckAccess() error {
+	out, err := c.run(nil, "auth", "can-i", "create", "pods", "--request-timeout=15s")
+	if strings.TrimSpace(out) == "yes" {
+		return nil
+	}
+	if err != nil && strings.TrimSpace(out) == "" {
+		return fmt.Errorf("cluster not reachable: %w", err)
+	}
+	return fmt.Errorf("not allowed to create pods in %s", c.Describe())
+}
+
+func (c *Client) Apply(manifest []byte) error {
+	_, err := c.run(bytes.NewReader(manifest), "apply", "-f", "-")
+	return err
+}
+
+func (c *Client) WaitReady(pod string, timeout time.Duration) error {
+	_, err := c.run(nil, "wait", "--for=condition=Ready", "pod/"+pod, fmt.Sprintf("--timeout=%ds", int(timeout.Seconds())))
+	return err
+}
+
+func (c *Client) ClaimExists(name string) bool {
+	return c.command("get", "pvc", name).Run() == nil
+}
+
+func (c *Client) GetPod(pod string) (*PodInfo, error) {
+	out, err := c.run(nil, "get", "pod", pod, "-o", "json", "--ignore-not-found")
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(out) == "" {
+		return nil, nil
+	}
+	return parsePod([]byte(out))
+}
+
+func parsePod(data []byte) (*PodInfo, error) {
+	var pod struct {
+		Metadata struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+		Spec struct {
+			NodeName   string `json:"nodeName"`
+			Containers []struct {
+				Name  string `json:"name"`
+				Image string `json:"image"`
+			} `json:"containers"`
+		} `json:"spec"`
+		Status struct {
+			Phase             string `json:"phase"`
+			PodIP             string `json:"podIP"`
+			StartTime         string `json:"startTime"`
+			ContainerStatuses []struct {
+				Name         string `json:"name"`
+				Ready        bool   `json:"ready"`
+				RestartCount int    `json:"restartCount"`
+			} `json:"containerStatuses"`
+		} `json:"status"`
+	}
+	if err := json.Unmarshal(data, &pod); err != nil {
+		return nil, fmt.Errorf("failed to parse pod: %w", err)
+	}
+	info := &PodInfo{
+		Name:      pod.Metadata.Name,
+		Namespace: pod.Metadata.Namespace,
+		Phase:     pod.Status.Phase,
+		Node:      pod.Spec.NodeName,
+		IP:        pod.Status.PodIP,
+	}
+	for _, container := range pod.Spec.Containers {
+		if container.Name == boxContainer {
+			info.Image = container.Image
+		}
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == boxContainer {
+			info.Ready = status.Ready
+			info.Restarts = status.RestartCount
+		}
+	}
+	info.StartedAt, _ = time.Parse(time.RFC3339, pod.Status.StartTime)
+	return info, nil
+}
+
+func (c *Client) Exec(pod string, tty bool, command ...string) *exec.Cmd {
+	args := []string{"exec", "-i"}
+	if tty {
+		args = append(args, "-t")
+	}
+	args = append(args, pod, "-c", boxContainer, "--")
+	cmd := c.command(append(args, command...)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd
+}
+
+func (c *Client) CopyTo(pod, localDir, dest string) error {
+	pack := exec.Command("tar", "-C", localDir, "-cf", "-", ".")
+	unpack := c.command("exec", "-i", pod, "-c", boxContainer, "--", "tar", "-xf", "-", "-C", dest)
+	return pipe(pack, unpack)
+}
+
+func (c *Client) CopyFrom(pod, src, localDir string) error {
+	pack := c.command("exec", pod, "-c", boxContainer, "--", "tar", "-cf", "-", "-C", src, ".")
+	unpack := exec.Command("tar", "-C", localDir, "-xf", "-")
+	return pipe(pack, unpack)
+}
+
+func pipe(from, to *exec.Cmd) error {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("failed to copy workspace: %w", err)
+	}
+	var fromErr, toErr bytes.Buffer
+	from.Stdout, from.Stderr = w, &fromErr
+	to.Stdin, to.Stderr = r, &toErr
+	if err := to.Start(); err != nil {
+		r.Close()
+		w.Close()
+		return fmt.Errorf("failed to copy workspace: %w", err)
+	}
+	r.Close()
+	err = from.Start()
+	w.Close()
+	if err == nil {
+		err = from.Wait()
+	}
+	if waitErr := to.Wait(); waitErr != nil {
+		return fmt.Errorf("failed to copy workspace: %s", firstNonEmpty(strings.TrimSpace(toErr.String()), waitErr.Error()))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to copy workspace: %s", firstNonEmpty(strings.TrimSpace(fromErr.String()), err.Error()))
+	}
+	return nil
+}
+
+func (c *Client) DeletePod(pod string) error {
+	_, err := c.run(nil, "delete", "pod", pod, "--ignore-not-found", "--grace-period=5", "--wait=true")
+	return err
+}
+
+func (c *Client) DeleteClaim(name string) error {
+	_, err := c.run(nil, "delete", "pvc", name, "--ignore-not-found", "--wait=false")
+	return err
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,172 @@
+package kube
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPodName(t *testing.T) {
+	tests := []struct {
+		box  string
+		want string
+	}{
+		{"devbox_web", "devbox-web"},
+		{"devbox_Alice_api.v2", "devbox-alice-api-v2"},
+		{"_devbox_", "devbox"},
+		{"devbox_" + strings.Repeat("x", 70), "devbox-" + strings.Repeat("x", 56)},
+	}
+	for _, tt := range tests {
+		if got := PodName(tt.box); got != tt.want {
+			t.Errorf("PodName(%q) = %q, want %q", tt.box, got, tt.want)
+		}
+	}
+	if got := ClaimName("devbox_web"); got != "devbox-web-workspace" {
+		t.Errorf("ClaimName() = %q", got)
+	}
+}
+
+func TestMemory(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"2g", "2Gi", false},
+		{"512m", "512Mi", false},
+		{"1.5G", "1.5Gi", false},
+		{"1048576", "1048576", false},
+		{"100b", "100", false},
+		{"2x", "", true},
+		{"g", "", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		got, err := Memory(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("Memory(%q) = %q, %v; want %q, wantErr %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestGPUCount(t *testing.T) {
+	tests := map[string]int{"": 0, "all": 1, "2": 2, "device=0,1": 2, "GPU-abc": 1}
+	for in, want := range tests {
+		if got := GPUCount(in); got != want {
+			t.Errorf("GPUCount(%q) = %d, want %d", in, got, want)
+		}
+	}
+}
+
+func TestManifest(t *testing.T) {
+	data, err := Manifest(BoxSpec{
+		Box:          "devbox_web",
+		Image:        "registry.example.com/web:1",
+		WorkingDir:   "/workspace",
+		Owner:        "1000",
+		Env:          map[string]string{"B": "2", "A": "1"},
+		CPUs:         "2",
+		Memory:       "4g",
+		GPUs:         "all",
+		StorageClass: "fast",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var list struct {
+		Kind  string `json:"kind"`
+		Items []struct {
+			Kind     string `json:"kind"`
+			Metadata struct {
+				Name   string            `json:"name"`
+				Labels map[string]string `json:"labels"`
+			} `json:"metadata"`
+			Spec struct {
+				StorageClassName string `json:"storageClassName"`
+				Resources        struct {
+					Requests map[string]string `json:"requests"`
+				} `json:"resources"`
+				Containers []struct {
+					Image        string              `json:"image"`
+					WorkingDir   string              `json:"workingDir"`
+					Env          []map[string]string `json:"env"`
+					VolumeMounts []map[string]string `json:"volumeMounts"`
+					Resources    struct {
+						Limits map[string]string `json:"limits"`
+					} `json:"resources"`
+				} `json:"containers"`
+				Volumes []struct {
+					PersistentVolumeClaim struct {
+						ClaimName string `json:"claimName"`
+					} `json:"persistentVolumeClaim"`
+				} `json:"volumes"`
+			} `json:"spec"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		t.Fatal(err)
+	}
+	if list.Kind != "List" || len(list.Items) != 2 {
+		t.Fatalf("Manifest() = %s", data)
+	}
+	claim, pod := list.Items[0], list.Items[1]
+	if claim.Kind != "PersistentVolumeClaim" || claim.Metadata.Name != "devbox-web-workspace" || claim.Spec.StorageClassName != "fast" || claim.Spec.Resources.Requests["storage"] != DefaultStorage {
+		t.Errorf("claim = %+v", claim)
+	}
+	if pod.Kind != "Pod" || pod.Metadata.Name != "devbox-web" || pod.Metadata.Labels[OwnerLabel] != "1000" || pod.Metadata.Labels[ManagedByLabel] != "devbox" {
+		t.Errorf("pod metadata = %+v", pod.Metadata)
+	}
+	c := pod.Spec.Containers[0]
+	if c.Image != "registry.example.com/web:1" || c.WorkingDir != "/workspace" || c.VolumeMounts[0]["mountPath"] != "/workspace" {
+		t.Errorf("container = %+v", c)
+	}
+	if len(c.Env) != 2 || c.Env[0]["name"] != "A" {
+		t.Errorf("env = %v, want sorted A, B", c.Env)
+	}
+	if want := map[string]string{"cpu": "2", "memory": "4Gi", "nvidia.com/gpu": "1"}; !reflect.DeepEqual(c.Resources.Limits, want) {
+		t.Errorf("limits = %v, want %v", c.Resources.Limits, want)
+	}
+	if pod.Spec.Volumes[0].PersistentVolumeClaim.ClaimName != "devbox-web-workspace" {
+		t.Errorf("volumes = %+v", pod.Spec.Volumes)
+	}
+
+	if _, err := Manifest(BoxSpec{Box: "devbox_web", Memory: "lots"}); err == nil {
+		t.Errorf("Manifest() accepted an invalid memory limit")
+	}
+}
+
+func TestParsePod(t *testing.T) {
+	data := []byte(`{
+		"metadata": {"name": "devbox-web", "namespace": "dev"},
+		"spec": {"nodeName": "node-3", "containers": [{"name": "sidecar", "image": "envoy"}, {"name": "box", "image": "ubuntu:22.04"}]},
+		"status": {
+			"phase": "Running",
+			"podIP": "10.1.2.3",
+			"startTime": "2024-05-01T10:00:00Z",
+			"containerStatuses": [{"name": "box", "ready": true, "restartCount": 2}]
+		}
+	}`)
+	info, err := parsePod(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := PodInfo{
+		Name:      "devbox-web",
+		Namespace: "dev",
+		Phase:     "Running",
+		Ready:     true,
+		Node:      "node-3",
+		IP:        "10.1.2.3",
+		Image:     "ubuntu:22.04",
+		Restarts:  2,
+		StartedAt: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+	}
+	if *info != want {
+		t.Errorf("parsePod() = %+v, want %+v", *info, want)
+	}
+	if _, err := parsePod([]byte("not json")); err == nil {
+		t.Errorf("parsePod() accepted invalid JSON")
+	}
+}
@@ -0,0 +1,179 @@
+package kube
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultStorage = "10Gi"
+	ManagedByLabel = "app.kubernetes.io/managed-by"
+	BoxLabel       = "devbox.box"
+	OwnerLabel     = "devbox.owner"
+)
+
+type BoxSpec struct {
+	Box          string
+	Image        string
+	WorkingDir   string
+	Owner        string
+	Env          map[string]string
+	CPUs         string
+	Memory       string
+	GPUs         string
+	StorageClass string
+	Storage      string
+	Annotations  map[string]string
+}
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+var invalidLabelChars = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+func PodName(box string) string {
+	name := invalidNameChars.ReplaceAllString(strings.ToLower(box), "-")
+	if len(name) > 63 {
+		name = name[:63]
+	}
+	return strings.Trim(name, "-")
+}
+
+func ClaimName(box string) string {
+	return PodName(box) + "-workspace"
+}
+
+func labelValue(value string) string {
+	value = invalidLabelChars.ReplaceAllString(value, "-")
+	if len(value) > 63 {
+		value = value[:63]
+	}
+	return strings.Trim(value, "-_.")
+}
+
+func Memory(docker string) (string, error) {
+	s := strings.ToLower(strings.TrimSpace(docker))
+	units := map[string]string{"b": "", "k": "Ki", "m": "Mi", "g": "Gi", "t": "Ti"}
+	suffix := ""
+	if n := len(s); n > 0 && s[n-1] >= 'a' && s[n-1] <= 'z' {
+		unit, ok := units[s[n-1:]]
+		if !ok {
+			return "", fmt.Errorf("invalid memory '%s'", docker)
+		}
+		s, suffix = s[:n-1], unit
+	}
+	if _, err := strconv.ParseFloat(s, 64); err != nil || s == "" {
+		return "", fmt.Errorf("invalid memory '%s'", docker)
+	}
+	return s + suffix, nil
+}
+
+func GPUCount(gpus string) int {
+	gpus = strings.TrimSpace(gpus)
+	switch {
+	case gpus == "":
+		return 0
+	case gpus == "all":
+		return 1
+	}
+	if n, err := strconv.Atoi(gpus); err == nil {
+		return n
+	}
+	return len(strings.Split(strings.TrimPrefix(gpus, "device="), ","))
+}
+
+func Manifest(spec BoxSpec) ([]byte, error) {
+	pod := PodName(spec.Box)
+	claim := ClaimName(spec.Box)
+	labels := map[string]string{ManagedByLabel: "devbox", BoxLabel: pod}
+	if owner := labelValue(spec.Owner); owner != "" {
+		labels[OwnerLabel] = owner
+	}
+
+	storage := spec.Storage
+	if storage == "" {
+		storage = DefaultStorage
+	}
+	claimSpec := map[string]interface{}{
+		"accessModes": []string{"ReadWriteOnce"},
+		"resources":   map[string]interface{}{"requests": map[string]string{"storage": storage}},
+	}
+	if spec.StorageClass != "" {
+		claimSpec["storageClassName"] = spec.StorageClass
+	}
+
+	var env []map[string]string
+	keys := make([]string, 0, len(spec.Env))
+	for k := range spec.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		env = append(env, map[string]string{"name": k, "value": spec.Env[k]})
+	}
+
+	limits := map[string]string{}
+	if spec.CPUs != "" {
+		limits["cpu"] = spec.CPUs
+	}
+	if spec.Memory != "" {
+		memory, err := Memory(spec.Memory)
+		if err != nil {
+			return nil, err
+		}
+		limits["memory"] = memory
+	}
+	if n := GPUCount(spec.GPUs); n > 0 {
+		limits["nvidia.com/gpu"] = strconv.Itoa(n)
+	}
+
+	container := map[string]interface{}{
+		"name":            boxContainer,
+		"image":           spec.Image,
+		"imagePullPolicy": "IfNotPresent",
+		"command":         []string{"sleep", "infinity"},
+		"workingDir":      spec.WorkingDir,
+		"stdin":           true,
+		"tty":             true,
+		"volumeMounts":    []map[string]string{{"name": "workspace", "mountPath": spec.WorkingDir}},
+	}
+	if len(env) > 0 {
+		container["env"] = env
+	}
+	if len(limits) > 0 {
+		container["resources"] = map[string]interface{}{"limits": limits}
+	}
+
+	podMeta := map[string]interface{}{"name": pod, "labels": labels}
+	if len(spec.Annotations) > 0 {
+		podMeta["annotations"] = spec.Annotations
+	}
+	list := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "List",
+		"items": []interface{}{
+			map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "PersistentVolumeClaim",
+				"metadata":   map[string]interface{}{"name": claim, "labels": labels},
+				"spec":       claimSpec,
+			},
+			map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Pod",
+				"metadata":   podMeta,
+				"spec": map[string]interface{}{
+					"restartPolicy":                 "Always",
+					"terminationGracePeriodSeconds": 5,
+					"containers":                    []interface{}{container},
+					"volumes": []interface{}{
+						map[string]interface{}{"name": "workspace", "persistentVolumeClaim": map[string]string{"claimName": claim}},
+					},
+				},
+			},
+		},
+	}
+	return json.MarshalIndent(list, "", "  ")
+}
@@ -0,0 +1,60 @@
+package parallel
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	AptLockPath     = "/var/lock/devbox-apt.lock"
+	aptLockExitCode = 75
+)
+
+var aptCommandPattern = regexp.MustCompile(`(^|[\s;&|(])(apt|apt-get|apt-mark|aptitude|dpkg)(\s|$)`)
+
+func AptLockTimeout() time.Duration {
+	if v := strings.TrimSpace(os.Getenv("DEVBOX_APT_LOCK_TIMEOUT")); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			return time.Duration(n) * time.Second
+		}
+	}
+	return 5 * time.Minute
+}
+
+func IsAptCommand(command string) bool {
+	return aptCommandPattern.MatchString(strings.ToLower(command))
+}
+
+func WrapAptLock(command string) string {
+	if !IsAptCommand(command) {
+		return command
+	}
+	secs := int(AptLockTimeout().Seconds())
+	return fmt.Sprintf("if command -v flock >/dev/null 2>&1; then mkdir -p /var/lock; exec 9>%s; "+
+		"if ! flock -w %d 9; then echo \"devbox: timed out after %ds waiting for the apt lock\" >&2; exit %d; fi; fi; %s",
+		AptLockPath, secs, secs, aptLockExitCode, command)
+}
+
+func AptLockError(boxName, command, stderr string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == aptLockExitCode {
+		return fmt.Errorf("timed out after %s waiting for the apt lock in box '%s' (another devbox apt command is still running; raise DEVBOX_APT_LOCK_TIMEOUT to wait longer): %s",
+			AptLockTimeout(), boxName, command)
+	}
+	if strings.Contains(stderr, "Could not get lock") || strings.Contains(stderr, "Unable to acquire the dpkg frontend lock") {
+		return fmt.Errorf("dpkg is locked in box '%s' by a process outside devbox (e.g. unattended-upgrades); retry once it finishes: %s", boxName, command)
+	}
+	return nil
+}
@@ -3,6 +3,8 @@ package parallel
 import (
 	"os"
 	"strconv"
+	"sync"
+	"time"
 )
 
 type Config struct {
@@ -10,23 +12,92 @@ type Config struct {
 	MaxWorkers          int
 	SetupCommandWorkers int
 	PackageQueryWorkers int
+	SetupTimeout        time.Duration
+	Schedules           map[string]Schedule
 }
 
+type Schedule struct {
+	Priority int
+	Weight   int
+}
+
+type ScheduleOverride struct {
+	Priority *int
+	Weight   int
+}
+
+type Overrides struct {
+	EnableParallel      *bool
+	MaxWorkers          int
+	SetupCommandWorkers int
+	PackageQueryWorkers int
+	SetupTimeout        time.Duration
+	Schedules           map[string]ScheduleOverride
+}
+
+var (
+	overridesMu       sync.RWMutex
+	settingsOverrides Overrides
+	projectOverrides  Overrides
+	flagOverrides     Overrides
+)
+
 func DefaultConfig() *Config {
 	return &Config{
 		EnableParallel:      true,
 		MaxWorkers:          4,
 		SetupCommandWorkers: 3,
 		PackageQueryWorkers: 5,
+		SetupTimeout:        10 * time.Minute,
+		Schedules: map[string]Schedule{
+			"system": {Priority: 100, Weight: 1},
+			"apt":    {Priority: 90, Weight: 1},
+			"pip":    {Priority: 30, Weight: 1},
+			"npm":    {Priority: 20, Weight: 2},
+			"yarn":   {Priority: 20, Weight: 1},
+			"pnpm":   {Priority: 20, Weight: 1},
+			"other":  {Priority: 0, Weight: 1},
+		},
+	}
+}
+
+func (c *Config) Schedule(key string) Schedule {
+	if s, ok := c.Schedules[key]; ok {
+		return s
 	}
+	return Schedule{Priority: 0, Weight: 1}
+}
+
+func SetSettings(o Overrides) {
+	overridesMu.Lock()
+	settingsOverrides = o
+	overridesMu.Unlock()
+}
+
+func SetProjectOverrides(o Overrides) {
+	overridesMu.Lock()
+	projectOverrides = o
+	overridesMu.Unlock()
+}
+
+func SetFlagOverrides(o Overrides) {
+	overridesMu.Lock()
+	flagOverrides = o
+	overridesMu.Unlock()
 }
 
 func LoadConfig() *Config {
 	config := DefaultConfig()
 
+	overridesMu.RLock()
+	settings, project, flags := settingsOverrides, projectOverrides, flagOverrides
+	overridesMu.RUnlock()
+
+	config.apply(settings)
+	config.apply(project)
+
 	if os.Getenv("DEVBOX_DISABLE_PARALLEL") == "true" {
 		config.EnableParallel = false
-		return config
 	}
 
 	if maxWorkers := os.Getenv("DEVBOX_MAX_WORKERS"); maxWorkers != "" {
@@ -47,5 +118,41 @@ func LoadConfig() *Config {
 		}
 	}
 
+	if setupTimeout := os.Getenv("DEVBOX_SETUP_TIMEOUT"); setupTimeout != "" {
+		if val, err := time.ParseDuration(setupTimeout); err == nil && val > 0 {
+			config.SetupTimeout = val
+		}
+	}
+
+	config.apply(flags)
+
 	return config
 }
+
+func (c *Config) apply(o Overrides) {
+	if o.EnableParallel != nil {
+		c.EnableParallel = *o.EnableParallel
+	}
+	if o.MaxWorkers > 0 {
+		c.MaxWorkers = o.MaxWorkers
+	}
+	if o.SetupCommandWorkers > 0 {
+		c.SetupCommandWorkers = o.SetupCommandWorkers
+	}
+	if o.PackageQueryWorkers > 0 {
+		c.PackageQueryWorkers = o.PackageQueryWorkers
+	}
+	if o.SetupTimeout > 0 {
+		c.SetupTimeout = o.SetupTimeout
+	}
+	for key, so := range o.Schedules {
+		s := c.Schedule(key)
+		if so.Priority != nil {
+			s.Priority = *so.Priority
+		}
+		if so.Weight > 0 {
+			s.Weight = so.Weight
+		}
+		c.Schedules[key] = s
+	}
+}
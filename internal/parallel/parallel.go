@@ -2,14 +2,26 @@ package parallel
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 )
 
+var (
+	ErrTaskTimeout = errors.New("task execution timeout")
+	ErrTaskPanic   = errors.New("task panicked")
+)
+
 type WorkerPool struct {
-	maxWorkers int
-	timeout    time.Duration
+	maxWorkers  int
+	timeout     time.Duration
+	taskTimeout time.Duration
+	minInterval time.Duration
+
+	rateMu    sync.Mutex
+	nextStart time.Time
 }
 
 func NewWorkerPool(maxWorkers int, timeout time.Duration) *WorkerPool {
@@ -25,74 +37,56 @@ func NewWorkerPool(maxWorkers int, timeout time.Duration) *WorkerPool {
 	}
 }
 
+func (wp *WorkerPool) SetTaskTimeout(d time.Duration) *WorkerPool {
+	if d < 0 {
+		d = 0
+	}
+	wp.taskTimeout = d
+	return wp
+}
+
+func (wp *WorkerPool) SetRateLimit(perSecond float64) *WorkerPool {
+	if perSecond <= 0 {
+		wp.minInterval = 0
+		return wp
+	}
+	wp.minInterval = time.Duration(float64(time.Second) / perSecond)
+	return wp
+}
+
 type Task func() error
 
+type ContextTask func(ctx context.Context) error
+
 type Result struct {
 	Index int
 	Error error
 }
 
 func (wp *WorkerPool) Execute(tasks []Task) []error {
-	if len(tasks) == 0 {
-		return nil
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), wp.timeout)
-	defer cancel()
-
-	taskChan := make(chan int, len(tasks))
-	resultChan := make(chan Result, len(tasks))
-
-	var wg sync.WaitGroup
-	workerCount := wp.maxWorkers
-	if len(tasks) < workerCount {
-		workerCount = len(tasks)
+	ctxTasks := make([]ContextTask, len(tasks))
+	for i, t := range tasks {
+		t := t
+		ctxTasks[i] = func(context.Context) error { return t() }
 	}
+	return wp.ExecuteContext(context.Background(), ctxTasks)
+}
 
-	for i := 0; i < workerCount; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for {
-				select {
-				case taskIndex, ok := <-taskChan:
-					if !ok {
-						return
-					}
-					err := tasks[taskIndex]()
-					resultChan <- Result{Index: taskIndex, Error: err}
-				case <-ctx.Done():
-					return
-				}
-			}
-		}()
+func (wp *WorkerPool) ExecuteContext(ctx context.Context, tasks []ContextTask) []error {
+	if len(tasks) == 0 {
+		return nil
 	}
+	return wp.executeWithWorkers(ctx, tasks, wp.maxWorkers)
+}
 
-	go func() {
-		defer close(taskChan)
-		for i := range tasks {
-			select {
-			case taskChan <- i:
-			case <-ctx.Done():
-				return
-			}
-		}
-	}()
-
-	results := make([]error, len(tasks))
-	for i := 0; i < len(tasks); i++ {
-		select {
-		case result := <-resultChan:
-			results[result.Index] = result.Error
-		case <-ctx.Done():
-			results[i] = fmt.Errorf("task execution timeout")
-		}
+func (wp *WorkerPool) executeWithWorkers(ctx context.Context, tasks []ContextTask, workers int) []error {
+	if len(tasks) == 0 {
+		return nil
 	}
-
-	wg.Wait()
-	close(resultChan)
-
-	return results
+	_, errs := runPool(wp, ctx, len(tasks), workers, func(taskCtx context.Context, i int) (struct{}, error) {
+		return struct{}{}, tasks[i](taskCtx)
+	})
+	return errs
 }
 
 type StringTask func() (string, error)
@@ -107,93 +101,249 @@ func (wp *WorkerPool) ExecuteStringTasks(tasks []StringTask) ([]string, []error)
 	if len(tasks) == 0 {
 		return nil, nil
 	}
+	return runPool(wp, context.Background(), len(tasks), wp.maxWorkers, func(_ context.Context, i int) (string, error) {
+		return tasks[i]()
+	})
+}
+
+type taskOutcome[T any] struct {
+	value T
+	err   error
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), wp.timeout)
+func runPool[T any](wp *WorkerPool, parent context.Context, n, workers int, run func(ctx context.Context, i int) (T, error)) ([]T, []error) {
+	ctx, cancel := context.WithTimeout(parent, wp.timeout)
 	defer cancel()
 
-	taskChan := make(chan int, len(tasks))
-	resultChan := make(chan StringResult, len(tasks))
+	values := make([]T, n)
+	errs := make([]error, n)
+	started := make([]bool, n)
 
+	taskChan := make(chan int)
 	var wg sync.WaitGroup
-	workerCount := wp.maxWorkers
-	if len(tasks) < workerCount {
-		workerCount = len(tasks)
+	workerCount := workers
+	if workerCount <= 0 {
+		workerCount = 1
+	}
+	if n < workerCount {
+		workerCount = n
 	}
 
-	for i := 0; i < workerCount; i++ {
+	for w := 0; w < workerCount; w++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			for {
-				select {
-				case taskIndex, ok := <-taskChan:
-					if !ok {
-						return
-					}
-					value, err := tasks[taskIndex]()
-					resultChan <- StringResult{Index: taskIndex, Value: value, Error: err}
-				case <-ctx.Done():
-					return
-				}
+			for i := range taskChan {
+				values[i], errs[i] = runOne(wp, ctx, i, run)
 			}
 		}()
 	}
 
+	for i := 0; i < n; i++ {
+		if err := wp.waitForSlot(ctx); err != nil {
+			break
+		}
+		select {
+		case taskChan <- i:
+			started[i] = true
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	close(taskChan)
+	wg.Wait()
+
+	for i := range errs {
+		if !started[i] {
+			errs[i] = poolError(wp, ctx)
+		}
+	}
+	return values, errs
+}
+
+func runOne[T any](wp *WorkerPool, ctx context.Context, i int, run func(ctx context.Context, i int) (T, error)) (T, error) {
+	var zero T
+	taskCtx := ctx
+	cancel := func() {}
+	if wp.taskTimeout > 0 {
+		taskCtx, cancel = context.WithTimeout(ctx, wp.taskTimeout)
+	}
+	defer cancel()
+
+	done := make(chan taskOutcome[T], 1)
 	go func() {
-		defer close(taskChan)
-		for i := range tasks {
-			select {
-			case taskChan <- i:
-			case <-ctx.Done():
-				return
+		defer func() {
+			if r := recover(); r != nil {
+				done <- taskOutcome[T]{err: fmt.Errorf("%w: %v", ErrTaskPanic, r)}
 			}
-		}
+		}()
+		v, err := run(taskCtx, i)
+		done <- taskOutcome[T]{value: v, err: err}
 	}()
 
-	values := make([]string, len(tasks))
-	errors := make([]error, len(tasks))
-	for i := 0; i < len(tasks); i++ {
-		select {
-		case result := <-resultChan:
-			values[result.Index] = result.Value
-			errors[result.Index] = result.Error
-		case <-ctx.Done():
-			errors[i] = fmt.Errorf("task execution timeout")
+	select {
+	case out := <-done:
+		return out.value, out.err
+	case <-taskCtx.Done():
+		if ctx.Err() == nil {
+			return zero, fmt.Errorf("%w after %s", ErrTaskTimeout, wp.taskTimeout)
 		}
+		return zero, poolError(wp, ctx)
 	}
+}
 
-	wg.Wait()
-	close(resultChan)
+func poolError(wp *WorkerPool, ctx context.Context) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w after %s", ErrTaskTimeout, wp.timeout)
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return ErrTaskTimeout
+}
 
-	return values, errors
+func (wp *WorkerPool) waitForSlot(ctx context.Context) error {
+	if wp.minInterval <= 0 {
+		return ctx.Err()
+	}
+	wp.rateMu.Lock()
+	now := time.Now()
+	start := wp.nextStart
+	if start.Before(now) {
+		start = now
+	}
+	wp.nextStart = start.Add(wp.minInterval)
+	wp.rateMu.Unlock()
+
+	wait := time.Until(start)
+	if wait <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 type Batch struct {
-	Name  string
-	Tasks []Task
+	Name     string
+	Tasks    []Task
+	Priority int
+	Weight   int
 }
 
 func (wp *WorkerPool) ExecuteBatches(batches []Batch) map[string][]error {
+	return wp.ExecuteBatchesWithProgress(batches, nil)
+}
+
+func (wp *WorkerPool) ExecuteBatchesWithProgress(batches []Batch, onDone func(name string, errs []error)) map[string][]error {
 	if len(batches) == 0 {
 		return nil
 	}
 
-	var wg sync.WaitGroup
-	results := make(map[string][]error)
-	resultsMux := sync.Mutex{}
-
-	for _, batch := range batches {
-		wg.Add(1)
-		go func(b Batch) {
-			defer wg.Done()
-			batchResults := wp.Execute(b.Tasks)
+	queue := scheduleBatches(batches)
+	errs := make([][]error, len(batches))
+	remaining := make([]int, len(batches))
+	reported := make([]bool, len(batches))
+	var mu sync.Mutex
+	closed := false
+	report := func(b int) {
+		if reported[b] {
+			return
+		}
+		reported[b] = true
+		if onDone != nil {
+			onDone(batches[b].Name, append([]error(nil), errs[b]...))
+		}
+	}
 
-			resultsMux.Lock()
-			results[b.Name] = batchResults
-			resultsMux.Unlock()
-		}(batch)
+	for b, batch := range batches {
+		errs[b] = make([]error, len(batch.Tasks))
+		remaining[b] = len(batch.Tasks)
+		if remaining[b] == 0 {
+			report(b)
+		}
 	}
 
-	wg.Wait()
+	_, poolErrs := runPool(wp, context.Background(), len(queue), wp.maxWorkers, func(_ context.Context, i int) (struct{}, error) {
+		ref := queue[i]
+		err := batches[ref.batch].Tasks[ref.task]()
+		mu.Lock()
+		defer mu.Unlock()
+		if !closed {
+			errs[ref.batch][ref.task] = err
+			remaining[ref.batch]--
+			if remaining[ref.batch] == 0 {
+				report(ref.batch)
+			}
+		}
+		return struct{}{}, err
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+	closed = true
+	results := make(map[string][]error, len(batches))
+	for i, ref := range queue {
+		if poolErrs[i] != nil && errs[ref.batch][ref.task] == nil {
+			errs[ref.batch][ref.task] = poolErrs[i]
+		}
+	}
+	for b, batch := range batches {
+		report(b)
+		results[batch.Name] = errs[b]
+	}
 	return results
 }
+
+type taskRef struct {
+	batch int
+	task  int
+}
+
+func scheduleBatches(batches []Batch) []taskRef {
+	order := make([]int, len(batches))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return batches[order[i]].Priority > batches[order[j]].Priority
+	})
+
+	var queue []taskRef
+	for start := 0; start < len(order); {
+		end := start + 1
+		for end < len(order) && batches[order[end]].Priority == batches[order[start]].Priority {
+			end++
+		}
+		next := make([]int, end-start)
+		for left := true; left; {
+			left = false
+			for k, b := range order[start:end] {
+				tasks := batches[b].Tasks
+				for n := 0; n < batchWeight(batches[b]) && next[k] < len(tasks); n++ {
+					queue = append(queue, taskRef{batch: b, task: next[k]})
+					next[k]++
+				}
+				if next[k] < len(tasks) {
+					left = true
+				}
+			}
+		}
+		start = end
+	}
+	return queue
+}
+
+func batchWeight(b Batch) int {
+	if b.Weight <= 0 {
+		return 1
+	}
+	return b.Weight
+}
@@ -1,7 +1,15 @@
 package parallel
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -40,6 +48,103 @@ func TestWorkerPool(t *testing.T) {
 	}
 }
 
+func TestWorkerPoolTimeoutKeepsResultIndexes(t *testing.T) {
+	pool := NewWorkerPool(2, 200*time.Millisecond)
+	release := make(chan struct{})
+	defer close(release)
+
+	errFast := errors.New("fast failure")
+	tasks := []Task{
+		func() error {
+			<-release
+			return nil
+		},
+		func() error {
+			return errFast
+		},
+	}
+
+	results := pool.Execute(tasks)
+	if !errors.Is(results[0], ErrTaskTimeout) {
+		t.Errorf("task 0: expected timeout, got %v", results[0])
+	}
+	if !errors.Is(results[1], errFast) {
+		t.Errorf("task 1: expected its own error, got %v", results[1])
+	}
+}
+
+func TestWorkerPoolTaskTimeout(t *testing.T) {
+	pool := NewWorkerPool(2, 5*time.Second).SetTaskTimeout(50 * time.Millisecond)
+
+	tasks := []ContextTask{
+		func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+		func(ctx context.Context) error {
+			return nil
+		},
+	}
+
+	start := time.Now()
+	results := pool.ExecuteContext(context.Background(), tasks)
+	if time.Since(start) > time.Second {
+		t.Errorf("per-task timeout not applied, took %v", time.Since(start))
+	}
+	if !errors.Is(results[0], ErrTaskTimeout) {
+		t.Errorf("task 0: expected task timeout, got %v", results[0])
+	}
+	if results[1] != nil {
+		t.Errorf("task 1: unexpected error %v", results[1])
+	}
+}
+
+func TestWorkerPoolRecoversPanics(t *testing.T) {
+	pool := NewWorkerPool(2, 5*time.Second)
+
+	results := pool.Execute([]Task{
+		func() error { panic("boom") },
+		func() error { return nil },
+	})
+	if !errors.Is(results[0], ErrTaskPanic) {
+		t.Errorf("task 0: expected panic error, got %v", results[0])
+	}
+	if results[1] != nil {
+		t.Errorf("task 1: unexpected error %v", results[1])
+	}
+}
+
+func TestWorkerPoolCancelledContext(t *testing.T) {
+	pool := NewWorkerPool(1, 5*time.Second)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results := pool.ExecuteContext(ctx, []ContextTask{
+		func(context.Context) error { return nil },
+		func(context.Context) error { return nil },
+	})
+	for i, err := range results {
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("task %d: expected context.Canceled, got %v", i, err)
+		}
+	}
+}
+
+func TestWorkerPoolRateLimit(t *testing.T) {
+	pool := NewWorkerPool(4, 5*time.Second).SetRateLimit(20)
+
+	tasks := make([]Task, 4)
+	for i := range tasks {
+		tasks[i] = func() error { return nil }
+	}
+
+	start := time.Now()
+	pool.Execute(tasks)
+	if elapsed := time.Since(start); elapsed < 140*time.Millisecond {
+		t.Errorf("expected rate limit to space out task starts, took %v", elapsed)
+	}
+}
+
 func TestStringTasks(t *testing.T) {
 	pool := NewWorkerPool(3, 5*time.Second)
 
@@ -171,3 +276,457 @@ func ExampleWorkerPool() {
 		}
 	}
 }
+
+func TestIsAptCommand(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		want bool
+	}{
+		{"apt update -y", true},
+		{"DEBIAN_FRONTEND=noninteractive apt-get install -y curl", true},
+		{"prev=$(apt-mark showhold); apt-mark hold git", true},
+		{"dpkg -i pkg.deb", true},
+		{"pip install requests", false},
+		{"npm install -g aptly-cli", false},
+	}
+	for _, tt := range tests {
+		if got := IsAptCommand(tt.cmd); got != tt.want {
+			t.Errorf("IsAptCommand(%q) = %v, want %v", tt.cmd, got, tt.want)
+		}
+	}
+}
+
+func TestWrapAptLock(t *testing.T) {
+	t.Setenv("DEVBOX_APT_LOCK_TIMEOUT", "90s")
+
+	if got := WrapAptLock("pip install requests"); got != "pip install requests" {
+		t.Errorf("non-apt command should not be wrapped, got %q", got)
+	}
+
+	wrapped := WrapAptLock("apt install -y git")
+	if !strings.Contains(wrapped, "flock -w 90 9") {
+		t.Errorf("expected 90s flock timeout, got %q", wrapped)
+	}
+	if !strings.HasSuffix(wrapped, "; apt install -y git") {
+		t.Errorf("expected original command at the end, got %q", wrapped)
+	}
+}
+
+func TestShellInitLoadsGuardedBashrc(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not available")
+	}
+	home := t.TempDir()
+	files := map[string]string{
+		".profile": "if [ -f \"$HOME/.bashrc\" ]; then . \"$HOME/.bashrc\"; fi\necho from-profile\n",
+		".bashrc":  "[ -z \"$PS1\" ] && return\nexport PATH=\"$PATH:/opt/devbox-test/bin\"\napt() { echo wrapped; }\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(home, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		record bool
+		want   string
+	}{
+		{true, "/opt/devbox-test/bin|function|unset"},
+		{false, "/opt/devbox-test/bin|missing|unset"},
+	}
+	for _, tt := range tests {
+		script := WithShellInit(`case ":$PATH:" in *:/opt/devbox-test/bin:*) printf /opt/devbox-test/bin ;; esac; `+
+			`printf '|%s|%s' "$(declare -F apt >/dev/null && echo function || echo missing)" "${PS1-unset}"`, tt.record)
+		cmd := exec.Command(bash, "-c", script)
+		cmd.Env = []string{"HOME=" + home, "PATH=/usr/bin:/bin"}
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("bash failed: %v: %s", err, out)
+		}
+		if got := string(out); got != tt.want {
+			t.Errorf("ShellInit(%v) output = %q, want %q", tt.record, got, tt.want)
+		}
+	}
+}
+
+func TestExecuteBatchesRunsHigherPriorityFirst(t *testing.T) {
+	pool := NewWorkerPool(1, 5*time.Second)
+
+	var mu sync.Mutex
+	var order []string
+	record := func(name string) Task {
+		return func() error {
+			time.Sleep(20 * time.Millisecond)
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+			return nil
+		}
+	}
+
+	results := pool.ExecuteBatches([]Batch{
+		{Name: "slow", Tasks: []Task{record("slow")}, Priority: 0},
+		{Name: "quick", Tasks: []Task{record("quick")}, Priority: 10},
+	})
+
+	if len(results) != 2 {
+		t.Fatalf("expected results for 2 batches, got %d", len(results))
+	}
+	if len(order) != 2 || order[0] != "quick" {
+		t.Errorf("expected higher priority batch to finish first, got %v", order)
+	}
+}
+
+func TestExecuteBatchesSharesOnePool(t *testing.T) {
+	pool := NewWorkerPool(2, 5*time.Second)
+
+	release := make(chan struct{})
+	lowDone := make(chan struct{})
+	results := pool.ExecuteBatches([]Batch{
+		{Name: "apt", Tasks: []Task{func() error {
+			select {
+			case <-lowDone:
+			case <-time.After(2 * time.Second):
+				return errors.New("lower priority batch waited for the higher priority one")
+			}
+			close(release)
+			return nil
+		}}, Priority: 90},
+		{Name: "pip", Tasks: []Task{func() error {
+			close(lowDone)
+			<-release
+			return nil
+		}}, Priority: 30},
+	})
+
+	for name, errs := range results {
+		for _, err := range errs {
+			if err != nil {
+				t.Errorf("%s: %v", name, err)
+			}
+		}
+	}
+}
+
+func TestExecuteCommandGroupsKeepsOrderedTiers(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "log")
+	script := filepath.Join(dir, "engine")
+	body := "#!/bin/sh\nfor tag in sys apt pip npm yarn other; do case \"$5\" in *\"#$tag\"*) t=$tag;; esac; done\n" +
+		"echo \"start $t\" >> " + logPath + "\nsleep 0.2\necho \"end $t\" >> " + logPath + "\n"
+	if err := os.WriteFile(script, []byte(body), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DEVBOX_ENGINE", script)
+
+	executor := NewSetupCommandExecutor("test-box", false, 3)
+	groups := executor.categorizeCommands([]string{
+		"echo ready #other",
+		"npm install -g typescript #npm",
+		"pip install flask #pip",
+		"apt-get install -y nodejs python3-pip #apt",
+		"yarn global add webpack #yarn",
+		"usermod -aG docker dev #sys",
+	})
+	if err := executor.ExecuteCommandGroups(groups); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pos := map[string]int{}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	for i, line := range lines {
+		pos[line] = i
+	}
+	before := func(a, b string) {
+		t.Helper()
+		ia, okA := pos[a]
+		ib, okB := pos[b]
+		if !okA || !okB || ia > ib {
+			t.Errorf("expected %q before %q, got %v", a, b, lines)
+		}
+	}
+	before("end sys", "start apt")
+	for _, pm := range []string{"pip", "npm", "yarn"} {
+		before("end apt", "start "+pm)
+		before("end "+pm, "start other")
+	}
+	if pos["start npm"] > pos["end pip"] && pos["start pip"] > pos["end npm"] {
+		t.Errorf("package manager groups should share the pool, got %v", lines)
+	}
+}
+
+func TestScheduleBatches(t *testing.T) {
+	noop := func() error { return nil }
+	tasks := func(n int) []Task {
+		out := make([]Task, n)
+		for i := range out {
+			out[i] = noop
+		}
+		return out
+	}
+	batches := []Batch{
+		{Name: "pip", Tasks: tasks(2), Priority: 30},
+		{Name: "npm", Tasks: tasks(3), Priority: 20, Weight: 2},
+		{Name: "yarn", Tasks: tasks(2), Priority: 20},
+		{Name: "apt", Tasks: tasks(1), Priority: 90},
+	}
+	var got []string
+	for _, ref := range scheduleBatches(batches) {
+		got = append(got, fmt.Sprintf("%s%d", batches[ref.batch].Name, ref.task))
+	}
+	want := []string{"apt0", "pip0", "pip1", "npm0", "npm1", "yarn0", "npm2", "yarn1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("scheduleBatches() = %v, want %v", got, want)
+	}
+}
+
+func TestConfigSchedules(t *testing.T) {
+	defer SetSettings(Overrides{})
+
+	cfg := LoadConfig()
+	if sys, apt := cfg.Schedule("system"), cfg.Schedule("apt"); sys.Priority <= apt.Priority {
+		t.Errorf("system commands should be scheduled before apt (%d <= %d)", sys.Priority, apt.Priority)
+	}
+	if other := cfg.Schedule("unknown"); other.Priority != 0 || other.Weight != 1 {
+		t.Errorf("unknown groups should default to priority 0, weight 1; got %+v", other)
+	}
+
+	priority := 95
+	SetSettings(Overrides{Schedules: map[string]ScheduleOverride{
+		"pip": {Priority: &priority},
+		"npm": {Weight: 4},
+	}})
+	cfg = LoadConfig()
+	if got := cfg.Schedule("pip"); got.Priority != 95 || got.Weight != 1 {
+		t.Errorf("pip schedule = %+v, want priority 95 and the default weight", got)
+	}
+	if got := cfg.Schedule("npm"); got.Priority != 20 || got.Weight != 4 {
+		t.Errorf("npm schedule = %+v, want the default priority and weight 4", got)
+	}
+	if got := DefaultConfig().Schedule("pip"); got.Priority != 30 {
+		t.Errorf("settings should not change the defaults, got %+v", got)
+	}
+}
+
+func TestCategorizeCommandsUsesConfiguredSchedules(t *testing.T) {
+	defer SetSettings(Overrides{})
+	priority := 95
+	SetSettings(Overrides{Schedules: map[string]ScheduleOverride{"pip": {Priority: &priority}}})
+
+	groups := NewSetupCommandExecutor("test-box", false, 2).categorizeCommands([]string{"pip install flask", "npm install -g typescript"})
+	for _, g := range groups {
+		if g.Name == "Python Packages" && g.Priority != 95 {
+			t.Errorf("Python Packages priority = %d, want 95", g.Priority)
+		}
+		if g.Name == "NPM Packages" && (g.Priority != 20 || g.Weight != 2) {
+			t.Errorf("NPM Packages schedule = %d/%d, want 20/2", g.Priority, g.Weight)
+		}
+	}
+}
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	defer SetSettings(Overrides{})
+	defer SetFlagOverrides(Overrides{})
+
+	disabled := false
+	SetSettings(Overrides{EnableParallel: &disabled, SetupCommandWorkers: 6, PackageQueryWorkers: 7})
+	t.Setenv("DEVBOX_SETUP_WORKERS", "8")
+
+	cfg := LoadConfig()
+	if cfg.EnableParallel {
+		t.Error("settings should disable parallelism")
+	}
+	if cfg.SetupCommandWorkers != 8 {
+		t.Errorf("env should override settings, got %d setup workers", cfg.SetupCommandWorkers)
+	}
+	if cfg.PackageQueryWorkers != 7 {
+		t.Errorf("settings should override defaults, got %d query workers", cfg.PackageQueryWorkers)
+	}
+
+	enabled := true
+	SetFlagOverrides(Overrides{EnableParallel: &enabled, SetupCommandWorkers: 2})
+	cfg = LoadConfig()
+	if !cfg.EnableParallel || cfg.SetupCommandWorkers != 2 {
+		t.Errorf("flags should take precedence, got enabled=%v setup=%d", cfg.EnableParallel, cfg.SetupCommandWorkers)
+	}
+}
+
+func TestLoadConfigSetupTimeout(t *testing.T) {
+	defer SetSettings(Overrides{})
+	defer SetProjectOverrides(Overrides{})
+
+	if got := LoadConfig().SetupTimeout; got != 10*time.Minute {
+		t.Errorf("default setup timeout = %s, want 10m", got)
+	}
+	SetSettings(Overrides{SetupTimeout: 20 * time.Minute})
+	SetProjectOverrides(Overrides{SetupTimeout: 45 * time.Minute})
+	if got := LoadConfig().SetupTimeout; got != 45*time.Minute {
+		t.Errorf("project should override settings, got %s", got)
+	}
+	t.Setenv("DEVBOX_SETUP_TIMEOUT", "1h")
+	if got := LoadConfig().SetupTimeout; got != time.Hour {
+		t.Errorf("DEVBOX_SETUP_TIMEOUT should override the project, got %s", got)
+	}
+}
+
+func TestParsePackageQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		manager string
+		output  string
+		want    []string
+	}{
+		{"empty", "npm", "", nil},
+		{"npm no globals", "npm", `{"name": "lib", "dependencies": {}}`, nil},
+		{"npm globals", "npm", `{
+  "name": "lib",
+  "dependencies": {
+    "typescript": {"version": "5.4.5", "overridden": false},
+    "@angular/cli": {"version": "17.3.0", "resolved": "https://registry.npmjs.org/@angular/cli/-/cli-17.3.0.tgz"},
+    "corepack": {"version": "0.25.2"}
+  }
+}`, []string{"@angular/cli@17.3.0", "corepack@0.25.2", "typescript@5.4.5"}},
+		{"npm nested tree", "npm", `{"dependencies": {"eslint": {"version": "8.57.0", "dependencies": {
+  "chalk": {"version": "4.1.2", "dependencies": {"ansi-styles": {"version": "4.3.0"}}},
+  "debug": {"version": "4.3.4"}
+}}}}`, []string{"eslint@8.57.0"}},
+		{"npm problems", "npm", `{"problems": ["missing: left-pad@^1.3.0"], "dependencies": {
+  "left-pad": {"required": "^1.3.0", "missing": true},
+  "nodemon": {"version": "3.1.0", "invalid": "\"^2\" from the root project"},
+  "broken": {}
+}}`, []string{"nodemon@3.1.0"}},
+		{"npm leading noise", "npm", "npm warn config global `--global` is deprecated\n{\"dependencies\": {\"pm2\": {\"version\": \"5.3.1\"}}}\n", []string{"pm2@5.3.1"}},
+		{"pnpm", "pnpm", `[{"path": "/root/.local/share/pnpm/global/5", "private": false, "dependencies": {
+  "turbo": {"from": "turbo", "version": "1.13.2", "resolved": "https://registry.npmjs.org/turbo/-/turbo-1.13.2.tgz", "path": "/x"},
+  "local-tool": {"from": "local-tool", "version": "link:../../tools/local-tool", "path": "/tools/local-tool"},
+  "vercel": {"from": "vercel", "version": "34.1.0", "dependencies": {"@vercel/node": {"version": "3.0.26"}}}
+}}]`, []string{"turbo@1.13.2", "vercel@34.1.0"}},
+		{"pnpm several global dirs", "pnpm", `[{"dependencies": {"a": {"version": "1.0.0"}}}, {"dependencies": {"b": {"version": "2.0.0"}}}, {}]`, []string{"a@1.0.0", "b@2.0.0"}},
+		{"pnpm empty", "pnpm", `[]`, nil},
+		{"malformed", "npm", `{"dependencies": {"a": `, nil},
+		{"not json", "pnpm", "ERR_PNPM_NO_GLOBAL_BIN_DIR Unable to find the global bin directory", nil},
+		{"lines", "pip", "requests==2.31.0\n\n  flask==3.0.0\n", []string{"requests==2.31.0", "flask==3.0.0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParsePackageQuery(tt.manager, tt.output); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParsePackageQuery(%s) = %v, want %v", tt.manager, got, tt.want)
+			}
+		})
+	}
+}
+
+func benchPackageOutputs(n int) (apt, pip, npm string) {
+	var a, p strings.Builder
+	deps := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&a, "libpkg%d=1.%d.0-1ubuntu1\n", i, i)
+		fmt.Fprintf(&p, "package-%d==2.%d.0\n", i, i)
+		deps = append(deps, fmt.Sprintf(`"pkg-%d":{"version":"3.%d.0"}`, i, i))
+	}
+	return a.String(), p.String(), `{"dependencies":{` + strings.Join(deps, ",") + `}}`
+}
+
+func BenchmarkWorkerPoolOverhead(b *testing.B) {
+	for _, workers := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			pool := NewWorkerPool(workers, time.Minute)
+			tasks := make([]Task, 256)
+			for i := range tasks {
+				tasks[i] = func() error { return nil }
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				pool.Execute(tasks)
+			}
+		})
+	}
+}
+
+func BenchmarkExecuteBatches(b *testing.B) {
+	pool := NewWorkerPool(4, time.Minute)
+	noop := func() error { return nil }
+	batch := func(name string) Batch {
+		s := DefaultConfig().Schedule(groupScheduleKeys[name])
+		return Batch{Name: name, Tasks: []Task{noop, noop, noop, noop, noop, noop, noop, noop}, Priority: s.Priority, Weight: s.Weight}
+	}
+	batches := []Batch{batch("Python Packages"), batch("NPM Packages"), batch("Yarn Packages"), batch("PNPM Packages"), batch("Other Commands")}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pool.ExecuteBatches(batches)
+	}
+}
+
+func BenchmarkCategorizeCommands(b *testing.B) {
+	sce := NewSetupCommandExecutor("devbox_bench", false, 3)
+	prefixes := []string{"apt-get install -y pkg", "pip install pkg", "npm install -g pkg", "yarn global add pkg", "pnpm add -g pkg", "systemctl enable svc", "curl -fsSL https://example.com/install.sh | sh #"}
+	commands := make([]string, 0, 700)
+	for i := 0; i < 100; i++ {
+		for _, p := range prefixes {
+			commands = append(commands, fmt.Sprintf("%s%d", p, i))
+		}
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sce.categorizeCommands(commands)
+	}
+}
+
+func BenchmarkParsePackageQuery(b *testing.B) {
+	apt, pip, npm := benchPackageOutputs(2000)
+	for _, tt := range []struct{ name, output string }{{"apt", apt}, {"pip", pip}, {"npm", npm}} {
+		b.Run(tt.name, func(b *testing.B) {
+			b.SetBytes(int64(len(tt.output)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				ParsePackageQuery(tt.name, tt.output)
+			}
+		})
+	}
+}
+
+func BenchmarkPackageQueryExecutor(b *testing.B) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		b.Skip("sh is not available")
+	}
+	dir := b.TempDir()
+	apt, pip, npm := benchPackageOutputs(500)
+	for name, data := range map[string]string{"apt.txt": apt, "pip.txt": pip, "npm.json": npm} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			b.Fatal(err)
+		}
+	}
+	engine := filepath.Join(dir, "engine")
+	script := "#!/bin/sh\ncase \"$*\" in\n" +
+		"*dpkg-query*) cat '" + filepath.Join(dir, "apt.txt") + "' ;;\n" +
+		"*pip*) cat '" + filepath.Join(dir, "pip.txt") + "' ;;\n" +
+		"*npm*) cat '" + filepath.Join(dir, "npm.json") + "' ;;\n" +
+		"esac\n"
+	if err := os.WriteFile(engine, []byte(script), 0755); err != nil {
+		b.Fatal(err)
+	}
+	b.Setenv("DEVBOX_ENGINE", engine)
+
+	for _, workers := range []int{1, 5} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			executor := NewPackageQueryExecutor("devbox_bench", workers)
+			for i := 0; i < b.N; i++ {
+				lists, err := executor.QueryAllPackages()
+				if err != nil {
+					b.Fatal(err)
+				}
+				if len(lists["apt"]) != 500 || len(lists["npm"]) != 500 {
+					b.Fatalf("apt = %d, npm = %d packages, want 500", len(lists["apt"]), len(lists["npm"]))
+				}
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"sort"
@@ -22,6 +23,8 @@ type SetupCommandExecutor struct {
 	boxName    string
 	workerPool *WorkerPool
 	showOutput bool
+	logger     *StepLogger
+	config     *Config
 }
 
 func NewSetupCommandExecutor(boxName string, showOutput bool, maxWorkers int) *SetupCommandExecutor {
@@ -29,10 +32,12 @@ func NewSetupCommandExecutor(boxName string, showOutput bool, maxWorkers int) *S
 		maxWorkers = 3
 	}
 
+	config := LoadConfig()
 	return &SetupCommandExecutor{
 		boxName:    boxName,
-		workerPool: NewWorkerPool(maxWorkers, 10*time.Minute),
+		workerPool: NewWorkerPool(maxWorkers, config.SetupTimeout),
 		showOutput: showOutput,
+		config:     config,
 	}
 }
 
@@ -40,6 +45,27 @@ type CommandGroup struct {
 	Name     string
 	Commands []string
 	Parallel bool
+	Priority int
+	Weight   int
+}
+
+var groupScheduleKeys = map[string]string{
+	"System Commands": "system",
+	"APT Packages":    "apt",
+	"Python Packages": "pip",
+	"NPM Packages":    "npm",
+	"Yarn Packages":   "yarn",
+	"PNPM Packages":   "pnpm",
+	"Other Commands":  "other",
+}
+
+func (sce *SetupCommandExecutor) newCommandGroup(name string, commands []string, parallel bool) CommandGroup {
+	s := sce.config.Schedule(groupScheduleKeys[name])
+	return CommandGroup{Name: name, Commands: commands, Parallel: parallel, Priority: s.Priority, Weight: s.Weight}
+}
+
+func (sce *SetupCommandExecutor) SetStepLogger(logger *StepLogger) {
+	sce.logger = logger
 }
 
 func (sce *SetupCommandExecutor) ExecuteCommandGroups(groups []CommandGroup) error {
@@ -47,58 +73,68 @@ func (sce *SetupCommandExecutor) ExecuteCommandGroups(groups []CommandGroup) err
 		return nil
 	}
 
-	var parallelBatches []Batch
-	var sequentialGroups []CommandGroup
-
-	for _, group := range groups {
-		if group.Parallel {
+	if sce.showOutput {
+		fmt.Printf("Executing %d command groups...\n", len(groups))
+	}
 
-			tasks := make([]Task, len(group.Commands))
-			for i, cmd := range group.Commands {
-				tasks[i] = sce.createCommandTask(cmd, i+1, len(group.Commands), group.Name)
-			}
-			parallelBatches = append(parallelBatches, Batch{Name: group.Name, Tasks: tasks})
-		} else {
-			sequentialGroups = append(sequentialGroups, group)
+	completed := 0
+	progress := func(name string) {
+		completed++
+		if sce.showOutput {
+			fmt.Printf("[%s] finished (%d/%d groups)\n", name, completed, len(groups))
 		}
 	}
 
-	if len(parallelBatches) > 0 {
-		if sce.showOutput {
-			fmt.Printf("Executing %d parallel command groups...\n", len(parallelBatches))
+	var pending []CommandGroup
+	for _, group := range groups {
+		if group.Parallel {
+			pending = append(pending, group)
+			continue
+		}
+		if err := sce.executeParallelGroups(pending, progress); err != nil {
+			return err
+		}
+		pending = nil
+		if err := sce.workerPool.Execute([]Task{sce.createSequentialTask(group)})[0]; err != nil {
+			return err
 		}
+		progress(group.Name)
+	}
+	if err := sce.executeParallelGroups(pending, progress); err != nil {
+		return err
+	}
 
-		batchResults := sce.workerPool.ExecuteBatches(parallelBatches)
+	if sce.showOutput {
+		fmt.Printf("All command groups completed successfully!\n")
+	}
+	return nil
+}
 
-		for batchName, results := range batchResults {
-			for i, err := range results {
-				if err != nil {
-					return fmt.Errorf("parallel command group '%s', command %d failed: %w", batchName, i+1, err)
-				}
-			}
-		}
+func (sce *SetupCommandExecutor) executeParallelGroups(groups []CommandGroup, progress func(name string)) error {
+	if len(groups) == 0 {
+		return nil
+	}
 
-		if sce.showOutput {
-			fmt.Printf("All parallel command groups completed successfully!\n")
+	batches := make([]Batch, len(groups))
+	for i, group := range groups {
+		tasks := make([]Task, len(group.Commands))
+		for j, cmd := range group.Commands {
+			tasks[j] = sce.createCommandTask(cmd, j+1, len(group.Commands), group.Name)
 		}
+		batches[i] = Batch{Name: group.Name, Tasks: tasks, Priority: group.Priority, Weight: group.Weight}
 	}
 
-	for _, group := range sequentialGroups {
-		if sce.showOutput {
-			fmt.Printf("Executing sequential group: %s\n", group.Name)
-		}
+	batchResults := sce.workerPool.ExecuteBatchesWithProgress(batches, func(name string, errs []error) {
+		progress(name)
+	})
 
-		for i, cmd := range group.Commands {
-			if err := sce.executeCommand(cmd, i+1, len(group.Commands), group.Name); err != nil {
-				return fmt.Errorf("sequential command group '%s', command %d failed: %w", group.Name, i+1, err)
+	for _, group := range groups {
+		for i, err := range batchResults[group.Name] {
+			if err != nil {
+				return fmt.Errorf("parallel command group '%s', command %d failed: %w", group.Name, i+1, err)
 			}
 		}
-
-		if sce.showOutput {
-			fmt.Printf("Sequential group '%s' completed successfully!\n", group.Name)
-		}
 	}
-
 	return nil
 }
 
@@ -146,36 +182,50 @@ func (sce *SetupCommandExecutor) categorizeCommands(commands []string) []Command
 	}
 
 	if len(systemCommands) > 0 {
-		groups = append(groups, CommandGroup{Name: "System Commands", Commands: systemCommands, Parallel: false})
+		groups = append(groups, sce.newCommandGroup("System Commands", systemCommands, false))
 	}
 
 	if len(aptCommands) > 0 {
-		groups = append(groups, CommandGroup{Name: "APT Packages", Commands: aptCommands, Parallel: false})
+		groups = append(groups, sce.newCommandGroup("APT Packages", aptCommands, false))
 	}
 
 	var packageGroups []CommandGroup
 	if len(pipCommands) > 0 {
-		packageGroups = append(packageGroups, CommandGroup{Name: "Python Packages", Commands: pipCommands, Parallel: true})
+		packageGroups = append(packageGroups, sce.newCommandGroup("Python Packages", pipCommands, true))
 	}
 	if len(npmCommands) > 0 {
-		packageGroups = append(packageGroups, CommandGroup{Name: "NPM Packages", Commands: npmCommands, Parallel: true})
+		packageGroups = append(packageGroups, sce.newCommandGroup("NPM Packages", npmCommands, true))
 	}
 	if len(yarnCommands) > 0 {
-		packageGroups = append(packageGroups, CommandGroup{Name: "Yarn Packages", Commands: yarnCommands, Parallel: true})
+		packageGroups = append(packageGroups, sce.newCommandGroup("Yarn Packages", yarnCommands, true))
 	}
 	if len(pnpmCommands) > 0 {
-		packageGroups = append(packageGroups, CommandGroup{Name: "PNPM Packages", Commands: pnpmCommands, Parallel: true})
+		packageGroups = append(packageGroups, sce.newCommandGroup("PNPM Packages", pnpmCommands, true))
 	}
 
 	groups = append(groups, packageGroups...)
 
 	if len(otherCommands) > 0 {
-		groups = append(groups, CommandGroup{Name: "Other Commands", Commands: otherCommands, Parallel: false})
+		groups = append(groups, sce.newCommandGroup("Other Commands", otherCommands, false))
 	}
 
 	return groups
 }
 
+func (sce *SetupCommandExecutor) createSequentialTask(group CommandGroup) Task {
+	return func() error {
+		if sce.showOutput {
+			fmt.Printf("Executing sequential group: %s\n", group.Name)
+		}
+		for i, cmd := range group.Commands {
+			if err := sce.executeCommand(cmd, i+1, len(group.Commands), group.Name); err != nil {
+				return fmt.Errorf("sequential command group '%s', command %d failed: %w", group.Name, i+1, err)
+			}
+		}
+		return nil
+	}
+}
+
 func (sce *SetupCommandExecutor) createCommandTask(command string, step, total int, groupName string) Task {
 	return func() error {
 		return sce.executeCommand(command, step, total, groupName)
@@ -187,31 +237,31 @@ func (sce *SetupCommandExecutor) executeCommand(command string, step, total int,
 		fmt.Printf("[%s] Step %d/%d: %s\n", groupName, step, total, command)
 	}
 
-	wrapped := ". /root/.bashrc >/dev/null 2>&1 || true; " + command
+	wrapped := WithShellInit(WrapAptLock(command), false)
 	cmd := exec.Command(engineCmd(), "exec", sce.boxName, "bash", "-c", wrapped)
 
+	var output, stderr bytes.Buffer
 	if sce.showOutput {
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("command failed: %s: %w", command, err)
-		}
+		cmd.Stdout = io.MultiWriter(os.Stdout, &output)
+		cmd.Stderr = io.MultiWriter(os.Stderr, &output, &stderr)
 	} else {
-		var stdout, stderr bytes.Buffer
-		cmd.Stdout = &stdout
-		cmd.Stderr = &stderr
+		cmd.Stdout = &output
+		cmd.Stderr = io.MultiWriter(&output, &stderr)
+	}
 
-		if err := cmd.Run(); err != nil {
+	err := cmd.Run()
+	logPath := sce.logger.Write(groupName, step, command, output.String(), err)
+	if err != nil {
+		if lockErr := AptLockError(sce.boxName, command, stderr.String(), err); lockErr != nil {
+			return WithLogPath(lockErr, logPath)
+		}
+		if !sce.showOutput {
 			fmt.Printf("Command failed: %s\n", command)
-			if stderr.Len() > 0 {
-				fmt.Printf("Error output: %s\n", stderr.String())
-			}
-			if stdout.Len() > 0 {
-				fmt.Printf("Standard output: %s\n", stdout.String())
+			if output.Len() > 0 {
+				fmt.Printf("Output: %s\n", output.String())
 			}
-			return fmt.Errorf("command failed: %s: %w", command, err)
 		}
+		return WithLogPath(fmt.Errorf("command failed: %s: %w", command, err), logPath)
 	}
 
 	return nil
@@ -222,10 +272,14 @@ type PackageQueryExecutor struct {
 	workerPool *WorkerPool
 }
 
-func NewPackageQueryExecutor(boxName string) *PackageQueryExecutor {
+func NewPackageQueryExecutor(boxName string, maxWorkers int) *PackageQueryExecutor {
+	if maxWorkers <= 0 {
+		maxWorkers = 5
+	}
+
 	return &PackageQueryExecutor{
 		boxName:    boxName,
-		workerPool: NewWorkerPool(5, 2*time.Minute),
+		workerPool: NewWorkerPool(maxWorkers, 2*time.Minute),
 	}
 }
 
@@ -234,15 +288,36 @@ type PackageQuery struct {
 	Command string
 }
 
-func (pqe *PackageQueryExecutor) QueryAllPackages() (map[string][]string, error) {
-	queries := []PackageQuery{
-		{"apt", "dpkg-query -W -f='${Package}=${Version}\\n' $(apt-mark showmanual 2>/dev/null || true) 2>/dev/null | sort"},
-		{"pip", "python3 -m pip freeze 2>/dev/null || pip3 freeze 2>/dev/null || true"},
-		{"npm", "npm list -g --depth=0 --json 2>/dev/null || true"},
-		{"yarn", "node -e \"(async()=>{const cp=require('child_process');function sh(c){try{return cp.execSync(c,{stdio:['ignore','pipe','ignore']}).toString()}catch(e){return ''}}const dir=sh('yarn global dir').trim();if(!dir){process.exit(0)}const fs=require('fs'),path=require('path');const pkgLock=path.join(dir,'package.json');let deps={};try{const pkg=JSON.parse(fs.readFileSync(pkgLock,'utf8'));deps=Object.assign({},pkg.dependencies||{},pkg.devDependencies||{})}catch{}Object.keys(deps).forEach(n=>{let v='';try{const pj=JSON.parse(fs.readFileSync(path.join(dir,'node_modules',n,'package.json'),'utf8'));v=pj.version||''}catch{}if(v)console.log(n+'@'+v)});})();\" 2>/dev/null || true"},
-		{"pnpm", "pnpm ls -g --depth=0 --json 2>/dev/null || true"},
+var PackageQueries = []PackageQuery{
+	{"apt", "dpkg-query -W -f='${Package}=${Version}\\n' $(apt-mark showmanual 2>/dev/null || true) 2>/dev/null | sort"},
+	{"pip", "python3 -m pip freeze 2>/dev/null || pip3 freeze 2>/dev/null || true"},
+	{"npm", "npm list -g --depth=0 --json 2>/dev/null || true"},
+	{"yarn", "node -e \"(async()=>{const cp=require('child_process');function sh(c){try{return cp.execSync(c,{stdio:['ignore','pipe','ignore']}).toString()}catch(e){return ''}}const dir=sh('yarn global dir').trim();if(!dir){process.exit(0)}const fs=require('fs'),path=require('path');const pkgLock=path.join(dir,'package.json');let deps={};try{const pkg=JSON.parse(fs.readFileSync(pkgLock,'utf8'));deps=Object.assign({},pkg.dependencies||{},pkg.devDependencies||{})}catch{}Object.keys(deps).forEach(n=>{let v='';try{const pj=JSON.parse(fs.readFileSync(path.join(dir,'node_modules',n,'package.json'),'utf8'));v=pj.version||''}catch{}if(v)console.log(n+'@'+v)});})();\" 2>/dev/null || true"},
+	{"pnpm", "pnpm ls -g --depth=0 --json 2>/dev/null || true"},
+}
+
+func SelectPackageQueries(names []string) []PackageQuery {
+	if names == nil {
+		return PackageQueries
+	}
+	want := map[string]bool{}
+	for _, n := range names {
+		want[n] = true
 	}
+	var out []PackageQuery
+	for _, q := range PackageQueries {
+		if want[q.Name] {
+			out = append(out, q)
+		}
+	}
+	return out
+}
 
+func (pqe *PackageQueryExecutor) QueryAllPackages() (map[string][]string, error) {
+	return pqe.QueryPackages(PackageQueries)
+}
+
+func (pqe *PackageQueryExecutor) QueryPackages(queries []PackageQuery) (map[string][]string, error) {
 	tasks := make([]StringTask, len(queries))
 	for i, query := range queries {
 		tasks[i] = pqe.createQueryTask(query.Command)
@@ -258,19 +333,21 @@ func (pqe *PackageQueryExecutor) QueryAllPackages() (map[string][]string, error)
 			continue
 		}
 
-		switch query.Name {
-		case "apt", "pip":
-			packageLists[query.Name] = parseLineList(results[i])
-		case "npm", "pnpm":
-			packageLists[query.Name] = parseJSONPackageList(results[i])
-		case "yarn":
-			packageLists[query.Name] = parseLineList(results[i])
-		}
+		packageLists[query.Name] = ParsePackageQuery(query.Name, results[i])
 	}
 
 	return packageLists, nil
 }
 
+func ParsePackageQuery(name, output string) []string {
+	switch name {
+	case "npm", "pnpm":
+		return parseJSONPackageList(output)
+	default:
+		return parseLineList(output)
+	}
+}
+
 func (pqe *PackageQueryExecutor) createQueryTask(command string) StringTask {
 	return func() (string, error) {
 		cmd := exec.Command(engineCmd(), "exec", pqe.boxName, "bash", "-c", command)
@@ -298,51 +375,55 @@ func parseLineList(output string) []string {
 	return result
 }
 
+type jsonPackageNode struct {
+	Version      string                     `json:"version"`
+	Missing      bool                       `json:"missing"`
+	Dependencies map[string]jsonPackageNode `json:"dependencies"`
+}
+
+func (n jsonPackageNode) installedVersion() string {
+	v := strings.TrimSpace(n.Version)
+	if n.Missing || v == "" || strings.HasPrefix(v, "link:") || strings.HasPrefix(v, "file:") {
+		return ""
+	}
+	return v
+}
+
 func parseJSONPackageList(output string) []string {
-	if strings.TrimSpace(output) == "" {
+	start := strings.IndexAny(output, "{[")
+	if start < 0 {
 		return nil
 	}
+	dec := json.NewDecoder(strings.NewReader(output[start:]))
 
-	type npmDep struct {
-		Version string `json:"version"`
-	}
-	var obj struct {
-		Dependencies map[string]npmDep `json:"dependencies"`
-	}
-	if err := json.Unmarshal([]byte(output), &obj); err == nil && obj.Dependencies != nil {
-		var res []string
-		for name, dep := range obj.Dependencies {
-			if dep.Version != "" {
-				res = append(res, fmt.Sprintf("%s@%s", name, dep.Version))
-			}
+	var roots []jsonPackageNode
+	if output[start] == '[' {
+		if err := dec.Decode(&roots); err != nil {
+			return nil
 		}
-		sort.Strings(res)
-		return res
-	}
-
-	var arr []map[string]any
-	if err := json.Unmarshal([]byte(output), &arr); err == nil {
-		depSet := map[string]string{}
-		for _, node := range arr {
-			if deps, ok := node["dependencies"].(map[string]any); ok {
-				for name, v := range deps {
-					if m, ok := v.(map[string]any); ok {
-						if ver, ok := m["version"].(string); ok && ver != "" {
-							depSet[name] = ver
-						}
-					}
-				}
-			}
+	} else {
+		var root jsonPackageNode
+		if err := dec.Decode(&root); err != nil {
+			return nil
 		}
-		if len(depSet) > 0 {
-			var res []string
-			for name, ver := range depSet {
-				res = append(res, fmt.Sprintf("%s@%s", name, ver))
+		roots = append(roots, root)
+	}
+
+	versions := map[string]string{}
+	for _, root := range roots {
+		for name, dep := range root.Dependencies {
+			if v := dep.installedVersion(); v != "" {
+				versions[name] = v
 			}
-			sort.Strings(res)
-			return res
 		}
 	}
-
-	return nil
+	if len(versions) == 0 {
+		return nil
+	}
+	res := make([]string, 0, len(versions))
+	for name, v := range versions {
+		res = append(res, name+"@"+v)
+	}
+	sort.Strings(res)
+	return res
 }
@@ -0,0 +1,21 @@
+package parallel
+
+import "strings"
+
+var recordedPackageCommands = []string{"apt", "apt-get", "pip", "pip3", "npm", "yarn", "pnpm", "corepack"}
+
+const loginShellInit = `PS1="${PS1:-\$ }"; { [ -r /etc/profile ] && . /etc/profile; ` +
+	`if [ -r "$HOME/.bash_profile" ]; then . "$HOME/.bash_profile"; ` +
+	`elif [ -r "$HOME/.bash_login" ]; then . "$HOME/.bash_login"; ` +
+	`elif [ -r "$HOME/.profile" ]; then . "$HOME/.profile"; fi; } >/dev/null 2>&1 </dev/null; unset PS1; `
+
+func ShellInit(record bool) string {
+	if record {
+		return loginShellInit
+	}
+	return loginShellInit + "unset -f " + strings.Join(recordedPackageCommands, " ") + " 2>/dev/null; "
+}
+
+func WithShellInit(command string, record bool) string {
+	return ShellInit(record) + command
+}
@@ -0,0 +1,79 @@
+package parallel
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const StepLogExitPrefix = "# exit: "
+
+var slugPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+type StepLogger struct {
+	dir  string
+	mu   sync.Mutex
+	made bool
+}
+
+func NewStepLogger(workspacePath string) *StepLogger {
+	if strings.TrimSpace(workspacePath) == "" {
+		return nil
+	}
+	ts := time.Now().UTC().Format("20060102-150405")
+	return &StepLogger{dir: filepath.Join(workspacePath, ".devbox", "logs", "setup-"+ts)}
+}
+
+func (sl *StepLogger) Dir() string {
+	if sl == nil {
+		return ""
+	}
+	return sl.dir
+}
+
+func (sl *StepLogger) Write(group string, step int, command, output string, runErr error) string {
+	if sl == nil {
+		return ""
+	}
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	if !sl.made {
+		if err := os.MkdirAll(sl.dir, 0755); err != nil {
+			return ""
+		}
+		sl.made = true
+	}
+
+	name := fmt.Sprintf("%02d.log", step)
+	if slug := strings.Trim(slugPattern.ReplaceAllString(strings.ToLower(group), "-"), "-"); slug != "" {
+		name = fmt.Sprintf("%s-%02d.log", slug, step)
+	}
+	path := filepath.Join(sl.dir, name)
+
+	status := "0"
+	if runErr != nil {
+		status = runErr.Error()
+	}
+	var b strings.Builder
+	b.WriteString("$ " + command + "\n")
+	b.WriteString(output)
+	if output != "" && !strings.HasSuffix(output, "\n") {
+		b.WriteString("\n")
+	}
+	b.WriteString(StepLogExitPrefix + status + "\n")
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return ""
+	}
+	return path
+}
+
+func WithLogPath(err error, path string) error {
+	if err == nil || path == "" {
+		return err
+	}
+	return fmt.Errorf("%w (log: %s)", err, path)
+}
@@ -0,0 +1,105 @@
+package sync
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type Entry struct {
+	Size    int64       `json:"size"`
+	ModTime int64       `json:"mtime"`
+	Mode    fs.FileMode `json:"mode"`
+}
+
+type Index map[string]Entry
+
+func Ignored(rel string, patterns []string) bool {
+	rel = filepath.ToSlash(rel)
+	base := path.Base(rel)
+	for _, pattern := range patterns {
+		pattern = strings.TrimSuffix(filepath.ToSlash(pattern), "/")
+		if ok, _ := path.Match(pattern, base); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, rel); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func Scan(root string, ignore []string) (Index, error) {
+	index := Index{}
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p != root && (errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrPermission)) {
+				return nil
+			}
+			return err
+		}
+		if p == root {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if Ignored(rel, ignore) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		mode := info.Mode()
+		if !mode.IsRegular() && !mode.IsDir() && mode&fs.ModeSymlink == 0 {
+			return nil
+		}
+		entry := Entry{Mode: mode}
+		if !mode.IsDir() {
+			entry.Size = info.Size()
+			entry.ModTime = info.ModTime().UnixNano()
+		}
+		index[rel] = entry
+		return nil
+	})
+	return index, err
+}
+
+func Diff(prev, cur Index) (changed, removed []string) {
+	var stale []string
+	for rel, entry := range cur {
+		old, ok := prev[rel]
+		switch {
+		case !ok:
+			changed = append(changed, rel)
+		case old.Mode.Type() != entry.Mode.Type():
+			stale = append(stale, rel)
+			changed = append(changed, rel)
+		case old != entry:
+			changed = append(changed, rel)
+		}
+	}
+	for rel := range prev {
+		if _, ok := cur[rel]; !ok {
+			stale = append(stale, rel)
+		}
+	}
+	sort.Strings(changed)
+	sort.Strings(stale)
+	for _, rel := range stale {
+		if n := len(removed); n > 0 && strings.HasPrefix(rel, removed[n-1]+"/") {
+			continue
+		}
+		removed = append(removed, rel)
+	}
+	return changed, removed
+}
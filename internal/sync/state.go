@@ -0,0 +1,79 @@
+package sync
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+type Status struct {
+	PID       int       `json:"pid"`
+	Box       string    `json:"box"`
+	Workspace string    `json:"workspace"`
+	Target    string    `json:"target"`
+	StartedAt time.Time `json:"started_at"`
+	CheckedAt time.Time `json:"checked_at"`
+	SyncedAt  time.Time `json:"synced_at"`
+	FlushedAt time.Time `json:"flushed_at"`
+	Files     int       `json:"files"`
+	Pushed    int       `json:"pushed"`
+	Removed   int       `json:"removed"`
+	Pending   int       `json:"pending"`
+	LastError string    `json:"last_error,omitempty"`
+}
+
+func (s *Status) Running() bool {
+	return s != nil && s.PID > 0 && syscall.Kill(s.PID, 0) == nil
+}
+
+func LoadStatus(path string) (*Status, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var status Status
+	if err := json.Unmarshal(data, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+func SaveStatus(path string, status *Status) error {
+	return writeJSON(path, status)
+}
+
+func LoadIndex(path string) Index {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var index Index
+	if json.Unmarshal(data, &index) != nil {
+		return nil
+	}
+	return index
+}
+
+func SaveIndex(path string, index Index) error {
+	return writeJSON(path, index)
+}
+
+func writeJSON(path string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,221 @@
+package sync
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestIgnored(t *testing.T) {
+	patterns := []string{"node_modules", "*.log", "build/*", ".venv/"}
+	tests := []struct {
+		rel  string
+		want bool
+	}{
+		{"node_modules", true},
+		{"web/node_modules", true},
+		{"debug.log", true},
+		{"logs/app.log", true},
+		{"build/out.bin", true},
+		{"src/build/out.bin", false},
+		{".venv", true},
+		{"src/main.go", false},
+	}
+	for _, tt := range tests {
+		if got := Ignored(tt.rel, patterns); got != tt.want {
+			t.Errorf("Ignored(%q) = %v, want %v", tt.rel, got, tt.want)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	file := func(size int64) Entry { return Entry{Size: size, ModTime: 1, Mode: 0644} }
+	dir := Entry{Mode: os.ModeDir | 0755}
+	prev := Index{
+		"a.txt":     file(1),
+		"b.txt":     file(1),
+		"old":       dir,
+		"old/x.txt": file(1),
+		"swap":      file(1),
+	}
+	cur := Index{
+		"a.txt":      file(1),
+		"b.txt":      file(2),
+		"new.txt":    file(1),
+		"swap":       dir,
+		"swap/y.txt": file(1),
+	}
+	changed, removed := Diff(prev, cur)
+	if want := []string{"b.txt", "new.txt", "swap", "swap/y.txt"}; !reflect.DeepEqual(changed, want) {
+		t.Errorf("Diff() changed = %q, want %q", changed, want)
+	}
+	if want := []string{"old", "swap"}; !reflect.DeepEqual(removed, want) {
+		t.Errorf("Diff() removed = %q, want %q", removed, want)
+	}
+	if changed, removed := Diff(cur, cur); len(changed)+len(removed) != 0 {
+		t.Errorf("Diff() of identical indexes = %q, %q", changed, removed)
+	}
+}
+
+type fakeTransport struct {
+	copied  []string
+	removed []string
+	boxFile map[string][]byte
+	err     error
+}
+
+func (f *fakeTransport) CopyFilesToBox(boxName, root, dest string, files []string) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.copied = append(f.copied, files...)
+	return nil
+}
+
+func (f *fakeTransport) RemoveFilesInBox(boxName, dest string, files []string) error {
+	f.removed = append(f.removed, files...)
+	return nil
+}
+
+func (f *fakeTransport) ReadFilesFromBox(boxName, dest string, files []string) (map[string][]byte, error) {
+	out := map[string][]byte{}
+	for _, rel := range files {
+		if data, ok := f.boxFile[rel]; ok {
+			out[rel] = data
+		}
+	}
+	return out, nil
+}
+
+func TestSyncerPass(t *testing.T) {
+	root := t.TempDir()
+	write := func(rel, data string) {
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("main.go", "package main")
+	write("src/util.go", "package src")
+	write("node_modules/left-pad/index.js", "module.exports = 1")
+
+	transport := &fakeTransport{}
+	s := &Syncer{Box: "devbox_web", Root: root, Dest: "/workspace", Ignore: []string{"node_modules"}, Transport: transport}
+	res, err := s.Pass()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"main.go", "src", "src/util.go"}; !reflect.DeepEqual(transport.copied, want) {
+		t.Errorf("first Pass() copied %q, want %q", transport.copied, want)
+	}
+	if res.Files != 3 || res.Pushed != 3 || res.Pending != 0 {
+		t.Errorf("first Pass() = %+v", res)
+	}
+
+	transport.copied = nil
+	if res, _ := s.Pass(); res.Pushed+res.Removed != 0 || len(transport.copied) != 0 {
+		t.Errorf("Pass() without changes = %+v, copied %q", res, transport.copied)
+	}
+
+	write("main.go", "package main // edited")
+	os.Chtimes(filepath.Join(root, "main.go"), time.Now().Add(time.Minute), time.Now().Add(time.Minute))
+	if err := os.Remove(filepath.Join(root, "src", "util.go")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Pass(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(transport.copied, []string{"main.go"}) || !reflect.DeepEqual(transport.removed, []string{"src/util.go"}) {
+		t.Errorf("Pass() after edits copied %q removed %q", transport.copied, transport.removed)
+	}
+
+	transport.copied = nil
+	transport.err = os.ErrPermission
+	write("extra.go", "package main")
+	res, err = s.Pass()
+	if err == nil || res.Pending != 1 {
+		t.Errorf("failing Pass() = %+v, %v; want 1 pending and an error", res, err)
+	}
+	transport.err = nil
+	if _, err := s.Pass(); err != nil || !reflect.DeepEqual(transport.copied, []string{"extra.go"}) {
+		t.Errorf("retried Pass() copied %q, %v", transport.copied, err)
+	}
+}
+
+func TestSyncerPassPullsFiles(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".devbox"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, ".devbox", "journal"), []byte("apt install git\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "main.go"), []byte("package main"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	transport := &fakeTransport{boxFile: map[string][]byte{".devbox/journal": []byte("apt install git\npip install ruff\n")}}
+	pull := []string{".devbox/journal", ".devbox/history"}
+	s := &Syncer{Box: "devbox_web", Root: root, Dest: "/workspace", Pull: pull, Transport: transport,
+		Index: Index{".devbox/journal": {Size: 1}}}
+	res, err := s.Pass()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{".devbox", "main.go"}; !reflect.DeepEqual(transport.copied, want) {
+		t.Errorf("Pass() pushed %q, want %q", transport.copied, want)
+	}
+	if len(transport.removed) != 0 {
+		t.Errorf("Pass() removed pulled files in the box: %q", transport.removed)
+	}
+	if res.Pulled != 1 {
+		t.Errorf("Pass() pulled %d files, want 1", res.Pulled)
+	}
+	data, err := os.ReadFile(filepath.Join(root, ".devbox", "journal"))
+	if err != nil || string(data) != "apt install git\npip install ruff\n" {
+		t.Errorf("journal = %q, %v; want the box copy", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(root, ".devbox", "history")); !os.IsNotExist(err) {
+		t.Errorf("history should not be created when the box has none, got %v", err)
+	}
+
+	if res, _ := s.Pass(); res.Pulled != 0 {
+		t.Errorf("Pass() with an unchanged box copy pulled %d files", res.Pulled)
+	}
+}
+
+func TestStatusRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sync", "web.json")
+	if status, err := LoadStatus(path); status != nil || err != nil {
+		t.Fatalf("LoadStatus() of missing file = %v, %v", status, err)
+	}
+	want := &Status{PID: os.Getpid(), Box: "devbox_web", Files: 3, LastError: "box 'devbox_web' is exited"}
+	if err := SaveStatus(path, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadStatus(path)
+	if err != nil || got.Box != want.Box || got.Files != 3 || got.LastError != want.LastError {
+		t.Fatalf("LoadStatus() = %+v, %v", got, err)
+	}
+	if !got.Running() {
+		t.Errorf("Running() = false for the test process")
+	}
+	if (&Status{}).Running() || (*Status)(nil).Running() {
+		t.Errorf("Running() = true without a pid")
+	}
+
+	indexPath := filepath.Join(dir, "sync", "web.index.json")
+	index := Index{"main.go": {Size: 12, ModTime: 5, Mode: 0644}}
+	if err := SaveIndex(indexPath, index); err != nil {
+		t.Fatal(err)
+	}
+	if got := LoadIndex(indexPath); !reflect.DeepEqual(got, index) {
+		t.Errorf("LoadIndex() = %v, want %v", got, index)
+	}
+}
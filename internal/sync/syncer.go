@@ -0,0 +1,96 @@
+package sync
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+)
+
+type Transport interface {
+	CopyFilesToBox(boxName, root, dest string, files []string) error
+	RemoveFilesInBox(boxName, dest string, files []string) error
+	ReadFilesFromBox(boxName, dest string, files []string) (map[string][]byte, error)
+}
+
+type Syncer struct {
+	Box       string
+	Root      string
+	Dest      string
+	Ignore    []string
+	Pull      []string
+	Transport Transport
+	Index     Index
+}
+
+type Result struct {
+	Files   int
+	Pushed  int
+	Removed int
+	Pulled  int
+	Pending int
+}
+
+func (s *Syncer) Pass() (Result, error) {
+	cur, err := Scan(s.Root, append(append([]string{}, s.Ignore...), s.Pull...))
+	if err != nil {
+		return Result{}, err
+	}
+	changed, removed := Diff(s.Index, cur)
+	removed = withoutPulled(removed, s.Pull)
+	res := Result{Files: len(cur), Pending: len(changed) + len(removed)}
+	if res.Pending > 0 {
+		if err := s.Transport.RemoveFilesInBox(s.Box, s.Dest, removed); err != nil {
+			return res, err
+		}
+		if err := s.Transport.CopyFilesToBox(s.Box, s.Root, s.Dest, changed); err != nil {
+			return res, err
+		}
+		res.Pushed, res.Removed, res.Pending = len(changed), len(removed), 0
+	}
+	s.Index = cur
+	pulled, err := s.pull()
+	res.Pulled = pulled
+	return res, err
+}
+
+func (s *Syncer) pull() (int, error) {
+	if len(s.Pull) == 0 {
+		return 0, nil
+	}
+	files, err := s.Transport.ReadFilesFromBox(s.Box, s.Dest, s.Pull)
+	if err != nil {
+		return 0, err
+	}
+	pulled := 0
+	for _, rel := range s.Pull {
+		data, ok := files[rel]
+		if !ok {
+			continue
+		}
+		path := filepath.Join(s.Root, filepath.FromSlash(rel))
+		if existing, err := os.ReadFile(path); err == nil && bytes.Equal(existing, data) {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return pulled, err
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return pulled, err
+		}
+		pulled++
+	}
+	return pulled, nil
+}
+
+func withoutPulled(removed, pull []string) []string {
+	if len(pull) == 0 {
+		return removed
+	}
+	kept := removed[:0]
+	for _, rel := range removed {
+		if !Ignored(rel, pull) {
+			kept = append(kept, rel)
+		}
+	}
+	return kept
+}
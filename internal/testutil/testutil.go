@@ -147,6 +147,7 @@ func CreateConfigManager(t *testing.T) (*config.ConfigManager, string) {
 	t.Helper()
 
 	tempDir := CreateTempDir(t)
+	t.Setenv("HOME", tempDir)
 
 	cm, err := config.NewConfigManager()
 	if err != nil {
@@ -0,0 +1,64 @@
+package integration
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"devbox/internal/parallel"
+)
+
+func benchBox(b *testing.B) string {
+	box := os.Getenv("DEVBOX_BENCH_BOX")
+	if box == "" {
+		b.Skip("set DEVBOX_BENCH_BOX to a running box to benchmark against the local daemon")
+	}
+	return box
+}
+
+func BenchmarkDaemonPackageQuery(b *testing.B) {
+	box := benchBox(b)
+	for _, workers := range []int{1, parallel.DefaultConfig().PackageQueryWorkers} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			executor := parallel.NewPackageQueryExecutor(box, workers)
+			for i := 0; i < b.N; i++ {
+				if _, err := executor.QueryAllPackages(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkDaemonSetupCommands(b *testing.B) {
+	box := benchBox(b)
+	commands := []string{
+		"pip --version >/dev/null 2>&1 || true",
+		"npm --version >/dev/null 2>&1 || true",
+		"yarn --version >/dev/null 2>&1 || true",
+		"pnpm --version >/dev/null 2>&1 || true",
+		"true",
+	}
+	executor := parallel.NewSetupCommandExecutor(box, false, parallel.DefaultConfig().SetupCommandWorkers)
+	for i := 0; i < b.N; i++ {
+		if err := executor.ExecuteParallel(commands); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDaemonLock(b *testing.B) {
+	project := os.Getenv("DEVBOX_BENCH_PROJECT")
+	if project == "" {
+		b.Skip("set DEVBOX_BENCH_PROJECT to a project with a running box to benchmark lock generation")
+	}
+	out := filepath.Join(b.TempDir(), "devbox.lock.json")
+	for i := 0; i < b.N; i++ {
+		cmd := exec.Command(getTestBinaryPath(), "lock", project, "--output", out, "--no-cache")
+		if output, err := cmd.CombinedOutput(); err != nil {
+			b.Fatalf("devbox lock failed: %v\n%s", err, output)
+		}
+	}
+}